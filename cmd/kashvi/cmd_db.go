@@ -25,7 +25,7 @@ var migrateCmd = &cobra.Command{
 	Short: "Run all pending database migrations",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if !isFrameworkSelf() {
-			return runInProject("migrate")
+			return runInProject("migrate", migrateArgs()...)
 		}
 		fmt.Println("kashvi migrate can only be run inside a Kashvi project directory.")
 		os.Exit(1)
@@ -33,13 +33,83 @@ var migrateCmd = &cobra.Command{
 	},
 }
 
+var (
+	migratePretend, migrateAllowUnsafe bool
+	migrateDatabase                    string
+)
+
+func init() {
+	addMigrateFlags(migrateCmd)
+	addDatabaseFlag(migrateRollbackCmd)
+	addForceFlags(migrateRollbackCmd)
+	addDatabaseFlag(migrateStatusCmd)
+	addForceFlags(seedCmd)
+}
+
+func addMigrateFlags(c *cobra.Command) {
+	addDatabaseFlag(c)
+	c.Flags().BoolVar(&migratePretend, "pretend", false, "print the SQL pending migrations would run, without running it")
+	c.Flags().BoolVar(&migrateAllowUnsafe, "allow-unsafe", false, "run migrations the MIGRATION_GUARD analyzer flags as unsafe")
+}
+
+func addDatabaseFlag(c *cobra.Command) {
+	c.Flags().StringVar(&migrateDatabase, "database", "", "named connection to migrate (DB_<NAME>_DSN); default primary")
+}
+
+// databaseArgs returns the --database flag forwarded to the project.
+func databaseArgs() []string {
+	if migrateDatabase == "" {
+		return nil
+	}
+	return []string{"--database", migrateDatabase}
+}
+
+var (
+	forceRun  bool
+	runReason string
+)
+
+// addForceFlags adds the flags the project binary needs to run a destructive
+// command in production without prompting.
+func addForceFlags(c *cobra.Command) {
+	c.Flags().BoolVar(&forceRun, "force", false, "run without the production confirmation prompt")
+	c.Flags().StringVar(&runReason, "reason", "", "why the command is being run (recorded in the audit log)")
+	// --confirm is the flag's name before --force.
+	c.Flags().BoolVar(&forceRun, "confirm", false, "")
+	c.Flags().MarkDeprecated("confirm", "use --force instead") //nolint:errcheck
+}
+
+// forceArgs returns the --force and --reason flags forwarded to the project.
+func forceArgs() []string {
+	var args []string
+	if forceRun {
+		args = append(args, "--force")
+	}
+	if runReason != "" {
+		args = append(args, "--reason", runReason)
+	}
+	return args
+}
+
+// migrateArgs returns the flags forwarded to the project's migrate command.
+func migrateArgs() []string {
+	args := databaseArgs()
+	if migratePretend {
+		args = append(args, "--pretend")
+	}
+	if migrateAllowUnsafe {
+		args = append(args, "--allow-unsafe")
+	}
+	return args
+}
+
 // kashvi migrate:rollback
 var migrateRollbackCmd = &cobra.Command{
 	Use:   "migrate:rollback",
 	Short: "Rollback the last batch of migrations",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if !isFrameworkSelf() {
-			return runInProject("migrate:rollback")
+			return runInProject("migrate:rollback", append(databaseArgs(), forceArgs()...)...)
 		}
 		fmt.Println("kashvi migrate:rollback can only be run inside a Kashvi project directory.")
 		os.Exit(1)
@@ -53,7 +123,7 @@ var migrateStatusCmd = &cobra.Command{
 	Short: "Show the status of each migration",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if !isFrameworkSelf() {
-			return runInProject("migrate:status")
+			return runInProject("migrate:status", databaseArgs()...)
 		}
 		fmt.Println("kashvi migrate:status can only be run inside a Kashvi project directory.")
 		os.Exit(1)
@@ -68,10 +138,33 @@ var seedCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Always delegate to project
 		if !isFrameworkSelf() {
-			return runInProject("seed")
+			return runInProject("seed", forceArgs()...)
 		}
 		fmt.Println("kashvi seed can only be run inside a Kashvi project directory.")
 		os.Exit(1)
 		return nil
 	},
 }
+
+// kashvi migrate:fresh / db:wipe — destructive; in production the project
+// binary prompts for the app name unless --force and --reason are passed
+// through.
+var migrateFreshCmd = destructiveDBCmd("migrate:fresh", "Drop all tables and re-run every migration")
+var dbWipeCmd = destructiveDBCmd("db:wipe", "Drop all tables")
+
+func destructiveDBCmd(name, short string) *cobra.Command {
+	c := &cobra.Command{
+		Use:   name,
+		Short: short,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if isFrameworkSelf() {
+				fmt.Printf("kashvi %s can only be run inside a Kashvi project directory.\n", name)
+				os.Exit(1)
+			}
+			return runInProject(name, append(databaseArgs(), forceArgs()...)...)
+		},
+	}
+	addDatabaseFlag(c)
+	addForceFlags(c)
+	return c
+}
@@ -8,20 +8,26 @@ package main
 // migrations, seeders and routes registered.
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
 
-// runInProject runs `go run <dir> <subcommand>` in the current working directory.
+// runInProject runs `go run <dir> <subcommand> [extra...]` in the current working directory.
 // It is used when the kashvi CLI is acting as an external driver for a
 // user project rather than the framework's own internal server.
-func runInProject(subcommand string) error {
+func runInProject(subcommand string, extra ...string) error {
 	cwd, _ := os.Getwd()
-	dir := findEntrypoint(cwd)
-	args := []string{"run", dir, subcommand}
+	dir := entrypoint(cwd)
+	args := append([]string{"run", dir, subcommand}, extra...)
 
 	c := exec.Command("go", args...)
 	c.Dir = cwd
@@ -29,21 +35,140 @@ func runInProject(subcommand string) error {
 	c.Stdout = os.Stdout
 	c.Stderr = os.Stderr
 	c.Env = os.Environ()
-	return c.Run()
+	prepareChild(c)
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	// Relay SIGINT/SIGTERM to the project process instead of dying first, so
+	// long-running commands (serve, queue:work, schedule:work) drain cleanly
+	// when a supervisor or Kubernetes stops the kashvi process.
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	done := make(chan error, 1)
+	go func() { done <- c.Wait() }()
+	for {
+		select {
+		case sig := <-sigs:
+			signalChild(c, sig)
+		case err := <-done:
+			return err
+		}
+	}
+}
+
+// localAnnotation marks commands the global CLI always runs itself
+// (generators, replay, log:*), even in project mode.
+const localAnnotation = "kashvi:local"
+
+// runsLocally marks cmds with localAnnotation.
+func runsLocally(cmds ...*cobra.Command) {
+	for _, c := range cmds {
+		if c.Annotations == nil {
+			c.Annotations = map[string]string{}
+		}
+		c.Annotations[localAnnotation] = "true"
+	}
+}
+
+// passthrough reports whether `kashvi <args>` is handed to the project
+// verbatim instead of being parsed here. In project mode that is every
+// command except the local ones — including commands this CLI does not know,
+// such as the project's own registrations — so flags like `migrate --step=2`
+// reach app.Run untouched. --help on a known command stays local.
+func passthrough(args []string) bool {
+	if isFrameworkSelf() || len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return false
+	}
+	switch args[0] {
+	case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return false
+	}
+	c, _, err := rootCmd.Find(args[:1])
+	if err != nil {
+		return true // unknown here; the project may define it
+	}
+	if c.Annotations[localAnnotation] != "" {
+		return false
+	}
+	for _, a := range args[1:] {
+		if a == "--" {
+			break
+		}
+		if a == "-h" || a == "--help" {
+			return false
+		}
+	}
+	return true
+}
+
+// runPassthrough runs `go run . <args>` and exits with the project's exit
+// code; the project has already printed its own error.
+func runPassthrough(args []string) {
+	err := runInProject(args[0], args[1:]...)
+	var exit *exec.ExitError
+	switch {
+	case errors.As(err, &exit):
+		os.Exit(exit.ExitCode())
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// projectCommand is one entry of `go run . command:list --json`.
+type projectCommand struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// projectCommands asks the project for the commands it registers with
+// app.Command. It returns nil when the project does not build or predates
+// command:list, so help still works.
+func projectCommands() []projectCommand {
+	cwd, _ := os.Getwd()
+	c := exec.Command("go", "run", entrypoint(cwd), "command:list", "--json")
+	c.Dir = cwd
+	out, err := c.Output()
+	if err != nil {
+		return nil
+	}
+	var cmds []projectCommand
+	if json.Unmarshal(out, &cmds) != nil {
+		return nil
+	}
+	return cmds
+}
+
+// projectHelp wraps the root help so that, in project mode, `kashvi --help`
+// ends with the project's own commands.
+func projectHelp(help func(*cobra.Command, []string)) func(*cobra.Command, []string) {
+	return func(c *cobra.Command, args []string) {
+		help(c, args)
+		if c != rootCmd {
+			return
+		}
+		cmds := projectCommands()
+		if len(cmds) == 0 {
+			return
+		}
+		fmt.Println("\nProject Commands (run through go run .):")
+		for _, pc := range cmds {
+			fmt.Printf("  %-20s %s\n", pc.Name, pc.Description)
+		}
+	}
 }
 
 // findEntrypoint returns the Go package path to pass to `go run`.
 // It checks whether the cwd itself has Go files; if not it probes
-// common subdirectory conventions used by Go projects.
+// common subdirectory conventions used by Go projects. The result is a
+// package path ("./cmd/server"), which `go run` accepts on every OS.
 func findEntrypoint(cwd string) string {
 	// If there are Go files in the cwd, use "." (standard layout)
-	entries, err := os.ReadDir(cwd)
-	if err == nil {
-		for _, e := range entries {
-			if !e.IsDir() && len(e.Name()) > 3 && e.Name()[len(e.Name())-3:] == ".go" {
-				return "."
-			}
-		}
+	if hasGoFiles(cwd) {
+		return "."
 	}
 
 	// Probe common entrypoint subdirectories in priority order
@@ -55,14 +180,8 @@ func findEntrypoint(cwd string) string {
 		"cmd",
 	}
 	for _, sub := range candidates {
-		subEntries, err := os.ReadDir(cwd + "/" + sub)
-		if err != nil {
-			continue
-		}
-		for _, e := range subEntries {
-			if !e.IsDir() && len(e.Name()) > 3 && e.Name()[len(e.Name())-3:] == ".go" {
-				return "./" + sub
-			}
+		if hasGoFiles(filepath.Join(cwd, filepath.FromSlash(sub))) {
+			return "./" + sub
 		}
 	}
 
@@ -70,6 +189,29 @@ func findEntrypoint(cwd string) string {
 	return "."
 }
 
+// hasGoFiles reports whether dir directly contains a .go file.
+func hasGoFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == ".go" {
+			return true
+		}
+	}
+	return false
+}
+
+// binaryName returns the file name `go build` should write for name on goos:
+// Windows only runs executables with an .exe extension.
+func binaryName(goos, name string) string {
+	if goos == "windows" && filepath.Ext(name) != ".exe" {
+		return name + ".exe"
+	}
+	return name
+}
+
 // isProjectMode returns true when the CLI is being used outside the kashvi
 // framework source tree. We detect this by looking for go.mod in the cwd.
 // When running inside the kashvi repo itself, direct package imports are used.
@@ -98,27 +240,33 @@ func addProjectDelegateCmds(root *cobra.Command) {
 		})
 	}
 
-	root.AddCommand(&cobra.Command{
+	migrate := &cobra.Command{
 		Use:   "migrate",
 		Short: "Run pending migrations (delegates to your project)",
 		RunE: func(c *cobra.Command, args []string) error {
-			return runInProject("migrate")
+			return runInProject("migrate", migrateArgs()...)
 		},
-	})
-	root.AddCommand(&cobra.Command{
+	}
+	addMigrateFlags(migrate)
+	root.AddCommand(migrate)
+	rollback := &cobra.Command{
 		Use:   "migrate:rollback",
 		Short: "Rollback last batch of migrations",
 		RunE: func(c *cobra.Command, args []string) error {
-			return runInProject("migrate:rollback")
+			return runInProject("migrate:rollback", databaseArgs()...)
 		},
-	})
-	root.AddCommand(&cobra.Command{
+	}
+	addDatabaseFlag(rollback)
+	root.AddCommand(rollback)
+	status := &cobra.Command{
 		Use:   "migrate:status",
 		Short: "Show migration status",
 		RunE: func(c *cobra.Command, args []string) error {
-			return runInProject("migrate:status")
+			return runInProject("migrate:status", databaseArgs()...)
 		},
-	})
+	}
+	addDatabaseFlag(status)
+	root.AddCommand(status)
 	root.AddCommand(&cobra.Command{
 		Use:   "seed",
 		Short: "Seed the database (delegates to your project)",
@@ -136,7 +284,7 @@ func addProjectDelegateCmds(root *cobra.Command) {
 }
 
 func printQuickStart() {
-	fmt.Println(`
+	fmt.Print(`
   kashvi – Go Web Framework  ⚡
 
   Install globally:
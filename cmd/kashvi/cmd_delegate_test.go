@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPassthrough(t *testing.T) {
+	t.Chdir(t.TempDir()) // a project, not the framework repo
+	registerCommands()
+	cases := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"migrate", "--step=2"}, true},
+		{[]string{"orders:sync", "--since", "1d"}, true}, // the project's own command
+		{[]string{"migrate", "--", "--help"}, true},
+		{[]string{"migrate", "--help"}, false},
+		{[]string{"make:model", "Invoice"}, false},
+		{[]string{"log:tail", "--level=error"}, false},
+		{[]string{"help", "migrate"}, false},
+		{[]string{"--version"}, false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if got := passthrough(c.args); got != c.want {
+			t.Errorf("passthrough(%q) = %v, want %v", c.args, got, c.want)
+		}
+	}
+
+	touch(t, filepath.Join("pkg", "app", "app.go"))
+	if passthrough([]string{"migrate", "--step=2"}) {
+		t.Error("the framework repo must not delegate to itself")
+	}
+}
+
+func TestRunInProjectForwardsArgsVerbatim(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles a project with go run")
+	}
+	t.Chdir(t.TempDir())
+	write := func(name, content string) {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/echo\n\ngo 1.21\n")
+	write("main.go", `package main
+
+import (
+	"os"
+	"strings"
+)
+
+func main() {
+	os.WriteFile("args.txt", []byte(strings.Join(os.Args[1:], "\n")), 0o644)
+}
+`)
+
+	args := []string{"orders:sync", "--since=1d", "-v", "two words", "--", "--help"}
+	if err := runInProject(args[0], args[1:]...); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("args.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Join(args, "\n"); string(got) != want {
+		t.Fatalf("project got args %q, want %q", got, want)
+	}
+}
+
+func TestProjectCommandsReadsCommandList(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles a project with go run")
+	}
+	t.Chdir(t.TempDir())
+	write := func(name, content string) {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/cmds\n\ngo 1.21\n")
+	write("main.go", `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) == 3 && os.Args[1] == "command:list" && os.Args[2] == "--json" {
+		fmt.Println(`+"`"+`[{"name":"orders:sync","description":"Sync orders"}]`+"`"+`)
+	}
+}
+`)
+
+	got := projectCommands()
+	if len(got) != 1 || got[0] != (projectCommand{Name: "orders:sync", Description: "Sync orders"}) {
+		t.Fatalf("projectCommands() = %+v", got)
+	}
+
+	write("main.go", "package main\n\nfunc main() { undefined() }\n")
+	if got := projectCommands(); got != nil {
+		t.Fatalf("projectCommands() on a broken project = %+v, want nil", got)
+	}
+}
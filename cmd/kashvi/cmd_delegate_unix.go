@@ -0,0 +1,35 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// prepareChild puts a non-interactive child in its own process group, since
+// `go run` does not pass signals on to the program it builds. Interactive
+// children stay in the terminal's foreground group so they can read stdin
+// and get Ctrl+C from the terminal directly.
+func prepareChild(c *exec.Cmd) {
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		return
+	}
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalChild relays sig to the child's process group.
+func signalChild(c *exec.Cmd, sig os.Signal) {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return
+	}
+	if c.SysProcAttr == nil || !c.SysProcAttr.Setpgid {
+		if s != syscall.SIGINT { // the terminal sends SIGINT to the whole group
+			_ = c.Process.Signal(s)
+		}
+		return
+	}
+	_ = syscall.Kill(-c.Process.Pid, s)
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+func prepareChild(*exec.Cmd) {}
+
+// signalChild stops the child. Windows cannot deliver SIGINT/SIGTERM to
+// another process, so there is no graceful drain here.
+func signalChild(c *exec.Cmd, _ os.Signal) {
+	_ = c.Process.Kill()
+}
@@ -0,0 +1,143 @@
+package main
+
+// cmd_env.go — `kashvi env:encrypt` / `env:decrypt`: turn .env values into
+// ENC(...) values that config decrypts at load time with APP_KEY, and back.
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shashiranjanraj/kashvi/config"
+)
+
+var (
+	envFile string
+	envAll  bool
+)
+
+// kashvi env:encrypt
+var envEncryptCmd = &cobra.Command{
+	Use:   "env:encrypt [KEY...]",
+	Short: "Encrypt .env values with APP_KEY",
+	Long: `Replace the named values in .env with ENC(...) values encrypted with
+APP_KEY. The app decrypts them when it loads its config, so the file can ship
+with a deployment while APP_KEY stays in the environment or a secret store.
+
+  APP_KEY=… kashvi env:encrypt DB_PASSWORD STRIPE_SECRET
+  APP_KEY=… kashvi env:encrypt --all --file=.env.production
+
+APP_KEY is read from the environment, or else from the config files. It is
+never encrypted itself.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, k := range args {
+			if strings.EqualFold(k, "APP_KEY") {
+				return fmt.Errorf("APP_KEY cannot be encrypted; keep it out of the file instead")
+			}
+		}
+		n, err := rewriteEnv(envFile, args, envAll, func(key, value string) (string, bool, error) {
+			if config.IsEncrypted(value) || key == "APP_KEY" {
+				return value, false, nil
+			}
+			enc, err := config.EncryptValue(value)
+			return enc, true, err
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🔒 Encrypted %d value(s) in %s.\n", n, envFile)
+		return nil
+	},
+}
+
+// kashvi env:decrypt
+var envDecryptCmd = &cobra.Command{
+	Use:   "env:decrypt [KEY...]",
+	Short: "Decrypt ENC(...) values in .env back to plaintext",
+	Long: `Replace ENC(...) values in .env with their plaintext, to edit them.
+Without KEY arguments every encrypted value is decrypted.
+
+  APP_KEY=… kashvi env:decrypt DB_PASSWORD`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := rewriteEnv(envFile, args, len(args) == 0, func(key, value string) (string, bool, error) {
+			if !config.IsEncrypted(value) {
+				return value, false, nil
+			}
+			plain, err := config.DecryptValue(value)
+			if err != nil {
+				return "", false, fmt.Errorf("%s: %w", key, err)
+			}
+			return plain, true, nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🔓 Decrypted %d value(s) in %s.\n", n, envFile)
+		return nil
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{envEncryptCmd, envDecryptCmd} {
+		c.Flags().StringVar(&envFile, "file", ".env", "The env file to rewrite, e.g. .env.production")
+	}
+	envEncryptCmd.Flags().BoolVar(&envAll, "all", false, "Encrypt every value except APP_KEY")
+}
+
+// rewriteEnv passes the values of keys (or of every key, with all) in the
+// env file at path through fn and writes back the ones it changed, leaving
+// comments, order and other lines as they were. It returns how many values
+// changed and fails if a named key is not in the file.
+func rewriteEnv(path string, keys []string, all bool, fn func(key, value string) (string, bool, error)) (int, error) {
+	if len(keys) == 0 && !all {
+		return 0, fmt.Errorf("name the keys to change, or pass --all")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	want := map[string]bool{}
+	for _, k := range keys {
+		want[strings.ToUpper(k)] = true
+	}
+	seen := map[string]bool{}
+	changed := 0
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		idx := strings.IndexByte(trimmed, '=')
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || idx <= 0 {
+			continue
+		}
+		key := strings.ToUpper(strings.TrimSpace(trimmed[:idx]))
+		if !all && !want[key] {
+			continue
+		}
+		seen[key] = true
+		value := strings.Trim(strings.TrimSpace(trimmed[idx+1:]), `"'`)
+		out, ok, err := fn(key, value)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			lines[i] = strings.TrimSpace(trimmed[:idx]) + "=" + out
+			changed++
+		}
+	}
+	for _, k := range keys {
+		if !seen[strings.ToUpper(k)] {
+			return 0, fmt.Errorf("%s is not set in %s", k, path)
+		}
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	return changed, os.WriteFile(path, []byte(strings.Join(lines, "\n")), info.Mode().Perm())
+}
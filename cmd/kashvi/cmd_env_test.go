@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/config"
+)
+
+func TestRewriteEnvRoundTrip(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("APP_KEY", "test-app-key")
+	original := "# database\nDB_PASSWORD=\"s3cret\"\nAPP_KEY=test-app-key\nAPP_ENV=production\n"
+	if err := os.WriteFile(".env", []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	encrypt := func(key, value string) (string, bool, error) {
+		if config.IsEncrypted(value) || key == "APP_KEY" {
+			return value, false, nil
+		}
+		enc, err := config.EncryptValue(value)
+		return enc, true, err
+	}
+	if n, err := rewriteEnv(".env", nil, true, encrypt); err != nil || n != 2 {
+		t.Fatalf("encrypt --all: %d, %v; want 2 values", n, err)
+	}
+	data, _ := os.ReadFile(".env")
+	if strings.Contains(string(data), "s3cret") || !strings.Contains(string(data), "APP_KEY=test-app-key") ||
+		!strings.HasPrefix(string(data), "# database\nDB_PASSWORD=ENC(") {
+		t.Fatalf("unexpected file after encrypting:\n%s", data)
+	}
+
+	decrypt := func(key, value string) (string, bool, error) {
+		plain, err := config.DecryptValue(value)
+		return plain, config.IsEncrypted(value), err
+	}
+	if n, err := rewriteEnv(".env", []string{"db_password"}, false, decrypt); err != nil || n != 1 {
+		t.Fatalf("decrypt DB_PASSWORD: %d, %v", n, err)
+	}
+	data, _ = os.ReadFile(".env")
+	if !strings.Contains(string(data), "DB_PASSWORD=s3cret\n") {
+		t.Errorf("DB_PASSWORD not decrypted:\n%s", data)
+	}
+
+	if _, err := rewriteEnv(".env", []string{"MISSING"}, false, decrypt); err == nil {
+		t.Error("unknown key: want an error")
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+// cmd_logs.go — `kashvi log:tail`: read back the structured logs that
+// logger.MongoHandler ships to MongoDB.
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+var (
+	logTailLevel     string
+	logTailRequestID string
+	logTailSince     time.Duration
+	logTailGrep      string
+	logTailLimit     int
+	logTailFollow    bool
+)
+
+// kashvi log:tail
+var logTailCmd = &cobra.Command{
+	Use:   "log:tail",
+	Short: "Show and follow structured logs stored in MongoDB",
+	Long: `Query the MongoDB log collection (MONGO_URI, MONGO_LOG_DB, MONGO_LOG_COLLECTION)
+and stream new records as they arrive.
+
+  kashvi log:tail --level=error --since=1h
+  kashvi log:tail --level=fatal
+  kashvi log:tail --request-id=a1b2c3d4 --follow=false`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.Load(); err != nil {
+			return err
+		}
+		uri := config.MongoURI()
+		if uri == "" {
+			return fmt.Errorf("MONGO_URI is not set — MongoDB log shipping is disabled")
+		}
+		if logTailLevel != "" {
+			if _, err := logger.ParseLevel(logTailLevel); err != nil {
+				return err
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		reader, err := logger.NewLogReader(ctx, uri, config.MongoLogDB(), config.MongoLogCollection())
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		filter := logger.LogFilter{
+			MinLevel:  logTailLevel,
+			RequestID: logTailRequestID,
+			Contains:  logTailGrep,
+			Limit:     logTailLimit,
+		}
+		if logTailSince > 0 {
+			filter.Since = time.Now().Add(-logTailSince)
+		}
+
+		docs, err := reader.Query(ctx, filter)
+		if err != nil {
+			return err
+		}
+		color := isTerminal(os.Stdout)
+		for _, d := range docs {
+			fmt.Println(formatLog(d, color))
+		}
+
+		if !logTailFollow {
+			return nil
+		}
+		return reader.Follow(ctx, filter, docs, time.Second, func(d logger.LogDocument) {
+			fmt.Println(formatLog(d, color))
+		})
+	},
+}
+
+var (
+	logPruneOlderThan time.Duration
+	logPruneDryRun    bool
+)
+
+// kashvi log:prune
+var logPruneCmd = &cobra.Command{
+	Use:   "log:prune",
+	Short: "Delete old records from the MongoDB log collection",
+	Long: `Delete log records older than --older-than (defaults to LOG_RETENTION_DAYS).
+
+  kashvi log:prune --older-than=720h --dry-run`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.Load(); err != nil {
+			return err
+		}
+		uri := config.MongoURI()
+		if uri == "" {
+			return fmt.Errorf("MONGO_URI is not set — MongoDB log shipping is disabled")
+		}
+
+		age := logPruneOlderThan
+		if age <= 0 {
+			days := config.LogRetentionDays()
+			if days <= 0 {
+				return fmt.Errorf("pass --older-than or set LOG_RETENTION_DAYS")
+			}
+			age = time.Duration(days) * 24 * time.Hour
+		}
+
+		ctx := context.Background()
+		reader, err := logger.NewLogReader(ctx, uri, config.MongoLogDB(), config.MongoLogCollection())
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		cutoff := time.Now().Add(-age)
+		n, err := reader.Prune(ctx, cutoff, logPruneDryRun)
+		if err != nil {
+			return err
+		}
+		if logPruneDryRun {
+			fmt.Printf("%d record(s) older than %s would be deleted.\n", n, cutoff.Format(time.RFC3339))
+		} else {
+			fmt.Printf("🧹 Deleted %d record(s) older than %s.\n", n, cutoff.Format(time.RFC3339))
+		}
+		return nil
+	},
+}
+
+func init() {
+	logPruneCmd.Flags().DurationVar(&logPruneOlderThan, "older-than", 0, "Delete records older than this (default: LOG_RETENTION_DAYS)")
+	logPruneCmd.Flags().BoolVar(&logPruneDryRun, "dry-run", false, "Only count matching records")
+
+	logTailCmd.Flags().StringVar(&logTailLevel, "level", "", "Minimum level: debug, info, warn, error, fatal or e.g. ERROR+2")
+	logTailCmd.Flags().StringVar(&logTailRequestID, "request-id", "", "Only records for this request ID")
+	logTailCmd.Flags().DurationVar(&logTailSince, "since", 10*time.Minute, "Show records newer than this (0 = no limit)")
+	logTailCmd.Flags().StringVar(&logTailGrep, "grep", "", "Only records whose message contains this text")
+	logTailCmd.Flags().IntVarP(&logTailLimit, "lines", "n", 100, "Number of past records to show")
+	logTailCmd.Flags().BoolVarP(&logTailFollow, "follow", "f", true, "Keep streaming new records")
+}
+
+// formatLog renders d as a single human-readable line:
+//
+//	15:04:05.000 ERROR [a1b2c3d4] payment failed amount=99.9 error="card declined"
+func formatLog(d logger.LogDocument, color bool) string {
+	var b strings.Builder
+	b.WriteString(d.Time.Local().Format("2006-01-02 15:04:05.000"))
+	b.WriteByte(' ')
+
+	level := fmt.Sprintf("%-5s", d.Level)
+	if color {
+		level = levelColor(d.Level) + level + "\033[0m"
+	}
+	b.WriteString(level)
+
+	if d.RequestID != "" {
+		b.WriteString(" [" + d.RequestID + "]")
+	}
+	b.WriteString(" " + d.Msg)
+
+	keys := make([]string, 0, len(d.Attrs))
+	for k := range d.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := fmt.Sprint(d.Attrs[k])
+		if strings.ContainsAny(v, " \t\"=") {
+			v = fmt.Sprintf("%q", v)
+		}
+		if color {
+			b.WriteString(" \033[2m" + k + "=\033[0m" + v)
+		} else {
+			b.WriteString(" " + k + "=" + v)
+		}
+	}
+	return b.String()
+}
+
+func levelColor(level string) string {
+	switch {
+	case strings.HasPrefix(level, "ERROR"):
+		return "\033[31m"
+	case strings.HasPrefix(level, "WARN"):
+		return "\033[33m"
+	case strings.HasPrefix(level, "INFO"):
+		return "\033[36m"
+	}
+	return "\033[2m"
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"go/format"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"gorm.io/gorm/schema"
 )
 
 // ─── Scaffold commands ────────────────────────────────────────────────────────
@@ -14,17 +19,81 @@ import (
 var makeModelCmd = &cobra.Command{
 	Use:   "make:model [Name]",
 	Short: "Scaffold a new model",
-	Args:  cobra.ExactArgs(1),
+	Long: `Scaffold a model in app/models.
+
+With --fields (or --interactive), also generate the create-table migration,
+a resource transformer in app/resources and create/update request structs
+with validate tags in app/requests:
+
+  kashvi make:model User --fields="name:string:index,email:string:unique,age:int"
+
+A field is name:type[:modifier...]. Types: string, text, uuid, int, int64,
+uint, float, bool, time. Modifiers: index, unique, nullable.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
-		content, err := renderStub("model", StubData{Name: name, Lower: strings.ToLower(name)})
+		spec, _ := cmd.Flags().GetString("fields")
+		interactive, _ := cmd.Flags().GetBool("interactive")
+
+		fields, err := parseFields(spec)
 		if err != nil {
 			return err
 		}
-		return writeStub(fmt.Sprintf("app/models/%s.go", strings.ToLower(name)), content)
+		if interactive {
+			if fields, err = promptFields(os.Stdin, os.Stdout); err != nil {
+				return err
+			}
+		}
+		if len(fields) == 0 {
+			content, err := renderStub("model", StubData{Name: name, Lower: strings.ToLower(name)})
+			if err != nil {
+				return err
+			}
+			return writeStub(fmt.Sprintf("app/models/%s.go", strings.ToLower(name)), content)
+		}
+		return makeModelWithFields(name, fields)
 	},
 }
 
+// makeModelWithFields writes the model, its migration, resource and request
+// structs from one field list.
+func makeModelWithFields(name string, fields []Field) error {
+	lower := strings.ToLower(name)
+	table := schema.NamingStrategy{}.TableName(name)
+	migName := fmt.Sprintf("%s_create_%s_table", time.Now().Format("20060102150405"), table)
+	data := StubData{
+		Name:       name,
+		Lower:      lower,
+		StructName: "M_" + migName,
+		Table:      table,
+		Module:     modulePath(),
+		Fields:     fields,
+		UsesTime:   usesTime(fields),
+	}
+
+	type spec struct{ stub, path string }
+	files := []spec{
+		{"model", fmt.Sprintf("app/models/%s.go", lower)},
+		{"migration_create", fmt.Sprintf("database/migrations/%s.go", migName)},
+		{"resource", fmt.Sprintf("app/resources/%s.go", lower)},
+		{"request", fmt.Sprintf("app/requests/%s.go", lower)},
+	}
+	for _, f := range files {
+		d := data
+		if f.stub == "migration_create" {
+			d.Name = migName
+		}
+		content, err := renderStub(f.stub, d)
+		if err != nil {
+			return err
+		}
+		if err := writeStub(f.path, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 var makeControllerCmd = &cobra.Command{
 	Use:   "make:controller [Name]",
 	Short: "Scaffold a new controller",
@@ -84,6 +153,131 @@ var makeSeederCmd = &cobra.Command{
 	},
 }
 
+var makeJobCmd = &cobra.Command{
+	Use:   "make:job [Name]",
+	Short: "Scaffold a new queue job",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		content, err := renderStub("job", StubData{Name: name, Lower: strings.ToLower(name)})
+		if err != nil {
+			return err
+		}
+		if err := writeStub(fmt.Sprintf("app/jobs/%s.go", strings.ToLower(name)), content); err != nil {
+			return err
+		}
+		fmt.Printf("\n📋  Import the package once so the job registers itself:\n\n")
+		fmt.Printf("    import _ \"<module>/app/jobs\"\n\n")
+		return nil
+	},
+}
+
+var makeMiddlewareCmd = &cobra.Command{
+	Use:   "make:middleware [Name]",
+	Short: "Scaffold HTTP middleware",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		content, err := renderStub("middleware", StubData{Name: name, Lower: strings.ToLower(name)})
+		if err != nil {
+			return err
+		}
+		return writeStub(fmt.Sprintf("app/middlewares/%s.go", strings.ToLower(name)), content)
+	},
+}
+
+var makeRequestCmd = &cobra.Command{
+	Use:   "make:request [Name]",
+	Short: "Scaffold a validated request struct",
+	Long: `Scaffold a request body struct in app/requests, with validate tags and a
+Validate hook for checks the tags cannot express. --fields takes the same
+list as make:model:
+
+  kashvi make:request RegisterRequest --fields="name:string,email:string,age:int"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		spec, _ := cmd.Flags().GetString("fields")
+		fields, err := parseFields(spec)
+		if err != nil {
+			return err
+		}
+		content, err := renderStub("form_request", StubData{
+			Name:     name,
+			Lower:    strings.ToLower(name),
+			Fields:   fields,
+			UsesTime: usesTime(fields),
+		})
+		if err != nil {
+			return err
+		}
+		return writeStub(fmt.Sprintf("app/requests/%s.go", strings.ToLower(name)), content)
+	},
+}
+
+var makeNotificationCmd = &cobra.Command{
+	Use:   "make:notification [Name]",
+	Short: "Scaffold a notification with mail and database channels",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		content, err := renderStub("notification", StubData{Name: name, Lower: strings.ToLower(name)})
+		if err != nil {
+			return err
+		}
+		return writeStub(fmt.Sprintf("app/notifications/%s.go", strings.ToLower(name)), content)
+	},
+}
+
+var makeEventCmd = &cobra.Command{
+	Use:   "make:event [Name]",
+	Short: "Scaffold an event type",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		content, err := renderStub("event", StubData{Name: name, Lower: strings.ToLower(name)})
+		if err != nil {
+			return err
+		}
+		return writeStub(fmt.Sprintf("app/events/%s.go", strings.ToLower(name)), content)
+	},
+}
+
+var makeListenerCmd = &cobra.Command{
+	Use:   "make:listener [Name] --event=[Event]",
+	Short: "Scaffold an event listener",
+	Long: `Scaffold a listener in app/listeners for an event in app/events. It
+registers itself with event.ListenFor in init(); --queued runs it on a queue
+worker:
+
+  kashvi make:event UserRegistered
+  kashvi make:listener SendWelcomeEmail --event=UserRegistered --queued`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		ev, _ := cmd.Flags().GetString("event")
+		ev = strings.TrimPrefix(ev, "events.")
+		queued, _ := cmd.Flags().GetBool("queued")
+		mod := modulePath()
+		content, err := renderStub("listener", StubData{
+			Name:   name,
+			Lower:  strings.ToLower(name),
+			Module: mod,
+			Event:  ev,
+			Queued: queued,
+		})
+		if err != nil {
+			return err
+		}
+		if err := writeStub(fmt.Sprintf("app/listeners/%s.go", strings.ToLower(name)), content); err != nil {
+			return err
+		}
+		fmt.Printf("\n📋  Import the package once so the listener registers itself:\n\n")
+		fmt.Printf("    import _ \"%s/app/listeners\"\n\n", mod)
+		return nil
+	},
+}
+
 // kashvi make:resource — one command to scaffold a complete CRUD resource.
 // Users requested `kashvi make:crud` alias with flags. We update this resource command to match.
 var makeResourceCmd = &cobra.Command{
@@ -154,23 +348,66 @@ var makeResourceCmd = &cobra.Command{
 }
 
 func init() {
+	makeModelCmd.Flags().String("fields", "", `columns as "name:string:index,email:string:unique,age:int"`)
+	makeModelCmd.Flags().BoolP("interactive", "i", false, "prompt for the fields one by one")
 	makeResourceCmd.Flags().Bool("authorize", false, "Add authentication middleware placeholders")
 	makeResourceCmd.Flags().Bool("cache", false, "Add caching mechanisms to generated boilerplate")
+	makeRequestCmd.Flags().String("fields", "", `fields as "name:string,email:string,age:int"`)
+	makeListenerCmd.Flags().String("event", "", "the event type in app/events it handles")
+	makeListenerCmd.MarkFlagRequired("event") //nolint:errcheck
+	makeListenerCmd.Flags().Bool("queued", false, "run the listener on a queue worker")
+
+	for _, c := range makeCmds() {
+		c.Flags().BoolVar(&forceWrite, "force", false, "overwrite files that already exist")
+	}
+}
+
+// makeCmds are the make:* generators.
+func makeCmds() []*cobra.Command {
+	return []*cobra.Command{makeModelCmd, makeControllerCmd, makeServiceCmd, makeMigrationCmd,
+		makeSeederCmd, makeJobCmd, makeResourceCmd, makeMiddlewareCmd, makeRequestCmd,
+		makeNotificationCmd, makeEventCmd, makeListenerCmd}
 }
 
 // ─── writeStub ────────────────────────────────────────────────────────────────
 
+// forceWrite is the make:* --force flag.
+var forceWrite bool
+
+// writeStub creates path, given with forward slashes, and any missing parent
+// directories. Go files are gofmt'ed when they parse. It only overwrites an
+// existing file under --force.
 func writeStub(path, content string) error {
-	dir := path[:strings.LastIndex(path, "/")]
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	path = filepath.FromSlash(path)
+	if filepath.Ext(path) == ".go" {
+		if src, err := format.Source([]byte(content)); err == nil {
+			content = string(src)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-	if _, err := os.Stat(path); err == nil {
-		return fmt.Errorf("file already exists: %s", path)
+	mode, verb := os.O_EXCL, "Created"
+	if forceWrite {
+		if _, err := os.Stat(path); err == nil {
+			verb = "Overwrote"
+		}
+		mode = os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|mode, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", path)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
 	}
-	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+	if err := f.Close(); err != nil {
 		return err
 	}
-	fmt.Printf("✅  Created: %s\n", path)
+	fmt.Printf("✅  %s: %s\n", verb, path)
 	return nil
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func runMake(t *testing.T, cmd *cobra.Command, flags map[string]string, args ...string) error {
+	t.Helper()
+	for name, value := range flags {
+		if err := cmd.Flags().Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		for name := range flags {
+			f := cmd.Flags().Lookup(name)
+			f.Value.Set(f.DefValue) //nolint:errcheck
+			f.Changed = false
+		}
+	})
+	return cmd.RunE(cmd, args)
+}
+
+func TestMakeGenerators(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("go.mod", []byte("module example.com/shop\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		cmd   *cobra.Command
+		flags map[string]string
+		name  string
+		path  string
+		want  string
+	}{
+		{makeMiddlewareCmd, nil, "RequireAdmin", "app/middlewares/requireadmin.go", "func RequireAdmin() func(http.Handler) http.Handler"},
+		{makeRequestCmd, map[string]string{"fields": "name:string,born:time"}, "RegisterRequest", "app/requests/registerrequest.go", `validate:"required,max=255"`},
+		{makeNotificationCmd, nil, "InvoicePaid", "app/notifications/invoicepaid.go", "func (n *InvoicePaid) ToMail() notification.MailData"},
+		{makeJobCmd, nil, "SendInvoice", "app/jobs/sendinvoice.go", `queue.Register("jobs.SendInvoice", func() queue.Job { return &SendInvoice{} })`},
+		{makeEventCmd, nil, "UserRegistered", "app/events/userregistered.go", "type UserRegistered struct"},
+		{makeListenerCmd, map[string]string{"event": "UserRegistered", "queued": "true"}, "SendWelcome", "app/listeners/sendwelcome.go", "event.ListenFor(SendWelcome, event.Queued())"},
+	}
+	for _, c := range cases {
+		if err := runMake(t, c.cmd, c.flags, c.name); err != nil {
+			t.Fatalf("%s: %v", c.cmd.Name(), err)
+		}
+		data, err := os.ReadFile(c.path)
+		if err != nil {
+			t.Fatalf("%s: %v", c.cmd.Name(), err)
+		}
+		if !strings.Contains(string(data), c.want) {
+			t.Errorf("%s: missing %q in:\n%s", c.path, c.want, data)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), c.path, data, 0); err != nil {
+			t.Errorf("%s does not parse: %v", c.path, err)
+		}
+	}
+	if data, _ := os.ReadFile("app/listeners/sendwelcome.go"); !strings.Contains(string(data), `"example.com/shop/app/events"`) {
+		t.Errorf("listener does not import the project's events:\n%s", data)
+	}
+}
+
+func TestMakeForceOverwrites(t *testing.T) {
+	t.Chdir(t.TempDir())
+	path := "app/events/orderplaced.go"
+	if err := runMake(t, makeEventCmd, nil, "OrderPlaced"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runMake(t, makeEventCmd, nil, "OrderPlaced")
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("second run: %v, want a refusal mentioning --force", err)
+	}
+	if err := runMake(t, makeEventCmd, map[string]string{"force": "true"}, "OrderPlaced"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "type OrderPlaced struct") {
+		t.Fatalf("--force did not overwrite:\n%s", data)
+	}
+}
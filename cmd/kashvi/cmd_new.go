@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/module"
+)
+
+// ─── kashvi new ───────────────────────────────────────────────────────────────
+
+// kashvi new myapp
+var newCmd = &cobra.Command{
+	Use:   "new [directory]",
+	Short: "Create a new Kashvi project",
+	Long: `Create a project skeleton in a new directory:
+
+  go.mod, main.go          the application, calling app.New()
+  app/routes/api.go        route registration
+  app/controllers/         a home and a users controller
+  app/models/              a User model
+  database/migrations/     the users table
+  database/seeders/        a demo user
+  .env.example, .env       settings; .env gets a random JWT_SECRET
+  Dockerfile, .gitignore, README.md
+
+It then adds the kashvi dependency with go get and go mod tidy. The module
+path defaults to the directory name:
+
+  kashvi new shop
+  kashvi new shop --module github.com/acme/shop`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mod, _ := cmd.Flags().GetString("module")
+		skipDeps, _ := cmd.Flags().GetBool("skip-deps")
+		return newProject(args[0], mod, skipDeps)
+	},
+}
+
+func init() {
+	newCmd.Flags().String("module", "", "module path for go.mod (default: the directory name)")
+	newCmd.Flags().Bool("skip-deps", false, "do not run go get / go mod tidy")
+}
+
+func newProject(dir, mod string, skipDeps bool) error {
+	name := filepath.Base(filepath.Clean(dir))
+	if mod == "" {
+		mod = name
+	}
+	if err := module.CheckImportPath(mod); err != nil {
+		return fmt.Errorf("invalid module path %q (set one with --module): %w", mod, err)
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s already exists and is not empty", dir)
+	}
+
+	if err := writeSkeleton(dir, name, mod); err != nil {
+		return err
+	}
+
+	if !skipDeps {
+		version := frameworkVersion()
+		fmt.Printf("\nAdding %s@%s…\n", frameworkModule, version)
+		err := goCmdIn(dir, "get", frameworkModule+"@"+version)
+		if err == nil {
+			err = goCmdIn(dir, "mod", "tidy")
+		}
+		if err != nil {
+			fmt.Printf("\n⚠️  Could not fetch the dependencies (%v). Run these once you are online:\n\n", err)
+			fmt.Printf("    cd %s\n    go get %s@%s\n    go mod tidy\n", dir, frameworkModule, version)
+			return nil
+		}
+	}
+
+	fmt.Printf("\n🎉  Created %s. Next:\n\n", name)
+	fmt.Printf("    cd %s\n    kashvi migrate\n    kashvi seed\n    kashvi serve\n\n", dir)
+	return nil
+}
+
+// writeSkeleton renders the project files into dir.
+func writeSkeleton(dir, name, mod string) error {
+	data := StubData{Name: name, Module: mod, GoVersion: goVersion()}
+
+	type spec struct{ stub, path string }
+	files := []spec{
+		{"new/gomod", "go.mod"},
+		{"new/main", "main.go"},
+		{"new/routes", "app/routes/api.go"},
+		{"new/home_controller", "app/controllers/home_controller.go"},
+		{"new/user_controller", "app/controllers/user_controller.go"},
+		{"new/user_seeder", "database/seeders/user_seeder.go"},
+		{"new/env", ".env.example"},
+		{"new/gitignore", ".gitignore"},
+		{"new/dockerfile", "Dockerfile"},
+		{"new/readme", "README.md"},
+	}
+	for _, f := range files {
+		content, err := renderStub(f.stub, data)
+		if err != nil {
+			return err
+		}
+		if err := writeStub(filepath.Join(dir, f.path), content); err != nil {
+			return err
+		}
+	}
+
+	// .env is .env.example with a secret of its own.
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	env := data
+	env.Secret = hex.EncodeToString(secret)
+	content, err := renderStub("new/env", env)
+	if err != nil {
+		return err
+	}
+	if err := writeStub(filepath.Join(dir, ".env"), content); err != nil {
+		return err
+	}
+
+	// The User model and its migration come from the make:model stubs.
+	fields, err := parseFields("name:string,email:string:unique")
+	if err != nil {
+		return err
+	}
+	migName := time.Now().Format("20060102150405") + "_create_users_table"
+	user := StubData{
+		Name:       "User",
+		Lower:      "user",
+		StructName: "M_" + migName,
+		Table:      "users",
+		Module:     mod,
+		Fields:     fields,
+	}
+	model, err := renderStub("model", user)
+	if err != nil {
+		return err
+	}
+	if err := writeStub(filepath.Join(dir, "app/models/user.go"), model); err != nil {
+		return err
+	}
+	user.Name = migName
+	migration, err := renderStub("migration_create", user)
+	if err != nil {
+		return err
+	}
+	return writeStub(filepath.Join(dir, "database/migrations", migName+".go"), migration)
+}
+
+// frameworkVersion is the kashvi version to require: this CLI's own when it
+// was installed from a release, otherwise the latest.
+func frameworkVersion() string {
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Path == frameworkModule &&
+		strings.HasPrefix(bi.Main.Version, "v") && !module.IsPseudoVersion(bi.Main.Version) {
+		return bi.Main.Version
+	}
+	return "latest"
+}
+
+// goVersion is the go directive for go.mod: the installed toolchain's
+// major.minor, as `go mod init` writes.
+func goVersion() string {
+	v := runtime.Version()
+	if out, err := exec.Command("go", "env", "GOVERSION").Output(); err == nil {
+		v = strings.TrimSpace(string(out))
+	}
+	parts := strings.SplitN(strings.TrimPrefix(v, "go"), ".", 3)
+	if len(parts) < 2 || strings.ContainsAny(parts[1], " -") {
+		return "1.25"
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// goCmdIn runs the go command in dir ("" for the current directory).
+func goCmdIn(dir string, args ...string) error {
+	c := exec.Command("go", args...)
+	c.Dir = dir
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewProjectWritesSkeleton(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := newProject("shop", "example.com/shop", true); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []string{"go.mod", "main.go", "app/routes/api.go", "app/controllers/user_controller.go",
+		"app/models/user.go", "database/seeders/user_seeder.go", ".env.example", ".env", "Dockerfile", ".gitignore"} {
+		if _, err := os.Stat(filepath.Join("shop", f)); err != nil {
+			t.Errorf("missing %s", f)
+		}
+	}
+	migrations, _ := filepath.Glob("shop/database/migrations/*_create_users_table.go")
+	if len(migrations) != 1 {
+		t.Errorf("migrations = %v", migrations)
+	}
+
+	goFiles, _ := filepath.Glob("shop/*/*/*.go")
+	goFiles = append(goFiles, "shop/main.go")
+	for _, f := range goFiles {
+		if _, err := parser.ParseFile(token.NewFileSet(), f, nil, 0); err != nil {
+			t.Errorf("%s does not parse: %v", f, err)
+		}
+	}
+	if data, _ := os.ReadFile("shop/main.go"); !strings.Contains(string(data), `"example.com/shop/app/routes"`) {
+		t.Errorf("main.go does not import the project's routes:\n%s", data)
+	}
+	example, _ := os.ReadFile("shop/.env.example")
+	env, _ := os.ReadFile("shop/.env")
+	if !strings.Contains(string(example), "JWT_SECRET=change-me-in-production") || strings.Contains(string(env), "change-me") {
+		t.Errorf(".env should get its own JWT_SECRET:\n%s", env)
+	}
+
+	if err := newProject("shop", "", true); err == nil || !strings.Contains(err.Error(), "not empty") {
+		t.Errorf("second run: %v, want a refusal", err)
+	}
+	if err := newProject("my app", "", true); err == nil || !strings.Contains(err.Error(), "--module") {
+		t.Errorf("invalid module path: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+// cmd_replay.go — `kashvi replay`: re-fire recorded production requests
+// (see pkg/recorder) against a local server, or export them as testkit
+// scenarios.
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/recorder"
+	"github.com/shashiranjanraj/kashvi/pkg/storage"
+)
+
+var (
+	replayTarget   string
+	replayScenario string
+	replayHeaders  []string
+	replayList     bool
+)
+
+// kashvi replay <id>
+var replayCmd = &cobra.Command{
+	Use:   "replay [id]",
+	Short: "Replay a recorded request or export it as a testkit scenario",
+	Long: `Replay a request captured by the recorder middleware (RECORDER_ENABLED=true).
+
+  kashvi replay --list
+  kashvi replay <id> --target http://localhost:8080 -H "Authorization: Bearer dev"
+  kashvi replay <id> --scenario testdata/scenarios`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.Load(); err != nil {
+			return err
+		}
+		storage.Connect()
+		opts := recorder.DefaultOptions()
+
+		if replayList || len(args) == 0 {
+			ids, err := recorder.List(opts.Disk, opts.Prefix)
+			if err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				fmt.Println("No recordings found.")
+			}
+			for _, id := range ids {
+				fmt.Println(id)
+			}
+			return nil
+		}
+
+		rec, err := recorder.Load(opts.Disk, opts.Prefix, args[0])
+		if err != nil {
+			return err
+		}
+
+		if replayScenario != "" {
+			file, err := recorder.ExportScenario(rec, replayScenario)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✅ Scenario written to %s (%d outgoing call(s) mocked)\n", file, len(rec.Outbound))
+			return nil
+		}
+
+		extra := http.Header{}
+		for _, h := range replayHeaders {
+			k, v, ok := strings.Cut(h, ":")
+			if !ok {
+				return fmt.Errorf("invalid header %q (want \"Name: value\")", h)
+			}
+			extra.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+		}
+
+		fmt.Printf("↻ %s %s → %s\n", rec.Method, rec.Path, replayTarget)
+		res, err := recorder.Replay(context.Background(), rec, replayTarget, extra)
+		if err != nil {
+			return err
+		}
+
+		mark := func(ok bool) string {
+			if ok {
+				return "same"
+			}
+			return "differs"
+		}
+		fmt.Printf("  status: %d (recorded %d, %s)\n", res.Status, rec.Response.Status, mark(res.StatusMatch))
+		fmt.Printf("  body:   %d bytes (%s)\n", len(res.Body), mark(res.BodyMatch))
+		if !res.BodyMatch {
+			fmt.Printf("\n%s\n", res.Body)
+		}
+		return nil
+	},
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&replayTarget, "target", "http://localhost:8080", "Base URL of the server to replay against")
+	replayCmd.Flags().StringVar(&replayScenario, "scenario", "", "Export as a testkit scenario into this directory instead of replaying")
+	replayCmd.Flags().StringArrayVarP(&replayHeaders, "header", "H", nil, "Extra header for the replayed request (repeatable)")
+	replayCmd.Flags().BoolVar(&replayList, "list", false, "List stored recordings")
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"runtime"
 	"sort"
 	"syscall"
 	"text/tabwriter"
@@ -43,6 +45,33 @@ var routeListCmd = &cobra.Command{
 	},
 }
 
+// kashvi ws:contract — delegates to the project, whose hubs and handlers
+// are registered by its own code.
+var wsContractCmd = &cobra.Command{
+	Use:   "ws:contract",
+	Short: "Print WebSocket hub message contracts (JSON Schema or TypeScript)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if isFrameworkSelf() {
+			fmt.Println("kashvi ws:contract can only be run inside a Kashvi project directory.")
+			os.Exit(1)
+		}
+		var extra []string
+		if ts, _ := cmd.Flags().GetBool("ts"); ts {
+			extra = append(extra, "--ts")
+		}
+		if out, _ := cmd.Flags().GetString("out"); out != "" {
+			extra = append(extra, "--out", out)
+		}
+		return runInProject("ws:contract", extra...)
+	},
+}
+
+func init() {
+	f := wsContractCmd.Flags()
+	f.Bool("ts", false, "print TypeScript declarations instead of JSON Schema")
+	f.String("out", "", "write to this file instead of stdout")
+}
+
 // printRouteTable is a helper used internally when routes are available.
 func printRouteTable(infos []routeInfo) {
 	if len(infos) == 0 {
@@ -69,16 +98,17 @@ type routeInfo struct{ Method, Path, Name string }
 // kashvi build — compile the server binary.
 var buildCmd = &cobra.Command{
 	Use:   "build",
-	Short: "Build the kashvi server binary (outputs ./kashvi)",
+	Short: "Build the kashvi server binary (outputs ./kashvi, kashvi.exe on Windows)",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("Building kashvi…")
-		c := exec.Command("go", "build", "-o", "kashvi", "./cmd/server")
+		out := binaryName(runtime.GOOS, "kashvi")
+		c := exec.Command("go", "build", "-o", out, "./cmd/server")
 		c.Stdout = os.Stdout
 		c.Stderr = os.Stderr
 		if err := c.Run(); err != nil {
 			return fmt.Errorf("build failed: %w", err)
 		}
-		fmt.Println("✅  Built: ./kashvi")
+		fmt.Println("✅  Built: ." + string(filepath.Separator) + out)
 		return nil
 	},
 }
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/shashiranjanraj/kashvi/pkg/app"
+)
+
+// frameworkModule is the module path projects require.
+const frameworkModule = "github.com/shashiranjanraj/kashvi"
+
+// kashvi upgrade
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade [version]",
+	Short: "Upgrade the project's kashvi dependency and show what changed",
+	Long: `Upgrade github.com/shashiranjanraj/kashvi in the current project (default:
+latest), then:
+
+  • print the release notes between the old and new version, leaving out
+    notes about APIs the project does not use
+  • run go build ./... to check the project still compiles
+
+Usage of framework APIs is detected by type-checking the project before the
+upgrade, so it must build at its current version.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if isFrameworkSelf() {
+			return fmt.Errorf("kashvi upgrade must be run inside a project that depends on kashvi")
+		}
+		target := "latest"
+		if len(args) == 1 {
+			target = args[0]
+		}
+		return upgrade(target)
+	},
+}
+
+func upgrade(target string) error {
+	before, err := frameworkModuleInfo()
+	if err != nil {
+		return err
+	}
+	if before.Replace != nil {
+		fmt.Printf("⚠️  go.mod replaces %s with %s; the build keeps using the replacement.\n",
+			frameworkModule, before.Replace.Path)
+	}
+
+	fmt.Println("Scanning the project for kashvi APIs…")
+	used, err := usedSymbols(".")
+	if err != nil {
+		fmt.Printf("⚠️  Could not type-check the project (%v); showing every note.\n", err)
+	}
+
+	fmt.Printf("Upgrading %s from %s to %s…\n", frameworkModule, before.Version, target)
+	if err := goCmd("get", frameworkModule+"@"+target); err != nil {
+		return fmt.Errorf("go get: %w", err)
+	}
+	after, err := frameworkModuleInfo()
+	if err != nil {
+		return err
+	}
+	if after.Version == before.Version {
+		fmt.Printf("✅  Already at %s.\n", after.Version)
+		return nil
+	}
+
+	releases, err := targetReleases(after.Dir)
+	if err != nil {
+		return err
+	}
+	notes, skipped := upgradeNotes(releases, before.Version, after.Version, used)
+	printUpgradeNotes(notes, skipped, used != nil)
+
+	fmt.Println("\nBuilding the project…")
+	if err := goCmd("build", "./..."); err != nil {
+		fmt.Printf("\n❌  The project no longer builds on %s. Fix the errors above, or go back with:\n\n", after.Version)
+		fmt.Printf("    go get %s@%s\n\n", frameworkModule, before.Version)
+		return fmt.Errorf("build failed after upgrading to %s", after.Version)
+	}
+	fmt.Printf("✅  Upgraded to %s and the project builds.\n", after.Version)
+	return nil
+}
+
+// moduleInfo is the part of `go list -m -json` upgrade needs.
+type moduleInfo struct {
+	Version string
+	Dir     string
+	Replace *struct{ Path, Dir string }
+}
+
+func frameworkModuleInfo() (moduleInfo, error) {
+	var info moduleInfo
+	out, err := exec.Command("go", "list", "-m", "-json", frameworkModule).Output()
+	if err != nil {
+		return info, fmt.Errorf("this project does not require %s: %w", frameworkModule, err)
+	}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return info, fmt.Errorf("go list: %w", err)
+	}
+	if info.Replace != nil && info.Replace.Dir != "" {
+		info.Dir = info.Replace.Dir
+	}
+	return info, nil
+}
+
+// targetReleases reads the release manifest of the version just installed,
+// falling back to the one built into this CLI for versions without it.
+func targetReleases(dir string) ([]app.Release, error) {
+	if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(app.ReleasesFile))); err == nil {
+		return app.ParseReleases(data)
+	}
+	return app.Releases()
+}
+
+func goCmd(args ...string) error { return goCmdIn("", args...) }
+
+// ─── API usage ────────────────────────────────────────────────────────────────
+
+// usedSymbols type-checks the packages under dir, tests included, and
+// returns the kashvi symbols they refer to, named as in app.ReleaseNote.
+func usedSymbols(dir string) (map[string]bool, error) {
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+		Dir:   dir,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, err
+	}
+	used := map[string]bool{}
+	var errs []string
+	for _, p := range pkgs {
+		for _, e := range p.Errors {
+			errs = append(errs, e.Error())
+		}
+		if p.TypesInfo == nil {
+			continue
+		}
+		for _, obj := range p.TypesInfo.Uses {
+			if name := symbolName(obj); name != "" {
+				used[name] = true
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", errs[0])
+	}
+	return used, nil
+}
+
+// symbolName names a package-level kashvi object or method:
+// "<import path>.Name" or "<import path>.Type.Method".
+func symbolName(obj types.Object) string {
+	pkg := obj.Pkg()
+	if pkg == nil || (pkg.Path() != frameworkModule && !strings.HasPrefix(pkg.Path(), frameworkModule+"/")) {
+		return ""
+	}
+	if fn, ok := obj.(*types.Func); ok {
+		if recv := fn.Signature().Recv(); recv != nil {
+			t := recv.Type()
+			if p, ok := t.(*types.Pointer); ok {
+				t = p.Elem()
+			}
+			if named, ok := t.(*types.Named); ok {
+				return pkg.Path() + "." + named.Obj().Name() + "." + fn.Name()
+			}
+			return ""
+		}
+	}
+	if obj.Parent() != pkg.Scope() {
+		return "" // fields, locals
+	}
+	return pkg.Path() + "." + obj.Name()
+}
+
+// ─── Notes ────────────────────────────────────────────────────────────────────
+
+// upgradeNote is a release note with the release it belongs to.
+type upgradeNote struct {
+	Version string
+	app.ReleaseNote
+}
+
+// upgradeNotes returns the notes of releases after from up to and including
+// to, oldest first. With a nil used set every note is returned; otherwise
+// notes about symbols the project does not use are counted in skipped.
+func upgradeNotes(releases []app.Release, from, to string, used map[string]bool) (notes []upgradeNote, skipped int) {
+	sorted := append([]app.Release(nil), releases...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return semver.Compare(sorted[i].Version, sorted[j].Version) < 0
+	})
+	for _, r := range sorted {
+		if semver.Compare(r.Version, from) <= 0 || semver.Compare(r.Version, to) > 0 {
+			continue
+		}
+		for _, n := range r.Notes {
+			if n.Symbol != "" && used != nil && !used[n.Symbol] {
+				skipped++
+				continue
+			}
+			notes = append(notes, upgradeNote{Version: r.Version, ReleaseNote: n})
+		}
+	}
+	return notes, skipped
+}
+
+func printUpgradeNotes(notes []upgradeNote, skipped int, filtered bool) {
+	if len(notes) == 0 {
+		fmt.Println("\nNo upgrade notes apply to this project.")
+		return
+	}
+	var b bytes.Buffer
+	version := ""
+	for _, n := range notes {
+		if n.Version != version {
+			version = n.Version
+			fmt.Fprintf(&b, "\n%s\n", version)
+		}
+		if n.Symbol != "" {
+			fmt.Fprintf(&b, "  • [%s] %s\n", strings.TrimPrefix(n.Symbol, frameworkModule+"/"), n.Text)
+		} else {
+			fmt.Fprintf(&b, "  • %s\n", n.Text)
+		}
+	}
+	fmt.Print(b.String())
+	if filtered && skipped > 0 {
+		fmt.Printf("\n(%d note(s) about APIs this project does not use were left out.)\n", skipped)
+	}
+}
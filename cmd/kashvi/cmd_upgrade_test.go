@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/app"
+)
+
+func TestUpgradeNotes(t *testing.T) {
+	start := frameworkModule + "/pkg/grpc.Start"
+	releases := []app.Release{
+		{Version: "v1.3.0", Notes: []app.ReleaseNote{{Text: "too new"}}},
+		{Version: "v1.2.0", Notes: []app.ReleaseNote{{Text: "everyone"}, {Symbol: start, Text: "grpc"}}},
+		{Version: "v1.1.0", Notes: []app.ReleaseNote{{Symbol: frameworkModule + "/pkg/sse.New", Text: "sse"}}},
+		{Version: "v1.0.0", Notes: []app.ReleaseNote{{Text: "already installed"}}},
+	}
+
+	notes, skipped := upgradeNotes(releases, "v1.0.0", "v1.2.0", map[string]bool{start: true})
+	if len(notes) != 2 || notes[0].Text != "everyone" || notes[1].Text != "grpc" || skipped != 1 {
+		t.Errorf("got %+v (skipped %d), want the v1.2.0 notes and the unused sse note skipped", notes, skipped)
+	}
+
+	// Without usage information every note in range is shown, oldest first.
+	notes, skipped = upgradeNotes(releases, "v1.0.0", "v1.2.0", nil)
+	if len(notes) != 3 || notes[0].Version != "v1.1.0" || skipped != 0 {
+		t.Errorf("got %+v (skipped %d), want all three notes from v1.1.0 on", notes, skipped)
+	}
+}
+
+func TestReleaseManifestMatchesVersion(t *testing.T) {
+	releases, err := app.Releases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) == 0 || releases[0].Version != "v"+app.Version {
+		t.Fatalf("newest release in %s should be v%s", app.ReleasesFile, app.Version)
+	}
+}
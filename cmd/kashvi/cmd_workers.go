@@ -3,8 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -12,35 +16,147 @@ import (
 	"github.com/shashiranjanraj/kashvi/pkg/schedule"
 )
 
-var queueWorkersFlag int
+var (
+	queueNamesFlag   string
+	queueWorkersFlag int
+	queueMaxJobs     int
+	queueMaxTime     time.Duration
+	queueMemory      string
+	queueMaxWorkers  int
+	queueDrain       time.Duration
+)
 
 // kashvi queue:work
 var queueWorkCmd = &cobra.Command{
 	Use:   "queue:work",
 	Short: "Start the queue worker",
+	Long: `Process queued jobs until SIGINT/SIGTERM or a restart limit is reached.
+
+On SIGINT/SIGTERM, or when --max-jobs, --max-time or --memory is hit, the
+worker stops fetching, finishes in-flight jobs (for at most --drain-timeout)
+and exits 0 so a supervisor can start a fresh one.
+
+With --max-concurrency the pool grows from --concurrency towards it while
+jobs back up, and shrinks back once the queues are quiet.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isFrameworkSelf() {
+			return runInProject("queue:work", queueWorkArgs()...)
+		}
+
 		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 		defer stop()
 
-		workers := queueWorkersFlag
-		if workers < 1 {
-			workers = 5
+		memory, err := queue.ParseByteSize(queueMemory)
+		if queueMemory != "" && err != nil {
+			return fmt.Errorf("invalid --memory %q: %w", queueMemory, err)
 		}
+		opts := queue.WorkerOptions{
+			Queues:      splitQueues(queueNamesFlag),
+			Concurrency: queueWorkersFlag,
+			MaxJobs:     queueMaxJobs,
+			MaxTime:     queueMaxTime,
+			MemoryLimit: memory,
 
-		fmt.Printf("🚀 Queue worker started (%d workers). Press Ctrl+C to stop.\n", workers)
-		queue.StartWorkers(ctx, workers)
-
-		<-ctx.Done()
-		fmt.Println("\n⚡ Queue worker stopped.")
+			DrainTimeout:   queueDrain,
+			MaxConcurrency: queueMaxWorkers,
+		}
+		fmt.Printf("🚀 Queue worker started (%d workers). Press Ctrl+C to stop.\n", opts.Concurrency)
+		reason := queue.Work(ctx, opts)
+		fmt.Printf("\n⚡ Queue worker stopped (%s).\n", reason)
 		return nil
 	},
 }
 
-// kashvi schedule:run
-var scheduleRunCmd = &cobra.Command{
-	Use:   "schedule:run",
-	Short: "Start the task scheduler",
+// queueWorkArgs forwards the worker flags to the project's queue:work.
+func queueWorkArgs() []string {
+	args := []string{"--queue", queueNamesFlag, "--concurrency", strconv.Itoa(queueWorkersFlag)}
+	if queueMaxJobs > 0 {
+		args = append(args, "--max-jobs", strconv.Itoa(queueMaxJobs))
+	}
+	if queueMaxTime > 0 {
+		args = append(args, "--max-time", queueMaxTime.String())
+	}
+	if queueMemory != "" {
+		args = append(args, "--memory", queueMemory)
+	}
+	if queueDrain > 0 {
+		args = append(args, "--drain-timeout", queueDrain.String())
+	}
+	if queueMaxWorkers > 0 {
+		args = append(args, "--max-concurrency", strconv.Itoa(queueMaxWorkers))
+	}
+	return args
+}
+
+func splitQueues(s string) []string {
+	var out []string
+	for _, q := range strings.Split(s, ",") {
+		if q = strings.TrimSpace(q); q != "" {
+			out = append(out, q)
+		}
+	}
+	return out
+}
+
+// kashvi queue:failed
+var queueFailedCmd = &cobra.Command{
+	Use:   "queue:failed",
+	Short: "List failed jobs stored in kashvi_failed_jobs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if isFrameworkSelf() {
+			fmt.Println("kashvi queue:failed can only be run inside a Kashvi project directory.")
+			os.Exit(1)
+		}
+		return runInProject("queue:failed")
+	},
+}
+
+// kashvi queue:retry
+var queueRetryCmd = &cobra.Command{
+	Use:   "queue:retry <id>... | all",
+	Short: "Push failed jobs back onto their queue",
+	Long: `Re-queue failed jobs by ID (see queue:failed), or every failed job with "all".
+
+Needs a durable QUEUE_DRIVER (database or redis) so a running worker picks
+the jobs up.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if isFrameworkSelf() {
+			fmt.Println("kashvi queue:retry can only be run inside a Kashvi project directory.")
+			os.Exit(1)
+		}
+		return runInProject("queue:retry", args...)
+	},
+}
+
+// kashvi quota:report
+var quotaReportCmd = &cobra.Command{
+	Use:   "quota:report",
+	Short: "Show per-tenant request usage for billing",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if isFrameworkSelf() {
+			fmt.Println("kashvi quota:report can only be run inside a Kashvi project directory.")
+			os.Exit(1)
+		}
+		var extra []string
+		for _, name := range []string{"month", "day", "tenant"} {
+			if v, _ := cmd.Flags().GetString(name); v != "" {
+				extra = append(extra, "--"+name, v)
+			}
+		}
+		return runInProject("quota:report", extra...)
+	},
+}
+
+// kashvi schedule:work
+var scheduleWorkCmd = &cobra.Command{
+	Use:   "schedule:work",
+	Short: "Run the task scheduler in the foreground",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isFrameworkSelf() {
+			return runInProject("schedule:work")
+		}
+
 		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 		defer stop()
 
@@ -55,14 +171,44 @@ var scheduleRunCmd = &cobra.Command{
 		}
 
 		fmt.Println("🕐 Scheduler started. Press Ctrl+C to stop.")
-		schedule.Start(ctx)
-
-		<-ctx.Done()
+		schedule.Work(ctx)
 		fmt.Println("\n⚡ Scheduler stopped.")
 		return nil
 	},
 }
 
+// kashvi schedule:run
+var scheduleRunCmd = &cobra.Command{
+	Use:   "schedule:run",
+	Short: "Run tasks due this minute once and exit",
+	Long: `Evaluate scheduled tasks once, run the ones due now, wait for them and exit.
+
+Invoke it every minute from cron or a Kubernetes CronJob:
+
+  * * * * * cd /srv/app && ./myapp schedule:run`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isFrameworkSelf() {
+			return runInProject("schedule:run")
+		}
+		n := schedule.RunDue(time.Now())
+		fmt.Printf("Ran %d scheduled task(s).\n", n)
+		return nil
+	},
+}
+
 func init() {
-	queueWorkCmd.Flags().IntVarP(&queueWorkersFlag, "workers", "w", 5, "Number of concurrent workers")
+	f := queueWorkCmd.Flags()
+	f.StringVar(&queueNamesFlag, "queue", "default", "comma-separated queues, highest priority first")
+	f.IntVarP(&queueWorkersFlag, "concurrency", "c", 5, "number of jobs processed in parallel")
+	f.IntVarP(&queueWorkersFlag, "workers", "w", 5, "alias for --concurrency")
+	f.IntVar(&queueMaxJobs, "max-jobs", 0, "exit after processing this many jobs (0 = unlimited)")
+	f.DurationVar(&queueMaxTime, "max-time", 0, "exit after running this long, e.g. 1h (0 = unlimited)")
+	f.StringVar(&queueMemory, "memory", "", "exit when memory use exceeds this size, e.g. 256MB")
+	f.DurationVar(&queueDrain, "drain-timeout", 0, "after SIGTERM, wait at most this long for in-flight jobs (0 = no limit)")
+	f.IntVar(&queueMaxWorkers, "max-concurrency", 0, "autoscale up to this many parallel jobs while a backlog builds up")
+
+	q := quotaReportCmd.Flags()
+	q.String("month", "", "month to report, YYYY-MM (default: current)")
+	q.String("day", "", "single day to report, YYYY-MM-DD")
+	q.String("tenant", "", "only this tenant")
 }
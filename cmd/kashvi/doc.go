@@ -16,7 +16,8 @@
 // The CLI detects whether it is running:
 //
 //	a) Inside the kashvi framework repo itself → uses direct Go imports
-//	b) Inside a user project → delegates to `go run . <command>`
+//	b) Inside a user project → delegates to `go run . <command> [args...]`,
+//	   forwarding unknown commands and every flag verbatim
 //
 // User projects just need this in their main.go:
 //
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// ─── Field specs ──────────────────────────────────────────────────────────────
+
+// Field is one column of a generated model, parsed from a spec such as
+// "email:string:unique" (name:type[:modifier...]).
+type Field struct {
+	Name     string // Go field name, e.g. UserID
+	Column   string // column and JSON name, e.g. user_id
+	Type     string // spec type, e.g. string
+	Index    bool
+	Unique   bool
+	Nullable bool
+}
+
+// fieldTypes maps spec types to their Go type and GORM column tag.
+var fieldTypes = map[string]struct{ goType, gorm string }{
+	"string": {"string", "size:255"},
+	"text":   {"string", "type:text"},
+	"uuid":   {"string", "size:36"},
+	"int":    {"int", ""},
+	"int64":  {"int64", ""},
+	"uint":   {"uint", ""},
+	"float":  {"float64", ""},
+	"bool":   {"bool", ""},
+	"time":   {"time.Time", ""},
+}
+
+// parseFields parses a comma-separated field spec:
+//
+//	name:string:index,email:string:unique,age:int,bio:text:nullable
+//
+// Modifiers are index, unique and nullable; the type defaults to string.
+func parseFields(spec string) ([]Field, error) {
+	var fields []Field
+	seen := map[string]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		f, err := parseField(part)
+		if err != nil {
+			return nil, err
+		}
+		if seen[f.Column] {
+			return nil, fmt.Errorf("field %q listed twice", f.Column)
+		}
+		seen[f.Column] = true
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func parseField(spec string) (Field, error) {
+	parts := strings.Split(spec, ":")
+	column := snakeCase(strings.TrimSpace(parts[0]))
+	if column == "" || !unicode.IsLetter(rune(column[0])) ||
+		strings.IndexFunc(column, func(r rune) bool { return !isIdentRune(r) }) >= 0 {
+		return Field{}, fmt.Errorf("field %q: name must be a letter followed by letters, digits or _", spec)
+	}
+	switch column {
+	case "id", "created_at", "updated_at", "deleted_at":
+		return Field{}, fmt.Errorf("field %q: %s comes from gorm.Model", spec, column)
+	}
+	f := Field{Name: goName(column), Column: column, Type: "string"}
+	if len(parts) > 1 && parts[1] != "" {
+		f.Type = strings.ToLower(strings.TrimSpace(parts[1]))
+	}
+	if _, ok := fieldTypes[f.Type]; !ok {
+		return Field{}, fmt.Errorf("field %q: unknown type %q (use %s)", spec, f.Type, typeList())
+	}
+	for _, m := range parts[min(2, len(parts)):] {
+		switch strings.ToLower(strings.TrimSpace(m)) {
+		case "index":
+			f.Index = true
+		case "unique":
+			f.Unique = true
+		case "nullable":
+			f.Nullable = true
+		default:
+			return Field{}, fmt.Errorf("field %q: unknown modifier %q (use index, unique or nullable)", spec, m)
+		}
+	}
+	return f, nil
+}
+
+func typeList() string {
+	return "string, text, uuid, int, int64, uint, float, bool or time"
+}
+
+// GoType is the model's Go type; nullable columns are pointers.
+func (f Field) GoType() string {
+	t := fieldTypes[f.Type].goType
+	if f.Nullable {
+		return "*" + t
+	}
+	return t
+}
+
+// RequestType is the Go type in request structs. Requests never use
+// pointers: the validator checks the value, and "nullable" skips empty ones.
+func (f Field) RequestType() string { return fieldTypes[f.Type].goType }
+
+// GormTag is the field's gorm:"..." value, empty when GORM's defaults fit.
+func (f Field) GormTag() string {
+	var opts []string
+	if t := fieldTypes[f.Type].gorm; t != "" {
+		opts = append(opts, t)
+	}
+	if !f.Nullable && f.Type != "bool" {
+		opts = append(opts, "not null")
+	}
+	switch {
+	case f.Unique:
+		opts = append(opts, "uniqueIndex")
+	case f.Index:
+		opts = append(opts, "index")
+	}
+	return strings.Join(opts, ";")
+}
+
+// Tags is the model field's struct tag.
+func (f Field) Tags() string {
+	tag := `json:"` + f.Column + `"`
+	if g := f.GormTag(); g != "" {
+		tag += ` gorm:"` + g + `"`
+	}
+	return "`" + tag + "`"
+}
+
+// ColumnTags is the field's struct tag in a migration's table snapshot.
+func (f Field) ColumnTags() string {
+	if g := f.GormTag(); g != "" {
+		return "`gorm:\"" + g + "\"`"
+	}
+	return ""
+}
+
+// CreateRules are the validate rules for a create request.
+func (f Field) CreateRules() string {
+	var rules []string
+	switch {
+	case f.Nullable:
+		rules = append(rules, "nullable")
+	case f.Type != "bool":
+		rules = append(rules, "required")
+	}
+	switch f.Type {
+	case "string":
+		rules = append(rules, "max=255")
+	case "uuid":
+		rules = append(rules, "uuid")
+	}
+	return strings.Join(rules, ",")
+}
+
+// UpdateRules are the validate rules for an update request, where every
+// field is optional.
+func (f Field) UpdateRules() string {
+	rules := strings.TrimPrefix(strings.TrimPrefix(f.CreateRules(), "nullable"), "required")
+	if rules == "" {
+		return ""
+	}
+	return "nullable" + rules
+}
+
+// RequestTags is a request field's struct tag.
+func (f Field) RequestTags(rules string) string {
+	tag := `json:"` + f.Column + `"`
+	if rules != "" {
+		tag += ` validate:"` + rules + `"`
+	}
+	return "`" + tag + "`"
+}
+
+// usesTime reports whether any field needs the time import.
+func usesTime(fields []Field) bool {
+	for _, f := range fields {
+		if f.Type == "time" {
+			return true
+		}
+	}
+	return false
+}
+
+// ─── Interactive prompt ───────────────────────────────────────────────────────
+
+// promptFields asks for one field spec per line until a blank line.
+func promptFields(in io.Reader, out io.Writer) ([]Field, error) {
+	fmt.Fprintf(out, "Fields as name:type[:index|unique|nullable]; types: %s.\n", typeList())
+	fmt.Fprintln(out, "Press Enter on an empty line to finish.")
+	var fields []Field
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "field> ")
+		if !sc.Scan() {
+			return fields, sc.Err()
+		}
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			return fields, nil
+		}
+		f, err := parseField(line)
+		if err == nil && seen[f.Column] {
+			err = fmt.Errorf("field %q listed twice", f.Column)
+		}
+		if err != nil {
+			fmt.Fprintln(out, "  ✗", err)
+			continue
+		}
+		seen[f.Column] = true
+		fields = append(fields, f)
+	}
+}
+
+// ─── Naming ───────────────────────────────────────────────────────────────────
+
+// initialisms are upper-cased whole in Go names, as golint expects.
+var initialisms = map[string]bool{
+	"id": true, "url": true, "uri": true, "uuid": true, "api": true, "ip": true,
+	"json": true, "html": true, "http": true, "sku": true, "sql": true,
+}
+
+// goName turns a snake_case column into an exported Go name: user_id → UserID.
+func goName(column string) string {
+	var b strings.Builder
+	for _, w := range strings.Split(column, "_") {
+		if w == "" {
+			continue
+		}
+		if initialisms[w] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9'
+}
+
+// snakeCase lower-cases s, splitting camelCase words with underscores:
+// firstName → first_name.
+func snakeCase(s string) string {
+	var b strings.Builder
+	rs := []rune(s)
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(rs[i-1]) || i+1 < len(rs) && unicode.IsLower(rs[i+1])) && rs[i-1] != '_' {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		if r == '-' || r == ' ' {
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// modulePath reads the module path from ./go.mod, so generated files can
+// import the project's own packages. It returns "<module>" when there is none.
+func modulePath() string {
+	data, err := os.ReadFile("go.mod")
+	if err != nil {
+		return "<module>"
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return "<module>"
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"go/format"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, err := parseFields("name:string:index, email:string:unique,age:int,userId:uint,bio:text:nullable,nickname")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Field{
+		{Name: "Name", Column: "name", Type: "string", Index: true},
+		{Name: "Email", Column: "email", Type: "string", Unique: true},
+		{Name: "Age", Column: "age", Type: "int"},
+		{Name: "UserID", Column: "user_id", Type: "uint"},
+		{Name: "Bio", Column: "bio", Type: "text", Nullable: true},
+		{Name: "Nickname", Column: "nickname", Type: "string"},
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("got %d fields, want %d: %+v", len(fields), len(want), fields)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("field %d = %+v, want %+v", i, fields[i], want[i])
+		}
+	}
+}
+
+func TestParseFields_Errors(t *testing.T) {
+	for _, spec := range []string{
+		"age:integer",       // unknown type
+		"age:int:primary",   // unknown modifier
+		"name,name:text",    // duplicate
+		"id:uint",           // from gorm.Model
+		"2fa:bool",          // not an identifier
+		"first-name;string", // stray punctuation
+	} {
+		if _, err := parseFields(spec); err == nil {
+			t.Errorf("parseFields(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestFieldTags(t *testing.T) {
+	f := Field{Name: "Email", Column: "email", Type: "string", Unique: true}
+	if got, want := f.Tags(), "`json:\"email\" gorm:\"size:255;not null;uniqueIndex\"`"; got != want {
+		t.Errorf("Tags() = %s, want %s", got, want)
+	}
+	if got := f.CreateRules(); got != "required,max=255" {
+		t.Errorf("CreateRules() = %q", got)
+	}
+	if got := f.UpdateRules(); got != "nullable,max=255" {
+		t.Errorf("UpdateRules() = %q", got)
+	}
+
+	n := Field{Name: "PaidAt", Column: "paid_at", Type: "time", Nullable: true}
+	if n.GoType() != "*time.Time" || n.RequestType() != "time.Time" || n.ColumnTags() != "" {
+		t.Errorf("nullable time: GoType %q, RequestType %q, ColumnTags %q", n.GoType(), n.RequestType(), n.ColumnTags())
+	}
+}
+
+func TestPromptFields(t *testing.T) {
+	in := strings.NewReader("name:string:index\nage:number\nage:int\n\nignored:int\n")
+	fields, err := promptFields(in, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 2 || fields[0].Column != "name" || fields[1].Type != "int" {
+		t.Errorf("got %+v, want name and age (the invalid line re-asked)", fields)
+	}
+}
+
+func TestFieldStubsAreValidGo(t *testing.T) {
+	fields, _ := parseFields("name:string:index,paid_at:time:nullable,active:bool")
+	data := StubData{
+		Name: "OrderItem", Lower: "orderitem", StructName: "M_1_create_order_items_table",
+		Table: "order_items", Module: "example.com/shop", Fields: fields, UsesTime: usesTime(fields),
+	}
+	for _, stub := range []string{"model", "migration_create", "resource", "request"} {
+		out, err := renderStub(stub, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := format.Source([]byte(out)); err != nil {
+			t.Errorf("%s stub is not valid Go: %v\n%s", stub, err, out)
+		}
+	}
+}
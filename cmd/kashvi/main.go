@@ -8,6 +8,20 @@ import (
 )
 
 func main() {
+	// --app (or kashvi.yaml's default) first: whether this is the framework
+	// or a project, and so which commands exist, depends on the directory.
+	args, err := selectApp(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	registerCommands()
+
+	if passthrough(args) {
+		runPassthrough(args)
+		return
+	}
+	rootCmd.SetArgs(args)
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -17,10 +31,15 @@ func main() {
 var rootCmd = &cobra.Command{
 	Use:   "kashvi",
 	Short: "Kashvi — Go framework CLI",
-	Long:  "Kashvi is a Laravel-inspired Go framework. Use this CLI to scaffold and manage your project.",
+	Long: `Kashvi is a Laravel-inspired Go framework. Use this CLI to scaffold and manage your project.
+
+In a workspace with several services, put --app before the command to pick
+one: kashvi --app=orders serve (an app from kashvi.yaml, or a directory).`,
 }
 
-func init() {
+// registerCommands adds the commands for the current directory: the
+// framework's own, or a project's with runtime commands delegated.
+func registerCommands() {
 	if isFrameworkSelf() {
 		// ── Framework dev mode: direct imports used, no delegation.
 		rootCmd.AddCommand(runCmd)
@@ -35,22 +54,46 @@ func init() {
 		rootCmd.AddCommand(migrateStatusCmd)
 		rootCmd.AddCommand(seedCmd)
 
-		// Workers (direct)
-		rootCmd.AddCommand(queueWorkCmd)
-		rootCmd.AddCommand(scheduleRunCmd)
 	} else {
 		// ── Project mode: delegate ALL runtime commands to the user's
 		// own main.go (which calls app.Run()) via `go run . <cmd>`.
 		// This ensures the project's own migrations, seeders and routes
-		// are properly registered.
+		// are properly registered. main() forwards the arguments verbatim
+		// (see passthrough); these definitions provide --help.
 		addProjectDelegateCmds(rootCmd)
+		// …and list the commands the project registers with app.Command.
+		rootCmd.SetHelpFunc(projectHelp(rootCmd.HelpFunc()))
 	}
 
+	// Workers — run directly in framework mode, delegate in a project.
+	rootCmd.AddCommand(queueWorkCmd)
+	rootCmd.AddCommand(queueFailedCmd)
+	rootCmd.AddCommand(queueRetryCmd)
+	rootCmd.AddCommand(quotaReportCmd)
+	rootCmd.AddCommand(scheduleWorkCmd)
+	rootCmd.AddCommand(scheduleRunCmd)
+	rootCmd.AddCommand(wsContractCmd)
+
+	// Destructive database commands — always delegated, audited by the project.
+	rootCmd.AddCommand(migrateFreshCmd)
+	rootCmd.AddCommand(dbWipeCmd)
+
+	// Debugging — read recordings/logs straight from storage, no delegation.
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(logTailCmd)
+	rootCmd.AddCommand(logPruneCmd)
+	runsLocally(replayCmd, logTailCmd, logPruneCmd)
+
+	// Maintenance — edits the project's go.mod and .env files, no delegation.
+	rootCmd.AddCommand(upgradeCmd)
+	rootCmd.AddCommand(appListCmd)
+	rootCmd.AddCommand(envEncryptCmd)
+	rootCmd.AddCommand(envDecryptCmd)
+	runsLocally(upgradeCmd, appListCmd, envEncryptCmd, envDecryptCmd)
+
 	// Scaffolding generators — always available, they only create files.
-	rootCmd.AddCommand(makeModelCmd)
-	rootCmd.AddCommand(makeControllerCmd)
-	rootCmd.AddCommand(makeServiceCmd)
-	rootCmd.AddCommand(makeMigrationCmd)
-	rootCmd.AddCommand(makeSeederCmd)
-	rootCmd.AddCommand(makeResourceCmd)
+	rootCmd.AddCommand(newCmd)
+	runsLocally(newCmd)
+	rootCmd.AddCommand(makeCmds()...)
+	runsLocally(makeCmds()...)
 }
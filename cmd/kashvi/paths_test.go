@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func touch(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindEntrypoint(t *testing.T) {
+	dir := t.TempDir()
+	if got := findEntrypoint(dir); got != "." {
+		t.Errorf("empty project: got %q, want the . fallback", got)
+	}
+
+	touch(t, filepath.Join(dir, "cmd", "app", "main.go"))
+	touch(t, filepath.Join(dir, "cmd", "server", "main.go"))
+	touch(t, filepath.Join(dir, "cmd", "server", "README.md"))
+	// Package paths use forward slashes whatever the OS separator is.
+	if got := findEntrypoint(dir); got != "./cmd/server" {
+		t.Errorf("got %q, want ./cmd/server", got)
+	}
+
+	touch(t, filepath.Join(dir, "main.go"))
+	if got := findEntrypoint(dir); got != "." {
+		t.Errorf("root main.go: got %q, want .", got)
+	}
+}
+
+func TestBinaryName(t *testing.T) {
+	cases := []struct{ goos, name, want string }{
+		{"linux", "kashvi", "kashvi"},
+		{"darwin", "kashvi", "kashvi"},
+		{"windows", "kashvi", "kashvi.exe"},
+		{"windows", "kashvi.exe", "kashvi.exe"},
+	}
+	for _, c := range cases {
+		if got := binaryName(c.goos, c.name); got != c.want {
+			t.Errorf("binaryName(%q, %q) = %q, want %q", c.goos, c.name, got, c.want)
+		}
+	}
+}
+
+func TestWriteStub(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := writeStub("app/models/user.go", "package models\n"); err != nil {
+		t.Fatal(err)
+	}
+	// Slash paths land in nested directories with the OS separator.
+	got, err := os.ReadFile(filepath.Join("app", "models", "user.go"))
+	if err != nil || string(got) != "package models\n" {
+		t.Fatalf("stub not written: %q, %v", got, err)
+	}
+
+	if err := writeStub("app/models/user.go", "overwritten"); err == nil {
+		t.Error("writeStub overwrote an existing file")
+	}
+	if got, _ := os.ReadFile(filepath.Join("app", "models", "user.go")); string(got) != "package models\n" {
+		t.Errorf("existing file changed to %q", got)
+	}
+}
@@ -9,16 +9,24 @@ import (
 	"text/template"
 )
 
-//go:embed stubs/*.stub
+//go:embed stubs/*.stub stubs/new/*.stub
 var defaultStubs embed.FS
 
 // StubData holds variables passed to the .stub templates
 type StubData struct {
 	Name       string
 	Lower      string
-	StructName string // e.g. M_202301010000_create_users_table
-	Authorize  bool   // Add Auth middleware/behavior
-	Cache      bool   // Add Cache middleware/behavior
+	StructName string  // e.g. M_202301010000_create_users_table
+	Authorize  bool    // Add Auth middleware/behavior
+	Cache      bool    // Add Cache middleware/behavior
+	Table      string  // e.g. users
+	Module     string  // the project's module path, from go.mod
+	Fields     []Field // columns from make:model --fields
+	UsesTime   bool    // some field needs the time import
+	GoVersion  string  // kashvi new: the go.mod go directive
+	Secret     string  // kashvi new: JWT_SECRET written to .env
+	Event      string  // make:listener: the event type it handles
+	Queued     bool    // make:listener: run on a queue worker
 }
 
 // renderStub locates the stub (user override first, embedded fallback)
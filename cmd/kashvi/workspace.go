@@ -0,0 +1,227 @@
+package main
+
+// workspace.go selects the service a command runs against in a monorepo.
+//
+// A workspace is a directory tree with several Kashvi services, described by
+// a kashvi.yaml at its root:
+//
+//	default: orders
+//	apps:
+//	  orders:
+//	    path: services/orders
+//	  billing:
+//	    path: services/billing
+//	    main: ./cmd/api
+//
+// `kashvi --app=orders serve` (or --app=services/orders) runs serve in that
+// service's directory, from anywhere in the workspace.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// workspaceFile is the name of the workspace manifest.
+const workspaceFile = "kashvi.yaml"
+
+// workspace is the content of kashvi.yaml.
+type workspace struct {
+	Default string                  `yaml:"default"`
+	Apps    map[string]workspaceApp `yaml:"apps"`
+}
+
+// workspaceApp is one service of a workspace.
+type workspaceApp struct {
+	// Path is the service's module directory, relative to kashvi.yaml.
+	Path string `yaml:"path"`
+	// Main is the package `go run` starts, relative to Path; empty means
+	// findEntrypoint decides.
+	Main string `yaml:"main"`
+}
+
+// mainPackage is Main as a `go run` package path ("./cmd/api"), or "".
+func (a workspaceApp) mainPackage() string {
+	if a.Main == "" {
+		return ""
+	}
+	return "./" + strings.TrimPrefix(filepath.ToSlash(filepath.Clean(a.Main)), "./")
+}
+
+// appMain is the selected app's main package, if kashvi.yaml names one.
+var appMain string
+
+// entrypoint is the package to `go run` in cwd: the selected app's main
+// package, or findEntrypoint's guess.
+func entrypoint(cwd string) string {
+	if appMain != "" {
+		return appMain
+	}
+	return findEntrypoint(cwd)
+}
+
+// selectApp handles a leading --app flag and kashvi.yaml's default app by
+// changing into the app's directory, so every command — delegated or local —
+// works on that service. It returns the arguments after --app.
+func selectApp(args []string) ([]string, error) {
+	name, args, err := appFlag(args)
+	if err != nil {
+		return nil, err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	ws, root, err := findWorkspace(cwd)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		if len(args) > 0 && args[0] == "new" {
+			return args, nil // kashvi new creates the project where it is run
+		}
+		if ws == nil || ws.Default == "" || cwd != root {
+			return args, nil // not at a workspace root: cwd is the project
+		}
+		name = ws.Default
+	}
+
+	dir, app, err := resolveApp(ws, root, cwd, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return nil, fmt.Errorf("--app: %w", err)
+	}
+	appMain = app.mainPackage()
+	return args, nil
+}
+
+// appFlag takes --app NAME or --app=NAME off the front of args.
+func appFlag(args []string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", args, nil
+	}
+	if v, ok := strings.CutPrefix(args[0], "--app="); ok {
+		if v == "" {
+			return "", nil, fmt.Errorf("--app needs an app name or directory")
+		}
+		return v, args[1:], nil
+	}
+	if args[0] == "--app" {
+		if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+			return "", nil, fmt.Errorf("--app needs an app name or directory")
+		}
+		return args[1], args[2:], nil
+	}
+	return "", args, nil
+}
+
+// findWorkspace looks for kashvi.yaml in dir and its parents. It returns a
+// nil workspace when there is none.
+func findWorkspace(dir string) (*workspace, string, error) {
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, workspaceFile))
+		if err == nil {
+			var ws workspace
+			if err := yaml.Unmarshal(data, &ws); err != nil {
+				return nil, "", fmt.Errorf("%s: %w", filepath.Join(dir, workspaceFile), err)
+			}
+			if ws.Default != "" {
+				if _, ok := ws.Apps[ws.Default]; !ok {
+					return nil, "", fmt.Errorf("%s: default app %q is not listed under apps",
+						filepath.Join(dir, workspaceFile), ws.Default)
+				}
+			}
+			return &ws, dir, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, "", nil
+		}
+		dir = parent
+	}
+}
+
+// resolveApp finds the directory of name: an app listed in kashvi.yaml, or
+// else a directory relative to cwd. A directory that is a listed app's path
+// picks up that app's main package.
+func resolveApp(ws *workspace, root, cwd, name string) (string, workspaceApp, error) {
+	if ws != nil {
+		if app, ok := ws.Apps[name]; ok {
+			return filepath.Join(root, filepath.FromSlash(app.Path)), app, nil
+		}
+	}
+	dir := filepath.Join(cwd, filepath.FromSlash(name))
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		if ws != nil {
+			return "", workspaceApp{}, fmt.Errorf("--app: %q is neither an app in %s (%s) nor a directory",
+				name, filepath.Join(root, workspaceFile), strings.Join(ws.names(), ", "))
+		}
+		return "", workspaceApp{}, fmt.Errorf("--app: %q is not a directory", name)
+	}
+	if ws != nil {
+		for _, app := range ws.Apps {
+			if filepath.Join(root, filepath.FromSlash(app.Path)) == dir {
+				return dir, app, nil
+			}
+		}
+	}
+	return dir, workspaceApp{}, nil
+}
+
+// names returns the workspace's app names, sorted.
+func (ws *workspace) names() []string {
+	names := make([]string, 0, len(ws.Apps))
+	for name := range ws.Apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// kashvi app:list
+var appListCmd = &cobra.Command{
+	Use:   "app:list",
+	Short: "List the apps in the workspace's kashvi.yaml",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		ws, root, err := findWorkspace(cwd)
+		if err != nil {
+			return err
+		}
+		if ws == nil {
+			fmt.Printf("No %s found in this directory or its parents.\n", workspaceFile)
+			return nil
+		}
+		fmt.Printf("Workspace: %s\n\n", root)
+		for _, name := range ws.names() {
+			app := ws.Apps[name]
+			marker := " "
+			if name == ws.Default {
+				marker = "*"
+			}
+			main := app.mainPackage()
+			if main == "" {
+				main = findEntrypoint(filepath.Join(root, filepath.FromSlash(app.Path)))
+			}
+			fmt.Printf("%s %-16s %-32s main: %s\n", marker, name, app.Path, main)
+		}
+		if ws.Default != "" {
+			fmt.Println("\n* default app, used when kashvi runs at the workspace root")
+		}
+		return nil
+	},
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAppFlag(t *testing.T) {
+	cases := []struct {
+		args []string
+		name string
+		rest []string
+	}{
+		{[]string{"--app=orders", "serve"}, "orders", []string{"serve"}},
+		{[]string{"--app", "services/orders", "migrate", "--step=2"}, "services/orders", []string{"migrate", "--step=2"}},
+		// --app after the command belongs to the command.
+		{[]string{"serve", "--app=orders"}, "", []string{"serve", "--app=orders"}},
+	}
+	for _, c := range cases {
+		name, rest, err := appFlag(c.args)
+		if err != nil || name != c.name || !reflect.DeepEqual(rest, c.rest) {
+			t.Errorf("appFlag(%q) = %q, %q, %v; want %q, %q", c.args, name, rest, err, c.name, c.rest)
+		}
+	}
+	for _, args := range [][]string{{"--app="}, {"--app"}, {"--app", "--help"}} {
+		if _, _, err := appFlag(args); err == nil {
+			t.Errorf("appFlag(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestSelectApp(t *testing.T) {
+	root, _ := filepath.EvalSymlinks(t.TempDir()) // cwd is compared as the OS reports it
+	touch(t, filepath.Join(root, "services", "orders", "main.go"))
+	touch(t, filepath.Join(root, "services", "billing", "cmd", "api", "main.go"))
+	if err := os.WriteFile(filepath.Join(root, workspaceFile), []byte(`
+default: orders
+apps:
+  orders:
+    path: services/orders
+  billing:
+    path: services/billing
+    main: cmd/api
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { appMain = "" })
+
+	cases := []struct {
+		cwd, flag string
+		wantDir   string
+		wantMain  string
+	}{
+		{".", "", "services/orders", ""},                               // default app at the root
+		{"services", "--app=billing", "services/billing", "./cmd/api"}, // by name, from below the root
+		{".", "--app=services/billing", "services/billing", "./cmd/api"},
+		{"services/orders", "", "services/orders", ""}, // inside an app: left alone
+	}
+	for _, c := range cases {
+		t.Chdir(filepath.Join(root, filepath.FromSlash(c.cwd)))
+		appMain = ""
+		args := []string{"serve"}
+		if c.flag != "" {
+			args = append([]string{c.flag}, args...)
+		}
+		rest, err := selectApp(args)
+		if err != nil {
+			t.Fatalf("%s %v: %v", c.cwd, args, err)
+		}
+		cwd, _ := os.Getwd()
+		if want := filepath.Join(root, filepath.FromSlash(c.wantDir)); cwd != want || appMain != c.wantMain ||
+			!reflect.DeepEqual(rest, []string{"serve"}) {
+			t.Errorf("%s %v: cwd %s, main %q, args %q; want %s, %q", c.cwd, args, cwd, appMain, rest, want, c.wantMain)
+		}
+	}
+
+	t.Chdir(root)
+	if _, err := selectApp([]string{"--app=payments", "serve"}); err == nil {
+		t.Error("unknown app: want an error")
+	}
+}
@@ -0,0 +1,193 @@
+package config_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/config"
+)
+
+func writeFile(t *testing.T, name, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadLayersAndTypedGetters(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFile(t, "config/app.json", `{
+		"app_env": "production",
+		"mail": {"smtp": {"host": "smtp.local", "port": 25, "tls": true}},
+		"cors_origins": ["https://a.test", "https://b.test"],
+		"timeout": "5s"
+	}`)
+	writeFile(t, ".env", "MAIL_SMTP_HOST=smtp.env\nRETRIES=3\n")
+	writeFile(t, ".env.production", "MAIL_SMTP_PORT=587\n")
+	if err := config.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := config.Get("mail.smtp.host", ""); got != "smtp.env" {
+		t.Errorf("mail.smtp.host = %q, want .env to win over app.json", got)
+	}
+	if got := config.GetInt("mail.smtp.port", 0); got != 587 {
+		t.Errorf("mail.smtp.port = %d, want .env.production to win", got)
+	}
+	if !config.GetBool("MAIL_SMTP_TLS", false) {
+		t.Error("MAIL_SMTP_TLS should be true")
+	}
+	if got := config.GetDuration("timeout", 0); got != 5*time.Second {
+		t.Errorf("timeout = %v", got)
+	}
+	if got := config.GetStringSlice("cors_origins", nil); !reflect.DeepEqual(got, []string{"https://a.test", "https://b.test"}) {
+		t.Errorf("cors_origins = %q", got)
+	}
+	if got := config.GetInt("retries", 0); got != 3 {
+		t.Errorf("retries = %d", got)
+	}
+	if config.GetInt("missing", 7) != 7 || config.GetBool("timeout", true) != true || config.GetDuration("retries", time.Minute) != time.Minute {
+		t.Error("unset or unparsable keys should return the fallback")
+	}
+}
+
+func TestReloadNotifiesAndWatch(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFile(t, ".env", "FEATURE_X=off\n")
+	if err := config.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan []string, 1)
+	config.OnChange(func(changed []string) {
+		select {
+		case changes <- changed:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := config.Watch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, ".env", "FEATURE_X=on\n")
+
+	select {
+	case changed := <-changes:
+		if !reflect.DeepEqual(changed, []string{"FEATURE_X"}) {
+			t.Errorf("changed = %q, want [FEATURE_X]", changed)
+		}
+		if !config.GetBool("feature_x", false) {
+			t.Error("FEATURE_X should be on after the reload")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload after .env changed")
+	}
+}
+
+func TestEncryptedValues(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("APP_KEY", "test-app-key")
+	enc, err := config.EncryptValue("s3cret")
+	if err != nil || !config.IsEncrypted(enc) {
+		t.Fatalf("EncryptValue = %q, %v", enc, err)
+	}
+	writeFile(t, ".env", "DB_PASSWORD="+enc+"\n")
+	if err := config.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Get("DB_PASSWORD", ""); got != "s3cret" {
+		t.Errorf("DB_PASSWORD = %q, want it decrypted at load", got)
+	}
+
+	// A wrong key fails the load and keeps the previous values.
+	t.Setenv("APP_KEY", "another-key")
+	if err := config.Reload(); err == nil || !strings.Contains(err.Error(), "DB_PASSWORD") {
+		t.Errorf("Reload with the wrong key: %v, want an error naming DB_PASSWORD", err)
+	}
+	if got := config.Get("DB_PASSWORD", ""); got != "s3cret" {
+		t.Errorf("DB_PASSWORD = %q after a failed reload", got)
+	}
+}
+
+type mapProvider map[string]string
+
+func (p mapProvider) Load(context.Context) (map[string]string, error) { return p, nil }
+
+func TestProviderMergesOverFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	kv := mapProvider{"db.password": "from-provider", "FEATURE_Y": "on"}
+	config.RegisterProvider("test-map", func(get config.Lookup) (config.Provider, error) {
+		if get("TEST_MAP_REGION", "") != "eu" {
+			t.Errorf("the factory should see the .env settings")
+		}
+		return kv, nil
+	})
+	writeFile(t, ".env", "CONFIG_PROVIDER=test-map\nTEST_MAP_REGION=eu\nDB_PASSWORD=from-env\nDB_USER=app\n")
+	if err := config.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Get("DB_PASSWORD", ""); got != "from-provider" {
+		t.Errorf("DB_PASSWORD = %q, want the provider to win", got)
+	}
+	if config.Get("DB_USER", "") != "app" || !config.GetBool("feature_y", false) {
+		t.Error("file values and provider-only keys should both be present")
+	}
+
+	writeFile(t, ".env", "CONFIG_PROVIDER=nope\n")
+	if err := config.Reload(); err == nil || !strings.Contains(err.Error(), `unknown CONFIG_PROVIDER "nope"`) {
+		t.Errorf("Reload with an unknown provider: %v", err)
+	}
+}
+
+func TestVaultAndConsulProviders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/secret/data/shop" && r.Header.Get("X-Vault-Token") == "tok":
+			w.Write([]byte(`{"data": {"data": {"stripe_key": "sk_live", "mail": {"port": 587}}, "metadata": {"version": 3}}}`))
+		case r.URL.Path == "/v1/kv/shop/" && r.URL.Query().Has("recurse"):
+			w.Write([]byte(`[{"Key": "shop/", "Value": null}, {"Key": "shop/cache/ttl", "Value": "` +
+				base64.StdEncoding.EncodeToString([]byte("5m")) + `"}]`))
+		default:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+	t.Chdir(t.TempDir())
+
+	writeFile(t, ".env", "CONFIG_PROVIDER=vault\nVAULT_ADDR="+srv.URL+"\nVAULT_TOKEN=tok\nVAULT_PATH=secret/data/shop\n")
+	if err := config.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if config.Get("STRIPE_KEY", "") != "sk_live" || config.GetInt("mail.port", 0) != 587 {
+		t.Errorf("vault values: STRIPE_KEY=%q MAIL_PORT=%q", config.Get("STRIPE_KEY", ""), config.Get("MAIL_PORT", ""))
+	}
+
+	writeFile(t, ".env", "CONFIG_PROVIDER=consul\nCONSUL_HTTP_ADDR="+srv.URL+"\nCONSUL_PREFIX=shop/\n")
+	if err := config.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := config.GetDuration("cache.ttl", 0); got != 5*time.Minute {
+		t.Errorf("CACHE_TTL = %v", got)
+	}
+
+	writeFile(t, ".env", "CONFIG_PROVIDER=vault\nVAULT_ADDR="+srv.URL+"\nVAULT_TOKEN=bad\nVAULT_PATH=secret/data/shop\n")
+	if err := config.Reload(); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Reload with a rejected token: %v", err)
+	}
+	if config.Get("CACHE_TTL", "") != "5m" {
+		t.Error("a failed provider load should keep the previous values")
+	}
+}
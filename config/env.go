@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -19,23 +22,83 @@ const (
 	defaultJWTSecret      = "change-me-in-production"
 	defaultAppPort        = "8080"
 	defaultAppEnv         = "local"
+	defaultAppName        = "kashvi"
+)
+
+// The files Load reads, in increasing order of precedence; .env.<APP_ENV>
+// (e.g. .env.production) is read after envFile.
+const (
+	configFile = "config/app.json"
+	envFile    = ".env"
 )
 
 var (
 	loadOnce sync.Once
 	loadErr  error
 
-	mu     sync.RWMutex
-	values = defaultValues()
+	mu          sync.RWMutex
+	values      = defaultValues()
+	defaults    = map[string]string{} // registered with SetDefaults
+	subscribers []func(changed []string)
 )
 
 func Load() error {
 	loadOnce.Do(func() {
-		loadErr = loadFromFiles("config/app.json", ".env")
+		loadErr = loadFromFiles(configFile, envFile)
 	})
 	return loadErr
 }
 
+// Reload re-reads the config files and tells OnChange subscribers which
+// keys changed. On error the previous values stay in place.
+func Reload() error {
+	mu.RLock()
+	old := values
+	mu.RUnlock()
+
+	if err := loadFromFiles(configFile, envFile); err != nil {
+		return err
+	}
+	loadOnce.Do(func() {}) // a later Load must not re-read over this
+
+	mu.RLock()
+	changed := changedKeys(old, values)
+	subs := append([]func([]string){}, subscribers...)
+	mu.RUnlock()
+
+	if len(changed) > 0 {
+		for _, fn := range subs {
+			fn(changed)
+		}
+	}
+	return nil
+}
+
+// OnChange registers fn to run after Reload (or Watch) changes any values.
+// changed holds the affected keys, sorted, in their normalised form
+// (MAIL_SMTP_PORT).
+func OnChange(fn func(changed []string)) {
+	mu.Lock()
+	defer mu.Unlock()
+	subscribers = append(subscribers, fn)
+}
+
+func changedKeys(old, cur map[string]string) []string {
+	var changed []string
+	for k, v := range cur {
+		if old[k] != v {
+			changed = append(changed, k)
+		}
+	}
+	for k := range old {
+		if _, ok := cur[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
 func DatabaseDriver() string {
 	_ = Load()
 
@@ -43,6 +106,8 @@ func DatabaseDriver() string {
 	switch driver {
 	case "sqlite", "postgres", "mysql", "sqlserver":
 		return driver
+	case sqliteMemoryDriver:
+		return "sqlite"
 	default:
 		return defaultDatabaseDriver
 	}
@@ -51,6 +116,10 @@ func DatabaseDriver() string {
 func DatabaseDSN() string {
 	_ = Load()
 
+	if DatabaseInMemory() {
+		return SQLiteMemoryDSN("kashvi")
+	}
+
 	override := get("DATABASE_DSN", "")
 	if override != "" {
 		return override
@@ -68,6 +137,59 @@ func DatabaseDSN() string {
 	}
 }
 
+// DatabaseConnection returns the driver and DSN of a named connection.
+// "primary" (or "") is the default DB_DRIVER/DATABASE_DSN connection; any
+// other name reads DB_<NAME>_DRIVER (defaulting to DB_DRIVER) and
+// DB_<NAME>_DSN. ok is false when the connection has no DSN configured.
+func DatabaseConnection(name string) (driver, dsn string, ok bool) {
+	_ = Load()
+
+	if name == "" || name == "primary" {
+		return DatabaseDriver(), DatabaseDSN(), true
+	}
+	prefix := "DB_" + strings.ToUpper(name) + "_"
+	dsn = get(prefix+"DSN", "")
+	if dsn == "" {
+		return "", "", false
+	}
+	driver = strings.ToLower(get(prefix+"DRIVER", DatabaseDriver()))
+	return driver, dsn, true
+}
+
+// sqliteMemoryDriver is the DB_DRIVER value that selects a throwaway
+// in-memory SQLite database (handy for tests and demos).
+const sqliteMemoryDriver = "sqlite::memory:"
+
+// DatabaseInMemory reports whether DB_DRIVER=sqlite::memory: was requested.
+func DatabaseInMemory() bool {
+	_ = Load()
+	return strings.ToLower(get("DB_DRIVER", defaultDatabaseDriver)) == sqliteMemoryDriver
+}
+
+// SQLiteMemoryDSN returns the DSN of a named, shared-cache in-memory SQLite
+// database. Distinct names give fully isolated databases.
+func SQLiteMemoryDSN(name string) string {
+	return "file:" + name + "?mode=memory&cache=shared"
+}
+
+// ── SQLite tuning ─────────────────────────────────────────────────────────────
+
+// SQLiteJournalMode returns the journal mode applied to file databases (default WAL).
+func SQLiteJournalMode() string { _ = Load(); return get("SQLITE_JOURNAL_MODE", "WAL") }
+
+// SQLiteBusyTimeout returns how long (ms) SQLite waits on a locked database.
+func SQLiteBusyTimeout() string { _ = Load(); return get("SQLITE_BUSY_TIMEOUT", "5000") }
+
+// SQLiteSynchronous returns the synchronous pragma (default NORMAL, safe with WAL).
+func SQLiteSynchronous() string { _ = Load(); return get("SQLITE_SYNCHRONOUS", "NORMAL") }
+
+// SQLiteForeignKeys reports whether foreign key enforcement is enabled (default true).
+func SQLiteForeignKeys() bool {
+	_ = Load()
+	v := strings.ToLower(get("SQLITE_FOREIGN_KEYS", "true"))
+	return v != "false" && v != "0" && v != "off"
+}
+
 func RedisAddr() string {
 	_ = Load()
 	return get("REDIS_ADDR", defaultRedisAddr)
@@ -81,6 +203,7 @@ func defaultValues() map[string]string {
 		"JWT_SECRET":     defaultJWTSecret,
 		"APP_PORT":       defaultAppPort,
 		"APP_ENV":        defaultAppEnv,
+		"APP_NAME":       defaultAppName,
 		"REDIS_PASSWORD": "",
 	}
 }
@@ -100,6 +223,13 @@ func AppEnv() string {
 	return get("APP_ENV", defaultAppEnv)
 }
 
+// AppName is the application's name (APP_NAME), which destructive CLI
+// commands ask the operator to type before running in production.
+func AppName() string {
+	_ = Load()
+	return get("APP_NAME", defaultAppName)
+}
+
 func RedisPassword() string {
 	_ = Load()
 	return get("REDIS_PASSWORD", "")
@@ -140,6 +270,59 @@ func MongoLogDB() string { _ = Load(); return get("MONGO_LOG_DB", "kashvi_logs")
 // MongoLogCollection returns the collection name used for application logs.
 func MongoLogCollection() string { _ = Load(); return get("MONGO_LOG_COLLECTION", "app_logs") }
 
+// ── Analytics ─────────────────────────────────────────────────────────────────
+
+// AnalyticsDriver returns the analytics sink: "clickhouse", "log" or "none" (default).
+func AnalyticsDriver() string { _ = Load(); return strings.ToLower(get("ANALYTICS_DRIVER", "none")) }
+
+// ClickHouseURL returns the base URL of the ClickHouse HTTP interface.
+func ClickHouseURL() string { _ = Load(); return get("CLICKHOUSE_URL", "http://localhost:8123") }
+
+// ClickHouseDatabase returns the ClickHouse database analytics are written to.
+func ClickHouseDatabase() string { _ = Load(); return get("CLICKHOUSE_DATABASE", "default") }
+
+// ClickHouseUser returns the ClickHouse user name.
+func ClickHouseUser() string { _ = Load(); return get("CLICKHOUSE_USER", "default") }
+
+// ClickHousePassword returns the ClickHouse password.
+func ClickHousePassword() string { _ = Load(); return get("CLICKHOUSE_PASSWORD", "") }
+
+// AnalyticsTable returns the table events are inserted into.
+func AnalyticsTable() string { _ = Load(); return get("ANALYTICS_TABLE", "events") }
+
+// AnalyticsBatchSize returns how many events are buffered before a flush.
+func AnalyticsBatchSize() int { return positiveInt("ANALYTICS_BATCH_SIZE", 500) }
+
+// AnalyticsFlushInterval returns the maximum time events wait before a flush.
+func AnalyticsFlushInterval() time.Duration {
+	return time.Duration(positiveInt("ANALYTICS_FLUSH_MS", 2000)) * time.Millisecond
+}
+
+// AnalyticsBufferSize returns the capacity of the in-process event buffer.
+// Events tracked while it is full are dropped rather than blocking requests.
+func AnalyticsBufferSize() int { return positiveInt("ANALYTICS_BUFFER", 10000) }
+
+// LogRetentionDays returns how long Mongo log records are kept (0 = forever).
+func LogRetentionDays() int {
+	_ = Load()
+	n, err := strconv.Atoi(get("LOG_RETENTION_DAYS", "0"))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// MongoLogCappedMB returns the size of the capped log collection in MB
+// (0 = regular collection).
+func MongoLogCappedMB() int {
+	_ = Load()
+	n, err := strconv.Atoi(get("MONGO_LOG_CAPPED_MB", "0"))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
 // ── gRPC ──────────────────────────────────────────────────────────────────────
 
 // GRPCPort returns the port the gRPC server listens on.
@@ -171,8 +354,26 @@ func RateLimitMax() int {
 	return n
 }
 
+// positiveInt reads key as an int, falling back to def when unset or invalid.
+func positiveInt(key string, def int) int {
+	_ = Load()
+	n := def
+	fmt.Sscanf(get(key, strconv.Itoa(def)), "%d", &n) //nolint:errcheck
+	if n <= 0 {
+		n = def
+	}
+	return n
+}
+
 func loadFromFiles(configPath, envPath string) error {
 	loaded := defaultValues()
+	mu.RLock()
+	for k, v := range defaults {
+		if strings.TrimSpace(loaded[k]) == "" {
+			loaded[k] = v
+		}
+	}
+	mu.RUnlock()
 
 	if err := mergeJSONConfig(configPath, loaded); err != nil {
 		if !os.IsNotExist(err) {
@@ -186,6 +387,28 @@ func loadFromFiles(configPath, envPath string) error {
 		}
 	}
 
+	if env := strings.TrimSpace(loaded["APP_ENV"]); env != "" {
+		if err := mergeDotEnv(envPath+"."+env, loaded); err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	// CONFIG_PROVIDER (Vault, Consul, AWS, …) wins over the files.
+	if err := mergeProvider(loaded); err != nil {
+		return err
+	}
+
+	// APP_KEY may come from the process environment instead, so the key
+	// that decrypts ENC(...) values does not ship alongside them.
+	if key := os.Getenv("APP_KEY"); key != "" {
+		loaded["APP_KEY"] = key
+	}
+	if err := decryptValues(loaded); err != nil {
+		return err
+	}
+
 	mu.Lock()
 	values = loaded
 	mu.Unlock()
@@ -205,20 +428,58 @@ func mergeJSONConfig(path string, out map[string]string) error {
 		return fmt.Errorf("decode %s: %w", path, err)
 	}
 
+	flattenJSON("", raw, out)
+	return nil
+}
+
+// flattenJSON stores nested objects under joined keys, so
+// {"mail": {"smtp": {"port": 587}}} becomes MAIL_SMTP_PORT=587. Numbers and
+// booleans are stored as text and arrays of scalars as comma-separated lists.
+func flattenJSON(prefix string, raw map[string]interface{}, out map[string]string) {
 	for key, val := range raw {
-		s, ok := val.(string)
-		if !ok {
+		k := normalizeKey(key)
+		if k == "" {
 			continue
 		}
-
-		k := strings.ToUpper(strings.TrimSpace(key))
-		if k == "" {
+		if prefix != "" {
+			k = prefix + "_" + k
+		}
+		if nested, ok := val.(map[string]interface{}); ok {
+			flattenJSON(k, nested, out)
 			continue
 		}
-		out[k] = strings.TrimSpace(s)
+		if list, ok := val.([]interface{}); ok {
+			items := make([]string, 0, len(list))
+			for _, item := range list {
+				if s, ok := scalarString(item); ok {
+					items = append(items, s)
+				}
+			}
+			out[k] = strings.Join(items, ",")
+			continue
+		}
+		if s, ok := scalarString(val); ok {
+			out[k] = s
+		}
+	}
+}
+
+func scalarString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
 	}
+	return "", false
+}
 
-	return nil
+// normalizeKey maps a key in any accepted spelling to the stored form:
+// "mail.smtp.port", "mail_smtp_port" and "MAIL_SMTP_PORT" are the same key.
+func normalizeKey(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(key), ".", "_"))
 }
 
 func mergeDotEnv(path string, out map[string]string) error {
@@ -240,7 +501,7 @@ func mergeDotEnv(path string, out map[string]string) error {
 			continue
 		}
 
-		key := strings.ToUpper(strings.TrimSpace(line[:idx]))
+		key := normalizeKey(line[:idx])
 		value := strings.TrimSpace(line[idx+1:])
 		value = strings.Trim(value, `"'`)
 		if key == "" {
@@ -267,9 +528,74 @@ func get(key, fallback string) string {
 	return fallback
 }
 
+// SetDefaults registers fallback values for config keys, such as the
+// settings a plugin needs. Values from app.json and .env still win, and keys
+// that already have a value keep it.
+func SetDefaults(kv map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for k, v := range kv {
+		k = normalizeKey(k)
+		defaults[k] = v
+		if strings.TrimSpace(values[k]) == "" {
+			values[k] = v
+		}
+	}
+}
+
 // Get reads any config key by name with an optional fallback.
-// Keys from .env and app.json are available after config.Load().
+// Keys from .env and app.json are available after config.Load(). Nested
+// app.json keys can be read with dots: Get("mail.smtp.port", "25").
 func Get(key, fallback string) string {
 	_ = Load()
-	return get(key, fallback)
+	return get(normalizeKey(key), fallback)
+}
+
+// GetInt reads key as an integer, returning fallback when it is unset or
+// not a number.
+func GetInt(key string, fallback int) int {
+	n, err := strconv.Atoi(Get(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// GetBool reads key as a boolean: true/1/yes/on or false/0/no/off, case
+// insensitive. Anything else returns fallback.
+func GetBool(key string, fallback bool) bool {
+	switch strings.ToLower(Get(key, "")) {
+	case "true", "1", "yes", "on":
+		return true
+	case "false", "0", "no", "off":
+		return false
+	}
+	return fallback
+}
+
+// GetDuration reads key as a Go duration ("30s", "5m", "1h30m"), returning
+// fallback when it is unset or invalid.
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(Get(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+// GetStringSlice reads key as a comma-separated list, trimming items and
+// dropping empty ones. A JSON array in app.json reads the same way. It
+// returns fallback when the key is unset.
+func GetStringSlice(key string, fallback []string) []string {
+	v := Get(key, "")
+	if v == "" {
+		return fallback
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
 }
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ─── Providers ────────────────────────────────────────────────────────────────
+
+// Provider supplies config values from an external store such as Vault,
+// AWS SSM Parameter Store, AWS Secrets Manager or Consul. CONFIG_PROVIDER
+// names the one to use; its values are merged over config/app.json and the
+// .env files when the config loads, and again every CONFIG_PROVIDER_REFRESH
+// while RefreshProvider runs.
+type Provider interface {
+	// Load returns the provider's values. Keys are normalised like any other
+	// (mail.smtp.port and MAIL_SMTP_PORT are the same key).
+	Load(ctx context.Context) (map[string]string, error)
+}
+
+// Lookup reads a config key from the files loaded so far, so a provider
+// factory can find its own settings (VAULT_ADDR, …).
+type Lookup func(key, fallback string) string
+
+// ProviderFactory builds a provider from the file and .env settings.
+type ProviderFactory func(get Lookup) (Provider, error)
+
+var (
+	providerMu sync.Mutex
+	factories  = map[string]ProviderFactory{
+		"vault":       newVaultProvider,
+		"consul":      newConsulProvider,
+		"aws-ssm":     newSSMProvider,
+		"aws-secrets": newSecretsManagerProvider,
+	}
+	// providerOn and providerRefresh record the last load's settings.
+	providerOn      bool
+	providerRefresh time.Duration
+)
+
+// RegisterProvider makes a custom provider available as CONFIG_PROVIDER=name.
+// Register it before the config loads, e.g. in an init function or before
+// app.New(). It replaces a built-in provider of the same name.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	factories[strings.ToLower(name)] = factory
+}
+
+// mergeProvider loads the CONFIG_PROVIDER values into loaded. It does
+// nothing when no provider is configured. The provider is built afresh on
+// every load, so a reload picks up changed credentials.
+func mergeProvider(loaded map[string]string) error {
+	get := func(key, fallback string) string {
+		if v := strings.TrimSpace(loaded[normalizeKey(key)]); v != "" {
+			return v
+		}
+		// Provider credentials are often injected by the platform.
+		if v := strings.TrimSpace(os.Getenv(normalizeKey(key))); v != "" {
+			return v
+		}
+		return fallback
+	}
+	name := strings.ToLower(get("CONFIG_PROVIDER", ""))
+	refresh, _ := time.ParseDuration(get("CONFIG_PROVIDER_REFRESH", ""))
+	providerMu.Lock()
+	providerOn, providerRefresh = name != "", refresh
+	providerMu.Unlock()
+	if name == "" {
+		return nil
+	}
+
+	p, err := newProvider(name, get)
+	if err != nil {
+		return err
+	}
+	timeout := 10 * time.Second
+	if d, err := time.ParseDuration(get("CONFIG_PROVIDER_TIMEOUT", "")); err == nil && d > 0 {
+		timeout = d
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	kv, err := p.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("provider %s: %w", name, err)
+	}
+	for k, v := range kv {
+		if k = normalizeKey(k); k != "" {
+			loaded[k] = strings.TrimSpace(v)
+		}
+	}
+	return nil
+}
+
+func newProvider(name string, get Lookup) (Provider, error) {
+	providerMu.Lock()
+	factory, ok := factories[name]
+	names := make([]string, 0, len(factories))
+	for n := range factories {
+		names = append(names, n)
+	}
+	providerMu.Unlock()
+	if !ok {
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown CONFIG_PROVIDER %q (have %s)", name, strings.Join(names, ", "))
+	}
+	p, err := factory(get)
+	if err != nil {
+		return nil, fmt.Errorf("provider %s: %w", name, err)
+	}
+	return p, nil
+}
+
+// RefreshProvider reloads the config every CONFIG_PROVIDER_REFRESH (e.g.
+// "5m") until ctx is done, so rotated secrets reach OnChange subscribers.
+// It returns at once when no provider or interval is configured. A failed
+// refresh is reported on stderr and the previous values stay in place.
+func RefreshProvider(ctx context.Context) {
+	_ = Load()
+	providerMu.Lock()
+	active, interval := providerOn, providerRefresh
+	providerMu.Unlock()
+	if !active || interval <= 0 {
+		return
+	}
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := Reload(); err != nil {
+					fmt.Fprintln(os.Stderr, "config: refresh:", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awscfg "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// The AWS providers use the SDK's default credential chain (environment,
+// shared profile, instance or task role); AWS_REGION picks the region.
+
+func awsConfig(get Lookup) (aws.Config, error) {
+	var opts []func(*awscfg.LoadOptions) error
+	if region := get("AWS_REGION", ""); region != "" {
+		opts = append(opts, awscfg.WithRegion(region))
+	}
+	cfg, err := awscfg.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("load AWS config: %w", err)
+	}
+	return cfg, nil
+}
+
+// ─── SSM Parameter Store ──────────────────────────────────────────────────────
+
+// ssmProvider reads every parameter under a path, decrypting SecureStrings;
+// /myapp/prod/mail/port under path /myapp/prod/ becomes MAIL_PORT.
+type ssmProvider struct {
+	client *ssm.Client
+	path   string
+}
+
+func newSSMProvider(get Lookup) (Provider, error) {
+	path := get("AWS_SSM_PATH", "")
+	if path == "" {
+		return nil, errors.New("AWS_SSM_PATH is not set (e.g. /myapp/production/)")
+	}
+	cfg, err := awsConfig(get)
+	if err != nil {
+		return nil, err
+	}
+	return &ssmProvider{client: ssm.NewFromConfig(cfg), path: "/" + strings.Trim(path, "/")}, nil
+}
+
+func (p *ssmProvider) Load(ctx context.Context) (map[string]string, error) {
+	out := map[string]string{}
+	pages := ssm.NewGetParametersByPathPaginator(p.client, &ssm.GetParametersByPathInput{
+		Path:           aws.String(p.path),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range page.Parameters {
+			key := strings.Trim(strings.TrimPrefix(aws.ToString(param.Name), p.path), "/")
+			if key != "" {
+				out[strings.ReplaceAll(key, "/", "_")] = aws.ToString(param.Value)
+			}
+		}
+	}
+	return out, nil
+}
+
+// ─── Secrets Manager ──────────────────────────────────────────────────────────
+
+// secretsManagerProvider reads one secret whose value is a JSON object of
+// config keys, as the Secrets Manager console stores key/value secrets.
+type secretsManagerProvider struct {
+	client *secretsmanager.Client
+	id     string
+}
+
+func newSecretsManagerProvider(get Lookup) (Provider, error) {
+	id := get("AWS_SECRET_ID", "")
+	if id == "" {
+		return nil, errors.New("AWS_SECRET_ID is not set (a secret name or ARN)")
+	}
+	cfg, err := awsConfig(get)
+	if err != nil {
+		return nil, err
+	}
+	return &secretsManagerProvider{client: secretsmanager.NewFromConfig(cfg), id: id}, nil
+}
+
+func (p *secretsManagerProvider) Load(ctx context.Context) (map[string]string, error) {
+	res, err := p.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(p.id)})
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(aws.ToString(res.SecretString)), &raw); err != nil {
+		return nil, fmt.Errorf("secret %s is not a JSON object: %w", p.id, err)
+	}
+	out := map[string]string{}
+	flattenJSON("", raw, out)
+	return out, nil
+}
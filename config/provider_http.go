@@ -0,0 +1,148 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// providerClient is the HTTP client of the Vault and Consul providers; the
+// request context carries CONFIG_PROVIDER_TIMEOUT.
+var providerClient = &http.Client{}
+
+// ─── Vault ────────────────────────────────────────────────────────────────────
+
+// vaultProvider reads one secret from HashiCorp Vault's KV engine (v1 or v2).
+type vaultProvider struct {
+	addr, token, namespace, path string
+}
+
+func newVaultProvider(get Lookup) (Provider, error) {
+	p := &vaultProvider{
+		addr:      strings.TrimRight(get("VAULT_ADDR", "http://127.0.0.1:8200"), "/"),
+		token:     get("VAULT_TOKEN", ""),
+		namespace: get("VAULT_NAMESPACE", ""),
+		path:      strings.Trim(get("VAULT_PATH", ""), "/"),
+	}
+	if p.token == "" {
+		return nil, errors.New("VAULT_TOKEN is not set")
+	}
+	if p.path == "" {
+		return nil, errors.New("VAULT_PATH is not set (e.g. secret/data/myapp)")
+	}
+	return p, nil
+}
+
+func (p *vaultProvider) Load(ctx context.Context) (map[string]string, error) {
+	header := http.Header{"X-Vault-Token": {p.token}}
+	if p.namespace != "" {
+		header.Set("X-Vault-Namespace", p.namespace)
+	}
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := getJSON(ctx, p.addr+"/v1/"+p.path, header, &body); err != nil {
+		return nil, err
+	}
+
+	// KV v2 wraps the secret as {"data": {"data": {...}, "metadata": {...}}}.
+	data := body.Data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, v2 := data["metadata"]; v2 {
+			data = inner
+		}
+	}
+	out := map[string]string{}
+	flattenJSON("", data, out)
+	return out, nil
+}
+
+// ─── Consul ───────────────────────────────────────────────────────────────────
+
+// consulProvider reads every key under a Consul KV prefix; kashvi/mail/port
+// under prefix kashvi/ becomes MAIL_PORT.
+type consulProvider struct {
+	addr, token, prefix string
+}
+
+func newConsulProvider(get Lookup) (Provider, error) {
+	addr := get("CONSUL_HTTP_ADDR", "http://127.0.0.1:8500")
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return &consulProvider{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  get("CONSUL_HTTP_TOKEN", ""),
+		prefix: strings.TrimLeft(get("CONSUL_PREFIX", get("APP_NAME", defaultAppName)+"/"), "/"),
+	}, nil
+}
+
+func (p *consulProvider) Load(ctx context.Context) (map[string]string, error) {
+	header := http.Header{}
+	if p.token != "" {
+		header.Set("X-Consul-Token", p.token)
+	}
+	var entries []struct {
+		Key   string `json:"Key"`
+		Value string `json:"Value"` // base64; null for folders
+	}
+	err := getJSON(ctx, p.addr+"/v1/kv/"+strings.ReplaceAll(url.PathEscape(p.prefix), "%2F", "/")+"?recurse=true", header, &entries)
+	if errors.Is(err, errNotFound) {
+		return map[string]string{}, nil // nothing under the prefix yet
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string, len(entries))
+	for _, e := range entries {
+		key := strings.Trim(strings.TrimPrefix(e.Key, p.prefix), "/")
+		if key == "" || strings.HasSuffix(e.Key, "/") {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Key, err)
+		}
+		out[strings.ReplaceAll(key, "/", "_")] = string(value)
+	}
+	return out, nil
+}
+
+// ─── HTTP ─────────────────────────────────────────────────────────────────────
+
+var errNotFound = errors.New("not found")
+
+// getJSON GETs rawURL and decodes the JSON response into out.
+func getJSON(ctx context.Context, rawURL string, header http.Header, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := providerClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("GET %s: %w", req.URL.Path, errNotFound)
+	case resp.StatusCode != http.StatusOK:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GET %s: %s: %s", req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("GET %s: decode: %w", req.URL.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shashiranjanraj/kashvi/internal/secretbox"
+)
+
+// ─── Encrypted values ─────────────────────────────────────────────────────────
+
+// Encrypted values are written ENC(<ciphertext>) in .env or app.json, where
+// the ciphertext is pkg/crypt's format under APP_KEY. They are decrypted while
+// loading, so Get and the typed getters only ever see plaintext.
+const (
+	encPrefix = "ENC("
+	encSuffix = ")"
+)
+
+// IsEncrypted reports whether v is an ENC(...) value.
+func IsEncrypted(v string) bool {
+	v = strings.TrimSpace(v)
+	return strings.HasPrefix(v, encPrefix) && strings.HasSuffix(v, encSuffix)
+}
+
+// EncryptValue encrypts plain with APP_KEY and returns it as ENC(...), ready
+// to paste into .env.
+func EncryptValue(plain string) (string, error) {
+	key, err := currentKey()
+	if err != nil {
+		return "", err
+	}
+	enc, err := secretbox.Seal(secretbox.Key(key), []byte(plain))
+	if err != nil {
+		return "", fmt.Errorf("config: encrypt: %w", err)
+	}
+	return encPrefix + enc + encSuffix, nil
+}
+
+// DecryptValue returns the plaintext of an ENC(...) value. Other values are
+// returned unchanged.
+func DecryptValue(v string) (string, error) {
+	if !IsEncrypted(v) {
+		return v, nil
+	}
+	key, err := currentKey()
+	if err != nil {
+		return "", err
+	}
+	return open(key, v)
+}
+
+// currentKey is APP_KEY from the process environment or the config files.
+func currentKey() (string, error) {
+	key := os.Getenv("APP_KEY")
+	if key == "" {
+		_ = Load()
+		key = get("APP_KEY", "")
+	}
+	if key == "" {
+		return "", errors.New("config: APP_KEY is not set")
+	}
+	return key, nil
+}
+
+func open(key, v string) (string, error) {
+	v = strings.TrimSpace(v)
+	enc := strings.TrimSuffix(strings.TrimPrefix(v, encPrefix), encSuffix)
+	plain, err := secretbox.Open(secretbox.Key(key), enc)
+	if errors.Is(err, secretbox.ErrOpen) {
+		return "", errors.New("wrong APP_KEY or damaged value")
+	}
+	return string(plain), err
+}
+
+// decryptValues replaces the ENC(...) values in loaded with their plaintext.
+// APP_KEY itself must be plaintext; set it in the process environment to
+// keep it out of the files.
+func decryptValues(loaded map[string]string) error {
+	var keys []string
+	for k, v := range loaded {
+		if IsEncrypted(v) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	appKey := strings.TrimSpace(loaded["APP_KEY"])
+	for _, k := range keys {
+		switch {
+		case k == "APP_KEY":
+			return errors.New("APP_KEY cannot be encrypted; set it in the environment instead")
+		case appKey == "":
+			return fmt.Errorf("%s is encrypted but APP_KEY is not set", k)
+		}
+		plain, err := open(appKey, loaded[k])
+		if err != nil {
+			return fmt.Errorf("decrypt %s: %w", k, err)
+		}
+		loaded[k] = plain
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce groups the burst of events an editor's save produces into
+// one reload.
+const watchDebounce = 100 * time.Millisecond
+
+// Watch reloads the config whenever config/app.json, .env or .env.<APP_ENV>
+// changes, until ctx is done; OnChange subscribers hear about each change.
+// It returns once the watcher is running. A file that fails to parse is
+// reported on stderr and the previous values stay in place.
+//
+// Only code that reads config when it needs it sees new values: settings
+// read once at startup, such as APP_PORT or DATABASE_DSN, need a restart.
+func Watch(ctx context.Context) error {
+	if err := Load(); err != nil {
+		return err
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("config: watch: %w", err)
+	}
+	// Watch the directories rather than the files: editors often save by
+	// replacing the file, which would end a watch on the old one.
+	for _, dir := range []string{filepath.Dir(configFile), filepath.Dir(envFile)} {
+		if err := w.Add(dir); err != nil && !os.IsNotExist(err) {
+			w.Close()
+			return fmt.Errorf("config: watch %s: %w", dir, err)
+		}
+	}
+
+	go func() {
+		defer w.Close()
+		var timer <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if watched(ev.Name) {
+					timer = time.After(watchDebounce)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				fmt.Fprintln(os.Stderr, "config: watch:", err)
+			case <-timer:
+				timer = nil
+				if err := Reload(); err != nil {
+					fmt.Fprintln(os.Stderr, "config: reload:", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// watched reports whether name is one of the files Load reads.
+func watched(name string) bool {
+	name = filepath.Clean(name)
+	for _, f := range []string{configFile, envFile, envFile + "." + AppEnv()} {
+		if name == filepath.Clean(f) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+// Package secretbox is the AES-256-GCM sealing behind pkg/crypt. It lives
+// apart from pkg/crypt, which reads APP_KEY through package config, so that
+// config can decrypt ENC(...) values while loading.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrOpen is returned when a sealed value is malformed or fails
+// authentication, e.g. because it was sealed with another key.
+var ErrOpen = errors.New("secretbox: open failed")
+
+// Key derives the 32-byte AES-256 key for secret (APP_KEY).
+func Key(secret string) []byte {
+	h := sha256.Sum256([]byte(secret))
+	return h[:]
+}
+
+// Seal encrypts data and returns base64url(nonce || ciphertext || tag).
+func Seal(key, data []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("nonce: %w", err)
+	}
+	// Seal appends ciphertext+tag after nonce.
+	return base64.URLEncoding.EncodeToString(gcm.Seal(nonce, nonce, data, nil)), nil
+}
+
+// Open decrypts a value produced by Seal.
+func Open(key []byte, encoded string) ([]byte, error) {
+	data, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrOpen
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, ErrOpen
+	}
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrOpen
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("new cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("new GCM: %w", err)
+	}
+	return gcm, nil
+}
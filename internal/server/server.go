@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,24 +13,65 @@ import (
 	"time"
 
 	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/analytics"
 	"github.com/shashiranjanraj/kashvi/pkg/cache"
 	"github.com/shashiranjanraj/kashvi/pkg/database"
 	kashvigrpc "github.com/shashiranjanraj/kashvi/pkg/grpc"
 	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/mtls"
+	"github.com/shashiranjanraj/kashvi/pkg/notification"
+	"github.com/shashiranjanraj/kashvi/pkg/proxyproto"
 	"github.com/shashiranjanraj/kashvi/pkg/queue"
+	"github.com/shashiranjanraj/kashvi/pkg/quota"
 	"github.com/shashiranjanraj/kashvi/pkg/storage"
+	"github.com/shashiranjanraj/kashvi/pkg/tracing"
 )
 
 // Start boots the HTTP + gRPC servers, runs until SIGINT/SIGTERM, then shuts
-// down gracefully.
+// down gracefully with the default Options.
 //
 // handler is the application's root http.Handler (built by pkg/app.buildHandler).
 // Passing nil uses a minimal default handler (useful for quick smoke tests).
 func Start(handler http.Handler) error {
+	return StartWithOptions(handler, Options{})
+}
+
+// StartWithOptions is Start with explicit lifecycle options. On shutdown it
+// runs the phases listed in Phases, in order (see shutdown.go).
+func StartWithOptions(handler http.Handler, opts Options) error {
 	if err := config.Load(); err != nil {
 		return fmt.Errorf("config: %w", err)
 	}
 
+	// Hot reload of config/app.json and .env files is opt-in; so is
+	// re-reading CONFIG_PROVIDER every CONFIG_PROVIDER_REFRESH.
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	config.OnChange(func(changed []string) {
+		logger.Info("config: reloaded", "keys", changed)
+	})
+	if config.GetBool("CONFIG_WATCH", false) {
+		if err := config.Watch(watchCtx); err != nil {
+			logger.Warn("config: hot reload disabled", "error", err)
+		}
+	}
+	config.RefreshProvider(watchCtx)
+
+	// Forward ERROR logs to Slack/PagerDuty when ALERT_* is configured.
+	if notification.InstallAlerts() {
+		logger.Info("alerts: forwarding error logs", "env", config.AppEnv())
+	}
+
+	// Tracing is non-fatal: without a collector the app runs untraced. It
+	// must be set up before database.Connect so SQL statements are traced.
+	tracingOpts := tracing.DefaultOptions()
+	if err := tracing.Init(tracingOpts); err != nil {
+		logger.Warn("tracing: disabled", "error", err)
+	} else if tracing.Enabled() {
+		tracing.InstrumentQueue()
+		logger.Info("tracing: exporting spans", "endpoint", tracingOpts.Endpoint, "service", tracingOpts.ServiceName)
+	}
+
 	// Log runtime concurrency level.
 	procs := runtime.GOMAXPROCS(0)
 	logger.Info("runtime", "GOMAXPROCS", procs, "NumCPU", runtime.NumCPU())
@@ -39,6 +82,11 @@ func Start(handler http.Handler) error {
 		return fmt.Errorf("refusing to start: JWT_SECRET must be changed in production")
 	}
 
+	// OnBoot hooks: config is loaded, nothing is connected yet.
+	if err := RunBootHooks(opts.BootHooks); err != nil {
+		return err
+	}
+
 	if err := database.Connect(); err != nil {
 		return fmt.Errorf("database: %w", err)
 	}
@@ -50,8 +98,26 @@ func Start(handler http.Handler) error {
 
 	// Wire DB into queue for persistent failed jobs.
 	queue.UseDB(database.DB)
+	if err := queue.UseConfiguredDriver(); err != nil {
+		return err
+	}
+
+	// Copy per-tenant quota counters into kashvi_quota_usage for billing.
+	quota.StartRollups(context.Background(), database.DB, quota.RollupInterval())
 
 	storage.Connect()
+	// Jobs run by this process get a scratch disk removed after each attempt.
+	queue.Use(storage.TempJobMiddleware())
+
+	// Analytics is non-fatal too — events are simply not recorded.
+	if err := analytics.Connect(); err != nil {
+		logger.Warn("analytics: disabled", "error", err)
+	}
+
+	// OnBooted hooks: every service is up, nothing is listening yet.
+	if err := RunBootHooks(opts.BootedHooks); err != nil {
+		return err
+	}
 
 	// ── HTTP server ─────────────────────────────────────────────────────────
 
@@ -70,6 +136,16 @@ func Start(handler http.Handler) error {
 		MaxHeaderBytes: 1 << 20, // 1 MB
 	}
 
+	// TLS (and mTLS client certificates) when TLS_CERT_FILE is set.
+	tlsCfg, err := mtls.ServerConfig(mtls.DefaultOptions())
+	if err != nil {
+		return err
+	}
+	if tlsCfg != nil {
+		srv.TLSConfig = tlsCfg
+		fmt.Printf("🔒 TLS enabled  [client certs: %s]\n", mtls.DefaultOptions().ClientAuth)
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
@@ -78,17 +154,35 @@ func Start(handler http.Handler) error {
 	go func() {
 		fmt.Printf("🚀 Kashvi HTTP  on %s  [env: %s]  [workers: %d]\n",
 			addr, config.AppEnv(), runtime.GOMAXPROCS(0))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		if proxyproto.Enabled() {
+			// Behind HAProxy / AWS NLB: take the client address from the
+			// PROXY header so RemoteAddr is the real peer.
+			ln = proxyproto.NewListener(ln, proxyproto.DefaultOptions())
+			fmt.Println("🔁 PROXY protocol enabled")
+		}
+		if tlsCfg != nil {
+			ln = tls.NewListener(ln, tlsCfg)
+		}
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
 			errCh <- err
 		}
 	}()
 
 	// ── gRPC server ─────────────────────────────────────────────────────────
 
-	grpcSrv, _, grpcErr := kashvigrpc.Start(config.GRPCPort())
-	if grpcErr != nil {
+	grpcSrv, _, grpcErr := kashvigrpc.Start(config.GRPCPort(), opts.GRPCServices...)
+	switch {
+	case grpcErr != nil && len(opts.GRPCServices) > 0:
+		srv.Close()
+		return grpcErr
+	case grpcErr != nil:
 		logger.Warn("grpc: server failed to start, HTTP-only mode", "error", grpcErr)
-	} else {
+	default:
 		fmt.Printf("🔌 Kashvi gRPC  on :%s\n", config.GRPCPort())
 	}
 
@@ -101,17 +195,5 @@ func Start(handler http.Handler) error {
 		fmt.Printf("\n⚡ Signal %s received — shutting down gracefully…\n", sig)
 	}
 
-	// Graceful HTTP shutdown (10 s deadline).
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	httpErr := srv.Shutdown(ctx)
-
-	// Graceful gRPC shutdown.
-	kashvigrpc.Stop(grpcSrv)
-
-	// Flush MongoDB log handler.
-	logger.CloseMongoHandler()
-
-	return httpErr
+	return shutdown(opts, srv, grpcSrv)
 }
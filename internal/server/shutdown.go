@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	gogrpc "google.golang.org/grpc"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/analytics"
+	"github.com/shashiranjanraj/kashvi/pkg/cache"
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/health"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
+	"github.com/shashiranjanraj/kashvi/pkg/quota"
+	"github.com/shashiranjanraj/kashvi/pkg/schedule"
+	"github.com/shashiranjanraj/kashvi/pkg/tracing"
+	"github.com/shashiranjanraj/kashvi/pkg/ws"
+)
+
+// Shutdown phases, run in this order after SIGINT/SIGTERM.
+const (
+	PhaseHTTP      = "http"      // stop accepting HTTP/gRPC, finish in-flight requests
+	PhaseWebSocket = "websocket" // send close frames to every WS client
+	PhaseScheduler = "scheduler" // stop schedule loops, wait for running tasks
+	PhaseQueue     = "queue"     // stop fetching jobs, wait for in-flight jobs
+	PhaseHooks     = "hooks"     // run Options.ShutdownHooks in registration order
+	PhaseFlush     = "flush"     // flush buffered analytics events, quota counters and trace spans
+	PhaseClose     = "close"     // close DB, Redis and the MongoDB log sink
+)
+
+// Phases lists the shutdown phases in execution order.
+var Phases = []string{PhaseHTTP, PhaseWebSocket, PhaseScheduler, PhaseQueue, PhaseHooks, PhaseFlush, PhaseClose}
+
+// defaultPhaseTimeouts apply when neither Options nor SHUTDOWN_<PHASE>_TIMEOUT
+// set a value.
+var defaultPhaseTimeouts = map[string]time.Duration{
+	PhaseHTTP:      10 * time.Second,
+	PhaseWebSocket: 5 * time.Second,
+	PhaseScheduler: 10 * time.Second,
+	PhaseQueue:     20 * time.Second,
+	PhaseHooks:     10 * time.Second,
+	PhaseFlush:     5 * time.Second,
+	PhaseClose:     5 * time.Second,
+}
+
+// Options tunes the server lifecycle.
+type Options struct {
+	// ShutdownTimeout caps the whole shutdown sequence. The flush and close
+	// phases always run with their own timeouts, reserved out of this
+	// budget; every earlier phase gets its own timeout, but never more than
+	// what is left of the rest. Default: SHUTDOWN_TIMEOUT or 30s.
+	ShutdownTimeout time.Duration
+	// PhaseTimeouts overrides individual phases (keys are Phase* constants).
+	// Unset phases use SHUTDOWN_<PHASE>_TIMEOUT or the built-in default.
+	PhaseTimeouts map[string]time.Duration
+	// GRPCServices register application services on the gRPC server before
+	// it starts serving. When any are set, failing to start gRPC is fatal
+	// instead of falling back to HTTP-only mode.
+	GRPCServices []func(*gogrpc.Server)
+	// BootHooks run once config is loaded, before the database, Redis, the
+	// queue and other services connect. BootedHooks run once they are all
+	// connected, just before the servers listen. Either failing aborts
+	// startup.
+	BootHooks, BootedHooks []func() error
+	// ShutdownHooks run one after another in the hooks phase, once traffic,
+	// the scheduler and the queue have stopped but before buffers are
+	// flushed and connections closed. They share the phase's context; a
+	// failing hook is logged and the rest still run.
+	ShutdownHooks []func(context.Context) error
+}
+
+func (o Options) total() time.Duration {
+	if o.ShutdownTimeout > 0 {
+		return o.ShutdownTimeout
+	}
+	return envDuration("SHUTDOWN_TIMEOUT", 30*time.Second)
+}
+
+func (o Options) phase(name string) time.Duration {
+	if d, ok := o.PhaseTimeouts[name]; ok && d > 0 {
+		return d
+	}
+	return envDuration("SHUTDOWN_"+strings.ToUpper(name)+"_TIMEOUT", defaultPhaseTimeouts[name])
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(config.Get(key, "")); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// shutdown runs every phase in order, logging each one. Failures and
+// timeouts are logged and do not stop later phases; the HTTP phase error is
+// returned so Start can report it.
+func shutdown(opts Options, srv *http.Server, grpcSrv *gogrpc.Server) error {
+	// Flushing buffers and closing connections must not be starved by a
+	// slow drain, so their timeouts are set aside before the others run.
+	reserved := opts.phase(PhaseFlush) + opts.phase(PhaseClose)
+	deadline := time.Now().Add(opts.total() - reserved)
+	// /readyz answers 503 from here on so load balancers stop routing to us.
+	health.SetDraining(true)
+	steps := map[string]func(context.Context) error{
+		PhaseHTTP: func(ctx context.Context) error {
+			err := srv.Shutdown(ctx)
+			stopGRPC(ctx, grpcSrv)
+			return err
+		},
+		PhaseWebSocket: ws.Shutdown,
+		PhaseScheduler: schedule.Stop,
+		PhaseQueue:     queue.Drain,
+		PhaseHooks: func(ctx context.Context) error {
+			return runShutdownHooks(ctx, opts.ShutdownHooks)
+		},
+		PhaseFlush: func(ctx context.Context) error {
+			return errors.Join(analytics.Close(ctx), quota.Flush(ctx), tracing.Shutdown(ctx))
+		},
+		PhaseClose: func(context.Context) error {
+			var errs []error
+			errs = append(errs, database.CloseConnections())
+			errs = append(errs, database.Close(database.DB))
+			if cache.RDB != nil {
+				errs = append(errs, cache.RDB.Close())
+			}
+			// Last: flushes shipped logs and disconnects from MongoDB.
+			logger.CloseMongoHandler()
+			return errors.Join(errs...)
+		},
+	}
+
+	var httpErr error
+	for _, name := range Phases {
+		budget := opts.phase(name)
+		if name != PhaseFlush && name != PhaseClose {
+			budget = min(budget, time.Until(deadline))
+		}
+		if budget <= 0 {
+			logger.Warn("shutdown: skipped, overall timeout exhausted", "phase", name)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), budget)
+		start := time.Now()
+		logger.Info("shutdown: phase started", "phase", name, "timeout", budget.String())
+		err := steps[name](ctx)
+		cancel()
+
+		if err != nil {
+			logger.Warn("shutdown: phase failed", "phase", name, "duration", time.Since(start).String(), "error", err)
+		} else {
+			logger.Info("shutdown: phase done", "phase", name, "duration", time.Since(start).String())
+		}
+		if name == PhaseHTTP && err != nil {
+			httpErr = fmt.Errorf("http shutdown: %w", err)
+		}
+	}
+	return httpErr
+}
+
+// RunBootHooks runs hooks in order, stopping at the first error. Worker
+// commands use it for BootHooks and BootedHooks around their own setup.
+func RunBootHooks(hooks []func() error) error {
+	for _, hook := range hooks {
+		if err := hook(); err != nil {
+			return fmt.Errorf("boot: %w", err)
+		}
+	}
+	return nil
+}
+
+// RunShutdownHooks runs opts.ShutdownHooks within the hooks phase timeout,
+// for processes such as queue workers that do not go through the full
+// shutdown sequence.
+func RunShutdownHooks(opts Options) error {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.phase(PhaseHooks))
+	defer cancel()
+	return runShutdownHooks(ctx, opts.ShutdownHooks)
+}
+
+func runShutdownHooks(ctx context.Context, hooks []func(context.Context) error) error {
+	var errs []error
+	for _, hook := range hooks {
+		errs = append(errs, hook(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// stopGRPC drains gRPC gracefully, forcing a hard stop if ctx expires first.
+func stopGRPC(ctx context.Context, s *gogrpc.Server) {
+	if s == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
@@ -0,0 +1,42 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/internal/server"
+)
+
+func TestRunShutdownHooksRunsEveryHookInOrder(t *testing.T) {
+	errFirst, errThird := errors.New("first"), errors.New("third")
+	var order []int
+	hook := func(n int, err error) func(context.Context) error {
+		return func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("hook %d: ctx has no deadline", n)
+			}
+			order = append(order, n)
+			return err
+		}
+	}
+	opts := server.Options{
+		PhaseTimeouts: map[string]time.Duration{server.PhaseHooks: time.Second},
+		ShutdownHooks: []func(context.Context) error{hook(1, errFirst), hook(2, nil), hook(3, errThird)},
+	}
+
+	err := server.RunShutdownHooks(opts)
+	if !errors.Is(err, errFirst) || !errors.Is(err, errThird) {
+		t.Fatalf("err = %v, want both hook errors joined", err)
+	}
+	if len(order) != 3 || order[0] != 1 || order[1] != 2 || order[2] != 3 {
+		t.Fatalf("hooks ran as %v, want [1 2 3]", order)
+	}
+}
+
+func TestRunShutdownHooksWithoutHooks(t *testing.T) {
+	if err := server.RunShutdownHooks(server.Options{}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,311 @@
+// Package analytics ships request and product events to a secondary
+// analytical store (ClickHouse) instead of the transactional database.
+//
+// Events are buffered in-process and written in batches by a background
+// goroutine, so Track never blocks a request on network I/O. When the buffer
+// is full new events are dropped (and counted) rather than slowing the app.
+//
+// Boot once (internal/server does this for you):
+//
+//	analytics.Connect()          // reads ANALYTICS_DRIVER, CLICKHOUSE_* …
+//	defer analytics.Close(ctx)   // flushes pending events
+//
+// Track from anywhere:
+//
+//	analytics.Track(analytics.Event{
+//	    Name:       "order.placed",
+//	    UserID:     user.ID,
+//	    Properties: map[string]any{"total": order.Total},
+//	})
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// ErrClosed is returned by Flush on a writer that has been closed.
+var ErrClosed = errors.New("analytics: writer closed")
+
+// Event is a single analytics record.
+type Event struct {
+	Name       string
+	Time       time.Time // defaults to time.Now() when zero
+	UserID     string
+	Properties map[string]any
+}
+
+// Sink persists a batch of events. Implementations must be safe to call from
+// the writer goroutine and should honour ctx cancellation.
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+}
+
+// SinkFunc adapts a plain function to the Sink interface.
+type SinkFunc func(ctx context.Context, events []Event) error
+
+// Write calls f(ctx, events).
+func (f SinkFunc) Write(ctx context.Context, events []Event) error { return f(ctx, events) }
+
+// ─── Writer ───────────────────────────────────────────────────────────────────
+
+// Options tunes a Writer. Zero values fall back to the config defaults.
+type Options struct {
+	BatchSize     int           // flush after this many events
+	FlushInterval time.Duration // flush at least this often
+	BufferSize    int           // events held in memory before dropping
+	WriteTimeout  time.Duration // deadline for a single Sink.Write
+}
+
+func (o Options) withDefaults() Options {
+	if o.BatchSize <= 0 {
+		o.BatchSize = config.AnalyticsBatchSize()
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = config.AnalyticsFlushInterval()
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = config.AnalyticsBufferSize()
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = 10 * time.Second
+	}
+	return o
+}
+
+// Writer batches events and hands them to a Sink asynchronously.
+type Writer struct {
+	sink     Sink
+	opts     Options
+	events   chan Event
+	flushReq chan chan error
+	stop     chan struct{}
+	done     chan struct{}
+	closed   atomic.Bool
+	once     sync.Once
+	dropped  atomic.Uint64
+}
+
+// NewWriter starts a background writer that flushes to sink.
+func NewWriter(sink Sink, opts Options) *Writer {
+	opts = opts.withDefaults()
+	w := &Writer{
+		sink:     sink,
+		opts:     opts,
+		events:   make(chan Event, opts.BufferSize),
+		flushReq: make(chan chan error),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Track enqueues e without blocking. It reports false when the event was
+// dropped because the writer is closed or its buffer is full.
+func (w *Writer) Track(e Event) bool {
+	if w.closed.Load() {
+		w.dropped.Add(1)
+		return false
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	select {
+	case w.events <- e:
+		return true
+	default:
+		w.dropped.Add(1)
+		return false
+	}
+}
+
+// Dropped returns how many events were discarded so far.
+func (w *Writer) Dropped() uint64 { return w.dropped.Load() }
+
+// Flush writes everything buffered so far and waits for the sink.
+func (w *Writer) Flush(ctx context.Context) error {
+	reply := make(chan error, 1)
+	select {
+	case w.flushReq <- reply:
+	case <-w.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting events, flushes the buffer and waits for the writer
+// goroutine to exit (or ctx to expire).
+func (w *Writer) Close(ctx context.Context) error {
+	w.once.Do(func() {
+		w.closed.Store(true)
+		close(w.stop)
+	})
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, w.opts.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := w.write(batch)
+		batch = make([]Event, 0, w.opts.BatchSize)
+		return err
+	}
+	// drain moves whatever is buffered into batch, flushing full batches.
+	drain := func() error {
+		var firstErr error
+		for {
+			select {
+			case e := <-w.events:
+				batch = append(batch, e)
+				if len(batch) >= w.opts.BatchSize {
+					if err := flush(); err != nil && firstErr == nil {
+						firstErr = err
+					}
+				}
+			default:
+				if err := flush(); err != nil && firstErr == nil {
+					firstErr = err
+				}
+				return firstErr
+			}
+		}
+	}
+
+	for {
+		select {
+		case e := <-w.events:
+			batch = append(batch, e)
+			if len(batch) >= w.opts.BatchSize {
+				_ = flush()
+			}
+		case <-ticker.C:
+			_ = flush()
+		case reply := <-w.flushReq:
+			reply <- drain()
+		case <-w.stop:
+			_ = drain()
+			return
+		}
+	}
+}
+
+func (w *Writer) write(batch []Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.opts.WriteTimeout)
+	defer cancel()
+	if err := w.sink.Write(ctx, batch); err != nil {
+		logger.Warn("analytics: write failed", "events", len(batch), "error", err)
+		return err
+	}
+	return nil
+}
+
+// ─── Default writer ───────────────────────────────────────────────────────────
+
+var (
+	mu            sync.RWMutex
+	defaultWriter *Writer
+)
+
+// Connect builds the default writer from config. ANALYTICS_DRIVER selects the
+// sink: "clickhouse", "log", or "none" (Track becomes a no-op).
+func Connect() error {
+	var sink Sink
+	switch driver := config.AnalyticsDriver(); driver {
+	case "", "none", "null":
+		return nil
+	case "clickhouse":
+		sink = NewClickHouse()
+	case "log":
+		sink = LogSink{}
+	default:
+		return fmt.Errorf("analytics: unsupported driver %q", driver)
+	}
+	Use(NewWriter(sink, Options{}))
+	return nil
+}
+
+// Use installs w as the default writer used by Track. Any previous default
+// writer is left running; close it yourself if it is no longer needed.
+func Use(w *Writer) {
+	mu.Lock()
+	defaultWriter = w
+	mu.Unlock()
+}
+
+// Track enqueues e on the default writer. It is a no-op when analytics is not
+// configured.
+func Track(e Event) bool {
+	mu.RLock()
+	w := defaultWriter
+	mu.RUnlock()
+	if w == nil {
+		return false
+	}
+	return w.Track(e)
+}
+
+// Flush flushes the default writer.
+func Flush(ctx context.Context) error {
+	mu.RLock()
+	w := defaultWriter
+	mu.RUnlock()
+	if w == nil {
+		return nil
+	}
+	return w.Flush(ctx)
+}
+
+// Close flushes and detaches the default writer.
+func Close(ctx context.Context) error {
+	mu.Lock()
+	w := defaultWriter
+	defaultWriter = nil
+	mu.Unlock()
+	if w == nil {
+		return nil
+	}
+	return w.Close(ctx)
+}
+
+// ─── Log sink ─────────────────────────────────────────────────────────────────
+
+// LogSink writes events to the application logger at debug level. Useful in
+// development when no ClickHouse instance is available.
+type LogSink struct{}
+
+// Write logs every event in the batch.
+func (LogSink) Write(_ context.Context, events []Event) error {
+	for _, e := range events {
+		logger.Debug("analytics: event", "name", e.Name, "user_id", e.UserID,
+			"time", e.Time, "properties", e.Properties)
+	}
+	return nil
+}
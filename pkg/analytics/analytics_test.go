@@ -0,0 +1,137 @@
+package analytics_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/analytics"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	batches [][]analytics.Event
+}
+
+func (s *recordingSink) Write(_ context.Context, events []analytics.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]analytics.Event(nil), events...))
+	return nil
+}
+
+func (s *recordingSink) count() (batches, events int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, b := range s.batches {
+		events += len(b)
+	}
+	return len(s.batches), events
+}
+
+func TestWriterBatches(t *testing.T) {
+	sink := &recordingSink{}
+	w := analytics.NewWriter(sink, analytics.Options{BatchSize: 2, FlushInterval: time.Hour, BufferSize: 10})
+
+	for i := 0; i < 5; i++ {
+		if !w.Track(analytics.Event{Name: "e"}) {
+			t.Fatalf("Track %d dropped", i)
+		}
+	}
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	batches, events := sink.count()
+	if events != 5 {
+		t.Fatalf("want 5 events written, got %d", events)
+	}
+	if batches != 3 {
+		t.Fatalf("want 3 batches (2+2+1), got %d", batches)
+	}
+	if w.Track(analytics.Event{Name: "late"}) {
+		t.Fatal("Track after Close should report a drop")
+	}
+}
+
+func TestWriterDropsWhenFull(t *testing.T) {
+	block := make(chan struct{})
+	sink := analytics.SinkFunc(func(context.Context, []analytics.Event) error {
+		<-block
+		return nil
+	})
+	w := analytics.NewWriter(sink, analytics.Options{BatchSize: 1, FlushInterval: time.Hour, BufferSize: 1})
+
+	// The first event is picked up and blocks the sink; one more fits in the
+	// buffer; the rest must be dropped instead of blocking the caller.
+	for i := 0; i < 10; i++ {
+		w.Track(analytics.Event{Name: "e"})
+	}
+	if w.Dropped() == 0 {
+		t.Fatal("expected dropped events while the sink is blocked")
+	}
+	close(block)
+	_ = w.Close(context.Background())
+}
+
+func TestClickHouseWrite(t *testing.T) {
+	var (
+		gotQuery string
+		gotUser  string
+		rows     []map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("query")
+		gotUser = r.Header.Get("X-ClickHouse-User")
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var row map[string]any
+			if err := json.Unmarshal(sc.Bytes(), &row); err != nil {
+				t.Errorf("bad row %q: %v", sc.Text(), err)
+			}
+			rows = append(rows, row)
+		}
+	}))
+	defer srv.Close()
+
+	ch := &analytics.ClickHouse{URL: srv.URL, Database: "db", Table: "events", User: "kashvi"}
+	err := ch.Write(context.Background(), []analytics.Event{
+		{Name: "signup", Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), UserID: "42", Properties: map[string]any{"plan": "pro"}},
+		{Name: "login", Time: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if !strings.HasPrefix(gotQuery, "INSERT INTO `db`.`events` FORMAT JSONEachRow") {
+		t.Errorf("unexpected query %q", gotQuery)
+	}
+	if gotUser != "kashvi" {
+		t.Errorf("user header = %q", gotUser)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("want 2 rows, got %d", len(rows))
+	}
+	if rows[0]["timestamp"] != "2024-01-02 03:04:05.000" || rows[0]["properties"] != `{"plan":"pro"}` {
+		t.Errorf("unexpected row %v", rows[0])
+	}
+}
+
+func TestClickHouseWriteError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Code: 60. Table does not exist", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	ch := &analytics.ClickHouse{URL: srv.URL, Table: "events"}
+	err := ch.Write(context.Background(), []analytics.Event{{Name: "x", Time: time.Now()}})
+	if err == nil || !strings.Contains(err.Error(), "Table does not exist") {
+		t.Fatalf("expected server error to surface, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/config"
+)
+
+// ClickHouse writes events through ClickHouse's HTTP interface using the
+// JSONEachRow format, so no native driver is required. It is a Sink rather
+// than a database.Connection on purpose: analytics only appends batches, and
+// named connections are GORM dialects that migrations run against.
+//
+// Expected table layout (see CreateTableSQL / EnsureTable):
+//
+//	name String, timestamp DateTime64(3, 'UTC'), user_id String, properties String
+type ClickHouse struct {
+	URL      string // e.g. http://localhost:8123
+	Database string
+	Table    string
+	User     string
+	Password string
+	Client   *http.Client
+}
+
+// NewClickHouse returns a ClickHouse sink configured from CLICKHOUSE_* and
+// ANALYTICS_TABLE.
+func NewClickHouse() *ClickHouse {
+	return &ClickHouse{
+		URL:      config.ClickHouseURL(),
+		Database: config.ClickHouseDatabase(),
+		Table:    config.AnalyticsTable(),
+		User:     config.ClickHouseUser(),
+		Password: config.ClickHousePassword(),
+		Client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// clickHouseRow is the JSONEachRow representation of an Event.
+type clickHouseRow struct {
+	Name       string `json:"name"`
+	Timestamp  string `json:"timestamp"`
+	UserID     string `json:"user_id"`
+	Properties string `json:"properties"`
+}
+
+// Write inserts events in a single INSERT … FORMAT JSONEachRow request.
+func (c *ClickHouse) Write(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, e := range events {
+		props := "{}"
+		if len(e.Properties) > 0 {
+			b, err := json.Marshal(e.Properties)
+			if err != nil {
+				return fmt.Errorf("analytics: clickhouse: encode %q properties: %w", e.Name, err)
+			}
+			props = string(b)
+		}
+		if err := enc.Encode(clickHouseRow{
+			Name:       e.Name,
+			Timestamp:  e.Time.UTC().Format("2006-01-02 15:04:05.000"),
+			UserID:     e.UserID,
+			Properties: props,
+		}); err != nil {
+			return fmt.Errorf("analytics: clickhouse: encode: %w", err)
+		}
+	}
+
+	return c.exec(ctx, "INSERT INTO "+c.tableName()+" FORMAT JSONEachRow", &body)
+}
+
+// CreateTableSQL returns DDL for a MergeTree table matching Write's layout.
+func (c *ClickHouse) CreateTableSQL() string {
+	return "CREATE TABLE IF NOT EXISTS " + c.tableName() + ` (
+    name       LowCardinality(String),
+    timestamp  DateTime64(3, 'UTC'),
+    user_id    String,
+    properties String
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (name, timestamp)`
+}
+
+// EnsureTable creates the events table if it does not exist.
+func (c *ClickHouse) EnsureTable(ctx context.Context) error {
+	return c.exec(ctx, c.CreateTableSQL(), nil)
+}
+
+func (c *ClickHouse) tableName() string {
+	if c.Database == "" {
+		return quoteIdent(c.Table)
+	}
+	return quoteIdent(c.Database) + "." + quoteIdent(c.Table)
+}
+
+// exec sends query (plus an optional data body) to the HTTP interface.
+func (c *ClickHouse) exec(ctx context.Context, query string, data io.Reader) error {
+	endpoint := strings.TrimRight(c.URL, "/") + "/?query=" + url.QueryEscape(query)
+
+	var body io.Reader = http.NoBody
+	if data != nil {
+		body = data
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("analytics: clickhouse: build request: %w", err)
+	}
+	if c.User != "" {
+		req.Header.Set("X-ClickHouse-User", c.User)
+	}
+	if c.Password != "" {
+		req.Header.Set("X-ClickHouse-Key", c.Password)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("analytics: clickhouse: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("analytics: clickhouse: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// quoteIdent wraps a ClickHouse identifier in backticks.
+func quoteIdent(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "\\`") + "`"
+}
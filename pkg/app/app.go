@@ -37,9 +37,16 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
+	"google.golang.org/grpc"
+
+	"github.com/shashiranjanraj/kashvi/internal/server"
+	"github.com/shashiranjanraj/kashvi/pkg/metrics"
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
 	"github.com/shashiranjanraj/kashvi/pkg/router"
 )
 
@@ -60,9 +67,15 @@ func RegisterSeeder(name string, fn SeederFunc) {
 // Application is the central configuration object for a Kashvi project.
 // Build one with New(), attach your configuration, then call Run().
 type Application struct {
-	routesFns []func(*router.Router)
-	models    []interface{}
-	seeders   []SeederFunc
+	routesFns   []func(*router.Router)
+	models      []interface{}
+	seeders     []SeederFunc
+	jobs        []queue.Job
+	scheduleFns []func()
+	notifyFns   []func()
+	commands    []consoleCommand
+	plugins     []pluginInfo
+	serverOpt   server.Options
 }
 
 // New creates a new Application instance with sensible defaults.
@@ -92,6 +105,140 @@ func (a *Application) Seeders(fns ...SeederFunc) *Application {
 	return a
 }
 
+// Queue registers job types for the queue, by sample value, in place of
+// queue.Register calls in init(). serve, queue:work and the scheduler
+// commands all see them, so jobs dispatched by one process decode in another:
+//
+//	app.New().Queue(jobs.WelcomeEmailJob{}, &jobs.ReportJob{})
+func (a *Application) Queue(jobs ...queue.Job) *Application {
+	a.jobs = append(a.jobs, jobs...)
+	return a
+}
+
+// Schedule registers a callback that declares scheduled tasks with
+// pkg/schedule. It runs once at startup for every command, after Queue
+// and Notifications, so schedule:work, schedule:run and the task list all
+// agree. You may call Schedule() multiple times.
+//
+//	app.New().Schedule(func() {
+//	    schedule.Daily().At("03:00").Name("backup").Run(backupDB)
+//	    schedule.Job(jobs.ReportJob{}, "0 6 * * *")
+//	})
+func (a *Application) Schedule(fn func()) *Application {
+	a.scheduleFns = append(a.scheduleFns, fn)
+	return a
+}
+
+// Notifications registers a callback that configures pkg/notification
+// (default Slack webhook, PagerDuty key, template directory, …). It runs once
+// at startup for every command, so notifications sent from requests, jobs
+// and scheduled tasks behave the same. You may call Notifications() multiple
+// times.
+//
+//	app.New().Notifications(func() {
+//	    notification.SetSlackWebhook(config.Get("SLACK_WEBHOOK_URL", ""))
+//	    notification.SetTemplateDir("resources/notifications")
+//	})
+func (a *Application) Notifications(fn func()) *Application {
+	a.notifyFns = append(a.notifyFns, fn)
+	return a
+}
+
+// register applies the Queue, Notifications and Schedule registrations.
+// Jobs come first so scheduled jobs can be dispatched, notifications next so
+// scheduled tasks can send them.
+func (a *Application) register() {
+	queue.RegisterJobs(a.jobs...)
+	for _, fn := range a.notifyFns {
+		fn()
+	}
+	for _, fn := range a.scheduleFns {
+		fn()
+	}
+}
+
+// Grpc registers a callback that adds your services to the gRPC server
+// before `serve` starts it next to the HTTP server. Both share the graceful
+// shutdown sequence. You may call Grpc() multiple times.
+//
+//	app.New().Grpc(func(s *grpc.Server) {
+//	    orderspb.RegisterOrderServiceServer(s, &orders.Service{})
+//	})
+func (a *Application) Grpc(fn func(*grpc.Server)) *Application {
+	a.serverOpt.GRPCServices = append(a.serverOpt.GRPCServices, fn)
+	return a
+}
+
+// Shutdown phases, in the order they run after SIGINT/SIGTERM. Use them with
+// ShutdownTimeoutFor.
+const (
+	ShutdownHTTP      = server.PhaseHTTP      // stop accepting HTTP/gRPC, finish in-flight requests
+	ShutdownWebSocket = server.PhaseWebSocket // close every WebSocket client
+	ShutdownScheduler = server.PhaseScheduler // stop the scheduler, wait for running tasks
+	ShutdownQueue     = server.PhaseQueue     // stop fetching jobs, wait for in-flight jobs
+	ShutdownHooks     = server.PhaseHooks     // run OnShutdown hooks
+	ShutdownFlush     = server.PhaseFlush     // flush analytics, quota counters and trace spans
+	ShutdownClose     = server.PhaseClose     // close DB, Redis and MongoDB logging
+)
+
+// ShutdownTimeout caps the whole graceful-shutdown sequence (default:
+// SHUTDOWN_TIMEOUT or 30s). Phases share this budget.
+func (a *Application) ShutdownTimeout(d time.Duration) *Application {
+	a.serverOpt.ShutdownTimeout = d
+	return a
+}
+
+// ShutdownTimeoutFor overrides the timeout of one shutdown phase, e.g.
+// ShutdownTimeoutFor(app.ShutdownQueue, 45*time.Second) to give long jobs
+// more time to finish.
+func (a *Application) ShutdownTimeoutFor(phase string, d time.Duration) *Application {
+	if a.serverOpt.PhaseTimeouts == nil {
+		a.serverOpt.PhaseTimeouts = map[string]time.Duration{}
+	}
+	a.serverOpt.PhaseTimeouts[phase] = d
+	return a
+}
+
+// ─── Lifecycle hooks ──────────────────────────────────────────────────────────
+//
+// serve, queue:work, schedule:work and schedule:run run OnBoot, their own
+// startup, OnBooted, the command itself, then OnShutdown. Hooks of one kind
+// run in registration order.
+
+// OnBoot registers a hook that runs once config is loaded, before the
+// database, Redis and queue connect — the place to configure third-party
+// SDKs. An error aborts startup.
+//
+//	app.New().OnBoot(func() error {
+//	    return sentry.Init(sentry.ClientOptions{Dsn: config.Get("SENTRY_DSN", "")})
+//	})
+func (a *Application) OnBoot(fn func() error) *Application {
+	a.serverOpt.BootHooks = append(a.serverOpt.BootHooks, fn)
+	return a
+}
+
+// OnBooted registers a hook that runs once every service is connected,
+// before the server listens or the worker starts — the place to warm
+// caches. An error aborts startup.
+func (a *Application) OnBooted(fn func() error) *Application {
+	a.serverOpt.BootedHooks = append(a.serverOpt.BootedHooks, fn)
+	return a
+}
+
+// OnShutdown registers a hook that runs during graceful shutdown, after
+// in-flight requests, scheduled tasks and queued jobs have finished and
+// while the database and Redis are still open. Hooks run in the order they
+// were registered, within the ShutdownHooks phase timeout (default:
+// SHUTDOWN_HOOKS_TIMEOUT or 10s); stop waiting when ctx is done.
+//
+//	app.New().OnShutdown(func(ctx context.Context) error {
+//	    return consumer.Close(ctx)
+//	})
+func (a *Application) OnShutdown(fn func(ctx context.Context) error) *Application {
+	a.serverOpt.ShutdownHooks = append(a.serverOpt.ShutdownHooks, fn)
+	return a
+}
+
 // Run reads os.Args and dispatches to the appropriate command.
 // This is the ONLY function you need to call from your main().
 func (a *Application) Run() {
@@ -100,30 +247,78 @@ func (a *Application) Run() {
 		cmd = os.Args[1]
 	}
 
+	a.register()
+
 	// Merge globally-registered seeders.
 	allSeeders := append(a.seeders, globalSeeders...)
 
+	var args []string
+	if len(os.Args) > 2 {
+		args = os.Args[2:]
+	}
+
+	start := time.Now()
+	pushMetrics := true
 	var err error
 	switch cmd {
 	case "serve", "start", "run", "s":
-		err = cmdServe(a)
+		pushMetrics = false // scraped on /metrics
+		err = audited(cmd, args, func() error { return cmdServe(a) })
 	case "migrate":
-		err = cmdMigrate()
+		if hasFlag(args, "--pretend") {
+			err = cmdMigratePretend(args)
+		} else {
+			err = audited(cmd, args, func() error { return cmdMigrate(args) })
+		}
 	case "migrate:rollback", "migrate:down":
-		err = cmdMigrateRollback()
+		err = audited(cmd, args, func() error { return cmdMigrateRollback(args) })
 	case "migrate:status":
-		err = cmdMigrateStatus()
+		err = audited(cmd, args, func() error { return cmdMigrateStatus(args) })
+	case "migrate:fresh":
+		err = audited(cmd, args, func() error { return cmdMigrateFresh(args) })
+	case "db:wipe":
+		err = audited(cmd, args, func() error { return cmdDBWipe(args) })
 	case "seed":
-		err = cmdSeed(allSeeders)
+		err = audited(cmd, args, func() error { return cmdSeed(allSeeders) })
+	case "queue:work":
+		err = audited(cmd, args, func() error { return cmdQueueWork(a, args) })
+	case "queue:failed":
+		err = audited(cmd, args, cmdQueueFailed)
+	case "queue:retry":
+		err = audited(cmd, args, func() error { return cmdQueueRetry(args) })
+	case "quota:report":
+		err = audited(cmd, args, func() error { return cmdQuotaReport(args) })
+	case "schedule:work":
+		err = audited(cmd, args, func() error { return cmdScheduleWork(a) })
+	case "schedule:run":
+		err = audited(cmd, args, func() error { return cmdScheduleRun(a) })
 	case "route:list", "routes":
 		err = cmdRouteList(a)
+	case "ws:contract":
+		err = cmdWSContract(a, args)
+	case "command:list":
+		pushMetrics = false
+		err = cmdCommandList(a, args)
+	case "plugin:list":
+		pushMetrics = false
+		err = cmdPluginList(a)
 	case "help", "--help", "-h":
-		printHelp()
+		pushMetrics = false
+		printHelp(a)
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %q\n\nRun with --help for usage.\n", cmd)
-		os.Exit(1)
+		c, ok := a.command(cmd)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown command: %q\n\nRun with --help for usage.\n", cmd)
+			os.Exit(1)
+		}
+		err = audited(cmd, args, func() error { return runCommand(a, c, args) })
 	}
 
+	if pushMetrics {
+		if perr := metrics.PushCommand(metrics.DefaultPushOptions(), cmd, start, err); perr != nil {
+			fmt.Fprintln(os.Stderr, "Warning:", perr)
+		}
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
@@ -141,7 +336,7 @@ func Run() {
 
 // ─── Command implementations ──────────────────────────────────────────────────
 
-func printHelp() {
+func printHelp(a *Application) {
 	fmt.Print(`Kashvi — Go Framework CLI
 
 Usage:
@@ -152,10 +347,41 @@ Usage:
 Commands:
   serve            Start the HTTP + gRPC server  (aliases: start, run)
   migrate          Run all pending database migrations
+                   (--pretend prints the SQL without running it,
+                    --allow-unsafe overrides MIGRATION_GUARD in production)
   migrate:rollback Rollback the last batch of migrations
   migrate:status   Show migration status
+  migrate:fresh    Drop all tables and re-run every migration
+  db:wipe          Drop all tables
   seed             Run all registered database seeders
   route:list       List registered API routes
+  ws:contract      Print the WebSocket hubs' message contracts as JSON Schema
+                   (--ts for TypeScript, --out=FILE to write to a file)
+  queue:work       Process queued jobs (--queue=high,default --concurrency=8
+                   --max-jobs=1000 --max-time=1h --memory=256MB
+                   --max-concurrency=32)
+  queue:failed     List failed jobs stored in kashvi_failed_jobs
+  queue:retry      Re-queue failed jobs (queue:retry 12 15 | queue:retry all)
+  quota:report     Per-tenant request usage (--month=2026-10 | --day=2026-10-18,
+                   --tenant=<id>)
+  schedule:work    Run the task scheduler in the foreground
+  schedule:run     Run tasks due this minute once and exit (for cron/k8s)
+  command:list     List the project's own commands (--json for tools)
+  plugin:list      List registered plugins and what each one adds
+
+Migration commands accept --database=NAME to target a named connection
+(DB_<NAME>_DSN); the default is the primary database.
+
+Every command is written to the audit log except those that only print:
+migrate --pretend, route:list, ws:contract, command:list, plugin:list and help.
+In production, migrate:fresh, migrate:rollback, db:wipe and seed ask you to
+type APP_NAME to continue; pass --force --reason "..." to run them
+non-interactively.
 
 `)
+	if cmds := a.sortedCommands(); len(cmds) > 0 {
+		fmt.Println("Project commands:")
+		printCommands(cmds)
+		fmt.Println()
+	}
 }
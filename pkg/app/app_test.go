@@ -0,0 +1,52 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBootRunsHooksAroundConnect(t *testing.T) {
+	var order []string
+	step := func(name string, err error) func() error {
+		return func() error {
+			order = append(order, name)
+			return err
+		}
+	}
+	a := New().
+		OnBoot(step("boot1", nil)).
+		OnBoot(step("boot2", nil)).
+		OnBooted(step("booted1", nil)).
+		OnBooted(step("booted2", nil))
+
+	if err := a.boot(step("connect", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(order, ","), "boot1,boot2,connect,booted1,booted2"; got != want {
+		t.Fatalf("ran %s, want %s", got, want)
+	}
+}
+
+func TestBootStopsAtFirstFailingHook(t *testing.T) {
+	failed := errors.New("sdk init failed")
+	var order []string
+	step := func(name string, err error) func() error {
+		return func() error {
+			order = append(order, name)
+			return err
+		}
+	}
+	a := New().
+		OnBoot(step("boot1", failed)).
+		OnBoot(step("boot2", nil)).
+		OnBooted(step("booted", nil))
+
+	err := a.boot(step("connect", nil))
+	if !errors.Is(err, failed) {
+		t.Fatalf("err = %v, want %v", err, failed)
+	}
+	if got := strings.Join(order, ","); got != "boot1" {
+		t.Fatalf("ran %s after a failing OnBoot hook, want only boot1", got)
+	}
+}
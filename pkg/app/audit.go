@@ -0,0 +1,257 @@
+package app
+
+// pkg/app/audit.go — structured audit trail for CLI invocations.
+//
+// Every command dispatched by Application.Run is recorded through the logger
+// (and so shipped to MongoDB when LOG_MONGO_URI is set) with who ran it, where,
+// for how long and whether it succeeded. Commands that only print (route:list,
+// migrate --pretend, ws:contract, command:list, plugin:list, help) are not:
+// their output is often piped into other tools, and the logger writes to
+// stdout too. Set CLI_AUDIT_LOG to a file path to additionally append one
+// JSON line per invocation.
+//
+// Destructive commands refuse to run in production unless --force is given,
+// or the operator types the app name (APP_NAME) at the prompt on an
+// interactive terminal, where a reason is asked for too. --force never
+// prompts; pass --reason with it to record why:
+//
+//	./myapp migrate:fresh --force --reason "reset staging copy, JIRA-123"
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// destructiveCommands require confirmation and a reason in production.
+var destructiveCommands = map[string]bool{
+	"migrate:fresh":    true,
+	"migrate:rollback": true,
+	"migrate:down":     true, // alias of migrate:rollback
+	"db:wipe":          true,
+	"seed":             true,
+}
+
+// AuditEntry is one recorded CLI invocation.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Command    string    `json:"command"`
+	Args       []string  `json:"args,omitempty"`
+	User       string    `json:"user"`
+	Host       string    `json:"host"`
+	Env        string    `json:"env"`
+	Reason     string    `json:"reason,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	Outcome    string    `json:"outcome"` // "success", "failed" or "refused"
+	Error      string    `json:"error,omitempty"`
+}
+
+// cliFlags holds the audit-related flags parsed from the command's arguments.
+type cliFlags struct {
+	force  bool // --force, or the older --confirm
+	reason string
+}
+
+func parseCLIFlags(args []string) cliFlags {
+	return cliFlags{
+		force:  hasFlag(args, "--force") || hasFlag(args, "--confirm"),
+		reason: strings.TrimSpace(flagValue(args, "--reason")),
+	}
+}
+
+// hasFlag reports whether name appears verbatim in args.
+func hasFlag(args []string, name string) bool {
+	for _, a := range args {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// flagValue returns the value of --name=value or --name value in args.
+func flagValue(args []string, name string) string {
+	for i, a := range args {
+		if v, ok := strings.CutPrefix(a, name+"="); ok {
+			return v
+		}
+		if a == name && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// audited runs fn for cmd and records the outcome. Destructive commands in
+// production are refused unless forced or confirmed.
+func audited(cmd string, args []string, fn func() error) error {
+	_ = config.Load()
+	flags := parseCLIFlags(args)
+	entry := AuditEntry{
+		Time:    time.Now().UTC(),
+		Command: cmd,
+		Args:    redactArgs(args),
+		User:    currentUser(),
+		Host:    hostname(),
+		Env:     config.AppEnv(),
+		Reason:  flags.reason,
+	}
+
+	if destructiveCommands[cmd] && isProduction(entry.Env) {
+		if err := requireConfirmation(cmd, &flags, os.Stdin, isTerminal(os.Stdin)); err != nil {
+			entry.Outcome = "refused"
+			entry.Error = err.Error()
+			recordAudit(entry)
+			return err
+		}
+		entry.Reason = flags.reason
+	}
+
+	err := fn()
+	entry.DurationMS = time.Since(entry.Time).Milliseconds()
+	entry.Outcome = "success"
+	if err != nil {
+		entry.Outcome = "failed"
+		entry.Error = err.Error()
+	}
+	recordAudit(entry)
+	return err
+}
+
+// requireConfirmation lets cmd run with --force, or on an interactive
+// terminal once the operator types the app name and gives a reason (asked
+// for when --reason was not passed). --force never prompts: forced runs
+// without a reason go ahead, with a warning, and the audit entry records it
+// empty.
+func requireConfirmation(cmd string, f *cliFlags, stdin io.Reader, interactive bool) error {
+	if f.force {
+		if f.reason == "" {
+			logger.Warn("cli: destructive command forced without a reason", "command", cmd)
+		}
+		return nil
+	}
+	if !interactive {
+		return fmt.Errorf("%s is destructive and APP_ENV is production: re-run with --force --reason \"...\"", cmd)
+	}
+	in := bufio.NewReader(stdin)
+	name := config.AppName()
+	fmt.Fprintf(os.Stderr, "APP_ENV is production and %s cannot be undone.\nType the application name (%s) to continue: ", cmd, name)
+	line, _ := in.ReadString('\n')
+	if strings.TrimSpace(line) != name {
+		return fmt.Errorf("%s aborted: confirmation did not match %q", cmd, name)
+	}
+	if f.reason == "" {
+		fmt.Fprintf(os.Stderr, "Reason for running %s in production: ", cmd)
+		line, _ := in.ReadString('\n')
+		f.reason = strings.TrimSpace(line)
+	}
+	if f.reason == "" {
+		return errors.New("a --reason is required for destructive commands in production")
+	}
+	return nil
+}
+
+func recordAudit(e AuditEntry) {
+	attrs := []any{
+		"audit", true,
+		"command", e.Command,
+		"args", e.Args,
+		"user", e.User,
+		"host", e.Host,
+		"env", e.Env,
+		"duration_ms", e.DurationMS,
+		"outcome", e.Outcome,
+	}
+	if e.Reason != "" || destructiveCommands[e.Command] {
+		attrs = append(attrs, "reason", e.Reason)
+	}
+	if e.Error != "" {
+		attrs = append(attrs, "error", e.Error)
+		logger.Warn("cli: command", attrs...)
+	} else {
+		logger.Info("cli: command", attrs...)
+	}
+
+	path := config.Get("CLI_AUDIT_LOG", "")
+	if path == "" {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		logger.Warn("cli: audit log unavailable", "path", path, "error", err)
+		return
+	}
+	defer f.Close()
+	_ = json.NewEncoder(f).Encode(e)
+}
+
+// redactArgs masks values of flags that look like credentials.
+func redactArgs(args []string) []string {
+	out := make([]string, len(args))
+	mask := false
+	for i, a := range args {
+		switch {
+		case mask:
+			out[i] = "[REDACTED]"
+			mask = false
+		case strings.HasPrefix(a, "-") && isSecretFlag(a):
+			if k, _, ok := strings.Cut(a, "="); ok {
+				out[i] = k + "=[REDACTED]"
+			} else {
+				out[i] = a
+				mask = true
+			}
+		default:
+			out[i] = a
+		}
+	}
+	return out
+}
+
+func isSecretFlag(flag string) bool {
+	k := strings.ToLower(flag)
+	for _, s := range []string{"password", "secret", "token", "key"} {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, k := range []string{"USER", "USERNAME", "LOGNAME"} {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return "unknown"
+}
+
+func hostname() string {
+	h, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return h
+}
+
+func isProduction(env string) bool {
+	return env == "production" || env == "prod"
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
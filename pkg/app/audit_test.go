@@ -0,0 +1,42 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/config"
+)
+
+func TestRequireConfirmation(t *testing.T) {
+	name := config.AppName()
+	cases := []struct {
+		name        string
+		flags       cliFlags
+		interactive bool
+		stdin       string
+		wantErr     bool
+		wantReason  string
+	}{
+		{"refused without force or terminal", cliFlags{}, false, "", true, ""},
+		{"forced in CI with a reason", cliFlags{force: true, reason: "OPS-1"}, false, "", false, "OPS-1"},
+		{"forced in CI without a reason", cliFlags{force: true}, false, "", false, ""},
+		{"confirmed at the prompt", cliFlags{}, true, name + "\nreset staging\n", false, "reset staging"},
+		{"wrong name at the prompt", cliFlags{}, true, "nope\nreset staging\n", true, ""},
+		{"forced at a terminal does not prompt", cliFlags{force: true}, true, "OPS-2\n", false, ""},
+		{"forced at a terminal keeps its reason", cliFlags{force: true, reason: "OPS-3"}, true, "", false, "OPS-3"},
+		{"confirmed with --reason", cliFlags{reason: "OPS-4"}, true, name + "\n", false, "OPS-4"},
+		{"empty reason at the prompt", cliFlags{}, true, name + "\n\n", true, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f := c.flags
+			err := requireConfirmation("migrate:fresh", &f, strings.NewReader(c.stdin), c.interactive)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, c.wantErr)
+			}
+			if err == nil && f.reason != c.wantReason {
+				t.Fatalf("reason = %q, want %q", f.reason, c.wantReason)
+			}
+		})
+	}
+}
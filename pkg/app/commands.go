@@ -4,12 +4,28 @@ package app
 // These are called from Application.Run() and use only framework packages.
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/internal/server"
+	"github.com/shashiranjanraj/kashvi/pkg/cache"
 	"github.com/shashiranjanraj/kashvi/pkg/database"
 	"github.com/shashiranjanraj/kashvi/pkg/migration"
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
+	"github.com/shashiranjanraj/kashvi/pkg/quota"
 	"github.com/shashiranjanraj/kashvi/pkg/router"
+	"github.com/shashiranjanraj/kashvi/pkg/schedule"
+	"github.com/shashiranjanraj/kashvi/pkg/storage"
+	"github.com/shashiranjanraj/kashvi/pkg/tracing"
+	"github.com/shashiranjanraj/kashvi/pkg/ws"
 )
 
 // cmdServe boots the HTTP + gRPC servers using the Application's handler.
@@ -17,28 +33,423 @@ func cmdServe(a *Application) error {
 	return startServer(a)
 }
 
-// cmdMigrate runs all pending migrations.
-func cmdMigrate() error {
+// migrationRunner connects to the database and returns a Runner for the
+// connection named by --database (default: primary).
+func migrationRunner(args []string) (*migration.Runner, error) {
 	if err := bootDB(); err != nil {
+		return nil, err
+	}
+	name := flagValue(args, "--database")
+	if name == "" {
+		name = migration.Primary
+	}
+	db, err := database.Connection(name)
+	if err != nil {
+		return nil, err
+	}
+	if name != migration.Primary {
+		fmt.Printf("Database: %s\n", name)
+	}
+	return migration.NewFor(db, name), nil
+}
+
+// cmdMigrate runs all pending migrations. With MIGRATION_GUARD=true, pending
+// migrations are analyzed first and unsafe ones are refused in production
+// unless --allow-unsafe is passed.
+func cmdMigrate(args []string) error {
+	r, err := migrationRunner(args)
+	if err != nil {
 		return err
 	}
-	return migration.New(database.DB).Run()
+	if migrationGuardEnabled() && isProduction(config.AppEnv()) && !hasFlag(args, "--allow-unsafe") {
+		findings, err := r.Check()
+		if err != nil {
+			return fmt.Errorf("migration guard: %w", err)
+		}
+		if len(findings) > 0 {
+			printFindings(findings)
+			return fmt.Errorf("refusing to run %d unsafe migration statement(s) in production; re-run with --allow-unsafe to override", len(findings))
+		}
+	}
+	return r.Run()
+}
+
+func migrationGuardEnabled() bool {
+	v := config.Get("MIGRATION_GUARD", "false")
+	return v == "true" || v == "1"
+}
+
+func printFindings(findings []migration.Finding) {
+	fmt.Println("⚠️  Unsafe migration statements:")
+	for _, f := range findings {
+		fmt.Printf("  %s\n", f)
+	}
+}
+
+// cmdMigratePretend prints the SQL each pending migration would execute.
+func cmdMigratePretend(args []string) error {
+	r, err := migrationRunner(args)
+	if err != nil {
+		return err
+	}
+	plans, err := r.Pretend()
+	if err != nil {
+		return err
+	}
+	if len(plans) == 0 {
+		fmt.Println("Nothing to migrate.")
+		return nil
+	}
+	if findings := migration.Analyze(r.Dialect(), plans); len(findings) > 0 {
+		printFindings(findings)
+		fmt.Println()
+	}
+	for _, p := range plans {
+		fmt.Printf("-- %s\n", p.Name)
+		if p.Note != "" {
+			fmt.Printf("-- (%s)\n", p.Note)
+		} else if len(p.SQL) == 0 {
+			fmt.Println("-- (no SQL)")
+		}
+		for _, stmt := range p.SQL {
+			fmt.Printf("%s;\n", stmt)
+		}
+		fmt.Println()
+	}
+	return nil
 }
 
 // cmdMigrateRollback reverses the last migration batch.
-func cmdMigrateRollback() error {
-	if err := bootDB(); err != nil {
+func cmdMigrateRollback(args []string) error {
+	r, err := migrationRunner(args)
+	if err != nil {
 		return err
 	}
-	return migration.New(database.DB).Rollback()
+	return r.Rollback()
+}
+
+// cmdMigrateFresh drops every table and re-runs all migrations.
+func cmdMigrateFresh(args []string) error {
+	r, err := migrationRunner(args)
+	if err != nil {
+		return err
+	}
+	return r.Fresh()
+}
+
+// cmdDBWipe drops every table.
+func cmdDBWipe(args []string) error {
+	r, err := migrationRunner(args)
+	if err != nil {
+		return err
+	}
+	return r.Wipe()
 }
 
 // cmdMigrateStatus prints migration status.
-func cmdMigrateStatus() error {
+func cmdMigrateStatus(args []string) error {
+	r, err := migrationRunner(args)
+	if err != nil {
+		return err
+	}
+	return r.Status()
+}
+
+// cmdQueueWork runs queue workers in the foreground until SIGINT/SIGTERM or
+// one of the restart limits is hit:
+//
+//	--queue=high,default  queues in priority order
+//	--concurrency=8       parallel jobs (alias --workers)
+//	--max-jobs=1000       exit after N jobs
+//	--max-time=1h         exit after a duration
+//	--memory=256MB        exit when memory use exceeds the limit
+//	--max-concurrency=32  autoscale up to 32 parallel jobs under backlog
+//	--drain-timeout=25s   stop waiting for in-flight jobs after SIGTERM
+//
+// It always exits 0 after draining in-flight jobs, so the supervisor simply
+// starts a fresh process. SIGTERM is the Kubernetes stop signal, so a rollout
+// never cuts a job short unless --drain-timeout expires first.
+func cmdQueueWork(a *Application, args []string) error {
+	opts, err := workerOptions(args)
+	if err != nil {
+		return err
+	}
+	defer startTracing()()
+	err = a.boot(func() error {
+		if err := bootDB(); err != nil {
+			return err
+		}
+		queue.UseDB(database.DB)
+		if err := queue.UseConfiguredDriver(); err != nil {
+			return err
+		}
+		queue.Use(storage.TempJobMiddleware())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	defer a.terminate()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("🚀 Queue worker started (queues: %s, concurrency: %d)\n", strings.Join(opts.Queues, ","), opts.Concurrency)
+	reason := queue.Work(ctx, opts)
+	fmt.Printf("⚡ Queue worker stopped (%s).\n", reason)
+	return nil
+}
+
+// startTracing enables tracing for a worker process when an OTLP endpoint
+// is configured (see pkg/tracing). The returned func exports buffered spans
+// before the process exits.
+func startTracing() func() {
+	if err := tracing.Init(tracing.DefaultOptions()); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning:", err)
+		return func() {}
+	}
+	if !tracing.Enabled() {
+		return func() {}
+	}
+	tracing.InstrumentQueue()
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tracing.Shutdown(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, "Warning:", err)
+		}
+	}
+}
+
+func workerOptions(args []string) (queue.WorkerOptions, error) {
+	opts := queue.WorkerOptions{Concurrency: 5}
+	if v := flagValue(args, "--queue"); v != "" {
+		for _, q := range strings.Split(v, ",") {
+			if q = strings.TrimSpace(q); q != "" {
+				opts.Queues = append(opts.Queues, q)
+			}
+		}
+	}
+	if len(opts.Queues) == 0 {
+		opts.Queues = []string{queue.DefaultQueue}
+	}
+
+	concurrency := flagValue(args, "--concurrency")
+	if concurrency == "" {
+		concurrency = flagValue(args, "--workers")
+	}
+	var err error
+	if concurrency != "" {
+		if opts.Concurrency, err = strconv.Atoi(concurrency); err != nil || opts.Concurrency < 1 {
+			return opts, fmt.Errorf("invalid --concurrency %q", concurrency)
+		}
+	}
+	if v := flagValue(args, "--max-jobs"); v != "" {
+		if opts.MaxJobs, err = strconv.Atoi(v); err != nil || opts.MaxJobs < 0 {
+			return opts, fmt.Errorf("invalid --max-jobs %q", v)
+		}
+	}
+	if v := flagValue(args, "--max-time"); v != "" {
+		if opts.MaxTime, err = time.ParseDuration(v); err != nil {
+			return opts, fmt.Errorf("invalid --max-time %q: %w", v, err)
+		}
+	}
+	if v := flagValue(args, "--memory"); v != "" {
+		if opts.MemoryLimit, err = queue.ParseByteSize(v); err != nil {
+			return opts, fmt.Errorf("invalid --memory %q: %w", v, err)
+		}
+	}
+	if v := flagValue(args, "--drain-timeout"); v != "" {
+		if opts.DrainTimeout, err = time.ParseDuration(v); err != nil {
+			return opts, fmt.Errorf("invalid --drain-timeout %q: %w", v, err)
+		}
+	}
+	if v := flagValue(args, "--max-concurrency"); v != "" {
+		if opts.MaxConcurrency, err = strconv.Atoi(v); err != nil || opts.MaxConcurrency < opts.Concurrency {
+			return opts, fmt.Errorf("invalid --max-concurrency %q (must be at least --concurrency)", v)
+		}
+	}
+	return opts, nil
+}
+
+// cmdQueueFailed lists jobs stored in kashvi_failed_jobs.
+func cmdQueueFailed() error {
+	if err := bootDB(); err != nil {
+		return err
+	}
+	queue.UseDB(database.DB)
+
+	records, err := queue.ListFailed()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Println("No failed jobs.")
+		return nil
+	}
+	fmt.Printf("%-6s  %-20s  %-12s  %-30s  %s\n", "ID", "FAILED AT", "QUEUE", "JOB", "ERROR")
+	for _, r := range records {
+		msg := r.Error
+		if len(msg) > 60 {
+			msg = msg[:57] + "..."
+		}
+		fmt.Printf("%-6d  %-20s  %-12s  %-30s  %s\n",
+			r.ID, r.FailedAt.Format("2006-01-02 15:04:05"), r.Queue, r.JobType, msg)
+	}
+	return nil
+}
+
+// cmdQueueRetry pushes failed jobs back onto their queue. Pass job IDs, or
+// "all" to retry every failed job.
+func cmdQueueRetry(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: queue:retry <id>... | all")
+	}
+	var ids []uint
+	if !(len(args) == 1 && args[0] == "all") {
+		for _, a := range args {
+			id, err := strconv.ParseUint(a, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid job id %q", a)
+			}
+			ids = append(ids, uint(id))
+		}
+	}
+
 	if err := bootDB(); err != nil {
 		return err
 	}
-	return migration.New(database.DB).Status()
+	// The in-memory queue dies with this process, so the jobs would be lost.
+	if d := config.Get("QUEUE_DRIVER", "memory"); d == "" || d == "memory" {
+		return fmt.Errorf("queue:retry needs a durable QUEUE_DRIVER (database or redis)")
+	}
+	queue.UseDB(database.DB)
+	if err := queue.UseConfiguredDriver(); err != nil {
+		return err
+	}
+
+	n, err := queue.RetryFailed(ids...)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🔁 Re-queued %d failed job(s).\n", n)
+	return nil
+}
+
+// cmdQuotaReport prints per-tenant request counts for billing. It rolls the
+// live counters up first, so the report includes today's traffic.
+//
+//	quota:report                    current month
+//	quota:report --month=2026-09
+//	quota:report --day=2026-10-18 --tenant=key:3f9a…
+func cmdQuotaReport(args []string) error {
+	period, bucket := quota.Month, time.Now().UTC().Format("2006-01")
+	if v := flagValue(args, "--month"); v != "" {
+		if _, err := time.Parse("2006-01", v); err != nil {
+			return fmt.Errorf("invalid --month %q (want YYYY-MM)", v)
+		}
+		bucket = v
+	}
+	if v := flagValue(args, "--day"); v != "" {
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			return fmt.Errorf("invalid --day %q (want YYYY-MM-DD)", v)
+		}
+		period, bucket = quota.Day, v
+	}
+
+	if err := bootDB(); err != nil {
+		return err
+	}
+	if err := cache.Connect(); err != nil {
+		fmt.Println("⚠️  Redis unavailable — showing the last rollup only.")
+	} else if _, err := quota.Rollup(context.Background(), database.DB); err != nil {
+		return err
+	}
+
+	records, err := quota.History(database.DB, period, bucket, flagValue(args, "--tenant"))
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Printf("No usage recorded for %s %s.\n", period, bucket)
+		return nil
+	}
+	fmt.Printf("Usage for %s %s\n\n", period, bucket)
+	fmt.Printf("%-40s  %12s\n", "TENANT", "REQUESTS")
+	var total int64
+	for _, r := range records {
+		fmt.Printf("%-40s  %12d\n", r.Tenant, r.Requests)
+		total += r.Requests
+	}
+	fmt.Printf("%-40s  %12d\n", "TOTAL", total)
+	return nil
+}
+
+// cmdScheduleWork runs the scheduler in its own long-lived process, so
+// scheduled tasks are not tied to the web server's lifetime.
+func cmdScheduleWork(a *Application) error {
+	if err := a.boot(bootScheduler); err != nil {
+		return err
+	}
+	defer a.terminate()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	printSchedule()
+	fmt.Println("🕐 Scheduler started. Press Ctrl+C to stop.")
+	schedule.Work(ctx)
+	fmt.Println("⚡ Scheduler stopped.")
+	return nil
+}
+
+// cmdScheduleRun evaluates due tasks once, waits for them and exits. Invoke
+// it every minute from cron or a Kubernetes CronJob.
+func cmdScheduleRun(a *Application) error {
+	if err := a.boot(bootScheduler); err != nil {
+		return err
+	}
+	defer a.terminate()
+	n := schedule.RunDue(time.Now())
+	fmt.Printf("Ran %d scheduled task(s).\n", n)
+	return nil
+}
+
+func bootScheduler() error {
+	if err := bootDB(); err != nil {
+		return err
+	}
+	return useScheduleStore()
+}
+
+// useScheduleStore persists scheduler last-run times per SCHEDULE_STORE:
+// "database" (default), "redis" or "memory".
+func useScheduleStore() error {
+	switch driver := config.Get("SCHEDULE_STORE", "database"); driver {
+	case "memory":
+		return nil
+	case "redis":
+		if err := cache.Connect(); err != nil {
+			return fmt.Errorf("schedule store: %w", err)
+		}
+		schedule.SetStore(schedule.NewRedisStore(cache.RDB))
+		return nil
+	case "database":
+		return schedule.UseDB(database.DB)
+	default:
+		return fmt.Errorf("schedule store: unknown SCHEDULE_STORE %q", driver)
+	}
+}
+
+func printSchedule() {
+	tasks := schedule.List()
+	if len(tasks) == 0 {
+		fmt.Println("No scheduled tasks registered.")
+		return
+	}
+	fmt.Println("Registered scheduled tasks:")
+	for _, t := range tasks {
+		fmt.Println("  •", t)
+	}
 }
 
 // cmdSeed runs all registered seeders (global + per-application).
@@ -84,6 +495,59 @@ func cmdRouteList(a *Application) error {
 	return nil
 }
 
+// cmdWSContract prints the contracts of every WebSocket hub. Routes are
+// built first, as in route:list, so hubs set up there are included.
+func cmdWSContract(a *Application, args []string) error {
+	r := router.New()
+	for _, fn := range a.routesFns {
+		fn(r)
+	}
+
+	doc := ws.Contracts()
+	var out []byte
+	if hasFlag(args, "--ts") {
+		out = []byte(doc.TypeScript())
+	} else {
+		b, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		out = append(b, '\n')
+	}
+	if path := flagValue(args, "--out"); path != "" {
+		if err := os.WriteFile(path, out, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %d hub contract(s) to %s\n", len(doc.Hubs), path)
+		return nil
+	}
+	_, err := os.Stdout.Write(out)
+	return err
+}
+
+// boot runs a worker command's startup the way serve runs its own: config,
+// the OnBoot hooks, connect (the command's services), then the OnBooted
+// hooks.
+func (a *Application) boot(connect func() error) error {
+	if err := config.Load(); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if err := server.RunBootHooks(a.serverOpt.BootHooks); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	return server.RunBootHooks(a.serverOpt.BootedHooks)
+}
+
+// terminate runs the OnShutdown hooks when a worker command stops.
+func (a *Application) terminate() {
+	if err := server.RunShutdownHooks(a.serverOpt); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: shutdown hook:", err)
+	}
+}
+
 // bootDB loads config and connects to the database.
 func bootDB() error {
 	if err := config.Load(); err != nil {
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ─── Console commands ─────────────────────────────────────────────────────────
+
+// consoleCommand is a project command registered with Application.Command.
+type consoleCommand struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	run         func(args []string) error
+}
+
+// builtinCommands are the names Run handles itself; keep in sync with its
+// switch. Application.Command refuses them.
+var builtinCommands = map[string]bool{
+	"serve": true, "start": true, "run": true, "s": true,
+	"migrate": true, "migrate:rollback": true, "migrate:down": true, "migrate:status": true,
+	"migrate:fresh": true, "db:wipe": true, "seed": true,
+	"queue:work": true, "queue:failed": true, "queue:retry": true, "quota:report": true,
+	"schedule:work": true, "schedule:run": true,
+	"route:list": true, "routes": true, "ws:contract": true, "command:list": true,
+	"plugin:list": true, "help": true, "--help": true, "-h": true,
+}
+
+// Command registers a project command. `go run . <name> [args...]` and
+// `kashvi <name> [args...]` run fn with the arguments after the name, once
+// config is loaded, the database is connected and the OnBoot/OnBooted hooks
+// have run; OnShutdown hooks run after it returns. Runs are written to the
+// audit log, and a non-nil error exits with status 1.
+//
+//	app.New().Command("report:daily", "Generate the daily report", func(args []string) error {
+//	    return reports.Daily(context.Background(), args)
+//	})
+//
+// Command panics if name is empty, is a built-in command or was already
+// registered.
+func (a *Application) Command(name, description string, fn func(args []string) error) *Application {
+	if name == "" || builtinCommands[name] {
+		panic(fmt.Sprintf("app: Command: %q is reserved", name))
+	}
+	if _, ok := a.command(name); ok {
+		panic(fmt.Sprintf("app: Command: %q registered twice", name))
+	}
+	a.commands = append(a.commands, consoleCommand{Name: name, Description: description, run: fn})
+	return a
+}
+
+func (a *Application) command(name string) (consoleCommand, bool) {
+	for _, c := range a.commands {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return consoleCommand{}, false
+}
+
+// runCommand runs a registered command inside the worker lifecycle.
+func runCommand(a *Application, c consoleCommand, args []string) error {
+	if err := a.boot(bootDB); err != nil {
+		return err
+	}
+	defer a.terminate()
+	return c.run(args)
+}
+
+// cmdCommandList prints the project's own commands. With --json it prints
+// them as a JSON array, which the kashvi CLI reads to list them in its help.
+func cmdCommandList(a *Application, args []string) error {
+	cmds := a.sortedCommands()
+	if hasFlag(args, "--json") {
+		if cmds == nil {
+			cmds = []consoleCommand{}
+		}
+		return json.NewEncoder(os.Stdout).Encode(cmds)
+	}
+	if len(cmds) == 0 {
+		fmt.Println("No project commands registered.")
+		return nil
+	}
+	printCommands(cmds)
+	return nil
+}
+
+func (a *Application) sortedCommands() []consoleCommand {
+	cmds := append([]consoleCommand(nil), a.commands...)
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+func printCommands(cmds []consoleCommand) {
+	for _, c := range cmds {
+		fmt.Printf("  %-16s %s\n", c.Name, c.Description)
+	}
+}
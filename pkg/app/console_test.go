@@ -0,0 +1,85 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what fn prints to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func noop([]string) error { return nil }
+
+func TestCommandRefusesReservedAndDuplicateNames(t *testing.T) {
+	for _, name := range []string{"", "serve", "migrate", "plugin:list", "help"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Command(%q) did not panic", name)
+				}
+			}()
+			New().Command(name, "", noop)
+		}()
+	}
+
+	a := New().Command("report:daily", "Daily report", noop)
+	defer func() {
+		if recover() == nil {
+			t.Error("registering report:daily twice did not panic")
+		}
+	}()
+	a.Command("report:daily", "again", noop)
+}
+
+func TestCommandListJSON(t *testing.T) {
+	a := New().
+		Command("report:daily", "Generate the daily report", noop).
+		Command("orders:sync", "Sync orders", noop)
+
+	out := captureStdout(t, func() {
+		if err := cmdCommandList(a, []string{"--json"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	var got []map[string]string
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("command:list --json printed %q: %v", out, err)
+	}
+	if len(got) != 2 || got[0]["name"] != "orders:sync" || got[1]["name"] != "report:daily" ||
+		got[1]["description"] != "Generate the daily report" {
+		t.Fatalf("command:list --json = %v, want both commands sorted by name", got)
+	}
+
+	out = captureStdout(t, func() { cmdCommandList(New(), []string{"--json"}) })
+	if strings.TrimSpace(out) != "[]" {
+		t.Fatalf("no commands: printed %q, want []", out)
+	}
+}
+
+func TestHelpListsProjectCommands(t *testing.T) {
+	a := New().Command("report:daily", "Generate the daily report", noop)
+	out := captureStdout(t, func() { printHelp(a) })
+	if !strings.Contains(out, "Project commands:") || !strings.Contains(out, "report:daily") {
+		t.Fatalf("help does not list report:daily:\n%s", out)
+	}
+}
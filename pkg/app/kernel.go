@@ -9,13 +9,19 @@ import (
 	"time"
 
 	"github.com/shashiranjanraj/kashvi/pkg/cache"
+	"github.com/shashiranjanraj/kashvi/pkg/clientip"
 	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/health"
+	"github.com/shashiranjanraj/kashvi/pkg/mail"
 	"github.com/shashiranjanraj/kashvi/pkg/metrics"
 	"github.com/shashiranjanraj/kashvi/pkg/middleware"
 	"github.com/shashiranjanraj/kashvi/pkg/orm"
-	"github.com/shashiranjanraj/kashvi/pkg/reqid"
+	"github.com/shashiranjanraj/kashvi/pkg/profiler"
+	"github.com/shashiranjanraj/kashvi/pkg/recorder"
 	"github.com/shashiranjanraj/kashvi/pkg/router"
 	"github.com/shashiranjanraj/kashvi/pkg/session"
+	"github.com/shashiranjanraj/kashvi/pkg/storage"
+	"github.com/shashiranjanraj/kashvi/pkg/tracing"
 )
 
 // buildHandler constructs the HTTP handler from the Application config.
@@ -34,22 +40,53 @@ func buildHandler(a *Application) http.Handler {
 
 	// Global middleware stack (outermost → innermost):
 	//  1. Prometheus metrics — outermost for accurate total latency
-	//  2. Recovery          — catches panics before they kill the goroutine
-	//  3. Request ID        — inject unique ID before anything logs
-	//  4. Logger            — logs request_id from context
-	//  5. Session           — load/create session cookie via Redis
-	//  6. CORS              — set CORS headers
-	//  7. Rate limiter      — reject abusers early
+	//  2. Profiler          — dev only: collects queries/logs for error pages
+	//  3. Tracing           — OpenTelemetry server span, when OTEL_EXPORTER_OTLP_ENDPOINT is set
+	//  4. Request ID        — X-Request-ID in/out, tagged logger before anything logs
+	//  5. Client IP         — resolve the real client behind trusted proxies
+	//  6. Logger            — logs request_id from context
+	//  7. Recovery          — catches panics, logs/reports them with the request's ID
+	//  8. Body limit        — cap request bodies (HTTP_MAX_BODY_BYTES; routes may override)
+	//  9. Timeout           — 503 after HTTP_HANDLER_TIMEOUT (routes may override)
+	// 10. Compression       — gzip/deflate/br responses, HTTP_COMPRESSION
+	// 11. Decompression     — decode Content-Encoding request bodies
+	// 12. Recorder          — opt-in capture of failing requests for replay
+	// 13. Chaos             — opt-in fault injection (never in production)
+	// 14. Session           — load/create session cookie via Redis
+	// 15. CORS              — set CORS headers (routes with their own CORS answer their preflights)
+	// 16. Rate limiter      — reject abusers early
+	// 17. Temp scope        — lazy scratch disk, removed when the request ends
 	r.Use(metrics.Middleware())
-	r.Use(middleware.Recovery)
-	r.Use(reqid.Middleware())
+	r.Use(profiler.Middleware)
+	r.Use(tracing.Middleware())
+	r.Use(middleware.RequestID())
+	r.Use(clientip.Middleware(clientip.DefaultOptions()))
 	r.Use(middleware.Logger)
+	r.Use(middleware.Recovery)
+	r.Use(middleware.BodyLimit(middleware.DefaultMaxBodyBytes()))
+	r.Use(middleware.TimeoutWith(middleware.DefaultTimeoutOptions()))
+	r.Use(middleware.Compress(middleware.DefaultCompressOptions()))
+	r.Use(middleware.Decompress(middleware.DefaultDecompressOptions()))
+	r.Use(recorder.Middleware(recorder.DefaultOptions()))
+	chaos := middleware.DefaultChaosOptions()
+	middleware.InstallChaosHooks(chaos)
+	r.Use(middleware.Chaos(chaos))
 	r.Use(session.Middleware(session.DefaultOptions()))
-	r.Use(middleware.CORS(middleware.DefaultCORSOptions()))
+	cors := middleware.DefaultCORSOptions()
+	cors.Skip = r.HasRoutePreflight
+	r.Use(middleware.CORS(cors))
 	r.Use(middleware.RateLimit(200, time.Minute))
+	r.Use(storage.TempMiddleware())
 
 	// Prometheus /metrics endpoint — no auth, no rate limit.
 	r.HandleFunc("/metrics", metrics.Handler())
+	// Liveness and readiness probes — /readyz runs every registered check.
+	r.HandleFunc("/healthz", health.LiveHandler())
+	r.HandleFunc("/readyz", health.ReadyHandler())
+	// Dev-only: browse mail rendered by MAIL_DRIVER=log.
+	if mail.PreviewEnabled() {
+		r.Mount(mail.PreviewPath, mail.PreviewHandler())
+	}
 
 	// Call every route-registration callback the user supplied.
 	for _, fn := range a.routesFns {
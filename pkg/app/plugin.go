@@ -0,0 +1,182 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/migration"
+	"github.com/shashiranjanraj/kashvi/pkg/router"
+)
+
+// ─── Plugins ──────────────────────────────────────────────────────────────────
+
+// Plugin is a third-party package that wires itself into an application,
+// e.g. a payments or CMS module:
+//
+//	app.New().Plugin(payments.Plugin{}, cms.New(cms.Options{})).Run()
+//
+// Register receives the builder and adds whatever the plugin provides with
+// the usual methods: Routes, AutoMigrate, Queue, Schedule, Command, Grpc,
+// OnBoot, OnShutdown, … Plugins may also implement PluginMigrations,
+// PluginConfig and PluginVersion.
+type Plugin interface {
+	// Name identifies the plugin in plugin:list, e.g. "payments".
+	Name() string
+	// Register adds the plugin's routes, models, jobs, commands and hooks.
+	Register(a *Application)
+}
+
+// PluginMigrations is implemented by plugins that ship migrations, keyed by
+// migration name. They run with the project's own, ordered by name, so use
+// make:migration style timestamps.
+type PluginMigrations interface {
+	Migrations() map[string]migration.Migration
+}
+
+// PluginConfig is implemented by plugins with default settings. The project's
+// app.json and .env override them.
+type PluginConfig interface {
+	ConfigDefaults() map[string]string
+}
+
+// PluginVersion is implemented by plugins that report their version in
+// plugin:list.
+type PluginVersion interface {
+	Version() string
+}
+
+// pluginInfo records what a plugin added, for plugin:list.
+type pluginInfo struct {
+	name, version, pkg string
+	routesFns          []func(*router.Router)
+	added              builderCounts
+	commands           []string
+	migrations         []string
+	config             []string
+}
+
+// builderCounts sizes the builder's registrations, so the difference before
+// and after Register is what a plugin added.
+type builderCounts struct {
+	routes, models, jobs, schedules, grpc, hooks, commands int
+}
+
+func (a *Application) counts() builderCounts {
+	o := a.serverOpt
+	return builderCounts{
+		routes:    len(a.routesFns),
+		models:    len(a.models),
+		jobs:      len(a.jobs),
+		schedules: len(a.scheduleFns),
+		grpc:      len(o.GRPCServices),
+		hooks:     len(o.BootHooks) + len(o.BootedHooks) + len(o.ShutdownHooks),
+		commands:  len(a.commands),
+	}
+}
+
+// Plugin registers plugins in order: config defaults first, then
+// migrations, then Register. It panics if two plugins share a name.
+func (a *Application) Plugin(plugins ...Plugin) *Application {
+	for _, p := range plugins {
+		a.plugin(p)
+	}
+	return a
+}
+
+func (a *Application) plugin(p Plugin) {
+	info := pluginInfo{name: p.Name(), pkg: pkgPath(p)}
+	for _, other := range a.plugins {
+		if other.name == info.name {
+			panic(fmt.Sprintf("app: Plugin: %q registered twice", info.name))
+		}
+	}
+	if v, ok := p.(PluginVersion); ok {
+		info.version = v.Version()
+	}
+	if c, ok := p.(PluginConfig); ok {
+		defaults := c.ConfigDefaults()
+		config.SetDefaults(defaults)
+		for k := range defaults {
+			info.config = append(info.config, strings.ToUpper(k))
+		}
+		sort.Strings(info.config)
+	}
+	if m, ok := p.(PluginMigrations); ok {
+		for name, mig := range m.Migrations() {
+			migration.Register(name, mig)
+			info.migrations = append(info.migrations, name)
+		}
+		sort.Strings(info.migrations)
+	}
+
+	before := a.counts()
+	p.Register(a)
+	after := a.counts()
+	info.added = builderCounts{
+		models:    after.models - before.models,
+		jobs:      after.jobs - before.jobs,
+		schedules: after.schedules - before.schedules,
+		grpc:      after.grpc - before.grpc,
+		hooks:     after.hooks - before.hooks,
+	}
+	info.routesFns = a.routesFns[before.routes:]
+	for _, c := range a.commands[before.commands:] {
+		info.commands = append(info.commands, c.Name)
+	}
+	a.plugins = append(a.plugins, info)
+}
+
+// pkgPath is the import path of the package that defines p's type.
+func pkgPath(p Plugin) string {
+	t := reflect.TypeOf(p)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.PkgPath()
+}
+
+// cmdPluginList prints the registered plugins and what each one added.
+func cmdPluginList(a *Application) error {
+	if len(a.plugins) == 0 {
+		fmt.Println("No plugins registered.")
+		return nil
+	}
+	for _, p := range a.plugins {
+		version := p.version
+		if version == "" {
+			version = "-"
+		}
+		fmt.Printf("%s  %s  (%s)\n", p.name, version, p.pkg)
+
+		r := router.New()
+		for _, fn := range p.routesFns {
+			fn(r)
+		}
+		var parts []string
+		add := func(n int, what string) {
+			if n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", n, what))
+			}
+		}
+		add(len(r.Routes()), "route(s)")
+		add(p.added.models, "model(s)")
+		add(len(p.migrations), "migration(s)")
+		add(p.added.jobs, "job(s)")
+		add(p.added.schedules, "schedule(s)")
+		add(p.added.grpc, "gRPC service(s)")
+		add(p.added.hooks, "lifecycle hook(s)")
+		if len(parts) > 0 {
+			fmt.Printf("    provides:  %s\n", strings.Join(parts, ", "))
+		}
+		if len(p.commands) > 0 {
+			fmt.Printf("    commands:  %s\n", strings.Join(p.commands, ", "))
+		}
+		if len(p.config) > 0 {
+			fmt.Printf("    config:    %s\n", strings.Join(p.config, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/migration"
+	"github.com/shashiranjanraj/kashvi/pkg/router"
+)
+
+type noopMigration struct{}
+
+func (noopMigration) Up(*gorm.DB) error   { return nil }
+func (noopMigration) Down(*gorm.DB) error { return nil }
+
+type paymentsPlugin struct{}
+
+func (paymentsPlugin) Name() string    { return "payments" }
+func (paymentsPlugin) Version() string { return "v1.2.0" }
+
+func (paymentsPlugin) ConfigDefaults() map[string]string {
+	return map[string]string{"payments_currency": "EUR"}
+}
+
+func (paymentsPlugin) Migrations() map[string]migration.Migration {
+	return map[string]migration.Migration{"20260101000000_create_payments": noopMigration{}}
+}
+
+func (paymentsPlugin) Register(a *Application) {
+	a.Routes(func(r *router.Router) {
+		r.Get("/payments", "payments.index", func(http.ResponseWriter, *http.Request) {})
+		r.Post("/payments", "payments.store", func(http.ResponseWriter, *http.Request) {})
+	}).
+		Command("payments:reconcile", "Reconcile payments", noop).
+		OnShutdown(func(context.Context) error { return nil })
+}
+
+type bare struct{ name string }
+
+func (b bare) Name() string        { return b.name }
+func (bare) Register(*Application) {}
+
+func TestPluginRegistersAndLists(t *testing.T) {
+	a := New().
+		Command("orders:sync", "Sync orders", noop). // the project's own, not the plugin's
+		Plugin(paymentsPlugin{}, bare{name: "cms"})
+
+	if got := config.Get("PAYMENTS_CURRENCY", ""); got != "EUR" {
+		t.Errorf("config default = %q, want EUR", got)
+	}
+	if _, ok := a.command("payments:reconcile"); !ok {
+		t.Error("plugin command not registered")
+	}
+
+	out := captureStdout(t, func() { cmdPluginList(a) })
+	for _, want := range []string{
+		"payments  v1.2.0  (github.com/shashiranjanraj/kashvi/pkg/app)",
+		"provides:  2 route(s), 1 migration(s), 1 lifecycle hook(s)",
+		"commands:  payments:reconcile\n",
+		"config:    PAYMENTS_CURRENCY",
+		"cms  -  (",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("plugin:list lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPluginRefusesDuplicateNames(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering two plugins named cms did not panic")
+		}
+	}()
+	New().Plugin(bare{name: "cms"}, bare{name: "cms"})
+}
+
+func TestPluginListWithoutPlugins(t *testing.T) {
+	out := captureStdout(t, func() { cmdPluginList(New()) })
+	if !strings.Contains(out, "No plugins registered.") {
+		t.Fatalf("plugin:list = %q", out)
+	}
+}
@@ -10,5 +10,5 @@ import "github.com/shashiranjanraj/kashvi/internal/server"
 // hands it to internal/server.Start for the actual listen+serve lifecycle.
 func startServer(a *Application) error {
 	handler := buildHandler(a)
-	return server.Start(handler)
+	return server.StartWithOptions(handler, a.serverOpt)
 }
@@ -1,4 +1,48 @@
 package app
 
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
 // Version is the current release version of the Kashvi framework.
-const Version = "1.0.0"
+const Version = "1.1.0"
+
+// ─── Release manifest ─────────────────────────────────────────────────────────
+
+// ReleasesFile is the release manifest's path inside the module. `kashvi
+// upgrade` reads it from the version it upgrades to.
+const ReleasesFile = "pkg/app/releases.json"
+
+//go:embed releases.json
+var releasesJSON []byte
+
+// Release is one entry of the release manifest.
+type Release struct {
+	Version string        `json:"version"` // semver with a leading v, e.g. v1.1.0
+	Notes   []ReleaseNote `json:"notes"`
+}
+
+// ReleaseNote tells projects what to check when upgrading past a release.
+// A note with a Symbol ("<import path>.Name" or "<import path>.Type.Method")
+// only concerns projects that use it; the others concern everyone.
+type ReleaseNote struct {
+	Symbol string `json:"symbol,omitempty"`
+	Text   string `json:"text"`
+}
+
+// Releases returns the manifest built into this binary, newest first.
+func Releases() ([]Release, error) {
+	return ParseReleases(releasesJSON)
+}
+
+// ParseReleases decodes a release manifest, e.g. ReleasesFile read from
+// another version of the module.
+func ParseReleases(data []byte) ([]Release, error) {
+	var releases []Release
+	if err := json.Unmarshal(data, &releases); err != nil {
+		return nil, fmt.Errorf("app: release manifest: %w", err)
+	}
+	return releases, nil
+}
@@ -0,0 +1,316 @@
+// Package billing adds Stripe subscriptions: customer and subscription
+// tables, Checkout and Billing Portal sessions, and a webhook endpoint that
+// keeps local state in sync so access checks never call Stripe.
+//
+//	STRIPE_SECRET=sk_live_…
+//	STRIPE_WEBHOOK_SECRET=whsec_…
+//
+//	// Start a subscription
+//	sess, err := billing.NewClient().Checkout(ctx, billing.CheckoutParams{
+//	    Owner: user, PriceID: "price_pro", TrialDays: 14,
+//	    SuccessURL: "https://app.example.com/billing/done",
+//	    CancelURL:  "https://app.example.com/pricing",
+//	})
+//	c.Redirect(http.StatusSeeOther, sess.URL)
+//
+//	// Receive webhooks
+//	r.Post("/stripe/webhook", "billing.webhook", billing.WebhookHandler(database.DB))
+//
+//	// Gate features
+//	if billing.Subscribed(database.DB, user, "default") { … }
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shashiranjanraj/kashvi/config"
+)
+
+// DefaultName is the subscription name used when none is given.
+const DefaultName = "default"
+
+// ErrNotConfigured is returned when STRIPE_SECRET is empty.
+var ErrNotConfigured = errors.New("billing: STRIPE_SECRET is not set")
+
+// Owner is anything that can hold a subscription — typically the user or
+// team model. The ID must be stable; it is stored locally and sent to Stripe
+// as client_reference_id and customer metadata.
+type Owner interface {
+	BillingOwnerID() string
+}
+
+// Emailer can be implemented by an Owner to prefill the Stripe customer.
+type Emailer interface {
+	BillingEmail() string
+}
+
+// ─── Client ───────────────────────────────────────────────────────────────────
+
+// Client calls the Stripe REST API.
+type Client struct {
+	Secret  string
+	BaseURL string
+	HTTP    *http.Client
+	// DB stores customers; defaults to the db passed to UseDB.
+	DB *gorm.DB
+}
+
+var defaultDB *gorm.DB
+
+// UseDB sets the database NewClient uses for customer records.
+func UseDB(db *gorm.DB) { defaultDB = db }
+
+// NewClient reads STRIPE_SECRET and uses the database set with UseDB.
+func NewClient() *Client {
+	return &Client{
+		Secret:  config.Get("STRIPE_SECRET", ""),
+		BaseURL: "https://api.stripe.com",
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+		DB:      defaultDB,
+	}
+}
+
+// APIError is an error response from Stripe.
+type APIError struct {
+	Status  int
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("billing: stripe %d %s: %s", e.Status, e.Type, e.Message)
+}
+
+// post sends a form-encoded request, as the Stripe API expects, and decodes
+// the JSON response into out.
+func (c *Client) post(ctx context.Context, path string, form url.Values, out any) error {
+	if c.Secret == "" {
+		return ErrNotConfigured
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("billing: %s: %w", path, err)
+	}
+	req.SetBasicAuth(c.Secret, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("billing: %s: %w", path, err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("billing: %s: %w", path, err)
+	}
+	if res.StatusCode >= 300 {
+		var wrapped struct {
+			Error APIError `json:"error"`
+		}
+		_ = json.Unmarshal(body, &wrapped)
+		wrapped.Error.Status = res.StatusCode
+		return &wrapped.Error
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("billing: %s: decode: %w", path, err)
+	}
+	return nil
+}
+
+// ─── Customers ────────────────────────────────────────────────────────────────
+
+// Customer returns the owner's Stripe customer, creating it on first use.
+func (c *Client) Customer(ctx context.Context, owner Owner) (*Customer, error) {
+	if c.DB == nil {
+		return nil, errors.New("billing: no database (call billing.UseDB)")
+	}
+	var cust Customer
+	err := c.DB.WithContext(ctx).Where("owner_id = ?", owner.BillingOwnerID()).First(&cust).Error
+	if err == nil {
+		return &cust, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("billing: find customer: %w", err)
+	}
+
+	form := url.Values{"metadata[owner_id]": {owner.BillingOwnerID()}}
+	if e, ok := owner.(Emailer); ok && e.BillingEmail() != "" {
+		form.Set("email", e.BillingEmail())
+	}
+	var created struct {
+		ID    string `json:"id"`
+		Email string `json:"email"`
+	}
+	if err := c.post(ctx, "/v1/customers", form, &created); err != nil {
+		return nil, err
+	}
+	cust = Customer{OwnerID: owner.BillingOwnerID(), StripeID: created.ID, Email: created.Email}
+	if err := c.DB.WithContext(ctx).Create(&cust).Error; err != nil {
+		return nil, fmt.Errorf("billing: save customer: %w", err)
+	}
+	return &cust, nil
+}
+
+// ─── Checkout & portal ────────────────────────────────────────────────────────
+
+// CheckoutParams describes a subscription Checkout session.
+type CheckoutParams struct {
+	Owner      Owner
+	PriceID    string
+	Quantity   int64 // default 1
+	TrialDays  int
+	SuccessURL string
+	CancelURL  string
+	// Name is the local subscription name (default "default"), for apps
+	// with several independent subscriptions per owner.
+	Name string
+}
+
+// Session is a hosted Stripe page to redirect the user to.
+type Session struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// Checkout creates a Stripe Checkout session for a new subscription. The
+// local subscription row is created by the webhook once payment succeeds.
+func (c *Client) Checkout(ctx context.Context, p CheckoutParams) (*Session, error) {
+	cust, err := c.Customer(ctx, p.Owner)
+	if err != nil {
+		return nil, err
+	}
+	if p.Quantity <= 0 {
+		p.Quantity = 1
+	}
+	if p.Name == "" {
+		p.Name = DefaultName
+	}
+	form := url.Values{
+		"mode":                                  {"subscription"},
+		"customer":                              {cust.StripeID},
+		"client_reference_id":                   {p.Owner.BillingOwnerID()},
+		"line_items[0][price]":                  {p.PriceID},
+		"line_items[0][quantity]":               {strconv.FormatInt(p.Quantity, 10)},
+		"success_url":                           {p.SuccessURL},
+		"cancel_url":                            {p.CancelURL},
+		"subscription_data[metadata][owner_id]": {p.Owner.BillingOwnerID()},
+		"subscription_data[metadata][kashvi_name]": {p.Name},
+	}
+	if p.TrialDays > 0 {
+		form.Set("subscription_data[trial_period_days]", strconv.Itoa(p.TrialDays))
+	}
+	var sess Session
+	if err := c.post(ctx, "/v1/checkout/sessions", form, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// BillingPortal creates a Stripe Billing Portal session where the owner can
+// update cards, switch plans, download invoices and cancel.
+func (c *Client) BillingPortal(ctx context.Context, owner Owner, returnURL string) (*Session, error) {
+	cust, err := c.Customer(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	var sess Session
+	form := url.Values{"customer": {cust.StripeID}, "return_url": {returnURL}}
+	if err := c.post(ctx, "/v1/billing_portal/sessions", form, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// Cancel cancels sub at the end of the paid period, so the owner keeps
+// access during the grace period. The local row is updated right away;
+// the webhook Stripe sends afterwards is idempotent.
+func (c *Client) Cancel(ctx context.Context, sub *Subscription) error {
+	var obj stripeSubscription
+	form := url.Values{"cancel_at_period_end": {"true"}}
+	if err := c.post(ctx, "/v1/subscriptions/"+sub.StripeID, form, &obj); err != nil {
+		return err
+	}
+	return syncSubscription(ctx, c.DB, obj)
+}
+
+// ─── Owner helpers ────────────────────────────────────────────────────────────
+
+// SubscriptionFor returns the owner's named subscription ("" = "default"),
+// or nil if there is none.
+func SubscriptionFor(db *gorm.DB, owner Owner, name string) *Subscription {
+	if name == "" {
+		name = DefaultName
+	}
+	var sub Subscription
+	err := db.Where("owner_id = ? AND name = ?", owner.BillingOwnerID(), name).
+		Order("created_at desc").First(&sub).Error
+	if err != nil {
+		return nil
+	}
+	return &sub
+}
+
+// Subscribed reports whether the owner has a valid named subscription,
+// optionally on one of prices.
+func Subscribed(db *gorm.DB, owner Owner, name string, prices ...string) bool {
+	sub := SubscriptionFor(db, owner, name)
+	if sub == nil || !sub.Valid() {
+		return false
+	}
+	if len(prices) == 0 {
+		return true
+	}
+	for _, p := range prices {
+		if sub.PriceID == p {
+			return true
+		}
+	}
+	return false
+}
+
+// OnTrial reports whether the owner's named subscription is in its trial.
+func OnTrial(db *gorm.DB, owner Owner, name string) bool {
+	sub := SubscriptionFor(db, owner, name)
+	return sub != nil && sub.OnTrial()
+}
+
+// OnGracePeriod reports whether the owner cancelled but is still paid up.
+func OnGracePeriod(db *gorm.DB, owner Owner, name string) bool {
+	sub := SubscriptionFor(db, owner, name)
+	return sub != nil && sub.OnGracePeriod()
+}
+
+// Trial can be embedded in a user model for trials that start at sign-up,
+// before any card or Stripe subscription exists.
+//
+//	type User struct {
+//	    gorm.Model
+//	    billing.Trial
+//	}
+//	user.StartTrial(14 * 24 * time.Hour)
+type Trial struct {
+	TrialEndsAt *time.Time `json:"trial_ends_at"`
+}
+
+// StartTrial sets the trial to end d from now.
+func (t *Trial) StartTrial(d time.Duration) {
+	end := time.Now().Add(d)
+	t.TrialEndsAt = &end
+}
+
+// OnGenericTrial reports whether the sign-up trial is still running.
+func (t Trial) OnGenericTrial() bool {
+	return t.TrialEndsAt != nil && time.Now().Before(*t.TrialEndsAt)
+}
@@ -0,0 +1,184 @@
+package billing_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shashiranjanraj/kashvi/pkg/billing"
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/event"
+)
+
+type user struct{ id string }
+
+func (u user) BillingOwnerID() string { return u.id }
+func (u user) BillingEmail() string   { return u.id + "@example.com" }
+
+func testDB(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	db, err := database.OpenMemory(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (billing.Migration{}).Up(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func sign(payload []byte, secret string, ts time.Time) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", ts.Unix())
+	mac.Write(payload)
+	return fmt.Sprintf("t=%d,v1=%s", ts.Unix(), hex.EncodeToString(mac.Sum(nil)))
+}
+
+func subEvent(id, status string, extra map[string]any) billing.Event {
+	obj := map[string]any{
+		"id": "sub_1", "customer": "cus_1", "status": status,
+		"current_period_end": time.Now().Add(30 * 24 * time.Hour).Unix(),
+		"metadata":           map[string]string{"owner_id": "u1"},
+		"items":              map[string]any{"data": []any{map[string]any{"quantity": 2, "price": map[string]string{"id": "price_pro"}}}},
+	}
+	for k, v := range extra {
+		obj[k] = v
+	}
+	raw, _ := json.Marshal(obj)
+	ev := billing.Event{ID: id, Type: "customer.subscription.updated"}
+	ev.Data.Object = raw
+	return ev
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+func TestVerifySignature(t *testing.T) {
+	payload := []byte(`{"id":"evt_1"}`)
+	now := time.Now()
+	if err := billing.VerifySignature(payload, sign(payload, "whsec", now), "whsec", now); err != nil {
+		t.Fatalf("valid signature: %v", err)
+	}
+	if err := billing.VerifySignature(payload, sign(payload, "other", now), "whsec", now); !errors.Is(err, billing.ErrBadSignature) {
+		t.Fatalf("wrong secret: %v", err)
+	}
+	if err := billing.VerifySignature(payload, sign(payload, "whsec", now.Add(-time.Hour)), "whsec", now); !errors.Is(err, billing.ErrStale) {
+		t.Fatalf("old timestamp: %v", err)
+	}
+	if err := billing.VerifySignature(payload, sign(payload, "", now), "", now); !errors.Is(err, billing.ErrNoSecret) {
+		t.Fatalf("empty secret must never verify: %v", err)
+	}
+}
+
+func TestHandleEvent_SyncsSubscriptionAndGracePeriod(t *testing.T) {
+	db := testDB(t, "billing_events")
+	ctx := context.Background()
+	u := user{"u1"}
+
+	var fired atomic.Int32
+	event.On("billing.customer.subscription.updated", func(any) { fired.Add(1) })
+
+	if err := billing.HandleEvent(ctx, db, subEvent("evt_1", billing.StatusActive, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if !billing.Subscribed(db, u, "", "price_pro") || billing.OnGracePeriod(db, u, "") {
+		t.Fatal("active subscription not synced")
+	}
+	if sub := billing.SubscriptionFor(db, u, ""); sub.Quantity != 2 {
+		t.Fatalf("quantity = %d, want 2", sub.Quantity)
+	}
+
+	cancel := subEvent("evt_2", billing.StatusActive, map[string]any{"cancel_at_period_end": true})
+	if err := billing.HandleEvent(ctx, db, cancel); err != nil {
+		t.Fatal(err)
+	}
+	// Redelivery is acknowledged but not reapplied.
+	if err := billing.HandleEvent(ctx, db, cancel); err != nil {
+		t.Fatal(err)
+	}
+	if !billing.OnGracePeriod(db, u, "") || !billing.Subscribed(db, u, "") {
+		t.Fatal("cancelled subscription should stay valid until the period ends")
+	}
+	if n := fired.Load(); n != 2 {
+		t.Fatalf("listener fired %d times, want 2 (redelivery ignored)", n)
+	}
+
+	ended := subEvent("evt_3", billing.StatusCanceled, map[string]any{"ended_at": time.Now().Add(-time.Minute).Unix()})
+	if err := billing.HandleEvent(ctx, db, ended); err != nil {
+		t.Fatal(err)
+	}
+	if billing.Subscribed(db, u, "") {
+		t.Fatal("ended subscription should not be valid")
+	}
+}
+
+func TestCheckout_CreatesCustomerOnce(t *testing.T) {
+	db := testDB(t, "billing_checkout")
+	var customers atomic.Int32
+	stripe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, _ := r.BasicAuth(); user != "sk_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/v1/customers":
+			customers.Add(1)
+			fmt.Fprintf(w, `{"id":"cus_1","email":%q}`, r.FormValue("email"))
+		case "/v1/checkout/sessions":
+			if r.FormValue("customer") != "cus_1" || r.FormValue("subscription_data[trial_period_days]") != "14" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":{"type":"invalid_request_error","message":"bad form"}}`)
+				return
+			}
+			fmt.Fprint(w, `{"id":"cs_1","url":"https://checkout.stripe.com/c/cs_1"}`)
+		case "/v1/billing_portal/sessions":
+			fmt.Fprint(w, `{"id":"bps_1","url":"https://billing.stripe.com/p/bps_1"}`)
+		}
+	}))
+	defer stripe.Close()
+
+	c := &billing.Client{Secret: "sk_test", BaseURL: stripe.URL, HTTP: stripe.Client(), DB: db}
+	ctx := context.Background()
+	sess, err := c.Checkout(ctx, billing.CheckoutParams{
+		Owner: user{"u1"}, PriceID: "price_pro", TrialDays: 14,
+		SuccessURL: "https://app/ok", CancelURL: "https://app/cancel",
+	})
+	if err != nil || !strings.HasPrefix(sess.URL, "https://checkout.stripe.com/") {
+		t.Fatalf("Checkout = %+v, %v", sess, err)
+	}
+	if _, err := c.BillingPortal(ctx, user{"u1"}, "https://app/account"); err != nil {
+		t.Fatal(err)
+	}
+	if n := customers.Load(); n != 1 {
+		t.Fatalf("created %d Stripe customers, want 1", n)
+	}
+
+	var apiErr *billing.APIError
+	_, err = c.Checkout(ctx, billing.CheckoutParams{Owner: user{"u1"}, PriceID: "price_pro"})
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadRequest {
+		t.Fatalf("Stripe error not surfaced: %v", err)
+	}
+}
+
+func TestTrial(t *testing.T) {
+	var tr billing.Trial
+	if tr.OnGenericTrial() {
+		t.Fatal("zero Trial should not be on trial")
+	}
+	tr.StartTrial(time.Hour)
+	if !tr.OnGenericTrial() {
+		t.Fatal("StartTrial did not start a trial")
+	}
+}
@@ -0,0 +1,122 @@
+package billing
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shashiranjanraj/kashvi/pkg/migration"
+)
+
+// Subscription statuses as reported by Stripe.
+const (
+	StatusActive     = "active"
+	StatusTrialing   = "trialing"
+	StatusPastDue    = "past_due"
+	StatusUnpaid     = "unpaid"
+	StatusCanceled   = "canceled"
+	StatusIncomplete = "incomplete"
+)
+
+// Customer links an app-side owner (usually a user or team) to its Stripe
+// customer.
+type Customer struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	OwnerID   string `gorm:"size:191;not null;uniqueIndex" json:"owner_id"`
+	StripeID  string `gorm:"size:191;not null;uniqueIndex" json:"stripe_id"`
+	Email     string `gorm:"size:255" json:"email"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Customer) TableName() string { return "kashvi_billing_customers" }
+
+// Subscription mirrors a Stripe subscription. Webhooks keep it in sync, so
+// checks never call Stripe.
+type Subscription struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	OwnerID  string `gorm:"size:191;not null;index" json:"owner_id"`
+	Name     string `gorm:"size:100;not null;default:default" json:"name"`
+	StripeID string `gorm:"size:191;not null;uniqueIndex" json:"stripe_id"`
+	Status   string `gorm:"size:32;not null" json:"status"`
+	PriceID  string `gorm:"size:191" json:"price_id"`
+	Quantity int64  `gorm:"not null;default:1" json:"quantity"`
+	// TrialEndsAt is set while Stripe reports a trial.
+	TrialEndsAt *time.Time `json:"trial_ends_at"`
+	// EndsAt is set once the subscription is cancelled; until then the
+	// owner keeps access (the grace period).
+	EndsAt           *time.Time `json:"ends_at"`
+	CurrentPeriodEnd *time.Time `json:"current_period_end"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func (Subscription) TableName() string { return "kashvi_billing_subscriptions" }
+
+// OnTrial reports whether the subscription is in its trial.
+func (s *Subscription) OnTrial() bool {
+	return s.TrialEndsAt != nil && time.Now().Before(*s.TrialEndsAt)
+}
+
+// Canceled reports whether the subscription has been cancelled, even if
+// it is still in its grace period.
+func (s *Subscription) Canceled() bool { return s.EndsAt != nil }
+
+// OnGracePeriod reports whether a cancelled subscription is still paid up.
+func (s *Subscription) OnGracePeriod() bool {
+	return s.EndsAt != nil && time.Now().Before(*s.EndsAt)
+}
+
+// Ended reports whether a cancelled subscription has run out.
+func (s *Subscription) Ended() bool { return s.Canceled() && !s.OnGracePeriod() }
+
+// PastDue reports whether Stripe failed to collect the latest payment.
+func (s *Subscription) PastDue() bool { return s.Status == StatusPastDue }
+
+// Valid reports whether the owner should have access: active, trialing,
+// or cancelled but within the grace period. Past-due subscriptions stay
+// valid while Stripe retries payment, unless DenyPastDue is set.
+func (s *Subscription) Valid() bool {
+	switch {
+	case s.OnTrial(), s.OnGracePeriod():
+		return true
+	case s.Ended():
+		return false
+	case s.Status == StatusActive, s.Status == StatusTrialing:
+		return true
+	case s.Status == StatusPastDue:
+		return !DenyPastDue
+	}
+	return false
+}
+
+// DenyPastDue makes past-due subscriptions invalid immediately instead of
+// during Stripe's payment retries.
+var DenyPastDue = false
+
+// processedEvent records webhook event IDs so redeliveries are ignored.
+type processedEvent struct {
+	ID          string    `gorm:"primaryKey;size:191"`
+	Type        string    `gorm:"size:100"`
+	ProcessedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (processedEvent) TableName() string { return "kashvi_billing_events" }
+
+// ─── Migration ────────────────────────────────────────────────────────────────
+
+// Migration creates the billing tables. It is registered automatically when
+// pkg/billing is imported, so `kashvi migrate` picks it up.
+type Migration struct{}
+
+func (Migration) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Customer{}, &Subscription{}, &processedEvent{})
+}
+
+func (Migration) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&processedEvent{}, &Subscription{}, &Customer{})
+}
+
+func init() {
+	migration.Register("20261018000000_create_kashvi_billing_tables", Migration{})
+}
@@ -0,0 +1,270 @@
+package billing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/event"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/response"
+)
+
+// Signature verification errors.
+var (
+	ErrNoSignature  = errors.New("billing: missing Stripe-Signature header")
+	ErrBadSignature = errors.New("billing: webhook signature mismatch")
+	ErrStale        = errors.New("billing: webhook timestamp outside tolerance")
+	ErrNoSecret     = errors.New("billing: STRIPE_WEBHOOK_SECRET is not set")
+)
+
+// Tolerance is how far a webhook timestamp may be from now.
+const Tolerance = 5 * time.Minute
+
+// maxWebhookBody caps the payload read from Stripe.
+const maxWebhookBody = 1 << 20
+
+// Event is a Stripe webhook event. Listeners registered with
+// event.On("billing.<type>", …) receive it after local state is synced:
+//
+//	event.On("billing.invoice.payment_failed", func(p any) {
+//	    ev := p.(billing.Event)
+//	    …
+//	})
+type Event struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// VerifySignature checks a Stripe-Signature header ("t=…,v1=…") against
+// payload using the endpoint secret. Any matching v1 signature is accepted,
+// which covers secret rotation.
+func VerifySignature(payload []byte, header, secret string, now time.Time) error {
+	if secret == "" {
+		return ErrNoSecret // an empty key would accept anyone's signature
+	}
+	if header == "" {
+		return ErrNoSignature
+	}
+	var ts int64
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts, _ = strconv.ParseInt(v, 10, 64)
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	if ts == 0 || len(sigs) == 0 {
+		return ErrNoSignature
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > Tolerance || d < -Tolerance {
+		return ErrStale
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", ts)
+	mac.Write(payload)
+	want := mac.Sum(nil)
+	for _, s := range sigs {
+		if got, err := hex.DecodeString(s); err == nil && hmac.Equal(got, want) {
+			return nil
+		}
+	}
+	return ErrBadSignature
+}
+
+// WebhookHandler verifies Stripe webhooks with STRIPE_WEBHOOK_SECRET,
+// syncs customers and subscriptions into db, and fires "billing.<type>"
+// events. Redelivered events are acknowledged without reprocessing.
+// Exclude the route from CSRF protection.
+func WebhookHandler(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Could not read body")
+			return
+		}
+		secret := config.Get("STRIPE_WEBHOOK_SECRET", "")
+		if err := VerifySignature(payload, r.Header.Get("Stripe-Signature"), secret, time.Now()); err != nil {
+			logger.Warn("billing: rejected webhook", "error", err)
+			response.Error(w, http.StatusBadRequest, "Invalid signature")
+			return
+		}
+		var ev Event
+		if err := json.Unmarshal(payload, &ev); err != nil || ev.ID == "" {
+			response.Error(w, http.StatusBadRequest, "Invalid event")
+			return
+		}
+
+		if err := HandleEvent(r.Context(), db, ev); err != nil {
+			// Stripe retries non-2xx responses with backoff.
+			logger.Error("billing: webhook failed", "event", ev.ID, "type", ev.Type, "error", err)
+			response.Error(w, http.StatusInternalServerError, "Webhook processing failed")
+			return
+		}
+		response.Success(w, map[string]bool{"received": true})
+	}
+}
+
+// HandleEvent applies a verified event to db once. It is exported for
+// replaying events fetched from the Stripe API.
+func HandleEvent(ctx context.Context, db *gorm.DB, ev Event) error {
+	fresh := false
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&processedEvent{ID: ev.ID, Type: ev.Type})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil // already processed
+		}
+		fresh = true
+		return apply(ctx, tx, ev)
+	})
+	if err != nil || !fresh {
+		return err
+	}
+	// After commit, so listeners see the synced rows.
+	event.Fire("billing."+ev.Type, ev)
+	return nil
+}
+
+// ─── Sync ─────────────────────────────────────────────────────────────────────
+
+type stripeSubscription struct {
+	ID                string            `json:"id"`
+	Customer          string            `json:"customer"`
+	Status            string            `json:"status"`
+	TrialEnd          int64             `json:"trial_end"`
+	CancelAtPeriodEnd bool              `json:"cancel_at_period_end"`
+	CancelAt          int64             `json:"cancel_at"`
+	EndedAt           int64             `json:"ended_at"`
+	CurrentPeriodEnd  int64             `json:"current_period_end"`
+	Metadata          map[string]string `json:"metadata"`
+	Items             struct {
+		Data []struct {
+			Quantity int64 `json:"quantity"`
+			Price    struct {
+				ID string `json:"id"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+func apply(ctx context.Context, tx *gorm.DB, ev Event) error {
+	switch ev.Type {
+	case "checkout.session.completed":
+		var s struct {
+			Customer          string `json:"customer"`
+			ClientReferenceID string `json:"client_reference_id"`
+			CustomerEmail     string `json:"customer_email"`
+		}
+		if err := json.Unmarshal(ev.Data.Object, &s); err != nil {
+			return err
+		}
+		if s.Customer == "" || s.ClientReferenceID == "" {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&Customer{
+			OwnerID: s.ClientReferenceID, StripeID: s.Customer, Email: s.CustomerEmail,
+		}).Error
+
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+		var s stripeSubscription
+		if err := json.Unmarshal(ev.Data.Object, &s); err != nil {
+			return err
+		}
+		return syncSubscription(ctx, tx, s)
+
+	case "customer.deleted":
+		var c struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(ev.Data.Object, &c); err != nil {
+			return err
+		}
+		return tx.Where("stripe_id = ?", c.ID).Delete(&Customer{}).Error
+	}
+	return nil
+}
+
+func unixPtr(sec int64) *time.Time {
+	if sec == 0 {
+		return nil
+	}
+	t := time.Unix(sec, 0)
+	return &t
+}
+
+// syncSubscription upserts the local copy of a Stripe subscription.
+func syncSubscription(ctx context.Context, db *gorm.DB, s stripeSubscription) error {
+	if db == nil {
+		return errors.New("billing: no database (call billing.UseDB)")
+	}
+	db = db.WithContext(ctx)
+
+	owner := s.Metadata["owner_id"]
+	if owner == "" {
+		var cust Customer
+		if err := db.Where("stripe_id = ?", s.Customer).First(&cust).Error; err != nil {
+			return fmt.Errorf("billing: subscription %s has no known owner: %w", s.ID, err)
+		}
+		owner = cust.OwnerID
+	}
+
+	sub := Subscription{StripeID: s.ID}
+	if err := db.Where("stripe_id = ?", s.ID).First(&sub).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	sub.OwnerID = owner
+	if sub.Name == "" {
+		sub.Name = s.Metadata["kashvi_name"]
+		if sub.Name == "" {
+			sub.Name = DefaultName
+		}
+	}
+	sub.Status = s.Status
+	sub.Quantity = 1
+	if len(s.Items.Data) > 0 {
+		sub.PriceID = s.Items.Data[0].Price.ID
+		sub.Quantity = s.Items.Data[0].Quantity
+	}
+	sub.TrialEndsAt = unixPtr(s.TrialEnd)
+	sub.CurrentPeriodEnd = unixPtr(s.CurrentPeriodEnd)
+
+	switch {
+	case s.Status == StatusCanceled:
+		sub.EndsAt = unixPtr(s.EndedAt)
+		if sub.EndsAt == nil {
+			now := time.Now()
+			sub.EndsAt = &now
+		}
+	case s.CancelAtPeriodEnd:
+		sub.EndsAt = sub.CurrentPeriodEnd
+	case s.CancelAt != 0:
+		sub.EndsAt = unixPtr(s.CancelAt)
+	default:
+		sub.EndsAt = nil // resumed
+	}
+	return db.Save(&sub).Error
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/metrics"
 	"github.com/shashiranjanraj/kashvi/pkg/validate"
 )
 
@@ -22,25 +24,52 @@ func maxBodyBytes() int64 {
 }
 
 // JSON decodes r.Body as JSON into dest and runs validation.
-// The body is capped at MAX_BODY_BYTES (default 4 MB) to prevent memory exhaustion.
+// The body is capped at MAX_BODY_BYTES (default 4 MB) to prevent memory exhaustion,
+// and its shape is checked against DefaultLimits before decoding. `sanitize`
+// tags are applied after decoding, before the validation rules run.
 // Returns (errs, nil) when there are validation failures.
-// Returns (nil, err) when the body is malformed JSON or too large.
+// Returns (nil, err) when the body is malformed JSON; a *LimitError when it
+// is too large or too deeply nested.
 func JSON(r *http.Request, dest interface{}) (errs map[string]string, err error) {
-	r.Body = http.MaxBytesReader(nil, r.Body, maxBodyBytes())
+	data, err := ReadJSON(r)
+	if err != nil {
+		return nil, err
+	}
 
-	dec := json.NewDecoder(r.Body)
-	if err = dec.Decode(dest); err != nil {
-		var maxErr *http.MaxBytesError
-		if errors.As(err, &maxErr) {
-			return nil, fmt.Errorf("request body too large (max %d bytes)", maxErr.Limit)
-		}
+	if err = json.Unmarshal(data, dest); err != nil {
 		return nil, fmt.Errorf("invalid JSON: %w", err)
 	}
 
-	errs = validate.Struct(dest)
+	validate.Sanitize(dest)
+	errs = validate.StructCtx(r.Context(), dest)
 	if validate.HasErrors(errs) {
 		return errs, nil
 	}
 
 	return nil, nil
 }
+
+// ReadJSON reads r.Body under the same limits as JSON without decoding it,
+// for callers that decode the payload in parts (see pkg/bulk).
+func ReadJSON(r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxBodyBytes())
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, rejected(&LimitError{Reason: ReasonBodySize, Limit: maxErr.Limit})
+		}
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := CheckJSON(data, DefaultLimits()); err != nil {
+		return nil, rejected(err.(*LimitError))
+	}
+	return data, nil
+}
+
+// rejected counts a refused payload and returns e.
+func rejected(e *LimitError) error {
+	metrics.PayloadRejected.WithLabelValues(e.Reason).Inc()
+	return e
+}
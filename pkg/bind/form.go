@@ -0,0 +1,201 @@
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/validate"
+)
+
+// maxUploadBytes returns the multipart body limit (default 32 MB).
+func maxUploadBytes() int64 {
+	n, err := strconv.ParseInt(config.Get("UPLOAD_MAX_BYTES", "33554432"), 10, 64)
+	if err != nil || n <= 0 {
+		return 32 << 20
+	}
+	return n
+}
+
+// multipartMemory is how much of a multipart body is kept in memory before
+// file parts spill to temporary files.
+const multipartMemory = 8 << 20
+
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// Form binds a multipart/form-data or application/x-www-form-urlencoded
+// body into dest, applies `sanitize` tags and runs validation — the form
+// counterpart of JSON.
+//
+// Fields are matched by the `form` tag, falling back to the json name.
+// Supported kinds: strings, ints, uints, floats, bools, their pointers and
+// slices, plus *multipart.FileHeader and []*multipart.FileHeader for
+// uploads (validated with the file rules: mimes=, max_size=, dimensions=…).
+//
+//	type AvatarInput struct {
+//	    Name   string                `form:"name"   validate:"required"`
+//	    Avatar *multipart.FileHeader `form:"avatar" validate:"required,image,max_size=2MB"`
+//	}
+//
+// The body is capped at UPLOAD_MAX_BYTES (default 32 MB). Values that do
+// not parse into their field type are reported in errs like rule failures.
+func Form(r *http.Request, dest interface{}) (errs map[string]string, err error) {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("bind: Form needs a pointer to a struct, got %T", dest)
+	}
+
+	r.Body = http.MaxBytesReader(nil, r.Body, maxUploadBytes())
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		err = r.ParseMultipartForm(multipartMemory)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, rejected(&LimitError{Reason: ReasonBodySize, Limit: maxErr.Limit})
+		}
+		return nil, fmt.Errorf("invalid form: %w", err)
+	}
+
+	errs = make(map[string]string)
+	fillForm(r, rv.Elem(), errs)
+
+	validate.Sanitize(dest)
+	for field, msg := range validate.StructCtx(r.Context(), dest) {
+		if _, exists := errs[field]; !exists {
+			errs[field] = msg
+		}
+	}
+	if validate.HasErrors(errs) {
+		return errs, nil
+	}
+	return nil, nil
+}
+
+func fillForm(r *http.Request, rv reflect.Value, errs map[string]string) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := formName(f)
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+
+		switch {
+		case f.Type == fileHeaderType:
+			if fhs := formFiles(r, name); len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+			continue
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem() == fileHeaderType:
+			if fhs := formFiles(r, name); len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+			continue
+		}
+
+		values, ok := r.Form[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if err := setField(fv, values); err != nil {
+			errs[name] = fmt.Sprintf("The %s field %s.", name, err.Error())
+		}
+	}
+}
+
+func formFiles(r *http.Request, name string) []*multipart.FileHeader {
+	if r.MultipartForm == nil {
+		return nil
+	}
+	return r.MultipartForm.File[name]
+}
+
+func formName(f reflect.StructField) string {
+	tag := f.Tag.Get("form")
+	if tag == "" {
+		tag = f.Tag.Get("json")
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return strings.ToLower(f.Name)
+}
+
+func setField(fv reflect.Value, values []string) error {
+	switch fv.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(fv.Type().Elem())
+		if err := setField(elem.Elem(), values); err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	case reflect.Slice:
+		out := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setScalar(out.Index(i), v); err != nil {
+				return err
+			}
+		}
+		fv.Set(out)
+		return nil
+	}
+	return setScalar(fv, values[0])
+}
+
+func setScalar(fv reflect.Value, s string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		if s == "" || s == "on" {
+			fv.SetBool(s == "on")
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("must be a positive integer")
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		fv.SetFloat(n)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package bind_test
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/bind"
+)
+
+type avatarInput struct {
+	Name   string                `form:"name"   sanitize:"trim" validate:"required"`
+	Age    int                   `form:"age"`
+	Avatar *multipart.FileHeader `form:"avatar" validate:"required,mimes=png,jpg,max_size=1KB,dimensions=min_width=2,ratio=1"`
+}
+
+func pngBytes(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func multipartRequest(t *testing.T, fields map[string]string, filename string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		_ = mw.WriteField(k, v)
+	}
+	if filename != "" {
+		fw, _ := mw.CreateFormFile("avatar", filename)
+		_, _ = fw.Write(content)
+	}
+	_ = mw.Close()
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestForm(t *testing.T) {
+	var in avatarInput
+	errs, err := bind.Form(multipartRequest(t, map[string]string{"name": " neo ", "age": "30"}, "me.png", pngBytes(t, 4, 4)), &in)
+	if err != nil || errs != nil {
+		t.Fatalf("Form: errs=%v err=%v", errs, err)
+	}
+	if in.Name != "neo" || in.Age != 30 || in.Avatar.Filename != "me.png" {
+		t.Errorf("bound %+v", in)
+	}
+}
+
+func TestFormFileRules(t *testing.T) {
+	cases := []struct {
+		name, filename string
+		content        []byte
+		fields         map[string]string
+		field, want    string
+	}{
+		{"missing", "", nil, map[string]string{"name": "x"}, "avatar", "The avatar field is required."},
+		{"renamed text", "evil.png", []byte("not an image at all"), map[string]string{"name": "x"}, "avatar", "The avatar must be a file of type: png,jpg."},
+		{"too large", "big.png", append(pngBytes(t, 4, 4), make([]byte, 2048)...), map[string]string{"name": "x"}, "avatar", "The avatar must not be greater than 1KB."},
+		{"ratio", "wide.png", pngBytes(t, 8, 2), map[string]string{"name": "x"}, "avatar", "The avatar has invalid image dimensions."},
+		{"bad int", "me.png", pngBytes(t, 4, 4), map[string]string{"name": "x", "age": "old"}, "age", "The age field must be an integer."},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var in avatarInput
+			errs, err := bind.Form(multipartRequest(t, tc.fields, tc.filename, tc.content), &in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if errs[tc.field] != tc.want {
+				t.Errorf("%s = %q, want %q (all: %v)", tc.field, errs[tc.field], tc.want, errs)
+			}
+		})
+	}
+}
+
+func TestFormURLEncoded(t *testing.T) {
+	var in struct {
+		Tags []string `form:"tag"`
+		Done bool     `form:"done"`
+	}
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("tag=a&tag=b&done=on"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, err := bind.Form(r, &in); err != nil {
+		t.Fatal(err)
+	}
+	if len(in.Tags) != 2 || !in.Done {
+		t.Errorf("bound %+v", in)
+	}
+}
@@ -0,0 +1,153 @@
+package bind
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/shashiranjanraj/kashvi/config"
+)
+
+// Limits bounds the shape of a JSON body before it is decoded, so deeply
+// nested or enormous documents are rejected in a single linear pass instead
+// of being handed to encoding/json. Zero disables a limit.
+type Limits struct {
+	MaxDepth    int // nesting of objects/arrays
+	MaxArrayLen int // elements in any single array
+	MaxTokens   int // values + keys in the whole document
+}
+
+// DefaultLimits reads JSON_MAX_DEPTH (default 64), JSON_MAX_ARRAY_LEN
+// (default 10000) and JSON_MAX_TOKENS (default 100000).
+func DefaultLimits() Limits {
+	return Limits{
+		MaxDepth:    envLimit("JSON_MAX_DEPTH", 64),
+		MaxArrayLen: envLimit("JSON_MAX_ARRAY_LEN", 10000),
+		MaxTokens:   envLimit("JSON_MAX_TOKENS", 100000),
+	}
+}
+
+func envLimit(key string, def int) int {
+	n, err := strconv.Atoi(config.Get(key, strconv.Itoa(def)))
+	if err != nil || n < 0 {
+		return def
+	}
+	return n
+}
+
+// Limit reasons reported by LimitError and the rejected-payload metric.
+const (
+	ReasonBodySize    = "body_size"
+	ReasonDepth       = "depth"
+	ReasonArrayLength = "array_length"
+	ReasonTokens      = "tokens"
+)
+
+// LimitError reports a body rejected for its size or shape.
+type LimitError struct {
+	Reason string
+	Limit  int64
+}
+
+func (e *LimitError) Error() string {
+	switch e.Reason {
+	case ReasonBodySize:
+		return fmt.Sprintf("request body too large (max %d bytes)", e.Limit)
+	case ReasonDepth:
+		return fmt.Sprintf("JSON nested too deeply (max depth %d)", e.Limit)
+	case ReasonArrayLength:
+		return fmt.Sprintf("JSON array too long (max %d elements)", e.Limit)
+	default:
+		return fmt.Sprintf("JSON document too large (max %d values)", e.Limit)
+	}
+}
+
+// Status is the HTTP status to answer with: 413 for sheer size, 422 for a
+// well-sized document with an unacceptable shape.
+func (e *LimitError) Status() int {
+	switch e.Reason {
+	case ReasonDepth, ReasonArrayLength:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusRequestEntityTooLarge
+	}
+}
+
+// CheckJSON scans data and returns a *LimitError if it breaks lim. It does
+// not validate syntax — malformed input is left for the decoder to report.
+func CheckJSON(data []byte, lim Limits) error {
+	// arrays[i] is the element count of the i-th open container, or -1 for
+	// objects.
+	arrays := make([]int, 0, 16)
+	tokens := 0
+	inString, escaped, inScalar := false, false, false
+
+	startValue := func() error {
+		tokens++
+		if lim.MaxTokens > 0 && tokens > lim.MaxTokens {
+			return &LimitError{Reason: ReasonTokens, Limit: int64(lim.MaxTokens)}
+		}
+		if n := len(arrays); n > 0 && arrays[n-1] >= 0 {
+			arrays[n-1]++
+			if lim.MaxArrayLen > 0 && arrays[n-1] > lim.MaxArrayLen {
+				return &LimitError{Reason: ReasonArrayLength, Limit: int64(lim.MaxArrayLen)}
+			}
+		}
+		return nil
+	}
+
+	for _, b := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+		if inScalar {
+			switch b {
+			case ' ', '\t', '\r', '\n', ',', ':', ']', '}':
+				inScalar = false
+			default:
+				continue
+			}
+		}
+
+		switch b {
+		case ' ', '\t', '\r', '\n', ',', ':':
+		case '"':
+			// Object keys count as tokens but not as array elements;
+			// startValue only bumps the count inside arrays.
+			if err := startValue(); err != nil {
+				return err
+			}
+			inString = true
+		case '{', '[':
+			if err := startValue(); err != nil {
+				return err
+			}
+			if lim.MaxDepth > 0 && len(arrays) >= lim.MaxDepth {
+				return &LimitError{Reason: ReasonDepth, Limit: int64(lim.MaxDepth)}
+			}
+			if b == '[' {
+				arrays = append(arrays, 0)
+			} else {
+				arrays = append(arrays, -1)
+			}
+		case '}', ']':
+			if len(arrays) > 0 {
+				arrays = arrays[:len(arrays)-1]
+			}
+		default:
+			if err := startValue(); err != nil {
+				return err
+			}
+			inScalar = true
+		}
+	}
+	return nil
+}
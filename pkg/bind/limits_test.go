@@ -0,0 +1,36 @@
+package bind_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/bind"
+)
+
+func TestCheckJSON(t *testing.T) {
+	lim := bind.Limits{MaxDepth: 3, MaxArrayLen: 3, MaxTokens: 20}
+
+	cases := []struct {
+		name, body, reason string
+	}{
+		{"flat object", `{"a":1,"b":"x","c":[1,2,3]}`, ""},
+		{"brackets inside strings", `{"a":"[[[[[[{{{{","b":"\"]"}`, ""},
+		{"depth", `{"a":{"b":{"c":{}}}}`, bind.ReasonDepth},
+		{"array length", `[1,2,3,4]`, bind.ReasonArrayLength},
+		{"nested array length", `{"a":[[1],[2],[3],[4]]}`, bind.ReasonArrayLength},
+		{"tokens", `{` + strings.Repeat(`"k":1,`, 10) + `"z":1}`, bind.ReasonTokens},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := bind.CheckJSON([]byte(tc.body), lim)
+			var le *bind.LimitError
+			switch {
+			case tc.reason == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.reason != "" && (!errors.As(err, &le) || le.Reason != tc.reason):
+				t.Errorf("err = %v, want reason %s", err, tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+// Package bulk turns a handler for one item into a batch endpoint: it binds
+// a JSON array, validates every element, processes the valid ones with
+// bounded concurrency and answers with a per-item multi-status report.
+//
+//	type CreateUser struct {
+//	    Name  string `json:"name"  validate:"required"`
+//	    Email string `json:"email" validate:"required,email"`
+//	}
+//
+//	r.Post("/users/bulk", "users.bulk", ctx.Wrap(func(c *ctx.Context) {
+//	    bulk.Create(c, func(ctx context.Context, in CreateUser) (any, error) {
+//	        u := models.User{Name: in.Name, Email: in.Email}
+//	        return u, orm.WithCtx(ctx).Create(&u)
+//	    })
+//	}))
+//
+// The body is either an array or {"items": [...]}. One bad item never fails
+// the batch: the response is 200 when every item succeeded and 207 Multi-Status
+// otherwise, with one result per item in request order:
+//
+//	{"status": 207, "message": "1 of 2 items failed", "data": {
+//	    "total": 2, "succeeded": 1, "failed": 1,
+//	    "results": [
+//	        {"index": 0, "status": 201, "data": {"id": 7, ...}},
+//	        {"index": 1, "status": 422, "error": "Validation failed", "errors": {"email": "email must be a valid email"}}
+//	    ]}}
+//
+// Only problems with the payload as a whole (not JSON, not an array, too many
+// items, over the bind limits) fail the request, with the usual error envelope.
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/bind"
+	"github.com/shashiranjanraj/kashvi/pkg/ctx"
+	kerrors "github.com/shashiranjanraj/kashvi/pkg/errors"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/validate"
+	"gorm.io/gorm"
+)
+
+// Func processes one valid item and returns the data reported for it.
+type Func[T any] func(ctx context.Context, item T) (any, error)
+
+// Options configures a batch.
+type Options struct {
+	// MaxItems rejects larger batches with 422 before any item runs.
+	MaxItems int
+	// Concurrency is how many items are processed at once; 1 processes
+	// them in order.
+	Concurrency int
+	// SuccessStatus is the per-item status of a processed item: 200, or 201
+	// for creates.
+	SuccessStatus int
+}
+
+// DefaultOptions reads BULK_MAX_ITEMS (default 1000) and BULK_CONCURRENCY
+// (default 8).
+func DefaultOptions() Options {
+	return Options{
+		MaxItems:      envInt("BULK_MAX_ITEMS", 1000),
+		Concurrency:   envInt("BULK_CONCURRENCY", 8),
+		SuccessStatus: http.StatusOK,
+	}
+}
+
+func envInt(key string, def int) int {
+	n, err := strconv.Atoi(config.Get(key, strconv.Itoa(def)))
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+// Handle runs fn over the request's items with DefaultOptions.
+func Handle[T any](c *ctx.Context, fn Func[T]) {
+	HandleWith(c, DefaultOptions(), fn)
+}
+
+// Create is Handle reporting 201 for each processed item.
+func Create[T any](c *ctx.Context, fn Func[T]) {
+	opts := DefaultOptions()
+	opts.SuccessStatus = http.StatusCreated
+	HandleWith(c, opts, fn)
+}
+
+// ─── Errors ───────────────────────────────────────────────────────────────────
+
+// Error is an item failure with the status and message to report for it.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Fail returns an item error reported with status and message, e.g.
+// bulk.Fail(409, "email already taken").
+func Fail(status int, message string) error {
+	return &Error{Status: status, Message: message}
+}
+
+// ─── Results ──────────────────────────────────────────────────────────────────
+
+// Result is the outcome of one item.
+type Result struct {
+	Index  int               `json:"index"`
+	Status int               `json:"status"`
+	Data   any               `json:"data,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// OK reports whether the item succeeded.
+func (r Result) OK() bool { return r.Status < 400 }
+
+// Report summarises a batch; it is the data of the response envelope.
+type Report struct {
+	Total     int      `json:"total"`
+	Succeeded int      `json:"succeeded"`
+	Failed    int      `json:"failed"`
+	Results   []Result `json:"results"`
+}
+
+type envelope struct {
+	Status  int    `json:"status"`
+	Message string `json:"message,omitempty"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// HandleWith binds, validates and processes the request's items and writes
+// the multi-status response.
+func HandleWith[T any](c *ctx.Context, opts Options, fn Func[T]) {
+	items, status, err := decode(c.R, opts.MaxItems)
+	if err != nil {
+		c.Error(status, err.Error())
+		return
+	}
+
+	report := Run(c.Context(), items, opts, fn)
+	if report.Failed == 0 {
+		c.JSON(http.StatusOK, envelope{Status: http.StatusOK, Data: report})
+		return
+	}
+	c.JSON(http.StatusMultiStatus, envelope{
+		Status:  http.StatusMultiStatus,
+		Message: fmt.Sprintf("%d of %d items failed", report.Failed, report.Total),
+		Data:    report,
+	})
+}
+
+// Run decodes and validates each raw item into T and passes the valid ones
+// to fn, at most opts.Concurrency at a time. Use it directly for batches that
+// do not come from a request body, such as a queued import.
+func Run[T any](ctx context.Context, items []json.RawMessage, opts Options, fn Func[T]) Report {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.SuccessStatus == 0 {
+		opts.SuccessStatus = http.StatusOK
+	}
+
+	report := Report{Total: len(items), Results: make([]Result, len(items))}
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for i, raw := range items {
+		var item T
+		if err := json.Unmarshal(raw, &item); err != nil {
+			report.Results[i] = Result{Index: i, Status: http.StatusUnprocessableEntity, Error: "invalid item: " + err.Error()}
+			continue
+		}
+		validate.Sanitize(&item)
+		if errs := validate.StructCtx(ctx, &item); len(errs) > 0 {
+			report.Results[i] = Result{Index: i, Status: http.StatusUnprocessableEntity, Error: validate.Message, Errors: errs}
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, item T) {
+			defer func() { <-sem; wg.Done() }()
+			report.Results[i] = process(ctx, i, item, opts.SuccessStatus, fn)
+		}(i, item)
+	}
+	wg.Wait()
+
+	for _, r := range report.Results {
+		if r.OK() {
+			report.Succeeded++
+		}
+	}
+	report.Failed = report.Total - report.Succeeded
+	return report
+}
+
+// process runs fn for one item, turning its error or panic into a result.
+func process[T any](ctx context.Context, i int, item T, success int, fn Func[T]) (res Result) {
+	defer func() {
+		if p := recover(); p != nil {
+			kerrors.Report(ctx, kerrors.Event{Panic: p, Tags: map[string]string{"bulk.index": strconv.Itoa(i)}})
+			res = Result{Index: i, Status: http.StatusInternalServerError, Error: http.StatusText(http.StatusInternalServerError)}
+		}
+	}()
+
+	data, err := fn(ctx, item)
+	if err == nil {
+		return Result{Index: i, Status: success, Data: data}
+	}
+
+	var itemErr *Error
+	switch v, isViolation := validate.AsViolations(err); {
+	case isViolation:
+		return Result{Index: i, Status: http.StatusUnprocessableEntity, Error: validate.Message, Errors: v}
+	case errors.As(err, &itemErr):
+		return Result{Index: i, Status: itemErr.Status, Error: itemErr.Message}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return Result{Index: i, Status: http.StatusNotFound, Error: http.StatusText(http.StatusNotFound)}
+	default:
+		logger.WithCtx(ctx).Error("bulk: item failed", "index", i, "error", err)
+		return Result{Index: i, Status: http.StatusInternalServerError, Error: http.StatusText(http.StatusInternalServerError)}
+	}
+}
+
+// decode reads the body as an array of items or {"items": [...]}, returning
+// the status to answer with when the payload as a whole is unusable.
+func decode(r *http.Request, maxItems int) ([]json.RawMessage, int, error) {
+	data, err := bind.ReadJSON(r)
+	if err != nil {
+		var limitErr *bind.LimitError
+		if errors.As(err, &limitErr) {
+			return nil, limitErr.Status(), err
+		}
+		return nil, http.StatusBadRequest, err
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		var wrapped struct {
+			Items *[]json.RawMessage `json:"items"`
+		}
+		if json.Unmarshal(data, &wrapped) != nil || wrapped.Items == nil {
+			if !json.Valid(data) {
+				return nil, http.StatusBadRequest, errors.New("invalid JSON")
+			}
+			return nil, http.StatusUnprocessableEntity, errors.New(`expected an array of items or {"items": [...]}`)
+		}
+		items = *wrapped.Items
+	}
+	switch {
+	case len(items) == 0:
+		return nil, http.StatusUnprocessableEntity, errors.New("no items given")
+	case maxItems > 0 && len(items) > maxItems:
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("too many items: %d (max %d)", len(items), maxItems)
+	}
+	return items, 0, nil
+}
@@ -0,0 +1,139 @@
+package bulk_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/bulk"
+	"github.com/shashiranjanraj/kashvi/pkg/ctx"
+)
+
+type item struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,email"`
+}
+
+type response struct {
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
+	Data    bulk.Report `json:"data"`
+}
+
+func serve(t *testing.T, body string, h ctx.HandlerFunc) (int, response) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/users/bulk", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	ctx.Wrap(h).ServeHTTP(rec, req)
+	var res response
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, res
+}
+
+func TestHandleReportsEachItem(t *testing.T) {
+	body := `[
+		{"name": "Ada", "email": "ada@example.com"},
+		{"name": "", "email": "nope"},
+		{"name": "Taken", "email": "taken@example.com"},
+		"not an object",
+		{"name": "Boom", "email": "boom@example.com"},
+		{"name": "Oops", "email": "oops@example.com"}
+	]`
+	code, res := serve(t, body, func(c *ctx.Context) {
+		bulk.Create(c, func(_ context.Context, in item) (any, error) {
+			switch in.Name {
+			case "Taken":
+				return nil, bulk.Fail(http.StatusConflict, "email already taken")
+			case "Boom":
+				panic("boom")
+			case "Oops":
+				return nil, errors.New("db down")
+			}
+			return map[string]string{"name": in.Name}, nil
+		})
+	})
+
+	if code != http.StatusMultiStatus || res.Status != http.StatusMultiStatus {
+		t.Fatalf("status = %d/%d, want 207", code, res.Status)
+	}
+	if res.Data.Total != 6 || res.Data.Succeeded != 1 || res.Data.Failed != 5 {
+		t.Fatalf("summary = %+v", res.Data)
+	}
+	want := []int{201, 422, 409, 422, 500, 500}
+	for i, r := range res.Data.Results {
+		if r.Index != i || r.Status != want[i] {
+			t.Errorf("result %d = %+v, want status %d", i, r, want[i])
+		}
+	}
+	if errs := res.Data.Results[1].Errors; errs["name"] == "" || errs["email"] == "" {
+		t.Errorf("validation errors = %v", errs)
+	}
+	if msg := res.Data.Results[2].Error; msg != "email already taken" {
+		t.Errorf("fail message = %q", msg)
+	}
+	if msg := res.Data.Results[5].Error; strings.Contains(msg, "db down") {
+		t.Errorf("internal error leaked: %q", msg)
+	}
+}
+
+func TestHandleAllSucceeded(t *testing.T) {
+	code, res := serve(t, `{"items": [{"name": "A", "email": "a@example.com"}]}`, func(c *ctx.Context) {
+		bulk.Handle(c, func(_ context.Context, in item) (any, error) { return in.Name, nil })
+	})
+	if code != http.StatusOK || res.Data.Succeeded != 1 || res.Data.Results[0].Data != "A" {
+		t.Fatalf("code = %d, res = %+v", code, res)
+	}
+}
+
+func TestHandleRejectsPayload(t *testing.T) {
+	noop := func(c *ctx.Context) {
+		opts := bulk.DefaultOptions()
+		opts.MaxItems = 2
+		bulk.HandleWith(c, opts, func(context.Context, item) (any, error) { return nil, nil })
+	}
+	cases := map[string]int{
+		`{`:                  http.StatusBadRequest,
+		`{"name": "single"}`: http.StatusUnprocessableEntity,
+		`[]`:                 http.StatusUnprocessableEntity,
+		`[{}, {}, {}]`:       http.StatusUnprocessableEntity,
+	}
+	for body, want := range cases {
+		if code, _ := serve(t, body, noop); code != want {
+			t.Errorf("%s: status = %d, want %d", body, code, want)
+		}
+	}
+}
+
+func TestRunBoundsConcurrency(t *testing.T) {
+	items := make([]json.RawMessage, 20)
+	for i := range items {
+		items[i] = json.RawMessage(`{"name": "n", "email": "n@example.com"}`)
+	}
+	var running, peak atomic.Int32
+	report := bulk.Run(context.Background(), items, bulk.Options{Concurrency: 3}, func(context.Context, item) (any, error) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		return nil, nil
+	})
+	if report.Succeeded != 20 {
+		t.Fatalf("succeeded = %d", report.Succeeded)
+	}
+	if p := peak.Load(); p > 3 {
+		t.Fatalf("peak concurrency = %d, want <= 3", p)
+	}
+}
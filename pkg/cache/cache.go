@@ -2,12 +2,12 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/codec"
 )
 
 var RDB *redis.Client
@@ -41,20 +41,21 @@ func Get(key string, dest interface{}) bool {
 		return false
 	}
 
-	if err := json.Unmarshal([]byte(val), dest); err != nil {
+	if err := codec.Decode([]byte(val), dest); err != nil {
 		return false
 	}
 
 	return true
 }
 
-// Set stores value in Redis under key for the given TTL.
+// Set stores value in Redis under key for the given TTL. Values are JSON
+// unless they select another codec (see codec.Selector).
 func Set(key string, value interface{}, ttl time.Duration) error {
 	if RDB == nil {
 		return nil
 	}
 
-	data, err := json.Marshal(value)
+	data, err := codec.Encode(value)
 	if err != nil {
 		return err
 	}
@@ -62,6 +63,22 @@ func Set(key string, value interface{}, ttl time.Duration) error {
 	return RDB.Set(Ctx, key, data, ttl).Err()
 }
 
+// Add stores value under key for ttl only if the key does not exist yet,
+// and reports whether it was stored. Without Redis it stores nothing and
+// returns false.
+func Add(key string, value interface{}, ttl time.Duration) (bool, error) {
+	if RDB == nil {
+		return false, nil
+	}
+
+	data, err := codec.Encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	return RDB.SetNX(Ctx, key, data, ttl).Result()
+}
+
 // Del removes one or more keys from Redis.
 func Del(keys ...string) error {
 	if RDB == nil {
@@ -0,0 +1,166 @@
+// Package cachecontrol builds Cache-Control, Expires and Vary headers from
+// named policies, so every endpoint that means "public for five minutes"
+// sends the same headers.
+//
+//	cachecontrol.Public(5 * time.Minute)                       // CDN and browser
+//	cachecontrol.Private(time.Minute)                          // browser only
+//	cachecontrol.NoStore()                                     // never cached
+//	cachecontrol.StaleWhileRevalidate(time.Minute, time.Hour)  // serve stale, refresh behind
+//
+// Apply a policy per route with middleware.CacheControl, or from a handler
+// with c.CacheControl:
+//
+//	r.Get("/products", "products.index", h, middleware.CacheControl(cachecontrol.Public(5*time.Minute)))
+//	c.CacheControl(cachecontrol.Private(0).Vary("Accept-Language"))
+package cachecontrol
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is a caching policy. Build one with a preset and refine it with the
+// chainable methods; the zero Policy sends "no-cache".
+type Policy struct {
+	public, private   bool
+	noStore           bool
+	noCache           bool
+	mustRevalidate    bool
+	immutable         bool
+	maxAge, sMaxAge   time.Duration
+	swr, staleIfError time.Duration
+	vary              []string
+}
+
+// Public lets browsers and shared caches (CDNs, proxies) keep the response
+// for maxAge.
+func Public(maxAge time.Duration) Policy {
+	return Policy{public: true, maxAge: maxAge}
+}
+
+// Private lets only the user's browser keep the response, for maxAge. With
+// maxAge 0 the browser must revalidate before each reuse. Use it for
+// anything specific to the signed-in user.
+func Private(maxAge time.Duration) Policy {
+	return Policy{private: true, maxAge: maxAge, noCache: maxAge <= 0}
+}
+
+// NoStore forbids caching anywhere: tokens, payment pages, personal data.
+func NoStore() Policy {
+	return Policy{noStore: true}
+}
+
+// StaleWhileRevalidate is Public(maxAge) where caches may keep serving the
+// stale response for up to stale more while they refetch it in the
+// background.
+func StaleWhileRevalidate(maxAge, stale time.Duration) Policy {
+	return Policy{public: true, maxAge: maxAge, swr: stale}
+}
+
+// SharedMaxAge sets s-maxage: how long shared caches keep the response,
+// overriding max-age for them only.
+func (p Policy) SharedMaxAge(d time.Duration) Policy {
+	p.sMaxAge = d
+	return p
+}
+
+// StaleIfError lets caches serve the stale response for d when the origin
+// fails.
+func (p Policy) StaleIfError(d time.Duration) Policy {
+	p.staleIfError = d
+	return p
+}
+
+// MustRevalidate forbids serving the response once it is stale.
+func (p Policy) MustRevalidate() Policy {
+	p.mustRevalidate = true
+	return p
+}
+
+// Immutable tells browsers the response never changes while fresh, e.g.
+// fingerprinted assets, so they skip revalidating on reload.
+func (p Policy) Immutable() Policy {
+	p.immutable = true
+	return p
+}
+
+// Vary adds request headers the response depends on, such as
+// Accept-Language, so caches key on them.
+func (p Policy) Vary(headers ...string) Policy {
+	p.vary = append(append([]string(nil), p.vary...), headers...)
+	return p
+}
+
+// String returns the Cache-Control value, e.g. "public, max-age=300".
+func (p Policy) String() string {
+	if p.noStore {
+		return "no-store"
+	}
+	var d []string
+	switch {
+	case p.public:
+		d = append(d, "public")
+	case p.private:
+		d = append(d, "private")
+	}
+	if p.noCache || !p.public && !p.private {
+		d = append(d, "no-cache")
+	}
+	if p.maxAge > 0 || p.public {
+		d = append(d, "max-age="+seconds(p.maxAge))
+	}
+	if p.sMaxAge > 0 {
+		d = append(d, "s-maxage="+seconds(p.sMaxAge))
+	}
+	if p.swr > 0 {
+		d = append(d, "stale-while-revalidate="+seconds(p.swr))
+	}
+	if p.staleIfError > 0 {
+		d = append(d, "stale-if-error="+seconds(p.staleIfError))
+	}
+	if p.mustRevalidate {
+		d = append(d, "must-revalidate")
+	}
+	if p.immutable {
+		d = append(d, "immutable")
+	}
+	return strings.Join(d, ", ")
+}
+
+// Apply sets Cache-Control, Expires (for HTTP/1.0 caches) and Vary on h.
+// Vary values already present, e.g. Accept-Encoding from compression, are
+// kept.
+func (p Policy) Apply(h http.Header, now time.Time) {
+	h.Set("Cache-Control", p.String())
+	if p.noStore || p.noCache || p.maxAge <= 0 {
+		h.Set("Expires", "0") // already expired
+	} else {
+		h.Set("Expires", now.Add(p.maxAge).UTC().Format(http.TimeFormat))
+	}
+	if p.noStore {
+		h.Set("Pragma", "no-cache")
+	}
+	AddVary(h, p.vary...)
+}
+
+// AddVary adds headers to h's Vary list, skipping ones already listed.
+func AddVary(h http.Header, headers ...string) {
+	have := map[string]bool{}
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			have[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+	}
+	for _, name := range headers {
+		if key := strings.ToLower(strings.TrimSpace(name)); key != "" && !have[key] && !have["*"] {
+			h.Add("Vary", http.CanonicalHeaderKey(strings.TrimSpace(name)))
+			have[key] = true
+		}
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
@@ -0,0 +1,50 @@
+package cachecontrol_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/cachecontrol"
+)
+
+func TestPresets(t *testing.T) {
+	cases := []struct {
+		p    cachecontrol.Policy
+		want string
+	}{
+		{cachecontrol.Public(5 * time.Minute), "public, max-age=300"},
+		{cachecontrol.Private(time.Minute), "private, max-age=60"},
+		{cachecontrol.Private(0), "private, no-cache"},
+		{cachecontrol.NoStore(), "no-store"},
+		{cachecontrol.StaleWhileRevalidate(time.Minute, time.Hour), "public, max-age=60, stale-while-revalidate=3600"},
+		{cachecontrol.Public(time.Hour).SharedMaxAge(time.Minute).StaleIfError(time.Hour).MustRevalidate(),
+			"public, max-age=3600, s-maxage=60, stale-if-error=3600, must-revalidate"},
+		{cachecontrol.Public(365 * 24 * time.Hour).Immutable(), "public, max-age=31536000, immutable"},
+		{cachecontrol.Policy{}, "no-cache"},
+	}
+	for _, tc := range cases {
+		if got := tc.p.String(); got != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := http.Header{"Vary": {"Accept-Encoding"}}
+	cachecontrol.Public(time.Minute).Vary("accept-encoding", "Accept-Language").Apply(h, now)
+
+	if got := h.Get("Expires"); got != "Fri, 02 Jan 2026 03:05:05 GMT" {
+		t.Errorf("Expires = %q", got)
+	}
+	if got := h.Values("Vary"); len(got) != 2 || got[1] != "Accept-Language" {
+		t.Errorf("Vary = %q, want Accept-Encoding kept once and Accept-Language added", got)
+	}
+
+	h = http.Header{}
+	cachecontrol.NoStore().Apply(h, now)
+	if h.Get("Cache-Control") != "no-store" || h.Get("Expires") != "0" || h.Get("Pragma") != "no-cache" {
+		t.Errorf("NoStore headers = %v", h)
+	}
+}
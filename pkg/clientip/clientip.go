@@ -0,0 +1,226 @@
+// Package clientip resolves the real client address of an HTTP request.
+//
+// X-Forwarded-For is only trustworthy for the hops your own infrastructure
+// appended. Resolve walks the header right-to-left, skipping addresses that
+// belong to trusted proxies, and returns the first untrusted one — the
+// address the outermost trusted proxy actually saw. Anything further left
+// was supplied by the client and can be forged.
+//
+// Middleware wiring (done by the kernel):
+//
+//	r.Use(clientip.Middleware(clientip.DefaultOptions()))
+//
+// Reading inside a handler:
+//
+//	ip, _ := clientip.FromCtx(r.Context()) // netip.Addr
+//	c.ClientIP()                           // same value as a string
+//
+// For L4 load balancers (HAProxy, AWS NLB) that cannot add headers, enable
+// the PROXY protocol listener instead (see pkg/proxyproto) — RemoteAddr then
+// carries the client address and no header trust is needed.
+package clientip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/shashiranjanraj/kashvi/config"
+)
+
+// ctxKey is the unexported key used to store the client address in context.
+type ctxKey struct{}
+
+// WithValue stores ip in ctx and returns the new context.
+func WithValue(ctx context.Context, ip netip.Addr) context.Context {
+	return context.WithValue(ctx, ctxKey{}, ip)
+}
+
+// FromCtx extracts the resolved client address from ctx.
+func FromCtx(ctx context.Context) (netip.Addr, bool) {
+	ip, ok := ctx.Value(ctxKey{}).(netip.Addr)
+	return ip, ok && ip.IsValid()
+}
+
+// Options controls which forwarding headers are believed.
+type Options struct {
+	// TrustedProxies are the networks of your own load balancers / proxies.
+	// Forwarding headers are ignored entirely unless RemoteAddr is in here.
+	TrustedProxies []netip.Prefix
+	// TrustAll believes every hop (TRUSTED_PROXIES=*). Only safe when the
+	// app is unreachable except through the proxy chain.
+	TrustAll bool
+	// Hops, when > 0, takes exactly the Nth address from the right of
+	// X-Forwarded-For instead of walking trusted ranges. Use it when the
+	// proxy addresses are not known in advance (e.g. a CDN in front of a LB).
+	// A shorter chain falls back to RemoteAddr.
+	Hops int
+}
+
+// privateRanges is the default trust list: loopback plus RFC 1918 / ULA
+// networks, which covers sidecars, docker bridges and in-VPC balancers.
+var privateRanges = []netip.Prefix{
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("fc00::/7"),
+}
+
+// DefaultOptions reads TRUSTED_PROXIES (comma-separated CIDRs, "*" for all,
+// default: loopback and private ranges) and TRUSTED_HOPS.
+func DefaultOptions() Options {
+	var opts Options
+	switch v := strings.TrimSpace(config.Get("TRUSTED_PROXIES", "")); v {
+	case "":
+		opts.TrustedProxies = privateRanges
+	case "*":
+		opts.TrustAll = true
+	default:
+		opts.TrustedProxies = ParsePrefixes(v)
+	}
+	opts.Hops, _ = strconv.Atoi(config.Get("TRUSTED_HOPS", "0"))
+	return opts
+}
+
+// ParsePrefixes parses a comma-separated list of CIDRs or bare IPs,
+// skipping invalid entries.
+func ParsePrefixes(s string) []netip.Prefix {
+	var out []netip.Prefix
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if p, err := netip.ParsePrefix(part); err == nil {
+			out = append(out, p.Masked())
+			continue
+		}
+		if a, err := netip.ParseAddr(part); err == nil {
+			a = a.Unmap()
+			out = append(out, netip.PrefixFrom(a, a.BitLen()))
+		}
+	}
+	return out
+}
+
+func (o Options) trusted(ip netip.Addr) bool {
+	if o.TrustAll {
+		return true
+	}
+	for _, p := range o.TrustedProxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the client address for r. It never returns an invalid
+// address for a request with a parseable RemoteAddr.
+func Resolve(r *http.Request, opts Options) netip.Addr {
+	peer := ParseAddr(r.RemoteAddr)
+	if !peer.IsValid() || !opts.trusted(peer) {
+		return peer
+	}
+
+	chain := forwarded(r)
+	if len(chain) == 0 {
+		if ip := ParseAddr(r.Header.Get("X-Real-Ip")); ip.IsValid() {
+			return ip
+		}
+		return peer
+	}
+
+	if opts.Hops > 0 {
+		if opts.Hops > len(chain) {
+			// Fewer hops than configured: the request skipped part of
+			// the proxy chain, so no entry can be trusted.
+			return peer
+		}
+		return chain[len(chain)-opts.Hops]
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if !opts.trusted(chain[i]) {
+			return chain[i]
+		}
+	}
+	// Every hop is one of ours: the leftmost is the best we have.
+	return chain[0]
+}
+
+// forwarded collects X-Forwarded-For entries across all header lines,
+// stopping at the first unparseable one from the right (anything left of
+// garbage is not trustworthy).
+func forwarded(r *http.Request) []netip.Addr {
+	var raw []string
+	for _, line := range r.Header.Values("X-Forwarded-For") {
+		raw = append(raw, strings.Split(line, ",")...)
+	}
+	out := make([]netip.Addr, 0, len(raw))
+	for i := len(raw) - 1; i >= 0; i-- {
+		ip := ParseAddr(strings.TrimSpace(raw[i]))
+		if !ip.IsValid() {
+			break
+		}
+		out = append(out, ip)
+	}
+	// Reverse back to left-to-right order.
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// ParseAddr parses "ip", "ip:port" or "[ipv6]:port" and unmaps IPv4-in-IPv6.
+// It returns the zero Addr on failure.
+func ParseAddr(s string) netip.Addr {
+	if s == "" {
+		return netip.Addr{}
+	}
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().Unmap()
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	if a, err := netip.ParseAddr(strings.Trim(s, "[]")); err == nil {
+		return a.Unmap()
+	}
+	return netip.Addr{}
+}
+
+// FromRequest returns the address stored by Middleware, falling back to the
+// TCP peer when the middleware did not run.
+func FromRequest(r *http.Request) netip.Addr {
+	if ip, ok := FromCtx(r.Context()); ok {
+		return ip
+	}
+	return ParseAddr(r.RemoteAddr)
+}
+
+// String returns FromRequest(r) as text, or RemoteAddr when unparseable.
+func String(r *http.Request) string {
+	if ip := FromRequest(r); ip.IsValid() {
+		return ip.String()
+	}
+	return r.RemoteAddr
+}
+
+// Middleware resolves the client address once per request and stores it in
+// the context for c.ClientIP(), rate limiting and request logging.
+func Middleware(opts Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := Resolve(r, opts); ip.IsValid() {
+				r = r.WithContext(WithValue(r.Context(), ip))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
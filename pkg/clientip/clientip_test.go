@@ -0,0 +1,60 @@
+package clientip_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/clientip"
+)
+
+func request(remote string, xff ...string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remote
+	for _, v := range xff {
+		r.Header.Add("X-Forwarded-For", v)
+	}
+	return r
+}
+
+func TestResolve(t *testing.T) {
+	lb := clientip.Options{TrustedProxies: clientip.ParsePrefixes("10.0.0.0/8, 192.168.1.1")}
+
+	cases := []struct {
+		name string
+		r    *http.Request
+		opts clientip.Options
+		want string
+	}{
+		{"no proxy", request("203.0.113.7:1234"), lb, "203.0.113.7"},
+		{"untrusted peer ignores header", request("203.0.113.7:1234", "1.1.1.1"), lb, "203.0.113.7"},
+		{"trusted peer", request("10.1.2.3:80", "1.1.1.1"), lb, "1.1.1.1"},
+		{"spoofed leftmost skipped", request("10.1.2.3:80", "6.6.6.6, 1.1.1.1"), lb, "1.1.1.1"},
+		{"trusted chain skipped", request("10.1.2.3:80", "1.1.1.1, 192.168.1.1"), lb, "1.1.1.1"},
+		{"multiple header lines", request("10.1.2.3:80", "6.6.6.6", "1.1.1.1"), lb, "1.1.1.1"},
+		{"ipv6 peer", request("[2001:db8::1]:443"), lb, "2001:db8::1"},
+		{"hops", request("10.1.2.3:80", "6.6.6.6, 1.1.1.1, 8.8.8.8"), clientip.Options{TrustAll: true, Hops: 2}, "1.1.1.1"},
+		{"hops beyond chain", request("10.1.2.3:80", "1.1.1.1"), clientip.Options{TrustAll: true, Hops: 5}, "10.1.2.3"},
+		{"garbage stops the walk", request("10.1.2.3:80", "1.1.1.1, nonsense"), lb, "10.1.2.3"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := clientip.Resolve(tc.r, tc.opts).String(); got != tc.want {
+				t.Errorf("Resolve = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var got netip.Addr
+	h := clientip.Middleware(clientip.Options{TrustAll: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = clientip.FromCtx(r.Context())
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), request("127.0.0.1:9", "198.51.100.4"))
+
+	if got != netip.MustParseAddr("198.51.100.4") {
+		t.Errorf("FromCtx = %v, want 198.51.100.4", got)
+	}
+}
@@ -0,0 +1,147 @@
+// Package codec is the serialization registry shared by the queue and the
+// cache. JSON stays the default; types on hot paths can opt into a compact
+// binary codec by implementing Selector:
+//
+//	func (*pb.ThumbnailJob) Codec() string { return codec.Proto }
+//
+// Protobuf ships built in. Other formats (msgpack, CBOR, …) plug in with
+// Register.
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Built-in codec names.
+const (
+	JSON  = "json"
+	Proto = "proto"
+)
+
+// Codec marshals values to bytes and back.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// Selector is implemented by values that are serialized with a codec other
+// than JSON. It is consulted on write; readers learn the codec from the
+// stored data, so switching a type back and forth is safe.
+type Selector interface {
+	Codec() string
+}
+
+// ErrUnknown is returned for a codec name that was never registered.
+var ErrUnknown = errors.New("codec: unknown codec")
+
+var (
+	mu     sync.RWMutex
+	codecs = map[string]Codec{
+		JSON:  jsonCodec{},
+		Proto: protoCodec{},
+	}
+)
+
+// Register makes c available under name, replacing any codec registered
+// under the same name.
+func Register(name string, c Codec) {
+	mu.Lock()
+	codecs[name] = c
+	mu.Unlock()
+}
+
+// Lookup returns the codec registered under name.
+func Lookup(name string) (Codec, error) {
+	mu.RLock()
+	c, ok := codecs[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknown, name)
+	}
+	return c, nil
+}
+
+// NameOf returns the codec v selects, or JSON.
+func NameOf(v any) string {
+	if s, ok := v.(Selector); ok && s.Codec() != "" {
+		return s.Codec()
+	}
+	return JSON
+}
+
+// ─── Built-in codecs ──────────────────────────────────────────────────────────
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("codec: %T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protoCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("codec: %T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// ─── Tagged encoding ──────────────────────────────────────────────────────────
+
+// Stored values written by a non-JSON codec start with this header:
+// 0x00, format version, name length, name. JSON never starts with 0x00, so
+// plain JSON written before codecs existed still decodes.
+const (
+	tagByte = 0x00
+	version = 1
+)
+
+// Encode marshals v with the codec it selects. JSON output is untagged;
+// other codecs are prefixed with a header naming them.
+func Encode(v any) ([]byte, error) {
+	name := NameOf(v)
+	c, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.Marshal(v)
+	if err != nil || name == JSON {
+		return data, err
+	}
+	if len(name) > 255 {
+		return nil, fmt.Errorf("codec: name %q too long", name)
+	}
+	out := make([]byte, 0, 3+len(name)+len(data))
+	out = append(out, tagByte, version, byte(len(name)))
+	out = append(out, name...)
+	return append(out, data...), nil
+}
+
+// Decode reverses Encode, choosing the codec from the header.
+func Decode(data []byte, v any) error {
+	if len(data) == 0 || data[0] != tagByte {
+		return json.Unmarshal(data, v)
+	}
+	if len(data) < 3 || data[1] != version || len(data) < 3+int(data[2]) {
+		return errors.New("codec: malformed header")
+	}
+	n := int(data[2])
+	c, err := Lookup(string(data[3 : 3+n]))
+	if err != nil {
+		return err
+	}
+	return c.Unmarshal(data[3+n:], v)
+}
@@ -0,0 +1,105 @@
+package codec_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+
+	"github.com/shashiranjanraj/kashvi/pkg/codec"
+)
+
+// report is a protobuf message that opts into the proto codec.
+type report struct{ *errdetails.BadRequest }
+
+func (report) Codec() string { return codec.Proto }
+
+func sample() report {
+	br := &errdetails.BadRequest{}
+	for i := 0; i < 20; i++ {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field: fmt.Sprintf("items.%d.quantity", i), Description: "must be greater than 0",
+		})
+	}
+	return report{br}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	in := sample()
+	data, err := codec.Encode(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, _ := json.Marshal(in)
+	if len(data) >= len(jsonData) {
+		t.Fatalf("proto payload is %d bytes, JSON %d", len(data), len(jsonData))
+	}
+
+	out := report{&errdetails.BadRequest{}}
+	if err := codec.Decode(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.FieldViolations) != 20 || out.FieldViolations[3].Field != "items.3.quantity" {
+		t.Fatalf("round trip lost data: %v", out.FieldViolations)
+	}
+
+	// Values without a Selector stay plain JSON, readable by older releases.
+	plain, _ := codec.Encode(map[string]int{"a": 1})
+	if string(plain) != `{"a":1}` {
+		t.Fatalf("JSON payload = %q", plain)
+	}
+	var m map[string]int
+	if err := codec.Decode(plain, &m); err != nil || m["a"] != 1 {
+		t.Fatalf("Decode JSON = %v, %v", m, err)
+	}
+}
+
+type unregistered struct{}
+
+func (unregistered) Codec() string { return "missing" }
+
+func TestEncode_UnknownCodec(t *testing.T) {
+	if _, err := codec.Encode(unregistered{}); !errors.Is(err, codec.ErrUnknown) {
+		t.Fatalf("err = %v, want ErrUnknown", err)
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	in := sample()
+	b.Run("json", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			data, _ := json.Marshal(in)
+			b.SetBytes(int64(len(data)))
+		}
+	})
+	b.Run("proto", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			data, _ := codec.Encode(in)
+			b.SetBytes(int64(len(data)))
+		}
+	})
+}
+
+func BenchmarkDecode(b *testing.B) {
+	in := sample()
+	jsonData, _ := json.Marshal(in)
+	protoData, _ := codec.Encode(in)
+	b.Run("json", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			out := report{&errdetails.BadRequest{}}
+			if err := json.Unmarshal(jsonData, &out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("proto", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			out := report{&errdetails.BadRequest{}}
+			if err := codec.Decode(protoData, &out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
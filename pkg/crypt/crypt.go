@@ -15,17 +15,15 @@
 package crypt
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
+	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 
 	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/internal/secretbox"
 )
 
 // ErrDecrypt is returned when decryption or authentication fails.
@@ -38,8 +36,7 @@ func key() ([]byte, error) {
 		return nil, errors.New("crypt: APP_KEY not configured")
 	}
 	// Always derive a fixed-length key via SHA-256.
-	h := sha256.Sum256([]byte(secret))
-	return h[:], nil
+	return secretbox.Key(secret), nil
 }
 
 // Encrypt encrypts plaintext using AES-256-GCM and returns a base64url string.
@@ -54,25 +51,11 @@ func EncryptBytes(data []byte) (string, error) {
 	if err != nil {
 		return "", err
 	}
-
-	block, err := aes.NewCipher(k)
-	if err != nil {
-		return "", fmt.Errorf("crypt: new cipher: %w", err)
-	}
-
-	gcm, err := cipher.NewGCM(block)
+	enc, err := secretbox.Seal(k, data)
 	if err != nil {
-		return "", fmt.Errorf("crypt: new GCM: %w", err)
+		return "", fmt.Errorf("crypt: %w", err)
 	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", fmt.Errorf("crypt: nonce: %w", err)
-	}
-
-	// Seal appends ciphertext+tag after nonce.
-	ciphertext := gcm.Seal(nonce, nonce, data, nil)
-	return base64.URLEncoding.EncodeToString(ciphertext), nil
+	return enc, nil
 }
 
 // Decrypt decrypts a base64url string produced by Encrypt.
@@ -90,31 +73,12 @@ func DecryptBytes(encoded string) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	data, err := base64.URLEncoding.DecodeString(encoded)
-	if err != nil {
-		return nil, ErrDecrypt
-	}
-
-	block, err := aes.NewCipher(k)
-	if err != nil {
-		return nil, fmt.Errorf("crypt: new cipher: %w", err)
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, fmt.Errorf("crypt: new GCM: %w", err)
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return nil, ErrDecrypt
-	}
-
-	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
-	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
+	plain, err := secretbox.Open(k, encoded)
+	switch {
+	case errors.Is(err, secretbox.ErrOpen):
 		return nil, ErrDecrypt
+	case err != nil:
+		return nil, fmt.Errorf("crypt: %w", err)
 	}
 	return plain, nil
 }
@@ -140,6 +104,24 @@ func DecryptJSON(encoded string, dest interface{}) error {
 	return nil
 }
 
+// Sign returns a base64url HMAC-SHA256 of data keyed by APP_KEY, for
+// tamper-proof links such as unsubscribe or email-verification URLs.
+func Sign(data string) (string, error) {
+	k, err := key()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, append([]byte("kashvi-sign:"), k...))
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Verify reports whether sig is Sign(data), in constant time.
+func Verify(data, sig string) bool {
+	want, err := Sign(data)
+	return err == nil && hmac.Equal([]byte(want), []byte(sig))
+}
+
 // Hash returns a SHA-256 hex digest of the input — useful for checksums.
 func Hash(input string) string {
 	h := sha256.Sum256([]byte(input))
@@ -21,18 +21,33 @@ package ctx
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/netip"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/shashiranjanraj/kashvi/config"
 	"github.com/shashiranjanraj/kashvi/pkg/bind"
+	"github.com/shashiranjanraj/kashvi/pkg/clientip"
+	kashvihttp "github.com/shashiranjanraj/kashvi/pkg/http"
+	"github.com/shashiranjanraj/kashvi/pkg/metrics"
+	"github.com/shashiranjanraj/kashvi/pkg/mtls"
+	"github.com/shashiranjanraj/kashvi/pkg/orm"
+	"github.com/shashiranjanraj/kashvi/pkg/respwriter"
 	"github.com/shashiranjanraj/kashvi/pkg/validate"
 )
 
+// ErrNoRequest is returned by body helpers called on a nil or released Context.
+var ErrNoRequest = errors.New("ctx: no active request")
+
 // HandlerFunc is the Kashvi context-aware handler signature.
 type HandlerFunc func(c *Context)
 
@@ -53,12 +68,19 @@ func Wrap(h HandlerFunc) http.HandlerFunc {
 // ─── Context ──────────────────────────────────────────────────────────────────
 
 // Context wraps a request/response pair and provides a rich helper API.
+//
+// A Context is only valid for the lifetime of the handler it was passed to:
+// once the handler returns it is recycled for another request. Goroutines
+// that outlive the handler must work on c.Copy() instead.
 type Context struct {
-	W      http.ResponseWriter
-	R      *http.Request
-	mu     sync.RWMutex
-	store  map[string]any
-	status int // written status code (0 = not written yet)
+	W        http.ResponseWriter
+	R        *http.Request
+	mu       sync.RWMutex
+	store    map[string]any
+	rw       *respwriter.Writer // W as seen through the shared wrapper
+	own      respwriter.Writer  // pooled wrapper used when W is not wrapped yet
+	status   int                // status snapshot carried by copies
+	released atomic.Bool        // set once the handler has returned
 }
 
 // pool recycles Context objects to reduce GC pressure.
@@ -66,11 +88,26 @@ var pool = sync.Pool{
 	New: func() any { return &Context{store: make(map[string]any)} },
 }
 
+// debugPool reports whether use-after-release detection is enabled.
+// It is on in every environment except production, where contexts are
+// recycled for throughput instead.
+var debugPool = sync.OnceValue(func() bool {
+	env := config.AppEnv()
+	return env != "production" && env != "prod"
+})
+
 func acquire(w http.ResponseWriter, r *http.Request) *Context {
 	c := pool.Get().(*Context)
-	c.W = w
+	if rw, ok := w.(*respwriter.Writer); ok {
+		c.rw = rw
+	} else {
+		c.own.Reset(w)
+		c.rw = &c.own
+	}
+	c.W = c.rw
 	c.R = r
 	c.status = 0
+	c.released.Store(false)
 	for k := range c.store {
 		delete(c.store, k)
 	}
@@ -78,20 +115,84 @@ func acquire(w http.ResponseWriter, r *http.Request) *Context {
 }
 
 func release(c *Context) {
+	c.released.Store(true)
+	if debugPool() {
+		// Keep W/R intact and never recycle, so a stale reference held by a
+		// goroutine trips usable() instead of silently reading another
+		// request's data.
+		return
+	}
 	c.W = nil
 	c.R = nil
+	c.rw = nil
+	c.own.Reset(nil)
 	pool.Put(c)
 }
 
+// usable reports whether c still refers to a live request. Outside
+// production it panics when c has already been released, which almost
+// always means a goroutine kept the Context after its handler returned.
+func (c *Context) usable() bool {
+	if c == nil {
+		return false
+	}
+	if c.released.Load() {
+		if debugPool() {
+			panic("ctx: Context used after its handler returned; " +
+				"call c.Copy() before handing it to a goroutine")
+		}
+		return false
+	}
+	return c.R != nil
+}
+
+// writable is usable plus a ResponseWriter to write to. Copies returned by
+// Copy() have none, so response helpers on them are no-ops.
+func (c *Context) writable() bool {
+	return c.usable() && c.W != nil
+}
+
+// Copy returns a snapshot of c that is safe to use from another goroutine
+// after the handler returns. The copy keeps the request (detached from its
+// cancellation) and a copy of the per-request store, but has no
+// ResponseWriter — response helpers on it do nothing.
+//
+//	cc := c.Copy()
+//	go func() {
+//	    audit.Record(cc.GetUint("user_id"), cc.Path())
+//	}()
+func (c *Context) Copy() *Context {
+	if !c.usable() {
+		return nil
+	}
+	cp := &Context{
+		R:      c.R.Clone(context.WithoutCancel(c.R.Context())),
+		store:  make(map[string]any, len(c.store)),
+		status: c.WrittenStatus(),
+	}
+	c.mu.RLock()
+	for k, v := range c.store {
+		cp.store[k] = v
+	}
+	c.mu.RUnlock()
+	return cp
+}
+
 // ─── Request helpers ──────────────────────────────────────────────────────────
 
 // Param returns a URL path parameter (e.g. "/users/{id}" → c.Param("id")).
 func (c *Context) Param(key string) string {
+	if !c.usable() {
+		return ""
+	}
 	return chi.URLParam(c.R, key)
 }
 
 // Query returns a query-string value. Returns "" if not present.
 func (c *Context) Query(key string) string {
+	if !c.usable() {
+		return ""
+	}
 	return c.R.URL.Query().Get(key)
 }
 
@@ -105,16 +206,25 @@ func (c *Context) DefaultQuery(key, def string) string {
 
 // PostForm returns a form field from an application/x-www-form-urlencoded body.
 func (c *Context) PostForm(key string) string {
+	if !c.usable() {
+		return ""
+	}
 	return c.R.FormValue(key)
 }
 
 // Header returns the value of a request header.
 func (c *Context) Header(key string) string {
+	if !c.usable() {
+		return ""
+	}
 	return c.R.Header.Get(key)
 }
 
 // Cookie returns the value of a named cookie.
 func (c *Context) Cookie(name string) (string, error) {
+	if !c.usable() {
+		return "", http.ErrNoCookie
+	}
 	cookie, err := c.R.Cookie(name)
 	if err != nil {
 		return "", err
@@ -125,49 +235,124 @@ func (c *Context) Cookie(name string) (string, error) {
 // Body reads and returns the raw request body bytes.
 // The body can only be read once; use BindJSON for structured data.
 func (c *Context) Body() ([]byte, error) {
+	if !c.usable() {
+		return nil, ErrNoRequest
+	}
 	return io.ReadAll(c.R.Body)
 }
 
 // Method returns the HTTP method of the request.
-func (c *Context) Method() string { return c.R.Method }
+func (c *Context) Method() string {
+	if !c.usable() {
+		return ""
+	}
+	return c.R.Method
+}
 
 // Path returns the request URL path.
-func (c *Context) Path() string { return c.R.URL.Path }
+func (c *Context) Path() string {
+	if !c.usable() {
+		return ""
+	}
+	return c.R.URL.Path
+}
 
 // FullPath returns method + path (e.g. "GET /api/users").
 func (c *Context) FullPath() string {
+	if !c.usable() {
+		return ""
+	}
 	return c.R.Method + " " + c.R.URL.Path
 }
 
-// ClientIP returns the real client IP, respecting X-Forwarded-For.
+// ClientIP returns the real client IP as text.
+//
+// Forwarding headers are only honoured when they came through a trusted
+// proxy (see pkg/clientip and TRUSTED_PROXIES); otherwise this is the TCP
+// peer, which the PROXY protocol listener may have rewritten.
 func (c *Context) ClientIP() string {
-	if fwd := c.R.Header.Get("X-Forwarded-For"); fwd != "" {
-		return strings.SplitN(fwd, ",", 2)[0]
+	if ip := c.ClientAddr(); ip.IsValid() {
+		return ip.String()
+	}
+	if !c.usable() {
+		return ""
+	}
+	return c.R.RemoteAddr
+}
+
+// ClientAddr returns the real client IP as a netip.Addr, or the zero Addr
+// when it cannot be determined.
+func (c *Context) ClientAddr() netip.Addr {
+	if !c.usable() {
+		return netip.Addr{}
 	}
-	if real := c.R.Header.Get("X-Real-Ip"); real != "" {
-		return real
+	if ip, ok := clientip.FromCtx(c.R.Context()); ok {
+		return ip
 	}
-	// Strip port from RemoteAddr.
-	ip := c.R.RemoteAddr
-	if idx := strings.LastIndex(ip, ":"); idx != -1 {
-		ip = ip[:idx]
+	return clientip.Resolve(c.R, clientip.DefaultOptions())
+}
+
+// PeerCertificate returns the client certificate verified during the TLS
+// handshake, or nil when the request did not present one (see pkg/mtls).
+func (c *Context) PeerCertificate() *x509.Certificate {
+	if !c.usable() {
+		return nil
+	}
+	return mtls.PeerCertificate(c.R)
+}
+
+// ClientIdentity returns the mTLS identity set by
+// middleware.RequireClientCert, falling back to resolving the peer
+// certificate directly.
+func (c *Context) ClientIdentity() (mtls.Identity, bool) {
+	if !c.usable() {
+		return mtls.Identity{}, false
+	}
+	if id, ok := mtls.FromCtx(c.R.Context()); ok {
+		return id, true
 	}
-	return ip
+	if cert := mtls.PeerCertificate(c.R); cert != nil {
+		return mtls.Resolve(cert), true
+	}
+	return mtls.Identity{}, false
 }
 
 // IsXHR reports whether the request was made via XMLHttpRequest.
 func (c *Context) IsXHR() bool {
+	if !c.usable() {
+		return false
+	}
 	return strings.EqualFold(c.R.Header.Get("X-Requested-With"), "XMLHttpRequest")
 }
 
 // Context returns the underlying request context.
-func (c *Context) Context() context.Context { return c.R.Context() }
+func (c *Context) Context() context.Context {
+	if !c.usable() {
+		return context.Background()
+	}
+	return c.R.Context()
+}
+
+// DB returns an ORM query bound to the request context, so a client
+// disconnect or request timeout cancels the query instead of leaking it.
+//
+//	var user models.User
+//	if err := c.DB().Where("id = ?", c.Param("id")).First(&user); err != nil { ... }
+func (c *Context) DB() *orm.Query { return orm.WithCtx(c.Context()) }
+
+// HTTP returns an outgoing HTTP client bound to the request context.
+//
+//	resp, err := c.HTTP().Get("https://api.example.com/rates").Send()
+func (c *Context) HTTP() *kashvihttp.Client { return kashvihttp.WithCtx(c.Context()) }
 
 // ─── Per-request store ────────────────────────────────────────────────────────
 
 // Set stores a value in the per-request key-value store.
 // Useful for passing data between middleware and handlers.
 func (c *Context) Set(key string, val any) {
+	if !c.usable() {
+		return
+	}
 	c.mu.Lock()
 	c.store[key] = val
 	c.mu.Unlock()
@@ -175,6 +360,9 @@ func (c *Context) Set(key string, val any) {
 
 // Get retrieves a value from the per-request store.
 func (c *Context) Get(key string) (any, bool) {
+	if !c.usable() {
+		return nil, false
+	}
 	c.mu.RLock()
 	v, ok := c.store[key]
 	c.mu.RUnlock()
@@ -208,7 +396,8 @@ func (c *Context) GetUint(key string) uint {
 
 // BindJSON decodes the JSON body into dest and runs validation.
 // On validation failure it automatically sends a 422 response and returns false.
-// On JSON decode error it sends a 400 and returns false.
+// On JSON decode error it sends a 400 and returns false; bodies that break
+// the size or shape limits (see bind.Limits) get a 413 or 422.
 // Returns true only when dest is valid and ready to use.
 //
 //	var input RegisterInput
@@ -216,8 +405,16 @@ func (c *Context) GetUint(key string) uint {
 //	    return // response already sent
 //	}
 func (c *Context) BindJSON(dest any) bool {
+	if !c.usable() {
+		return false
+	}
 	errs, err := bind.JSON(c.R, dest)
 	if err != nil {
+		var limitErr *bind.LimitError
+		if errors.As(err, &limitErr) {
+			c.Error(limitErr.Status(), limitErr.Error())
+			return false
+		}
 		c.Error(http.StatusBadRequest, err.Error())
 		return false
 	}
@@ -231,24 +428,83 @@ func (c *Context) BindJSON(dest any) bool {
 // ShouldBindJSON decodes the JSON body into dest and runs validation.
 // Unlike BindJSON, it does NOT write a response — the caller handles errors.
 func (c *Context) ShouldBindJSON(dest any) (map[string]string, error) {
+	if !c.usable() {
+		return nil, ErrNoRequest
+	}
 	return bind.JSON(c.R, dest)
 }
 
-// Validate runs validation rules on an already-populated struct.
+// BindForm binds a multipart or URL-encoded form (including file uploads)
+// into dest and runs validation, answering like BindJSON on failure.
+//
+//	var input AvatarInput // Avatar *multipart.FileHeader `form:"avatar" validate:"required,image,max_size=2MB"`
+//	if !c.BindForm(&input) {
+//	    return
+//	}
+func (c *Context) BindForm(dest any) bool {
+	if !c.usable() {
+		return false
+	}
+	errs, err := bind.Form(c.R, dest)
+	if err != nil {
+		var limitErr *bind.LimitError
+		if errors.As(err, &limitErr) {
+			c.Error(limitErr.Status(), limitErr.Error())
+			return false
+		}
+		c.Error(http.StatusBadRequest, err.Error())
+		return false
+	}
+	if validate.HasErrors(errs) {
+		c.ValidationError(errs)
+		return false
+	}
+	return true
+}
+
+// ShouldBindForm is BindForm without writing a response.
+func (c *Context) ShouldBindForm(dest any) (map[string]string, error) {
+	if !c.usable() {
+		return nil, ErrNoRequest
+	}
+	return bind.Form(c.R, dest)
+}
+
+// FormFile returns the first uploaded file for the multipart field name.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if !c.usable() {
+		return nil, ErrNoRequest
+	}
+	f, fh, err := c.R.FormFile(name)
+	if err != nil {
+		return nil, err
+	}
+	_ = f.Close() // callers reopen via fh.Open()
+	return fh, nil
+}
+
+// Validate runs validation rules (and the struct's Validate(ctx) hook, if
+// any) on an already-populated struct.
 // Returns the error map (nil map = no errors).
 func (c *Context) Validate(v any) map[string]string {
-	return validate.Struct(v)
+	return validate.StructCtx(c.Context(), v)
 }
 
 // ─── Response helpers ─────────────────────────────────────────────────────────
 
 // SetHeader sets a response header.
 func (c *Context) SetHeader(key, value string) {
+	if !c.writable() {
+		return
+	}
 	c.W.Header().Set(key, value)
 }
 
 // SetCookie sets a cookie on the response.
 func (c *Context) SetCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) {
+	if !c.writable() {
+		return
+	}
 	http.SetCookie(c.W, &http.Cookie{
 		Name:     name,
 		Value:    value,
@@ -262,15 +518,19 @@ func (c *Context) SetCookie(name, value string, maxAge int, path, domain string,
 
 // Status writes just the HTTP status code with an empty body.
 func (c *Context) Status(code int) {
-	c.status = code
+	if !c.writable() {
+		return
+	}
 	c.W.WriteHeader(code)
 }
 
 // JSON writes a JSON response with the given status code.
 func (c *Context) JSON(code int, v any) {
+	if !c.writable() {
+		return
+	}
 	c.W.Header().Set("Content-Type", "application/json")
 	c.W.WriteHeader(code)
-	c.status = code
 	json.NewEncoder(c.W).Encode(v) //nolint:errcheck
 }
 
@@ -289,15 +549,33 @@ func (c *Context) Error(code int, message string) {
 	c.JSON(code, envelope{Status: code, Message: message})
 }
 
-// ValidationError sends a 422 Unprocessable Entity with field-level errors.
+// ValidationError sends a 422 Unprocessable Entity with field-level errors:
+// a StructCtx result or validate.FieldViolations returned by a service.
+//
+// Each field is counted in kashvi_http_validation_failures_total under the
+// matched route pattern.
 func (c *Context) ValidationError(errs map[string]string) {
+	if c.usable() {
+		metrics.RecordValidationFailures(c.routePattern(), errs)
+	}
 	c.JSON(http.StatusUnprocessableEntity, envelope{
 		Status:  http.StatusUnprocessableEntity,
-		Message: "Validation failed",
+		Message: validate.Message,
 		Errors:  errs,
 	})
 }
 
+// routePattern returns "METHOD /pattern/{param}" for the matched chi route,
+// or "unmatched" outside a router (keeps metric cardinality bounded).
+func (c *Context) routePattern() string {
+	if rc := chi.RouteContext(c.R.Context()); rc != nil {
+		if p := rc.RoutePattern(); p != "" {
+			return c.R.Method + " " + p
+		}
+	}
+	return "unmatched"
+}
+
 // Unauthorized sends a 401.
 func (c *Context) Unauthorized(message ...string) {
 	msg := "Unauthorized"
@@ -327,19 +605,27 @@ func (c *Context) NotFound(message ...string) {
 
 // String writes a plain-text response.
 func (c *Context) String(code int, format string, args ...any) {
+	if !c.writable() {
+		return
+	}
 	c.W.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	c.W.WriteHeader(code)
-	c.status = code
 	fmt.Fprintf(c.W, format, args...)
 }
 
 // Redirect sends an HTTP redirect response.
 func (c *Context) Redirect(code int, url string) {
+	if !c.writable() {
+		return
+	}
 	http.Redirect(c.W, c.R, url, code)
 }
 
 // File serves a file from the local filesystem.
 func (c *Context) File(filepath string) {
+	if !c.writable() {
+		return
+	}
 	http.ServeFile(c.W, c.R, filepath)
 }
 
@@ -351,7 +637,22 @@ func (c *Context) Abort(code int, message string) {
 
 // WrittenStatus returns the HTTP status code that was written to the response,
 // or 0 if no response has been written yet.
-func (c *Context) WrittenStatus() int { return c.status }
+func (c *Context) WrittenStatus() int {
+	if c == nil {
+		return 0
+	}
+	if c.rw == nil {
+		return c.status
+	}
+	if !c.rw.Written() {
+		return 0
+	}
+	return c.rw.Status()
+}
+
+// Written reports whether the response headers have already been sent,
+// whether through a helper like JSON or by writing to c.W directly.
+func (c *Context) Written() bool { return c.WrittenStatus() != 0 }
 
 // ─── JSON envelope (mirrors pkg/response) ────────────────────────────────────
 
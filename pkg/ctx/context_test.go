@@ -6,7 +6,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
 	appctx "github.com/shashiranjanraj/kashvi/pkg/ctx"
+	"github.com/shashiranjanraj/kashvi/pkg/metrics"
 )
 
 func newCtx(method, path, body string) (*appctx.Context, *httptest.ResponseRecorder) {
@@ -106,6 +110,7 @@ func TestBindJSONInvalid(t *testing.T) {
 func TestClientIP(t *testing.T) {
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.5:41234" // private range: trusted proxy by default
 	req.Header.Set("X-Forwarded-For", "1.2.3.4")
 
 	appctx.Wrap(func(c *appctx.Context) {
@@ -117,6 +122,20 @@ func TestClientIP(t *testing.T) {
 	})(rec, req)
 }
 
+func TestClientIP_IgnoresForwardedFromUntrustedPeer(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.9:5555"
+	req.Header.Set("X-Forwarded-For", "1.2.3.4")
+
+	appctx.Wrap(func(c *appctx.Context) {
+		if ip := c.ClientIP(); ip != "203.0.113.9" {
+			t.Errorf("expected 203.0.113.9, got %s", ip)
+		}
+		c.Success(nil)
+	})(rec, req)
+}
+
 func TestErrorResponse(t *testing.T) {
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -129,3 +148,136 @@ func TestErrorResponse(t *testing.T) {
 		t.Errorf("expected 404, got %d", rec.Code)
 	}
 }
+
+func TestCopyOutlivesHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/jobs?id=7", nil)
+
+	var cp *appctx.Context
+	appctx.Wrap(func(c *appctx.Context) {
+		c.Set("user_id", uint(9))
+		cp = c.Copy()
+		c.Success(nil)
+	})(rec, req)
+
+	if got := cp.GetUint("user_id"); got != 9 {
+		t.Errorf("expected copied store value 9, got %d", got)
+	}
+	if got := cp.Query("id"); got != "7" {
+		t.Errorf("expected query 7 on copy, got %q", got)
+	}
+	if err := cp.Context().Err(); err != nil {
+		t.Errorf("copy context should not be cancelled, got %v", err)
+	}
+	// Writing through a copy is a no-op rather than a panic.
+	cp.JSON(http.StatusTeapot, nil)
+	if rec.Code != http.StatusOK {
+		t.Errorf("copy must not write to the original response, got %d", rec.Code)
+	}
+}
+
+func TestUseAfterReleasePanics(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	var stale *appctx.Context
+	appctx.Wrap(func(c *appctx.Context) { stale = c })(rec, req)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic when using a released Context")
+		}
+	}()
+	stale.Param("id")
+}
+
+func TestNilContextIsSafe(t *testing.T) {
+	var c *appctx.Context
+	if c.Query("x") != "" || c.GetString("k") != "" {
+		t.Error("expected zero values from nil Context")
+	}
+	if c.Context() == nil {
+		t.Error("expected a non-nil background context")
+	}
+	c.JSON(http.StatusOK, nil) // must not panic
+}
+
+func TestJSONWithETagNotModified(t *testing.T) {
+	handler := appctx.Wrap(func(c *appctx.Context) {
+		c.JSONWithETag(http.StatusOK, map[string]any{"id": 1})
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	etag := rec.Header().Get("ETag")
+	if etag == "" || rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with ETag, got %d %q", rec.Code, etag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"other", `+etag)
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", rec.Code)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected empty body on 304, got %q", rec.Body.String())
+	}
+}
+
+func TestJSONWithWeakETag(t *testing.T) {
+	rec := httptest.NewRecorder()
+	appctx.Wrap(func(c *appctx.Context) {
+		c.JSONWithWeakETag(http.StatusOK, []int{1, 2, 3})
+	})(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if etag := rec.Header().Get("ETag"); !strings.HasPrefix(etag, `W/"`) {
+		t.Errorf("expected weak ETag, got %q", etag)
+	}
+}
+
+func TestBindJSONLimits(t *testing.T) {
+	// Defaults: depth 64, 10 000 array elements.
+	cases := map[string]string{
+		"depth": `{"a":` + strings.Repeat("[", 100) + strings.Repeat("]", 100) + `}`,
+		"array": `{"ids":[` + strings.Repeat("1,", 10000) + `1]}`,
+	}
+	for name, body := range cases {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		appctx.Wrap(func(c *appctx.Context) {
+			var input map[string]any
+			if c.BindJSON(&input) {
+				t.Errorf("%s: expected BindJSON to fail", name)
+			}
+		})(rec, req)
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected 422, got %d (body: %s)", name, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestValidationFailureMetric(t *testing.T) {
+	r := chi.NewRouter()
+	r.Post("/api/users/{id}", appctx.Wrap(func(c *appctx.Context) {
+		var input struct {
+			Email string `json:"email" validate:"required,email"`
+			Name  string `json:"name"  validate:"required"`
+		}
+		c.BindJSON(&input)
+	}))
+
+	counter := metrics.ValidationFailures.WithLabelValues("POST /api/users/{id}", "email")
+	before := testutil.ToFloat64(counter)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/7", strings.NewReader(`{"email":"nope","name":"x"}`))
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("email failures = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.ValidationFailures.WithLabelValues("POST /api/users/{id}", "name")); got != 0 {
+		t.Errorf("name failures = %v, want 0", got)
+	}
+}
@@ -0,0 +1,117 @@
+package ctx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/cachecontrol"
+)
+
+// ─── Conditional requests ─────────────────────────────────────────────────────
+
+// JSONWithETag serialises v once, tags the response with a strong ETag
+// derived from the bytes, and answers a matching If-None-Match with a bodyless
+// 304 instead of resending the payload.
+//
+//	c.JSONWithETag(http.StatusOK, user)
+func (c *Context) JSONWithETag(code int, v any) {
+	c.jsonWithETag(code, v, false)
+}
+
+// JSONWithWeakETag is like JSONWithETag but emits a weak validator (W/"…").
+// Use it for collections and other representations that are semantically
+// equivalent even when their bytes may differ (ordering, formatting).
+func (c *Context) JSONWithWeakETag(code int, v any) {
+	c.jsonWithETag(code, v, true)
+}
+
+// IfNoneMatch sets the ETag header to etag and, when the request's
+// If-None-Match matches it, writes 304 Not Modified and returns true. Use it
+// to skip loading and serialising a resource whose version you already know:
+//
+//	etag := fmt.Sprintf(`"%d-%d"`, post.ID, post.UpdatedAt.Unix())
+//	if c.IfNoneMatch(etag) {
+//	    return
+//	}
+//	c.JSON(http.StatusOK, post.WithComments())
+func (c *Context) IfNoneMatch(etag string) bool {
+	if !c.writable() {
+		return false
+	}
+	c.W.Header().Set("ETag", etag)
+	if !etagMatches(c.R, etag) {
+		return false
+	}
+	c.W.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+func (c *Context) jsonWithETag(code int, v any, weak bool) {
+	if !c.writable() {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		c.Error(http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		etag = "W/" + etag
+	}
+
+	// Only successful reads are cacheable validators.
+	if code == http.StatusOK && c.IfNoneMatch(etag) {
+		return
+	}
+	c.W.Header().Set("ETag", etag)
+	c.W.Header().Set("Content-Type", "application/json")
+	c.W.WriteHeader(code)
+	c.W.Write(buf.Bytes()) //nolint:errcheck
+}
+
+// etagMatches applies the weak comparison RFC 9110 prescribes for
+// If-None-Match. Only GET and HEAD are answered with 304.
+func etagMatches(r *http.Request, etag string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// ─── Cache policy ─────────────────────────────────────────────────────────────
+
+// CacheControl sets the Cache-Control, Expires and Vary headers of a caching
+// policy on the response. Call it before writing the body:
+//
+//	c.CacheControl(cachecontrol.Public(5 * time.Minute).Vary("Accept-Language"))
+//	c.JSONWithETag(http.StatusOK, products)
+//
+// It overrides a route's middleware.CacheControl for this response.
+func (c *Context) CacheControl(p cachecontrol.Policy) {
+	if !c.writable() {
+		return
+	}
+	p.Apply(c.W.Header(), time.Now())
+}
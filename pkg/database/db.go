@@ -2,9 +2,14 @@ package database
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/profiler"
+	"github.com/shashiranjanraj/kashvi/pkg/tracing"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
@@ -19,27 +24,107 @@ var DB *gorm.DB
 // Returns an error instead of calling log.Fatal so the caller can
 // shut down gracefully.
 func Connect() error {
-	driver := config.DatabaseDriver()
-	dsn := config.DatabaseDSN()
+	db, err := Open(config.DatabaseDriver(), config.DatabaseDSN())
+	if err != nil {
+		return err
+	}
+	if config.DatabaseInMemory() {
+		if err := pinSingleConn(db); err != nil {
+			return err
+		}
+	}
+	if profiler.Enabled() {
+		// Development: record request-scoped queries for the error page.
+		if err := db.Use(profiler.GormPlugin{}); err != nil {
+			return fmt.Errorf("database: profiler: %w", err)
+		}
+	}
+	if tracing.Enabled() {
+		// A span per statement, nested under the request's or job's span.
+		if err := db.Use(tracing.GormPlugin{}); err != nil {
+			return fmt.Errorf("database: tracing: %w", err)
+		}
+	}
+	DB = db
+	return nil
+}
+
+// ─── Named connections ─────────────────────────────────────────────────────────
+
+var (
+	connMu sync.Mutex
+	conns  = map[string]*gorm.DB{}
+)
+
+// Connection returns the named connection, opening it on first use.
+// "primary" (or "") is the global DB; other names are configured with
+// DB_<NAME>_DRIVER and DB_<NAME>_DSN (see config.DatabaseConnection).
+func Connection(name string) (*gorm.DB, error) {
+	if name == "" || name == "primary" {
+		if DB == nil {
+			return nil, fmt.Errorf("database: primary connection not established")
+		}
+		return DB, nil
+	}
+
+	connMu.Lock()
+	defer connMu.Unlock()
+	if db, ok := conns[name]; ok {
+		return db, nil
+	}
+	driver, dsn, ok := config.DatabaseConnection(name)
+	if !ok {
+		return nil, fmt.Errorf("database: connection %q not configured (set DB_%s_DSN)", name, strings.ToUpper(name))
+	}
+	db, err := Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("database: connection %q: %w", name, err)
+	}
+	conns[name] = db
+	return db, nil
+}
+
+// CloseConnections closes every named connection opened by Connection.
+// The primary DB is left alone.
+func CloseConnections() error {
+	connMu.Lock()
+	defer connMu.Unlock()
+	var first error
+	for name, db := range conns {
+		if err := Close(db); err != nil && first == nil {
+			first = err
+		}
+		delete(conns, name)
+	}
+	return first
+}
+
+// Open connects to driver/dsn with the framework's defaults (silent GORM
+// logger, production pool settings, SQLite pragmas) without touching the
+// global DB. Use it for secondary connections.
+func Open(driver, dsn string) (*gorm.DB, error) {
+	if driver == "sqlite" {
+		dsn = sqliteDSN(dsn)
+	}
 
 	dialector, err := buildDialector(driver, dsn)
 	if err != nil {
-		return fmt.Errorf("database: build dialector: %w", err)
+		return nil, fmt.Errorf("database: build dialector: %w", err)
 	}
 
 	gormCfg := &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent), // use pkg/logger, not GORM's own
 	}
 
-	DB, err = gorm.Open(dialector, gormCfg)
+	db, err := gorm.Open(dialector, gormCfg)
 	if err != nil {
-		return fmt.Errorf("database: open: %w", err)
+		return nil, fmt.Errorf("database: open: %w", err)
 	}
 
 	// Configure connection pool for production.
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		return fmt.Errorf("database: get sql.DB: %w", err)
+		return nil, fmt.Errorf("database: get sql.DB: %w", err)
 	}
 	sqlDB.SetMaxOpenConns(25)
 	sqlDB.SetMaxIdleConns(10)
@@ -48,12 +133,82 @@ func Connect() error {
 
 	// Verify connection is live.
 	if err := sqlDB.Ping(); err != nil {
-		return fmt.Errorf("database: ping: %w", err)
+		return nil, fmt.Errorf("database: ping: %w", err)
 	}
 
+	return db, nil
+}
+
+// OpenMemory opens an isolated in-memory SQLite database called name.
+// Every distinct name is a separate database that lives until the returned
+// connection is closed — ideal for per-test fixtures (see testkit.MemoryDB).
+func OpenMemory(name string) (*gorm.DB, error) {
+	db, err := Open("sqlite", config.SQLiteMemoryDSN(name))
+	if err != nil {
+		return nil, err
+	}
+	if err := pinSingleConn(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Close closes the underlying connection pool of db.
+func Close(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// pinSingleConn keeps an in-memory database alive: it vanishes when its last
+// connection closes, so the pool must hold exactly one connection forever.
+func pinSingleConn(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("database: get sql.DB: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+	sqlDB.SetConnMaxIdleTime(0)
 	return nil
 }
 
+// sqliteDSN appends the configured pragmas as go-sqlite3 DSN parameters, so
+// they apply to every pooled connection rather than just the first. Values
+// already present in dsn win.
+func sqliteDSN(dsn string) string {
+	inMemory := strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+
+	params := url.Values{}
+	params.Set("_busy_timeout", config.SQLiteBusyTimeout())
+	if config.SQLiteForeignKeys() {
+		params.Set("_foreign_keys", "1")
+	}
+	if !inMemory {
+		// WAL and synchronous only make sense for on-disk databases.
+		params.Set("_journal_mode", config.SQLiteJournalMode())
+		params.Set("_synchronous", config.SQLiteSynchronous())
+	}
+
+	base, query, _ := strings.Cut(dsn, "?")
+	existing, _ := url.ParseQuery(query)
+	for k, v := range params {
+		if existing.Get(k) == "" {
+			existing[k] = v
+		}
+	}
+	if !strings.HasPrefix(base, "file:") && len(existing) > 0 {
+		base = "file:" + base
+	}
+	return base + "?" + existing.Encode()
+}
+
 func buildDialector(driver, dsn string) (gorm.Dialector, error) {
 	switch driver {
 	case "sqlite":
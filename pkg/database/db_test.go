@@ -0,0 +1,23 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSQLiteDSNAppliesPragmas(t *testing.T) {
+	got := sqliteDSN("kashvi.db")
+	for _, want := range []string{"file:kashvi.db?", "_journal_mode=WAL", "_busy_timeout=5000", "_foreign_keys=1", "_synchronous=NORMAL"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("sqliteDSN() = %q, missing %q", got, want)
+		}
+	}
+
+	got = sqliteDSN("file:x?mode=memory&cache=shared&_busy_timeout=10")
+	if strings.Contains(got, "_journal_mode") {
+		t.Errorf("in-memory DSN should not set journal mode: %q", got)
+	}
+	if !strings.Contains(got, "_busy_timeout=10") {
+		t.Errorf("explicit DSN params should win: %q", got)
+	}
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"gorm.io/gorm"
+)
+
+// RetryOptions controls how transient failures are retried.
+type RetryOptions struct {
+	Attempts int           // total attempts including the first (default 3)
+	Backoff  time.Duration // initial backoff, doubled per attempt with jitter (default 50ms)
+}
+
+// DefaultRetryOptions reads DB_RETRY_ATTEMPTS and DB_RETRY_BACKOFF_MS.
+func DefaultRetryOptions() RetryOptions {
+	opts := RetryOptions{Attempts: 3, Backoff: 50 * time.Millisecond}
+	if n, err := strconv.Atoi(config.Get("DB_RETRY_ATTEMPTS", "3")); err == nil && n > 0 {
+		opts.Attempts = n
+	}
+	if ms, err := strconv.Atoi(config.Get("DB_RETRY_BACKOFF_MS", "50")); err == nil && ms > 0 {
+		opts.Backoff = time.Duration(ms) * time.Millisecond
+	}
+	return opts
+}
+
+// WithRetry runs fn, retrying it while it fails with a transient error
+// (deadlock, serialization failure, dropped connection). fn must be
+// idempotent — it may run more than once, including after a connection was
+// lost with its outcome unknown.
+//
+//	err := database.WithRetry(ctx, func() error {
+//	    return database.DB.WithContext(ctx).Model(&acct).Update("balance", 0).Error
+//	})
+func WithRetry(ctx context.Context, fn func() error) error {
+	return retry(ctx, DefaultRetryOptions(), IsTransient, fn)
+}
+
+// RetryableTransaction runs fn inside a transaction on DB and re-runs the
+// whole transaction when the database aborted it over a conflict (see
+// IsConflict). Dropped connections are not retried: a connection lost
+// during COMMIT leaves the outcome unknown, and re-running could apply the
+// transaction twice. Wrap an idempotent transaction in WithRetry for that.
+// Any other error (or a panic) rolls back and is returned as-is.
+//
+//	err := database.RetryableTransaction(ctx, func(tx *gorm.DB) error {
+//	    if err := tx.Model(&from).Update("balance", gorm.Expr("balance - ?", amt)).Error; err != nil {
+//	        return err
+//	    }
+//	    return tx.Model(&to).Update("balance", gorm.Expr("balance + ?", amt)).Error
+//	})
+func RetryableTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if DB == nil {
+		return errors.New("database: not connected")
+	}
+	return retry(ctx, DefaultRetryOptions(), IsConflict, func() error {
+		return DB.WithContext(ctx).Transaction(fn)
+	})
+}
+
+func retry(ctx context.Context, opts RetryOptions, retryable func(error) bool, fn func() error) error {
+	if opts.Attempts < 1 {
+		opts.Attempts = 1
+	}
+	var err error
+	for attempt := 1; attempt <= opts.Attempts; attempt++ {
+		err = fn()
+		if err == nil || !retryable(err) || attempt == opts.Attempts {
+			return err
+		}
+
+		// Exponential backoff with full jitter so competing transactions that
+		// deadlocked on each other do not retry in lock-step.
+		backoff := opts.Backoff << (attempt - 1)
+		backoff = time.Duration(rand.Int64N(int64(backoff)) + 1)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+	}
+	return err
+}
+
+// IsTransient reports whether err is a database failure that is likely to
+// succeed when retried: a conflict (see IsConflict) or a dropped connection,
+// across the supported drivers. Only retry idempotent work on a dropped
+// connection; the failed statement may have been applied.
+func IsTransient(err error) bool {
+	return IsConflict(err) || isConnectionError(err)
+}
+
+// IsConflict reports whether err is a deadlock, serialization failure or
+// lock timeout: the database rolled the work back, so re-running a whole
+// transaction is safe.
+func IsConflict(err error) bool {
+	if err == nil || isCanceled(err) {
+		return false
+	}
+
+	// Postgres (pgx): serialization failure and deadlock.
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		state := pgErr.SQLState()
+		return state == "40001" || state == "40P01"
+	}
+
+	// SQL Server: 1205 deadlock victim, 1222 lock timeout.
+	var msErr interface{ SQLErrorNumber() int32 }
+	if errors.As(err, &msErr) {
+		n := msErr.SQLErrorNumber()
+		return n == 1205 || n == 1222
+	}
+
+	// MySQL and SQLite errors carry no stable interface, so fall back to the
+	// messages both drivers produce.
+	return containsAny(err, conflictMessages)
+}
+
+// isConnectionError reports whether err means the connection to the
+// database failed or was dropped.
+func isConnectionError(err error) bool {
+	if err == nil || isCanceled(err) {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// Postgres: SQLSTATE class 08 (connection exception), plus admin/crash
+	// shutdown.
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		state := pgErr.SQLState()
+		return strings.HasPrefix(state, "08") || state == "57P01" || state == "57P02" || state == "57P03"
+	}
+	return containsAny(err, connectionMessages)
+}
+
+func isCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func containsAny(err error, needles []string) bool {
+	msg := strings.ToLower(err.Error())
+	for _, needle := range needles {
+		if strings.Contains(msg, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+var conflictMessages = []string{
+	"error 1213",                 // MySQL: deadlock found
+	"error 1205",                 // MySQL: lock wait timeout
+	"deadlock",                   // generic
+	"database is locked",         // SQLite: SQLITE_BUSY
+	"database table is locked",   // SQLite: SQLITE_LOCKED
+	"could not serialize access", // Postgres text form of 40001
+}
+
+var connectionMessages = []string{
+	"error 2006",                   // MySQL: server has gone away
+	"error 2013",                   // MySQL: lost connection during query
+	"bad connection",               // database/sql
+	"connection reset by peer",     // network
+	"broken pipe",                  // network
+	"server closed the connection", // Postgres
+}
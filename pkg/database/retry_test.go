@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type sqlStateErr string
+
+func (e sqlStateErr) Error() string    { return "pg error " + string(e) }
+func (e sqlStateErr) SQLState() string { return string(e) }
+
+func TestIsTransient(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("record not found"), false},
+		{driver.ErrBadConn, true},
+		{fmt.Errorf("query: %w", sqlStateErr("40P01")), true},
+		{sqlStateErr("40001"), true},
+		{sqlStateErr("23505"), false}, // unique violation
+		{errors.New("Error 1213 (40001): Deadlock found when trying to get lock"), true},
+		{errors.New("database is locked"), true},
+		{context.Canceled, false},
+	}
+	for _, c := range cases {
+		if got := IsTransient(c.err); got != c.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestRetryStopsOnPermanentError(t *testing.T) {
+	calls := 0
+	permanent := errors.New("syntax error")
+	err := retry(context.Background(), RetryOptions{Attempts: 5, Backoff: time.Millisecond}, IsTransient, func() error {
+		calls++
+		return permanent
+	})
+	if !errors.Is(err, permanent) || calls != 1 {
+		t.Errorf("expected one call returning the permanent error, got %d calls, err=%v", calls, err)
+	}
+}
+
+func TestRetryRecoversFromTransientError(t *testing.T) {
+	calls := 0
+	err := retry(context.Background(), RetryOptions{Attempts: 3, Backoff: time.Millisecond}, IsTransient, func() error {
+		calls++
+		if calls < 3 {
+			return driver.ErrBadConn
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("expected success on third attempt, got %d calls, err=%v", calls, err)
+	}
+}
+
+func TestIsConflict(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{sqlStateErr("40001"), true},
+		{sqlStateErr("40P01"), true},
+		{sqlStateErr("08006"), false}, // connection failure
+		{errors.New("Error 1213 (40001): Deadlock found when trying to get lock"), true},
+		{errors.New("Error 1205 (HY000): Lock wait timeout exceeded"), true},
+		{errors.New("Error 2013: Lost connection to MySQL server during query"), false},
+		{driver.ErrBadConn, false},
+		{errors.New("write: broken pipe"), false},
+	}
+	for _, c := range cases {
+		if got := IsConflict(c.err); got != c.want {
+			t.Errorf("IsConflict(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestTransactionRetryDoesNotRetryLostConnections(t *testing.T) {
+	calls := 0
+	err := retry(context.Background(), RetryOptions{Attempts: 3, Backoff: time.Millisecond}, IsConflict, func() error {
+		calls++
+		return driver.ErrBadConn // e.g. lost during COMMIT: outcome unknown
+	})
+	if !errors.Is(err, driver.ErrBadConn) || calls != 1 {
+		t.Errorf("expected one call, got %d calls, err=%v", calls, err)
+	}
+}
@@ -0,0 +1,122 @@
+// Package errors forwards unexpected failures — recovered panics and errors
+// the application chooses to capture — to an external error tracker such as
+// Sentry or Bugsnag.
+//
+// Register a Reporter once at boot; until then reporting is a no-op:
+//
+//	kerrors.SetReporter(kerrors.ReporterFunc(func(ctx context.Context, e kerrors.Event) {
+//	    hub := sentry.CurrentHub().Clone()
+//	    hub.Scope().SetTag("request_id", e.RequestID)
+//	    hub.CaptureException(e.Err)
+//	}))
+//
+// The HTTP Recovery middleware and the gRPC recovery interceptor report
+// every panic. Report anything else with Capture:
+//
+//	if err := payments.Refund(ctx, id); err != nil {
+//	    kerrors.Capture(ctx, err, "order_id", id)
+//	}
+package errors
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/reqid"
+)
+
+// Event is one reported failure.
+type Event struct {
+	Err error
+	// Panic is the recovered value when the failure was a panic.
+	Panic any
+	// Stack is the goroutine stack where the failure was captured.
+	Stack []byte
+	// RequestID is the HTTP or gRPC request ID from the context, if any.
+	RequestID string
+	// Method and Path describe the request: "GET" and "/orders/7" for HTTP,
+	// "grpc" and the full method name for gRPC. Empty outside a request.
+	Method string
+	Path   string
+	// Tags are extra key/value pairs given to Capture.
+	Tags map[string]string
+	Time time.Time
+}
+
+// Reporter sends events to an error tracker. Report is called synchronously
+// from the failing goroutine, so slow trackers should queue internally.
+type Reporter interface {
+	Report(ctx context.Context, e Event)
+}
+
+// ReporterFunc adapts a function to Reporter.
+type ReporterFunc func(ctx context.Context, e Event)
+
+// Report calls f.
+func (f ReporterFunc) Report(ctx context.Context, e Event) { f(ctx, e) }
+
+var (
+	mu       sync.RWMutex
+	reporter Reporter
+)
+
+// SetReporter installs the reporter (nil disables reporting).
+func SetReporter(r Reporter) {
+	mu.Lock()
+	defer mu.Unlock()
+	reporter = r
+}
+
+// Report fills in the request ID, time and stack when they are missing and
+// hands e to the reporter. A reporter that panics is logged, never
+// propagated.
+func Report(ctx context.Context, e Event) {
+	mu.RLock()
+	r := reporter
+	mu.RUnlock()
+	if r == nil {
+		return
+	}
+	if e.RequestID == "" {
+		e.RequestID = reqid.FromCtx(ctx)
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Stack == nil {
+		e.Stack = debug.Stack()
+	}
+	if e.Err == nil && e.Panic != nil {
+		if err, ok := e.Panic.(error); ok {
+			e.Err = err
+		} else {
+			e.Err = fmt.Errorf("panic: %v", e.Panic)
+		}
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			logger.WithCtx(ctx).Error("errors: reporter panicked", "panic", fmt.Sprint(p))
+		}
+	}()
+	r.Report(ctx, e)
+}
+
+// Capture reports err with optional tags given as key/value pairs. A nil
+// err is ignored.
+func Capture(ctx context.Context, err error, tags ...string) {
+	if err == nil {
+		return
+	}
+	e := Event{Err: err}
+	if len(tags) > 1 {
+		e.Tags = make(map[string]string, len(tags)/2)
+		for i := 0; i+1 < len(tags); i += 2 {
+			e.Tags[tags[i]] = tags[i+1]
+		}
+	}
+	Report(ctx, e)
+}
@@ -0,0 +1,166 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
+)
+
+// ─── Typed listeners ──────────────────────────────────────────────────────────
+
+// listener is one typed listener; call and job are closures over its E.
+type listener struct {
+	name   string
+	queued bool
+	queue  string
+	call   func(e any) error
+	job    func(e any) queue.Job
+}
+
+var listeners = map[reflect.Type][]listener{}
+
+// ListenOption configures a listener registered with ListenFor.
+type ListenOption func(*listener)
+
+// Queued runs the listener on a queue worker instead of inside Dispatch.
+// The event is serialized with the job, so its fields must be exported.
+// Workers must register the same listeners at boot, which they do when they
+// run the same binary.
+func Queued() ListenOption {
+	return func(l *listener) { l.queued = true }
+}
+
+// OnQueue runs the listener on the named queue (implies Queued).
+func OnQueue(name string) ListenOption {
+	return func(l *listener) {
+		l.queued = true
+		l.queue = name
+	}
+}
+
+// Named sets the listener's name, which identifies a queued listener across
+// processes. It defaults to the function's name (e.g.
+// "app/listeners.SendWelcomeEmail"); set it explicitly for closures whose
+// generated names ("main.init.func1") would shift when code moves.
+func Named(name string) ListenOption {
+	return func(l *listener) { l.name = name }
+}
+
+// ListenFor registers fn for events of type E. Listeners run in registration
+// order; queued ones are pushed to the queue when the event is dispatched.
+//
+//	event.ListenFor(func(e UserRegistered) error { ... })
+//	event.ListenFor(SendWelcomeEmail, event.OnQueue("emails"))
+func ListenFor[E any](fn func(E) error, opts ...ListenOption) {
+	l := listener{
+		name: funcName(fn),
+		call: func(e any) error { return fn(e.(E)) },
+	}
+	for _, o := range opts {
+		o(&l)
+	}
+	if l.queued {
+		queue.Register(fmt.Sprintf("%T", &listenerJob[E]{}), func() queue.Job { return &listenerJob[E]{} })
+	}
+
+	t := reflect.TypeFor[E]()
+	mu.Lock()
+	defer mu.Unlock()
+	l.name = uniqueName(listeners[t], l.name)
+	if l.queued {
+		name := l.name
+		l.job = func(e any) queue.Job { return &listenerJob[E]{Listener: name, Event: e.(E)} }
+	}
+	listeners[t] = append(listeners[t], l)
+}
+
+// Dispatch delivers e to every listener of its type. Sync listeners run
+// inline and queued ones are pushed; every listener is attempted and the
+// failures are returned joined, each prefixed with the listener's name.
+// Dispatching an event nobody listens to is not an error.
+func Dispatch(e any) error {
+	mu.RLock()
+	ls := append([]listener(nil), listeners[reflect.TypeOf(e)]...)
+	mu.RUnlock()
+
+	var errs []error
+	for _, l := range ls {
+		var err error
+		if l.queued {
+			err = queue.Dispatch(l.job(e), queue.OnQueue(l.queue))
+		} else {
+			err = l.call(e)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("event: %s: %w", l.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HasListeners reports whether any listener is registered for E.
+func HasListeners[E any]() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return len(listeners[reflect.TypeFor[E]()]) > 0
+}
+
+// listenerJob carries an event to a queued listener.
+type listenerJob[E any] struct {
+	Listener string `json:"listener"`
+	Event    E      `json:"event"`
+}
+
+// Handle runs the named listener; its error triggers the queue's retries.
+func (j *listenerJob[E]) Handle() error {
+	t := reflect.TypeFor[E]()
+	mu.RLock()
+	var call func(any) error
+	for _, l := range listeners[t] {
+		if l.name == j.Listener {
+			call = l.call
+			break
+		}
+	}
+	mu.RUnlock()
+
+	if call == nil {
+		return fmt.Errorf("event: no listener %q for %s", j.Listener, t)
+	}
+	return call(j.Event)
+}
+
+// funcName returns fn's package-qualified name without the "-fm" suffix
+// method values get.
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "listener"
+	}
+	return strings.TrimSuffix(f.Name(), "-fm")
+}
+
+// uniqueName suffixes name with "#2", "#3", … when the same function is
+// registered more than once for a type.
+func uniqueName(ls []listener, name string) string {
+	taken := func(n string) bool {
+		for _, l := range ls {
+			if l.name == n {
+				return true
+			}
+		}
+		return false
+	}
+	if !taken(name) {
+		return name
+	}
+	for i := 2; ; i++ {
+		if n := fmt.Sprintf("%s#%d", name, i); !taken(n) {
+			return n
+		}
+	}
+}
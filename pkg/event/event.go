@@ -1,7 +1,22 @@
-// Package event provides a simple synchronous/async event dispatcher.
+// Package event decouples the code that something happened from the code
+// that reacts to it.
+//
+// Typed events are plain structs; listeners receive them by type and may run
+// inline or on the queue:
+//
+//	type UserRegistered struct{ UserID uint; Email string }
+//
+//	event.ListenFor(func(e UserRegistered) error { return audit.Log(e.UserID) })
+//	event.ListenFor(SendWelcomeEmail, event.Queued())
+//
+//	err := event.Dispatch(UserRegistered{UserID: u.ID, Email: u.Email})
+//
+// Named events (On/Fire) carry an untyped payload and suit string-keyed
+// families such as "billing.<stripe type>".
 package event
 
 import (
+	"reflect"
 	"sync"
 )
 
@@ -13,13 +28,20 @@ var (
 	handlers = map[string][]Handler{}
 )
 
-// Listen registers a handler for the given event name.
-func Listen(event string, handler Handler) {
+// On registers a handler for the given event name.
+func On(event string, handler Handler) {
 	mu.Lock()
 	defer mu.Unlock()
 	handlers[event] = append(handlers[event], handler)
 }
 
+// Listen registers a handler for the given event name.
+//
+// Deprecated: use On, or ListenFor for typed events.
+func Listen(event string, handler Handler) {
+	On(event, handler)
+}
+
 // Fire dispatches an event synchronously to all registered listeners.
 func Fire(event string, payload interface{}) {
 	mu.RLock()
@@ -45,9 +67,10 @@ func FireAsync(event string, payload interface{}) {
 	}
 }
 
-// Flush removes all listeners (useful in tests).
+// Flush removes all listeners, named and typed (useful in tests).
 func Flush() {
 	mu.Lock()
 	defer mu.Unlock()
 	handlers = map[string][]Handler{}
+	listeners = map[reflect.Type][]listener{}
 }
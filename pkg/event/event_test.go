@@ -0,0 +1,103 @@
+package event_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/event"
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
+)
+
+type userRegistered struct {
+	UserID uint
+	Email  string
+}
+
+type orderShipped struct{ OrderID uint }
+
+func TestDispatch_SyncListenersInOrder(t *testing.T) {
+	event.Flush()
+	defer event.Flush()
+
+	var got []string
+	event.ListenFor(func(e userRegistered) error { got = append(got, "a:"+e.Email); return nil })
+	event.ListenFor(func(e userRegistered) error { return errors.New("smtp down") }, event.Named("mailer"))
+	event.ListenFor(func(e userRegistered) error { got = append(got, "c"); return nil })
+	event.ListenFor(func(orderShipped) error { t.Error("wrong event type delivered"); return nil })
+
+	err := event.Dispatch(userRegistered{UserID: 1, Email: "a@x.io"})
+	if err == nil || !strings.Contains(err.Error(), "event: mailer: smtp down") {
+		t.Fatalf("err = %v", err)
+	}
+	if strings.Join(got, ",") != "a:a@x.io,c" {
+		t.Fatalf("listeners ran %v; later listeners must still run after a failure", got)
+	}
+	if !event.HasListeners[orderShipped]() || event.HasListeners[struct{ X int }]() {
+		t.Fatal("HasListeners")
+	}
+	if err := event.Dispatch(struct{ X int }{}); err != nil {
+		t.Fatalf("no listeners: %v", err)
+	}
+}
+
+var welcomed = make(chan userRegistered, 1)
+
+func sendWelcome(e userRegistered) error {
+	welcomed <- e
+	return nil
+}
+
+func TestDispatch_QueuedListenerRunsOnWorker(t *testing.T) {
+	event.Flush()
+	defer event.Flush()
+
+	event.ListenFor(sendWelcome, event.OnQueue("events-test"))
+	if err := event.Dispatch(userRegistered{UserID: 7, Email: "q@x.io"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-welcomed:
+		t.Fatal("queued listener ran inside Dispatch")
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if reason := queue.Work(ctx, queue.WorkerOptions{Queues: []string{"events-test"}, Concurrency: 1, MaxJobs: 1}); reason != queue.StopMaxJobs {
+		t.Fatalf("worker stopped: %q", reason)
+	}
+	select {
+	case e := <-welcomed:
+		if e.UserID != 7 || e.Email != "q@x.io" {
+			t.Fatalf("event = %+v", e)
+		}
+	default:
+		t.Fatal("queued listener did not run")
+	}
+}
+
+func TestOn_NamedEvents(t *testing.T) {
+	event.Flush()
+	defer event.Flush()
+
+	var got any
+	event.On("billing.test", func(p any) { got = p })
+	event.Fire("billing.test", 42)
+	if got != 42 {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestListenIsOn(t *testing.T) {
+	event.Flush()
+	defer event.Flush()
+	var got any
+	event.Listen("user.deleted", func(p any) { got = p })
+	event.Fire("user.deleted", 7)
+	if got != 7 {
+		t.Fatalf("deprecated Listen handler got %v", got)
+	}
+}
@@ -0,0 +1,155 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/ratelimit"
+)
+
+// ─── Rate limiting ────────────────────────────────────────────────────────────
+
+var grpcRateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "grpc_server_rate_limited_total",
+	Help: "Total number of gRPC calls rejected by a rate limit, by method.",
+}, []string{"grpc_method"})
+
+var (
+	limitsMu   sync.RWMutex
+	limits     map[string]*ratelimit.Rule // pattern → rule
+	limitsOnce sync.Once
+)
+
+// RateLimit limits calls matching pattern, per client. pattern is a full
+// method ("/orders.v1.Orders/Create"), a whole service ("/orders.v1.Orders/*")
+// or "*" for every method; the most specific one applies. Calls matching a
+// service or "*" pattern share one bucket per client.
+//
+// Limits can also be configured with GRPC_RATE_LIMITS, a comma-separated
+// list of pattern=rule in ratelimit.Parse syntax:
+//
+//	GRPC_RATE_LIMITS=/orders.v1.Orders/Create=10/s:20,/orders.v1.Orders/*=300/m,*=1000/m
+//
+// Rules set in code override the same pattern from the environment.
+func RateLimit(pattern string, rule *ratelimit.Rule) {
+	loadRateLimits()
+	r := *rule
+	r.Named("grpc:" + pattern)
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	limits[pattern] = &r
+}
+
+func loadRateLimits() {
+	limitsOnce.Do(func() {
+		m := map[string]*ratelimit.Rule{}
+		for _, entry := range strings.Split(config.Get("GRPC_RATE_LIMITS", ""), ",") {
+			if strings.TrimSpace(entry) == "" {
+				continue
+			}
+			pattern, spec, ok := strings.Cut(entry, "=")
+			rule, err := ratelimit.Parse(spec)
+			if !ok || err != nil {
+				slog.Error("grpc: ignoring invalid GRPC_RATE_LIMITS entry", "entry", entry, "error", err)
+				continue
+			}
+			pattern = strings.TrimSpace(pattern)
+			m[pattern] = rule.Named("grpc:" + pattern)
+		}
+		limitsMu.Lock()
+		limits = m
+		limitsMu.Unlock()
+	})
+}
+
+// limitFor returns the most specific rule for method, or nil.
+func limitFor(method string) *ratelimit.Rule {
+	loadRateLimits()
+	limitsMu.RLock()
+	defer limitsMu.RUnlock()
+	if r, ok := limits[method]; ok {
+		return r
+	}
+	if i := strings.LastIndexByte(method, '/'); i > 0 {
+		if r, ok := limits[method[:i]+"/*"]; ok {
+			return r
+		}
+	}
+	return limits["*"]
+}
+
+// rateLimitClient keys buckets by the GRPC_RATE_LIMIT_KEY metadata entry
+// (hashed, e.g. "x-api-key") when set and present, else by the peer's IP.
+func rateLimitClient(ctx context.Context) string {
+	if key := config.Get("GRPC_RATE_LIMIT_KEY", ""); key != "" {
+		if v := metadata.ValueFromIncomingContext(ctx, key); len(v) > 0 && v[0] != "" {
+			sum := sha256.Sum256([]byte(v[0]))
+			return "md:" + hex.EncodeToString(sum[:8])
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr := p.Addr.String()
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+		return "ip:" + addr
+	}
+	return ""
+}
+
+// RateLimitInterceptor applies the limits set with RateLimit and
+// GRPC_RATE_LIMITS. Calls over the limit fail with RESOURCE_EXHAUSTED and a
+// google.rpc.RetryInfo detail; every limited call gets x-ratelimit-limit,
+// x-ratelimit-remaining and x-ratelimit-reset response headers, as on HTTP.
+// If the bucket store fails, calls are let through. Start installs it.
+func RateLimitInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	rule := limitFor(info.FullMethod)
+	client := rateLimitClient(ctx)
+	if rule == nil || client == "" {
+		return handler(ctx, req)
+	}
+	res, err := rule.Take(ctx, client)
+	if err != nil {
+		slog.Warn("grpc: rate limit store unavailable, allowing call", "method", info.FullMethod, "error", err)
+		return handler(ctx, req)
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs( //nolint:errcheck // fails only outside a server call
+		"x-ratelimit-limit", strconv.Itoa(rule.Limit()),
+		"x-ratelimit-remaining", strconv.Itoa(res.Remaining),
+		"x-ratelimit-reset", strconv.FormatInt(time.Now().Add(res.ResetAfter).Unix(), 10),
+	))
+	if !res.Allowed {
+		grpcRateLimited.WithLabelValues(info.FullMethod).Inc()
+		st := status.New(codes.ResourceExhausted, "rate limit exceeded")
+		if d, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(res.RetryAfter)}); err == nil {
+			st = d
+		}
+		return nil, st.Err()
+	}
+	return handler(ctx, req)
+}
@@ -0,0 +1,61 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	kgrpc "github.com/shashiranjanraj/kashvi/pkg/grpc"
+	"github.com/shashiranjanraj/kashvi/pkg/ratelimit"
+)
+
+func TestRateLimitInterceptor(t *testing.T) {
+	ratelimit.SetStore(ratelimit.NewMemoryStore())
+	t.Cleanup(func() { ratelimit.SetStore(nil) })
+	kgrpc.RateLimit("/orders.v1.Orders/*", ratelimit.PerMinute(60).Burst(2))
+	kgrpc.RateLimit("/orders.v1.Orders/Get", ratelimit.PerMinute(600))
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.7"), Port: 5555},
+	})
+	call := func(method string) error {
+		_, err := kgrpc.RateLimitInterceptor(ctx, struct{}{},
+			&grpc.UnaryServerInfo{FullMethod: method},
+			func(context.Context, any) (any, error) { return "ok", nil })
+		return err
+	}
+
+	// The service pattern's bucket is shared by its methods.
+	for _, m := range []string{"/orders.v1.Orders/Create", "/orders.v1.Orders/Cancel"} {
+		if err := call(m); err != nil {
+			t.Fatalf("%s: %v", m, err)
+		}
+	}
+	err := call("/orders.v1.Orders/Create")
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("code = %v, want ResourceExhausted", st.Code())
+	}
+	var retry *errdetails.RetryInfo
+	for _, d := range st.Details() {
+		if r, ok := d.(*errdetails.RetryInfo); ok {
+			retry = r
+		}
+	}
+	if retry == nil || retry.GetRetryDelay().AsDuration() <= 0 {
+		t.Fatalf("RetryInfo = %v", retry)
+	}
+
+	// An exact method pattern wins, and unmatched methods are not limited.
+	for _, m := range []string{"/orders.v1.Orders/Get", "/users.v1.Users/Get"} {
+		if err := call(m); err != nil {
+			t.Fatalf("%s: %v", m, err)
+		}
+	}
+}
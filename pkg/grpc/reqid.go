@@ -0,0 +1,87 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/reqid"
+)
+
+// ─── Request ID ───────────────────────────────────────────────────────────────
+
+// requestIDKey is reqid.Header as a metadata key (gRPC keys are lowercase).
+var requestIDKey = strings.ToLower(reqid.Header)
+
+// withRequestID tags ctx the way reqid.Middleware and middleware.Logger do
+// for HTTP: the caller's x-request-id (or a new one) is stored with
+// reqid.WithValue and a logger carrying it with logger.InjectLogger, so
+// reqid.FromCtx and logger.WithCtx work the same in gRPC handlers.
+func withRequestID(ctx context.Context, method string) (context.Context, string) {
+	id := ""
+	if v := metadata.ValueFromIncomingContext(ctx, requestIDKey); len(v) > 0 {
+		id = v[0]
+	}
+	if id == "" {
+		id = reqid.New()
+	}
+	ctx = reqid.WithValue(ctx, id)
+	ctx = logger.InjectLogger(ctx, logger.L.With("request_id", id, "grpc_method", method))
+	return ctx, id
+}
+
+// RequestIDInterceptor gives every unary call a request ID and a tagged
+// logger (see withRequestID) and returns the ID in the x-request-id
+// trailer. Start installs it first, so every other interceptor's log lines
+// carry the ID.
+func RequestIDInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, id := withRequestID(ctx, info.FullMethod)
+	grpc.SetTrailer(ctx, metadata.Pairs(requestIDKey, id)) //nolint:errcheck // fails only outside a server call
+	return handler(ctx, req)
+}
+
+// RequestIDStreamInterceptor is RequestIDInterceptor for streaming calls.
+func RequestIDStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, id := withRequestID(ss.Context(), info.FullMethod)
+	ss.SetTrailer(metadata.Pairs(requestIDKey, id))
+	return handler(srv, &taggedStream{ServerStream: ss, ctx: ctx})
+}
+
+// taggedStream overrides the stream's context with the tagged one.
+type taggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *taggedStream) Context() context.Context { return s.ctx }
+
+// RequestIDClientInterceptor forwards the request ID in ctx to outgoing
+// calls, so a chain of services logs one ID end to end:
+//
+//	conn, err := grpc.NewClient(addr, grpc.WithUnaryInterceptor(kashvigrpc.RequestIDClientInterceptor))
+func RequestIDClientInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply interface{},
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	if id := reqid.FromCtx(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, requestIDKey, id)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
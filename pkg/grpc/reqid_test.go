@@ -0,0 +1,75 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	kgrpc "github.com/shashiranjanraj/kashvi/pkg/grpc"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/reqid"
+)
+
+func TestRequestIDInterceptor_Context(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "abc123"))
+	_, err := kgrpc.RequestIDInterceptor(ctx, struct{}{},
+		&grpc.UnaryServerInfo{FullMethod: "/users.v1.Users/Get"},
+		func(ctx context.Context, _ any) (any, error) {
+			if id := reqid.FromCtx(ctx); id != "abc123" {
+				t.Errorf("reqid.FromCtx = %q", id)
+			}
+			if logger.WithCtx(ctx) == logger.L {
+				t.Error("logger.WithCtx returned the untagged base logger")
+			}
+			return nil, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequestIDInterceptor_Trailer(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(kgrpc.RequestIDInterceptor))
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis) //nolint:errcheck
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(kgrpc.RequestIDClientInterceptor),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := grpc_health_v1.NewHealthClient(conn)
+
+	check := func(ctx context.Context) string {
+		t.Helper()
+		var trailer metadata.MD
+		if _, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.Trailer(&trailer)); err != nil {
+			t.Fatal(err)
+		}
+		if v := trailer.Get("x-request-id"); len(v) == 1 {
+			return v[0]
+		}
+		return ""
+	}
+
+	// The client interceptor forwards the caller's ID; the server echoes it.
+	if got := check(reqid.WithValue(context.Background(), "from-http")); got != "from-http" {
+		t.Fatalf("trailer = %q, want the forwarded ID", got)
+	}
+	if got := check(context.Background()); len(got) != 32 {
+		t.Fatalf("trailer = %q, want a generated ID", got)
+	}
+}
@@ -1,9 +1,14 @@
 // Package grpc provides a production-ready gRPC server for Kashvi.
 //
 // Features:
+//   - OpenTelemetry server spans (see pkg/tracing), when tracing is enabled
+//   - Request ID interceptor (x-request-id metadata, tagged logger.WithCtx logger)
 //   - Panic-recovery interceptor (returns INTERNAL status instead of killing goroutine)
 //   - Request logging interceptor (method, duration, status code)
-//   - Prometheus metrics interceptor (grpc_server_handled_total, grpc_server_handling_seconds)
+//   - Prometheus metrics interceptor (grpc_server_handled_total, grpc_server_handling_seconds,
+//     grpc_server_request_size_bytes, grpc_server_response_size_bytes)
+//   - Per-method rate limiting interceptor (RESOURCE_EXHAUSTED with google.rpc.RetryInfo)
+//   - Request validation interceptor (INVALID_ARGUMENT with google.rpc.BadRequest)
 //   - Standard gRPC health-check service (grpc.health.v1.Health)
 //   - Graceful shutdown via Stop()
 //
@@ -27,9 +32,14 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	kerrors "github.com/shashiranjanraj/kashvi/pkg/errors"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/tracing"
 )
 
 // ─── Prometheus metrics ───────────────────────────────────────────────────────
@@ -45,12 +55,26 @@ var (
 		Help:    "Histogram of gRPC response latency in seconds.",
 		Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
 	}, []string{"grpc_method"})
+
+	// 64 B … 1 MB, matching the HTTP size histograms.
+	grpcRequestSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_request_size_bytes",
+		Help:    "Size of gRPC request messages in bytes.",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+	}, []string{"grpc_method"})
+
+	grpcResponseSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_response_size_bytes",
+		Help:    "Size of gRPC response messages in bytes.",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+	}, []string{"grpc_method"})
 )
 
 // ─── Interceptors ─────────────────────────────────────────────────────────────
 
-// recoveryInterceptor catches panics in gRPC handlers and returns a gRPC
-// INTERNAL error instead of crashing the process.
+// recoveryInterceptor catches panics in gRPC handlers, reports them to the
+// errors.Reporter and returns a gRPC INTERNAL error instead of crashing the
+// process.
 func recoveryInterceptor(
 	ctx context.Context,
 	req interface{},
@@ -59,11 +83,13 @@ func recoveryInterceptor(
 ) (resp interface{}, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			slog.Error("grpc: panic recovered",
+			stack := debug.Stack()
+			logger.WithCtx(ctx).Error("grpc: panic recovered",
 				"method", info.FullMethod,
 				"panic", r,
-				"stack", string(debug.Stack()),
+				"stack", string(stack),
 			)
+			kerrors.Report(ctx, kerrors.Event{Panic: r, Stack: stack, Method: "grpc", Path: info.FullMethod})
 			err = status.Errorf(codes.Internal, "internal server error")
 		}
 	}()
@@ -86,7 +112,7 @@ func loggingInterceptor(
 		code = status.Code(err)
 	}
 
-	slog.Info("grpc: request",
+	logger.WithCtx(ctx).Info("grpc: request",
 		"method", info.FullMethod,
 		"duration_ms", dur.Milliseconds(),
 		"code", code.String(),
@@ -94,13 +120,17 @@ func loggingInterceptor(
 	return resp, err
 }
 
-// metricsInterceptor records Prometheus counters and histograms per RPC.
+// metricsInterceptor records Prometheus counters and histograms per RPC,
+// including the encoded size of request and response messages.
 func metricsInterceptor(
 	ctx context.Context,
 	req interface{},
 	info *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (interface{}, error) {
+	if m, ok := req.(proto.Message); ok {
+		grpcRequestSize.WithLabelValues(info.FullMethod).Observe(float64(proto.Size(m)))
+	}
 	start := time.Now()
 	resp, err := handler(ctx, req)
 	dur := time.Since(start)
@@ -112,6 +142,9 @@ func metricsInterceptor(
 
 	grpcRequestsTotal.WithLabelValues(info.FullMethod, code.String()).Inc()
 	grpcRequestDuration.WithLabelValues(info.FullMethod).Observe(dur.Seconds())
+	if m, ok := resp.(proto.Message); ok && err == nil {
+		grpcResponseSize.WithLabelValues(info.FullMethod).Observe(float64(proto.Size(m)))
+	}
 	return resp, err
 }
 
@@ -163,9 +196,15 @@ func (h *healthServer) Watch(
 
 // ─── Public API ───────────────────────────────────────────────────────────────
 
-// Start creates and starts a gRPC server on the given port.
+// Start creates and starts a gRPC server on the given port. Each services
+// func is called with the server before it starts serving, which is where
+// application services are registered (gRPC does not allow registering
+// them later):
+//
+//	kashvigrpc.Start(port, func(s *grpc.Server) { orderspb.RegisterOrdersServer(s, impl) })
+//
 // Returns the server and the net.Listener so callers can gracefully stop it.
-func Start(port string) (*grpc.Server, net.Listener, error) {
+func Start(port string, services ...func(*grpc.Server)) (*grpc.Server, net.Listener, error) {
 	addr := ":" + port
 
 	lis, err := net.Listen("tcp", addr)
@@ -176,11 +215,16 @@ func Start(port string) (*grpc.Server, net.Listener, error) {
 	srv := grpc.NewServer(
 		grpc.UnaryInterceptor(
 			chainUnary(
+				tracing.UnaryServerInterceptor,
+				RequestIDInterceptor,
 				recoveryInterceptor,
 				loggingInterceptor,
 				metricsInterceptor,
+				RateLimitInterceptor,
+				ValidationInterceptor,
 			),
 		),
+		grpc.ChainStreamInterceptor(tracing.StreamServerInterceptor, RequestIDStreamInterceptor),
 		// Connection settings for high throughput.
 		grpc.MaxRecvMsgSize(4*1024*1024), // 4 MB
 		grpc.MaxSendMsgSize(4*1024*1024), // 4 MB
@@ -192,6 +236,10 @@ func Start(port string) (*grpc.Server, net.Listener, error) {
 	// Enable server reflection so tools like grpcurl work without proto files.
 	reflection.Register(srv)
 
+	for _, register := range services {
+		register(srv)
+	}
+
 	slog.Info("gRPC server starting", "addr", addr)
 
 	go func() {
@@ -0,0 +1,49 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	kgrpc "github.com/shashiranjanraj/kashvi/pkg/grpc"
+)
+
+// pingService is a hand-written ServiceDesc standing in for generated code.
+var pingService = grpc.ServiceDesc{
+	ServiceName: "test.Ping",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Ping",
+		Handler: func(_ any, ctx context.Context, dec func(any) error, icpt grpc.UnaryServerInterceptor) (any, error) {
+			in := new(emptypb.Empty)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			h := func(context.Context, any) (any, error) { return &emptypb.Empty{}, nil }
+			if icpt == nil {
+				return h(ctx, in)
+			}
+			return icpt(ctx, in, &grpc.UnaryServerInfo{FullMethod: "/test.Ping/Ping"}, h)
+		},
+	}},
+}
+
+func TestStart_RegistersServices(t *testing.T) {
+	srv, lis, err := kgrpc.Start("0", func(s *grpc.Server) { s.RegisterService(&pingService, struct{}{}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { kgrpc.Stop(srv) })
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := conn.Invoke(context.Background(), "/test.Ping/Ping", &emptypb.Empty{}, &emptypb.Empty{}); err != nil {
+		t.Fatalf("registered service: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/shashiranjanraj/kashvi/pkg/validate"
+)
+
+// ─── Request validation ───────────────────────────────────────────────────────
+
+// pgvMessage is implemented by messages generated with protoc-gen-validate
+// (rules declared as (validate.rules) field options).
+type pgvMessage interface {
+	ValidateAll() error
+}
+
+// pgvFieldError is a single violation produced by protoc-gen-validate.
+type pgvFieldError interface {
+	Field() string
+	Reason() string
+}
+
+// ValidationInterceptor validates incoming messages before the handler runs
+// and rejects invalid ones with INVALID_ARGUMENT carrying a
+// google.rpc.BadRequest detail listing every field violation — the gRPC
+// counterpart of an HTTP 422.
+//
+// Messages generated by protoc-gen-validate are checked with their
+// ValidateAll method. Any other message is checked with pkg/validate, so
+// `validate:"…"` struct tags (e.g. injected with protoc-go-inject-tag) and
+// Validate(ctx) hooks work as they do for HTTP input. A handler that returns
+// validate.FieldViolations (e.g. from a service's validate.Check) gets the
+// same status. Start installs it.
+func ValidationInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if m, ok := req.(pgvMessage); ok {
+		if violations := pgvViolations(m.ValidateAll()); len(violations) > 0 {
+			return nil, invalidArgument(violations)
+		}
+	} else if errs := validate.StructCtx(ctx, req); len(errs) > 0 {
+		return nil, InvalidArgument(errs)
+	}
+
+	resp, err := handler(ctx, req)
+	if v, ok := validate.AsViolations(err); ok {
+		return resp, InvalidArgument(v)
+	}
+	return resp, err
+}
+
+// InvalidArgument renders violations as an INVALID_ARGUMENT status with a
+// google.rpc.BadRequest detail, one FieldViolation per field in field order.
+// Violations reads them back on the client.
+func InvalidArgument(v validate.FieldViolations) error {
+	out := make([]*errdetails.BadRequest_FieldViolation, 0, len(v))
+	for _, f := range v.Fields() {
+		out = append(out, &errdetails.BadRequest_FieldViolation{Field: f, Description: v[f]})
+	}
+	return invalidArgument(out)
+}
+
+// Violations returns the field violations carried by an INVALID_ARGUMENT
+// error, as InvalidArgument sent them. ok is false for any other error.
+func Violations(err error) (validate.FieldViolations, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.InvalidArgument {
+		return nil, false
+	}
+	v := validate.FieldViolations{}
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, fv := range br.GetFieldViolations() {
+				v[fv.GetField()] = fv.GetDescription()
+			}
+		}
+	}
+	return v, len(v) > 0
+}
+
+// pgvViolations flattens a protoc-gen-validate error (a multi-error exposing
+// AllErrors, or a single field error) into field violations.
+func pgvViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	if err == nil {
+		return nil
+	}
+	var errs []error
+	if multi, ok := err.(interface{ AllErrors() []error }); ok {
+		errs = multi.AllErrors()
+	} else {
+		errs = []error{err}
+	}
+	out := make([]*errdetails.BadRequest_FieldViolation, 0, len(errs))
+	for _, e := range errs {
+		var fe pgvFieldError
+		if errors.As(e, &fe) {
+			out = append(out, &errdetails.BadRequest_FieldViolation{Field: fe.Field(), Description: fe.Reason()})
+		} else {
+			out = append(out, &errdetails.BadRequest_FieldViolation{Description: e.Error()})
+		}
+	}
+	return out
+}
+
+func invalidArgument(violations []*errdetails.BadRequest_FieldViolation) error {
+	st := status.New(codes.InvalidArgument, validate.Message)
+	detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
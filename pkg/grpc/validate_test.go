@@ -0,0 +1,96 @@
+package grpc_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	kgrpc "github.com/shashiranjanraj/kashvi/pkg/grpc"
+	"github.com/shashiranjanraj/kashvi/pkg/validate"
+)
+
+type createUserRequest struct {
+	Email string `json:"email,omitempty" validate:"required,email"`
+	Age   int32  `json:"age,omitempty" validate:"gte=18"`
+}
+
+type fieldError struct{ field, reason string }
+
+func (e fieldError) Error() string  { return e.field + ": " + e.reason }
+func (e fieldError) Field() string  { return e.field }
+func (e fieldError) Reason() string { return e.reason }
+
+type multiError []error
+
+func (m multiError) Error() string      { return "invalid" }
+func (m multiError) AllErrors() []error { return m }
+
+type pgvRequest struct{}
+
+func (pgvRequest) ValidateAll() error {
+	return multiError{fieldError{"name", "value length must be at least 2 runes"}}
+}
+
+func call(req any) (bool, error) {
+	called := false
+	_, err := kgrpc.ValidationInterceptor(context.Background(), req,
+		&grpc.UnaryServerInfo{FullMethod: "/users.v1.Users/Create"},
+		func(context.Context, any) (any, error) { called = true; return nil, nil })
+	return called, err
+}
+
+func violations(t *testing.T, err error) map[string]string {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("code = %v, want InvalidArgument", st.Code())
+	}
+	out := map[string]string{}
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				out[v.GetField()] = v.GetDescription()
+			}
+		}
+	}
+	return out
+}
+
+func TestValidationInterceptor(t *testing.T) {
+	if called, err := call(&createUserRequest{Email: "a@example.com", Age: 30}); !called || err != nil {
+		t.Fatalf("valid request: called=%v err=%v", called, err)
+	}
+
+	called, err := call(&createUserRequest{Email: "nope", Age: 12})
+	if called {
+		t.Fatal("handler ran for an invalid request")
+	}
+	if v := violations(t, err); v["email"] == "" || v["age"] == "" {
+		t.Fatalf("violations = %v, want email and age", v)
+	}
+
+	_, err = call(pgvRequest{})
+	if v := violations(t, err); v["name"] == "" {
+		t.Fatalf("protoc-gen-validate violations = %v", v)
+	}
+}
+
+func TestHandlerViolations(t *testing.T) {
+	_, err := kgrpc.ValidationInterceptor(context.Background(), &createUserRequest{Email: "a@example.com", Age: 30},
+		&grpc.UnaryServerInfo{FullMethod: "/users.v1.Users/Create"},
+		func(context.Context, any) (any, error) {
+			return nil, fmt.Errorf("create user: %w", validate.FieldViolations{"email": "The email has already been taken."})
+		})
+	v, ok := kgrpc.Violations(err)
+	if !ok || v["email"] != "The email has already been taken." {
+		t.Fatalf("Violations(%v) = %v, %v; want the handler's email violation", err, v, ok)
+	}
+	if _, ok := kgrpc.Violations(status.Error(codes.NotFound, "missing")); ok {
+		t.Error("Violations should ignore other codes")
+	}
+}
@@ -0,0 +1,224 @@
+// Package health reports whether this instance is alive and ready to do
+// useful work. Subsystems register readiness checks as they start — queue
+// workers, the scheduler loop, WebSocket hubs, the MongoDB log shipper — and
+// remove them when they stop, so /readyz reflects what is actually running:
+//
+//	health.Register("payments-api", func(ctx context.Context) error {
+//	    return paymentsClient.Ping(ctx)
+//	})
+//
+//	r.HandleFunc("/healthz", health.LiveHandler())
+//	r.HandleFunc("/readyz", health.ReadyHandler())
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check reports why a subsystem cannot serve, or nil when it can.
+type Check func(ctx context.Context) error
+
+// CheckTimeout bounds each check run by Ready.
+var CheckTimeout = 2 * time.Second
+
+var (
+	mu       sync.RWMutex
+	checks   = map[string]Check{}
+	draining atomic.Bool
+)
+
+// Register adds (or replaces) the readiness check called name.
+func Register(name string, check Check) {
+	mu.Lock()
+	checks[name] = check
+	mu.Unlock()
+}
+
+// Unregister removes the readiness check called name.
+func Unregister(name string) {
+	mu.Lock()
+	delete(checks, name)
+	mu.Unlock()
+}
+
+// SetDraining marks the instance as shutting down: Ready fails from now on,
+// so load balancers stop routing to it while in-flight work finishes.
+func SetDraining(v bool) { draining.Store(v) }
+
+// Status values used in a Report.
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusDraining = "draining"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the outcome of every registered check.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// OK reports whether the instance is ready.
+func (r Report) OK() bool { return r.Status == StatusOK }
+
+// Ready runs every registered check concurrently, each bounded by
+// CheckTimeout.
+func Ready(ctx context.Context) Report {
+	mu.RLock()
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fns := make([]Check, len(names))
+	for i, name := range names {
+		fns[i] = checks[name]
+	}
+	mu.RUnlock()
+
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+			defer cancel()
+			if err := run(cctx, fns[i]); err != nil {
+				results[i] = Result{Status: StatusFailing, Error: err.Error()}
+				return
+			}
+			results[i] = Result{Status: StatusOK}
+		}(i)
+	}
+	wg.Wait()
+
+	rep := Report{Status: StatusOK, Checks: make(map[string]Result, len(names))}
+	for i, name := range names {
+		rep.Checks[name] = results[i]
+		if results[i].Status != StatusOK {
+			rep.Status = StatusFailing
+		}
+	}
+	if draining.Load() {
+		rep.Status = StatusDraining
+	}
+	return rep
+}
+
+// run calls check, turning a panic or an overrun into an error.
+func run(ctx context.Context, check Check) (err error) {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- check(ctx)
+	}()
+	select {
+	case err = <-done:
+		return err
+	case <-ctx.Done():
+		return errors.New("check timed out")
+	}
+}
+
+// ─── HTTP ─────────────────────────────────────────────────────────────────────
+
+// LiveHandler answers 200 while the process can serve HTTP at all. Use it
+// for liveness probes; it never runs checks, so a failing dependency does
+// not get the pod restarted.
+func LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+	}
+}
+
+// ReadyHandler answers 200 with the Report when every check passes, and
+// 503 otherwise (including while draining).
+func ReadyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rep := Ready(r.Context())
+		code := http.StatusOK
+		if !rep.OK() {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, rep)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v) //nolint:errcheck
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+// Heartbeat is a check for loops: the loop calls Beat on every iteration,
+// and the check fails once no beat arrived within maxAge.
+type Heartbeat struct {
+	last   atomic.Int64 // unix nanos
+	maxAge time.Duration
+}
+
+// NewHeartbeat returns a Heartbeat that has just beaten.
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	h := &Heartbeat{maxAge: maxAge}
+	h.Beat()
+	return h
+}
+
+// Beat records that the loop is alive.
+func (h *Heartbeat) Beat() { h.last.Store(time.Now().UnixNano()) }
+
+// Check fails when the last beat is older than maxAge.
+func (h *Heartbeat) Check(context.Context) error {
+	if age := time.Since(time.Unix(0, h.last.Load())); age > h.maxAge {
+		return fmt.Errorf("no heartbeat for %s", age.Truncate(time.Second))
+	}
+	return nil
+}
+
+// Sustained wraps a condition sampler so a check only fails once the
+// condition has held for longer than grace — e.g. a buffer that is full now
+// and then is fine, one that stays full is not.
+type Sustained struct {
+	since atomic.Int64 // unix nanos the condition was first seen; 0 = not held
+	grace time.Duration
+}
+
+// NewSustained returns a Sustained with the given grace period.
+func NewSustained(grace time.Duration) *Sustained { return &Sustained{grace: grace} }
+
+// Observe records whether the bad condition currently holds and returns an
+// error once it has held for longer than the grace period.
+func (s *Sustained) Observe(bad bool, what string) error {
+	if !bad {
+		s.since.Store(0)
+		return nil
+	}
+	now := time.Now().UnixNano()
+	s.since.CompareAndSwap(0, now)
+	if held := time.Duration(now - s.since.Load()); held > s.grace {
+		return fmt.Errorf("%s for %s", what, held.Truncate(time.Second))
+	}
+	return nil
+}
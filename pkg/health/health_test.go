@@ -0,0 +1,118 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/health"
+)
+
+func TestReady_ReportsEveryCheck(t *testing.T) {
+	health.Register("t.ok", func(context.Context) error { return nil })
+	health.Register("t.bad", func(context.Context) error { return errors.New("down") })
+	defer health.Unregister("t.ok")
+	defer health.Unregister("t.bad")
+
+	rep := health.Ready(context.Background())
+	if rep.OK() {
+		t.Fatal("report OK with a failing check")
+	}
+	if rep.Checks["t.ok"].Status != health.StatusOK {
+		t.Fatalf("t.ok = %+v", rep.Checks["t.ok"])
+	}
+	if c := rep.Checks["t.bad"]; c.Status != health.StatusFailing || c.Error != "down" {
+		t.Fatalf("t.bad = %+v", c)
+	}
+
+	health.Unregister("t.bad")
+	if rep := health.Ready(context.Background()); !rep.OK() {
+		t.Fatalf("after unregister: %+v", rep)
+	}
+}
+
+func TestReady_TimesOutAndRecoversPanics(t *testing.T) {
+	old := health.CheckTimeout
+	health.CheckTimeout = 50 * time.Millisecond
+	defer func() { health.CheckTimeout = old }()
+
+	block := make(chan struct{})
+	defer close(block)
+	health.Register("t.slow", func(context.Context) error { <-block; return nil })
+	health.Register("t.panic", func(context.Context) error { panic("boom") })
+	defer health.Unregister("t.slow")
+	defer health.Unregister("t.panic")
+
+	rep := health.Ready(context.Background())
+	if rep.Checks["t.slow"].Error != "check timed out" {
+		t.Fatalf("t.slow = %+v", rep.Checks["t.slow"])
+	}
+	if rep.Checks["t.panic"].Status != health.StatusFailing {
+		t.Fatalf("t.panic = %+v", rep.Checks["t.panic"])
+	}
+}
+
+func TestReadyHandler_StatusCodes(t *testing.T) {
+	get := func() (int, health.Report) {
+		rec := httptest.NewRecorder()
+		health.ReadyHandler()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var rep health.Report
+		if err := json.Unmarshal(rec.Body.Bytes(), &rep); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return rec.Code, rep
+	}
+
+	if code, _ := get(); code != http.StatusOK {
+		t.Fatalf("healthy: %d", code)
+	}
+
+	health.SetDraining(true)
+	code, rep := get()
+	health.SetDraining(false)
+	if code != http.StatusServiceUnavailable || rep.Status != health.StatusDraining {
+		t.Fatalf("draining: %d %+v", code, rep)
+	}
+
+	rec := httptest.NewRecorder()
+	health.LiveHandler()(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("healthz: %d", rec.Code)
+	}
+}
+
+func TestHeartbeat_FailsWhenStale(t *testing.T) {
+	hb := health.NewHeartbeat(20 * time.Millisecond)
+	if err := hb.Check(context.Background()); err != nil {
+		t.Fatalf("fresh: %v", err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if err := hb.Check(context.Background()); err == nil {
+		t.Fatal("stale heartbeat passed")
+	}
+	hb.Beat()
+	if err := hb.Check(context.Background()); err != nil {
+		t.Fatalf("after beat: %v", err)
+	}
+}
+
+func TestSustained_OnlyFailsAfterGrace(t *testing.T) {
+	s := health.NewSustained(20 * time.Millisecond)
+	if err := s.Observe(true, "full"); err != nil {
+		t.Fatalf("first observation: %v", err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if err := s.Observe(true, "full"); err == nil {
+		t.Fatal("sustained condition passed")
+	}
+	if err := s.Observe(false, "full"); err != nil {
+		t.Fatalf("cleared: %v", err)
+	}
+	if err := s.Observe(true, "full"); err != nil {
+		t.Fatalf("grace not reset: %v", err)
+	}
+}
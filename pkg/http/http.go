@@ -15,6 +15,18 @@
 //	resp, err := http.Post("https://api.example.com/users").
 //	    Body(map[string]any{"name": "Shashi"}).
 //	    Send()
+//
+//	// Bound to the inbound request, so a client disconnect or timeout
+//	// cancels the outgoing call (c.HTTP() inside a ctx handler):
+//	resp, err := http.WithCtx(r.Context()).Get("https://api.example.com/users").Send()
+//
+// Requests bound to a context carrying a request ID (see
+// middleware.RequestID) send it as X-Request-ID, so one ID follows a call
+// across services.
+//
+// Every attempt is recorded in metrics.DefaultRegistry as
+// kashvi_http_client_request_duration_seconds, kashvi_http_client_requests_total
+// (labels: host, method, status_class) and kashvi_http_client_requests_in_flight.
 package http
 
 import (
@@ -25,9 +37,14 @@ import (
 	"io"
 	"math"
 	gohttp "net/http"
+	"sync"
 	"time"
 
 	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/metrics"
+	"github.com/shashiranjanraj/kashvi/pkg/reqid"
+	"github.com/shashiranjanraj/kashvi/pkg/signing"
+	"github.com/shashiranjanraj/kashvi/pkg/tracing"
 )
 
 // defaultTransport is the high-performance connection-pooled transport used in
@@ -54,6 +71,86 @@ func ResetTransport() {
 	DefaultClient.Transport = defaultTransport
 }
 
+// ------------------- Observers -------------------
+
+// Observer is notified after every attempt that received a response. ctx is
+// the request's context (see WithCtx), so observers can attribute outgoing
+// calls to the inbound request that triggered them.
+type Observer func(ctx context.Context, method, url string, resp *Response)
+
+var (
+	observersMu sync.RWMutex
+	observers   []Observer
+)
+
+// Observe registers fn to be called after every completed attempt.
+// Observers must be fast and must not modify resp.
+func Observe(fn Observer) {
+	observersMu.Lock()
+	observers = append(observers, fn)
+	observersMu.Unlock()
+}
+
+func notify(ctx context.Context, method, url string, resp *Response) {
+	observersMu.RLock()
+	defer observersMu.RUnlock()
+	for _, fn := range observers {
+		fn(ctx, method, url, resp)
+	}
+}
+
+// ------------------- Fault injection -------------------
+
+var faultInjector func(req *gohttp.Request) error
+
+// SetFaultInjector installs fn to run before every outgoing attempt; a
+// non-nil error fails the attempt as if the network had (so retries and
+// circuit breakers can be exercised). nil removes it. Not for production —
+// see middleware.InstallChaosHooks.
+func SetFaultInjector(fn func(req *gohttp.Request) error) {
+	observersMu.Lock()
+	faultInjector = fn
+	observersMu.Unlock()
+}
+
+func injectFault(req *gohttp.Request) error {
+	observersMu.RLock()
+	fn := faultInjector
+	observersMu.RUnlock()
+	if fn == nil {
+		return nil
+	}
+	return fn(req)
+}
+
+// ------------------- Stubbing -------------------
+
+// Stub answers an outgoing request in place of the network. It returns
+// handled=false to let the request through; a non-nil error fails the
+// attempt like a network error.
+type Stub func(req *gohttp.Request) (resp *Response, handled bool, err error)
+
+var stub Stub
+
+// SetStub installs fn to run before every outgoing attempt; nil removes it.
+// The recorder uses it to answer outgoing calls from a recording while the
+// request is replayed.
+func SetStub(fn Stub) {
+	observersMu.Lock()
+	stub = fn
+	observersMu.Unlock()
+}
+
+func stubbed(req *gohttp.Request) (*Response, bool, error) {
+	observersMu.RLock()
+	fn := stub
+	observersMu.RUnlock()
+	if fn == nil {
+		return nil, false, nil
+	}
+	return fn(req)
+}
+
 // ------------------- Request -------------------
 
 // Request is a fluent HTTP request builder.
@@ -66,6 +163,7 @@ type Request struct {
 	retries   int
 	retryWait time.Duration
 	ctx       context.Context
+	signedBy  string
 }
 
 // Get starts a GET request.
@@ -83,6 +181,45 @@ func Patch(url string) *Request { return newRequest(gohttp.MethodPatch, url) }
 // Delete starts a DELETE request.
 func Delete(url string) *Request { return newRequest(gohttp.MethodDelete, url) }
 
+// Client starts requests that share a parent context.
+type Client struct {
+	ctx context.Context
+}
+
+// WithCtx returns a Client whose requests inherit ctx — its cancellation and
+// deadline apply to every attempt and to the backoff between retries.
+func WithCtx(ctx context.Context) *Client {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Client{ctx: ctx}
+}
+
+// Get starts a GET request bound to the client's context.
+func (c *Client) Get(url string) *Request {
+	return newRequest(gohttp.MethodGet, url).WithContext(c.ctx)
+}
+
+// Post starts a POST request bound to the client's context.
+func (c *Client) Post(url string) *Request {
+	return newRequest(gohttp.MethodPost, url).WithContext(c.ctx)
+}
+
+// Put starts a PUT request bound to the client's context.
+func (c *Client) Put(url string) *Request {
+	return newRequest(gohttp.MethodPut, url).WithContext(c.ctx)
+}
+
+// Patch starts a PATCH request bound to the client's context.
+func (c *Client) Patch(url string) *Request {
+	return newRequest(gohttp.MethodPatch, url).WithContext(c.ctx)
+}
+
+// Delete starts a DELETE request bound to the client's context.
+func (c *Client) Delete(url string) *Request {
+	return newRequest(gohttp.MethodDelete, url).WithContext(c.ctx)
+}
+
 func newRequest(method, url string) *Request {
 	return &Request{
 		method:    method,
@@ -135,6 +272,14 @@ func (r *Request) Retry(n int, wait time.Duration) *Request {
 	return r
 }
 
+// SignedBy signs every attempt as service with its HMAC key (see
+// pkg/signing), for internal calls to routes behind
+// middleware.RequireSignature.
+func (r *Request) SignedBy(service string) *Request {
+	r.signedBy = service
+	return r
+}
+
 // WithContext sets a custom context.
 func (r *Request) WithContext(ctx context.Context) *Request {
 	r.ctx = ctx
@@ -143,7 +288,9 @@ func (r *Request) WithContext(ctx context.Context) *Request {
 
 // ------------------- Send -------------------
 
-// Send executes the request and returns a Response.
+// Send executes the request and returns a Response. When the context ends
+// before the attempts run out, the error says how many were made and wraps
+// the context's error as well as the last attempt's.
 func (r *Request) Send() (*Response, error) {
 	var lastErr error
 
@@ -153,12 +300,21 @@ func (r *Request) Send() (*Response, error) {
 			return resp, nil
 		}
 		lastErr = err
-		if attempt < r.retries {
+		if r.ctx.Err() == nil && attempt < r.retries {
 			// Exponential backoff: wait * 2^(attempt-1)
 			backoff := time.Duration(float64(r.retryWait) * math.Pow(2, float64(attempt-1)))
 			logger.Warn("http: request failed, retrying",
 				"url", r.url, "attempt", attempt, "backoff", backoff, "error", err)
-			time.Sleep(backoff)
+			select {
+			case <-time.After(backoff):
+			case <-r.ctx.Done():
+			}
+		}
+		if r.ctx.Err() != nil && attempt < r.retries {
+			// The caller gave up (client disconnected, deadline hit) — retrying
+			// would only burn time on a result nobody will read.
+			return nil, fmt.Errorf("http: %s %s: gave up after %d of %d attempts: %w (last error: %w)",
+				r.method, r.url, attempt, r.retries, r.ctx.Err(), lastErr)
 		}
 	}
 
@@ -182,27 +338,85 @@ func (r *Request) do() (*Response, error) {
 	for k, v := range r.headers {
 		req.Header.Set(k, v)
 	}
+	// Forward the inbound request's ID so the callee's logs can be joined
+	// with ours; an explicit Header(reqid.Header, …) wins.
+	if id := reqid.FromCtx(r.ctx); id != "" && req.Header.Get(reqid.Header) == "" {
+		req.Header.Set(reqid.Header, id)
+	}
 	if ct != "" {
 		req.Header.Set("Content-Type", ct)
 	}
+	if r.signedBy != "" {
+		// Re-signed per attempt: each retry needs a fresh timestamp and nonce.
+		var raw []byte
+		if body != nil {
+			raw, _ = io.ReadAll(body)
+			req.Body = io.NopCloser(bytes.NewReader(raw))
+			req.ContentLength = int64(len(raw))
+			req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(raw)), nil }
+		}
+		if err := signing.Sign(req, r.signedBy, raw); err != nil {
+			return nil, fmt.Errorf("http: sign: %w", err)
+		}
+	}
+
+	// Every attempt is instrumented (kashvi_http_client_*), labelled by host so
+	// each outgoing dependency shows up separately on dashboards.
+	host := req.URL.Host
+	start := time.Now()
+	metrics.ClientRequestInFlight.WithLabelValues(host).Inc()
+	defer metrics.ClientRequestInFlight.WithLabelValues(host).Dec()
+
+	// With tracing enabled each attempt is a client span, and its
+	// traceparent header continues the trace in the callee.
+	endSpan := func(int, error) {}
+	if tracing.Enabled() {
+		endSpan = tracing.StartClient(ctx, req)
+	}
+
+	if err := injectFault(req); err != nil {
+		metrics.ObserveClientRequest(host, r.method, 0, err, start)
+		endSpan(0, err)
+		return nil, fmt.Errorf("http: send: %w", err)
+	}
+
+	if out, ok, err := stubbed(req); ok || err != nil {
+		status := 0
+		if out != nil {
+			status = out.StatusCode
+		}
+		metrics.ObserveClientRequest(host, r.method, status, err, start)
+		endSpan(status, err)
+		if err != nil {
+			return nil, fmt.Errorf("http: send: %w", err)
+		}
+		notify(r.ctx, r.method, r.url, out)
+		return out, nil
+	}
 
 	resp, err := DefaultClient.Do(req)
 	if err != nil {
+		metrics.ObserveClientRequest(host, r.method, 0, err, start)
+		endSpan(0, err)
 		return nil, fmt.Errorf("http: send: %w", err)
 	}
 
 	raw, err := io.ReadAll(resp.Body)
 	resp.Body.Close()
+	metrics.ObserveClientRequest(host, r.method, resp.StatusCode, err, start)
+	endSpan(resp.StatusCode, err)
 	if err != nil {
 		return nil, fmt.Errorf("http: read body: %w", err)
 	}
 
-	return &Response{
+	out := &Response{
 		StatusCode: resp.StatusCode,
 		Headers:    resp.Header,
 		Raw:        raw,
 		native:     resp,
-	}, nil
+	}
+	notify(r.ctx, r.method, r.url, out)
+	return out, nil
 }
 
 func (r *Request) buildBody() (io.Reader, string, error) {
@@ -0,0 +1,153 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	gohttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	kashvihttp "github.com/shashiranjanraj/kashvi/pkg/http"
+	"github.com/shashiranjanraj/kashvi/pkg/metrics"
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+	"github.com/shashiranjanraj/kashvi/pkg/signing"
+)
+
+func TestSendRecordsClientMetrics(t *testing.T) {
+	srv := httptest.NewServer(gohttp.HandlerFunc(func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(gohttp.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{}`)) //nolint:errcheck
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	ok := metrics.ClientRequestTotal.WithLabelValues(u.Host, "GET", "2xx")
+	notFound := metrics.ClientRequestTotal.WithLabelValues(u.Host, "GET", "4xx")
+	before2xx, before4xx := testutil.ToFloat64(ok), testutil.ToFloat64(notFound)
+
+	if _, err := kashvihttp.Get(srv.URL + "/ok").Send(); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if _, err := kashvihttp.Get(srv.URL + "/missing").Send(); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if got := testutil.ToFloat64(ok) - before2xx; got != 1 {
+		t.Errorf("2xx counter delta = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(notFound) - before4xx; got != 1 {
+		t.Errorf("4xx counter delta = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.ClientRequestInFlight.WithLabelValues(u.Host)); got != 0 {
+		t.Errorf("in-flight gauge = %v after completion, want 0", got)
+	}
+}
+
+func TestSendCountsTransportErrors(t *testing.T) {
+	srv := httptest.NewServer(gohttp.NotFoundHandler())
+	host := srv.Listener.Addr().String()
+	srv.Close() // connection refused from now on
+
+	errs := metrics.ClientRequestTotal.WithLabelValues(host, "POST", "error")
+	before := testutil.ToFloat64(errs)
+
+	if _, err := kashvihttp.Post("http://" + host).Send(); err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+	if got := testutil.ToFloat64(errs) - before; got != 1 {
+		t.Errorf("error counter delta = %v, want 1", got)
+	}
+}
+
+func TestSignedByRoundTrip(t *testing.T) {
+	signing.SetKeyFunc(func(service string) [][]byte {
+		if service == "billing-service" || service == "search" {
+			return [][]byte{[]byte("s3cret")}
+		}
+		return nil
+	})
+	defer signing.SetKeyFunc(nil)
+
+	var caller string
+	h := middleware.RequireSignature("billing-service")(gohttp.HandlerFunc(func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		caller, _ = middleware.ServiceFromCtx(r)
+		w.Write([]byte(`{}`)) //nolint:errcheck
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := kashvihttp.Post(srv.URL + "/internal/charge?id=7").Body(map[string]int{"amount": 42}).SignedBy("billing-service").Send()
+	if err != nil || resp.StatusCode != 200 || caller != "billing-service" {
+		t.Fatalf("signed: status=%v err=%v caller=%q", resp, err, caller)
+	}
+
+	resp, _ = kashvihttp.Post(srv.URL + "/internal/charge").Body("x").Send()
+	if resp.StatusCode != gohttp.StatusUnauthorized {
+		t.Errorf("unsigned: status = %d, want 401", resp.StatusCode)
+	}
+
+	resp, _ = kashvihttp.Post(srv.URL + "/internal/charge").SignedBy("search").Send()
+	if resp.StatusCode != gohttp.StatusForbidden {
+		t.Errorf("other service: status = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestForwardsRequestID(t *testing.T) {
+	var got string
+	upstream := httptest.NewServer(gohttp.HandlerFunc(func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		got = r.Header.Get("X-Request-ID")
+	}))
+	defer upstream.Close()
+
+	api := httptest.NewServer(middleware.RequestID()(gohttp.HandlerFunc(func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		if _, err := kashvihttp.WithCtx(r.Context()).Get(upstream.URL).Send(); err != nil {
+			t.Errorf("Send: %v", err)
+		}
+	})))
+	defer api.Close()
+
+	req, _ := gohttp.NewRequest(gohttp.MethodGet, api.URL, nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	resp, err := gohttp.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got != "req-42" {
+		t.Errorf("upstream X-Request-ID = %q, want req-42", got)
+	}
+	if h := resp.Header.Get("X-Request-ID"); h != "req-42" {
+		t.Errorf("response X-Request-ID = %q, want req-42", h)
+	}
+}
+
+func TestSendReportsAttemptsMade(t *testing.T) {
+	srv := httptest.NewServer(gohttp.NotFoundHandler())
+	host := srv.Listener.Addr().String()
+	srv.Close() // connection refused from now on
+
+	_, err := kashvihttp.Get("http://"+host).Retry(2, time.Millisecond).Send()
+	if err == nil || !strings.Contains(err.Error(), "all 2 attempts failed") {
+		t.Fatalf("err = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = kashvihttp.Get("http://"+host).Retry(5, time.Second).WithContext(ctx).Send()
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "after 1 of 5 attempts") {
+		t.Fatalf("err = %v, want the deadline and the real attempt count", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("Send kept backing off after the context ended")
+	}
+}
@@ -0,0 +1,124 @@
+// Package inbox gives message consumers effectively-once processing: the
+// message ID is recorded in the same transaction as the consumer's writes,
+// so a redelivered message is skipped and a failed one leaves no trace.
+//
+// Use it wherever delivery is at-least-once — Kafka, NATS, SQS, webhooks or
+// queue jobs — and the handler writes to the database:
+//
+//	in := inbox.New(database.DB, "billing.orders")
+//
+//	done, err := in.Process(ctx, msg.ID, func(tx *gorm.DB) error {
+//	    return tx.Create(&Invoice{OrderID: order.ID}).Error
+//	})
+//	if err != nil {
+//	    return err // don't ack; the broker redelivers
+//	}
+//	if !done {
+//	    logger.Debug("duplicate message", "id", msg.ID)
+//	}
+//	msg.Ack()
+//
+// Effects outside the transaction (HTTP calls, e-mails) are not covered; fire
+// them after Process returns true, or make them idempotent themselves.
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/migration"
+)
+
+// Message is a processed message ID, scoped to one consumer.
+type Message struct {
+	Consumer    string    `gorm:"primaryKey;size:191"`
+	ID          string    `gorm:"primaryKey;size:191"`
+	ProcessedAt time.Time `gorm:"not null;index"`
+}
+
+func (Message) TableName() string { return "kashvi_inbox" }
+
+// Inbox records the messages handled by one consumer. Consumers with
+// different names may process the same message ID independently.
+type Inbox struct {
+	db       *gorm.DB
+	consumer string
+}
+
+// New returns the inbox for consumer on db.
+func New(db *gorm.DB, consumer string) *Inbox {
+	return &Inbox{db: db, consumer: consumer}
+}
+
+// Process runs fn in a transaction together with recording id. It reports
+// false without calling fn when id was already processed. If fn fails the
+// transaction rolls back — including the record — so a redelivery is
+// processed again. Transient database errors are retried.
+func (in *Inbox) Process(ctx context.Context, id string, fn func(tx *gorm.DB) error) (bool, error) {
+	if id == "" {
+		return false, errors.New("inbox: empty message id")
+	}
+	fresh := false
+	err := database.WithRetry(ctx, func() error {
+		fresh = false
+		return in.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&Message{Consumer: in.consumer, ID: id, ProcessedAt: time.Now()})
+			if res.Error != nil {
+				return fmt.Errorf("inbox: record %s: %w", id, res.Error)
+			}
+			if res.RowsAffected == 0 {
+				return nil // duplicate
+			}
+			fresh = true
+			return fn(tx)
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+	return fresh, nil
+}
+
+// Seen reports whether id was already processed by this consumer.
+func (in *Inbox) Seen(ctx context.Context, id string) (bool, error) {
+	var n int64
+	err := in.db.WithContext(ctx).Model(&Message{}).
+		Where("consumer = ? AND id = ?", in.consumer, id).Count(&n).Error
+	if err != nil {
+		return false, fmt.Errorf("inbox: seen %s: %w", id, err)
+	}
+	return n > 0, nil
+}
+
+// Prune deletes records older than the broker's redelivery window, for all
+// consumers, and returns how many were removed. Schedule it daily:
+//
+//	schedule.Daily().Run(func() { inbox.Prune(ctx, database.DB, 7*24*time.Hour) })
+func Prune(ctx context.Context, db *gorm.DB, olderThan time.Duration) (int64, error) {
+	res := db.WithContext(ctx).Where("processed_at < ?", time.Now().Add(-olderThan)).Delete(&Message{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("inbox: prune: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+// ─── Migration ────────────────────────────────────────────────────────────────
+
+// Migration creates the kashvi_inbox table. It is registered automatically
+// when pkg/inbox is imported, so `kashvi migrate` picks it up.
+type Migration struct{}
+
+func (Migration) Up(db *gorm.DB) error { return db.AutoMigrate(&Message{}) }
+
+func (Migration) Down(db *gorm.DB) error { return db.Migrator().DropTable(&Message{}) }
+
+func init() {
+	migration.Register("20261018000100_create_kashvi_inbox_table", Migration{})
+}
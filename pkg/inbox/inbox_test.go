@@ -0,0 +1,60 @@
+package inbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/inbox"
+)
+
+type invoice struct {
+	ID      uint `gorm:"primaryKey"`
+	OrderID string
+}
+
+func TestProcess_SkipsDuplicatesAndRollsBackFailures(t *testing.T) {
+	db, err := database.OpenMemory("inbox_process")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (inbox.Migration{}).Up(db); err != nil {
+		t.Fatal(err)
+	}
+	db.AutoMigrate(&invoice{})
+	ctx := context.Background()
+	in := inbox.New(db, "orders")
+	create := func(tx *gorm.DB) error { return tx.Create(&invoice{OrderID: "o1"}).Error }
+
+	if _, err := in.Process(ctx, "m1", func(*gorm.DB) error { return errors.New("boom") }); err == nil {
+		t.Fatal("handler error not returned")
+	}
+	if seen, _ := in.Seen(ctx, "m1"); seen {
+		t.Fatal("failed message was recorded; a redelivery would be lost")
+	}
+
+	for i, want := range []bool{true, false} {
+		done, err := in.Process(ctx, "m1", create)
+		if err != nil || done != want {
+			t.Fatalf("delivery %d: done=%v err=%v, want %v", i+1, done, err, want)
+		}
+	}
+	var n int64
+	db.Model(&invoice{}).Count(&n)
+	if n != 1 {
+		t.Fatalf("%d invoices, want 1", n)
+	}
+
+	// Another consumer handles the same message independently.
+	if done, _ := inbox.New(db, "analytics").Process(ctx, "m1", func(*gorm.DB) error { return nil }); !done {
+		t.Fatal("consumers should not share message IDs")
+	}
+
+	if pruned, err := inbox.Prune(ctx, db, -time.Minute); err != nil || pruned != 2 {
+		t.Fatalf("Prune = %d, %v; want 2", pruned, err)
+	}
+}
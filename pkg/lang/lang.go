@@ -0,0 +1,216 @@
+// Package lang holds translated strings keyed by locale.
+//
+// Translations live in LANG_PATH (default "lang") as one JSON file per
+// locale; nested objects become dotted keys:
+//
+//	// lang/en.json
+//	{"welcome": {"subject": "Welcome, :name!"}}
+//
+//	// lang/fr.json
+//	{"welcome": {"subject": "Bienvenue, :name !"}}
+//
+// Look strings up with the recipient's locale:
+//
+//	lang.Get("fr-CA", "welcome.subject", "name", user.Name) // "Bienvenue, Asha !"
+package lang
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+var (
+	mu       sync.RWMutex
+	messages = map[string]map[string]string{} // locale → key → message
+	fallback string
+
+	loadOnce sync.Once
+)
+
+// ─── Loading ──────────────────────────────────────────────────────────────────
+
+// Load reads every <locale>.json file in dir, merging into what is already
+// loaded. A missing dir is not an error.
+func Load(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("lang: load %s: %w", dir, err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return fmt.Errorf("lang: load %s: %w", e.Name(), err)
+		}
+		var tree map[string]any
+		if err := json.Unmarshal(raw, &tree); err != nil {
+			return fmt.Errorf("lang: parse %s: %w", e.Name(), err)
+		}
+		flat := map[string]string{}
+		flatten("", tree, flat)
+		Add(strings.TrimSuffix(e.Name(), ".json"), flat)
+	}
+	return nil
+}
+
+// Add registers messages for locale, overriding existing keys. Use it for
+// translations embedded in the binary or in tests.
+func Add(locale string, msgs map[string]string) {
+	locale = normalize(locale)
+	mu.Lock()
+	defer mu.Unlock()
+	if messages[locale] == nil {
+		messages[locale] = map[string]string{}
+	}
+	for k, v := range msgs {
+		messages[locale][k] = v
+	}
+}
+
+func flatten(prefix string, tree map[string]any, out map[string]string) {
+	for k, v := range tree {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(k, v, out)
+		case string:
+			out[k] = v
+		default:
+			out[k] = fmt.Sprint(v)
+		}
+	}
+}
+
+// ensureLoaded loads LANG_PATH on first lookup.
+func ensureLoaded() {
+	loadOnce.Do(func() {
+		dir := config.Get("LANG_PATH", "lang")
+		if err := Load(dir); err != nil {
+			logger.Error("lang: load failed", "path", dir, "error", err)
+		}
+	})
+}
+
+// ─── Locales ──────────────────────────────────────────────────────────────────
+
+// Fallback returns the locale used when a key is missing in the requested
+// one: SetFallback's value, else APP_LOCALE (default "en").
+func Fallback() string {
+	mu.RLock()
+	f := fallback
+	mu.RUnlock()
+	if f != "" {
+		return f
+	}
+	return normalize(config.Get("APP_LOCALE", "en"))
+}
+
+// SetFallback overrides the fallback locale.
+func SetFallback(locale string) {
+	mu.Lock()
+	fallback = normalize(locale)
+	mu.Unlock()
+}
+
+// Locales returns the loaded locales, sorted.
+func Locales() []string {
+	ensureLoaded()
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make([]string, 0, len(messages))
+	for l := range messages {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// normalize lower-cases locale and uses "-" as separator: "pt_BR" → "pt-br".
+func normalize(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// candidates returns the locales tried for locale: itself, its base
+// language, then the fallback.
+func candidates(locale string) []string {
+	locale = normalize(locale)
+	var out []string
+	if locale != "" {
+		out = append(out, locale)
+		if base, _, ok := strings.Cut(locale, "-"); ok {
+			out = append(out, base)
+		}
+	}
+	return append(out, Fallback())
+}
+
+// ─── Lookup ───────────────────────────────────────────────────────────────────
+
+// Get returns the message for key in locale, trying "fr-ca", then "fr",
+// then the fallback locale, and finally returning key itself. args are
+// name/value pairs replacing ":name" placeholders.
+func Get(locale, key string, args ...any) string {
+	msg, ok := lookup(locale, key)
+	if !ok {
+		return key
+	}
+	return replace(msg, args)
+}
+
+// Has reports whether key is translated in locale or one of its fallbacks.
+func Has(locale, key string) bool {
+	_, ok := lookup(locale, key)
+	return ok
+}
+
+// For returns a Get bound to locale, handy as a template function.
+func For(locale string) func(key string, args ...any) string {
+	return func(key string, args ...any) string { return Get(locale, key, args...) }
+}
+
+func lookup(locale, key string) (string, bool) {
+	ensureLoaded()
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, l := range candidates(locale) {
+		if msg, ok := messages[l][key]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// replace substitutes ":name" placeholders, longest names first so ":names"
+// is not clobbered by ":name".
+func replace(msg string, args []any) string {
+	if len(args) < 2 {
+		return msg
+	}
+	type pair struct{ name, value string }
+	pairs := make([]pair, 0, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, pair{fmt.Sprint(args[i]), fmt.Sprint(args[i+1])})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return len(pairs[i].name) > len(pairs[j].name) })
+	for _, p := range pairs {
+		msg = strings.ReplaceAll(msg, ":"+p.name, p.value)
+	}
+	return msg
+}
@@ -0,0 +1,37 @@
+package lang_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/lang"
+)
+
+func TestGet_FallsBackAndReplaces(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "en.json"), []byte(`{"greet": {"hello": "Hello, :name", "bye": "Bye"}}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "fr.json"), []byte(`{"greet": {"hello": "Bonjour, :name"}}`), 0o644)
+	if err := lang.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	lang.SetFallback("en")
+
+	cases := []struct{ locale, key, want string }{
+		{"fr", "greet.hello", "Bonjour, Asha"},
+		{"fr_CA", "greet.hello", "Bonjour, Asha"}, // base language
+		{"fr", "greet.bye", "Bye"},                // fallback locale
+		{"de", "greet.hello", "Hello, Asha"},
+		{"fr", "greet.missing", "greet.missing"},
+	}
+	for _, c := range cases {
+		if got := lang.Get(c.locale, c.key, "name", "Asha"); got != c.want {
+			t.Errorf("Get(%q, %q) = %q, want %q", c.locale, c.key, got, c.want)
+		}
+	}
+
+	lang.Add("en", map[string]string{"count": ":n of :names"})
+	if got := lang.Get("en", "count", "n", 2, "names", "files"); got != "2 of files" {
+		t.Errorf("placeholders = %q", got)
+	}
+}
@@ -104,6 +104,16 @@ func (f *levelFilterHandler) WithGroup(name string) slog.Handler {
 	return &levelFilterHandler{inner: f.inner.WithGroup(name), level: f.level}
 }
 
+// Wrap replaces the root handler with fn(current handler), e.g. to add
+// alerting on top of stdout/Mongo. Call it during boot: loggers derived from
+// L earlier keep the previous handler.
+//
+//	logger.Wrap(func(h slog.Handler) slog.Handler { return myHandler{h} })
+func Wrap(fn func(slog.Handler) slog.Handler) {
+	L = slog.New(fn(L.Handler()))
+	slog.SetDefault(L)
+}
+
 // ─────────────────────────────────────────────
 // Context-aware logger
 // ─────────────────────────────────────────────
@@ -7,8 +7,8 @@
 //   - Writes are enqueued into a buffered channel (non-blocking).
 //   - A single background goroutine drains the channel and performs
 //     InsertMany in configurable batch sizes (default 50).
-//   - If the channel is full, the record is silently dropped; logging must
-//     never block application code.
+//   - If the channel is full, the record is dropped (and counted, see
+//     MongoDropped); logging must never block application code.
 //   - Graceful shutdown: call Close() to flush and disconnect.
 package logger
 
@@ -16,11 +16,16 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/health"
 )
 
 const (
@@ -31,12 +36,13 @@ const (
 
 // LogDocument is the shape written to MongoDB.
 type LogDocument struct {
-	Time      time.Time `bson:"time"`
-	Level     string    `bson:"level"`
-	Source    string    `bson:"source,omitempty"`
-	Msg       string    `bson:"msg"`
-	RequestID string    `bson:"request_id,omitempty"`
-	Attrs     bson.M    `bson:"attrs,omitempty"`
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Time      time.Time          `bson:"time"`
+	Level     string             `bson:"level"`
+	Source    string             `bson:"source,omitempty"`
+	Msg       string             `bson:"msg"`
+	RequestID string             `bson:"request_id,omitempty"`
+	Attrs     bson.M             `bson:"attrs,omitempty"`
 }
 
 // MongoHandler is a slog.Handler that writes to MongoDB asynchronously.
@@ -50,6 +56,13 @@ type MongoHandler struct {
 	groups []string
 }
 
+// mongoDropped counts records that never reached MongoDB (queue full or a
+// failed insert). Exposed as kashvi_log_mongo_dropped_total.
+var mongoDropped atomic.Uint64
+
+// MongoDropped returns how many log records were dropped by the MongoHandler.
+func MongoDropped() uint64 { return mongoDropped.Load() }
+
 // NewMongoHandler creates a MongoHandler connected to uri/db/collection.
 // The caller must eventually call Close().
 func NewMongoHandler(uri, db, collection string) (*MongoHandler, error) {
@@ -73,11 +86,10 @@ func NewMongoHandler(uri, db, collection string) (*MongoHandler, error) {
 
 	col := client.Database(db).Collection(collection)
 
-	// Create time-based index for easy log querying / TTL.
-	_, _ = col.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.D{{Key: "time", Value: -1}},
-		Options: options.Index().SetBackground(true),
-	})
+	if err := EnsureMongoLogCollection(ctx, col, config.LogRetentionDays(), config.MongoLogCappedMB()); err != nil {
+		// Indexes are an optimisation — keep shipping logs without them.
+		slog.Warn("mongo_handler: index setup failed", "error", err)
+	}
 
 	h := &MongoHandler{
 		col:    col,
@@ -87,6 +99,7 @@ func NewMongoHandler(uri, db, collection string) (*MongoHandler, error) {
 	}
 
 	go h.drainLoop()
+	health.Register(mongoCheckName, h.check(health.NewSustained(mongoFullGrace)))
 	return h, nil
 }
 
@@ -129,7 +142,8 @@ func (h *MongoHandler) Handle(_ context.Context, r slog.Record) error {
 	select {
 	case h.queue <- doc:
 	default:
-		// silently dropped — logging must never block
+		// dropped (and counted) — logging must never block
+		mongoDropped.Add(1)
 	}
 	return nil
 }
@@ -177,7 +191,10 @@ func (h *MongoHandler) drainLoop() {
 		}
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		_, _ = h.col.InsertMany(ctx, batch) // errors are intentionally ignored
+		if _, err := h.col.InsertMany(ctx, batch); err != nil {
+			// Never log from here (it would loop back into this handler).
+			mongoDropped.Add(uint64(len(batch)))
+		}
 		batch = batch[:0]
 	}
 
@@ -201,9 +218,24 @@ func (h *MongoHandler) drainLoop() {
 	}
 }
 
+// mongoCheckName is the readiness check registered by NewMongoHandler; it
+// fails once the queue has stayed full for mongoFullGrace, i.e. MongoDB
+// cannot keep up and records are being dropped.
+const (
+	mongoCheckName = "log:mongo"
+	mongoFullGrace = 30 * time.Second
+)
+
+func (h *MongoHandler) check(full *health.Sustained) health.Check {
+	return func(context.Context) error {
+		return full.Observe(len(h.queue) == cap(h.queue), "log queue full")
+	}
+}
+
 // Close flushes pending logs and disconnects from MongoDB.
 // Safe to call multiple times.
 func (h *MongoHandler) Close() {
+	health.Unregister(mongoCheckName)
 	select {
 	case <-h.done:
 	default:
@@ -0,0 +1,181 @@
+// Package logger — mongo_indexes.go
+//
+// Collection setup for MongoDB log shipping: optional capped collection, TTL
+// retention and the indexes `kashvi log:tail` filters on.
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoIndexOptionsConflict is returned when an index exists with other options.
+const mongoIndexOptionsConflict = 85
+
+// EnsureMongoLogCollection prepares col for log storage:
+//
+//   - cappedMB > 0 creates it as a capped collection of that size (only when
+//     it does not exist yet — an existing collection is never converted);
+//   - retentionDays > 0 adds a TTL on "time" (ignored for capped collections,
+//     which MongoDB does not allow TTL indexes on — size bounds them instead);
+//   - indexes on {level, time} and {request_id, time} back the log:tail filters.
+//
+// It is idempotent and safe to run on every start. Each index is set up on
+// its own: one that fails does not stop the others, and the failures are
+// returned joined.
+func EnsureMongoLogCollection(ctx context.Context, col *mongo.Collection, retentionDays, cappedMB int) error {
+	capped, err := ensureCapped(ctx, col, cappedMB)
+	if err != nil {
+		return err
+	}
+	var ttl int64
+	if retentionDays > 0 && !capped {
+		ttl = int64(retentionDays) * 24 * 60 * 60
+	}
+	return ensureLogIndexes(ctx, collectionIndexes{col}, ttl)
+}
+
+// indexOps is the part of the MongoDB index API ensureLogIndexes uses.
+type indexOps interface {
+	create(ctx context.Context, m mongo.IndexModel) error
+	drop(ctx context.Context, name string) error
+	setTTL(ctx context.Context, name string, seconds int64) error
+}
+
+const timeIndex = "time_-1"
+
+// ensureLogIndexes creates the log indexes, with a TTL of ttl seconds on
+// "time" when ttl > 0. When the time index exists with other options, the
+// TTL is changed in place, or the index is dropped and recreated when the
+// TTL must go. Other conflicting indexes were changed on purpose and are
+// left alone.
+func ensureLogIndexes(ctx context.Context, ops indexOps, ttl int64) error {
+	var errs []error
+
+	if ttl > math.MaxInt32 {
+		// MongoDB stores expireAfterSeconds as a 32-bit int; about 68 years
+		// is as long as a TTL can be.
+		slog.Warn("logger: log retention capped at the MongoDB TTL maximum", "seconds", ttl, "max", math.MaxInt32)
+		ttl = math.MaxInt32
+	}
+
+	timeIdx := options.Index().SetName(timeIndex)
+	if ttl > 0 {
+		timeIdx.SetExpireAfterSeconds(int32(ttl))
+	}
+	model := mongo.IndexModel{Keys: bson.D{{Key: "time", Value: -1}}, Options: timeIdx}
+	err := ops.create(ctx, model)
+	if isIndexConflict(err) {
+		if ttl > 0 {
+			// The index predates the TTL setting — adjust it in place.
+			err = ops.setTTL(ctx, timeIndex, ttl)
+		} else if err = ops.drop(ctx, timeIndex); err == nil {
+			// Retention was turned off; collMod cannot remove a TTL.
+			err = ops.create(ctx, model)
+		}
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("logger: time index: %w", err))
+	}
+
+	for _, m := range []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "level", Value: 1}, {Key: "time", Value: -1}},
+			Options: options.Index().SetName("level_1_time_-1"),
+		},
+		{
+			Keys: bson.D{{Key: "request_id", Value: 1}, {Key: "time", Value: -1}},
+			Options: options.Index().SetName("request_id_1_time_-1").
+				SetPartialFilterExpression(bson.M{"request_id": bson.M{"$exists": true}}),
+		},
+	} {
+		switch err := ops.create(ctx, m); {
+		case isIndexConflict(err):
+			slog.Warn("logger: index exists with other options, leaving it as is", "index", *m.Options.Name)
+		case err != nil:
+			errs = append(errs, fmt.Errorf("logger: index %s: %w", *m.Options.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isIndexConflict(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == mongoIndexOptionsConflict
+}
+
+// collectionIndexes implements indexOps on a collection.
+type collectionIndexes struct{ col *mongo.Collection }
+
+func (c collectionIndexes) create(ctx context.Context, m mongo.IndexModel) error {
+	_, err := c.col.Indexes().CreateOne(ctx, m)
+	return err
+}
+
+func (c collectionIndexes) drop(ctx context.Context, name string) error {
+	_, err := c.col.Indexes().DropOne(ctx, name)
+	return err
+}
+
+func (c collectionIndexes) setTTL(ctx context.Context, name string, seconds int64) error {
+	return c.col.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: c.col.Name()},
+		{Key: "index", Value: bson.D{
+			{Key: "name", Value: name},
+			{Key: "expireAfterSeconds", Value: seconds},
+		}},
+	}).Err()
+}
+
+// ensureCapped creates col as a capped collection when requested and reports
+// whether the collection is capped.
+func ensureCapped(ctx context.Context, col *mongo.Collection, cappedMB int) (bool, error) {
+	db := col.Database()
+	specs, err := db.ListCollectionSpecifications(ctx, bson.M{"name": col.Name()})
+	if err != nil {
+		return false, fmt.Errorf("logger: inspect collection: %w", err)
+	}
+	if len(specs) > 0 {
+		var opts struct {
+			Capped bool `bson:"capped"`
+		}
+		_ = bson.Unmarshal(specs[0].Options, &opts)
+		return opts.Capped, nil
+	}
+	if cappedMB <= 0 {
+		return false, nil
+	}
+	err = db.CreateCollection(ctx, col.Name(), options.CreateCollection().
+		SetCapped(true).
+		SetSizeInBytes(int64(cappedMB)*1024*1024))
+	if err != nil {
+		return false, fmt.Errorf("logger: create capped collection: %w", err)
+	}
+	return true, nil
+}
+
+// Prune deletes records older than before and returns how many were removed.
+// With dryRun it only counts them.
+func (r *LogReader) Prune(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	filter := bson.M{"time": bson.M{"$lt": before}}
+	if dryRun {
+		n, err := r.col.CountDocuments(ctx, filter)
+		if err != nil {
+			return 0, fmt.Errorf("logger: count logs: %w", err)
+		}
+		return n, nil
+	}
+	res, err := r.col.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("logger: prune logs: %w", err)
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeIndexes records calls and fails create for the names in conflict
+// until they are dropped.
+type fakeIndexes struct {
+	conflict map[string]bool
+	calls    []string
+	ttls     []int64 // expireAfterSeconds passed to create and setTTL
+}
+
+func (f *fakeIndexes) create(_ context.Context, m mongo.IndexModel) error {
+	name := *m.Options.Name
+	f.calls = append(f.calls, "create "+name)
+	if s := m.Options.ExpireAfterSeconds; s != nil {
+		f.ttls = append(f.ttls, int64(*s))
+	}
+	if f.conflict[name] {
+		return mongo.CommandError{Code: mongoIndexOptionsConflict, Message: "IndexOptionsConflict"}
+	}
+	return nil
+}
+
+func (f *fakeIndexes) drop(_ context.Context, name string) error {
+	f.calls = append(f.calls, "drop "+name)
+	delete(f.conflict, name)
+	return nil
+}
+
+func (f *fakeIndexes) setTTL(_ context.Context, name string, seconds int64) error {
+	f.calls = append(f.calls, "collMod "+name)
+	f.ttls = append(f.ttls, seconds)
+	return nil
+}
+
+func TestEnsureLogIndexesResolvesConflicts(t *testing.T) {
+	cases := []struct {
+		name string
+		ttl  int64
+		want string
+	}{
+		{"TTL added to an existing index", 86400, "create time_-1,collMod time_-1"},
+		{"TTL removed", 0, "create time_-1,drop time_-1,create time_-1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f := &fakeIndexes{conflict: map[string]bool{"time_-1": true, "level_1_time_-1": true}}
+			if err := ensureLogIndexes(context.Background(), f, c.ttl); err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Join(f.calls, ",")
+			if !strings.HasPrefix(got, c.want+",") {
+				t.Fatalf("calls = %s, want %s first", got, c.want)
+			}
+			// A conflicting secondary index does not stop the next one.
+			if !strings.HasSuffix(got, "create level_1_time_-1,create request_id_1_time_-1") {
+				t.Fatalf("calls = %s", got)
+			}
+		})
+	}
+}
+
+func TestEnsureLogIndexesCapsTTL(t *testing.T) {
+	ttl := int64(100*365) * 24 * 60 * 60 // LOG_RETENTION_DAYS=36500
+	for _, conflict := range []bool{false, true} {
+		f := &fakeIndexes{conflict: map[string]bool{"time_-1": conflict}}
+		if err := ensureLogIndexes(context.Background(), f, ttl); err != nil {
+			t.Fatal(err)
+		}
+		for _, got := range f.ttls {
+			if got != math.MaxInt32 {
+				t.Fatalf("conflict=%v: TTL = %d, want %d", conflict, got, math.MaxInt32)
+			}
+		}
+		if len(f.ttls) == 0 {
+			t.Fatalf("conflict=%v: no TTL set", conflict)
+		}
+	}
+}
+
+type failingIndexes struct{ fakeIndexes }
+
+func (f *failingIndexes) create(ctx context.Context, m mongo.IndexModel) error {
+	f.fakeIndexes.create(ctx, m) //nolint:errcheck
+	if *m.Options.Name == "time_-1" {
+		return errors.New("not authorized")
+	}
+	return nil
+}
+
+func TestEnsureLogIndexesContinuesAfterFailure(t *testing.T) {
+	f := &failingIndexes{}
+	err := ensureLogIndexes(context.Background(), f, 0)
+	if err == nil || !strings.Contains(err.Error(), "time index: not authorized") {
+		t.Fatalf("err = %v", err)
+	}
+	if len(f.calls) != 3 {
+		t.Fatalf("calls = %v, want every index attempted", f.calls)
+	}
+}
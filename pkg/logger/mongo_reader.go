@@ -0,0 +1,280 @@
+// Package logger — mongo_reader.go
+//
+// Read-side of the MongoDB log shipping: query and follow the records written
+// by MongoHandler (used by `kashvi log:tail`).
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LogFilter selects log documents.
+type LogFilter struct {
+	MinLevel  string    // "debug" | "info" | "warn" | "error" | "fatal" | "ERROR+2" … ("" = all)
+	RequestID string    // exact request_id
+	Since     time.Time // records at or after this time
+	Contains  string    // case-insensitive substring of msg
+	Limit     int       // maximum backlog returned by Query (0 = 100)
+}
+
+// ParseLevel parses a --level value: a slog level name with an optional
+// offset ("warn", "ERROR+2"), "warning", or "fatal"/"critical" for
+// ERROR+4, the level alerts page on.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "warning":
+		return slog.LevelWarn, nil
+	case "fatal", "critical":
+		return slog.LevelError + 4, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("logger: unknown level %q", s)
+	}
+	return l, nil
+}
+
+// levelQuery returns the $or clauses matching the level names MongoHandler stores (slog's
+// Level.String: "INFO", "WARN+2", "ERROR+4" …) at min or above. Every name
+// below ERROR+10^n is listed; the unbounded tail of larger ERROR offsets is
+// matched by a regex.
+func levelQuery(field string, min slog.Level) []bson.M {
+	digits := 1
+	if off := min - slog.LevelError; off > 0 {
+		digits = len(strconv.Itoa(int(off)))
+	}
+	top := slog.LevelError
+	for range digits {
+		top = slog.LevelError + (top-slog.LevelError+1)*10 - 1
+	}
+	var names []string
+	for l := min; l <= top; l++ {
+		names = append(names, l.String())
+	}
+	return []bson.M{
+		{field: bson.M{"$in": names}},
+		{field: bson.M{"$regex": primitive.Regex{Pattern: fmt.Sprintf(`^ERROR\+[1-9][0-9]{%d,}$`, digits)}}},
+	}
+}
+
+// bson builds the MongoDB query for f. An unparseable MinLevel is ignored;
+// callers validate it with ParseLevel first.
+func (f LogFilter) bson() bson.M { return f.match("") }
+
+// match builds the query for fields under prefix ("fullDocument." for change
+// stream events).
+func (f LogFilter) match(prefix string) bson.M {
+	q := bson.M{}
+	if f.MinLevel != "" {
+		if min, err := ParseLevel(f.MinLevel); err == nil {
+			q["$or"] = levelQuery(prefix+"level", min)
+		}
+	}
+	if f.RequestID != "" {
+		q[prefix+"request_id"] = f.RequestID
+	}
+	if !f.Since.IsZero() {
+		q[prefix+"time"] = bson.M{"$gte": f.Since}
+	}
+	if f.Contains != "" {
+		q[prefix+"msg"] = bson.M{"$regex": primitive.Regex{Pattern: regexp.QuoteMeta(f.Contains), Options: "i"}}
+	}
+	return q
+}
+
+// LogReader reads records from the MongoDB log collection.
+type LogReader struct {
+	client *mongo.Client
+	col    *mongo.Collection
+}
+
+// NewLogReader connects to the collection MongoHandler writes to.
+// The caller must call Close.
+func NewLogReader(ctx context.Context, uri, db, collection string) (*LogReader, error) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).
+		SetConnectTimeout(5*time.Second).
+		SetServerSelectionTimeout(5*time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("logger: mongo connect: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("logger: mongo ping: %w", err)
+	}
+	return &LogReader{client: client, col: client.Database(db).Collection(collection)}, nil
+}
+
+// Close disconnects from MongoDB.
+func (r *LogReader) Close() error {
+	return r.client.Disconnect(context.Background())
+}
+
+// Query returns the most recent f.Limit matching records, oldest first.
+func (r *LogReader) Query(ctx context.Context, f LogFilter) ([]LogDocument, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	cur, err := r.col.Find(ctx, f.bson(), options.Find().
+		SetSort(bson.D{{Key: "time", Value: -1}}).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, fmt.Errorf("logger: query logs: %w", err)
+	}
+	var docs []LogDocument
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("logger: decode logs: %w", err)
+	}
+	// Reverse into chronological order.
+	for i, j := 0, len(docs)-1; i < j; i, j = i+1, j-1 {
+		docs[i], docs[j] = docs[j], docs[i]
+	}
+	return docs, nil
+}
+
+// Follow calls fn for every matching record newer than the backlog the
+// caller already has (typically what Query returned; nil starts from now),
+// until ctx is cancelled. It uses a change stream when the server supports
+// one (replica sets) and falls back to polling every interval otherwise.
+func (r *LogReader) Follow(ctx context.Context, f LogFilter, backlog []LogDocument, interval time.Duration, fn func(LogDocument)) error {
+	c := newFollowCursor(backlog)
+	err := r.followStream(ctx, f, c, fn)
+	if err == nil || ctx.Err() != nil {
+		return nil
+	}
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) {
+		return err
+	}
+	// Standalone servers reject $changeStream — poll instead.
+	return r.followPoll(ctx, f, c, interval, fn)
+}
+
+// followCursor remembers the newest record delivered so far, so following
+// resumes where the backlog stopped: nothing inserted in between is lost and
+// nothing already printed is printed again.
+type followCursor struct {
+	last time.Time
+	seen map[primitive.ObjectID]bool // ids delivered at time == last
+}
+
+func newFollowCursor(backlog []LogDocument) *followCursor {
+	c := &followCursor{last: time.Now(), seen: map[primitive.ObjectID]bool{}}
+	if len(backlog) > 0 {
+		c.last = backlog[0].Time
+		for _, d := range backlog {
+			c.mark(d)
+		}
+	}
+	return c
+}
+
+// mark records d as delivered.
+func (c *followCursor) mark(d LogDocument) {
+	if d.Time.After(c.last) {
+		c.last = d.Time
+		c.seen = map[primitive.ObjectID]bool{}
+	}
+	if !d.Time.Before(c.last) {
+		c.seen[d.ID] = true
+	}
+}
+
+// deliver calls fn with the records of docs (sorted by time) that have not
+// been delivered yet.
+func (c *followCursor) deliver(docs []LogDocument, fn func(LogDocument)) {
+	for _, d := range docs {
+		if d.Time.Before(c.last) || c.seen[d.ID] {
+			continue
+		}
+		c.mark(d)
+		fn(d)
+	}
+}
+
+// since returns the records matching f from the cursor on, oldest first.
+func (r *LogReader) since(ctx context.Context, f LogFilter, c *followCursor) ([]LogDocument, error) {
+	q := f
+	q.Since = c.last
+	cur, err := r.col.Find(ctx, q.bson(), options.Find().SetSort(bson.D{{Key: "time", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("logger: poll logs: %w", err)
+	}
+	var docs []LogDocument
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("logger: decode logs: %w", err)
+	}
+	return docs, nil
+}
+
+func (r *LogReader) followStream(ctx context.Context, f LogFilter, c *followCursor, fn func(LogDocument)) error {
+	match := bson.M{"operationType": "insert"}
+	g := f
+	g.Since = time.Time{} // only new inserts are delivered anyway
+	for k, v := range g.match("fullDocument.") {
+		match[k] = v
+	}
+	stream, err := r.col.Watch(ctx, mongo.Pipeline{{{Key: "$match", Value: match}}})
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	// Catch up on what was inserted between the backlog and the stream
+	// opening. The stream may deliver some of these again.
+	docs, err := r.since(ctx, f, c)
+	if err != nil {
+		return err
+	}
+	caughtUp := map[primitive.ObjectID]bool{}
+	c.deliver(docs, func(d LogDocument) {
+		caughtUp[d.ID] = true
+		fn(d)
+	})
+
+	for stream.Next(ctx) {
+		var ev struct {
+			FullDocument LogDocument `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&ev); err == nil && !caughtUp[ev.FullDocument.ID] {
+			fn(ev.FullDocument)
+		}
+	}
+	return stream.Err()
+}
+
+func (r *LogReader) followPoll(ctx context.Context, f LogFilter, c *followCursor, interval time.Duration, fn func(LogDocument)) error {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		docs, err := r.since(ctx, f, c)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		c.deliver(docs, fn)
+	}
+}
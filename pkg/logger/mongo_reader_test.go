@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLogFilterBSON(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := LogFilter{MinLevel: "warning", RequestID: "r1", Since: since, Contains: "a.b"}.bson()
+
+	if _, ok := q["$or"]; !ok {
+		t.Errorf("no level clause in %v", q)
+	}
+	if q["request_id"] != "r1" {
+		t.Errorf("request_id = %v", q["request_id"])
+	}
+	if q["time"].(bson.M)["$gte"] != since {
+		t.Errorf("time = %v", q["time"])
+	}
+	if re := q["msg"].(bson.M)["$regex"].(primitive.Regex); re.Pattern != `a\.b` || re.Options != "i" {
+		t.Errorf("msg regex = %+v", re)
+	}
+
+	if len(LogFilter{}.bson()) != 0 {
+		t.Error("empty filter should match everything")
+	}
+}
+
+// matchesLevel evaluates levelQuery's clauses against a stored level name.
+func matchesLevel(clauses []bson.M, name string) bool {
+	for _, c := range clauses {
+		cond := c["level"].(bson.M)
+		if in, ok := cond["$in"].([]string); ok && slices.Contains(in, name) {
+			return true
+		}
+		if re, ok := cond["$regex"].(primitive.Regex); ok && regexp.MustCompile(re.Pattern).MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLevelQuery(t *testing.T) {
+	cases := []struct {
+		min  string
+		name string
+		want bool
+	}{
+		{"warning", "WARN", true},
+		{"warn", "INFO+2", false},
+		{"warn", "WARN+2", true},
+		{"error", "ERROR", true},
+		{"error", "ERROR+4", true}, // notification.LevelFatal
+		{"error", "ERROR+123", true},
+		{"fatal", "ERROR+3", false},
+		{"fatal", "ERROR+4", true},
+		{"fatal", "ERROR+12", true},
+		{"ERROR+15", "ERROR+12", false},
+		{"ERROR+15", "ERROR+15", true},
+		{"ERROR+15", "ERROR+100", true},
+		{"debug", "DEBUG-2", false},
+		{"debug", "DEBUG+2", true},
+	}
+	for _, c := range cases {
+		min, err := ParseLevel(c.min)
+		if err != nil {
+			t.Fatalf("ParseLevel(%q): %v", c.min, err)
+		}
+		if got := matchesLevel(levelQuery("level", min), c.name); got != c.want {
+			t.Errorf("--level=%s matches %s = %v, want %v", c.min, c.name, got, c.want)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("ParseLevel accepted an unknown level")
+	}
+	if min, _ := ParseLevel("critical"); min != slog.LevelError+4 {
+		t.Errorf("critical = %v, want ERROR+4", min)
+	}
+}
+
+func TestFollowCursorResumesAfterBacklog(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	doc := func(sec int) LogDocument {
+		return LogDocument{ID: primitive.NewObjectID(), Time: t0.Add(time.Duration(sec) * time.Second)}
+	}
+	a, b, c := doc(1), doc(2), doc(2)
+	cur := newFollowCursor([]LogDocument{a, b})
+	if !cur.last.Equal(b.Time) {
+		t.Fatalf("cursor starts at %v, want the newest printed record %v", cur.last, b.Time)
+	}
+
+	// The poll returns everything at or after the cursor: b again, c logged
+	// at the same time as b, and d after it.
+	d := doc(3)
+	var got []LogDocument
+	cur.deliver([]LogDocument{b, c, d}, func(x LogDocument) { got = append(got, x) })
+	if len(got) != 2 || got[0].ID != c.ID || got[1].ID != d.ID {
+		t.Fatalf("delivered %v, want c and d once", got)
+	}
+
+	got = nil
+	cur.deliver([]LogDocument{d}, func(x LogDocument) { got = append(got, x) })
+	if len(got) != 0 {
+		t.Fatalf("d delivered twice")
+	}
+
+	if empty := newFollowCursor(nil); time.Since(empty.last) > time.Minute {
+		t.Errorf("without a backlog the cursor should start now, got %v", empty.last)
+	}
+}
@@ -0,0 +1,178 @@
+package mail
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// ------------------- Drivers -------------------
+
+// Driver names for MAIL_DRIVER.
+const (
+	DriverSMTP  = "smtp"  // deliver through MAIL_HOST (default)
+	DriverLog   = "log"   // render to MAIL_PREVIEW_DIR and log a line
+	DriverArray = "array" // keep in memory; read with Sent (tests)
+)
+
+var (
+	driverMu       sync.RWMutex
+	driverOverride string
+)
+
+// SetDriver overrides MAIL_DRIVER for this process; "" restores the
+// configured driver. Tests use it to capture mail with DriverArray:
+//
+//	mail.SetDriver(mail.DriverArray)
+//	defer mail.SetDriver("")
+func SetDriver(name string) {
+	driverMu.Lock()
+	driverOverride = name
+	driverMu.Unlock()
+}
+
+func currentDriver() string {
+	driverMu.RLock()
+	defer driverMu.RUnlock()
+	if driverOverride != "" {
+		return driverOverride
+	}
+	return config.Get("MAIL_DRIVER", DriverSMTP)
+}
+
+// SentMessage is a message captured by the array driver.
+type SentMessage struct {
+	From    string
+	To      []string
+	CC      []string
+	BCC     []string
+	ReplyTo []string
+	Subject string
+	Body    string
+	HTML    bool
+	Headers map[string]string
+	Raw     []byte // the RFC 5322 message SMTP would have sent
+}
+
+var (
+	sentMu sync.Mutex
+	sent   []SentMessage
+)
+
+// Sent returns the messages captured by the array driver, oldest first.
+func Sent() []SentMessage {
+	sentMu.Lock()
+	defer sentMu.Unlock()
+	return append([]SentMessage(nil), sent...)
+}
+
+// ResetSent forgets the captured messages.
+func ResetSent() {
+	sentMu.Lock()
+	sent = nil
+	sentMu.Unlock()
+}
+
+func (m *Message) record(from string) {
+	headers := map[string]string{}
+	for _, h := range m.allHeaders() {
+		headers[h.name] = h.value
+	}
+	sentMu.Lock()
+	sent = append(sent, SentMessage{
+		From: from, To: m.to, CC: m.cc, BCC: m.bcc, ReplyTo: m.replyTo,
+		Subject: m.subject, Body: m.body, HTML: m.isHTML,
+		Headers: headers, Raw: m.buildRaw(from),
+	})
+	sentMu.Unlock()
+}
+
+// ------------------- Previews -------------------
+
+// PreviewDir is where the log driver writes previews: MAIL_PREVIEW_DIR,
+// default storage/mail-previews.
+func PreviewDir() string {
+	return config.Get("MAIL_PREVIEW_DIR", filepath.Join("storage", "mail-previews"))
+}
+
+func (m *Message) sendLog(from string) error {
+	path, err := m.writePreview(from)
+	if err != nil {
+		return err
+	}
+	logger.Info("mail: preview written", "to", strings.Join(m.to, ","), "subject", m.subject, "path", path)
+	return nil
+}
+
+var slugRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// writePreview renders the message as a standalone HTML page. The body is
+// shown in a sandboxed iframe so its styles cannot leak into the header.
+func (m *Message) writePreview(from string) (string, error) {
+	dir := PreviewDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("mail: preview dir: %w", err)
+	}
+	slug := strings.Trim(slugRe.ReplaceAllString(strings.ToLower(m.subject), "-"), "-")
+	if len(slug) > 40 {
+		slug = slug[:40]
+	}
+	name := time.Now().Format("20060102-150405.000000") + "-" + slug + ".html"
+	path := filepath.Join(dir, name)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("mail: preview: %w", err)
+	}
+	defer f.Close()
+
+	body := m.body
+	if !m.isHTML {
+		body = "<pre style=\"white-space:pre-wrap\">" + template.HTMLEscapeString(body) + "</pre>"
+	}
+	var hs []struct{ Name, Value string }
+	for _, h := range m.allHeaders() {
+		hs = append(hs, struct{ Name, Value string }{h.name, h.value})
+	}
+	var atts []string
+	for _, a := range m.attachments {
+		atts = append(atts, fmt.Sprintf("%s (%d bytes)", a.name, len(a.content)))
+	}
+	err = previewTmpl.Execute(f, map[string]any{
+		"From": from, "To": m.to, "CC": m.cc, "BCC": m.bcc, "ReplyTo": m.replyTo,
+		"Subject": m.subject, "Headers": hs, "Attachments": atts,
+		"Body": body, "Sent": time.Now().Format(time.RFC1123),
+	})
+	if err != nil {
+		return "", fmt.Errorf("mail: preview: %w", err)
+	}
+	return path, nil
+}
+
+var previewTmpl = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Subject}}</title>
+<style>body{font:14px system-ui,sans-serif;margin:0}table{border-collapse:collapse;margin:12px}
+th{text-align:right;color:#666;padding:2px 8px;vertical-align:top}td{padding:2px 8px}
+iframe{border:0;border-top:1px solid #ddd;width:100%;height:80vh}</style></head>
+<body><table>
+<tr><th>Subject</th><td><b>{{.Subject}}</b></td></tr>
+<tr><th>From</th><td>{{.From}}</td></tr>
+<tr><th>To</th><td>{{range $i, $a := .To}}{{if $i}}, {{end}}{{$a}}{{end}}</td></tr>
+{{if .CC}}<tr><th>Cc</th><td>{{range $i, $a := .CC}}{{if $i}}, {{end}}{{$a}}{{end}}</td></tr>{{end}}
+{{if .BCC}}<tr><th>Bcc</th><td>{{range $i, $a := .BCC}}{{if $i}}, {{end}}{{$a}}{{end}}</td></tr>{{end}}
+{{if .ReplyTo}}<tr><th>Reply-To</th><td>{{range $i, $a := .ReplyTo}}{{if $i}}, {{end}}{{$a}}{{end}}</td></tr>{{end}}
+{{range .Headers}}<tr><th>{{.Name}}</th><td>{{.Value}}</td></tr>{{end}}
+{{if .Attachments}}<tr><th>Attachments</th><td>{{range .Attachments}}{{.}}<br>{{end}}</td></tr>{{end}}
+<tr><th>Sent</th><td>{{.Sent}}</td></tr>
+</table>
+<iframe sandbox srcdoc="{{.Body}}"></iframe>
+</body></html>
+`))
@@ -12,6 +12,14 @@
 //	    Subject("Invoice").
 //	    Template("invoice.html", data).
 //	    Send()
+//
+//	// Bulk mail: reply-to, low priority and a signed one-click unsubscribe
+//	mail.To(user.Email).
+//	    Subject("Weekly digest").
+//	    ReplyTo("support@example.com").
+//	    Priority(mail.PriorityLow).
+//	    Unsubscribe("digest").
+//	    Send()
 package mail
 
 import (
@@ -55,6 +63,10 @@ type Message struct {
 	to          []string
 	cc          []string
 	bcc         []string
+	replyTo     []string
+	headers     []header
+	priority    Priority
+	unsubscribe string // List-Unsubscribe URL; see Unsubscribe
 	subject     string
 	body        string
 	isHTML      bool
@@ -62,6 +74,45 @@ type Message struct {
 	smtpCfg     SMTP
 }
 
+type header struct{ name, value string }
+
+// Priority is the importance mail clients show for a message.
+type Priority int
+
+// Priorities, written as X-Priority, Importance and Priority headers.
+const (
+	PriorityNormal Priority = iota
+	PriorityHigh
+	PriorityLow
+)
+
+func (p Priority) headers() []header {
+	switch p {
+	case PriorityHigh:
+		return []header{{"X-Priority", "1 (Highest)"}, {"Importance", "high"}, {"Priority", "urgent"}}
+	case PriorityLow:
+		return []header{{"X-Priority", "5 (Lowest)"}, {"Importance", "low"}, {"Priority", "non-urgent"}}
+	}
+	return nil
+}
+
+// allHeaders returns the priority, List-Unsubscribe and custom headers in
+// the order they are written.
+func (m *Message) allHeaders() []header {
+	hs := m.priority.headers()
+	if m.unsubscribe != "" {
+		// RFC 8058 one-click: providers POST to the URL without a browser.
+		hs = append(hs,
+			header{"List-Unsubscribe", "<" + m.unsubscribe + ">"},
+			header{"List-Unsubscribe-Post", "List-Unsubscribe=One-Click"})
+	}
+	return append(hs, m.headers...)
+}
+
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
 type attachment struct {
 	name    string
 	content []byte
@@ -88,6 +139,25 @@ func (m *Message) BCC(addresses ...string) *Message {
 	return m
 }
 
+// ReplyTo sets where replies go when it should not be the From address.
+func (m *Message) ReplyTo(addresses ...string) *Message {
+	m.replyTo = append(m.replyTo, addresses...)
+	return m
+}
+
+// Header adds a custom header such as "X-Campaign-ID". Line breaks are
+// stripped from name and value, so user input cannot inject headers.
+func (m *Message) Header(name, value string) *Message {
+	m.headers = append(m.headers, header{name: oneLine(name), value: oneLine(value)})
+	return m
+}
+
+// Priority marks the message high or low priority for mail clients.
+func (m *Message) Priority(p Priority) *Message {
+	m.priority = p
+	return m
+}
+
 // Subject sets the email subject.
 func (m *Message) Subject(s string) *Message {
 	m.subject = s
@@ -140,14 +210,22 @@ func (m *Message) UseConfig(cfg SMTP) *Message {
 
 // ------------------- Sending -------------------
 
-// Send delivers the email via SMTP.
+// Send delivers the email through the configured driver (see driver.go):
+// SMTP by default, or a preview file / in-memory record in development.
 func (m *Message) Send() error {
 	cfg := m.smtpCfg
+	from := fmt.Sprintf("%s <%s>", cfg.FromName, cfg.From)
+	switch currentDriver() {
+	case DriverLog:
+		return m.sendLog(from)
+	case DriverArray:
+		m.record(from)
+		return nil
+	}
+
 	if cfg.Username == "" {
 		return fmt.Errorf("mail: MAIL_USERNAME not configured")
 	}
-
-	from := fmt.Sprintf("%s <%s>", cfg.FromName, cfg.From)
 	allTo := append(m.to, append(m.cc, m.bcc...)...)
 
 	raw := m.buildRaw(from)
@@ -206,7 +284,13 @@ func (m *Message) buildRaw(from string) []byte {
 	if len(m.cc) > 0 {
 		b.WriteString("Cc: " + strings.Join(m.cc, ", ") + "\r\n")
 	}
+	if len(m.replyTo) > 0 {
+		b.WriteString("Reply-To: " + strings.Join(m.replyTo, ", ") + "\r\n")
+	}
 	b.WriteString("Subject: " + m.subject + "\r\n")
+	for _, h := range m.allHeaders() {
+		b.WriteString(h.name + ": " + h.value + "\r\n")
+	}
 	b.WriteString("MIME-Version: 1.0\r\n")
 	b.WriteString(fmt.Sprintf("Content-Type: %s; charset=\"UTF-8\"\r\n", contentType))
 	b.WriteString("\r\n")
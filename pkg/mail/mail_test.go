@@ -0,0 +1,114 @@
+package mail_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/mail"
+)
+
+func TestUnsubscribeHandler(t *testing.T) {
+	link, err := mail.UnsubscribeURL("Ana@Example.com", "newsletter")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotEmail, gotList string
+	h := mail.UnsubscribeHandler(func(email, list string) error {
+		gotEmail, gotList = email, list
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodPost, link, strings.NewReader("List-Unsubscribe=One-Click")))
+	if rec.Code != http.StatusOK || gotEmail != "Ana@Example.com" || gotList != "newsletter" {
+		t.Fatalf("one-click: %d %q %q", rec.Code, gotEmail, gotList)
+	}
+
+	tampered := strings.Replace(link, "list=newsletter", "list=billing", 1)
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, tampered, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("tampered link: %d", rec.Code)
+	}
+}
+
+func TestArrayDriver_CapturesHeaders(t *testing.T) {
+	mail.SetDriver(mail.DriverArray)
+	defer mail.SetDriver("")
+	mail.ResetSent()
+
+	err := mail.To("ana@example.com").
+		Subject("Weekly digest").
+		ReplyTo("support@example.com").
+		Header("X-Campaign", "w12\r\nBcc: evil@example.com").
+		Priority(mail.PriorityLow).
+		Unsubscribe("digest").
+		Text("hello").
+		Send()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := mail.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("captured %d messages", len(sent))
+	}
+	m := sent[0]
+	if m.Headers["X-Priority"] != "5 (Lowest)" || m.Headers["List-Unsubscribe-Post"] != "List-Unsubscribe=One-Click" {
+		t.Fatalf("headers = %v", m.Headers)
+	}
+	if !strings.HasPrefix(m.Headers["List-Unsubscribe"], "<http") {
+		t.Fatalf("List-Unsubscribe = %q", m.Headers["List-Unsubscribe"])
+	}
+	raw := string(m.Raw)
+	if !strings.Contains(raw, "Reply-To: support@example.com\r\n") {
+		t.Fatalf("raw missing Reply-To:\n%s", raw)
+	}
+	if strings.Contains(raw, "\r\nBcc:") {
+		t.Fatalf("header injection reached the message:\n%s", raw)
+	}
+}
+
+func TestLogDriver_PreviewUI(t *testing.T) {
+	t.Chdir(t.TempDir())
+	mail.SetDriver(mail.DriverLog)
+	defer mail.SetDriver("")
+
+	if err := mail.To("ana@example.com").Subject("Reset your password").Body("<p>Click</p>").Send(); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path, remote string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		mail.PreviewHandler().ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := get(mail.PreviewPath, "203.0.113.9:1234"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("remote request: %d", rec.Code)
+	}
+	// A trusted proxy forwarding a spoofed loopback address is not local.
+	if rec := get(mail.PreviewPath, "10.0.0.5:1234", "X-Forwarded-For", "127.0.0.1"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("spoofed X-Forwarded-For: %d", rec.Code)
+	}
+	list := get(mail.PreviewPath, "127.0.0.1:1234")
+	i := strings.Index(list.Body.String(), mail.PreviewPath+"/")
+	if list.Code != http.StatusOK || i < 0 {
+		t.Fatalf("list: %d %s", list.Code, list.Body)
+	}
+	link := list.Body.String()[i:]
+	link = link[:strings.Index(link, `"`)]
+	page := get(link, "127.0.0.1:1234")
+	if page.Code != http.StatusOK || !strings.Contains(page.Body.String(), "Reset your password") {
+		t.Fatalf("preview %s: %d %s", link, page.Code, page.Body)
+	}
+	if rec := get(mail.PreviewPath+"/..%2f..%2fetc%2fpasswd", "127.0.0.1:1234"); rec.Code == http.StatusOK {
+		t.Fatal("path traversal served a file")
+	}
+}
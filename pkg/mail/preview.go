@@ -0,0 +1,89 @@
+package mail
+
+import (
+	"crypto/subtle"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/clientip"
+)
+
+// ------------------- Preview UI -------------------
+
+// PreviewPath is where the kernel mounts PreviewHandler.
+const PreviewPath = "/_kashvi/mail"
+
+// PreviewEnabled reports whether the preview UI should be mounted: the log
+// driver is active in a development environment.
+func PreviewEnabled() bool {
+	switch config.AppEnv() {
+	case "local", "dev", "development":
+		return currentDriver() == DriverLog
+	}
+	return false
+}
+
+// PreviewHandler lists the previews written by the log driver, newest
+// first, and serves each one. When MAIL_PREVIEW_PASSWORD is set it requires
+// HTTP basic auth (user MAIL_PREVIEW_USER, default "kashvi"); otherwise only
+// connections from a loopback address are served, whatever proxy headers
+// say.
+func PreviewHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !previewAllowed(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="kashvi mail previews"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, PreviewPath), "/")
+		if name == "" {
+			listPreviews(w)
+			return
+		}
+		if name != filepath.Base(name) || !strings.HasSuffix(name, ".html") {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join(PreviewDir(), name))
+	})
+}
+
+func previewAllowed(r *http.Request) bool {
+	pw := config.Get("MAIL_PREVIEW_PASSWORD", "")
+	if pw == "" {
+		// The peer itself, not X-Forwarded-For: a proxy on a private
+		// network would otherwise pass on a spoofed 127.0.0.1.
+		return clientip.ParseAddr(r.RemoteAddr).IsLoopback()
+	}
+	user, pass, ok := r.BasicAuth()
+	return ok &&
+		subtle.ConstantTimeCompare([]byte(user), []byte(config.Get("MAIL_PREVIEW_USER", "kashvi"))) == 1 &&
+		subtle.ConstantTimeCompare([]byte(pass), []byte(pw)) == 1
+}
+
+func listPreviews(w http.ResponseWriter) {
+	entries, _ := os.ReadDir(PreviewDir())
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".html") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names))) // names start with a timestamp
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	listTmpl.Execute(w, map[string]any{"Path": PreviewPath, "Names": names}) //nolint:errcheck
+}
+
+var listTmpl = template.Must(template.New("list").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Mail previews</title>
+<style>body{font:14px system-ui,sans-serif;margin:24px}li{margin:4px 0}</style></head>
+<body><h1>Mail previews</h1>
+{{if .Names}}<ul>{{range .Names}}<li><a href="{{$.Path}}/{{.}}">{{.}}</a></li>{{end}}</ul>
+{{else}}<p>No previews yet. Send mail with <code>MAIL_DRIVER=log</code>.</p>{{end}}
+</body></html>
+`))
@@ -0,0 +1,91 @@
+package mail
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/crypt"
+)
+
+// ------------------- Unsubscribe -------------------
+
+// ErrBadUnsubscribe is returned for unsubscribe links that are malformed or
+// whose signature does not match.
+var ErrBadUnsubscribe = errors.New("mail: invalid unsubscribe link")
+
+// Unsubscribe adds List-Unsubscribe and List-Unsubscribe-Post headers with
+// a signed link for the first recipient, so mailbox providers show their
+// one-click unsubscribe button. list names what the recipient leaves
+// ("newsletter", "product-updates"). Serve the link with UnsubscribeHandler.
+func (m *Message) Unsubscribe(list string) *Message {
+	if len(m.to) == 0 {
+		return m
+	}
+	if link, err := UnsubscribeURL(m.to[0], list); err == nil {
+		m.unsubscribe = link
+	}
+	return m
+}
+
+// UnsubscribeURL returns the signed unsubscribe link for email and list,
+// e.g. to put in the message body as well. The base URL is
+// MAIL_UNSUBSCRIBE_URL (default http://localhost:8080/mail/unsubscribe).
+func UnsubscribeURL(email, list string) (string, error) {
+	sig, err := crypt.Sign(unsubscribePayload(email, list))
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{"email": {email}, "list": {list}, "sig": {sig}}
+	base := config.Get("MAIL_UNSUBSCRIBE_URL", "http://localhost:8080/mail/unsubscribe")
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + q.Encode(), nil
+}
+
+// VerifyUnsubscribe checks the signed link on r and returns who is leaving
+// which list.
+func VerifyUnsubscribe(r *http.Request) (email, list string, err error) {
+	q := r.URL.Query()
+	email, list = q.Get("email"), q.Get("list")
+	if email == "" || !crypt.Verify(unsubscribePayload(email, list), q.Get("sig")) {
+		return "", "", ErrBadUnsubscribe
+	}
+	return email, list, nil
+}
+
+// UnsubscribeHandler serves unsubscribe links: it verifies the signature and
+// calls fn to record the opt-out. It answers both the provider's one-click
+// POST and a person following the link with GET:
+//
+//	r.HandleFunc("/mail/unsubscribe", mail.UnsubscribeHandler(func(email, list string) error {
+//	    return models.Unsubscribe(db, email, list)
+//	}))
+func UnsubscribeHandler(fn func(email, list string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		email, list, err := VerifyUnsubscribe(r)
+		if err != nil {
+			http.Error(w, "invalid unsubscribe link", http.StatusForbidden)
+			return
+		}
+		if err := fn(email, list); err != nil {
+			http.Error(w, "could not unsubscribe, please try again", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("You have been unsubscribed.\n")) //nolint:errcheck
+	}
+}
+
+func unsubscribePayload(email, list string) string {
+	return "unsubscribe\n" + strings.ToLower(email) + "\n" + list
+}
@@ -12,13 +12,18 @@
 package metrics
 
 import (
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/respwriter"
 )
 
 // ─────────────────────────────────────────────
@@ -58,18 +63,78 @@ var (
 		Help:      "Number of HTTP requests currently being served.",
 	})
 
-	// ResponseSize tracks the response body size in bytes.
+	// ResponseSize tracks the response body bytes sent on the wire, by
+	// content type class (see ContentClass) and Content-Encoding
+	// ("identity" when uncompressed).
 	ResponseSize = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "kashvi",
 			Subsystem: "http",
 			Name:      "response_size_bytes",
-			Help:      "Response body sizes in bytes.",
-			Buckets:   []float64{100, 1_000, 10_000, 100_000, 1_000_000},
+			Help:      "Response body sizes in bytes, as sent.",
+			Buckets:   sizeBuckets,
+		},
+		[]string{"method", "path", "content_type", "encoding"},
+	)
+
+	// ResponseUncompressedSize tracks the response body size as written by
+	// the handler, before compression middleware.
+	ResponseUncompressedSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "kashvi",
+			Subsystem: "http",
+			Name:      "response_uncompressed_size_bytes",
+			Help:      "Response body sizes in bytes, before compression.",
+			Buckets:   sizeBuckets,
 		},
-		[]string{"method", "path"},
+		[]string{"method", "path", "content_type"},
 	)
 
+	// RequestSize tracks request body bytes read from the client (as sent,
+	// so still compressed if the client used Content-Encoding).
+	RequestSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "kashvi",
+			Subsystem: "http",
+			Name:      "request_size_bytes",
+			Help:      "Request body sizes in bytes.",
+			Buckets:   sizeBuckets,
+		},
+		[]string{"method", "path", "content_type"},
+	)
+
+	// ClientRequestDuration tracks outgoing HTTP calls made through pkg/http,
+	// by target host, method and status class ("2xx" … "5xx", or "error").
+	ClientRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "kashvi",
+			Subsystem: "http_client",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of outgoing HTTP requests in seconds.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"host", "method", "status_class"},
+	)
+
+	// ClientRequestTotal counts outgoing HTTP calls (every attempt, including retries).
+	ClientRequestTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "kashvi",
+			Subsystem: "http_client",
+			Name:      "requests_total",
+			Help:      "Total number of outgoing HTTP requests.",
+		},
+		[]string{"host", "method", "status_class"},
+	)
+
+	// ClientRequestInFlight tracks outgoing HTTP calls currently awaiting a response.
+	ClientRequestInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "kashvi",
+		Subsystem: "http_client",
+		Name:      "requests_in_flight",
+		Help:      "Number of outgoing HTTP requests currently in flight.",
+	}, []string{"host"})
+
 	// DBQueryDuration tracks ORM query latency.
 	DBQueryDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -105,6 +170,24 @@ var (
 		[]string{"job_type"},
 	)
 
+	// QueueWorkers is the number of running queue worker goroutines. It
+	// moves with autoscaling (WorkerOptions.MaxConcurrency).
+	QueueWorkers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "kashvi",
+		Subsystem: "queue",
+		Name:      "workers",
+		Help:      "Number of running queue worker goroutines.",
+	})
+
+	// QueueDepth is the number of ready jobs per queue, sampled by
+	// autoscaling workers.
+	QueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "kashvi",
+		Subsystem: "queue",
+		Name:      "depth",
+		Help:      "Ready jobs waiting per queue.",
+	}, []string{"queue"})
+
 	// CacheHits / CacheMisses track cache effectiveness.
 	CacheHits = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -124,6 +207,77 @@ var (
 		},
 		[]string{"driver"},
 	)
+
+	// LogMongoDropped counts log records the MongoDB handler had to drop
+	// (buffer full or insert failed).
+	LogMongoDropped = prometheus.NewCounterFunc(
+		prometheus.CounterOpts{
+			Namespace: "kashvi",
+			Subsystem: "log",
+			Name:      "mongo_dropped_total",
+			Help:      "Log records dropped before reaching MongoDB.",
+		},
+		func() float64 { return float64(logger.MongoDropped()) },
+	)
+
+	// PayloadRejected counts request bodies refused by bind for their size
+	// or shape (reason: body_size, depth, array_length, tokens).
+	PayloadRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "kashvi",
+			Subsystem: "http",
+			Name:      "payload_rejected_total",
+			Help:      "Request bodies rejected by size, nesting or token limits.",
+		},
+		[]string{"reason"},
+	)
+
+	// ValidationFailures counts 422 field errors per route pattern and field,
+	// showing which API fields integrators get wrong most often.
+	ValidationFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "kashvi",
+			Subsystem: "http",
+			Name:      "validation_failures_total",
+			Help:      "Validation errors returned to clients, by route and field.",
+		},
+		[]string{"route", "field"},
+	)
+
+	// PanicsRecovered counts handler panics caught by middleware.Recovery,
+	// per route pattern.
+	PanicsRecovered = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "kashvi",
+			Subsystem: "http",
+			Name:      "panics_recovered_total",
+			Help:      "Panics recovered in HTTP handlers, by route.",
+		},
+		[]string{"route"},
+	)
+
+	// DataMigrationRows counts rows processed by data migrations.
+	DataMigrationRows = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "kashvi",
+			Subsystem: "migration",
+			Name:      "data_rows_total",
+			Help:      "Rows processed by data migrations.",
+		},
+		[]string{"name"},
+	)
+
+	// DataMigrationProgress is the completed fraction (0–1) of each data
+	// migration that declares a total.
+	DataMigrationProgress = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "kashvi",
+			Subsystem: "migration",
+			Name:      "data_progress_ratio",
+			Help:      "Completed fraction of a data migration.",
+		},
+		[]string{"name"},
+	)
 )
 
 // ─────────────────────────────────────────────
@@ -146,11 +300,24 @@ func init() {
 		RequestTotal,
 		RequestInFlight,
 		ResponseSize,
+		ResponseUncompressedSize,
+		RequestSize,
+		ClientRequestDuration,
+		ClientRequestTotal,
+		ClientRequestInFlight,
 		DBQueryDuration,
 		QueueJobsProcessed,
 		QueueJobDuration,
+		QueueWorkers,
+		QueueDepth,
 		CacheHits,
 		CacheMisses,
+		LogMongoDropped,
+		PayloadRejected,
+		ValidationFailures,
+		PanicsRecovered,
+		DataMigrationRows,
+		DataMigrationProgress,
 	)
 }
 
@@ -206,26 +373,14 @@ func NewGauge(namespace, name, help string, labels []string) *prometheus.GaugeVe
 // HTTP middleware
 // ─────────────────────────────────────────────
 
-// responseRecorder wraps http.ResponseWriter to capture status code and size.
-type responseRecorder struct {
-	http.ResponseWriter
-	status int
-	size   int
-}
-
-func (r *responseRecorder) WriteHeader(code int) {
-	r.status = code
-	r.ResponseWriter.WriteHeader(code)
-}
-
-func (r *responseRecorder) Write(b []byte) (int, error) {
-	n, err := r.ResponseWriter.Write(b)
-	r.size += n
-	return n, err
-}
+// sizeBuckets are the byte buckets of the request and response size
+// histograms.
+var sizeBuckets = []float64{100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000}
 
 // Middleware returns an http.Handler middleware that records Prometheus metrics
-// for every request: duration histogram, total counter, in-flight gauge, response size.
+// for every request: duration histogram, total counter, in-flight gauge,
+// request size and response size (on the wire and before compression).
+// Install it outside middleware.Compress so both sizes are seen.
 func Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -235,19 +390,80 @@ func Middleware() func(http.Handler) http.Handler {
 			RequestInFlight.Inc()
 			defer RequestInFlight.Dec()
 
-			rr := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
-			next.ServeHTTP(rr, r)
+			var body *countingBody
+			if r.Body != nil && r.Body != http.NoBody {
+				body = &countingBody{ReadCloser: r.Body}
+				r.Body = body
+			}
+			reqClass := ContentClass(r.Header.Get("Content-Type"))
+
+			rw := respwriter.Wrap(w)
+			next.ServeHTTP(rw, r)
 
 			duration := time.Since(start).Seconds()
-			status := strconv.Itoa(rr.status)
+			status := strconv.Itoa(rw.Status())
 
 			RequestDuration.WithLabelValues(r.Method, path, status).Observe(duration)
 			RequestTotal.WithLabelValues(r.Method, path, status).Inc()
-			ResponseSize.WithLabelValues(r.Method, path).Observe(float64(rr.size))
+			if body != nil {
+				RequestSize.WithLabelValues(r.Method, path, reqClass).Observe(float64(body.n))
+			}
+
+			h := rw.Header()
+			class := ContentClass(h.Get("Content-Type"))
+			encoding := h.Get("Content-Encoding")
+			if encoding == "" {
+				encoding = "identity"
+			}
+			ResponseSize.WithLabelValues(r.Method, path, class, encoding).Observe(float64(rw.Size()))
+			ResponseUncompressedSize.WithLabelValues(r.Method, path, class).Observe(float64(rw.UncompressedSize()))
 		})
 	}
 }
 
+// countingBody counts request body bytes as the handler reads them.
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// ContentClass collapses a Content-Type into a low-cardinality label:
+// "json", "html", "text", "xml", "form", "image", "media", "binary",
+// "other", or "none" when the header is empty.
+func ContentClass(contentType string) string {
+	mt, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(contentType)), ";")
+	mt = strings.TrimSpace(mt)
+	switch {
+	case mt == "":
+		return "none"
+	case mt == "application/json" || strings.HasSuffix(mt, "+json") || mt == "application/x-ndjson":
+		return "json"
+	case mt == "text/html":
+		return "html"
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		return "xml"
+	case strings.HasPrefix(mt, "text/") || mt == "application/javascript":
+		return "text"
+	case mt == "application/x-www-form-urlencoded" || strings.HasPrefix(mt, "multipart/"):
+		return "form"
+	case strings.HasPrefix(mt, "image/"):
+		return "image"
+	case strings.HasPrefix(mt, "audio/") || strings.HasPrefix(mt, "video/"):
+		return "media"
+	case mt == "application/octet-stream" || mt == "application/pdf" ||
+		mt == "application/zip" || mt == "application/gzip" || mt == "application/protobuf" ||
+		mt == "application/x-protobuf" || mt == "application/grpc":
+		return "binary"
+	}
+	return "other"
+}
+
 // ─────────────────────────────────────────────
 // /metrics endpoint handler
 // ─────────────────────────────────────────────
@@ -272,6 +488,33 @@ func ObserveDBQuery(operation string, start time.Time) {
 	DBQueryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
 }
 
+// ObserveClientRequest records one outgoing HTTP attempt. status is ignored
+// when err is non-nil (the attempt is counted as "error").
+func ObserveClientRequest(host, method string, status int, err error, start time.Time) {
+	class := "error"
+	if err == nil {
+		class = StatusClass(status)
+	}
+	ClientRequestDuration.WithLabelValues(host, method, class).Observe(time.Since(start).Seconds())
+	ClientRequestTotal.WithLabelValues(host, method, class).Inc()
+}
+
+// StatusClass collapses an HTTP status code into "1xx" … "5xx".
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// RecordValidationFailures counts one failure per field in errs. route
+// should be a route pattern ("POST /api/users/{id}"), never a raw path.
+func RecordValidationFailures(route string, errs map[string]string) {
+	for field := range errs {
+		ValidationFailures.WithLabelValues(route, field).Inc()
+	}
+}
+
 // RecordQueueJob records a queue job result.
 func RecordQueueJob(jobType, status string, start time.Time) {
 	QueueJobsProcessed.WithLabelValues(status).Inc()
@@ -0,0 +1,126 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/shashiranjanraj/kashvi/pkg/metrics"
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+)
+
+// histogramSum returns the sample sum of the named histogram series whose
+// labels include want.
+func histogramSum(t *testing.T, name string, want map[string]string) float64 {
+	t.Helper()
+	families, err := metrics.DefaultRegistry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	next:
+		for _, m := range f.GetMetric() {
+			for k, v := range want {
+				if !hasLabel(m, k, v) {
+					continue next
+				}
+			}
+			return m.GetHistogram().GetSampleSum()
+		}
+	}
+	t.Fatalf("no %s series with %v", name, want)
+	return 0
+}
+
+func hasLabel(m *dto.Metric, name, value string) bool {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name && l.GetValue() == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMiddleware_RecordsSizes(t *testing.T) {
+	body := strings.Repeat(`{"id":1,"name":"kashvi"},`, 200)
+	compress := middleware.DefaultCompressOptions()
+	compress.Enabled = true
+	h := metrics.Middleware()(middleware.Compress(compress)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := make([]byte, 64)
+		for {
+			if _, err := r.Body.Read(buf); err != nil {
+				break
+			}
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(body)) //nolint:errcheck
+	})))
+
+	req := httptest.NewRequest(http.MethodPost, "/sizes", strings.NewReader(`{"q":"hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := histogramSum(t, "kashvi_http_request_size_bytes", map[string]string{"path": "/sizes", "content_type": "json"}); got != 13 {
+		t.Errorf("request size = %v, want 13", got)
+	}
+	raw := histogramSum(t, "kashvi_http_response_uncompressed_size_bytes", map[string]string{"path": "/sizes", "content_type": "json"})
+	wire := histogramSum(t, "kashvi_http_response_size_bytes", map[string]string{"path": "/sizes", "content_type": "json", "encoding": "gzip"})
+	if raw != float64(len(body)) || wire != float64(rec.Body.Len()) || wire >= raw {
+		t.Errorf("response sizes: uncompressed=%v wire=%v (body %d, sent %d)", raw, wire, len(body), rec.Body.Len())
+	}
+}
+
+func TestContentClass(t *testing.T) {
+	cases := map[string]string{
+		"":                                "none",
+		"application/json; charset=utf-8": "json",
+		"application/problem+json":        "json",
+		"text/html; charset=utf-8":        "html",
+		"text/plain":                      "text",
+		"multipart/form-data; boundary=x": "form",
+		"image/png":                       "image",
+		"application/octet-stream":        "binary",
+		"application/vnd.ms-excel":        "other",
+	}
+	for ct, want := range cases {
+		if got := metrics.ContentClass(ct); got != want {
+			t.Errorf("ContentClass(%q) = %q, want %q", ct, got, want)
+		}
+	}
+}
+
+func TestPushCommand(t *testing.T) {
+	var method, path, body string
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gw.Close()
+
+	if err := metrics.PushCommand(metrics.PushOptions{}, "migrate", time.Now(), nil); err != nil {
+		t.Fatalf("disabled push: %v", err)
+	}
+
+	err := metrics.PushCommand(metrics.PushOptions{URL: gw.URL}, "migrate", time.Now().Add(-2*time.Second), errors.New("boom"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method != http.MethodPost || path != "/metrics/job/kashvi/command/migrate" {
+		t.Fatalf("pushed %s %s", method, path)
+	}
+	if !strings.Contains(body, "kashvi_command_success") || strings.Contains(body, "kashvi_command_last_success_timestamp_seconds") {
+		t.Fatalf("a failed run should push success=0 without a last-success timestamp")
+	}
+}
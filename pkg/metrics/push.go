@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+
+	"github.com/shashiranjanraj/kashvi/config"
+)
+
+// ─────────────────────────────────────────────
+// Pushgateway (short-lived commands)
+// ─────────────────────────────────────────────
+
+// pushTimeout bounds a push so a down gateway never stalls a command's exit.
+const pushTimeout = 5 * time.Second
+
+// PushOptions configures PushCommand.
+type PushOptions struct {
+	// URL of the Pushgateway; pushing is disabled when empty.
+	URL string
+	// Job is the Pushgateway job label.
+	Job                string
+	Username, Password string
+}
+
+// DefaultPushOptions reads METRICS_PUSHGATEWAY_URL, METRICS_PUSH_JOB
+// (default "kashvi"), METRICS_PUSHGATEWAY_USER and
+// METRICS_PUSHGATEWAY_PASSWORD.
+func DefaultPushOptions() PushOptions {
+	return PushOptions{
+		URL:      config.Get("METRICS_PUSHGATEWAY_URL", ""),
+		Job:      config.Get("METRICS_PUSH_JOB", "kashvi"),
+		Username: config.Get("METRICS_PUSHGATEWAY_USER", ""),
+		Password: config.Get("METRICS_PUSHGATEWAY_PASSWORD", ""),
+	}
+}
+
+// PushCommand reports a finished CLI command (migrate, seed, queue:work, …)
+// to a Prometheus Pushgateway, because the process exits before Prometheus
+// could scrape it. It is a no-op when opts.URL is empty. The push is
+// grouped by job and command, and carries:
+//
+//	kashvi_command_duration_seconds
+//	kashvi_command_success                         1 or 0
+//	kashvi_command_last_run_timestamp_seconds
+//	kashvi_command_last_success_timestamp_seconds  kept from the last success
+//
+// plus everything in DefaultRegistry, e.g. the jobs a one-shot queue:work
+// processed.
+func PushCommand(opts PushOptions, command string, start time.Time, cmdErr error) error {
+	if opts.URL == "" {
+		return nil
+	}
+	now := time.Now()
+
+	reg := prometheus.NewRegistry()
+	gauge := func(name, help string, v float64) {
+		g := prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "kashvi", Subsystem: "command", Name: name, Help: help})
+		g.Set(v)
+		reg.MustRegister(g)
+	}
+	success := 0.0
+	if cmdErr == nil {
+		success = 1
+		gauge("last_success_timestamp_seconds", "Unix time the command last succeeded.", float64(now.Unix()))
+	}
+	gauge("duration_seconds", "Duration of the last run of the command.", now.Sub(start).Seconds())
+	gauge("success", "Whether the last run of the command succeeded (1) or failed (0).", success)
+	gauge("last_run_timestamp_seconds", "Unix time the command last finished.", float64(now.Unix()))
+
+	job := opts.Job
+	if job == "" {
+		job = "kashvi"
+	}
+	p := push.New(opts.URL, job).
+		Gatherer(prometheus.Gatherers{reg, DefaultRegistry}).
+		Grouping("command", command).
+		Client(&http.Client{Timeout: pushTimeout})
+	if opts.Username != "" {
+		p = p.BasicAuth(opts.Username, opts.Password)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+	// Add (POST) replaces only the metrics pushed now, so a failed run keeps
+	// the previous last_success timestamp.
+	if err := p.AddContext(ctx); err != nil {
+		return fmt.Errorf("metrics: push %s: %w", command, err)
+	}
+	return nil
+}
@@ -41,7 +41,13 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 // UserIDFromCtx retrieves the authenticated user's ID from the context.
 func UserIDFromCtx(r *http.Request) (uint, bool) {
-	id, ok := r.Context().Value(ctxUserID).(uint)
+	return UserIDFromContext(r.Context())
+}
+
+// UserIDFromContext is UserIDFromCtx for code that only has the request's
+// context, such as services and queued work.
+func UserIDFromContext(ctx context.Context) (uint, bool) {
+	id, ok := ctx.Value(ctxUserID).(uint)
 	return id, ok
 }
 
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/cachecontrol"
+	"github.com/shashiranjanraj/kashvi/pkg/respwriter"
+)
+
+// ─── Cache-Control ───────────────────────────────────────────────────────────
+
+// CacheControl applies a caching policy to a route or group:
+//
+//	r.Get("/products", "products.index", h,
+//	    middleware.CacheControl(cachecontrol.StaleWhileRevalidate(time.Minute, time.Hour)))
+//
+// The policy's Cache-Control, Expires and Vary headers go on successful GET
+// and HEAD responses (2xx, 304 and permanent redirects). Error responses get
+// "no-store" so a CDN never keeps a 500 for the policy's max-age. A handler
+// that sets Cache-Control itself, e.g. with c.CacheControl, wins.
+func CacheControl(p cachecontrol.Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rw := respwriter.Wrap(w)
+			rw.OnHeader(func(status int) {
+				h := rw.Header()
+				switch {
+				case h.Get("Cache-Control") != "":
+					// the handler chose its own policy
+				case cacheableStatus(status):
+					p.Apply(h, time.Now())
+				default:
+					cachecontrol.NoStore().Apply(h, time.Now())
+				}
+			})
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+func cacheableStatus(status int) bool {
+	switch {
+	case status >= 200 && status < 300:
+		return true
+	case status == http.StatusNotModified,
+		status == http.StatusMovedPermanently,
+		status == http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
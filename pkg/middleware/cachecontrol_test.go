@@ -0,0 +1,43 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/cachecontrol"
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+)
+
+func TestCacheControl(t *testing.T) {
+	status := http.StatusOK
+	own := ""
+	h := middleware.CacheControl(cachecontrol.Public(time.Minute).Vary("Accept-Language"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if own != "" {
+				w.Header().Set("Cache-Control", own)
+			}
+			w.WriteHeader(status)
+		}))
+	serve := func(method string) http.Header {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/products", nil))
+		return rec.Header()
+	}
+
+	if got := serve(http.MethodGet); got.Get("Cache-Control") != "public, max-age=60" || got.Get("Vary") != "Accept-Language" || got.Get("Expires") == "" {
+		t.Errorf("GET 200 headers = %v", got)
+	}
+	if got := serve(http.MethodPost).Get("Cache-Control"); got != "" {
+		t.Errorf("POST Cache-Control = %q, want none", got)
+	}
+	status = http.StatusInternalServerError
+	if got := serve(http.MethodGet).Get("Cache-Control"); got != "no-store" {
+		t.Errorf("GET 500 Cache-Control = %q, want no-store", got)
+	}
+	status, own = http.StatusOK, "private, max-age=5"
+	if got := serve(http.MethodGet).Get("Cache-Control"); got != own {
+		t.Errorf("Cache-Control = %q, want the handler's own", got)
+	}
+}
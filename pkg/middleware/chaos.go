@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	kashvihttp "github.com/shashiranjanraj/kashvi/pkg/http"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
+	"github.com/shashiranjanraj/kashvi/pkg/response"
+)
+
+// ErrChaos is the error injected into outgoing HTTP calls and queue jobs.
+var ErrChaos = errors.New("chaos: injected fault")
+
+// ChaosOptions configures fault injection. Rates are probabilities in 0..1.
+// Chaos is never active when APP_ENV is production/prod.
+type ChaosOptions struct {
+	Enabled bool
+	Paths   []string // path prefixes to target; empty = every path
+
+	Latency     time.Duration // added before the handler runs
+	LatencyRate float64
+
+	ErrorRate   float64 // respond ErrorStatus without calling the handler
+	ErrorStatus int
+
+	DropRate float64 // close the connection without a response
+
+	SlowBodyRate  float64       // dribble the response body…
+	SlowBodyDelay time.Duration // …sleeping this long before each write
+
+	// Per-request overrides via X-Chaos-Latency (duration), X-Chaos-Error
+	// (status), X-Chaos-Drop (any value) and X-Chaos-Slow-Body (duration).
+	AllowHeaders bool
+
+	HTTPClientErrorRate float64 // fail outgoing pkg/http attempts
+	QueueErrorRate      float64 // fail queue pushes and job runs
+}
+
+// DefaultChaosOptions reads CHAOS_* from config.
+func DefaultChaosOptions() ChaosOptions {
+	return ChaosOptions{
+		Enabled:             config.Get("CHAOS_ENABLED", "false") == "true",
+		Paths:               splitCSV(config.Get("CHAOS_PATHS", "")),
+		Latency:             envDuration("CHAOS_LATENCY", 0),
+		LatencyRate:         envRate("CHAOS_LATENCY_RATE", 1),
+		ErrorRate:           envRate("CHAOS_ERROR_RATE", 0),
+		ErrorStatus:         envInt("CHAOS_ERROR_STATUS", http.StatusInternalServerError),
+		DropRate:            envRate("CHAOS_DROP_RATE", 0),
+		SlowBodyRate:        envRate("CHAOS_SLOW_BODY_RATE", 0),
+		SlowBodyDelay:       envDuration("CHAOS_SLOW_BODY_DELAY", 200*time.Millisecond),
+		AllowHeaders:        config.Get("CHAOS_HEADERS", "true") == "true",
+		HTTPClientErrorRate: envRate("CHAOS_HTTP_ERROR_RATE", 0),
+		QueueErrorRate:      envRate("CHAOS_QUEUE_ERROR_RATE", 0),
+	}
+}
+
+// chaosAllowed reports whether opts may inject faults in this environment.
+func chaosAllowed(opts ChaosOptions) bool {
+	env := config.AppEnv()
+	return opts.Enabled && env != "production" && env != "prod"
+}
+
+// Chaos returns a middleware that injects latency, errors, dropped
+// connections and slow bodies into matching requests. Outside of its
+// allowed environments it returns next unchanged.
+//
+//	r.Use(middleware.Chaos(middleware.DefaultChaosOptions()))
+//
+//	curl -H 'X-Chaos-Latency: 2s' -H 'X-Chaos-Error: 503' localhost:8080/api/orders
+func Chaos(opts ChaosOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !chaosAllowed(opts) {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !chaosTargets(opts.Paths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			latency, status, drop, slow := opts.roll()
+			if opts.AllowHeaders {
+				latency, status, drop, slow = chaosHeaders(r, latency, status, drop, slow)
+			}
+
+			if latency > 0 {
+				select {
+				case <-time.After(latency):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			if drop {
+				dropConnection(w)
+				return
+			}
+			if status > 0 {
+				response.Error(w, status, "Chaos: injected failure")
+				return
+			}
+			if slow > 0 {
+				w = &slowWriter{ResponseWriter: w, delay: slow}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// InstallChaosHooks wires the HTTP client and queue fault injectors
+// according to opts. It does nothing where Chaos would be disabled.
+func InstallChaosHooks(opts ChaosOptions) {
+	if !chaosAllowed(opts) {
+		return
+	}
+	if rate := opts.HTTPClientErrorRate; rate > 0 {
+		kashvihttp.SetFaultInjector(func(*http.Request) error {
+			if rand.Float64() < rate {
+				return ErrChaos
+			}
+			return nil
+		})
+	}
+	if rate := opts.QueueErrorRate; rate > 0 {
+		queue.SetFaultInjector(func(op, jobType string) error {
+			if rand.Float64() < rate {
+				return ErrChaos
+			}
+			return nil
+		})
+	}
+	logger.Warn("chaos: fault injection enabled",
+		"error_rate", opts.ErrorRate, "drop_rate", opts.DropRate,
+		"latency", opts.Latency, "http_client_error_rate", opts.HTTPClientErrorRate,
+		"queue_error_rate", opts.QueueErrorRate)
+}
+
+// roll decides the configured faults for one request.
+func (o ChaosOptions) roll() (latency time.Duration, status int, drop bool, slow time.Duration) {
+	if o.Latency > 0 && rand.Float64() < o.LatencyRate {
+		latency = o.Latency
+	}
+	if rand.Float64() < o.DropRate {
+		drop = true
+	} else if rand.Float64() < o.ErrorRate {
+		status = o.ErrorStatus
+	}
+	if rand.Float64() < o.SlowBodyRate {
+		slow = o.SlowBodyDelay
+	}
+	return
+}
+
+func chaosHeaders(r *http.Request, latency time.Duration, status int, drop bool, slow time.Duration) (time.Duration, int, bool, time.Duration) {
+	if d, err := time.ParseDuration(r.Header.Get("X-Chaos-Latency")); err == nil {
+		latency = d
+	}
+	if n, err := strconv.Atoi(r.Header.Get("X-Chaos-Error")); err == nil && n >= 400 && n <= 599 {
+		status = n
+	}
+	if r.Header.Get("X-Chaos-Drop") != "" {
+		drop = true
+	}
+	if d, err := time.ParseDuration(r.Header.Get("X-Chaos-Slow-Body")); err == nil {
+		slow = d
+	}
+	return latency, status, drop, slow
+}
+
+func chaosTargets(prefixes []string, path string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropConnection closes the client connection without writing a response.
+func dropConnection(w http.ResponseWriter) {
+	if conn, _, err := http.NewResponseController(w).Hijack(); err == nil {
+		conn.Close()
+		return
+	}
+	// Not hijackable (HTTP/2, recorders): abort the handler, which makes
+	// net/http reset the stream without logging a stack trace.
+	panic(http.ErrAbortHandler)
+}
+
+// slowWriter sleeps before each body write and flushes after it, so clients
+// see the body trickle in.
+type slowWriter struct {
+	http.ResponseWriter
+	delay time.Duration
+}
+
+func (s *slowWriter) Write(b []byte) (int, error) {
+	time.Sleep(s.delay)
+	n, err := s.ResponseWriter.Write(b)
+	http.NewResponseController(s.ResponseWriter).Flush() //nolint:errcheck
+	return n, err
+}
+
+func (s *slowWriter) Unwrap() http.ResponseWriter { return s.ResponseWriter }
+
+// ─── env helpers ──────────────────────────────────────────────────────────────
+
+func splitCSV(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(config.Get(key, "")); err == nil {
+		return d
+	}
+	return def
+}
+
+func envRate(key string, def float64) float64 {
+	f, err := strconv.ParseFloat(config.Get(key, ""), 64)
+	if err != nil || f < 0 || f > 1 {
+		return def
+	}
+	return f
+}
+
+func envInt(key string, def int) int {
+	n, err := strconv.Atoi(config.Get(key, ""))
+	if err != nil {
+		return def
+	}
+	return n
+}
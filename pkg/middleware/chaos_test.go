@@ -0,0 +1,68 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	kashvihttp "github.com/shashiranjanraj/kashvi/pkg/http"
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+)
+
+func okHandler(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) } //nolint:errcheck
+
+func TestChaosHeaderOverrides(t *testing.T) {
+	h := middleware.Chaos(middleware.ChaosOptions{Enabled: true, AllowHeaders: true})(http.HandlerFunc(okHandler))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+	req.Header.Set("X-Chaos-Latency", "30ms")
+	req.Header.Set("X-Chaos-Error", "503")
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	h.ServeHTTP(rec, req)
+	if time.Since(start) < 30*time.Millisecond {
+		t.Error("expected injected latency")
+	}
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+}
+
+func TestChaosRespectsPathsAndDisabled(t *testing.T) {
+	opts := middleware.ChaosOptions{Enabled: true, Paths: []string{"/api/"}, ErrorRate: 1, ErrorStatus: 500}
+	h := middleware.Chaos(opts)(http.HandlerFunc(okHandler))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("untargeted path got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/x", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("targeted path got %d, want 500", rec.Code)
+	}
+
+	opts.Enabled = false
+	rec = httptest.NewRecorder()
+	middleware.Chaos(opts)(http.HandlerFunc(okHandler)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/x", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("disabled chaos still injected %d", rec.Code)
+	}
+}
+
+func TestChaosHTTPClientHook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+
+	middleware.InstallChaosHooks(middleware.ChaosOptions{Enabled: true, HTTPClientErrorRate: 1})
+	defer kashvihttp.SetFaultInjector(nil)
+
+	_, err := kashvihttp.Get(srv.URL).Send()
+	if !errors.Is(err, middleware.ErrChaos) {
+		t.Fatalf("expected injected error, got %v", err)
+	}
+}
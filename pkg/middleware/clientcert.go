@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/shashiranjanraj/kashvi/pkg/mtls"
+	"github.com/shashiranjanraj/kashvi/pkg/response"
+)
+
+// RequireClientCert accepts only requests that presented a client
+// certificate verified against TLS_CLIENT_CA_FILE (see pkg/mtls). Requests
+// without one get 401; an identity missing any of abilities gets 403. The
+// identity is stored on the request context for mtls.FromCtx / c.ClientIdentity.
+//
+//	internal := r.Group("/internal", middleware.RequireClientCert("orders:read"))
+func RequireClientCert(abilities ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cert := mtls.PeerCertificate(r)
+			if cert == nil {
+				response.Unauthorized(w)
+				return
+			}
+			id := mtls.Resolve(cert)
+			for _, a := range abilities {
+				if !id.Can(a) {
+					response.Forbidden(w)
+					return
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(mtls.WithIdentity(r.Context(), id)))
+		})
+	}
+}
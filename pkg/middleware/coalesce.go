@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ─── Request coalescing ──────────────────────────────────────────────────────
+
+// CoalesceOptions configures CoalesceWith.
+type CoalesceOptions struct {
+	// Vary lists the request headers that scope a response: requests only
+	// share a response when these headers match. DefaultCoalesceOptions
+	// uses Authorization and Cookie (the caller's identity) plus Accept and
+	// Accept-Language.
+	Vary []string
+	// Key, when set, replaces the default key (host, path, sorted query and
+	// the Vary headers). Requests with the same key share one execution;
+	// return "" to run a request on its own.
+	Key func(r *http.Request) string
+}
+
+// DefaultCoalesceOptions scopes shared responses to the caller's
+// credentials and content negotiation.
+func DefaultCoalesceOptions() CoalesceOptions {
+	return CoalesceOptions{Vary: []string{"Authorization", "Cookie", "Accept", "Accept-Language"}}
+}
+
+// Coalesce is CoalesceWith(DefaultCoalesceOptions()).
+func Coalesce() func(http.Handler) http.Handler {
+	return CoalesceWith(DefaultCoalesceOptions())
+}
+
+// CoalesceWith deduplicates identical GET requests that are in flight at
+// the same time: the first one runs the handler, and the ones that arrive
+// before it finishes wait and receive a copy of its response. A burst of
+// dashboard widgets asking for the same expensive report costs one query:
+//
+//	r.Get("/reports/revenue", "reports.revenue", revenue, middleware.Coalesce())
+//
+// Only use it on read-only routes whose response depends on nothing but the
+// key. Waiters get the first request's outcome, including a timeout or
+// cancellation, and its headers, including Set-Cookie. Responses are held
+// in memory; WebSocket upgrades and SSE streams are not coalesced.
+func CoalesceWith(opts CoalesceOptions) func(http.Handler) http.Handler {
+	key := opts.Key
+	if key == nil {
+		key = func(r *http.Request) string { return coalesceKey(r, opts.Vary) }
+	}
+	var group singleflight.Group
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || isStreaming(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			v, _, _ := group.Do(k, func() (any, error) {
+				rec := &recordedResponse{h: make(http.Header)}
+				next.ServeHTTP(rec, r)
+				return rec, nil
+			})
+			v.(*recordedResponse).writeTo(w)
+		})
+	}
+}
+
+// coalesceKey identifies a request by host, path, sorted query and a hash of
+// the vary headers, so credentials never appear in the key.
+func coalesceKey(r *http.Request, vary []string) string {
+	sum := sha256.New()
+	for _, name := range vary {
+		sum.Write([]byte(name + ":" + strings.Join(r.Header.Values(name), ",") + "\n"))
+	}
+	return r.Host + r.URL.Path + "?" + r.URL.Query().Encode() + "#" + hex.EncodeToString(sum.Sum(nil)[:16])
+}
+
+// recordedResponse buffers a handler's response so it can be replayed to
+// every waiter.
+type recordedResponse struct {
+	h    http.Header
+	code int
+	body bytes.Buffer
+}
+
+func (rr *recordedResponse) Header() http.Header { return rr.h }
+
+func (rr *recordedResponse) WriteHeader(code int) {
+	if rr.code == 0 {
+		rr.code = code
+	}
+}
+
+func (rr *recordedResponse) Write(p []byte) (int, error) {
+	if rr.code == 0 {
+		rr.code = http.StatusOK
+	}
+	return rr.body.Write(p)
+}
+
+func (rr *recordedResponse) writeTo(w http.ResponseWriter) {
+	dst := w.Header()
+	for k, vv := range rr.h {
+		dst[k] = append([]string(nil), vv...)
+	}
+	code := rr.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+	w.WriteHeader(code)
+	w.Write(rr.body.Bytes()) //nolint:errcheck
+}
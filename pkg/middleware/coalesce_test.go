@@ -0,0 +1,75 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+)
+
+func TestCoalesceSharesOneExecution(t *testing.T) {
+	var runs atomic.Int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	h := middleware.Coalesce()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runs.Add(1)
+		started <- struct{}{}
+		<-release
+		w.Header().Set("X-User", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, "report") //nolint:errcheck
+	}))
+
+	serve := func(target, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", auth)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var wg sync.WaitGroup
+	recs := make([]*httptest.ResponseRecorder, 6)
+	for i := range recs {
+		target, auth := "/reports?b=2&a=1", "Bearer alice"
+		switch i {
+		case 1, 2:
+			target = "/reports?a=1&b=2" // same query, other order
+		case 5:
+			auth = "Bearer bob" // another caller never shares alice's response
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recs[i] = serve(target, auth)
+		}()
+	}
+	<-started
+	<-started // alice's and bob's leaders
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := runs.Load(); n != 2 {
+		t.Errorf("handler ran %d times, want 2 (one per caller)", n)
+	}
+	for i, rec := range recs {
+		want := "Bearer alice"
+		if i == 5 {
+			want = "Bearer bob"
+		}
+		if rec.Code != http.StatusAccepted || rec.Body.String() != "report" || rec.Header().Get("X-User") != want {
+			t.Errorf("response %d = %d %q %q", i, rec.Code, rec.Body.String(), rec.Header().Get("X-User"))
+		}
+	}
+
+	// Later requests run again: only in-flight requests are shared.
+	if serve("/reports?a=1&b=2", "Bearer alice"); runs.Load() != 3 {
+		t.Errorf("handler ran %d times, want a fresh run after the burst", runs.Load())
+	}
+}
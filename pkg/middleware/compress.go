@@ -0,0 +1,532 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/respwriter"
+)
+
+// ─── Codecs ──────────────────────────────────────────────────────────────────
+
+// Codec is a content-coding usable by Decompress and Compress.
+// gzip (alias x-gzip), deflate and br (Brotli) are built in; RegisterCodec
+// adds others, e.g. zstd.
+type Codec struct {
+	Name string
+	// NewReader wraps a compressed request body.
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+	// NewWriter compresses a response. level is CompressOptions.Level
+	// (-1 means the codec's default). Nil disables response compression.
+	NewWriter func(w io.Writer, level int) (io.WriteCloser, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{}
+	// codecPreference breaks ties between equally-weighted Accept-Encoding
+	// entries: the better ratio wins.
+	codecPreference = []string{"br", "zstd", "gzip", "deflate"}
+)
+
+func init() {
+	RegisterCodec(Codec{
+		Name: "gzip",
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, level)
+		},
+	})
+	RegisterCodec(Codec{
+		Name:      "deflate",
+		NewReader: newDeflateReader,
+		NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return zlib.NewWriterLevel(w, level)
+		},
+	})
+	RegisterCodec(Codec{
+		Name: "br",
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(brotli.NewReader(r)), nil
+		},
+		NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			if level < 0 {
+				level = brotli.DefaultCompression
+			}
+			return brotli.NewWriterLevel(w, level), nil
+		},
+	})
+}
+
+// RegisterCodec adds or replaces a content-coding. Names are case-insensitive.
+func RegisterCodec(c Codec) {
+	name := strings.ToLower(c.Name)
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[name] = c
+	for _, p := range codecPreference {
+		if p == name {
+			return
+		}
+	}
+	codecPreference = append(codecPreference, name)
+}
+
+func lookupCodec(name string) (Codec, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "x-gzip" {
+		name = "gzip"
+	}
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[name]
+	return c, ok
+}
+
+func codecNames() string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	var names []string
+	for _, n := range codecPreference {
+		if _, ok := codecs[n]; ok {
+			names = append(names, n)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// newDeflateReader accepts both zlib-wrapped deflate (what RFC 9110 means by
+// "deflate") and the raw deflate streams some clients send instead.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	hdr, err := br.Peek(2)
+	if err == nil && hdr[0]&0x0f == 8 && (uint16(hdr[0])<<8|uint16(hdr[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// ─── Request decompression ───────────────────────────────────────────────────
+
+// ErrCompressionRatio is returned while reading a decompressed body whose
+// expansion ratio exceeds DecompressOptions.MaxRatio (a likely zip bomb).
+var ErrCompressionRatio = errors.New("middleware: compressed body expands beyond the allowed ratio")
+
+// DecompressOptions configures Decompress.
+type DecompressOptions struct {
+	// MaxSize caps the decompressed body. Exceeding it fails the read with
+	// *http.MaxBytesError, which bind reports as "request body too large".
+	MaxSize int64
+	// MaxRatio caps decompressed/compressed bytes once more than 64 KB has
+	// been produced. 0 disables the check.
+	MaxRatio int
+}
+
+// DefaultDecompressOptions reads REQUEST_DECOMPRESS_MAX_BYTES (default 10 MB)
+// and REQUEST_DECOMPRESS_MAX_RATIO (default 100).
+func DefaultDecompressOptions() DecompressOptions {
+	return DecompressOptions{
+		MaxSize:  int64(envInt("REQUEST_DECOMPRESS_MAX_BYTES", 10<<20)),
+		MaxRatio: envInt("REQUEST_DECOMPRESS_MAX_RATIO", 100),
+	}
+}
+
+// maxContentCodings bounds stacked encodings ("gzip, gzip, gzip, …").
+const maxContentCodings = 2
+
+// ratioFloor is the output size below which MaxRatio is not enforced, so
+// small, highly repetitive payloads are not rejected.
+const ratioFloor = 64 << 10
+
+// Decompress transparently decodes request bodies sent with a
+// Content-Encoding of a registered codec. Handlers and bind see plain bytes
+// and no Content-Encoding header. Unknown codings get 415 with an
+// Accept-Encoding header listing the supported ones (RFC 7694).
+func Decompress(opts DecompressOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ce := r.Header.Get("Content-Encoding")
+			if ce == "" || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var chain []Codec
+			for _, name := range strings.Split(ce, ",") {
+				name = strings.TrimSpace(name)
+				if name == "" || strings.EqualFold(name, "identity") {
+					continue
+				}
+				c, ok := lookupCodec(name)
+				if !ok || c.NewReader == nil || len(chain) == maxContentCodings {
+					w.Header().Set("Accept-Encoding", codecNames())
+					http.Error(w, `{"status":415,"message":"Unsupported Content-Encoding"}`, http.StatusUnsupportedMediaType)
+					return
+				}
+				chain = append(chain, c)
+			}
+
+			counted := &countingReader{r: r.Body}
+			var body io.Reader = counted
+			closers := []io.Closer{r.Body}
+			// Codings are listed in the order they were applied; undo them
+			// in reverse.
+			for i := len(chain) - 1; i >= 0; i-- {
+				dec, err := chain[i].NewReader(body)
+				if err != nil {
+					http.Error(w, `{"status":400,"message":"Malformed compressed body"}`, http.StatusBadRequest)
+					return
+				}
+				closers = append(closers, dec)
+				body = dec
+			}
+
+			r.Body = &decodedBody{r: body, src: counted, closers: closers, opts: opts}
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type decodedBody struct {
+	r       io.Reader
+	src     *countingReader
+	closers []io.Closer
+	opts    DecompressOptions
+	n       int64
+	err     error
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.r.Read(p)
+	b.n += int64(n)
+	switch {
+	case b.opts.MaxSize > 0 && b.n > b.opts.MaxSize:
+		b.err = &http.MaxBytesError{Limit: b.opts.MaxSize}
+	case b.opts.MaxRatio > 0 && b.n > ratioFloor && b.n > int64(b.opts.MaxRatio)*b.src.n:
+		b.err = ErrCompressionRatio
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return n, err
+}
+
+func (b *decodedBody) Close() error {
+	var errs []error
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		errs = append(errs, b.closers[i].Close())
+	}
+	return errors.Join(errs...)
+}
+
+// ─── Response compression ────────────────────────────────────────────────────
+
+// CompressOptions configures Compress.
+type CompressOptions struct {
+	Enabled bool
+	// Level is passed to the codec; -1 selects its default.
+	Level int
+	// MinSize is the smallest body worth compressing.
+	MinSize int
+	// ContentTypes are media-type prefixes eligible for compression.
+	ContentTypes []string
+	// SkipContentTypes are media-type prefixes that are never compressed
+	// because they already are (images, archives, media). They win over
+	// ContentTypes, so a broad prefix such as "application/" stays safe.
+	SkipContentTypes []string
+}
+
+// DefaultCompressOptions reads HTTP_COMPRESSION (default true),
+// HTTP_COMPRESSION_LEVEL (-1), HTTP_COMPRESSION_MIN_SIZE (1024) and
+// HTTP_COMPRESSION_TYPES (comma-separated prefixes replacing the default
+// list of text-like types).
+func DefaultCompressOptions() CompressOptions {
+	types := splitCSV(config.Get("HTTP_COMPRESSION_TYPES", ""))
+	if len(types) == 0 {
+		types = []string{
+			"text/",
+			"application/json",
+			"application/problem+json",
+			"application/javascript",
+			"application/xml",
+			"application/x-ndjson",
+			"image/svg+xml",
+		}
+	}
+	return CompressOptions{
+		Enabled:      config.Get("HTTP_COMPRESSION", "true") == "true",
+		Level:        envInt("HTTP_COMPRESSION_LEVEL", -1),
+		MinSize:      envInt("HTTP_COMPRESSION_MIN_SIZE", 1024),
+		ContentTypes: types,
+		SkipContentTypes: []string{
+			"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+			"video/", "audio/", "font/woff",
+			"application/zip", "application/gzip", "application/x-gzip",
+			"application/zstd", "application/x-bzip2", "application/x-xz",
+			"application/x-7z-compressed", "application/vnd.rar",
+			"application/pdf", "application/wasm",
+		},
+	}
+}
+
+// Compress negotiates Accept-Encoding against the registered codecs and
+// compresses eligible responses. Bodies smaller than MinSize, responses
+// that already carry a Content-Encoding, HEAD requests and WebSocket
+// upgrades are passed through untouched.
+func Compress(opts CompressOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !opts.Enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressWriter{Writer: respwriter.Wrap(w), opts: opts, status: http.StatusOK}
+			cw.codec, cw.ok = NegotiateEncoding(r.Header.Get("Accept-Encoding"))
+			defer func() {
+				if p := recover(); p != nil {
+					// Leave the buffered partial body unsent so Recovery can
+					// answer; a started encoder is left unfinished, so the
+					// client sees a truncated stream.
+					panic(p)
+				}
+				cw.close()
+			}()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// NegotiateEncoding picks the registered codec the client prefers, honouring
+// q-values and "*". Ties go to the better-compressing coding.
+func NegotiateEncoding(accept string) (Codec, bool) {
+	if accept == "" {
+		return Codec{}, false
+	}
+	weights := map[string]float64{}
+	wildcard := -1.0
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "x-gzip" {
+			name = "gzip"
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		weights[name] = q
+	}
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	type cand struct {
+		c    Codec
+		q    float64
+		rank int
+	}
+	var cands []cand
+	for rank, name := range codecPreference {
+		c, ok := codecs[name]
+		if !ok || c.NewWriter == nil {
+			continue
+		}
+		q, listed := weights[name]
+		if !listed {
+			q = wildcard
+		}
+		if q > 0 {
+			cands = append(cands, cand{c, q, rank})
+		}
+	}
+	if len(cands) == 0 {
+		return Codec{}, false
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].q > cands[j].q })
+	return cands[0].c, true
+}
+
+// compressWriter buffers the first MinSize bytes to decide whether to
+// compress, then streams through the encoder. It builds on respwriter, which
+// forwards Hijack and Push and counts the bytes that reach the client.
+type compressWriter struct {
+	*respwriter.Writer
+	opts  CompressOptions
+	codec Codec
+	ok    bool
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser
+	raw     int64 // bytes written by the handler
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		return
+	}
+	if code < 200 {
+		w.Writer.WriteHeader(code)
+		return
+	}
+	w.status = code
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	w.raw += int64(len(b))
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.opts.MinSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.Writer.Write(b)
+}
+
+// ReadFrom sends r through Write so io.Copy and http.ServeContent bodies are
+// compressed too; once the response is known to be uncompressed it keeps
+// respwriter's fast path (sendfile).
+func (w *compressWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.decided && w.enc == nil {
+		n, err := w.Writer.ReadFrom(r)
+		w.raw += n
+		return n, err
+	}
+	return io.Copy(writerOnly{w}, r)
+}
+
+// writerOnly hides ReadFrom, so io.Copy cannot recurse back into it.
+type writerOnly struct{ io.Writer }
+
+// decide commits headers and either starts the encoder or passes through.
+// large reports whether the body reached MinSize.
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+	h := w.Header()
+	eligible := w.eligible(h)
+	if eligible {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	if eligible && large && w.ok {
+		enc, err := w.codec.NewWriter(w.Writer, w.opts.Level)
+		if err == nil {
+			h.Set("Content-Encoding", w.codec.Name)
+			h.Del("Content-Length")
+			w.enc = enc
+		}
+	}
+	w.Writer.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.enc != nil {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.Writer.Write(buf)
+	return err
+}
+
+func (w *compressWriter) eligible(h http.Header) bool {
+	if h.Get("Content-Encoding") != "" || w.status < 200 ||
+		w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	ct := h.Get("Content-Type")
+	if ct == "" && len(w.buf) > 0 {
+		ct = http.DetectContentType(w.buf)
+		h.Set("Content-Type", ct)
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range w.opts.SkipContentTypes {
+		if strings.HasPrefix(mt, prefix) {
+			return false
+		}
+	}
+	for _, prefix := range w.opts.ContentTypes {
+		if strings.HasPrefix(mt, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush sends buffered data. A flush before MinSize is reached commits to an
+// uncompressed response (streaming endpoints such as SSE).
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	w.Writer.Flush()
+}
+
+func (w *compressWriter) close() {
+	if !w.decided {
+		_ = w.decide(len(w.buf) >= w.opts.MinSize)
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		// Let metrics report the size before and after compression.
+		w.SetUncompressedSize(w.raw)
+	}
+}
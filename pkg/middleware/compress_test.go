@@ -0,0 +1,331 @@
+package middleware_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+)
+
+func gzipped(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(b)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// echo returns the request body, or the read error as a 413.
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	_, _ = w.Write(body)
+})
+
+func TestDecompress_Gzip(t *testing.T) {
+	h := middleware.Decompress(middleware.DecompressOptions{MaxSize: 1 << 20})(echo)
+	req := httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(gzipped(t, []byte(`{"ok":true}`))))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Body.String() != `{"ok":true}` {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+}
+
+func TestDecompress_Deflate(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	_, _ = zw.Write([]byte("hello"))
+	_ = zw.Close()
+
+	h := middleware.Decompress(middleware.DecompressOptions{})(echo)
+	req := httptest.NewRequest(http.MethodPost, "/", &buf)
+	req.Header.Set("Content-Encoding", "deflate")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Body.String() != "hello" {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+}
+
+func TestDecompress_Brotli(t *testing.T) {
+	var buf bytes.Buffer
+	bw := brotli.NewWriter(&buf)
+	_, _ = bw.Write([]byte(`{"event":"invoice.paid"}`))
+	_ = bw.Close()
+
+	h := middleware.Decompress(middleware.DecompressOptions{MaxSize: 1 << 20})(echo)
+	req := httptest.NewRequest(http.MethodPost, "/hook", &buf)
+	req.Header.Set("Content-Encoding", "br")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"event":"invoice.paid"}` {
+		t.Errorf("%d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDecompress_Unsupported(t *testing.T) {
+	h := middleware.Decompress(middleware.DecompressOptions{})(echo)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("xx"))
+	req.Header.Set("Content-Encoding", "compress")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, want 415", rec.Code)
+	}
+	if !strings.Contains(rec.Header().Get("Accept-Encoding"), "gzip") {
+		t.Errorf("Accept-Encoding = %q", rec.Header().Get("Accept-Encoding"))
+	}
+}
+
+func TestDecompress_Bomb(t *testing.T) {
+	bomb := gzipped(t, make([]byte, 4<<20)) // ~4 KB on the wire
+
+	var readErr error
+	h := middleware.Decompress(middleware.DecompressOptions{MaxRatio: 100})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(bomb))
+	req.Header.Set("Content-Encoding", "gzip")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if !errors.Is(readErr, middleware.ErrCompressionRatio) {
+		t.Errorf("ratio: err = %v", readErr)
+	}
+
+	h = middleware.Decompress(middleware.DecompressOptions{MaxSize: 1 << 20})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(bomb))
+	req.Header.Set("Content-Encoding", "gzip")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) {
+		t.Errorf("size: err = %v", readErr)
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := map[string]string{
+		"gzip, deflate":           "gzip",
+		"deflate;q=1, gzip;q=0.5": "deflate",
+		"*":                       "br",
+		"gzip, deflate, br":       "br",
+		"gzip;q=0, br;q=0, *;q=1": "deflate",
+		"identity":                "",
+		"zstd":                    "", // not registered by default
+		"":                        "",
+	}
+	for accept, want := range cases {
+		c, _ := middleware.NegotiateEncoding(accept)
+		if got := c.Name; got != want {
+			t.Errorf("NegotiateEncoding(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	payload := strings.Repeat(`{"name":"kashvi"},`, 200)
+	h := middleware.Compress(middleware.CompressOptions{
+		Enabled: true, Level: -1, MinSize: 1024, ContentTypes: []string{"application/json"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, payload)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q", rec.Header().Get("Content-Encoding"))
+	}
+	if rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Vary = %q", rec.Header().Get("Vary"))
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(zr)
+	if string(got) != payload {
+		t.Error("round-trip mismatch")
+	}
+
+	// Small bodies and clients without Accept-Encoding are left alone.
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != payload {
+		t.Error("response compressed without Accept-Encoding")
+	}
+}
+
+func TestCompress_SkipsCompressedTypes(t *testing.T) {
+	opts := middleware.DefaultCompressOptions()
+	opts.Enabled = true
+	opts.ContentTypes = []string{"application/", "image/"}
+	for ct, want := range map[string]string{
+		"application/json": "gzip",
+		"application/zip":  "",
+		"image/png":        "",
+		"image/svg+xml":    "gzip",
+	} {
+		h := middleware.Compress(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ct)
+			_, _ = io.WriteString(w, strings.Repeat("x", 4096))
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Content-Encoding"); got != want {
+			t.Errorf("%s: Content-Encoding = %q, want %q", ct, got, want)
+		}
+	}
+}
+
+func TestCompress_Brotli(t *testing.T) {
+	payload := strings.Repeat(`{"name":"kashvi"},`, 200)
+	h := middleware.Compress(middleware.CompressOptions{
+		Enabled: true, Level: -1, MinSize: 1024, ContentTypes: []string{"application/json"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, payload)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "br" {
+		t.Fatalf("Content-Encoding = %q", rec.Header().Get("Content-Encoding"))
+	}
+	got, _ := io.ReadAll(brotli.NewReader(rec.Body))
+	if string(got) != payload {
+		t.Error("round-trip mismatch")
+	}
+}
+
+func TestCompress_PanicReachesRecovery(t *testing.T) {
+	h := middleware.Recovery(middleware.Compress(middleware.CompressOptions{
+		Enabled: true, Level: -1, MinSize: 1024, ContentTypes: []string{"application/json"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"partial":`)
+		panic("boom")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "partial") {
+		t.Fatalf("got %d %q, want Recovery's 500 alone", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCompress_ReadFrom(t *testing.T) {
+	payload := strings.Repeat("kashvi,", 500)
+	h := middleware.Compress(middleware.CompressOptions{
+		Enabled: true, Level: -1, MinSize: 1024, ContentTypes: []string{"text/"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		_, _ = io.Copy(w, strings.NewReader(payload)) // uses ReadFrom
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", rec.Header().Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(zr); string(got) != payload {
+		t.Error("round-trip mismatch")
+	}
+}
+
+func TestCompress_ForwardsHijack(t *testing.T) {
+	srv := httptest.NewServer(middleware.Compress(middleware.CompressOptions{
+		Enabled: true, Level: -1, MinSize: 1024, ContentTypes: []string{"text/"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		conn.Close()
+	})))
+	defer srv.Close()
+	if resp, err := http.Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Fatalf("got %d, want the connection dropped", resp.StatusCode)
+	}
+}
+
+func TestCompress_StreamsUnderTimeoutOnceFlushed(t *testing.T) {
+	first := make(chan struct{})
+	release := make(chan struct{})
+	h := middleware.Timeout(time.Second)(middleware.Compress(middleware.CompressOptions{
+		Enabled: true, Level: -1, MinSize: 16, ContentTypes: []string{"text/"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, strings.Repeat("chunk one ", 10))
+		_ = http.NewResponseController(w).Flush()
+		close(first)
+		<-release
+		_, _ = io.WriteString(w, "chunk two")
+	})))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	<-first
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", resp.Header.Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(resp.Body) // reads the flushed header before the handler returns
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	got, _ := io.ReadAll(zr)
+	if !strings.HasSuffix(string(got), "chunk two") {
+		t.Fatalf("body = %q", got)
+	}
+}
@@ -1,17 +1,33 @@
 package middleware
 
 import (
-	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 )
 
 // CORSOptions configures the CORS middleware.
 type CORSOptions struct {
-	AllowedOrigins []string // e.g. ["https://app.example.com"] or ["*"]
+	// AllowedOrigins lists the origins that may call the API, e.g.
+	// ["https://app.example.com"]. "*" allows any origin and
+	// "https://*.example.com" any subdomain.
+	AllowedOrigins []string
 	AllowedMethods []string
 	AllowedHeaders []string
+	// ExposedHeaders lists response headers scripts may read, beyond the
+	// CORS-safelisted ones (e.g. "X-RateLimit-Remaining").
+	ExposedHeaders []string
 	MaxAge         int // seconds for preflight cache
+	// AllowCredentials lets browsers send cookies and Authorization. The
+	// request's origin is echoed instead of "*", as the spec requires, so
+	// AllowedOrigins must list the origins explicitly: combining it with
+	// "*" would let any site make credentialed calls, and CORS panics.
+	AllowCredentials bool
+	// Skip, when set, makes the middleware pass requests it returns true
+	// for straight through. The kernel uses it so routes with their own
+	// CORS middleware answer their own preflights.
+	Skip func(r *http.Request) bool
 }
 
 // DefaultCORSOptions returns permissive options suited for local development.
@@ -24,40 +40,114 @@ func DefaultCORSOptions() CORSOptions {
 	}
 }
 
-// CORS returns a middleware that adds Cross-Origin Resource Sharing headers.
+// CORS returns a middleware that adds Cross-Origin Resource Sharing headers
+// and answers preflight requests with 204.
+//
+// It can be attached globally, to a group or to a single route. On a group
+// or route, the router also registers OPTIONS for the route's path, so the
+// browser's preflight reaches it without going through the route's other
+// middleware (such as auth):
+//
+//	api.Get("/widgets", "widgets.index", list,
+//	    middleware.CORS(middleware.CORSOptions{
+//	        AllowedOrigins:   []string{"https://app.example.com"},
+//	        AllowedMethods:   []string{"GET"},
+//	        AllowCredentials: true,
+//	    }),
+//	    middleware.AuthMiddleware,
+//	)
 func CORS(opts CORSOptions) func(http.Handler) http.Handler {
-	methods := strings.Join(opts.AllowedMethods, ", ")
-	headers := strings.Join(opts.AllowedHeaders, ", ")
-
+	if opts.AllowCredentials && slices.Contains(opts.AllowedOrigins, "*") {
+		panic(`middleware: CORS: AllowCredentials cannot be combined with the "*" origin; list the allowed origins`)
+	}
+	c := &cors{
+		opts:    opts,
+		methods: strings.Join(opts.AllowedMethods, ", "),
+		headers: strings.Join(opts.AllowedHeaders, ", "),
+		exposed: strings.Join(opts.ExposedHeaders, ", "),
+	}
 	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			origin := r.Header.Get("Origin")
-
-			// Determine if origin is allowed.
-			allowed := ""
-			for _, o := range opts.AllowedOrigins {
-				if o == "*" || o == origin {
-					allowed = o
-					break
-				}
-			}
+		return &corsHandler{cors: c, next: next}
+	}
+}
+
+type cors struct {
+	opts                      CORSOptions
+	methods, headers, exposed string
+}
 
-			if allowed != "" {
-				w.Header().Set("Access-Control-Allow-Origin", allowed)
-				w.Header().Set("Access-Control-Allow-Methods", methods)
-				w.Header().Set("Access-Control-Allow-Headers", headers)
-				if opts.MaxAge > 0 {
-					w.Header().Set("Access-Control-Max-Age", fmt.Sprintf("%d", opts.MaxAge))
-				}
+// allowOrigin returns the Access-Control-Allow-Origin value for origin, or
+// "" when it is not allowed.
+func (c *cors) allowOrigin(origin string) string {
+	for _, o := range c.opts.AllowedOrigins {
+		switch {
+		case o == "*":
+			return "*"
+		case origin == "":
+			continue
+		case o == origin:
+			return origin
+		case strings.Contains(o, "*"):
+			prefix, suffix, _ := strings.Cut(o, "*")
+			if len(origin) > len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return origin
 			}
+		}
+	}
+	return ""
+}
 
-			// Handle preflight.
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusNoContent)
-				return
+// corsHandler is the handler CORS wraps around next. AnswersPreflight marks
+// it for the router (see router.PreflightHandler).
+type corsHandler struct {
+	*cors
+	next http.Handler
+}
+
+func (h *corsHandler) AnswersPreflight() {}
+
+func (h *corsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.opts.Skip != nil && h.opts.Skip(r) {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+	hdr := w.Header()
+	// An outer CORS middleware may already have answered for this request;
+	// the innermost (most specific) one wins.
+	for _, k := range []string{
+		"Access-Control-Allow-Origin", "Access-Control-Allow-Methods",
+		"Access-Control-Allow-Headers", "Access-Control-Allow-Credentials",
+		"Access-Control-Expose-Headers", "Access-Control-Max-Age",
+	} {
+		hdr.Del(k)
+	}
+
+	allowed := h.allowOrigin(r.Header.Get("Origin"))
+	if allowed != "*" && !strings.Contains(strings.Join(hdr.Values("Vary"), ","), "Origin") {
+		hdr.Add("Vary", "Origin")
+	}
+	if allowed != "" {
+		hdr.Set("Access-Control-Allow-Origin", allowed)
+		if h.opts.AllowCredentials {
+			hdr.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if preflight {
+			hdr.Set("Access-Control-Allow-Methods", h.methods)
+			hdr.Set("Access-Control-Allow-Headers", h.headers)
+			if h.opts.MaxAge > 0 {
+				hdr.Set("Access-Control-Max-Age", strconv.Itoa(h.opts.MaxAge))
 			}
+		} else if h.exposed != "" {
+			hdr.Set("Access-Control-Expose-Headers", h.exposed)
+		}
+	}
 
-			next.ServeHTTP(w, r)
-		})
+	if preflight {
+		w.WriteHeader(http.StatusNoContent)
+		return
 	}
+	h.next.ServeHTTP(w, r)
 }
@@ -0,0 +1,85 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+	"github.com/shashiranjanraj/kashvi/pkg/router"
+)
+
+func TestCORS_RouteLevelPreflight(t *testing.T) {
+	r := router.New()
+	global := middleware.DefaultCORSOptions()
+	global.Skip = r.HasRoutePreflight
+	r.Use(middleware.CORS(global))
+
+	denyAll := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	r.Get("/public", "public", ok)
+	r.Group("/api").Get("/widgets", "widgets", ok,
+		middleware.CORS(middleware.CORSOptions{
+			AllowedOrigins:   []string{"https://*.example.com"},
+			AllowedMethods:   []string{"GET"},
+			AllowedHeaders:   []string{"Authorization"},
+			ExposedHeaders:   []string{"X-Total"},
+			AllowCredentials: true,
+		}),
+		denyAll,
+	)
+
+	do := func(method, path, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", "GET")
+		}
+		rec := httptest.NewRecorder()
+		r.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Route-level preflight skips auth and uses the route's options.
+	rec := do(http.MethodOptions, "/api/widgets", "https://app.example.com")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight status = %d", rec.Code)
+	}
+	h := rec.Header()
+	if h.Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		h.Get("Access-Control-Allow-Credentials") != "true" ||
+		h.Get("Access-Control-Allow-Methods") != "GET" {
+		t.Fatalf("preflight headers = %v", h)
+	}
+	if rec := do(http.MethodOptions, "/api/widgets", "https://evil.test"); rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("disallowed origin got %q", rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	// The actual request carries the route's headers, not the global "*".
+	rec = do(http.MethodGet, "/api/widgets", "https://app.example.com")
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("actual request origin = %q", got)
+	}
+	if rec.Header().Get("Access-Control-Expose-Headers") != "X-Total" {
+		t.Fatalf("expose headers = %q", rec.Header().Get("Access-Control-Expose-Headers"))
+	}
+
+	// Other routes still get the global policy.
+	rec = do(http.MethodOptions, "/public", "https://anywhere.test")
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("global preflight = %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestCORS_CredentialsRejectAnyOrigin(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal(`CORS accepted AllowCredentials with the "*" origin`)
+		}
+	}()
+	middleware.CORS(middleware.CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/response"
+	"github.com/shashiranjanraj/kashvi/pkg/session"
+)
+
+// CSRF protects session-based (cookie) flows against cross-site request
+// forgery. It makes sure every session has a token (session.CSRFToken) and
+// rejects POST, PUT, PATCH and DELETE requests with 403 unless they echo it
+// in the X-CSRF-Token header or the _token form field.
+//
+// It needs session.Middleware in front of it (the kernel installs it);
+// without a session every request fails with 500, since a token kept
+// nowhere could never be checked.
+// Attach it to the routes serving forms, not to Bearer-token APIs:
+//
+//	web := r.Group("/", middleware.CSRF())
+//	web.Post("/profile", "profile.update", update)
+//
+// Uploads streamed with storage.SpoolUpload should send the header, since
+// reading the form field parses the whole body.
+func CSRF() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sess, ok := session.Lookup(r)
+			if !ok {
+				logger.WithCtx(r.Context()).Error("csrf: no session; add session.Middleware in front of middleware.CSRF",
+					"method", r.Method,
+					"path", r.URL.Path,
+				)
+				response.Error(w, http.StatusInternalServerError, "Internal Server Error")
+				return
+			}
+			if _, ok := sess.GetString(session.CSRFKey); !ok {
+				sess.CSRFToken()
+				if err := sess.Save(w); err != nil {
+					logger.Warn("csrf: could not save session", "error", err)
+				}
+			}
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sent := r.Header.Get(session.CSRFHeader)
+			if sent == "" {
+				sent = r.FormValue(session.CSRFField)
+			}
+			want := sess.CSRFToken()
+			if sent == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(want)) != 1 {
+				response.Error(w, http.StatusForbidden, "CSRF token mismatch")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
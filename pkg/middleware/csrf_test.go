@@ -0,0 +1,70 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+	"github.com/shashiranjanraj/kashvi/pkg/session"
+)
+
+func TestCSRF(t *testing.T) {
+	const token = "known-token"
+	var issued string
+	h := session.Middleware(session.DefaultOptions())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Stand in for a session loaded from the store.
+			if r.Header.Get("X-Test-Session") != "" {
+				session.FromCtx(r).Set(session.CSRFKey, token)
+			}
+			middleware.CSRF()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				issued = session.CSRFToken(r)
+			})).ServeHTTP(w, r)
+		}))
+	do := func(req *http.Request) int {
+		req.Header.Set("X-Test-Session", "1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Safe methods pass and a fresh session gets a token.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || len(issued) != 64 {
+		t.Fatalf("GET: %d, token %q", rec.Code, issued)
+	}
+
+	if code := do(httptest.NewRequest(http.MethodPost, "/", nil)); code != http.StatusForbidden {
+		t.Fatalf("POST without token = %d", code)
+	}
+	bad := httptest.NewRequest(http.MethodPost, "/", nil)
+	bad.Header.Set(session.CSRFHeader, "guess")
+	if code := do(bad); code != http.StatusForbidden {
+		t.Fatalf("POST with wrong token = %d", code)
+	}
+
+	hdr := httptest.NewRequest(http.MethodDelete, "/", nil)
+	hdr.Header.Set(session.CSRFHeader, token)
+	if code := do(hdr); code != http.StatusOK {
+		t.Fatalf("DELETE with header token = %d", code)
+	}
+	form := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{session.CSRFField: {token}}.Encode()))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if code := do(form); code != http.StatusOK {
+		t.Fatalf("POST with form token = %d", code)
+	}
+}
+
+func TestCSRFWithoutSessionMiddleware(t *testing.T) {
+	ran := false
+	h := middleware.CSRF()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { ran = true }))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError || ran {
+		t.Fatalf("status = %d, handler ran = %v", rec.Code, ran)
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/shashiranjanraj/kashvi/pkg/profiler"
+	"github.com/shashiranjanraj/kashvi/pkg/reqid"
+)
+
+// sensitiveHeaders are masked on the development error page.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+	"X-Api-Key":           true,
+	"X-Csrf-Token":        true,
+}
+
+// wantsHTML reports whether the client is a browser asking for a page rather
+// than an API client expecting the JSON envelope.
+func wantsHTML(r *http.Request) bool {
+	if r.Header.Get("X-Requested-With") == "XMLHttpRequest" {
+		return false
+	}
+	accept := r.Header.Get("Accept")
+	html := strings.Index(accept, "text/html")
+	if html < 0 {
+		return false
+	}
+	// "application/json, text/html" — JSON listed first wins.
+	json := strings.Index(accept, "application/json")
+	return json < 0 || html < json
+}
+
+type devErrorData struct {
+	Error     string
+	Type      string
+	Stack     string
+	Method    string
+	URL       string
+	RequestID string
+	Headers   [][2]string
+	Queries   []profiler.Query
+	Logs      []profiler.LogEntry
+	Dropped   int
+}
+
+// renderDevError writes the development error page for a recovered panic.
+func renderDevError(w http.ResponseWriter, r *http.Request, rec any, stack []byte) {
+	data := devErrorData{
+		Error:     fmt.Sprintf("%v", rec),
+		Type:      fmt.Sprintf("%T", rec),
+		Stack:     string(stack),
+		Method:    r.Method,
+		URL:       r.URL.String(),
+		RequestID: reqid.FromCtx(r.Context()),
+	}
+	for k, v := range r.Header {
+		val := strings.Join(v, ", ")
+		if sensitiveHeaders[k] {
+			val = "••••••"
+		}
+		data.Headers = append(data.Headers, [2]string{k, val})
+	}
+	sort.Slice(data.Headers, func(i, j int) bool { return data.Headers[i][0] < data.Headers[j][0] })
+
+	if p := profiler.FromContext(r.Context()); p != nil {
+		data.Queries = p.Queries()
+		data.Logs = p.Logs()
+		data.Dropped = p.Dropped()
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusInternalServerError)
+	devErrorTmpl.Execute(w, data) //nolint:errcheck
+}
+
+var devErrorTmpl = template.Must(template.New("error").Parse(`<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Error}}</title>
+<style>
+body{font:14px/1.5 -apple-system,BlinkMacSystemFont,"Segoe UI",sans-serif;margin:0;background:#f6f7f9;color:#1f2328}
+header{background:#b42318;color:#fff;padding:24px 32px}
+header h1{margin:0 0 4px;font-size:20px;word-break:break-word}
+header p{margin:0;opacity:.85}
+section{background:#fff;margin:16px 32px;padding:16px 20px;border-radius:6px;box-shadow:0 1px 2px rgba(0,0,0,.08)}
+h2{font-size:15px;margin:0 0 12px}
+pre{margin:0;overflow:auto;font:12px/1.45 ui-monospace,Menlo,Consolas,monospace;white-space:pre-wrap}
+table{border-collapse:collapse;width:100%}
+td,th{text-align:left;vertical-align:top;padding:4px 8px;border-bottom:1px solid #eee;font-size:13px}
+th{width:1%;white-space:nowrap;color:#57606a}
+code{font:12px ui-monospace,Menlo,Consolas,monospace}
+.err{color:#b42318}
+.muted{color:#57606a}
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Error}}</h1>
+<p>{{.Type}} · {{.Method}} {{.URL}}{{if .RequestID}} · request {{.RequestID}}{{end}}</p>
+</header>
+
+<section>
+<h2>Stack trace</h2>
+<pre>{{.Stack}}</pre>
+</section>
+
+<section>
+<h2>Queries ({{len .Queries}})</h2>
+{{if .Queries}}<table>
+{{range .Queries}}<tr><th>{{.Duration}}</th><td><code>{{.SQL}}</code>{{if .Error}}<div class="err">{{.Error}}</div>{{end}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No queries recorded. Queries are captured when built with c.DB() or orm.WithCtx(r.Context()).</p>{{end}}
+</section>
+
+<section>
+<h2>Logs ({{len .Logs}})</h2>
+{{if .Logs}}<table>
+{{range .Logs}}<tr><th>{{.Level}}</th><td>{{.Message}}{{range $k, $v := .Attrs}} <code class="muted">{{$k}}={{$v}}</code>{{end}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No logs recorded.</p>{{end}}
+{{if .Dropped}}<p class="muted">{{.Dropped}} further entries were dropped.</p>{{end}}
+</section>
+
+<section>
+<h2>Request headers</h2>
+<table>
+{{range .Headers}}<tr><th>{{index . 0}}</th><td><code>{{index . 1}}</code></td></tr>
+{{end}}</table>
+</section>
+</body>
+</html>
+`))
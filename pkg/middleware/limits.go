@@ -0,0 +1,429 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/response"
+)
+
+// ─── Body size limit ─────────────────────────────────────────────────────────
+
+// DefaultMaxBodyBytes reads HTTP_MAX_BODY_BYTES (default 32 MB, the same as
+// UPLOAD_MAX_BYTES so uploads bound by bind keep working).
+func DefaultMaxBodyBytes() int64 {
+	return int64(envInt("HTTP_MAX_BODY_BYTES", 32<<20))
+}
+
+// BodyLimit caps request bodies at n bytes; n <= 0 removes the cap. Reading
+// past it fails with *http.MaxBytesError, which bind reports as "request
+// body too large". A body whose Content-Length is already over the limit
+// fails on the first read, before any of it is received.
+//
+// The kernel applies DefaultMaxBodyBytes to every request. A route- or
+// group-level BodyLimit replaces that limit for its routes, so it can raise
+// it as well as lower it:
+//
+//	r.Post("/videos", "videos.store", upload, middleware.BodyLimit(2<<30))
+func BodyLimit(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if l, ok := r.Context().Value(bodyLimitKey{}).(*limitedBody); ok {
+				l.setLimit(n) // innermost limit wins
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			l := &limitedBody{rc: r.Body, limit: n, declared: r.ContentLength}
+			r = r.WithContext(context.WithValue(r.Context(), bodyLimitKey{}, l))
+			r.Body = l
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type bodyLimitKey struct{}
+
+// limitedBody is http.MaxBytesReader with a limit that can change until the
+// body is read.
+type limitedBody struct {
+	mu       sync.Mutex
+	rc       io.ReadCloser
+	limit    int64
+	declared int64 // Content-Length, -1 if unknown
+	read     int64
+	err      error
+}
+
+func (l *limitedBody) setLimit(n int64) {
+	l.mu.Lock()
+	l.limit = n
+	l.mu.Unlock()
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return 0, l.err
+	}
+	if l.limit > 0 {
+		if l.declared > l.limit {
+			l.err = &http.MaxBytesError{Limit: l.limit}
+			return 0, l.err
+		}
+		// Read one byte past the limit so an exact fit is not an error.
+		if left := l.limit - l.read + 1; int64(len(p)) > left {
+			p = p[:left]
+		}
+	}
+	n, err := l.rc.Read(p)
+	l.read += int64(n)
+	if l.limit > 0 && l.read > l.limit {
+		l.err = &http.MaxBytesError{Limit: l.limit}
+		return n - int(l.read-l.limit), l.err
+	}
+	return n, err
+}
+
+func (l *limitedBody) Close() error { return l.rc.Close() }
+
+// ─── Handler timeout ─────────────────────────────────────────────────────────
+
+// TimeoutOptions configures TimeoutWith.
+type TimeoutOptions struct {
+	// Timeout is how long a handler may run. 0 disables the timeout.
+	Timeout time.Duration
+	// Skip, when set, passes requests it returns true for straight through.
+	// DefaultTimeoutOptions skips WebSocket upgrades and SSE streams, which
+	// are long-lived and cannot be buffered.
+	Skip func(r *http.Request) bool
+}
+
+// DefaultTimeoutOptions reads HTTP_HANDLER_TIMEOUT (default 10s, the
+// server's write timeout).
+func DefaultTimeoutOptions() TimeoutOptions {
+	return TimeoutOptions{
+		Timeout: envDuration("HTTP_HANDLER_TIMEOUT", 10*time.Second),
+		Skip:    isStreaming,
+	}
+}
+
+// isStreaming reports whether r opens a WebSocket or an SSE stream.
+func isStreaming(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// Timeout limits handlers to d, for use on a route or group. Like the
+// defaults, it passes WebSocket upgrades and SSE streams straight through.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return TimeoutWith(TimeoutOptions{Timeout: d, Skip: isStreaming})
+}
+
+// TimeoutWith works like http.TimeoutHandler: the handler runs with a
+// context that is cancelled after opts.Timeout (context.Cause reports
+// context.DeadlineExceeded), and its response is buffered. If it is not
+// done in time, the client gets 503 with the JSON error envelope and
+// anything the handler writes afterwards is discarded. Handlers that do
+// slow work should watch r.Context().
+//
+// The kernel applies DefaultTimeoutOptions to every request. A route- or
+// group-level Timeout replaces that budget, counted from when it runs, and
+// extends the connection's read and write deadlines to match, so slow
+// exports and uploads can run longer than the server's defaults:
+//
+//	r.Get("/reports/export", "reports.export", export, middleware.Timeout(2*time.Minute))
+//
+// Responses are held in memory until the handler returns or flushes. The
+// first Flush (or Hijack) sends what was buffered, stops the budget and
+// passes everything after it straight through, so SSE and chunked streams
+// still work when they are not skipped. For routes that stream without
+// flushing, such as large downloads, use NoTimeout. A panic in the handler
+// is re-raised on the request's goroutine with its original stack, which
+// Recovery logs and reports.
+func TimeoutWith(opts TimeoutOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Skip != nil && opts.Skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if s, ok := r.Context().Value(timeoutKey{}).(*timeoutScope); ok {
+				// An outer TimeoutWith is already timing this request; the
+				// innermost budget wins.
+				s.reset(opts.Timeout, true)
+				next.ServeHTTP(w, r)
+				return
+			}
+			serveWithTimeout(w, r, next, opts.Timeout)
+		})
+	}
+}
+
+// NoTimeout lifts an enclosing TimeoutWith, such as the kernel's, for a
+// route or group: the budget stops, the connection's deadlines are cleared
+// and the response streams instead of being buffered. Use it for large
+// downloads and long exports:
+//
+//	r.Get("/exports/orders.ndjson", "orders.export", export, middleware.NoTimeout())
+func NoTimeout() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s, ok := r.Context().Value(timeoutKey{}).(*timeoutScope); ok {
+				s.tw.mu.Lock()
+				s.tw.stream(true)
+				s.tw.mu.Unlock()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type timeoutKey struct{}
+
+// timeoutScope is the deadline of one request. Inner Timeout middleware
+// move it through reset.
+type timeoutScope struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	expired chan struct{}
+	cancel  context.CancelCauseFunc
+	rc      *http.ResponseController
+	tw      *timeoutWriter
+}
+
+// reset restarts the budget at d from now; d <= 0 stops it. The
+// connection's write deadline follows, and with extendConn (route-level
+// budgets) so does its read deadline.
+func (s *timeoutScope) reset(d time.Duration, extendConn bool) {
+	if !s.stop() {
+		return // already expired
+	}
+	var deadline time.Time // zero: no deadline
+	if d > 0 {
+		s.mu.Lock()
+		s.timer = time.AfterFunc(d, s.expire)
+		s.mu.Unlock()
+		deadline = time.Now().Add(d + time.Second) // room to write the 503
+	}
+	s.rc.SetWriteDeadline(deadline) //nolint:errcheck // unsupported writers keep the server's
+	if extendConn {
+		s.rc.SetReadDeadline(deadline) //nolint:errcheck
+	}
+}
+
+// stop stops the budget and reports whether it had not expired yet.
+func (s *timeoutScope) stop() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil && !s.timer.Stop() {
+		return false
+	}
+	s.timer = nil
+	return true
+}
+
+func (s *timeoutScope) expire() {
+	s.cancel(context.DeadlineExceeded)
+	close(s.expired)
+}
+
+func serveWithTimeout(w http.ResponseWriter, r *http.Request, next http.Handler, d time.Duration) {
+	if d <= 0 {
+		next.ServeHTTP(w, r)
+		return
+	}
+	ctx, cancel := context.WithCancelCause(r.Context())
+	defer cancel(nil)
+	tw := &timeoutWriter{w: w, h: make(http.Header)}
+	s := &timeoutScope{expired: make(chan struct{}), cancel: cancel, rc: http.NewResponseController(w), tw: tw}
+	tw.s = s
+	s.reset(d, false)
+	r = r.WithContext(context.WithValue(ctx, timeoutKey{}, s))
+
+	done := make(chan struct{})
+	panicked := make(chan any, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				if p != http.ErrAbortHandler {
+					p = &handlerPanic{value: p, stack: debug.Stack()}
+				}
+				panicked <- p
+			}
+		}()
+		next.ServeHTTP(tw, r)
+		close(done)
+	}()
+
+	var timedOut bool
+	select {
+	case p := <-panicked:
+		panic(p) // let Recovery, outside this middleware, report it
+	case <-done:
+		timedOut = !s.stop() // expired just as the handler returned
+	case <-s.expired:
+		timedOut = true
+	}
+
+	tw.mu.Lock()
+	if tw.streaming {
+		// The handler writes to w itself, so it must finish first; a budget
+		// that expires now only cancels its context.
+		tw.mu.Unlock()
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+		}
+		return
+	}
+	defer tw.mu.Unlock()
+	if timedOut {
+		tw.timedOut = true
+		logger.WithCtx(r.Context()).Warn("request timed out",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"timeout", d.String(),
+		)
+		response.Error(w, http.StatusServiceUnavailable, "Request timed out")
+		return
+	}
+	dst := w.Header()
+	for k, vv := range tw.h {
+		dst[k] = vv
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	w.WriteHeader(tw.code)
+	w.Write(tw.buf.Bytes()) //nolint:errcheck
+}
+
+// handlerPanic carries a panic from the handler's goroutine to the
+// request's, together with the stack where it happened.
+type handlerPanic struct {
+	value any
+	stack []byte
+}
+
+func (p *handlerPanic) Error() string { return fmt.Sprint(p.value) }
+
+// timeoutWriter buffers the handler's response until it finishes in time,
+// or until it starts streaming.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	w           http.ResponseWriter
+	s           *timeoutScope
+	h           http.Header
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	timedOut    bool
+	streaming   bool // writes go straight to w
+}
+
+// stream stops the budget and sends what was buffered; later writes go
+// straight to the client. It reports false if the request has already
+// timed out. extendConn also clears the read deadline. tw.mu must be held.
+func (tw *timeoutWriter) stream(extendConn bool) bool {
+	if tw.streaming {
+		return true
+	}
+	if tw.timedOut || !tw.s.stop() {
+		return false
+	}
+	tw.s.rc.SetWriteDeadline(time.Time{}) //nolint:errcheck // unsupported writers keep the server's
+	if extendConn {
+		tw.s.rc.SetReadDeadline(time.Time{}) //nolint:errcheck
+	}
+	dst := tw.w.Header()
+	for k, vv := range tw.h {
+		dst[k] = vv
+	}
+	tw.h = dst
+	tw.streaming = true
+	if tw.wroteHeader {
+		tw.w.WriteHeader(tw.code)
+		tw.w.Write(tw.buf.Bytes()) //nolint:errcheck
+		tw.buf.Reset()
+	}
+	return true
+}
+
+// Flush starts streaming the response and flushes it to the client.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.stream(false) {
+		return
+	}
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		tw.code = http.StatusOK
+		tw.w.WriteHeader(tw.code)
+	}
+	tw.s.rc.Flush() //nolint:errcheck
+}
+
+// Hijack hands the connection to the handler, stopping the budget. It
+// fails once a response has been started.
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.wroteHeader && !tw.streaming {
+		return nil, nil, http.ErrHijacked
+	}
+	if !tw.stream(false) {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	return tw.s.rc.Hijack()
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.h }
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	if code < 100 || code > 999 {
+		panic(fmt.Sprintf("invalid WriteHeader code %v", code))
+	}
+	tw.wroteHeader = true
+	tw.code = code
+	if tw.streaming {
+		tw.w.WriteHeader(code)
+	}
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		tw.code = http.StatusOK
+	}
+	if tw.streaming {
+		return tw.w.Write(p)
+	}
+	return tw.buf.Write(p)
+}
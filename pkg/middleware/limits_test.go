@@ -0,0 +1,195 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	kerrors "github.com/shashiranjanraj/kashvi/pkg/errors"
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+)
+
+func TestBodyLimit(t *testing.T) {
+	read := func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Write(b)
+	}
+	global := middleware.BodyLimit(8)
+
+	cases := []struct {
+		name    string
+		h       http.Handler
+		body    string
+		chunked bool
+		want    int
+	}{
+		{"fits", global(http.HandlerFunc(read)), "12345678", false, 200},
+		{"declared too large", global(http.HandlerFunc(read)), "123456789", false, 413},
+		{"streamed too large", global(http.HandlerFunc(read)), "123456789", true, 413},
+		{"route raises", global(middleware.BodyLimit(16)(http.HandlerFunc(read))), "123456789", false, 200},
+		{"route lowers", global(middleware.BodyLimit(4)(http.HandlerFunc(read))), "12345", true, 413},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			if tc.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			tc.h.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.want)
+			}
+			if tc.want == 200 && rec.Body.String() != tc.body {
+				t.Fatalf("body = %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	slow := func(d time.Duration) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(d):
+				w.Header().Set("X-Done", "1")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("done"))
+			case <-r.Context().Done():
+				w.Write([]byte("late"))
+			}
+		})
+	}
+	global := middleware.TimeoutWith(middleware.DefaultTimeoutOptions())
+	short := middleware.Timeout(20 * time.Millisecond)
+
+	serve := func(h http.Handler, hdr ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for i := 0; i+1 < len(hdr); i += 2 {
+			req.Header.Set(hdr[i], hdr[i+1])
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(short(slow(0)))
+	if rec.Code != http.StatusCreated || rec.Header().Get("X-Done") != "1" || rec.Body.String() != "done" {
+		t.Fatalf("fast handler: %d %v %q", rec.Code, rec.Header(), rec.Body.String())
+	}
+
+	rec = serve(short(slow(time.Second)))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"Request timed out"`) {
+		t.Fatalf("slow handler: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// A route-level Timeout replaces the outer budget, both ways.
+	if rec := serve(short(middleware.Timeout(200 * time.Millisecond)(slow(50 * time.Millisecond)))); rec.Code != http.StatusCreated {
+		t.Fatalf("raised budget: %d", rec.Code)
+	}
+	if rec := serve(global(short(slow(time.Second)))); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("lowered budget: %d", rec.Code)
+	}
+
+	// Streams are not buffered or timed by the defaults.
+	stream := middleware.TimeoutWith(middleware.TimeoutOptions{
+		Timeout: 10 * time.Millisecond,
+		Skip:    middleware.DefaultTimeoutOptions().Skip,
+	})
+	if rec := serve(stream(slow(50*time.Millisecond)), "Accept", "text/event-stream"); rec.Code != http.StatusCreated {
+		t.Fatalf("SSE: %d", rec.Code)
+	}
+}
+
+func TestTimeoutPanicReachesRecovery(t *testing.T) {
+	var got kerrors.Event
+	kerrors.SetReporter(kerrors.ReporterFunc(func(_ context.Context, e kerrors.Event) { got = e }))
+	t.Cleanup(func() { kerrors.SetReporter(nil) })
+
+	h := middleware.Recovery(middleware.Timeout(time.Second)(http.HandlerFunc(panicky)))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if got.Panic != "boom" {
+		t.Fatalf("reported panic = %#v, want the handler's value", got.Panic)
+	}
+	if !strings.Contains(string(got.Stack), "middleware_test.panicky") {
+		t.Fatalf("reported stack does not reach the handler:\n%s", got.Stack)
+	}
+}
+
+func TestTimeoutSkipsStreams(t *testing.T) {
+	var got http.ResponseWriter
+	h := middleware.Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = w
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got != http.ResponseWriter(rec) {
+		t.Fatal("SSE handler got the buffering writer")
+	}
+}
+
+func TestTimeoutStreamsOnceFlushed(t *testing.T) {
+	h := middleware.Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		io.WriteString(w, "{\"n\":1}\n") //nolint:errcheck
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond) // past the budget, but already streaming
+		io.WriteString(w, "{\"n\":2}\n")  //nolint:errcheck
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "{\"n\":1}\n{\"n\":2}\n" || !rec.Flushed {
+		t.Fatalf("got %d %q flushed=%v", rec.Code, rec.Body.String(), rec.Flushed)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+}
+
+func TestNoTimeout(t *testing.T) {
+	global := middleware.TimeoutWith(middleware.TimeoutOptions{Timeout: 20 * time.Millisecond})
+	h := global(middleware.NoTimeout()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := range 3 {
+			time.Sleep(10 * time.Millisecond)
+			fmt.Fprintf(w, "chunk %d;", i)
+		}
+	})))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/exports/orders.ndjson", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "chunk 0;chunk 1;chunk 2;" {
+		t.Fatalf("got %d %q, want the whole response past the global budget", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTimeoutForwardsHijack(t *testing.T) {
+	srv := httptest.NewServer(middleware.Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		conn.Close() // drop the connection, as Chaos does
+	})))
+	defer srv.Close()
+	if resp, err := http.Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Fatalf("got %d, want the connection dropped", resp.StatusCode)
+	}
+}
@@ -1,31 +1,24 @@
 package middleware
 
 import (
+	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/shashiranjanraj/kashvi/pkg/clientip"
 	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/profiler"
 	"github.com/shashiranjanraj/kashvi/pkg/reqid"
+	"github.com/shashiranjanraj/kashvi/pkg/respwriter"
 )
 
-// responseWriter wraps http.ResponseWriter to capture the status code.
-type responseWriter struct {
-	http.ResponseWriter
-	statusCode int
-}
-
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
-	rw.ResponseWriter.WriteHeader(code)
-}
-
 // Logger logs each request with method, path, status, duration, IP, and
-// the unique request_id injected by reqid.Middleware.
+// the unique request_id injected by RequestID.
 //
-// Wire reqid.Middleware() BEFORE this middleware so the ID is available
+// Wire RequestID() BEFORE this middleware so the ID is available
 // in the context when Logger runs.
 //
-//	r.Use(reqid.Middleware())
+//	r.Use(middleware.RequestID())
 //	r.Use(middleware.Logger)
 func Logger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -35,18 +28,23 @@ func Logger(next http.Handler) http.Handler {
 		// Build a per-request logger pre-tagged with the request_id.
 		// Every downstream call to logger.WithCtx(ctx) returns this logger.
 		reqLog := logger.L.With("request_id", rid)
+		if p := profiler.FromContext(r.Context()); p != nil {
+			// Development: mirror this request's log lines into the profiler.
+			reqLog = slog.New(profiler.Bind(logger.L.Handler(), p)).With("request_id", rid)
+		}
 		ctx := logger.InjectLogger(r.Context(), reqLog)
 		r = r.WithContext(ctx)
 
-		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		rw := respwriter.Wrap(w)
 		next.ServeHTTP(rw, r)
 
 		reqLog.Info("request",
 			"method", r.Method,
 			"path", r.URL.Path,
-			"status", rw.statusCode,
+			"status", rw.Status(),
+			"bytes", rw.Size(),
 			"duration", time.Since(start).String(),
-			"ip", r.RemoteAddr,
+			"ip", clientip.String(r),
 		)
 	})
 }
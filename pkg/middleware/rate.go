@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/clientip"
 )
 
 // bucket tracks a sliding-window request count for one IP.
@@ -73,10 +75,9 @@ func getBucket(ip string) *bucket {
 func RateLimit(max int, window time.Duration) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ip := r.RemoteAddr
-			if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
-				ip = fwd
-			}
+			// Keyed on the resolved client address; clients cannot pick
+			// their own bucket by forging X-Forwarded-For.
+			ip := clientip.String(r)
 
 			if !getBucket(ip).allow(max, window) {
 				http.Error(w, `{"status":429,"message":"Too Many Requests"}`, http.StatusTooManyRequests)
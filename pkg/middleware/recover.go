@@ -5,33 +5,78 @@ import (
 	"net/http"
 	"runtime/debug"
 
+	"github.com/go-chi/chi/v5"
+
+	kerrors "github.com/shashiranjanraj/kashvi/pkg/errors"
 	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/metrics"
+	"github.com/shashiranjanraj/kashvi/pkg/profiler"
 	"github.com/shashiranjanraj/kashvi/pkg/response"
 )
 
-// Recovery catches any panic in downstream handlers, logs the stack trace,
-// and returns a 500 Internal Server Error to the client.
+// Recovery catches any panic in downstream handlers, logs the stack trace
+// with the request's logger (so the line carries request_id), counts it in
+// kashvi_http_panics_recovered_total, hands it to the errors.Reporter
+// registered at boot (Sentry and the like) and returns a 500 Internal
+// Server Error to the client.
+//
+// In development (see profiler.Enabled) browser requests get an HTML page
+// with the stack trace, request headers and the queries/logs recorded by the
+// profiler; API clients keep receiving the JSON envelope.
+// http.ErrAbortHandler is re-panicked so net/http aborts the response
+// quietly, as it expects.
 // Always add this as the innermost middleware (last in the chain) so it wraps
 // all other middleware and handlers.
 //
 //	r.Use(metrics.Middleware())
-//	r.Use(reqid.Middleware())
-//	r.Use(middleware.Recovery)   // ← catches panics from all below
+//	r.Use(middleware.RequestID())
 //	r.Use(middleware.Logger)
+//	r.Use(middleware.Recovery)   // ← catches panics from all below
 func Recovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
-			if err := recover(); err != nil {
-				stack := debug.Stack()
-				logger.Error("panic recovered",
-					"error", fmt.Sprintf("%v", err),
-					"stack", string(stack),
-					"method", r.Method,
-					"path", r.URL.Path,
-				)
-				response.Error(w, http.StatusInternalServerError, "Internal Server Error")
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
 			}
+			stack := debug.Stack()
+			if hp, ok := err.(*handlerPanic); ok {
+				// Raised again by Timeout; report where it really happened.
+				err, stack = hp.value, hp.stack
+			}
+			logger.WithCtx(r.Context()).Error("panic recovered",
+				"error", fmt.Sprintf("%v", err),
+				"stack", string(stack),
+				"method", r.Method,
+				"path", r.URL.Path,
+			)
+			metrics.PanicsRecovered.WithLabelValues(panicRoute(r)).Inc()
+			kerrors.Report(r.Context(), kerrors.Event{
+				Panic:  err,
+				Stack:  stack,
+				Method: r.Method,
+				Path:   r.URL.Path,
+			})
+			if profiler.Enabled() && wantsHTML(r) {
+				renderDevError(w, r, err, stack)
+				return
+			}
+			response.Error(w, http.StatusInternalServerError, "Internal Server Error")
 		}()
 		next.ServeHTTP(w, r)
 	})
 }
+
+// panicRoute is the matched route pattern, or "unmatched" (bounded label
+// cardinality, as for the validation metrics).
+func panicRoute(r *http.Request) string {
+	if rc := chi.RouteContext(r.Context()); rc != nil {
+		if p := rc.RoutePattern(); p != "" {
+			return r.Method + " " + p
+		}
+	}
+	return "unmatched"
+}
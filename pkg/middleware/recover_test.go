@@ -0,0 +1,96 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	kerrors "github.com/shashiranjanraj/kashvi/pkg/errors"
+	"github.com/shashiranjanraj/kashvi/pkg/metrics"
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+	"github.com/shashiranjanraj/kashvi/pkg/profiler"
+	"github.com/shashiranjanraj/kashvi/pkg/reqid"
+)
+
+func panicky(w http.ResponseWriter, r *http.Request) { panic("boom") }
+
+func TestRecoveryDevPageForBrowsers(t *testing.T) {
+	if !profiler.Enabled() {
+		t.Skip("dev error page requires APP_ENV=local")
+	}
+	h := profiler.Middleware(middleware.Recovery(http.HandlerFunc(panicky)))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") || !strings.Contains(body, "boom") {
+		t.Fatalf("expected HTML error page, got %q", rec.Header().Get("Content-Type"))
+	}
+	if strings.Contains(body, "secret-token") {
+		t.Error("Authorization header must be masked")
+	}
+}
+
+func TestRecoveryKeepsJSONForAPIClients(t *testing.T) {
+	h := middleware.Recovery(http.HandlerFunc(panicky))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", ct)
+	}
+	if !strings.Contains(rec.Body.String(), `"Internal Server Error"`) {
+		t.Errorf("unexpected body %s", rec.Body.String())
+	}
+}
+
+func TestRecoveryReportsPanics(t *testing.T) {
+	var got []kerrors.Event
+	kerrors.SetReporter(kerrors.ReporterFunc(func(_ context.Context, e kerrors.Event) { got = append(got, e) }))
+	t.Cleanup(func() { kerrors.SetReporter(nil) })
+	before := testutil.ToFloat64(metrics.PanicsRecovered.WithLabelValues("unmatched"))
+
+	h := reqid.Middleware()(middleware.Recovery(http.HandlerFunc(panicky)))
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set(reqid.Header, "rid-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if len(got) != 1 {
+		t.Fatalf("reported %d events", len(got))
+	}
+	e := got[0]
+	if e.Panic != "boom" || e.Err == nil || e.RequestID != "rid-1" || e.Method != "POST" || e.Path != "/orders" || len(e.Stack) == 0 {
+		t.Errorf("event = %+v", e)
+	}
+	if d := testutil.ToFloat64(metrics.PanicsRecovered.WithLabelValues("unmatched")) - before; d != 1 {
+		t.Errorf("panics counter moved by %v", d)
+	}
+}
+
+func TestRecoveryRepanicsAbortHandler(t *testing.T) {
+	h := middleware.Recovery(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic(http.ErrAbortHandler) }))
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Fatalf("recovered %v, want http.ErrAbortHandler", p)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/reqid"
+)
+
+// maxRequestIDLen bounds client-supplied IDs; longer ones are replaced.
+const maxRequestIDLen = 128
+
+// RequestID gives every request an ID: the client's X-Request-ID when it
+// sends a usable one (up to 128 printable ASCII characters), otherwise a new
+// one. The ID is
+//
+//   - stored in the context for reqid.FromCtx,
+//   - attached to a logger injected with logger.InjectLogger, so
+//     logger.WithCtx lines carry request_id before Logger runs,
+//   - echoed in the X-Request-ID response header, and
+//   - forwarded on outgoing pkg/http calls made with the request's context.
+//
+// The kernel installs it ahead of everything that logs:
+//
+//	r.Use(middleware.RequestID())
+//	r.Use(middleware.Logger)
+func RequestID() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(reqid.Header)
+			if !validRequestID(id) {
+				id = reqid.New()
+			}
+			w.Header().Set(reqid.Header, id)
+
+			ctx := reqid.WithValue(r.Context(), id)
+			ctx = logger.InjectLogger(ctx, logger.L.With("request_id", id))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// validRequestID rejects empty, oversized and non-printable IDs, which would
+// otherwise end up verbatim in logs and response headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if c := id[i]; c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
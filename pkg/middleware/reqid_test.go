@@ -0,0 +1,59 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+	"github.com/shashiranjanraj/kashvi/pkg/reqid"
+)
+
+func TestRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	prev := logger.L
+	logger.L = slog.New(slog.NewTextHandler(&buf, nil))
+	t.Cleanup(func() { logger.L = prev })
+
+	var seen string
+	h := middleware.RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = reqid.FromCtx(r.Context())
+		logger.WithCtx(r.Context()).Info("handled")
+	}))
+
+	cases := []struct {
+		name, in string
+		reused   bool
+	}{
+		{"reuses client ID", "edge-7f3a", true},
+		{"generates when missing", "", false},
+		{"replaces unprintable ID", "bad\nid", false},
+		{"replaces oversized ID", strings.Repeat("x", 200), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.in != "" {
+				req.Header.Set(reqid.Header, tc.in)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			out := rec.Header().Get(reqid.Header)
+			if out == "" || out != seen {
+				t.Fatalf("header %q, context %q", out, seen)
+			}
+			if (out == tc.in) != tc.reused {
+				t.Errorf("ID = %q, reused = %v, want %v", out, out == tc.in, tc.reused)
+			}
+			if !strings.Contains(buf.String(), "request_id="+out) {
+				t.Errorf("log line not tagged: %s", buf.String())
+			}
+		})
+	}
+}
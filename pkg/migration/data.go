@@ -0,0 +1,224 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/metrics"
+)
+
+// ------------------- Data migrations -------------------
+
+// Batch is handed to a DataFunc once per iteration.
+type Batch struct {
+	// DB is the transaction for this batch. The checkpoint is committed in
+	// the same transaction, so a batch is either fully applied or retried.
+	DB *gorm.DB
+	// Cursor is the value passed to Advance by the previous batch (0 at start).
+	Cursor int64
+	// Size is the configured batch size.
+	Size int
+	// Number counts batches from 1 across resumptions.
+	Number int
+
+	next      int64
+	processed int
+	advanced  bool
+}
+
+// Advance records how many rows this batch handled and the cursor the next
+// batch should start after (usually the last primary key seen). Calling it
+// with processed == 0, or not calling it at all, marks the migration done.
+func (b *Batch) Advance(processed int, cursor int64) {
+	b.processed, b.next, b.advanced = processed, cursor, true
+}
+
+// DataFunc processes one batch.
+type DataFunc func(b *Batch) error
+
+// DataMigration is a long-running, resumable migration that works through a
+// table in batches. It implements Migration, so register it like any other:
+//
+//	migration.Register("20240301000000_backfill_slugs",
+//	    migration.Data("backfill_slugs", 500, func(b *migration.Batch) error {
+//	        var posts []models.Post
+//	        if err := b.DB.Where("id > ?", b.Cursor).Order("id").Limit(b.Size).Find(&posts).Error; err != nil {
+//	            return err
+//	        }
+//	        for _, p := range posts {
+//	            b.DB.Model(&p).Update("slug", slugify(p.Title))
+//	        }
+//	        if len(posts) > 0 {
+//	            b.Advance(len(posts), int64(posts[len(posts)-1].ID))
+//	        }
+//	        return nil
+//	    }).Throttle(50*time.Millisecond))
+//
+// Progress is checkpointed in kashvi_data_migrations after every batch; if the
+// process is interrupted, the next `kashvi migrate` resumes from the last
+// committed cursor.
+type DataMigration struct {
+	name      string
+	batchSize int
+	fn        DataFunc
+	throttle  time.Duration
+	total     func(db *gorm.DB) (int64, error)
+	down      func(db *gorm.DB) error
+	ctx       context.Context
+}
+
+// Data creates a batched data migration.
+func Data(name string, batchSize int, fn DataFunc) *DataMigration {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	return &DataMigration{name: name, batchSize: batchSize, fn: fn, ctx: context.Background()}
+}
+
+// Throttle pauses d between batches to limit load on the database.
+func (d *DataMigration) Throttle(pause time.Duration) *DataMigration {
+	d.throttle = pause
+	return d
+}
+
+// Total supplies the expected row count so progress can be reported as a
+// percentage. It is evaluated once when the migration starts.
+func (d *DataMigration) Total(fn func(db *gorm.DB) (int64, error)) *DataMigration {
+	d.total = fn
+	return d
+}
+
+// OnDown sets the rollback action. Without one, rolling back only clears the
+// checkpoint so the migration starts over next time.
+func (d *DataMigration) OnDown(fn func(db *gorm.DB) error) *DataMigration {
+	d.down = fn
+	return d
+}
+
+// WithContext makes the migration stop (after the current batch) when ctx is
+// cancelled. The checkpoint is kept, so it resumes on the next run.
+func (d *DataMigration) WithContext(ctx context.Context) *DataMigration {
+	d.ctx = ctx
+	return d
+}
+
+// dataCheckpoint is the GORM model for kashvi_data_migrations.
+type dataCheckpoint struct {
+	Name        string `gorm:"primaryKey;size:255"`
+	Cursor      int64
+	Processed   int64
+	Batches     int
+	CompletedAt *time.Time
+	UpdatedAt   time.Time
+}
+
+func (dataCheckpoint) TableName() string { return "kashvi_data_migrations" }
+
+// Up runs batches until fn reports no more work, resuming from the checkpoint.
+func (d *DataMigration) Up(db *gorm.DB) error {
+	if err := db.AutoMigrate(&dataCheckpoint{}); err != nil {
+		return fmt.Errorf("migration: data checkpoint table: %w", err)
+	}
+
+	var cp dataCheckpoint
+	err := db.Where("name = ?", d.name).First(&cp).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		cp = dataCheckpoint{Name: d.name}
+		if err := db.Create(&cp).Error; err != nil {
+			return fmt.Errorf("migration: data %s checkpoint: %w", d.name, err)
+		}
+	case err != nil:
+		return fmt.Errorf("migration: data %s checkpoint: %w", d.name, err)
+	}
+	if cp.CompletedAt != nil {
+		return nil
+	}
+	if cp.Batches > 0 {
+		fmt.Printf("  ↻ Resuming %s at cursor %d (%d rows done)\n", d.name, cp.Cursor, cp.Processed)
+	}
+
+	var total int64
+	if d.total != nil {
+		if total, err = d.total(db); err != nil {
+			return fmt.Errorf("migration: data %s total: %w", d.name, err)
+		}
+	}
+
+	for {
+		if err := d.ctx.Err(); err != nil {
+			return fmt.Errorf("migration: data %s interrupted at cursor %d: %w", d.name, cp.Cursor, err)
+		}
+
+		b := &Batch{Cursor: cp.Cursor, Size: d.batchSize, Number: cp.Batches + 1}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			b.DB = tx
+			if err := d.fn(b); err != nil {
+				return err
+			}
+			next := cp
+			if !b.advanced || b.processed == 0 {
+				now := time.Now()
+				next.CompletedAt = &now
+			} else {
+				next.Cursor = b.next
+				next.Processed += int64(b.processed)
+				next.Batches++
+			}
+			if err := tx.Save(&next).Error; err != nil {
+				return fmt.Errorf("checkpoint: %w", err)
+			}
+			cp = next
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("migration: data %s batch %d: %w", d.name, b.Number, err)
+		}
+
+		metrics.DataMigrationRows.WithLabelValues(d.name).Add(float64(b.processed))
+		if cp.CompletedAt != nil {
+			if total > 0 {
+				metrics.DataMigrationProgress.WithLabelValues(d.name).Set(1)
+			}
+			logger.Info("migration: data done", "name", d.name, "rows", cp.Processed, "batches", cp.Batches)
+			fmt.Printf("  ✅ %s: %d rows in %d batches\n", d.name, cp.Processed, cp.Batches)
+			return nil
+		}
+		d.report(cp, total)
+
+		if d.throttle > 0 {
+			select {
+			case <-time.After(d.throttle):
+			case <-d.ctx.Done():
+			}
+		}
+	}
+}
+
+func (d *DataMigration) report(cp dataCheckpoint, total int64) {
+	if total > 0 {
+		ratio := min(float64(cp.Processed)/float64(total), 1)
+		metrics.DataMigrationProgress.WithLabelValues(d.name).Set(ratio)
+		fmt.Printf("  … %s: batch %d, %d/%d rows (%.1f%%)\n", d.name, cp.Batches, cp.Processed, total, ratio*100)
+		return
+	}
+	fmt.Printf("  … %s: batch %d, %d rows\n", d.name, cp.Batches, cp.Processed)
+}
+
+// Down runs the OnDown action (if any) and clears the checkpoint.
+func (d *DataMigration) Down(db *gorm.DB) error {
+	if d.down != nil {
+		if err := d.down(db); err != nil {
+			return err
+		}
+	}
+	if !db.Migrator().HasTable(&dataCheckpoint{}) {
+		return nil
+	}
+	return db.Where("name = ?", d.name).Delete(&dataCheckpoint{}).Error
+}
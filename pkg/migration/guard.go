@@ -0,0 +1,115 @@
+package migration
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ------------------- Zero-downtime guardrails -------------------
+
+// Finding is a potentially dangerous statement in a pending migration.
+type Finding struct {
+	Migration string
+	Rule      string
+	SQL       string
+	Message   string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s [%s] %s\n    %s", f.Migration, f.Rule, f.Message, f.SQL)
+}
+
+type guardRule struct {
+	name     string
+	dialects []string // empty = all
+	match    func(stmt string) bool
+	message  string
+}
+
+var (
+	reAddColumn      = regexp.MustCompile(`(?i)^ALTER TABLE\s+\S+\s+ADD\s`)
+	reNotNull        = regexp.MustCompile(`(?i)\bNOT NULL\b`)
+	reDefault        = regexp.MustCompile(`(?i)\bDEFAULT\b`)
+	rePgAlterType    = regexp.MustCompile(`(?i)\bALTER COLUMN\s+\S+\s+(SET DATA\s+)?TYPE\b`)
+	reMySQLModify    = regexp.MustCompile(`(?i)^ALTER TABLE\s+\S+\s+(MODIFY|CHANGE)\s`)
+	reSQLiteRecreate = regexp.MustCompile("(?i)^CREATE TABLE\\s+[`\"]?\\S+__temp[`\"]?")
+	reCreateIndex    = regexp.MustCompile(`(?i)^CREATE\s+(UNIQUE\s+)?INDEX\s`)
+	reConcurrently   = regexp.MustCompile(`(?i)\bCONCURRENTLY\b`)
+)
+
+var guardRules = []guardRule{
+	{
+		name: "not-null-without-default",
+		match: func(s string) bool {
+			return reAddColumn.MatchString(s) && reNotNull.MatchString(s) && !reDefault.MatchString(s)
+		},
+		message: "adding a NOT NULL column without a DEFAULT fails on non-empty tables or rewrites them",
+	},
+	{
+		name:     "table-rewrite",
+		dialects: []string{"postgres"},
+		match:    rePgAlterType.MatchString,
+		message:  "changing a column type rewrites the whole table under an ACCESS EXCLUSIVE lock",
+	},
+	{
+		name:     "table-rewrite",
+		dialects: []string{"mysql"},
+		match:    reMySQLModify.MatchString,
+		message:  "MODIFY/CHANGE COLUMN copies the table and may block writes",
+	},
+	{
+		name:     "table-rewrite",
+		dialects: []string{"sqlite"},
+		match:    reSQLiteRecreate.MatchString,
+		message:  "altering a column recreates and copies the whole table",
+	},
+	{
+		name:     "index-without-concurrently",
+		dialects: []string{"postgres"},
+		match: func(s string) bool {
+			return reCreateIndex.MatchString(s) && !reConcurrently.MatchString(s)
+		},
+		message: "CREATE INDEX blocks writes for the whole build; use CREATE INDEX CONCURRENTLY",
+	},
+}
+
+// Analyze inspects planned SQL for operations that are unsafe to run against
+// a live database. dialect is the GORM dialector name ("postgres", "mysql",
+// "sqlite", "sqlserver").
+func Analyze(dialect string, plans []Plan) []Finding {
+	var out []Finding
+	for _, p := range plans {
+		for _, stmt := range p.SQL {
+			stmt = strings.TrimSpace(stmt)
+			for _, rule := range guardRules {
+				if !ruleApplies(rule, dialect) || !rule.match(stmt) {
+					continue
+				}
+				out = append(out, Finding{Migration: p.Name, Rule: rule.name, SQL: stmt, Message: rule.message})
+			}
+		}
+	}
+	return out
+}
+
+func ruleApplies(r guardRule, dialect string) bool {
+	if len(r.dialects) == 0 {
+		return true
+	}
+	for _, d := range r.dialects {
+		if d == dialect {
+			return true
+		}
+	}
+	return false
+}
+
+// Check previews pending migrations (see Pretend) and analyzes their SQL.
+func (r *Runner) Check() ([]Finding, error) {
+	plans, err := r.Pretend()
+	if err != nil {
+		return nil, err
+	}
+	return Analyze(r.Dialect(), plans), nil
+}
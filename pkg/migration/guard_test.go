@@ -0,0 +1,42 @@
+package migration_test
+
+import (
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/migration"
+)
+
+func TestAnalyze(t *testing.T) {
+	plans := []migration.Plan{{
+		Name: "20240201_changes",
+		SQL: []string{
+			`ALTER TABLE "users" ADD "role" text NOT NULL`,
+			`ALTER TABLE "users" ADD "plan" text NOT NULL DEFAULT 'free'`,
+			`ALTER TABLE "users" ALTER COLUMN "age" TYPE bigint`,
+			`CREATE INDEX "idx_users_email" ON "users" ("email")`,
+			`CREATE INDEX CONCURRENTLY "idx_users_name" ON "users" ("name")`,
+		},
+	}}
+
+	got := map[string]int{}
+	for _, f := range migration.Analyze("postgres", plans) {
+		got[f.Rule]++
+	}
+	want := map[string]int{
+		"not-null-without-default":   1,
+		"table-rewrite":              1,
+		"index-without-concurrently": 1,
+	}
+	for rule, n := range want {
+		if got[rule] != n {
+			t.Errorf("postgres %s: got %d findings, want %d", rule, got[rule], n)
+		}
+	}
+
+	// Postgres-only rules don't fire for other dialects.
+	for _, f := range migration.Analyze("mysql", plans) {
+		if f.Rule != "not-null-without-default" {
+			t.Errorf("mysql: unexpected finding %s", f.Rule)
+		}
+	}
+}
@@ -17,7 +17,10 @@
 // Run from CLI:
 //
 //	kashvi migrate             // run all pending
+//	kashvi migrate --pretend   // print the SQL pending migrations would run
 //	kashvi migrate:rollback    // rollback last batch
+//	kashvi migrate:fresh       // drop every table, then migrate
+//	kashvi db:wipe             // drop every table
 package migration
 
 import (
@@ -52,6 +55,7 @@ func (migrationRecord) TableName() string { return "kashvi_migrations" }
 
 type registeredMigration struct {
 	name string
+	conn string
 	m    Migration
 }
 
@@ -61,31 +65,109 @@ var registry []registeredMigration
 // name should be a timestamp-prefixed string, e.g. "20240101000000_create_users_table".
 // Migrations are run in the order they are registered, so call Register in
 // chronological order (use an init() in each migration file).
+//
+// A migration targets the primary connection unless it implements Targeted
+// or is registered with RegisterOn.
 func Register(name string, m Migration) {
-	registry = append(registry, registeredMigration{name: name, m: m})
+	conn := Primary
+	if t, ok := m.(Targeted); ok && t.Connection() != "" {
+		conn = t.Connection()
+	}
+	RegisterOn(conn, name, m)
+}
+
+// RegisterOn adds a migration that runs against the named connection
+// (see database.Connection), e.g. "analytics" or "tenant_template".
+func RegisterOn(connection, name string, m Migration) {
+	registry = append(registry, registeredMigration{name: name, conn: connection, m: m})
+}
+
+// Primary is the name of the default connection (database.DB).
+const Primary = "primary"
+
+// Targeted is implemented by migrations that declare their connection.
+type Targeted interface {
+	Connection() string
 }
 
 // ------------------- Runner -------------------
 
 // Runner executes and tracks migrations.
 type Runner struct {
-	db *gorm.DB
+	db   *gorm.DB
+	conn string
 }
 
-// New creates a Runner backed by the provided gorm.DB.
+// New creates a Runner for the primary connection backed by db.
 func New(db *gorm.DB) *Runner {
-	return &Runner{db: db}
+	return &Runner{db: db, conn: Primary}
+}
+
+// NewFor creates a Runner for the migrations registered on connection.
+// Each connection keeps its own tracking table, so batches are independent.
+func NewFor(db *gorm.DB, connection string) *Runner {
+	if connection == "" {
+		connection = Primary
+	}
+	return &Runner{db: db, conn: connection}
+}
+
+// TrackingTable returns the name of the runner's tracking table:
+// kashvi_migrations for the primary connection, kashvi_migrations_<name>
+// otherwise.
+func (r *Runner) TrackingTable() string {
+	if r.conn == Primary {
+		return migrationRecord{}.TableName()
+	}
+	return migrationRecord{}.TableName() + "_" + r.conn
+}
+
+// Connection returns the name of the connection this runner migrates.
+func (r *Runner) Connection() string { return r.conn }
+
+// Dialect returns the GORM dialector name of the runner's database.
+func (r *Runner) Dialect() string { return r.db.Dialector.Name() }
+
+// records scopes a query to the tracking table.
+func (r *Runner) records() *gorm.DB {
+	return r.db.Table(r.TrackingTable())
+}
+
+// migrations returns the registered migrations for this runner's connection.
+func (r *Runner) migrations() []registeredMigration {
+	var out []registeredMigration
+	for _, reg := range registry {
+		if reg.conn == r.conn {
+			out = append(out, reg)
+		}
+	}
+	return out
+}
+
+// Connections lists every connection that has registered migrations,
+// primary first.
+func Connections() []string {
+	seen := map[string]bool{}
+	out := []string{Primary}
+	seen[Primary] = true
+	for _, reg := range registry {
+		if !seen[reg.conn] {
+			seen[reg.conn] = true
+			out = append(out, reg.conn)
+		}
+	}
+	return out
 }
 
 // EnsureTable creates the tracking table if it does not exist.
 func (r *Runner) EnsureTable() error {
-	return r.db.AutoMigrate(&migrationRecord{})
+	return r.records().AutoMigrate(&migrationRecord{})
 }
 
 // Pending returns the names of migrations that have not yet been run.
 func (r *Runner) Pending() ([]registeredMigration, error) {
 	var ran []migrationRecord
-	if err := r.db.Find(&ran).Error; err != nil {
+	if err := r.records().Find(&ran).Error; err != nil {
 		return nil, err
 	}
 
@@ -95,20 +177,23 @@ func (r *Runner) Pending() ([]registeredMigration, error) {
 	}
 
 	var pending []registeredMigration
-	for _, reg := range registry {
+	for _, reg := range r.migrations() {
 		if !ranSet[reg.name] {
 			pending = append(pending, reg)
 		}
 	}
 
-	// Ensure stable ordering by name (timestamps sort lexicographically).
-	sort.Slice(pending, func(i, j int) bool {
-		return pending[i].name < pending[j].name
-	})
-
+	sortMigrations(pending)
 	return pending, nil
 }
 
+// sortMigrations orders by name (timestamps sort lexicographically).
+func sortMigrations(ms []registeredMigration) {
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].name < ms[j].name
+	})
+}
+
 // Run executes all pending migrations in a single batch.
 func (r *Runner) Run() error {
 	if err := r.EnsureTable(); err != nil {
@@ -137,7 +222,7 @@ func (r *Runner) Run() error {
 		}
 
 		record := migrationRecord{Name: reg.name, Batch: batch}
-		if err := r.db.Create(&record).Error; err != nil {
+		if err := r.records().Create(&record).Error; err != nil {
 			return fmt.Errorf("migration: record %s: %w", reg.name, err)
 		}
 
@@ -156,7 +241,7 @@ func (r *Runner) Rollback() error {
 
 	// Find the last batch number.
 	var maxBatch struct{ Max int }
-	r.db.Model(&migrationRecord{}).Select("MAX(batch) as max").Scan(&maxBatch)
+	r.records().Select("MAX(batch) as max").Scan(&maxBatch)
 	if maxBatch.Max == 0 {
 		fmt.Println("Nothing to roll back.")
 		return nil
@@ -164,7 +249,7 @@ func (r *Runner) Rollback() error {
 
 	// Get all migrations in that batch, descending order.
 	var records []migrationRecord
-	if err := r.db.Where("batch = ?", maxBatch.Max).
+	if err := r.records().Where("batch = ?", maxBatch.Max).
 		Order("id desc").
 		Find(&records).Error; err != nil {
 		return err
@@ -172,7 +257,7 @@ func (r *Runner) Rollback() error {
 
 	// Find corresponding Migration implementations.
 	regMap := make(map[string]Migration, len(registry))
-	for _, reg := range registry {
+	for _, reg := range r.migrations() {
 		regMap[reg.name] = reg.m
 	}
 
@@ -189,7 +274,7 @@ func (r *Runner) Rollback() error {
 			return fmt.Errorf("migration: %s down: %w", rec.Name, err)
 		}
 
-		if err := r.db.Delete(&rec).Error; err != nil {
+		if err := r.records().Delete(&rec).Error; err != nil {
 			return err
 		}
 
@@ -199,6 +284,30 @@ func (r *Runner) Rollback() error {
 	return nil
 }
 
+// Wipe drops every table in the database, including the tracking table.
+func (r *Runner) Wipe() error {
+	tables, err := r.db.Migrator().GetTables()
+	if err != nil {
+		return fmt.Errorf("migration: list tables: %w", err)
+	}
+	for _, t := range tables {
+		fmt.Printf("  ✖ Dropping: %s\n", t)
+		if err := r.db.Migrator().DropTable(t); err != nil {
+			return fmt.Errorf("migration: drop %s: %w", t, err)
+		}
+	}
+	logger.Warn("migration: wiped database", "tables", len(tables))
+	return nil
+}
+
+// Fresh drops every table and re-runs all migrations from scratch.
+func (r *Runner) Fresh() error {
+	if err := r.Wipe(); err != nil {
+		return err
+	}
+	return r.Run()
+}
+
 // Status prints all migrations and whether each has been run.
 func (r *Runner) Status() error {
 	if err := r.EnsureTable(); err != nil {
@@ -206,7 +315,7 @@ func (r *Runner) Status() error {
 	}
 
 	var ran []migrationRecord
-	if err := r.db.Find(&ran).Error; err != nil {
+	if err := r.records().Find(&ran).Error; err != nil {
 		return err
 	}
 
@@ -217,19 +326,37 @@ func (r *Runner) Status() error {
 
 	fmt.Printf("%-60s  %-8s  %s\n", "Migration", "Status", "Batch")
 	fmt.Println(string(make([]byte, 80)))
-	for _, reg := range registry {
+	for _, reg := range r.migrations() {
 		if rec, ok := ranMap[reg.name]; ok {
 			fmt.Printf("%-60s  %-8s  %d\n", reg.name, "Ran", rec.Batch)
 		} else {
 			fmt.Printf("%-60s  %-8s  -\n", reg.name, "Pending")
 		}
 	}
+
+	if !r.db.Migrator().HasTable(&dataCheckpoint{}) {
+		return nil
+	}
+	var cps []dataCheckpoint
+	if err := r.db.Order("name").Find(&cps).Error; err != nil {
+		return err
+	}
+	if len(cps) > 0 {
+		fmt.Printf("\n%-40s  %-10s  %-12s  %s\n", "Data migration", "State", "Rows", "Cursor")
+		for _, cp := range cps {
+			state := "running"
+			if cp.CompletedAt != nil {
+				state = "done"
+			}
+			fmt.Printf("%-40s  %-10s  %-12d  %d\n", cp.Name, state, cp.Processed, cp.Cursor)
+		}
+	}
 	return nil
 }
 
 func (r *Runner) nextBatch() int {
 	var maxBatch struct{ Max int }
-	r.db.Model(&migrationRecord{}).Select("MAX(batch) as max").Scan(&maxBatch)
+	r.records().Select("MAX(batch) as max").Scan(&maxBatch)
 	return maxBatch.Max + 1
 }
 
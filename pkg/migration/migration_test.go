@@ -0,0 +1,173 @@
+package migration_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/migration"
+	"github.com/shashiranjanraj/kashvi/pkg/testkit"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+type createWidgets struct{}
+
+func (createWidgets) Up(db *gorm.DB) error   { return db.AutoMigrate(&widget{}) }
+func (createWidgets) Down(db *gorm.DB) error { return db.Migrator().DropTable(&widget{}) }
+
+func init() {
+	migration.Register("20240101000000_create_widgets", createWidgets{})
+}
+
+func TestWipeAndFresh(t *testing.T) {
+	db := testkit.MemoryDB(t)
+	r := migration.New(db)
+
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	db.Create(&widget{Name: "a"})
+
+	if err := r.Wipe(); err != nil {
+		t.Fatalf("Wipe: %v", err)
+	}
+	if tables, _ := db.Migrator().GetTables(); len(tables) != 0 {
+		t.Fatalf("tables after wipe: %v", tables)
+	}
+
+	if err := r.Fresh(); err != nil {
+		t.Fatalf("Fresh: %v", err)
+	}
+	var n int64
+	db.Model(&widget{}).Count(&n)
+	if !db.Migrator().HasTable(&widget{}) || n != 0 {
+		t.Fatalf("fresh: has table=%v rows=%d", db.Migrator().HasTable(&widget{}), n)
+	}
+}
+
+func TestPretend_CapturesSQLWithoutApplying(t *testing.T) {
+	db := testkit.MemoryDB(t)
+	r := migration.New(db)
+
+	plans, err := r.Pretend()
+	if err != nil {
+		t.Fatalf("Pretend: %v", err)
+	}
+	if len(plans) != 1 || plans[0].Name != "20240101000000_create_widgets" {
+		t.Fatalf("plans = %+v", plans)
+	}
+	if len(plans[0].SQL) == 0 || !strings.Contains(plans[0].SQL[0], "CREATE TABLE `widgets`") {
+		t.Fatalf("sql = %q", plans[0].SQL)
+	}
+	if db.Migrator().HasTable(&widget{}) {
+		t.Fatal("pretend must not create tables")
+	}
+	if db.Migrator().HasTable("kashvi_migrations") {
+		t.Fatal("pretend must not create the tracking table")
+	}
+}
+
+func TestDataMigration_ResumesFromCheckpoint(t *testing.T) {
+	db := testkit.MemoryDB(t, &widget{})
+	for i := 0; i < 25; i++ {
+		db.Create(&widget{Name: "w"})
+	}
+
+	failAt := 2
+	calls := 0
+	backfill := func(b *migration.Batch) error {
+		calls++
+		if b.Number == failAt {
+			return errors.New("boom")
+		}
+		var ws []widget
+		if err := b.DB.Where("id > ?", b.Cursor).Order("id").Limit(b.Size).Find(&ws).Error; err != nil {
+			return err
+		}
+		for _, w := range ws {
+			b.DB.Model(&w).Update("name", "done")
+		}
+		if len(ws) > 0 {
+			b.Advance(len(ws), int64(ws[len(ws)-1].ID))
+		}
+		return nil
+	}
+
+	dm := migration.Data("rename_widgets", 10, backfill)
+	if err := dm.Up(db); err == nil {
+		t.Fatal("expected batch 2 to fail")
+	}
+	var done int64
+	db.Model(&widget{}).Where("name = ?", "done").Count(&done)
+	if done != 10 {
+		t.Fatalf("after failure: %d rows done, want 10 (batch 2 rolled back)", done)
+	}
+
+	failAt = -1
+	calls = 0
+	if err := dm.Up(db); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	db.Model(&widget{}).Where("name = ?", "done").Count(&done)
+	if done != 25 {
+		t.Fatalf("after resume: %d rows done, want 25", done)
+	}
+	// Resumed at batch 2: batches 2, 3 and the empty terminating batch.
+	if calls != 3 {
+		t.Fatalf("resume ran %d batches, want 3", calls)
+	}
+
+	// Completed migrations are no-ops.
+	calls = 0
+	if err := dm.Up(db); err != nil || calls != 0 {
+		t.Fatalf("rerun: err=%v calls=%d", err, calls)
+	}
+}
+
+type event struct {
+	ID   uint
+	Kind string
+}
+
+type createEvents struct{}
+
+func (createEvents) Up(db *gorm.DB) error   { return db.AutoMigrate(&event{}) }
+func (createEvents) Down(db *gorm.DB) error { return db.Migrator().DropTable(&event{}) }
+func (createEvents) Connection() string     { return "analytics" }
+
+func init() {
+	migration.Register("20240102000000_create_events", createEvents{})
+}
+
+func TestNewFor_TracksConnectionsSeparately(t *testing.T) {
+	db := testkit.MemoryDB(t)
+
+	analytics := migration.NewFor(db, "analytics")
+	if analytics.TrackingTable() != "kashvi_migrations_analytics" {
+		t.Fatalf("tracking table = %q", analytics.TrackingTable())
+	}
+	if err := analytics.Run(); err != nil {
+		t.Fatalf("analytics Run: %v", err)
+	}
+	if !db.Migrator().HasTable(&event{}) || db.Migrator().HasTable(&widget{}) {
+		t.Fatal("analytics runner must only apply analytics migrations")
+	}
+
+	// Primary still has its own migration pending.
+	if err := migration.New(db).Run(); err != nil {
+		t.Fatalf("primary Run: %v", err)
+	}
+	if !db.Migrator().HasTable(&widget{}) {
+		t.Fatal("primary migration not applied")
+	}
+
+	got := migration.Connections()
+	if len(got) != 2 || got[0] != "primary" || got[1] != "analytics" {
+		t.Fatalf("Connections() = %v", got)
+	}
+}
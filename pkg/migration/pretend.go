@@ -0,0 +1,155 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ------------------- Pretend (dry run) -------------------
+
+// Plan is the SQL a pending migration would execute.
+type Plan struct {
+	Name string
+	SQL  []string
+	Note string // set instead of SQL when the migration cannot be previewed
+}
+
+// Pretend runs every pending migration's Up against a recording connection
+// and returns the statements it would execute, without changing the schema.
+//
+// Reads (schema introspection such as HasTable) still hit the database so
+// AutoMigrate produces accurate diffs. Writes are captured instead of run.
+// Everything happens inside a transaction that is always rolled back, so a
+// write issued through a query (e.g. INSERT … RETURNING) is undone too.
+func (r *Runner) Pretend() ([]Plan, error) {
+	pending, err := r.pendingForPretend()
+	if err != nil {
+		return nil, fmt.Errorf("migration: fetch pending: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	tx := r.db.Begin()
+	if tx.Error != nil {
+		return nil, fmt.Errorf("migration: pretend begin: %w", tx.Error)
+	}
+	defer tx.Rollback()
+
+	pool := &pretendPool{inner: tx.Statement.ConnPool, dialect: tx.Dialector}
+	sess := tx.Session(&gorm.Session{Context: context.Background()})
+	sess.Statement.ConnPool = pool
+
+	plans := make([]Plan, 0, len(pending))
+	for _, reg := range pending {
+		if _, ok := reg.m.(*DataMigration); ok {
+			plans = append(plans, Plan{Name: reg.name, Note: "data migration: runs in resumable batches, not previewed"})
+			continue
+		}
+		pool.reset()
+		if err := reg.m.Up(sess); err != nil {
+			return plans, fmt.Errorf("migration: %s pretend: %w", reg.name, err)
+		}
+		plans = append(plans, Plan{Name: reg.name, SQL: pool.statements()})
+	}
+	return plans, nil
+}
+
+// pendingForPretend is Pending without creating the tracking table: a fresh
+// database simply has every migration pending.
+func (r *Runner) pendingForPretend() ([]registeredMigration, error) {
+	if !r.db.Migrator().HasTable(r.TrackingTable()) {
+		all := r.migrations()
+		sortMigrations(all)
+		return all, nil
+	}
+	return r.Pending()
+}
+
+// pretendPool records writes and forwards reads to the wrapped pool.
+// It implements gorm.TxCommitter so nested Transaction calls (used by some
+// migrators when altering columns) stay inside the outer rolled-back tx.
+type pretendPool struct {
+	inner   gorm.ConnPool
+	dialect gorm.Dialector
+
+	mu  sync.Mutex
+	sql []string
+}
+
+func (p *pretendPool) record(query string, args []any) {
+	if isTxControl(query) {
+		return
+	}
+	p.mu.Lock()
+	p.sql = append(p.sql, p.dialect.Explain(query, args...))
+	p.mu.Unlock()
+}
+
+func (p *pretendPool) reset() {
+	p.mu.Lock()
+	p.sql = nil
+	p.mu.Unlock()
+}
+
+func (p *pretendPool) statements() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.sql...)
+}
+
+func (p *pretendPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.inner.PrepareContext(ctx, query)
+}
+
+func (p *pretendPool) ExecContext(_ context.Context, query string, args ...any) (sql.Result, error) {
+	p.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+func (p *pretendPool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if !isRead(query) {
+		p.record(query, args)
+	}
+	return p.inner.QueryContext(ctx, query, args...)
+}
+
+func (p *pretendPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if !isRead(query) {
+		p.record(query, args)
+	}
+	return p.inner.QueryRowContext(ctx, query, args...)
+}
+
+func (p *pretendPool) Commit() error   { return nil }
+func (p *pretendPool) Rollback() error { return nil }
+
+func firstKeyword(query string) string {
+	f := strings.Fields(query)
+	if len(f) == 0 {
+		return ""
+	}
+	return strings.ToUpper(f[0])
+}
+
+func isRead(query string) bool {
+	switch firstKeyword(query) {
+	case "SELECT", "PRAGMA", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "WITH":
+		return true
+	}
+	return false
+}
+
+func isTxControl(query string) bool {
+	switch firstKeyword(query) {
+	case "SAVEPOINT", "RELEASE", "ROLLBACK", "BEGIN", "COMMIT":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+// Package mtls adds client-certificate (mutual TLS) authentication.
+//
+// The server side is configured from the environment:
+//
+//	TLS_CERT_FILE=/etc/kashvi/tls.crt
+//	TLS_KEY_FILE=/etc/kashvi/tls.key
+//	TLS_CLIENT_CA_FILE=/etc/kashvi/clients-ca.pem
+//	TLS_CLIENT_AUTH=required          # none | optional | required
+//
+// A verified peer certificate becomes an Identity named after its first URI
+// SAN (e.g. a SPIFFE ID), DNS SAN, email SAN or subject CN. Abilities are
+// granted by rules that match any of those names:
+//
+//	MTLS_IDENTITIES="spiffe://corp/billing=orders:read,orders:write;*.ops.internal=*"
+//
+//	mtls.Map("spiffe://corp/billing", "orders:read", "orders:write")
+//
+// Routes then require a certificate, and optionally abilities:
+//
+//	r.Group("/internal", middleware.RequireClientCert("orders:read"))
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// ─── Server configuration ─────────────────────────────────────────────────────
+
+// Options points at the PEM files the server needs.
+type Options struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	// ClientAuth is "none", "optional" (verify a certificate if one is
+	// sent) or "required".
+	ClientAuth string
+}
+
+// DefaultOptions reads TLS_CERT_FILE, TLS_KEY_FILE, TLS_CLIENT_CA_FILE and
+// TLS_CLIENT_AUTH (default "none").
+func DefaultOptions() Options {
+	return Options{
+		CertFile:     config.Get("TLS_CERT_FILE", ""),
+		KeyFile:      config.Get("TLS_KEY_FILE", ""),
+		ClientCAFile: config.Get("TLS_CLIENT_CA_FILE", ""),
+		ClientAuth:   strings.ToLower(config.Get("TLS_CLIENT_AUTH", "none")),
+	}
+}
+
+// ServerConfig builds the listener's *tls.Config. It returns nil when no
+// server certificate is configured, meaning the server speaks plain HTTP.
+func ServerConfig(opts Options) (*tls.Config, error) {
+	if opts.CertFile == "" && opts.KeyFile == "" {
+		if opts.ClientAuth != "" && opts.ClientAuth != "none" {
+			return nil, errors.New("mtls: TLS_CLIENT_AUTH needs TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("mtls: load key pair: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	switch opts.ClientAuth {
+	case "", "none":
+		return cfg, nil
+	case "optional":
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	case "required":
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("mtls: unknown TLS_CLIENT_AUTH %q", opts.ClientAuth)
+	}
+
+	if opts.ClientCAFile == "" {
+		return nil, errors.New("mtls: client certificates need TLS_CLIENT_CA_FILE")
+	}
+	pem, err := os.ReadFile(opts.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("mtls: read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("mtls: no certificates in %s", opts.ClientCAFile)
+	}
+	cfg.ClientCAs = pool
+	return cfg, nil
+}
+
+// ─── Peer certificate ─────────────────────────────────────────────────────────
+
+// PeerCertificate returns the client certificate of r if the TLS handshake
+// verified it against the client CA. Unverified certificates are ignored.
+func PeerCertificate(r *http.Request) *x509.Certificate {
+	if r == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// ─── Identities ───────────────────────────────────────────────────────────────
+
+// Identity is the authenticated caller behind a client certificate.
+type Identity struct {
+	// Name is the certificate's primary name: the first URI SAN, DNS SAN,
+	// email SAN or subject CN, in that order.
+	Name string
+	// Names lists every name the certificate carries, in the same order.
+	Names     []string
+	Abilities []string
+	Cert      *x509.Certificate
+}
+
+// Can reports whether the identity holds ability ("*" holds every ability).
+func (id Identity) Can(ability string) bool {
+	for _, a := range id.Abilities {
+		if a == ability || a == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Rule grants Abilities to certificates with a name matching Pattern. The
+// pattern uses path.Match syntax, so "*.ops.internal" matches one label.
+type Rule struct {
+	Pattern   string
+	Abilities []string
+}
+
+var (
+	rulesMu   sync.RWMutex
+	rules     []Rule
+	envLoaded bool
+)
+
+// Map adds a rule granting abilities to certificates whose name matches
+// pattern. Rules from MTLS_IDENTITIES are loaded first.
+func Map(pattern string, abilities ...string) {
+	loadEnvRules()
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules = append(rules, Rule{Pattern: pattern, Abilities: abilities})
+}
+
+// ParseRules parses "pattern=ability,ability;pattern=ability".
+func ParseRules(s string) ([]Rule, error) {
+	var out []Rule
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern, list, ok := strings.Cut(entry, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("mtls: invalid identity rule %q", entry)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("mtls: invalid pattern %q: %w", pattern, err)
+		}
+		rule := Rule{Pattern: pattern}
+		for _, a := range strings.Split(list, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				rule.Abilities = append(rule.Abilities, a)
+			}
+		}
+		out = append(out, rule)
+	}
+	return out, nil
+}
+
+func loadEnvRules() {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if envLoaded {
+		return
+	}
+	envLoaded = true
+	parsed, err := ParseRules(config.Get("MTLS_IDENTITIES", ""))
+	if err != nil {
+		// Fail closed: a broken rule set grants nothing rather than guessing.
+		logger.Error("mtls: ignoring MTLS_IDENTITIES", "error", err)
+		return
+	}
+	rules = append(parsed, rules...)
+}
+
+// names lists the certificate's identities from most to least specific.
+func names(cert *x509.Certificate) []string {
+	var out []string
+	for _, u := range cert.URIs {
+		out = append(out, u.String())
+	}
+	out = append(out, cert.DNSNames...)
+	out = append(out, cert.EmailAddresses...)
+	if cert.Subject.CommonName != "" {
+		out = append(out, cert.Subject.CommonName)
+	}
+	return out
+}
+
+// Resolve maps a verified certificate to its Identity, collecting the
+// abilities of every matching rule.
+func Resolve(cert *x509.Certificate) Identity {
+	loadEnvRules()
+	id := Identity{Names: names(cert), Cert: cert}
+	if len(id.Names) > 0 {
+		id.Name = id.Names[0]
+	}
+
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		if !matchAny(rule.Pattern, id.Names) {
+			continue
+		}
+		for _, a := range rule.Abilities {
+			if !seen[a] {
+				seen[a] = true
+				id.Abilities = append(id.Abilities, a)
+			}
+		}
+	}
+	return id
+}
+
+func matchAny(pattern string, names []string) bool {
+	for _, n := range names {
+		if ok, _ := path.Match(pattern, n); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ─── Context ──────────────────────────────────────────────────────────────────
+
+type ctxKey struct{}
+
+// WithIdentity stores id in ctx and returns the new context.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromCtx returns the identity stored by the client-cert middleware.
+func FromCtx(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(ctxKey{}).(Identity)
+	return id, ok
+}
@@ -0,0 +1,157 @@
+package mtls_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+	"github.com/shashiranjanraj/kashvi/pkg/mtls"
+)
+
+// ─── Certificate helpers ──────────────────────────────────────────────────────
+
+type issued struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func issue(t *testing.T, tmpl *x509.Certificate, parent *issued) *issued {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.NotBefore = time.Now().Add(-time.Minute)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	parentCert, parentKey := tmpl, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &issued{cert: cert, key: key, der: der}
+}
+
+func writePEM(t *testing.T, dir, name, typ string, der []byte) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func (c *issued) tlsCert() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+func TestRequireClientCert_EndToEnd(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "test CA"}, IsCA: true, BasicConstraintsValid: true,
+		KeyUsage: x509.KeyUsageCertSign,
+	}, nil)
+	server := issue(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "server"}, IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	spiffe, _ := url.Parse("spiffe://corp/billing")
+	client := issue(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "billing"}, URIs: []*url.URL{spiffe},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	keyDER, _ := x509.MarshalECPrivateKey(server.key)
+	cfg, err := mtls.ServerConfig(mtls.Options{
+		CertFile:     writePEM(t, dir, "server.crt", "CERTIFICATE", server.der),
+		KeyFile:      writePEM(t, dir, "server.key", "EC PRIVATE KEY", keyDER),
+		ClientCAFile: writePEM(t, dir, "ca.crt", "CERTIFICATE", ca.der),
+		ClientAuth:   "optional",
+	})
+	if err != nil {
+		t.Fatalf("ServerConfig: %v", err)
+	}
+
+	mtls.Map("spiffe://corp/*", "orders:read")
+	handler := func(abilities ...string) http.Handler {
+		return middleware.RequireClientCert(abilities...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, _ := mtls.FromCtx(r.Context())
+			io.WriteString(w, id.Name)
+		}))
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/read", handler("orders:read"))
+	mux.Handle("/write", handler("orders:write"))
+
+	srv := httptest.NewUnstartedServer(mux)
+	srv.TLS = cfg
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(path string, certs ...tls.Certificate) (int, string) {
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+		res, err := c.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(body)
+	}
+
+	if code, body := get("/read", client.tlsCert()); code != http.StatusOK || body != "spiffe://corp/billing" {
+		t.Fatalf("with cert: %d %q, want 200 with the SPIFFE ID", code, body)
+	}
+	if code, _ := get("/write", client.tlsCert()); code != http.StatusForbidden {
+		t.Fatalf("missing ability: %d, want 403", code)
+	}
+	if code, _ := get("/read"); code != http.StatusUnauthorized {
+		t.Fatalf("without cert: %d, want 401", code)
+	}
+}
+
+func TestServerConfig(t *testing.T) {
+	if cfg, err := mtls.ServerConfig(mtls.Options{}); cfg != nil || err != nil {
+		t.Fatalf("no cert configured: got %v, %v; want plain HTTP", cfg, err)
+	}
+	if _, err := mtls.ServerConfig(mtls.Options{ClientAuth: "required"}); err == nil {
+		t.Fatal("client auth without a server cert should fail")
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := mtls.ParseRules("spiffe://corp/billing=orders:read, orders:write; *.ops.internal=*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || len(rules[0].Abilities) != 2 || rules[1].Pattern != "*.ops.internal" {
+		t.Fatalf("rules = %+v", rules)
+	}
+	if _, err := mtls.ParseRules("no-equals-sign"); err == nil {
+		t.Fatal("expected an error for a rule without abilities")
+	}
+}
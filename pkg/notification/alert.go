@@ -0,0 +1,337 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// ------------------- Log alerts -------------------
+
+// AlertOptions configures the alerting slog handler.
+type AlertOptions struct {
+	SlackWebhook string     // "" disables Slack alerts
+	SlackLevel   slog.Level // minimum level sent to Slack
+
+	PagerDutyKey   string     // "" disables PagerDuty alerts
+	PagerDutyLevel slog.Level // minimum level sent to PagerDuty
+
+	Environments []string      // APP_ENV values alerts are active in
+	DedupWindow  time.Duration // identical messages are sent at most once per window
+	MaxPerMinute int           // hard cap across all messages
+}
+
+// DefaultAlertOptions reads ALERT_* from config.
+func DefaultAlertOptions() AlertOptions {
+	window, err := time.ParseDuration(config.Get("ALERT_DEDUP_WINDOW", "5m"))
+	if err != nil {
+		window = 5 * time.Minute
+	}
+	var perMin int
+	if _, err := fmt.Sscanf(config.Get("ALERT_RATE_LIMIT", "10"), "%d", &perMin); err != nil || perMin <= 0 {
+		perMin = 10
+	}
+	var envs []string
+	for _, e := range strings.Split(config.Get("ALERT_ENVIRONMENTS", "production,prod"), ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			envs = append(envs, e)
+		}
+	}
+	return AlertOptions{
+		SlackWebhook:   config.Get("ALERT_SLACK_WEBHOOK", ""),
+		SlackLevel:     parseLevel(config.Get("ALERT_SLACK_LEVEL", "error")),
+		PagerDutyKey:   config.Get("ALERT_PAGERDUTY_ROUTING_KEY", ""),
+		PagerDutyLevel: parseLevel(config.Get("ALERT_PAGERDUTY_LEVEL", "fatal")),
+		Environments:   envs,
+		DedupWindow:    window,
+		MaxPerMinute:   perMin,
+	}
+}
+
+// LevelFatal is the level alerts treat as "page someone now". slog has no
+// fatal level; log with logger.L.Log(ctx, notification.LevelFatal, …).
+const LevelFatal = slog.LevelError + 4
+
+func parseLevel(s string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "fatal", "critical":
+		return LevelFatal
+	}
+	return slog.LevelError
+}
+
+// InstallAlerts wraps the global logger with an alert handler built from
+// DefaultAlertOptions when the current APP_ENV is listed in
+// ALERT_ENVIRONMENTS and at least one channel is configured. It reports
+// whether alerts were installed.
+func InstallAlerts() bool {
+	opts := DefaultAlertOptions()
+	if opts.SlackWebhook == "" && opts.PagerDutyKey == "" {
+		return false
+	}
+	env := config.AppEnv()
+	active := false
+	for _, e := range opts.Environments {
+		if e == env {
+			active = true
+		}
+	}
+	if !active {
+		return false
+	}
+	logger.Wrap(func(h slog.Handler) slog.Handler { return NewAlertHandler(h, opts) })
+	return true
+}
+
+// alertState is shared by every handler derived via WithAttrs/WithGroup.
+type alertState struct {
+	opts     AlertOptions
+	fallback slog.Handler // where delivery failures are reported (never alerts)
+
+	mu          sync.Mutex
+	lastSent    map[string]time.Time
+	suppressed  map[string]int
+	windowStart time.Time
+	sentInMin   int
+	pruned      time.Time
+
+	queue chan alert
+}
+
+type alert struct {
+	level      slog.Level
+	group      string // dotted path of the groups the record was logged in
+	msg        string
+	attrs      map[string]any
+	time       time.Time
+	suppressed int
+}
+
+// AlertHandler forwards high-severity records to Slack/PagerDuty, with
+// per-message deduplication and a global rate limit, and passes every record
+// on to the wrapped handler unchanged. Delivery is asynchronous.
+type AlertHandler struct {
+	inner slog.Handler
+	state *alertState
+	attrs map[string]any // flattened, keys qualified by their groups
+	group string         // dotted path of the open groups, "" at the top level
+}
+
+// NewAlertHandler wraps inner.
+func NewAlertHandler(inner slog.Handler, opts AlertOptions) *AlertHandler {
+	st := &alertState{
+		opts:       opts,
+		fallback:   inner,
+		lastSent:   map[string]time.Time{},
+		suppressed: map[string]int{},
+		queue:      make(chan alert, 64),
+	}
+	go st.deliverLoop()
+	return &AlertHandler{inner: inner, state: st}
+}
+
+func (h *AlertHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.inner.Enabled(ctx, l) || h.state.wants(l)
+}
+
+func (h *AlertHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.state.wants(r.Level) {
+		h.state.offer(h.attrs, h.group, r)
+	}
+	if h.inner.Enabled(ctx, r.Level) {
+		return h.inner.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *AlertHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	m := make(map[string]any, len(h.attrs)+len(attrs))
+	for k, v := range h.attrs {
+		m[k] = v
+	}
+	for _, a := range attrs {
+		addAttr(m, groupPrefix(h.group), a)
+	}
+	return &AlertHandler{inner: h.inner.WithAttrs(attrs), state: h.state, attrs: m, group: h.group}
+}
+
+func (h *AlertHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &AlertHandler{inner: h.inner.WithGroup(name), state: h.state, attrs: h.attrs, group: groupPrefix(h.group) + name}
+}
+
+// groupPrefix returns the key prefix for attrs logged in group.
+func groupPrefix(group string) string {
+	if group == "" {
+		return ""
+	}
+	return group + "."
+}
+
+// addAttr stores a in m under its group-qualified key, flattening groups the
+// way slog's text handler does ("payments.order.id").
+func addAttr(m map[string]any, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, g := range v.Group() {
+			addAttr(m, prefix, g)
+		}
+		return
+	}
+	if a.Key != "" {
+		m[prefix+a.Key] = v.Any()
+	}
+}
+
+func (s *alertState) wants(l slog.Level) bool {
+	return (s.opts.SlackWebhook != "" && l >= s.opts.SlackLevel) ||
+		(s.opts.PagerDutyKey != "" && l >= s.opts.PagerDutyLevel)
+}
+
+// offer applies dedup and rate limiting, then enqueues the alert. The same
+// message logged in different groups is deduplicated separately.
+func (s *alertState) offer(base map[string]any, group string, r slog.Record) {
+	key := r.Level.String() + "|" + groupPrefix(group) + r.Message
+	now := time.Now()
+
+	s.mu.Lock()
+	s.prune(now)
+	if last, ok := s.lastSent[key]; ok && now.Sub(last) < s.opts.DedupWindow {
+		s.suppressed[key]++
+		s.mu.Unlock()
+		return
+	}
+	if now.Sub(s.windowStart) >= time.Minute {
+		s.windowStart, s.sentInMin = now, 0
+	}
+	if s.sentInMin >= s.opts.MaxPerMinute {
+		s.suppressed[key]++
+		s.mu.Unlock()
+		return
+	}
+	s.sentInMin++
+	s.lastSent[key] = now
+	suppressed := s.suppressed[key]
+	delete(s.suppressed, key)
+	s.mu.Unlock()
+
+	a := alert{level: r.Level, group: group, msg: r.Message, time: r.Time, attrs: make(map[string]any, len(base)+r.NumAttrs()), suppressed: suppressed}
+	for k, v := range base {
+		a.attrs[k] = v
+	}
+	r.Attrs(func(at slog.Attr) bool {
+		addAttr(a.attrs, groupPrefix(group), at)
+		return true
+	})
+
+	select {
+	case s.queue <- a:
+	default: // delivery is backed up — never block the caller
+	}
+}
+
+// prune forgets messages last sent more than DedupWindow ago, at most once
+// per window, so one-off messages (with IDs or timestamps in them) do not
+// pile up. Their suppressed counts go too, as do those of messages only
+// ever held back by the rate limit. Callers hold s.mu.
+func (s *alertState) prune(now time.Time) {
+	every := max(s.opts.DedupWindow, time.Minute)
+	if now.Sub(s.pruned) < every {
+		return
+	}
+	s.pruned = now
+	for key, last := range s.lastSent {
+		if now.Sub(last) >= s.opts.DedupWindow {
+			delete(s.lastSent, key)
+			delete(s.suppressed, key)
+		}
+	}
+	for key := range s.suppressed {
+		if _, ok := s.lastSent[key]; !ok {
+			delete(s.suppressed, key)
+		}
+	}
+}
+
+func (s *alertState) deliverLoop() {
+	for a := range s.queue {
+		var errs []error
+		if s.opts.SlackWebhook != "" && a.level >= s.opts.SlackLevel {
+			if err := sendSlack(a.slack(s.opts.SlackWebhook)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if s.opts.PagerDutyKey != "" && a.level >= s.opts.PagerDutyLevel {
+			if err := sendPagerDuty(a.pagerDuty(s.opts.PagerDutyKey)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		for _, err := range errs {
+			// Report through the wrapped handler only, so a failing channel
+			// cannot trigger another alert.
+			slog.New(s.fallback).Warn("notification: alert delivery failed", "error", err)
+		}
+	}
+}
+
+func (a alert) title() string {
+	t := fmt.Sprintf("[%s] %s", config.AppEnv(), a.msg)
+	if a.suppressed > 0 {
+		t += fmt.Sprintf(" (+%d similar suppressed)", a.suppressed)
+	}
+	return t
+}
+
+func (a alert) slack(webhook string) SlackData {
+	var lines []string
+	for k, v := range a.attrs {
+		lines = append(lines, fmt.Sprintf("*%s*: %v", k, v))
+	}
+	color := "danger"
+	if a.level < slog.LevelError {
+		color = "warning"
+	}
+	return SlackData{
+		WebhookURL: webhook,
+		Text:       fmt.Sprintf(":rotating_light: %s %s", a.level, a.title()),
+		Attachments: []SlackAttachment{{
+			Color:  color,
+			Text:   strings.Join(lines, "\n"),
+			Footer: a.time.UTC().Format(time.RFC3339),
+		}},
+	}
+}
+
+func (a alert) pagerDuty(key string) PagerDutyData {
+	severity := "error"
+	switch {
+	case a.level >= LevelFatal:
+		severity = "critical"
+	case a.level < slog.LevelError:
+		severity = "warning"
+	}
+	return PagerDutyData{
+		RoutingKey: key,
+		Summary:    a.title(),
+		Severity:   severity,
+		DedupKey:   config.AppEnv() + ":" + groupPrefix(a.group) + a.msg,
+		Details:    a.attrs,
+	}
+}
@@ -0,0 +1,148 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAlertHandler_DedupAndPassThrough(t *testing.T) {
+	var mu sync.Mutex
+	var texts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Text string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		texts = append(texts, body.Text)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	h := NewAlertHandler(slog.NewTextHandler(&out, nil), AlertOptions{
+		SlackWebhook: srv.URL,
+		SlackLevel:   slog.LevelError,
+		DedupWindow:  time.Minute,
+		MaxPerMinute: 10,
+	})
+	log := slog.New(h).With("service", "api")
+
+	log.Info("started")
+	log.Error("db down", "attempt", 1)
+	log.Error("db down", "attempt", 2)
+	log.Error("cache down")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(texts)
+		mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(texts) != 2 {
+		t.Fatalf("want 2 alerts (deduped), got %d: %q", len(texts), texts)
+	}
+	if !strings.Contains(texts[0], "db down") || !strings.Contains(texts[1], "cache down") {
+		t.Errorf("unexpected alerts: %q", texts)
+	}
+	if got := strings.Count(out.String(), "db down"); got != 2 {
+		t.Errorf("inner handler should see every record, saw db down %d times", got)
+	}
+	if !strings.Contains(out.String(), "started") {
+		t.Error("info record not passed through")
+	}
+}
+
+func TestAlertHandler_EnabledBelowInnerLevel(t *testing.T) {
+	inner := slog.NewTextHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelError + 8})
+	h := NewAlertHandler(inner, AlertOptions{
+		SlackWebhook: "http://127.0.0.1:0",
+		SlackLevel:   slog.LevelError,
+		MaxPerMinute: 1,
+	})
+	if !h.Enabled(context.Background(), slog.LevelError) {
+		t.Error("alert level must be enabled even if the inner handler filters it")
+	}
+	if h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("info should not be enabled")
+	}
+}
+
+func TestAlertStatePrunesExpiredMessages(t *testing.T) {
+	s := &alertState{
+		opts:       AlertOptions{DedupWindow: time.Minute, MaxPerMinute: 10},
+		lastSent:   map[string]time.Time{},
+		suppressed: map[string]int{},
+		queue:      make(chan alert, 64),
+	}
+	now := time.Now()
+	s.lastSent["ERROR|order 1 failed"] = now.Add(-2 * time.Minute)
+	s.suppressed["ERROR|order 1 failed"] = 3
+	s.lastSent["ERROR|db down"] = now.Add(-10 * time.Second)
+	s.suppressed["ERROR|db down"] = 1
+	s.suppressed["ERROR|rate limited"] = 5 // never sent
+
+	s.offer(nil, "", slog.NewRecord(now, slog.LevelError, "cache down", 0))
+
+	if _, ok := s.lastSent["ERROR|order 1 failed"]; ok {
+		t.Error("expired message kept")
+	}
+	if _, ok := s.suppressed["ERROR|order 1 failed"]; ok {
+		t.Error("expired suppressed count kept")
+	}
+	if _, ok := s.suppressed["ERROR|rate limited"]; ok {
+		t.Error("count of a never-sent message kept")
+	}
+	if s.suppressed["ERROR|db down"] != 1 {
+		t.Error("message still within the window was pruned")
+	}
+	if _, ok := s.lastSent["ERROR|cache down"]; !ok || len(s.lastSent) != 2 {
+		t.Errorf("lastSent = %v", s.lastSent)
+	}
+}
+
+func TestAlertHandler_GroupsQualifyAttrsAndDedup(t *testing.T) {
+	st := &alertState{
+		opts:       AlertOptions{SlackWebhook: "http://127.0.0.1:0", SlackLevel: slog.LevelError, DedupWindow: time.Minute, MaxPerMinute: 10},
+		lastSent:   map[string]time.Time{},
+		suppressed: map[string]int{},
+		queue:      make(chan alert, 64),
+	}
+	root := slog.New(&AlertHandler{inner: slog.NewTextHandler(&bytes.Buffer{}, nil), state: st}).With("service", "api")
+	payments := root.WithGroup("payments").With("order", 7)
+
+	payments.Error("charge failed", "amount", 99, slog.Group("card", "brand", "visa"))
+	root.WithGroup("billing").Error("charge failed")
+	payments.Error("charge failed") // duplicate within the window
+
+	if len(st.queue) != 2 {
+		t.Fatalf("queued %d alerts, want one per group", len(st.queue))
+	}
+	a := <-st.queue
+	want := map[string]any{"service": "api", "payments.order": int64(7), "payments.amount": int64(99), "payments.card.brand": "visa"}
+	if len(a.attrs) != len(want) {
+		t.Fatalf("attrs = %v, want %v", a.attrs, want)
+	}
+	for k, v := range want {
+		if a.attrs[k] != v {
+			t.Errorf("attrs[%q] = %v, want %v", k, a.attrs[k], v)
+		}
+	}
+	if b := <-st.queue; b.pagerDuty("key").DedupKey == a.pagerDuty("key").DedupKey {
+		t.Errorf("PagerDuty dedup key %q shared across groups", a.pagerDuty("key").DedupKey)
+	}
+}
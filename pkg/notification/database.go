@@ -0,0 +1,146 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/migration"
+)
+
+// ------------------- Database channel -------------------
+
+// Record is a stored notification: an in-app notification written by the
+// "database" channel, or an item waiting for a digest (see DigestMail).
+type Record struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Notifiable string     `gorm:"size:191;not null;index" json:"notifiable"`
+	Type       string     `gorm:"size:191;not null" json:"type"`
+	Message    string     `gorm:"type:text" json:"message"`
+	Data       string     `gorm:"type:text" json:"data,omitempty"` // JSON
+	Digest     string     `gorm:"size:20;not null;default:'';index" json:"-"`
+	Locale     string     `gorm:"size:20" json:"-"`
+	DigestedAt *time.Time `json:"-"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreatedAt  time.Time  `gorm:"index" json:"created_at"`
+}
+
+func (Record) TableName() string { return "kashvi_notifications" }
+
+var (
+	dbMu sync.RWMutex
+	dbOv *gorm.DB
+)
+
+// UseDB sets the database for the database and digest channels; it
+// defaults to database.DB.
+func UseDB(db *gorm.DB) {
+	dbMu.Lock()
+	dbOv = db
+	dbMu.Unlock()
+}
+
+func currentDB() (*gorm.DB, error) {
+	dbMu.RLock()
+	db := dbOv
+	dbMu.RUnlock()
+	if db == nil {
+		db = database.DB
+	}
+	if db == nil {
+		return nil, fmt.Errorf("notification: database not connected")
+	}
+	return db, nil
+}
+
+// typeName names a notification by its type without the pointer star:
+// "notifications.WelcomeNotification".
+func typeName(n Notification) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", n), "*")
+}
+
+func sendDatabase(address string, n Notification) error {
+	d, ok := n.(Databaseable)
+	if !ok {
+		return fmt.Errorf("notification: %T does not implement Databaseable", n)
+	}
+	data := d.ToDatabase()
+	rec := Record{Notifiable: address, Type: data.Type, Message: data.Message}
+	if rec.Type == "" {
+		rec.Type = typeName(n)
+	}
+	if data.Data != nil {
+		raw, err := json.Marshal(data.Data)
+		if err != nil {
+			return fmt.Errorf("notification: database marshal: %w", err)
+		}
+		rec.Data = string(raw)
+	}
+	return store(&rec)
+}
+
+func store(rec *Record) error {
+	db, err := currentDB()
+	if err != nil {
+		return err
+	}
+	if err := db.Create(rec).Error; err != nil {
+		return fmt.Errorf("notification: store: %w", err)
+	}
+	return nil
+}
+
+// Unread returns notifiable's unread in-app notifications, newest first.
+func Unread(ctx context.Context, notifiable string) ([]Record, error) {
+	db, err := currentDB()
+	if err != nil {
+		return nil, err
+	}
+	var recs []Record
+	err = db.WithContext(ctx).
+		Where("notifiable = ? AND digest = '' AND read_at IS NULL", notifiable).
+		Order("created_at DESC, id DESC").Find(&recs).Error
+	if err != nil {
+		return nil, fmt.Errorf("notification: unread: %w", err)
+	}
+	return recs, nil
+}
+
+// MarkRead marks notifiable's notifications with the given IDs as read.
+func MarkRead(ctx context.Context, notifiable string, ids ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	db, err := currentDB()
+	if err != nil {
+		return err
+	}
+	err = db.WithContext(ctx).Model(&Record{}).
+		Where("notifiable = ? AND id IN ? AND read_at IS NULL", notifiable, ids).
+		Update("read_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("notification: mark read: %w", err)
+	}
+	return nil
+}
+
+// ------------------- Migration -------------------
+
+// Migration creates the kashvi_notifications table. It is registered
+// automatically when pkg/notification is imported, so `kashvi migrate`
+// picks it up.
+type Migration struct{}
+
+func (Migration) Up(db *gorm.DB) error { return db.AutoMigrate(&Record{}) }
+
+func (Migration) Down(db *gorm.DB) error { return db.Migrator().DropTable(&Record{}) }
+
+func init() {
+	migration.Register("20261018000200_create_kashvi_notifications_table", Migration{})
+}
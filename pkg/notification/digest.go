@@ -0,0 +1,223 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/lang"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// ------------------- Digests -------------------
+
+// Digest channels hold notifications in kashvi_notifications and send one
+// summary per recipient when FlushDigests runs, instead of a message each:
+//
+//	func (n *CommentAdded) Via() []string { return []string{"database", notification.DigestMail} }
+//
+//	schedule.Every(5).Minutes().Run(notification.DigestTask(time.Hour))
+const (
+	DigestMail  = "digest:mail"
+	DigestSlack = "digest:slack"
+)
+
+// DigestTemplate is the template a digest summary is rendered from when it
+// exists (digest.tmpl, digest.mail.tmpl, digest.slack.tmpl), with
+// DigestData as data. Without it a plain list is sent.
+var DigestTemplate = "digest"
+
+// Digestable supplies the line a notification adds to a digest. Without it
+// the line is ToDatabase's Message, then the mail subject, then the Slack
+// text.
+type Digestable interface {
+	ToDigest() string
+}
+
+// DigestData is the template data of a digest summary.
+type DigestData struct {
+	Notifiable string
+	Items      []Record // oldest first
+}
+
+// Count returns the number of items in the digest.
+func (d DigestData) Count() int { return len(d.Items) }
+
+// Template implements Templated.
+func (DigestData) Template() string { return DigestTemplate }
+
+func queueDigest(address, channel, locale string, n Notification) error {
+	line, err := digestLine(n, locale)
+	if err != nil {
+		return err
+	}
+	return store(&Record{
+		Notifiable: address,
+		Type:       typeName(n),
+		Message:    line,
+		Digest:     channel,
+		Locale:     locale,
+	})
+}
+
+func digestLine(n Notification, locale string) (string, error) {
+	if d, ok := n.(Digestable); ok {
+		return d.ToDigest(), nil
+	}
+	if d, ok := n.(Databaseable); ok {
+		if msg := d.ToDatabase().Message; msg != "" {
+			return msg, nil
+		}
+	}
+	if d, err := mailData(n, locale); err == nil && d.Subject != "" {
+		return d.Subject, nil
+	}
+	if d, err := slackData(n, locale); err == nil && d.Text != "" {
+		return d.Text, nil
+	}
+	return "", fmt.Errorf("notification: %T has no digest line; implement Digestable", n)
+}
+
+// FlushDigests sends a summary for every recipient and digest channel whose
+// oldest waiting item is at least window old, so each recipient hears at
+// most about once per window. It returns how many summaries were sent.
+// Items are marked as sent only after a successful send; failures are
+// retried on the next run.
+func FlushDigests(ctx context.Context, window time.Duration) (int, error) {
+	db, err := currentDB()
+	if err != nil {
+		return 0, err
+	}
+	var pending []Record
+	err = db.WithContext(ctx).
+		Where("digest <> '' AND digested_at IS NULL").
+		Order("created_at, id").Find(&pending).Error
+	if err != nil {
+		return 0, fmt.Errorf("notification: digest: %w", err)
+	}
+
+	type groupKey struct{ notifiable, channel string }
+	var order []groupKey
+	groups := map[groupKey][]Record{}
+	for _, r := range pending {
+		k := groupKey{r.Notifiable, r.Digest}
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], r)
+	}
+
+	cutoff := time.Now().Add(-window)
+	var (
+		sent int
+		errs []error
+	)
+	for _, k := range order {
+		items := groups[k]
+		if items[0].CreatedAt.After(cutoff) {
+			continue
+		}
+		if err := sendDigest(k.notifiable, k.channel, items); err != nil {
+			errs = append(errs, fmt.Errorf("notification: digest for %s: %w", k.notifiable, err))
+			continue
+		}
+		ids := make([]uint, len(items))
+		for i, r := range items {
+			ids[i] = r.ID
+		}
+		err := db.WithContext(ctx).Model(&Record{}).Where("id IN ?", ids).
+			Update("digested_at", time.Now()).Error
+		if err != nil {
+			errs = append(errs, fmt.Errorf("notification: digest for %s: mark sent: %w", k.notifiable, err))
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// DigestTask returns a function that flushes digests, for the scheduler;
+// it logs failures instead of returning them. Run it more often than
+// window so summaries go out soon after their window closes.
+func DigestTask(window time.Duration) func() {
+	return func() {
+		n, err := FlushDigests(context.Background(), window)
+		if err != nil {
+			logger.Error("notification: digest flush failed", "sent", n, "error", err)
+			return
+		}
+		if n > 0 {
+			logger.Info("notification: digests sent", "count", n)
+		}
+	}
+}
+
+func sendDigest(address, channel string, items []Record) error {
+	data := DigestData{Notifiable: address, Items: items}
+	locale := items[len(items)-1].Locale
+	switch channel {
+	case DigestMail:
+		d, err := digestMail(data, locale)
+		if err != nil {
+			return err
+		}
+		return sendMail(address, d)
+	case DigestSlack:
+		d, err := digestSlack(data, locale)
+		if err != nil {
+			return err
+		}
+		return sendSlack(d)
+	default:
+		return fmt.Errorf("notification: unknown digest channel %q", channel)
+	}
+}
+
+// digestSubject is "You have 3 new notifications", or the
+// notifications.digest.subject translation with :count.
+func digestSubject(locale string, count int) string {
+	if lang.Has(locale, "notifications.digest.subject") {
+		return lang.Get(locale, "notifications.digest.subject", "count", count)
+	}
+	return fmt.Sprintf("You have %d new notifications", count)
+}
+
+func digestMail(data DigestData, locale string) (MailData, error) {
+	files, err := templateFiles(DigestTemplate, "mail")
+	if err != nil {
+		return MailData{}, err
+	}
+	if len(files) > 0 {
+		r, err := render(data, "mail", locale, "subject", "html", "text")
+		return MailData{Subject: r["subject"], Body: r["html"], Text: r["text"]}, err
+	}
+	subject := digestSubject(locale, data.Count())
+	var body, text strings.Builder
+	body.WriteString("<p>" + html.EscapeString(subject) + "</p>\n<ul>\n")
+	for _, r := range data.Items {
+		body.WriteString("<li>" + html.EscapeString(r.Message) + "</li>\n")
+		text.WriteString("- " + r.Message + "\n")
+	}
+	body.WriteString("</ul>")
+	return MailData{Subject: subject, Body: body.String(), Text: text.String()}, nil
+}
+
+func digestSlack(data DigestData, locale string) (SlackData, error) {
+	files, err := templateFiles(DigestTemplate, "slack")
+	if err != nil {
+		return SlackData{}, err
+	}
+	if len(files) > 0 {
+		r, err := render(data, "slack", locale, "slack")
+		return SlackData{Text: r["slack"]}, err
+	}
+	var text strings.Builder
+	text.WriteString(digestSubject(locale, data.Count()))
+	for _, r := range data.Items {
+		text.WriteString("\n• " + r.Message)
+	}
+	return SlackData{Text: text.String()}, nil
+}
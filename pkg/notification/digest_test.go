@@ -0,0 +1,78 @@
+package notification_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/mail"
+	"github.com/shashiranjanraj/kashvi/pkg/notification"
+)
+
+type commentAdded struct{ By string }
+
+func (commentAdded) Via() []string      { return []string{"database", notification.DigestMail} }
+func (c commentAdded) ToDigest() string { return c.By + " commented" }
+func (c commentAdded) ToDatabase() notification.DatabaseData {
+	return notification.DatabaseData{Message: c.By + " commented on your post"}
+}
+
+func TestFlushDigests_OneSummaryPerRecipient(t *testing.T) {
+	db, err := database.OpenMemory("notification_digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (notification.Migration{}).Up(db); err != nil {
+		t.Fatal(err)
+	}
+	notification.UseDB(db)
+	notification.SetTemplateDir(t.TempDir())
+	mail.SetDriver(mail.DriverArray)
+	t.Cleanup(func() { notification.UseDB(nil); mail.SetDriver(""); mail.ResetSent() })
+	ctx := context.Background()
+
+	for _, n := range []struct{ to, by string }{
+		{"alice@example.com", "<Bob>"}, {"alice@example.com", "Carol"}, {"dan@example.com", "Erin"},
+	} {
+		if errs := notification.Send(n.to, commentAdded{By: n.by}); len(errs) > 0 {
+			t.Fatal(errs)
+		}
+	}
+	if len(mail.Sent()) != 0 {
+		t.Fatal("digest channel sent mail immediately")
+	}
+
+	if n, err := notification.FlushDigests(ctx, time.Hour); err != nil || n != 0 {
+		t.Fatalf("flush before window closed: %d, %v", n, err)
+	}
+	if n, err := notification.FlushDigests(ctx, 0); err != nil || n != 2 {
+		t.Fatalf("flush = %d, %v", n, err)
+	}
+	sent := mail.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d mails", len(sent))
+	}
+	alice := sent[0]
+	if alice.To[0] != "alice@example.com" || alice.Subject != "You have 2 new notifications" {
+		t.Fatalf("alice got %q to %v", alice.Subject, alice.To)
+	}
+	if !strings.Contains(alice.Body, "<li>&lt;Bob&gt; commented</li>") || !strings.Contains(alice.Body, "Carol commented") {
+		t.Fatalf("alice body = %q", alice.Body)
+	}
+	if n, _ := notification.FlushDigests(ctx, 0); n != 0 {
+		t.Fatalf("items sent twice: %d", n)
+	}
+
+	unread, err := notification.Unread(ctx, "alice@example.com")
+	if err != nil || len(unread) != 2 || unread[0].Message != "Carol commented on your post" {
+		t.Fatalf("unread = %+v, %v", unread, err)
+	}
+	if err := notification.MarkRead(ctx, "alice@example.com", unread[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if unread, _ := notification.Unread(ctx, "alice@example.com"); len(unread) != 1 {
+		t.Fatalf("after MarkRead: %d unread", len(unread))
+	}
+}
@@ -17,6 +17,11 @@
 // Send:
 //
 //	notification.Send("user@example.com", &WelcomeNotification{User: user})
+//
+// Multi-language products render content from templates in the recipient's
+// locale instead (see Templated):
+//
+//	notification.SendIn(user.Locale, user.Email, &WelcomeNotification{User: user})
 package notification
 
 import (
@@ -24,6 +29,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/shashiranjanraj/kashvi/pkg/logger"
@@ -62,6 +68,16 @@ type WebhookData struct {
 	Headers map[string]string
 }
 
+// PagerDutyData carries a PagerDuty Events API v2 trigger.
+type PagerDutyData struct {
+	RoutingKey string // integration key; overrides the default if set
+	Summary    string
+	Source     string // affected system, defaults to the host name
+	Severity   string // "critical" | "error" | "warning" | "info"
+	DedupKey   string // incidents with the same key are grouped
+	Details    map[string]any
+}
+
 // DatabaseData carries the data to be stored in a notifications table.
 type DatabaseData struct {
 	Type    string
@@ -73,7 +89,8 @@ type DatabaseData struct {
 
 // Notification is the interface every notification must satisfy.
 type Notification interface {
-	// Via returns the list of channel names: "mail", "slack", "webhook", "database".
+	// Via returns the list of channel names: "mail", "slack", "webhook",
+	// "pagerduty", "database", DigestMail or DigestSlack.
 	Via() []string
 }
 
@@ -92,6 +109,11 @@ type Webhookable interface {
 	ToWebhook() WebhookData
 }
 
+// PagerDutyable can be implemented to support the PagerDuty channel.
+type PagerDutyable interface {
+	ToPagerDuty() PagerDutyData
+}
+
 // Databaseable can be implemented to store the notification in the DB.
 type Databaseable interface {
 	ToDatabase() DatabaseData
@@ -99,19 +121,33 @@ type Databaseable interface {
 
 // ------------------- Global config -------------------
 
-var defaultSlackWebhook string
+var (
+	defaultSlackWebhook string
+	defaultPagerDutyKey string
+)
 
 // SetSlackWebhook sets the default Slack incoming webhook URL.
 func SetSlackWebhook(url string) { defaultSlackWebhook = url }
 
+// SetPagerDutyKey sets the default PagerDuty Events API v2 routing key.
+func SetPagerDutyKey(key string) { defaultPagerDutyKey = key }
+
 // ------------------- Send -------------------
 
 // Send dispatches the notification through all channels returned by Via().
 // address is typically an email address used for the mail channel.
+// Templated content is rendered in the fallback locale; use SendIn for a
+// recipient with a known locale.
 func Send(address string, n Notification) []error {
+	return SendIn("", address, n)
+}
+
+// SendIn is Send with templated content rendered in locale (e.g. the
+// user's "fr-CA"); see Templated.
+func SendIn(locale, address string, n Notification) []error {
 	var errs []error
 	for _, channel := range n.Via() {
-		if err := dispatch(address, channel, n); err != nil {
+		if err := dispatch(address, channel, locale, n); err != nil {
 			logger.Error("notification: channel failed",
 				"channel", channel, "error", err)
 			errs = append(errs, err)
@@ -122,8 +158,13 @@ func Send(address string, n Notification) []error {
 
 // SendAsync dispatches the notification in background goroutines.
 func SendAsync(address string, n Notification) {
+	SendInAsync("", address, n)
+}
+
+// SendInAsync is SendIn in a background goroutine.
+func SendInAsync(locale, address string, n Notification) {
 	go func() {
-		if errs := Send(address, n); len(errs) > 0 {
+		if errs := SendIn(locale, address, n); len(errs) > 0 {
 			for _, e := range errs {
 				logger.Error("notification: async error", "error", e)
 			}
@@ -131,21 +172,21 @@ func SendAsync(address string, n Notification) {
 	}()
 }
 
-func dispatch(address, channel string, n Notification) error {
+func dispatch(address, channel, locale string, n Notification) error {
 	switch channel {
 	case "mail":
-		m, ok := n.(Mailable)
-		if !ok {
-			return fmt.Errorf("notification: %T does not implement Mailable", n)
+		d, err := mailData(n, locale)
+		if err != nil {
+			return err
 		}
-		return sendMail(address, m.ToMail())
+		return sendMail(address, d)
 
 	case "slack":
-		s, ok := n.(Slackable)
-		if !ok {
-			return fmt.Errorf("notification: %T does not implement Slackable", n)
+		d, err := slackData(n, locale)
+		if err != nil {
+			return err
 		}
-		return sendSlack(s.ToSlack())
+		return sendSlack(d)
 
 	case "webhook":
 		wh, ok := n.(Webhookable)
@@ -154,6 +195,19 @@ func dispatch(address, channel string, n Notification) error {
 		}
 		return sendWebhook(wh.ToWebhook())
 
+	case "pagerduty":
+		p, ok := n.(PagerDutyable)
+		if !ok {
+			return fmt.Errorf("notification: %T does not implement PagerDutyable", n)
+		}
+		return sendPagerDuty(p.ToPagerDuty())
+
+	case "database":
+		return sendDatabase(address, n)
+
+	case DigestMail, DigestSlack:
+		return queueDigest(address, channel, locale, n)
+
 	default:
 		return fmt.Errorf("notification: unknown channel %q", channel)
 	}
@@ -214,6 +268,48 @@ func sendSlack(d SlackData) error {
 	return nil
 }
 
+// ------------------- PagerDuty channel -------------------
+
+// pagerDutyURL is the Events API v2 endpoint (a var so tests can redirect it).
+var pagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+func sendPagerDuty(d PagerDutyData) error {
+	key := d.RoutingKey
+	if key == "" {
+		key = defaultPagerDutyKey
+	}
+	if key == "" {
+		return fmt.Errorf("notification: pagerduty routing key not configured")
+	}
+	source := d.Source
+	if source == "" {
+		source, _ = os.Hostname()
+	}
+	severity := d.Severity
+	if severity == "" {
+		severity = "error"
+	}
+
+	event := map[string]any{
+		"routing_key":  key,
+		"event_action": "trigger",
+		"payload": map[string]any{
+			"summary":        d.Summary,
+			"source":         source,
+			"severity":       severity,
+			"custom_details": d.Details,
+		},
+	}
+	if d.DedupKey != "" {
+		event["dedup_key"] = d.DedupKey
+	}
+
+	if err := sendWebhook(WebhookData{URL: pagerDutyURL, Payload: event}); err != nil {
+		return fmt.Errorf("notification: pagerduty: %w", err)
+	}
+	return nil
+}
+
 // ------------------- Webhook channel -------------------
 
 func sendWebhook(d WebhookData) error {
@@ -0,0 +1,185 @@
+package notification
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/lang"
+)
+
+// ------------------- Templated content -------------------
+
+// Templated notifications render their channel content from template files
+// instead of hard-coded strings. Template returns the template name; the
+// notification itself is the template data.
+//
+// templates/notifications/welcome.tmpl:
+//
+//	{{define "subject"}}{{t "welcome.subject" "name" .User.Name}}{{end}}
+//	{{define "html"}}<h1>{{t "welcome.greeting" "name" .User.Name}}</h1>{{end}}
+//	{{define "text"}}{{t "welcome.greeting" "name" .User.Name}}{{end}}
+//	{{define "slack"}}{{t "welcome.slack" "name" .User.Name}}{{end}}
+//
+// A channel file (welcome.mail.tmpl, welcome.slack.tmpl) is parsed after the
+// base file, so its blocks override the shared ones for that channel only;
+// either file may be absent, but not both.
+//
+// Templates get two functions: t looks up a pkg/lang key in the recipient's
+// locale (see SendIn) and locale returns that locale. The "html" block is
+// rendered with html/template escaping; the others are plain text.
+//
+// When a notification is both Templated and Mailable (or Slackable), fields
+// set by ToMail/ToSlack win and templates fill in the empty ones — use the
+// struct for recipients, webhooks and attachments, templates for wording.
+type Templated interface {
+	Template() string
+}
+
+var (
+	templateMu  sync.RWMutex
+	templateDir string
+)
+
+// SetTemplateDir overrides NOTIFICATION_TEMPLATES (default
+// "templates/notifications").
+func SetTemplateDir(dir string) {
+	templateMu.Lock()
+	templateDir = dir
+	templateMu.Unlock()
+}
+
+func currentTemplateDir() string {
+	templateMu.RLock()
+	dir := templateDir
+	templateMu.RUnlock()
+	if dir != "" {
+		return dir
+	}
+	return config.Get("NOTIFICATION_TEMPLATES", "templates/notifications")
+}
+
+// render executes the named blocks of n's templates for channel in locale.
+// Blocks the templates do not define are left out of the result.
+func render(n Templated, channel, locale string, blocks ...string) (map[string]string, error) {
+	name := n.Template()
+	files, err := templateFiles(name, channel)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("notification: no template %q for %s in %s", name, channel, currentTemplateDir())
+	}
+
+	if locale == "" {
+		locale = lang.Fallback()
+	}
+	funcs := map[string]any{
+		"t":      lang.For(locale),
+		"locale": func() string { return locale },
+	}
+	text, err := template.New(name).Funcs(funcs).ParseFiles(files...)
+	if err != nil {
+		return nil, fmt.Errorf("notification: template %s: %w", name, err)
+	}
+	html, err := htmltemplate.New(name).Funcs(funcs).ParseFiles(files...)
+	if err != nil {
+		return nil, fmt.Errorf("notification: template %s: %w", name, err)
+	}
+
+	out := map[string]string{}
+	for _, b := range blocks {
+		var buf bytes.Buffer
+		var err error
+		switch {
+		case b == "html" && html.Lookup(b) != nil:
+			err = html.ExecuteTemplate(&buf, b, n)
+		case b != "html" && text.Lookup(b) != nil:
+			err = text.ExecuteTemplate(&buf, b, n)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("notification: template %s: %s: %w", name, b, err)
+		}
+		out[b] = strings.TrimSpace(buf.String())
+	}
+	return out, nil
+}
+
+// templateFiles returns the existing files for name on channel: the shared
+// <name>.tmpl, then the <name>.<channel>.tmpl override.
+func templateFiles(name, channel string) ([]string, error) {
+	var files []string
+	for _, f := range []string{name + ".tmpl", name + "." + channel + ".tmpl"} {
+		p := filepath.Join(currentTemplateDir(), f)
+		if _, err := os.Stat(p); err == nil {
+			files = append(files, p)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("notification: template %s: %w", f, err)
+		}
+	}
+	return files, nil
+}
+
+// mailData builds the mail content for n: ToMail's fields, with empty ones
+// filled from the "subject", "html" and "text" blocks.
+func mailData(n Notification, locale string) (MailData, error) {
+	var d MailData
+	m, mailable := n.(Mailable)
+	if mailable {
+		d = m.ToMail()
+	}
+	tn, templated := n.(Templated)
+	if !templated {
+		if !mailable {
+			return d, fmt.Errorf("notification: %T does not implement Mailable", n)
+		}
+		return d, nil
+	}
+	r, err := render(tn, "mail", locale, "subject", "html", "text")
+	if err != nil {
+		return d, err
+	}
+	fill(&d.Subject, r["subject"])
+	fill(&d.Body, r["html"])
+	fill(&d.Text, r["text"])
+	return d, nil
+}
+
+// slackData builds the Slack content for n: ToSlack's fields, with an empty
+// Text filled from the "slack" block.
+func slackData(n Notification, locale string) (SlackData, error) {
+	var d SlackData
+	s, slackable := n.(Slackable)
+	if slackable {
+		d = s.ToSlack()
+	}
+	tn, templated := n.(Templated)
+	if !templated {
+		if !slackable {
+			return d, fmt.Errorf("notification: %T does not implement Slackable", n)
+		}
+		return d, nil
+	}
+	r, err := render(tn, "slack", locale, "slack")
+	if err != nil {
+		return d, err
+	}
+	fill(&d.Text, r["slack"])
+	return d, nil
+}
+
+func fill(dst *string, v string) {
+	if *dst == "" {
+		*dst = v
+	}
+}
@@ -0,0 +1,57 @@
+package notification_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/lang"
+	"github.com/shashiranjanraj/kashvi/pkg/mail"
+	"github.com/shashiranjanraj/kashvi/pkg/notification"
+)
+
+type welcome struct{ Name string }
+
+func (welcome) Via() []string    { return []string{"mail"} }
+func (welcome) Template() string { return "welcome" }
+func (welcome) ToMail() notification.MailData {
+	return notification.MailData{To: "ops@example.com"}
+}
+
+func TestSendIn_RendersTemplatesInLocale(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "welcome.tmpl"), []byte(
+		`{{define "subject"}}{{t "welcome.subject" "name" .Name}}{{end}}`+
+			`{{define "html"}}<p>shared</p>{{end}}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "welcome.mail.tmpl"), []byte(
+		`{{define "html"}}<p>{{t "welcome.subject" "name" .Name}} ({{locale}})</p>{{end}}`), 0o644)
+	notification.SetTemplateDir(dir)
+	lang.Add("en", map[string]string{"welcome.subject": "Welcome, :name"})
+	lang.Add("fr", map[string]string{"welcome.subject": "Bienvenue, :name"})
+	lang.SetFallback("en")
+	mail.SetDriver(mail.DriverArray)
+	t.Cleanup(func() { mail.SetDriver(""); mail.ResetSent() })
+
+	if errs := notification.SendIn("fr-CA", "user@example.com", welcome{Name: "<Asha>"}); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if errs := notification.Send("user@example.com", welcome{Name: "Ben"}); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	sent := mail.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d messages", len(sent))
+	}
+	fr, en := sent[0], sent[1]
+	if fr.Subject != "Bienvenue, <Asha>" || fr.To[0] != "ops@example.com" {
+		t.Errorf("fr = %q to %v", fr.Subject, fr.To)
+	}
+	if !strings.Contains(fr.Body, "Bienvenue, &lt;Asha&gt; (fr-CA)") {
+		t.Errorf("fr body = %q, want escaped mail override", fr.Body)
+	}
+	if en.Subject != "Welcome, Ben" {
+		t.Errorf("en subject = %q", en.Subject)
+	}
+}
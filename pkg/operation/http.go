@@ -0,0 +1,97 @@
+package operation
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/shashiranjanraj/kashvi/pkg/ctx"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/router"
+)
+
+// ─── HTTP ─────────────────────────────────────────────────────────────────────
+
+// BasePath is where Routes mounts the status endpoint and what Accept's
+// Location header points at.
+var BasePath = "/operations"
+
+// PollAfter is the Retry-After hint, in seconds, sent while an operation is
+// unfinished.
+var PollAfter = 2
+
+type envelope struct {
+	Status  int    `json:"status"`
+	Message string `json:"message,omitempty"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Accept starts the operation name with input and answers 202 Accepted
+// with the pending operation, a Location header for polling and a
+// Retry-After hint:
+//
+//	HTTP/1.1 202 Accepted
+//	Location: /operations/5f0c…
+//	{"status": 202, "message": "Accepted", "data": {"id": "5f0c…", "status": "pending", "progress": 0, …}}
+func Accept(c *ctx.Context, name string, input any) {
+	op, err := Start(c.Context(), name, input)
+	if err != nil {
+		logger.WithCtx(c.Context()).Error("operation: start failed", "name", name, "error", err)
+		c.Error(http.StatusInternalServerError, "could not start the operation")
+		return
+	}
+	c.SetHeader("Location", BasePath+"/"+op.ID)
+	c.SetHeader("Retry-After", strconv.Itoa(PollAfter))
+	c.JSON(http.StatusAccepted, envelope{Status: http.StatusAccepted, Message: "Accepted", Data: op})
+}
+
+// Show answers GET {BasePath}/{id} with the operation: its status and
+// progress while it runs, then its result or error. Operations started by
+// an authenticated user are only shown to that user: requests without a
+// user get 401 and other users 404, so Show must run behind the same auth
+// middleware as the routes that start operations.
+func Show(c *ctx.Context) {
+	op, err := Find(c.Context(), c.Param("id"))
+	if errors.Is(err, ErrNotFound) {
+		c.NotFound("operation not found")
+		return
+	}
+	if err != nil {
+		logger.WithCtx(c.Context()).Error("operation: show failed", "id", c.Param("id"), "error", err)
+		c.Error(http.StatusInternalServerError, "could not load the operation")
+		return
+	}
+	if op.Owner != "" {
+		switch user := owner(c.Context()); {
+		case user == "":
+			c.Unauthorized()
+			return
+		case user != op.Owner:
+			c.NotFound("operation not found")
+			return
+		}
+	}
+	if !op.Status.Done() {
+		c.SetHeader("Retry-After", strconv.Itoa(PollAfter))
+	}
+	c.Success(op)
+}
+
+// Routes mounts Show at GET {BasePath}/{id}, named "operations.show",
+// without middleware. It suits operations started anonymously; see
+// RoutesWith for ones started by authenticated users.
+//
+//	app.New().Routes(operation.Routes)
+func Routes(r *router.Router) {
+	RoutesWith()(r)
+}
+
+// RoutesWith is Routes with mw, typically the auth middleware of the
+// routes that start operations, run before Show:
+//
+//	app.New().Routes(operation.RoutesWith(middleware.AuthMiddleware))
+func RoutesWith(mw ...router.Middleware) func(*router.Router) {
+	return func(r *router.Router) {
+		r.Get(BasePath+"/{id}", "operations.show", ctx.Wrap(Show), mw...)
+	}
+}
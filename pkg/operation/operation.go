@@ -0,0 +1,378 @@
+// Package operation implements the long-running operation pattern: the
+// request that starts slow work answers 202 Accepted with an operation ID,
+// the work runs as a queue job that reports its progress, and clients poll
+// GET /operations/{id} for the status and, once done, the result.
+//
+// Register the work once at boot, start it from a handler and mount the
+// status route:
+//
+//	operation.Register("reports.export", func(ctx context.Context, p *operation.Progress, in ExportInput) (any, error) {
+//	    for i, month := range in.Months {
+//	        p.Update(ctx, 100*i/len(in.Months), "exporting "+month)
+//	        …
+//	    }
+//	    return map[string]string{"url": url}, nil
+//	})
+//
+//	r.Post("/reports/export", "reports.export", ctx.Wrap(func(c *ctx.Context) {
+//	    var in ExportInput
+//	    if !c.BindJSON(&in) {
+//	        return
+//	    }
+//	    operation.Accept(c, "reports.export", in)
+//	}))
+//	operation.Routes(r) // GET /operations/{id}
+//
+// Operations are stored in the kashvi_operations table, whose migration is
+// registered when the package is imported. A queue worker must be running
+// (`kashvi queue:work`, or the in-process workers of `kashvi serve`).
+package operation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	kerrors "github.com/shashiranjanraj/kashvi/pkg/errors"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+	"github.com/shashiranjanraj/kashvi/pkg/migration"
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
+)
+
+// Status is the state of an operation.
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // queued, not picked up yet
+	StatusRunning   Status = "running"   // a worker is processing it
+	StatusSucceeded Status = "succeeded" // done; Result holds the outcome
+	StatusFailed    Status = "failed"    // done; Error says why
+)
+
+// Done reports whether s is final.
+func (s Status) Done() bool { return s == StatusSucceeded || s == StatusFailed }
+
+// Operation is one run of a registered handler.
+type Operation struct {
+	ID    string `gorm:"primaryKey;size:32" json:"id"`
+	Name  string `gorm:"size:191;not null;index" json:"name"`
+	Owner string `gorm:"size:191;not null;default:'';index" json:"-"`
+	// Status, Progress (0–100) and Message are what pollers show.
+	Status   Status `gorm:"size:16;not null;index" json:"status"`
+	Progress int    `gorm:"not null;default:0" json:"progress"`
+	Message  string `gorm:"size:255" json:"message,omitempty"`
+	// Input and Result are JSON.
+	Input      string     `gorm:"type:text" json:"-"`
+	Result     string     `gorm:"type:text" json:"-"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt  time.Time  `gorm:"index" json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+func (Operation) TableName() string { return "kashvi_operations" }
+
+// MarshalJSON inlines Result as JSON rather than a string.
+func (op Operation) MarshalJSON() ([]byte, error) {
+	type plain Operation
+	var result json.RawMessage
+	if op.Result != "" {
+		result = json.RawMessage(op.Result)
+	}
+	return json.Marshal(struct {
+		plain
+		Result json.RawMessage `json:"result,omitempty"`
+	}{plain(op), result})
+}
+
+// Decode unmarshals a succeeded operation's result into v.
+func (op *Operation) Decode(v any) error {
+	if op.Status != StatusSucceeded {
+		return fmt.Errorf("operation: %s is %s", op.ID, op.Status)
+	}
+	return json.Unmarshal([]byte(op.Result), v)
+}
+
+// ─── Database ─────────────────────────────────────────────────────────────────
+
+var (
+	dbMu sync.RWMutex
+	dbOv *gorm.DB
+)
+
+// UseDB sets the database holding operations; it defaults to database.DB.
+func UseDB(db *gorm.DB) {
+	dbMu.Lock()
+	dbOv = db
+	dbMu.Unlock()
+}
+
+func currentDB() (*gorm.DB, error) {
+	dbMu.RLock()
+	db := dbOv
+	dbMu.RUnlock()
+	if db == nil {
+		db = database.DB
+	}
+	if db == nil {
+		return nil, errors.New("operation: database not connected")
+	}
+	return db, nil
+}
+
+// ErrNotFound is returned by Find for an unknown ID.
+var ErrNotFound = errors.New("operation: not found")
+
+// Find returns the operation with id.
+func Find(ctx context.Context, id string) (*Operation, error) {
+	db, err := currentDB()
+	if err != nil {
+		return nil, err
+	}
+	var op Operation
+	err = db.WithContext(ctx).Where("id = ?", id).First(&op).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("operation: find %s: %w", id, err)
+	}
+	return &op, nil
+}
+
+func update(ctx context.Context, id string, fields map[string]any) error {
+	db, err := currentDB()
+	if err != nil {
+		return err
+	}
+	if err := db.WithContext(ctx).Model(&Operation{}).Where("id = ?", id).Updates(fields).Error; err != nil {
+		return fmt.Errorf("operation: update %s: %w", id, err)
+	}
+	return nil
+}
+
+// Prune deletes finished operations older than olderThan and returns how
+// many were removed. Schedule it daily:
+//
+//	schedule.Daily().Run(func() { operation.Prune(ctx, 7*24*time.Hour) })
+func Prune(ctx context.Context, olderThan time.Duration) (int64, error) {
+	db, err := currentDB()
+	if err != nil {
+		return 0, err
+	}
+	res := db.WithContext(ctx).
+		Where("status IN ? AND finished_at < ?", []Status{StatusSucceeded, StatusFailed}, time.Now().Add(-olderThan)).
+		Delete(&Operation{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("operation: prune: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+// ─── Handlers ─────────────────────────────────────────────────────────────────
+
+// Handler does an operation's work: it decodes its input into T, reports
+// progress through p and returns the result, which is stored as JSON.
+// A returned error fails the operation; retry transient failures inside the
+// handler.
+type Handler[T any] func(ctx context.Context, p *Progress, input T) (any, error)
+
+type runFunc func(ctx context.Context, p *Progress, input []byte) (any, error)
+
+var (
+	regMu    sync.RWMutex
+	handlers = map[string]runFunc{}
+)
+
+// Register makes fn available as the operation called name. Call it at boot
+// in every process that runs queue workers.
+func Register[T any](name string, fn Handler[T]) {
+	regMu.Lock()
+	defer regMu.Unlock()
+	handlers[name] = func(ctx context.Context, p *Progress, raw []byte) (any, error) {
+		var input T
+		if err := json.Unmarshal(raw, &input); err != nil {
+			return nil, fmt.Errorf("decode input: %w", err)
+		}
+		return fn(ctx, p, input)
+	}
+}
+
+func lookup(name string) (runFunc, bool) {
+	regMu.RLock()
+	defer regMu.RUnlock()
+	fn, ok := handlers[name]
+	return fn, ok
+}
+
+// Names returns the registered operation names, sorted.
+func Names() []string {
+	regMu.RLock()
+	defer regMu.RUnlock()
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Progress reports a running operation's progress.
+type Progress struct {
+	id string
+}
+
+// ID is the operation's ID.
+func (p *Progress) ID() string { return p.id }
+
+// Update records percent (clamped to 0–100) and a short message for
+// pollers. Each call is one database write, so call it per step, not per
+// row.
+func (p *Progress) Update(ctx context.Context, percent int, message string) error {
+	percent = min(max(percent, 0), 100)
+	return update(ctx, p.id, map[string]any{"progress": percent, "message": truncate(message, 255)})
+}
+
+// ─── Starting ─────────────────────────────────────────────────────────────────
+
+// Start stores a pending operation for the registered handler name and
+// queues it. The operation belongs to the authenticated user in ctx, if
+// any; Show only returns it to them.
+func Start(ctx context.Context, name string, input any) (*Operation, error) {
+	if _, ok := lookup(name); !ok {
+		return nil, fmt.Errorf("operation: %q is not registered", name)
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("operation: marshal input: %w", err)
+	}
+	db, err := currentDB()
+	if err != nil {
+		return nil, err
+	}
+
+	op := &Operation{ID: newID(), Name: name, Owner: owner(ctx), Status: StatusPending, Input: string(raw)}
+	if err := db.WithContext(ctx).Create(op).Error; err != nil {
+		return nil, fmt.Errorf("operation: create: %w", err)
+	}
+	if err := queue.DispatchCtx(ctx, Job{ID: op.ID}); err != nil {
+		finish(ctx, op.ID, StatusFailed, "", "could not be queued")
+		return nil, fmt.Errorf("operation: dispatch %s: %w", op.ID, err)
+	}
+	return op, nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// owner is the authenticated user ID in ctx, or "".
+func owner(ctx context.Context) string {
+	if id, ok := middleware.UserIDFromContext(ctx); ok {
+		return strconv.FormatUint(uint64(id), 10)
+	}
+	return ""
+}
+
+// ─── Job ──────────────────────────────────────────────────────────────────────
+
+// Job runs one operation. Start dispatches it; it is registered with the
+// queue when the package is imported.
+type Job struct {
+	ID string `json:"id"`
+}
+
+// Handle runs the operation without a request context.
+func (j Job) Handle() error { return j.HandleCtx(context.Background()) }
+
+// HandleCtx runs the operation's handler and records the outcome. Failing
+// to read or write the operation is returned so the queue retries; the
+// handler's own failure is recorded and not retried.
+func (j Job) HandleCtx(ctx context.Context) error {
+	op, err := Find(ctx, j.ID)
+	if errors.Is(err, ErrNotFound) {
+		logger.WithCtx(ctx).Warn("operation: job for a missing operation", "id", j.ID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if op.Status.Done() {
+		return nil // a redelivered job
+	}
+	fn, ok := lookup(op.Name)
+	if !ok {
+		return finish(ctx, op.ID, StatusFailed, "", fmt.Sprintf("operation %q is not registered in this worker", op.Name))
+	}
+	if err := update(ctx, op.ID, map[string]any{"status": StatusRunning, "started_at": time.Now()}); err != nil {
+		return err
+	}
+
+	result, err := run(ctx, fn, &Progress{id: op.ID}, []byte(op.Input))
+	if err != nil {
+		logger.WithCtx(ctx).Warn("operation: failed", "id", op.ID, "name", op.Name, "error", err)
+		return finish(ctx, op.ID, StatusFailed, "", err.Error())
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return finish(ctx, op.ID, StatusFailed, "", "marshal result: "+err.Error())
+	}
+	return finish(ctx, op.ID, StatusSucceeded, string(raw), "")
+}
+
+// run calls fn, turning a panic into an error.
+func run(ctx context.Context, fn runFunc, p *Progress, input []byte) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			kerrors.Report(ctx, kerrors.Event{Panic: r, Tags: map[string]string{"operation.id": p.id}})
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, p, input)
+}
+
+func finish(ctx context.Context, id string, status Status, result, errMsg string) error {
+	fields := map[string]any{"status": status, "result": result, "error": errMsg, "finished_at": time.Now()}
+	if status == StatusSucceeded {
+		fields["progress"] = 100
+	}
+	return update(ctx, id, fields)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
+
+// ─── Migration ────────────────────────────────────────────────────────────────
+
+// Migration creates the kashvi_operations table. It is registered
+// automatically when pkg/operation is imported, so `kashvi migrate` picks
+// it up.
+type Migration struct{}
+
+func (Migration) Up(db *gorm.DB) error { return db.AutoMigrate(&Operation{}) }
+
+func (Migration) Down(db *gorm.DB) error { return db.Migrator().DropTable(&Operation{}) }
+
+func init() {
+	migration.Register("20261018000300_create_kashvi_operations_table", Migration{})
+	queue.RegisterJobs(Job{})
+}
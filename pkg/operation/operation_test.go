@@ -0,0 +1,190 @@
+package operation_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/auth"
+	"github.com/shashiranjanraj/kashvi/pkg/ctx"
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+	"github.com/shashiranjanraj/kashvi/pkg/operation"
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
+	"github.com/shashiranjanraj/kashvi/pkg/router"
+)
+
+type exportInput struct {
+	Months []string `json:"months"`
+}
+
+func setup(t *testing.T) http.Handler {
+	t.Helper()
+	db, err := database.OpenMemory("operation_" + t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (operation.Migration{}).Up(db); err != nil {
+		t.Fatal(err)
+	}
+	operation.UseDB(db)
+	t.Cleanup(func() { operation.UseDB(nil) })
+
+	operation.Register("reports.export", func(ctx context.Context, p *operation.Progress, in exportInput) (any, error) {
+		for i, m := range in.Months {
+			if err := p.Update(ctx, 100*i/len(in.Months), "exporting "+m); err != nil {
+				return nil, err
+			}
+		}
+		if len(in.Months) == 0 {
+			return nil, errors.New("no months given")
+		}
+		return map[string]int{"rows": len(in.Months)}, nil
+	})
+
+	r := router.New()
+	r.Post("/reports/export", "reports.export", ctx.Wrap(func(c *ctx.Context) {
+		var in exportInput
+		if !c.BindJSON(&in) {
+			return
+		}
+		operation.Accept(c, "reports.export", in)
+	}))
+	operation.Routes(r)
+	return r.Handler()
+}
+
+type body struct {
+	Status int `json:"status"`
+	Data   struct {
+		ID       string          `json:"id"`
+		Status   string          `json:"status"`
+		Progress int             `json:"progress"`
+		Error    string          `json:"error"`
+		Result   json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+func do(t *testing.T, h http.Handler, method, target, payload string) (*httptest.ResponseRecorder, body) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(payload)))
+	var b body
+	if err := json.Unmarshal(rec.Body.Bytes(), &b); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return rec, b
+}
+
+// work runs queue workers until the operation is finished.
+func work(t *testing.T, id string) *operation.Operation {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go queue.Work(ctx, queue.WorkerOptions{Concurrency: 1})
+	for ctx.Err() == nil {
+		op, err := operation.Find(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if op.Status.Done() {
+			return op
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("operation %s did not finish", id)
+	return nil
+}
+
+func TestAcceptRunAndShow(t *testing.T) {
+	h := setup(t)
+
+	rec, b := do(t, h, http.MethodPost, "/reports/export", `{"months": ["jan", "feb"]}`)
+	if rec.Code != http.StatusAccepted || b.Data.Status != "pending" || b.Data.ID == "" {
+		t.Fatalf("accept = %d %+v", rec.Code, b)
+	}
+	if loc := rec.Header().Get("Location"); loc != "/operations/"+b.Data.ID {
+		t.Fatalf("Location = %q", loc)
+	}
+
+	_, pending := do(t, h, http.MethodGet, "/operations/"+b.Data.ID, "")
+	if pending.Data.Status != "pending" {
+		t.Fatalf("before work: %+v", pending)
+	}
+
+	op := work(t, b.Data.ID)
+	var result struct{ Rows int }
+	if err := op.Decode(&result); err != nil || result.Rows != 2 {
+		t.Fatalf("result = %+v, %v", result, err)
+	}
+
+	rec, done := do(t, h, http.MethodGet, "/operations/"+b.Data.ID, "")
+	if rec.Code != http.StatusOK || done.Data.Status != "succeeded" || done.Data.Progress != 100 ||
+		string(done.Data.Result) != `{"rows":2}` || rec.Header().Get("Retry-After") != "" {
+		t.Fatalf("show = %d %+v", rec.Code, done)
+	}
+}
+
+func TestFailedOperation(t *testing.T) {
+	h := setup(t)
+
+	_, b := do(t, h, http.MethodPost, "/reports/export", `{"months": []}`)
+	op := work(t, b.Data.ID)
+	if op.Status != operation.StatusFailed || op.Error != "no months given" {
+		t.Fatalf("op = %+v", op)
+	}
+	if rec, _ := do(t, h, http.MethodGet, "/operations/unknown", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown id = %d", rec.Code)
+	}
+}
+
+func TestStartUnregistered(t *testing.T) {
+	setup(t)
+	if _, err := operation.Start(context.Background(), "nope", nil); err == nil {
+		t.Fatal("expected an error for an unregistered operation")
+	}
+}
+
+func TestShowOwnedOperation(t *testing.T) {
+	setup(t)
+	r := router.New()
+	r.Post("/reports/export", "reports.export", ctx.Wrap(func(c *ctx.Context) {
+		operation.Accept(c, "reports.export", exportInput{Months: []string{"jan"}})
+	}), middleware.AuthMiddleware)
+	operation.RoutesWith(middleware.AuthMiddleware)(r)
+	open := router.New()
+	operation.Routes(open)
+
+	as := func(h http.Handler, method, target string, user uint) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if user != 0 {
+			token, err := auth.GenerateToken(user, "user")
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	loc := as(r.Handler(), http.MethodPost, "/reports/export", 7).Header().Get("Location")
+	if loc == "" {
+		t.Fatal("accept did not answer with a Location")
+	}
+	if rec := as(r.Handler(), http.MethodGet, loc, 7); rec.Code != http.StatusOK {
+		t.Fatalf("owner = %d", rec.Code)
+	}
+	if rec := as(r.Handler(), http.MethodGet, loc, 8); rec.Code != http.StatusNotFound {
+		t.Fatalf("other user = %d", rec.Code)
+	}
+	if rec := as(open.Handler(), http.MethodGet, loc, 0); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated = %d", rec.Code)
+	}
+}
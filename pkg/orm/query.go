@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -28,6 +29,22 @@ func DB() *Query {
 	return &Query{db: database.DB}
 }
 
+// WithCtx returns a fresh Query bound to ctx, so the query is cancelled when
+// ctx is — typically the inbound request's context:
+//
+//	orm.WithCtx(r.Context()).Where("id = ?", id).First(&user)
+//
+// Inside a ctx handler, c.DB() does the same.
+func WithCtx(ctx context.Context) *Query {
+	return DB().WithContext(ctx)
+}
+
+// WithContext binds ctx to the query; cancellation or deadline expiry aborts
+// the statement on the database side.
+func (q *Query) WithContext(ctx context.Context) *Query {
+	return &Query{db: q.db.WithContext(ctx)}
+}
+
 // Model sets the model for the query (table resolution).
 func (q *Query) Model(v interface{}) *Query {
 	return &Query{db: q.db.Model(v)}
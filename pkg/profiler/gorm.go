@@ -0,0 +1,63 @@
+package profiler
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startKey = "profiler:start"
+
+// GormPlugin records every statement whose context carries a Profile — i.e.
+// queries built with orm.WithCtx(r.Context()) or c.DB().
+type GormPlugin struct{}
+
+// Name implements gorm.Plugin.
+func (GormPlugin) Name() string { return "kashvi:profiler" }
+
+// Initialize registers before/after callbacks on every statement kind.
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	errs := []error{
+		cb.Create().Before("gorm:create").Register("profiler:before_create", before),
+		cb.Create().After("gorm:create").Register("profiler:after_create", after),
+		cb.Query().Before("gorm:query").Register("profiler:before_query", before),
+		cb.Query().After("gorm:query").Register("profiler:after_query", after),
+		cb.Update().Before("gorm:update").Register("profiler:before_update", before),
+		cb.Update().After("gorm:update").Register("profiler:after_update", after),
+		cb.Delete().Before("gorm:delete").Register("profiler:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("profiler:after_delete", after),
+		cb.Row().Before("gorm:row").Register("profiler:before_row", before),
+		cb.Row().After("gorm:row").Register("profiler:after_row", after),
+		cb.Raw().Before("gorm:raw").Register("profiler:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("profiler:after_raw", after),
+	}
+	return errors.Join(errs...)
+}
+
+func before(db *gorm.DB) {
+	if FromContext(db.Statement.Context) != nil {
+		db.InstanceSet(startKey, time.Now())
+	}
+}
+
+func after(db *gorm.DB) {
+	p := FromContext(db.Statement.Context)
+	if p == nil {
+		return
+	}
+	q := Query{
+		SQL:  db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...),
+		Rows: db.RowsAffected,
+	}
+	if v, ok := db.InstanceGet(startKey); ok {
+		if start, ok := v.(time.Time); ok {
+			q.Duration = time.Since(start)
+		}
+	}
+	if db.Error != nil {
+		q.Error = db.Error.Error()
+	}
+	p.AddQuery(q)
+}
@@ -0,0 +1,55 @@
+package profiler
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Bind returns a handler like h that also copies every record into p.
+// middleware.Logger uses it for the per-request logger returned by
+// logger.WithCtx. Records logged with a context carrying a different
+// profile are recorded there instead.
+func Bind(h slog.Handler, p *Profile) slog.Handler {
+	return &logHandler{inner: h, profile: p}
+}
+
+type logHandler struct {
+	inner   slog.Handler
+	profile *Profile
+	attrs   []slog.Attr
+}
+
+func (h *logHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.inner.Enabled(ctx, l)
+}
+
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	p := h.profile
+	if cp := FromContext(ctx); cp != nil {
+		p = cp
+	}
+	if p != nil {
+		e := LogEntry{Time: r.Time, Level: r.Level.String(), Message: r.Message, Attrs: map[string]string{}}
+		for _, a := range h.attrs {
+			e.Attrs[a.Key] = a.Value.String()
+		}
+		r.Attrs(func(a slog.Attr) bool {
+			e.Attrs[a.Key] = a.Value.String()
+			return true
+		})
+		p.AddLog(e)
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{
+		inner:   h.inner.WithAttrs(attrs),
+		profile: h.profile,
+		attrs:   append(append([]slog.Attr(nil), h.attrs...), attrs...),
+	}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{inner: h.inner.WithGroup(name), profile: h.profile, attrs: h.attrs}
+}
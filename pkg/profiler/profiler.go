@@ -0,0 +1,139 @@
+// Package profiler collects per-request debugging data — SQL queries and log
+// lines — in development, so error pages can show what happened before a
+// failure.
+//
+// It is a no-op unless APP_ENV is local/dev/development. The framework wires
+// it automatically:
+//
+//	r.Use(profiler.Middleware)            // pkg/app kernel
+//	db.Use(profiler.GormPlugin{})         // database.Connect
+//	profiler.Bind(handler, profile)       // middleware.Logger
+//
+// Read the data for the current request anywhere downstream:
+//
+//	if p := profiler.FromContext(r.Context()); p != nil {
+//	    for _, q := range p.Queries() { fmt.Println(q.SQL, q.Duration) }
+//	}
+package profiler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/config"
+)
+
+// maxEntries bounds how many queries/log lines a single profile keeps.
+const maxEntries = 200
+
+// Enabled reports whether profiling is active (development environments only).
+func Enabled() bool {
+	switch config.AppEnv() {
+	case "local", "dev", "development":
+		return true
+	}
+	return false
+}
+
+// Query is one SQL statement executed while serving the request.
+type Query struct {
+	SQL      string
+	Duration time.Duration
+	Rows     int64
+	Error    string
+}
+
+// LogEntry is one log record emitted through the request logger.
+type LogEntry struct {
+	Time    time.Time
+	Level   string
+	Message string
+	Attrs   map[string]string
+}
+
+// Profile accumulates debugging data for a single request.
+type Profile struct {
+	Start time.Time
+
+	mu      sync.Mutex
+	queries []Query
+	logs    []LogEntry
+	dropped int
+}
+
+// New returns an empty profile started now.
+func New() *Profile { return &Profile{Start: time.Now()} }
+
+// AddQuery records q (silently dropping beyond the per-request cap).
+func (p *Profile) AddQuery(q Query) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.queries) >= maxEntries {
+		p.dropped++
+		return
+	}
+	p.queries = append(p.queries, q)
+}
+
+// AddLog records e (silently dropping beyond the per-request cap).
+func (p *Profile) AddLog(e LogEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.logs) >= maxEntries {
+		p.dropped++
+		return
+	}
+	p.logs = append(p.logs, e)
+}
+
+// Queries returns a copy of the recorded queries.
+func (p *Profile) Queries() []Query {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Query(nil), p.queries...)
+}
+
+// Logs returns a copy of the recorded log entries.
+func (p *Profile) Logs() []LogEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]LogEntry(nil), p.logs...)
+}
+
+// Dropped returns how many entries exceeded the cap and were discarded.
+func (p *Profile) Dropped() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dropped
+}
+
+// ─── Context ──────────────────────────────────────────────────────────────────
+
+type ctxKey struct{}
+
+// WithProfile returns a copy of ctx carrying p.
+func WithProfile(ctx context.Context, p *Profile) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext returns the profile attached to ctx, or nil.
+func FromContext(ctx context.Context) *Profile {
+	if ctx == nil {
+		return nil
+	}
+	p, _ := ctx.Value(ctxKey{}).(*Profile)
+	return p
+}
+
+// Middleware attaches a fresh Profile to every request when Enabled().
+// In other environments it passes requests through untouched.
+func Middleware(next http.Handler) http.Handler {
+	if !Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithProfile(r.Context(), New())))
+	})
+}
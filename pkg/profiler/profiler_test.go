@@ -0,0 +1,66 @@
+package profiler_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/profiler"
+)
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+func TestGormPluginRecordsRequestQueries(t *testing.T) {
+	db, err := database.OpenMemory("profiler_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close(db)
+	if err := db.Use(profiler.GormPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&widget{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a profile in the context nothing is recorded (and nothing breaks).
+	db.Create(&widget{Name: "untracked"})
+
+	p := profiler.New()
+	ctx := profiler.WithProfile(context.Background(), p)
+	db.WithContext(ctx).Create(&widget{Name: "a"})
+	var got []widget
+	db.WithContext(ctx).Where("name = ?", "a").Find(&got)
+
+	qs := p.Queries()
+	if len(qs) != 2 {
+		t.Fatalf("want 2 queries, got %d: %+v", len(qs), qs)
+	}
+	if !strings.Contains(qs[1].SQL, `name = "a"`) {
+		t.Errorf("expected bound vars in SQL, got %q", qs[1].SQL)
+	}
+	if qs[1].Rows != 1 {
+		t.Errorf("rows = %d, want 1", qs[1].Rows)
+	}
+}
+
+func TestBindRecordsLogs(t *testing.T) {
+	p := profiler.New()
+	log := slog.New(profiler.Bind(slog.NewTextHandler(io.Discard, nil), p)).With("request_id", "r1")
+	log.Info("charged", "amount", 10)
+
+	logs := p.Logs()
+	if len(logs) != 1 {
+		t.Fatalf("want 1 log entry, got %d", len(logs))
+	}
+	e := logs[0]
+	if e.Message != "charged" || e.Attrs["request_id"] != "r1" || e.Attrs["amount"] != "10" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
@@ -0,0 +1,288 @@
+// Package proxyproto implements the HAProxy PROXY protocol (v1 text and v2
+// binary) for TCP listeners, so servers behind HAProxy or an AWS NLB see the
+// real client address in conn.RemoteAddr() and therefore in r.RemoteAddr.
+//
+// Usage:
+//
+//	ln, _ := net.Listen("tcp", ":8080")
+//	ln = proxyproto.NewListener(ln, proxyproto.Options{
+//	    Trusted: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+//	})
+//	http.Serve(ln, handler)
+//
+// The header is only honoured on connections from Trusted upstreams; anyone
+// else could otherwise spoof their address. The server wires this up when
+// PROXY_PROTOCOL=true (see DefaultOptions).
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/clientip"
+)
+
+// ErrInvalidHeader is returned by Read when a trusted upstream sends a
+// malformed (or, with Required, missing) PROXY header.
+var ErrInvalidHeader = errors.New("proxyproto: invalid PROXY header")
+
+// Options configures NewListener.
+type Options struct {
+	// Trusted lists upstream networks allowed to send a PROXY header.
+	// Empty trusts every upstream — only do that when the port is not
+	// reachable except through the load balancer.
+	Trusted []netip.Prefix
+	// Required rejects connections from trusted upstreams that do not send a
+	// header. When false, such connections are served with their TCP peer
+	// address (useful during a rollout).
+	Required bool
+	// HeaderTimeout bounds how long to wait for the header. Default 5s.
+	HeaderTimeout time.Duration
+}
+
+// DefaultOptions reads PROXY_PROTOCOL_TRUSTED (comma-separated CIDRs) and
+// PROXY_PROTOCOL_REQUIRED.
+func DefaultOptions() Options {
+	return Options{
+		Trusted:  clientip.ParsePrefixes(config.Get("PROXY_PROTOCOL_TRUSTED", "")),
+		Required: config.Get("PROXY_PROTOCOL_REQUIRED", "false") == "true",
+	}
+}
+
+// Enabled reports whether PROXY_PROTOCOL=true.
+func Enabled() bool {
+	v := config.Get("PROXY_PROTOCOL", "false")
+	return v == "true" || v == "1"
+}
+
+// ─── Listener ────────────────────────────────────────────────────────────────
+
+type listener struct {
+	net.Listener
+	opts Options
+}
+
+// NewListener wraps ln so accepted connections parse a PROXY header.
+// The header is read lazily on the connection's own goroutine (first Read or
+// RemoteAddr call), so a slow client never blocks Accept.
+func NewListener(ln net.Listener, opts Options) net.Listener {
+	if opts.HeaderTimeout <= 0 {
+		opts.HeaderTimeout = 5 * time.Second
+	}
+	return &listener{Listener: ln, opts: opts}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !l.trusted(c.RemoteAddr()) {
+		return c, nil
+	}
+	return &conn{Conn: c, opts: l.opts, br: bufio.NewReader(c)}, nil
+}
+
+func (l *listener) trusted(addr net.Addr) bool {
+	if len(l.opts.Trusted) == 0 {
+		return true
+	}
+	ap, err := netip.ParseAddrPort(addr.String())
+	if err != nil {
+		return false
+	}
+	ip := ap.Addr().Unmap()
+	for _, p := range l.opts.Trusted {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ─── Conn ────────────────────────────────────────────────────────────────────
+
+type conn struct {
+	net.Conn
+	opts Options
+	br   *bufio.Reader
+
+	once   sync.Once
+	src    net.Addr
+	dst    net.Addr
+	hdrErr error
+}
+
+func (c *conn) init() {
+	c.once.Do(func() {
+		_ = c.Conn.SetReadDeadline(time.Now().Add(c.opts.HeaderTimeout))
+		c.src, c.dst, c.hdrErr = readHeader(c.br)
+		_ = c.Conn.SetReadDeadline(time.Time{})
+		if errors.Is(c.hdrErr, errNoHeader) {
+			c.hdrErr = nil
+			if c.opts.Required {
+				c.hdrErr = fmt.Errorf("%w: header required", ErrInvalidHeader)
+			}
+		}
+	})
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	c.init()
+	if c.hdrErr != nil {
+		return 0, c.hdrErr
+	}
+	return c.br.Read(b)
+}
+
+// RemoteAddr returns the client address from the PROXY header, or the TCP
+// peer when no header was sent (or it was a LOCAL/UNKNOWN command).
+func (c *conn) RemoteAddr() net.Addr {
+	c.init()
+	if c.src != nil {
+		return c.src
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// LocalAddr returns the original destination from the PROXY header, if any.
+func (c *conn) LocalAddr() net.Addr {
+	c.init()
+	if c.dst != nil {
+		return c.dst
+	}
+	return c.Conn.LocalAddr()
+}
+
+// ─── Header parsing ──────────────────────────────────────────────────────────
+
+var (
+	errNoHeader = errors.New("proxyproto: no header")
+
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// readHeader consumes a PROXY header from br. It returns errNoHeader (and
+// consumes nothing) when the stream does not start with one. src/dst are nil
+// for LOCAL / UNKNOWN headers.
+func readHeader(br *bufio.Reader) (src, dst net.Addr, err error) {
+	peek, err := br.Peek(len(v1Prefix))
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, bufio.ErrBufferFull) {
+			return nil, nil, errNoHeader
+		}
+		return nil, nil, err
+	}
+	if bytes.Equal(peek, v1Prefix) {
+		return readV1(br)
+	}
+	if peek[0] == v2Signature[0] {
+		if sig, err := br.Peek(len(v2Signature)); err == nil && bytes.Equal(sig, v2Signature) {
+			return readV2(br)
+		}
+	}
+	return nil, nil, errNoHeader
+}
+
+// readV1 parses "PROXY TCP4 src dst sport dport\r\n" (max 107 bytes).
+func readV1(br *bufio.Reader) (net.Addr, net.Addr, error) {
+	var line []byte
+	for len(line) < 107 {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, nil, fmt.Errorf("%w: v1 line too long or unterminated", ErrInvalidHeader)
+	}
+	f := strings.Fields(string(line[:len(line)-2]))
+	if len(f) >= 2 && f[1] == "UNKNOWN" {
+		return nil, nil, nil
+	}
+	if len(f) != 6 || (f[1] != "TCP4" && f[1] != "TCP6") {
+		return nil, nil, fmt.Errorf("%w: %q", ErrInvalidHeader, line)
+	}
+	src, err1 := tcpAddr(f[2], f[4])
+	dst, err2 := tcpAddr(f[3], f[5])
+	if err := errors.Join(err1, err2); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	return src, dst, nil
+}
+
+func tcpAddr(ip, port string) (*net.TCPAddr, error) {
+	a, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, err
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(a, uint16(p))), nil
+}
+
+// readV2 parses the binary header: 12-byte signature, ver/cmd, fam/proto,
+// 2-byte length, then addresses (and TLVs, which are skipped).
+func readV2(br *bufio.Reader) (net.Addr, net.Addr, error) {
+	hdr := make([]byte, 16)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	if hdr[12]>>4 != 2 {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidHeader, hdr[12]>>4)
+	}
+	cmd := hdr[12] & 0x0f
+	fam := hdr[13] >> 4
+	length := int(binary.BigEndian.Uint16(hdr[14:16]))
+	body := make([]byte, length)
+	if _, err := io.ReadFull(br, body); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	if cmd == 0 { // LOCAL: health check from the proxy itself
+		return nil, nil, nil
+	}
+	if cmd != 1 {
+		return nil, nil, fmt.Errorf("%w: unknown command %d", ErrInvalidHeader, cmd)
+	}
+
+	switch fam {
+	case 1: // AF_INET
+		if length < 12 {
+			return nil, nil, fmt.Errorf("%w: short IPv4 block", ErrInvalidHeader)
+		}
+		src := netip.AddrFrom4([4]byte(body[0:4]))
+		dst := netip.AddrFrom4([4]byte(body[4:8]))
+		return tcpFrom(src, body[8:10]), tcpFrom(dst, body[10:12]), nil
+	case 2: // AF_INET6
+		if length < 36 {
+			return nil, nil, fmt.Errorf("%w: short IPv6 block", ErrInvalidHeader)
+		}
+		src := netip.AddrFrom16([16]byte(body[0:16]))
+		dst := netip.AddrFrom16([16]byte(body[16:32]))
+		return tcpFrom(src, body[32:34]), tcpFrom(dst, body[34:36]), nil
+	default: // AF_UNSPEC / AF_UNIX: keep the TCP peer
+		return nil, nil, nil
+	}
+}
+
+func tcpFrom(a netip.Addr, port []byte) *net.TCPAddr {
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(a, binary.BigEndian.Uint16(port)))
+}
@@ -0,0 +1,109 @@
+package proxyproto_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/proxyproto"
+)
+
+// serve accepts one connection on a wrapped listener, sends raw from the
+// client side and returns the server's view of RemoteAddr and the payload.
+func serve(t *testing.T, opts proxyproto.Options, raw []byte) (string, string, error) {
+	t.Helper()
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := proxyproto.NewListener(inner, opts)
+	defer ln.Close()
+
+	go func() {
+		c, err := net.Dial("tcp", inner.Addr().String())
+		if err != nil {
+			return
+		}
+		_, _ = c.Write(raw)
+		_ = c.Close()
+	}()
+
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	_ = c.SetDeadline(time.Now().Add(2 * time.Second))
+	body, err := io.ReadAll(c)
+	return c.RemoteAddr().String(), string(body), err
+}
+
+func TestV1(t *testing.T) {
+	addr, body, err := serve(t, proxyproto.Options{}, []byte("PROXY TCP4 198.51.100.7 10.0.0.1 40000 80\r\nGET / HTTP/1.1\r\n\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != "198.51.100.7:40000" {
+		t.Errorf("RemoteAddr = %s", addr)
+	}
+	if body != "GET / HTTP/1.1\r\n\r\n" {
+		t.Errorf("payload = %q", body)
+	}
+}
+
+func TestV2(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("\r\n\r\n\x00\r\nQUIT\n")
+	buf.Write([]byte{0x21, 0x21}) // v2 PROXY, AF_INET6/STREAM
+	_ = binary.Write(&buf, binary.BigEndian, uint16(36))
+	src := netip.MustParseAddr("2001:db8::9").As16()
+	dst := netip.MustParseAddr("2001:db8::1").As16()
+	buf.Write(src[:])
+	buf.Write(dst[:])
+	_ = binary.Write(&buf, binary.BigEndian, uint16(5000))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(443))
+	buf.WriteString("hello")
+
+	addr, body, err := serve(t, proxyproto.Options{}, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != "[2001:db8::9]:5000" {
+		t.Errorf("RemoteAddr = %s", addr)
+	}
+	if body != "hello" {
+		t.Errorf("payload = %q", body)
+	}
+}
+
+func TestNoHeader(t *testing.T) {
+	addr, body, err := serve(t, proxyproto.Options{}, []byte("GET / HTTP/1.1\r\n\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr[:10] != "127.0.0.1:" || body != "GET / HTTP/1.1\r\n\r\n" {
+		t.Errorf("got %s %q", addr, body)
+	}
+
+	_, _, err = serve(t, proxyproto.Options{Required: true}, []byte("GET / HTTP/1.1\r\n\r\n"))
+	if !errors.Is(err, proxyproto.ErrInvalidHeader) {
+		t.Errorf("Required: err = %v, want ErrInvalidHeader", err)
+	}
+}
+
+func TestUntrustedUpstreamIgnoresHeader(t *testing.T) {
+	opts := proxyproto.Options{Trusted: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}
+	raw := "PROXY TCP4 198.51.100.7 10.0.0.1 40000 80\r\n"
+	addr, body, err := serve(t, opts, []byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr[:10] != "127.0.0.1:" || body != raw {
+		t.Errorf("header from untrusted peer was honoured: %s %q", addr, body)
+	}
+}
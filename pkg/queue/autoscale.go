@@ -0,0 +1,145 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/metrics"
+)
+
+// ------------------- Autoscaling -------------------
+
+// scaleDownTicks is how many consecutive intervals the pool must be larger
+// than needed before a worker is retired, so short lulls don't cause churn.
+const scaleDownTicks = 10
+
+// scaler owns the worker goroutines of one Work call and, when
+// MaxConcurrency is set, resizes them from queue depth and job latency.
+type scaler struct {
+	m     *Manager
+	opts  WorkerOptions
+	spawn func() context.CancelFunc
+
+	mu      sync.Mutex
+	workers []context.CancelFunc
+
+	busy      atomic.Int64 // workers currently running a job
+	jobs      atomic.Int64 // jobs finished since the last tick
+	busyNanos atomic.Int64 // their total run time
+
+	latency time.Duration // moving average job run time
+	low     int           // consecutive ticks with too many workers
+}
+
+// observe records one finished job.
+func (s *scaler) observe(d time.Duration) {
+	s.jobs.Add(1)
+	s.busyNanos.Add(int64(d))
+}
+
+// size returns the number of running workers.
+func (s *scaler) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.workers)
+}
+
+// resize starts or retires workers until n are running. Retired workers
+// finish their current job first.
+func (s *scaler) resize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.workers) < n {
+		s.workers = append(s.workers, s.spawn())
+	}
+	for len(s.workers) > n {
+		last := len(s.workers) - 1
+		s.workers[last]()
+		s.workers = s.workers[:last]
+	}
+}
+
+func (s *scaler) loop(ctx context.Context) {
+	interval := s.opts.ScaleInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.tick()
+		}
+	}
+}
+
+// tick resizes the pool once. With a driver that reports queue depth the
+// target is the number of workers that clears the backlog within
+// TargetDrain at the measured latency; otherwise the pool grows while every
+// worker is busy and shrinks towards the number that are.
+func (s *scaler) tick() {
+	if jobs := s.jobs.Swap(0); jobs > 0 {
+		avg := time.Duration(s.busyNanos.Swap(0) / jobs)
+		if s.latency == 0 {
+			s.latency = avg
+		} else {
+			s.latency = (s.latency*7 + avg*3) / 10
+		}
+	}
+
+	n, busy := s.size(), int(s.busy.Load())
+	want := busy
+	if depth := s.depth(); depth >= 0 {
+		drain := s.opts.TargetDrain
+		if drain <= 0 {
+			drain = 10 * time.Second
+		}
+		latency := s.latency
+		if latency <= 0 {
+			latency = drain // nothing measured yet: one worker per job
+		}
+		want += int((int64(depth)*int64(latency) + int64(drain) - 1) / int64(drain))
+	} else if busy >= n {
+		want = n + 1
+	}
+	want = max(s.opts.Concurrency, min(want, s.opts.MaxConcurrency))
+
+	switch {
+	case want > n:
+		s.low = 0
+		logger.Debug("queue: scaling workers up", "from", n, "to", want)
+		s.resize(want)
+	case want < n:
+		if s.low++; s.low >= scaleDownTicks {
+			s.low = 0
+			logger.Debug("queue: scaling workers down", "from", n, "to", n-1)
+			s.resize(n - 1)
+		}
+	default:
+		s.low = 0
+	}
+}
+
+// depth sums the ready jobs on the pool's queues, or returns -1 when the
+// driver cannot tell.
+func (s *scaler) depth() int {
+	s.m.mu.RLock()
+	d, ok := s.m.driver.(SizedDriver)
+	s.m.mu.RUnlock()
+	if !ok {
+		return -1
+	}
+	total := 0
+	for _, q := range s.opts.Queues {
+		n := d.Size(q)
+		metrics.QueueDepth.WithLabelValues(q).Set(float64(n))
+		total += n
+	}
+	return total
+}
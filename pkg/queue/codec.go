@@ -0,0 +1,44 @@
+package queue
+
+import (
+	"github.com/shashiranjanraj/kashvi/pkg/codec"
+)
+
+// ------------------- Payload codecs -------------------
+
+// envelopeVersion is the newest envelope format this release reads.
+//
+//	1 (v absent)  JSON payload
+//	2             payload encoded with the codec named in "codec"; binary
+//	              codecs are stored as a base64 string (or ciphertext)
+//
+// JSON jobs are still written as version 1, so mixed releases keep
+// exchanging them during a rolling deploy.
+const envelopeVersion = 2
+
+// marshalJob encodes job with the codec it selects (see codec.Selector),
+// recording the codec in e.
+func (e *envelope) marshalJob(job Job) ([]byte, error) {
+	name := codec.NameOf(job)
+	c, err := codec.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if name != codec.JSON {
+		e.Version, e.Codec = envelopeVersion, name
+	}
+	return c.Marshal(job)
+}
+
+// unmarshalJob decodes a plain (decrypted, unencoded) payload into job.
+func (e envelope) unmarshalJob(payload []byte, job Job) error {
+	name := e.Codec
+	if name == "" {
+		name = codec.JSON
+	}
+	c, err := codec.Lookup(name)
+	if err != nil {
+		return err
+	}
+	return c.Unmarshal(payload, job)
+}
@@ -0,0 +1,195 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JobRecord is a pending job stored by DatabaseDriver.
+type JobRecord struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	Queue       string    `gorm:"size:255;not null;index:idx_kashvi_jobs_queue_available,priority:1"`
+	Payload     string    `gorm:"type:text;not null"`
+	AvailableAt time.Time `gorm:"not null;index:idx_kashvi_jobs_queue_available,priority:2"`
+	// ReservedAt is when a worker claimed the job; nil while it waits.
+	// ReservedBy is the claim's token, so only that worker can Ack it.
+	ReservedAt *time.Time `gorm:"index"`
+	ReservedBy string     `gorm:"size:32"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+}
+
+func (JobRecord) TableName() string { return "kashvi_jobs" }
+
+// DatabaseDriver is a durable queue driver backed by the kashvi_jobs table.
+// It needs no extra infrastructure, which suits small deployments; workers
+// poll, so prefer Redis for high throughput.
+//
+// A job is claimed by stamping reserved_at on its row: only the worker whose
+// UPDATE affects the row runs it, so any number of workers can share the
+// table. The row is deleted by Ack once the job has finished, successfully
+// or not; if the worker dies first, the reservation expires after
+// ReserveFor and another worker picks the job up again.
+type DatabaseDriver struct {
+	db *gorm.DB
+	// PollInterval is how long PopFrom waits between empty polls.
+	PollInterval time.Duration
+	// ReserveFor is how long a claimed job stays hidden from other
+	// workers. Set it above the longest job's run time: a job still
+	// running when it expires is handed out a second time.
+	ReserveFor time.Duration
+
+	mu       sync.Mutex
+	reserved map[string][]reservation // payload → claims awaiting Ack
+}
+
+type reservation struct {
+	id    uint
+	token string
+}
+
+// NewDatabaseDriver migrates kashvi_jobs and returns a driver using db.
+func NewDatabaseDriver(db *gorm.DB) (*DatabaseDriver, error) {
+	if err := db.AutoMigrate(&JobRecord{}); err != nil {
+		return nil, fmt.Errorf("queue/database: migrate: %w", err)
+	}
+	return &DatabaseDriver{db: db, PollInterval: time.Second, ReserveFor: 90 * time.Second}, nil
+}
+
+// Push adds a job payload to the default queue.
+func (d *DatabaseDriver) Push(payload []byte) error {
+	return d.PushOn(DefaultQueue, payload)
+}
+
+// Pop blocks until a job is available on the default queue.
+func (d *DatabaseDriver) Pop(ctx context.Context) ([]byte, error) {
+	return d.PopFrom(ctx, []string{DefaultQueue})
+}
+
+// PushOn adds a job payload to the named queue.
+func (d *DatabaseDriver) PushOn(queue string, payload []byte) error {
+	return d.PushDelayedOn(queue, payload, 0)
+}
+
+// PushDelayedOn stores a job that becomes available after delay.
+func (d *DatabaseDriver) PushDelayedOn(queue string, payload []byte, delay time.Duration) error {
+	if queue == "" {
+		queue = DefaultQueue
+	}
+	rec := JobRecord{Queue: queue, Payload: string(payload), AvailableAt: time.Now().Add(delay)}
+	if err := d.db.Create(&rec).Error; err != nil {
+		return fmt.Errorf("queue/database: push: %w", err)
+	}
+	return nil
+}
+
+// PopFrom returns the oldest available job on queues, checking them in the
+// order given. When none is ready it waits one PollInterval and returns
+// (nil, nil), like a BRPOP timeout, so the worker loop polls again.
+func (d *DatabaseDriver) PopFrom(ctx context.Context, queues []string) ([]byte, error) {
+	payload, err := d.claim(ctx, queues)
+	if err != nil || payload != nil {
+		return payload, err
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(d.PollInterval):
+	}
+	return nil, nil
+}
+
+// Ack deletes the row of a job returned by PopFrom once it has finished.
+// Acking a job whose reservation expired and was claimed by another worker
+// is a no-op, so the job is not lost under the other worker.
+func (d *DatabaseDriver) Ack(payload []byte) error {
+	d.mu.Lock()
+	key := string(payload)
+	claims := d.reserved[key]
+	if len(claims) == 0 {
+		d.mu.Unlock()
+		return nil
+	}
+	r := claims[0]
+	if len(claims) == 1 {
+		delete(d.reserved, key)
+	} else {
+		d.reserved[key] = claims[1:]
+	}
+	d.mu.Unlock()
+
+	if err := d.db.Where("id = ? AND reserved_by = ?", r.id, r.token).Delete(&JobRecord{}).Error; err != nil {
+		return fmt.Errorf("queue/database: ack: %w", err)
+	}
+	return nil
+}
+
+// Size returns the number of jobs on queue that are ready to run.
+func (d *DatabaseDriver) Size(queue string) int {
+	var n int64
+	now := time.Now()
+	d.db.Model(&JobRecord{}).Where("queue = ? AND available_at <= ?", queue, now).
+		Where("reserved_at IS NULL OR reserved_at < ?", now.Add(-d.ReserveFor)).Count(&n)
+	return int(n)
+}
+
+// claim reserves the oldest available job, trying queues in priority order.
+// Jobs whose reservation has expired count as available again.
+func (d *DatabaseDriver) claim(ctx context.Context, queues []string) ([]byte, error) {
+	db := d.db.WithContext(ctx)
+	for _, q := range queues {
+		// Another worker may win the UPDATE; retry the queue a few times
+		// before moving on so contention does not skip ready work.
+		for attempt := 0; attempt < 3; attempt++ {
+			now := time.Now()
+			expired := now.Add(-d.ReserveFor)
+			var rec JobRecord
+			err := db.Where("queue = ? AND available_at <= ?", q, now).
+				Where("reserved_at IS NULL OR reserved_at < ?", expired).
+				Order("available_at, id").Limit(1).Find(&rec).Error
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, nil
+				}
+				return nil, fmt.Errorf("queue/database: pop: %w", err)
+			}
+			if rec.ID == 0 {
+				break
+			}
+			token := newToken()
+			res := db.Model(&JobRecord{}).
+				Where("id = ? AND (reserved_at IS NULL OR reserved_at < ?)", rec.ID, expired).
+				Updates(map[string]any{"reserved_at": now, "reserved_by": token})
+			if res.Error != nil {
+				return nil, fmt.Errorf("queue/database: pop: %w", res.Error)
+			}
+			if res.RowsAffected == 1 {
+				d.mu.Lock()
+				if d.reserved == nil {
+					d.reserved = make(map[string][]reservation)
+				}
+				// Replace this process's expired claim on the row, if any.
+				claims := d.reserved[rec.Payload][:0:0]
+				for _, r := range d.reserved[rec.Payload] {
+					if r.id != rec.ID {
+						claims = append(claims, r)
+					}
+				}
+				d.reserved[rec.Payload] = append(claims, reservation{rec.ID, token})
+				d.mu.Unlock()
+				return []byte(rec.Payload), nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
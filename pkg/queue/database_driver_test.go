@@ -0,0 +1,98 @@
+package queue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
+)
+
+func newDatabaseDriver(t *testing.T, name string) *queue.DatabaseDriver {
+	t.Helper()
+	db, err := database.OpenMemory(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := queue.NewDatabaseDriver(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.PollInterval = 10 * time.Millisecond
+	return d
+}
+
+func TestDatabaseDriver_PriorityAndDelay(t *testing.T) {
+	d := newDatabaseDriver(t, "queue_db_driver")
+
+	if err := d.PushDelayedOn("high", []byte("later"), 150*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PushOn("low", []byte("low")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PushOn("high", []byte("high")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	pop := func() []byte {
+		for ctx.Err() == nil {
+			got, err := d.PopFrom(ctx, []string{"high", "low"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != nil {
+				return got
+			}
+		}
+		return nil
+	}
+	for _, want := range []string{"high", "low"} {
+		if got := pop(); string(got) != want {
+			t.Fatalf("PopFrom = %q, want %q", got, want)
+		}
+	}
+	if got, _ := d.PopFrom(ctx, []string{"high", "low"}); got != nil {
+		t.Fatalf("delayed job popped early: %q", got)
+	}
+	if got := pop(); string(got) != "later" {
+		t.Fatalf("PopFrom = %q, want the delayed job", got)
+	}
+}
+
+func TestDatabaseDriver_ReservationAndAck(t *testing.T) {
+	d := newDatabaseDriver(t, "queue_db_driver_ack")
+	d.ReserveFor = 100 * time.Millisecond
+	ctx := context.Background()
+
+	if err := d.PushOn("default", []byte("crashes")); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := d.PopFrom(ctx, []string{"default"}); string(got) != "crashes" {
+		t.Fatalf("PopFrom = %q", got)
+	}
+	if got, _ := d.PopFrom(ctx, []string{"default"}); got != nil {
+		t.Fatalf("reserved job handed out twice: %q", got)
+	}
+	if n := d.Size("default"); n != 0 {
+		t.Fatalf("Size = %d, want reserved jobs excluded", n)
+	}
+
+	// The first worker never acks; the reservation expires and the job
+	// is claimed again.
+	time.Sleep(150 * time.Millisecond)
+	got, _ := d.PopFrom(ctx, []string{"default"})
+	if string(got) != "crashes" {
+		t.Fatalf("expired reservation not reclaimed: %q", got)
+	}
+	if err := d.Ack(got); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if got, _ := d.PopFrom(ctx, []string{"default"}); got != nil {
+		t.Fatalf("acked job popped again: %q", got)
+	}
+}
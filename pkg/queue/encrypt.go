@@ -0,0 +1,150 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/crypt"
+)
+
+// ------------------- Encryption & redaction -------------------
+
+// ShouldBeEncrypted marks a job type whose payload is always encrypted with
+// APP_KEY before it reaches the driver:
+//
+//	type ExportJob struct{ Email string }
+//	func (ExportJob) Handle() error      { … }
+//	func (ExportJob) ShouldBeEncrypted() {}
+type ShouldBeEncrypted interface {
+	Job
+	ShouldBeEncrypted()
+}
+
+// encryptedJob is the wrapper returned by Encrypted.
+type encryptedJob struct{ Job }
+
+// Encrypted wraps job so its payload is encrypted with pkg/crypt (APP_KEY)
+// before it is stored in Redis, the database, or the failed-job table.
+// Workers decrypt it before Handle runs; the job is registered and handled
+// under its own type as usual.
+//
+//	queue.Dispatch(queue.Encrypted(jobs.ExportJob{Email: u.Email}))
+func Encrypted(job Job) Job { return encryptedJob{job} }
+
+// unwrap returns the job to marshal and whether it must be encrypted.
+func unwrap(job Job) (Job, bool) {
+	if e, ok := job.(encryptedJob); ok {
+		return e.Job, true
+	}
+	_, ok := job.(ShouldBeEncrypted)
+	return job, ok
+}
+
+// sealPayload encrypts a marshalled job into a JSON string.
+func sealPayload(payload []byte) (json.RawMessage, error) {
+	enc, err := crypt.EncryptBytes(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(enc)
+}
+
+// openPayload reverses sealPayload.
+func openPayload(sealed json.RawMessage) ([]byte, error) {
+	var enc string
+	if err := json.Unmarshal(sealed, &enc); err != nil {
+		return nil, err
+	}
+	return crypt.DecryptBytes(enc)
+}
+
+const (
+	redacted = "[REDACTED]"
+	// maxSnippet caps payloads written to logs.
+	maxSnippet = 512
+)
+
+var (
+	redactMu     sync.RWMutex
+	redactFields []string
+)
+
+// SetRedactFields replaces the payload field names masked in log lines.
+// The default comes from QUEUE_REDACT_FIELDS.
+func SetRedactFields(fields ...string) {
+	redactMu.Lock()
+	redactFields = fields
+	redactMu.Unlock()
+}
+
+func currentRedactFields() []string {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+	if redactFields != nil {
+		return redactFields
+	}
+	var out []string
+	for _, f := range strings.Split(config.Get("QUEUE_REDACT_FIELDS",
+		"password,token,secret,api_key,email,phone,ssn,card_number,cvv"), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Redact returns a log-safe snippet of a job payload: configured fields
+// (matched case-insensitively at any depth) are masked and the result is
+// truncated. Non-JSON payloads are not shown.
+func Redact(payload []byte) string {
+	var v any
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return fmt.Sprintf("[%d bytes]", len(payload))
+	}
+	out, _ := json.Marshal(redactValue(v, currentRedactFields()))
+	if len(out) > maxSnippet {
+		return string(out[:maxSnippet]) + "…"
+	}
+	return string(out)
+}
+
+func redactValue(v any, fields []string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if containsFold(fields, k) {
+				t[k] = redacted
+			} else {
+				t[k] = redactValue(val, fields)
+			}
+		}
+	case []any:
+		for i := range t {
+			t[i] = redactValue(t[i], fields)
+		}
+	}
+	return v
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// snippet is the payload shown in log lines for env.
+func (env envelope) snippet() string {
+	if env.Encrypted {
+		return "[ENCRYPTED]"
+	}
+	if env.Codec != "" {
+		return "[" + env.Codec + " payload]"
+	}
+	return Redact(env.Payload)
+}
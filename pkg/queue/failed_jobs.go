@@ -2,25 +2,38 @@ package queue
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"gorm.io/gorm"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
 )
 
 // FailedJobRecord is the GORM model persisted to the database.
 // Auto-migrated by the HTTP kernel at startup.
 type FailedJobRecord struct {
 	ID       uint      `gorm:"primaryKey;autoIncrement"`
+	Queue    string    `gorm:"size:255;not null;default:default"`
 	JobType  string    `gorm:"size:255;not null;index"`
 	Payload  string    `gorm:"type:text;not null"`
 	Error    string    `gorm:"type:text"`
 	Attempts int       `gorm:"not null;default:0"`
 	FailedAt time.Time `gorm:"autoCreateTime"`
+	// Encrypted means Payload is still the ciphertext from the envelope.
+	Encrypted bool `gorm:"not null;default:false"`
+	// Codec names the payload codec when it is not JSON; Payload is then the
+	// envelope's encoded string.
+	Codec string `gorm:"size:32"`
 }
 
 func (FailedJobRecord) TableName() string { return "kashvi_failed_jobs" }
 
+// ErrNoFailedJobStore is returned by the failed-job helpers when UseDB has
+// not been called.
+var ErrNoFailedJobStore = errors.New("queue: failed jobs are not persisted (call queue.UseDB)")
+
 // failedJobStore is the optional DB backend for persisting failed jobs.
 // Set via UseDB() — nil means in-memory only.
 var failedJobDB *gorm.DB
@@ -37,7 +50,7 @@ func UseDB(db *gorm.DB) {
 
 // persistFailed writes a failed job record to the database (if configured)
 // and also appends to the in-memory slice as a fallback.
-func (m *Manager) persistFailed(job Job, typeName string, lastErr error, attempts int) {
+func (m *Manager) persistFailed(job Job, env envelope, lastErr error, attempts int) {
 	// Always append to in-memory slice.
 	m.mu.Lock()
 	m.failed = append(m.failed, FailedJob{
@@ -50,22 +63,85 @@ func (m *Manager) persistFailed(job Job, typeName string, lastErr error, attempt
 		return
 	}
 
-	payload, err := json.Marshal(job)
-	if err != nil {
-		payload = []byte(fmt.Sprintf(`{"error": "could not marshal: %v"}`, err))
+	typeName, queue := env.Type, env.Queue
+	// Encrypted jobs keep their ciphertext so PII never lands in the table;
+	// binary-codec jobs keep their encoded payload.
+	payload := []byte(env.Payload)
+	if !env.Encrypted && env.Codec == "" {
+		var err error
+		if payload, err = json.Marshal(job); err != nil {
+			payload = []byte(fmt.Sprintf(`{"error": "could not marshal: %v"}`, err))
+		}
+	}
+	if queue == "" {
+		queue = DefaultQueue
 	}
 
 	record := FailedJobRecord{
+		Queue:    queue,
 		JobType:  typeName,
 		Payload:  string(payload),
 		Error:    lastErr.Error(),
 		Attempts: attempts,
 		FailedAt: time.Now(),
+
+		Encrypted: env.Encrypted,
+		Codec:     env.Codec,
 	}
 
 	if err := failedJobDB.Create(&record).Error; err != nil {
 		// Non-fatal — the in-memory slice still has it.
-		// logger is not imported here to avoid import cycle, use fmt.
-		fmt.Printf("queue: failed to persist failed job %s: %v\n", typeName, err)
+		logger.Error("queue: failed to persist failed job", "type", typeName, "error", err)
+	}
+}
+
+// ListFailed returns the persisted failed jobs, oldest first.
+func ListFailed() ([]FailedJobRecord, error) {
+	if failedJobDB == nil {
+		return nil, ErrNoFailedJobStore
+	}
+	var records []FailedJobRecord
+	if err := failedJobDB.Order("id").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("queue: list failed: %w", err)
+	}
+	return records, nil
+}
+
+// RetryFailed pushes persisted failed jobs back onto the queue they failed
+// on and removes them from kashvi_failed_jobs. With no ids every failed job
+// is retried. It returns how many jobs were re-queued.
+func RetryFailed(ids ...uint) (int, error) {
+	if failedJobDB == nil {
+		return 0, ErrNoFailedJobStore
+	}
+	var records []FailedJobRecord
+	q := failedJobDB.Order("id")
+	if len(ids) > 0 {
+		q = q.Where("id IN ?", ids)
+	}
+	if err := q.Find(&records).Error; err != nil {
+		return 0, fmt.Errorf("queue: retry: %w", err)
+	}
+
+	n := 0
+	for _, rec := range records {
+		e := envelope{
+			Type: rec.JobType, Payload: json.RawMessage(rec.Payload), Queue: rec.Queue, Encrypted: rec.Encrypted,
+		}
+		if rec.Codec != "" {
+			e.Version, e.Codec = envelopeVersion, rec.Codec
+		}
+		env, err := json.Marshal(e)
+		if err != nil {
+			return n, fmt.Errorf("queue: retry %d: %w", rec.ID, err)
+		}
+		if err := defaultManager.pushRaw(rec.Queue, env); err != nil {
+			return n, fmt.Errorf("queue: retry %d: %w", rec.ID, err)
+		}
+		if err := failedJobDB.Delete(&FailedJobRecord{}, rec.ID).Error; err != nil {
+			return n, fmt.Errorf("queue: retry %d: %w", rec.ID, err)
+		}
+		n++
 	}
+	return n, nil
 }
@@ -5,28 +5,63 @@ import (
 	"sync"
 )
 
-// MemoryDriver is an in-process, channel-backed queue driver.
+// MemoryDriver is an in-process queue driver with named queues.
 // Perfect for development and testing; not durable across restarts.
 type MemoryDriver struct {
-	mu sync.Mutex
-	ch chan []byte
+	mu     sync.Mutex
+	queues map[string][][]byte
+	wake   chan struct{} // closed and replaced on every push
 }
 
-// NewMemoryDriver creates an in-memory queue with a buffer of 1000 jobs.
+// NewMemoryDriver creates an empty in-memory queue.
 func NewMemoryDriver() *MemoryDriver {
-	return &MemoryDriver{ch: make(chan []byte, 1000)}
+	return &MemoryDriver{queues: map[string][][]byte{}, wake: make(chan struct{})}
 }
 
 func (d *MemoryDriver) Push(payload []byte) error {
-	d.ch <- payload
-	return nil
+	return d.PushOn(DefaultQueue, payload)
 }
 
 func (d *MemoryDriver) Pop(ctx context.Context) ([]byte, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case payload := <-d.ch:
-		return payload, nil
+	return d.PopFrom(ctx, []string{DefaultQueue})
+}
+
+// PushOn appends payload to the named queue.
+func (d *MemoryDriver) PushOn(queue string, payload []byte) error {
+	d.mu.Lock()
+	d.queues[queue] = append(d.queues[queue], payload)
+	close(d.wake)
+	d.wake = make(chan struct{})
+	d.mu.Unlock()
+	return nil
+}
+
+// PopFrom blocks until a job is available on any of queues, checking them in
+// the order given.
+func (d *MemoryDriver) PopFrom(ctx context.Context, queues []string) ([]byte, error) {
+	for {
+		d.mu.Lock()
+		for _, q := range queues {
+			if items := d.queues[q]; len(items) > 0 {
+				d.queues[q] = items[1:]
+				d.mu.Unlock()
+				return items[0], nil
+			}
+		}
+		wake := d.wake
+		d.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-wake:
+		}
 	}
 }
+
+// Size returns the number of jobs waiting on queue.
+func (d *MemoryDriver) Size(queue string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.queues[queue])
+}
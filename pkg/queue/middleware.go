@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"context"
+)
+
+// ------------------- Job middleware -------------------
+
+// JobHandler runs one attempt of a job.
+type JobHandler func(ctx context.Context, job Job) error
+
+// Middleware wraps job execution, the same way HTTP middleware wraps a
+// handler. Return without calling next to skip the attempt.
+type Middleware func(next JobHandler) JobHandler
+
+// JobInfo describes the job being run. Middleware reads it with Info(ctx).
+type JobInfo struct {
+	Type    string // registered type name, e.g. "*jobs.WelcomeEmailJob"
+	Queue   string
+	Attempt int // 1-based
+}
+
+type infoKey struct{}
+
+// Info returns the JobInfo of the job running in ctx. ok is false outside
+// a worker.
+func Info(ctx context.Context) (info JobInfo, ok bool) {
+	info, ok = ctx.Value(infoKey{}).(JobInfo)
+	return info, ok
+}
+
+// Use appends middleware to the chain around every job attempt. The first
+// middleware registered is the outermost. Call it during boot, before
+// workers start:
+//
+//	queue.Use(func(next queue.JobHandler) queue.JobHandler {
+//	    return func(ctx context.Context, job queue.Job) error {
+//	        start := time.Now()
+//	        err := next(ctx, job)
+//	        info, _ := queue.Info(ctx)
+//	        jobDuration.WithLabelValues(info.Type).Observe(time.Since(start).Seconds())
+//	        return err
+//	    }
+//	})
+func Use(mw ...Middleware) {
+	defaultManager.mu.Lock()
+	defaultManager.middleware = append(defaultManager.middleware, mw...)
+	defaultManager.mu.Unlock()
+}
+
+// handler builds the middleware chain around the job's own handler.
+func (m *Manager) handler() JobHandler {
+	h := JobHandler(callJob)
+	m.mu.RLock()
+	for i := len(m.middleware) - 1; i >= 0; i-- {
+		h = m.middleware[i](h)
+	}
+	m.mu.RUnlock()
+	return h
+}
+
+// callJob calls HandleCtx for a ContextJob, Handle otherwise.
+func callJob(ctx context.Context, job Job) error {
+	if cj, ok := job.(ContextJob); ok {
+		return cj.HandleCtx(ctx)
+	}
+	return job.Handle()
+}
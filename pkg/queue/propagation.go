@@ -0,0 +1,107 @@
+package queue
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/reqid"
+)
+
+// ------------------- Context propagation -------------------
+
+// ContextJob is implemented by jobs that want the context restored from the
+// dispatching request. It is called instead of Handle; logger.WithCtx(ctx)
+// and reqid.FromCtx(ctx) return the originating request's values.
+//
+//	func (j InvoiceJob) HandleCtx(ctx context.Context) error {
+//	    logger.WithCtx(ctx).Info("rendering invoice", "id", j.ID)
+//	    …
+//	}
+type ContextJob interface {
+	Job
+	HandleCtx(ctx context.Context) error
+}
+
+// Propagator copies cross-cutting values (trace context, tenant, …) from
+// the dispatching context into a job's metadata, and back into the worker's
+// context. Keys share one map per job, so prefix them per propagator.
+type Propagator interface {
+	Inject(ctx context.Context, meta map[string]string)
+	Extract(ctx context.Context, meta map[string]string) context.Context
+}
+
+// metaRequestID is the metadata key holding the originating request ID.
+const metaRequestID = "request_id"
+
+var (
+	propMu      sync.RWMutex
+	propagators []Propagator
+)
+
+// AddPropagator registers p for every job dispatched with a context. The
+// request ID is always propagated; use this for trace context:
+//
+//	queue.AddPropagator(myTracePropagator{})
+func AddPropagator(p Propagator) {
+	propMu.Lock()
+	propagators = append(propagators, p)
+	propMu.Unlock()
+}
+
+// DispatchCtx is Dispatch with the request ID and registered propagators'
+// values from ctx carried into the job, so its log lines can be matched to
+// the request that queued it.
+func DispatchCtx(ctx context.Context, job Job) error {
+	return defaultManager.push(DefaultQueue, job, injectMeta(ctx))
+}
+
+// DispatchOnCtx is DispatchOn with context propagation; see DispatchCtx.
+func DispatchOnCtx(ctx context.Context, queue string, job Job) error {
+	return defaultManager.push(queue, job, injectMeta(ctx))
+}
+
+// DispatchAfterCtx is DispatchAfter with context propagation; see DispatchCtx.
+func DispatchAfterCtx(ctx context.Context, job Job, delay time.Duration) {
+	if err := defaultManager.pushAfter(DefaultQueue, job, delay, injectMeta(ctx)); err != nil {
+		logger.WithCtx(ctx).Error("queue: delayed dispatch failed", "error", err)
+	}
+}
+
+// injectMeta collects the metadata stored in a job envelope.
+func injectMeta(ctx context.Context) map[string]string {
+	meta := map[string]string{}
+	if id := reqid.FromCtx(ctx); id != "" {
+		meta[metaRequestID] = id
+	}
+	propMu.RLock()
+	for _, p := range propagators {
+		p.Inject(ctx, meta)
+	}
+	propMu.RUnlock()
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+// jobContext rebuilds the dispatching request's context for a worker and
+// returns it with a logger tagged with the job type and request_id.
+func jobContext(env envelope) (context.Context, *slog.Logger) {
+	ctx := context.Background()
+	log := logger.L.With("job", env.Type)
+	if id := env.Meta[metaRequestID]; id != "" {
+		ctx = reqid.WithValue(ctx, id)
+		log = log.With("request_id", id)
+	}
+	if len(env.Meta) > 0 {
+		propMu.RLock()
+		for _, p := range propagators {
+			ctx = p.Extract(ctx, env.Meta)
+		}
+		propMu.RUnlock()
+	}
+	return logger.InjectLogger(ctx, log), log
+}
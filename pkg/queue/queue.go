@@ -12,16 +12,20 @@
 //	// Dispatch
 //	queue.Dispatch(WelcomeEmailJob{UserID: 1})
 //	queue.DispatchAfter(WelcomeEmailJob{UserID: 2}, 30*time.Second)
+//	queue.Dispatch(WelcomeEmailJob{UserID: 3}, queue.OnQueue("emails"))
 package queue
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"reflect"
 	"runtime/debug"
 	"sync"
 	"time"
 
+	"github.com/shashiranjanraj/kashvi/config"
 	"github.com/shashiranjanraj/kashvi/pkg/logger"
 )
 
@@ -45,6 +49,30 @@ type Driver interface {
 	Pop(ctx context.Context) ([]byte, error)
 }
 
+// DefaultQueue is the queue used by Dispatch and by workers started without
+// an explicit queue list.
+const DefaultQueue = "default"
+
+// NamedDriver is implemented by drivers that support multiple named queues.
+// PopFrom checks queues in the order given, so earlier names take priority.
+type NamedDriver interface {
+	PushOn(queue string, payload []byte) error
+	PopFrom(ctx context.Context, queues []string) ([]byte, error)
+}
+
+// SizedDriver is implemented by drivers that can report how many jobs are
+// waiting on a queue. Autoscaling workers use it to size the pool.
+type SizedDriver interface {
+	Size(queue string) int
+}
+
+// AckDriver is implemented by drivers that keep a popped job reserved until
+// it has run. Workers call Ack with the popped payload once the job has
+// finished, whether it succeeded or failed.
+type AckDriver interface {
+	Ack(payload []byte) error
+}
+
 // ------------------- Manager -------------------
 
 // Manager is the central queue hub.
@@ -54,6 +82,10 @@ type Manager struct {
 	registry map[string]func() Job // type name → constructor
 	failed   []FailedJob
 	maxRetry int
+	fault    FaultInjector
+	pools    []pool
+
+	middleware []Middleware
 }
 
 var defaultManager = &Manager{
@@ -72,6 +104,28 @@ func SetDriver(d Driver) {
 // SetMaxRetry sets how many times a failing job is retried.
 func SetMaxRetry(n int) { defaultManager.maxRetry = n }
 
+// FaultInjector lets resilience tests make queue operations fail on purpose.
+// op is "push" or "handle"; a non-nil error replaces the real outcome (a
+// failed handle goes through the normal retry/failed-job path).
+type FaultInjector func(op, jobType string) error
+
+// SetFaultInjector installs fn (nil removes it). See middleware.InstallChaosHooks.
+func SetFaultInjector(fn FaultInjector) {
+	defaultManager.mu.Lock()
+	defer defaultManager.mu.Unlock()
+	defaultManager.fault = fn
+}
+
+func (m *Manager) injectFault(op, typeName string) error {
+	m.mu.RLock()
+	fn := m.fault
+	m.mu.RUnlock()
+	if fn == nil {
+		return nil
+	}
+	return fn(op, typeName)
+}
+
 // Register makes a job type available for deserialization by name.
 // Call this once at boot for every job type you define.
 func Register(name string, factory func() Job) {
@@ -80,86 +134,279 @@ func Register(name string, factory func() Job) {
 	defaultManager.registry[name] = factory
 }
 
+// RegisterJobs registers each job's type under the name Dispatch gives it,
+// so a sample value is enough:
+//
+//	queue.RegisterJobs(jobs.WelcomeEmailJob{}, &jobs.ReportJob{})
+//
+// Payloads are decoded into a fresh *T for both T and *T samples, so T's
+// Handle may have a value or pointer receiver.
+func RegisterJobs(jobs ...Job) {
+	for _, job := range jobs {
+		t := reflect.TypeOf(job)
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		Register(fmt.Sprintf("%T", job), func() Job {
+			return reflect.New(t).Interface().(Job)
+		})
+	}
+}
+
 // ------------------- Dispatch -------------------
 
+// DelayedDriver is implemented by drivers that can hold a job until it is
+// due. DispatchAfter uses it so delayed jobs survive process restarts.
+type DelayedDriver interface {
+	PushDelayedOn(queue string, payload []byte, delay time.Duration) error
+}
+
 type envelope struct {
 	Type    string          `json:"type"`
 	Payload json.RawMessage `json:"payload"`
+	// Queue is where the job was dispatched, so a failed job can be retried
+	// onto the same queue.
+	Queue string `json:"queue,omitempty"`
+	// Meta carries request_id and trace context from the dispatching request;
+	// see DispatchCtx.
+	Meta map[string]string `json:"meta,omitempty"`
+	// Encrypted means Payload is a crypt ciphertext string; see Encrypted.
+	Encrypted bool `json:"encrypted,omitempty"`
+	// Version is the envelope format; absent means 1. Codec names the
+	// payload codec when it is not JSON; see codec.go.
+	Version int    `json:"v,omitempty"`
+	Codec   string `json:"codec,omitempty"`
 }
 
-// Dispatch pushes job onto the default queue immediately.
-func Dispatch(job Job) error {
-	return defaultManager.push(job)
+// DispatchOption changes where or how a job is dispatched.
+type DispatchOption func(*dispatchConfig)
+
+type dispatchConfig struct {
+	queue string
 }
 
-// DispatchAfter pushes job onto the queue after a delay.
-// Note: for the in-memory driver, this spawns a goroutine; for Redis, use a
-// dedicated delayed-queue strategy (e.g. sorted set).
-func DispatchAfter(job Job, delay time.Duration) {
-	go func() {
-		time.Sleep(delay)
-		if err := Dispatch(job); err != nil {
-			logger.Error("queue: delayed dispatch failed", "error", err)
+// OnQueue dispatches the job onto the named queue instead of "default".
+// Workers consume queues in the priority order given to StartWorkers or
+// `queue:work --queue=high,default`.
+//
+//	queue.Dispatch(jobs.WelcomeEmailJob{UserID: u.ID}, queue.OnQueue("emails"))
+func OnQueue(name string) DispatchOption {
+	return func(c *dispatchConfig) {
+		if name != "" {
+			c.queue = name
 		}
-	}()
+	}
 }
 
-func (m *Manager) push(job Job) error {
-	typeName := fmt.Sprintf("%T", job)
+func dispatchOptions(opts []DispatchOption) dispatchConfig {
+	c := dispatchConfig{queue: DefaultQueue}
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
+// Dispatch pushes job immediately, onto the default queue unless OnQueue is
+// given.
+func Dispatch(job Job, opts ...DispatchOption) error {
+	return defaultManager.push(dispatchOptions(opts).queue, job, nil)
+}
+
+// DispatchOn pushes job onto the named queue. It is shorthand for
+// Dispatch(job, OnQueue(queue)). Drivers without named-queue support fall
+// back to their single queue.
+func DispatchOn(queue string, job Job) error {
+	return Dispatch(job, OnQueue(queue))
+}
+
+// DispatchAfter pushes job after a delay. Drivers that implement
+// DelayedDriver (Redis, database) store the job until it is due; others hold
+// it in a goroutine, so it is lost if the process exits first.
+func DispatchAfter(job Job, delay time.Duration, opts ...DispatchOption) {
+	if err := defaultManager.pushAfter(dispatchOptions(opts).queue, job, delay, nil); err != nil {
+		logger.Error("queue: delayed dispatch failed", "error", err)
+	}
+}
 
-	payload, err := json.Marshal(job)
+func (m *Manager) push(queue string, job Job, meta map[string]string) error {
+	lock, ok := acquireUnique(job, 0)
+	if !ok {
+		return nil
+	}
+	err := m.enqueue(queue, job, meta)
 	if err != nil {
-		return fmt.Errorf("queue: marshal job %s: %w", typeName, err)
+		releaseUnique(lock)
 	}
+	return err
+}
 
-	env, err := json.Marshal(envelope{Type: typeName, Payload: payload})
+// enqueue encodes job and hands it to the driver.
+func (m *Manager) enqueue(queue string, job Job, meta map[string]string) error {
+	env, typeName, err := encode(queue, job, meta)
 	if err != nil {
-		return fmt.Errorf("queue: marshal envelope: %w", err)
+		return err
 	}
 
+	if err := m.injectFault("push", typeName); err != nil {
+		return fmt.Errorf("queue: push %s: %w", typeName, err)
+	}
+	return m.pushRaw(queue, env)
+}
+
+// pushRaw hands an encoded envelope to the driver.
+func (m *Manager) pushRaw(queue string, env []byte) error {
 	m.mu.RLock()
 	d := m.driver
 	m.mu.RUnlock()
 
+	if nd, ok := d.(NamedDriver); ok && queue != DefaultQueue {
+		return nd.PushOn(queue, env)
+	}
 	return d.Push(env)
 }
 
-// ------------------- Worker -------------------
+func (m *Manager) pushAfter(queue string, job Job, delay time.Duration, meta map[string]string) error {
+	lock, unique := acquireUnique(job, delay)
+	if !unique {
+		return nil
+	}
+
+	m.mu.RLock()
+	dd, ok := m.driver.(DelayedDriver)
+	m.mu.RUnlock()
 
-// StartWorkers launches n concurrent workers that process jobs from the queue.
-// The workers run until ctx is cancelled.
-func StartWorkers(ctx context.Context, n int) {
-	for i := 0; i < n; i++ {
-		go defaultManager.work(ctx)
+	if !ok || delay <= 0 {
+		go func() {
+			time.Sleep(delay)
+			if err := m.enqueue(queue, job, meta); err != nil {
+				releaseUnique(lock)
+				logger.Error("queue: delayed dispatch failed", "error", err)
+			}
+		}()
+		return nil
+	}
+
+	err := m.pushDelayed(dd, queue, job, delay, meta)
+	if err != nil {
+		releaseUnique(lock)
+	}
+	return err
+}
+
+func (m *Manager) pushDelayed(dd DelayedDriver, queue string, job Job, delay time.Duration, meta map[string]string) error {
+	env, typeName, err := encode(queue, job, meta)
+	if err != nil {
+		return err
+	}
+	if err := m.injectFault("push", typeName); err != nil {
+		return fmt.Errorf("queue: push %s: %w", typeName, err)
 	}
-	logger.Info("queue: workers started", "count", n)
+	return dd.PushDelayedOn(queue, env, delay)
 }
 
-func (m *Manager) work(ctx context.Context) {
-	for {
+// encode wraps job in the envelope workers decode.
+func encode(queue string, job Job, meta map[string]string) ([]byte, string, error) {
+	job, encrypt := unwrap(job)
+	typeName := fmt.Sprintf("%T", job)
+
+	e := envelope{Type: typeName, Queue: queue, Meta: meta, Encrypted: encrypt}
+	payload, err := e.marshalJob(job)
+	if err != nil {
+		return nil, typeName, fmt.Errorf("queue: marshal job %s: %w", typeName, err)
+	}
+	if encrypt {
+		if payload, err = sealPayload(payload); err != nil {
+			return nil, typeName, fmt.Errorf("queue: encrypt job %s: %w", typeName, err)
+		}
+	} else if e.Codec != "" {
+		payload, _ = json.Marshal(payload) // binary: base64 string
+	}
+	e.Payload = payload
+
+	env, err := json.Marshal(e)
+	if err != nil {
+		return nil, typeName, fmt.Errorf("queue: marshal envelope: %w", err)
+	}
+	return env, typeName, nil
+}
+
+// UseConfiguredDriver selects the driver named by QUEUE_DRIVER: "memory"
+// (default) keeps the in-process queue, "redis" connects to REDIS_ADDR and
+// "database" stores jobs in the kashvi_jobs table of the DB passed to UseDB.
+func UseConfiguredDriver() error {
+	switch name := config.Get("QUEUE_DRIVER", "memory"); name {
+	case "", "memory":
+		return nil
+	case "database":
+		if failedJobDB == nil {
+			return fmt.Errorf("queue: QUEUE_DRIVER=database needs queue.UseDB first")
+		}
+		d, err := NewDatabaseDriver(failedJobDB)
+		if err != nil {
+			return err
+		}
+		SetDriver(d)
+		return nil
+	case "redis":
+		d, err := NewRedisDriverFromConfig()
+		if err != nil {
+			return err
+		}
+		SetDriver(d)
+		return nil
+	default:
+		return fmt.Errorf("queue: unknown QUEUE_DRIVER %q", name)
+	}
+}
+
+// ------------------- Worker -------------------
+
+// StartWorkers launches n concurrent workers that process jobs from queues,
+// highest priority first (default: "default"). A job on an earlier queue is
+// always taken before one on a later queue:
+//
+//	queue.StartWorkers(ctx, 4, "emails", "default")
+//
+// The workers run until ctx is cancelled. Use Work for restart limits.
+func StartWorkers(ctx context.Context, n int, queues ...string) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	defaultManager.mu.Lock()
+	defaultManager.pools = append(defaultManager.pools, pool{cancel: cancel, done: done})
+	defaultManager.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		Work(ctx, WorkerOptions{Concurrency: n, Queues: queues})
+	}()
+}
+
+// pool is a set of workers started with StartWorkers.
+type pool struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Drain stops every worker pool started with StartWorkers from fetching new
+// jobs and waits for in-flight jobs to finish, or until ctx expires.
+func Drain(ctx context.Context) error {
+	m := defaultManager
+	m.mu.Lock()
+	pools := m.pools
+	m.pools = nil
+	m.mu.Unlock()
+
+	for _, p := range pools {
+		p.cancel()
+	}
+	for _, p := range pools {
 		select {
+		case <-p.done:
 		case <-ctx.Done():
-			return
-		default:
-			m.mu.RLock()
-			d := m.driver
-			m.mu.RUnlock()
-
-			raw, err := d.Pop(ctx)
-			if err != nil {
-				if ctx.Err() != nil {
-					return // context cancelled
-				}
-				time.Sleep(500 * time.Millisecond)
-				continue
-			}
-			if raw == nil {
-				continue
-			}
-
-			m.process(raw)
+			return fmt.Errorf("queue: drain: %w", ctx.Err())
 		}
 	}
+	return nil
 }
 
 func (m *Manager) process(raw []byte) {
@@ -179,49 +426,76 @@ func (m *Manager) process(raw []byte) {
 		return
 	}
 
+	if env.Version > envelopeVersion {
+		logger.Error("queue: envelope from a newer release", "type", env.Type, "version", env.Version)
+		return
+	}
+	payload := []byte(env.Payload)
+	var err error
+	if env.Encrypted {
+		if payload, err = openPayload(env.Payload); err != nil {
+			logger.Error("queue: decrypt payload", "type", env.Type, "error", err)
+			return
+		}
+	} else if env.Codec != "" {
+		if err = json.Unmarshal(env.Payload, &payload); err != nil {
+			logger.Error("queue: bad payload", "type", env.Type, "codec", env.Codec, "error", err)
+			return
+		}
+	}
 	job := factory()
-	if err := json.Unmarshal(env.Payload, job); err != nil {
-		logger.Error("queue: unmarshal payload", "type", env.Type, "error", err)
+	if err := env.unmarshalJob(payload, job); err != nil {
+		logger.Error("queue: unmarshal payload", "type", env.Type, "payload", env.snippet(), "error", err)
 		return
 	}
 
-	m.runWithRetry(job, env.Type)
+	ctx, log := jobContext(env)
+	m.runWithRetry(ctx, log, job, env)
 }
 
-func (m *Manager) runWithRetry(job Job, typeName string) {
+func (m *Manager) runWithRetry(ctx context.Context, log *slog.Logger, job Job, env envelope) {
+	typeName, queue := env.Type, env.Queue
 	var lastErr error
+	h := m.handler()
 	for attempt := 1; attempt <= m.maxRetry; attempt++ {
-		err := m.safeHandle(job)
+		err := m.injectFault("handle", typeName)
+		if err == nil {
+			actx := context.WithValue(ctx, infoKey{}, JobInfo{Type: typeName, Queue: queue, Attempt: attempt})
+			err = m.safeHandle(actx, log, h, job)
+		}
 		if err != nil {
 			lastErr = err
-			logger.Warn("queue: job failed, retrying",
+			log.Warn("queue: job failed, retrying",
 				"type", typeName, "attempt", attempt, "error", err)
 			time.Sleep(time.Duration(attempt) * time.Second) // backoff
 			continue
 		}
-		logger.Info("queue: job processed", "type", typeName)
+		log.Info("queue: job processed", "type", typeName)
+		releaseProcessed(typeName, job)
 		return
 	}
 
 	// All retries exhausted — persist the failure.
-	m.persistFailed(job, typeName, lastErr, m.maxRetry)
-	logger.Error("queue: job exhausted retries", "type", typeName, "error", lastErr)
+	m.persistFailed(job, env, lastErr, m.maxRetry)
+	releaseUnique(uniqueKey(typeName, job))
+	log.Error("queue: job exhausted retries", "type", typeName, "payload", env.snippet(), "error", lastErr)
 }
 
-// safeHandle calls job.Handle() and catches panics, converting them to errors
-// so the worker goroutine is never killed by a misbehaving job.
-func (m *Manager) safeHandle(job Job) (err error) {
+// safeHandle runs job through the middleware chain h and catches panics,
+// converting them to errors so the worker goroutine is never killed by a
+// misbehaving job or middleware.
+func (m *Manager) safeHandle(ctx context.Context, log *slog.Logger, h JobHandler, job Job) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			stack := debug.Stack()
-			logger.Error("queue: job panicked",
+			log.Error("queue: job panicked",
 				"panic", fmt.Sprintf("%v", r),
 				"stack", string(stack),
 			)
 			err = fmt.Errorf("panic: %v", r)
 		}
 	}()
-	return job.Handle()
+	return h(ctx, job)
 }
 
 // FailedJobs returns a snapshot of all failed jobs.
@@ -2,13 +2,21 @@ package queue_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+
+	"github.com/shashiranjanraj/kashvi/pkg/codec"
+	"github.com/shashiranjanraj/kashvi/pkg/database"
 	"github.com/shashiranjanraj/kashvi/pkg/queue"
+	"github.com/shashiranjanraj/kashvi/pkg/reqid"
 )
 
 // ─── Job types ────────────────────────────────────────────────────────────────
@@ -82,3 +90,543 @@ func TestDispatchConcurrent(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+type countJob struct{}
+
+var countJobRuns atomic.Int32
+
+func (countJob) Handle() error {
+	countJobRuns.Add(1)
+	return nil
+}
+
+func TestWork_MaxJobs(t *testing.T) {
+	queue.Register("queue_test.countJob", func() queue.Job { return &countJob{} })
+	for i := 0; i < 5; i++ {
+		if err := queue.DispatchOn("low", countJob{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if err := queue.DispatchOn("high", countJob{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reason := queue.Work(ctx, queue.WorkerOptions{
+		Queues:      []string{"high", "low"},
+		Concurrency: 1,
+		MaxJobs:     4,
+	})
+	if reason != queue.StopMaxJobs {
+		t.Fatalf("reason = %q, want max-jobs", reason)
+	}
+	if got := countJobRuns.Load(); got != 4 {
+		t.Fatalf("ran %d jobs, want 4", got)
+	}
+}
+
+type sampleJob struct{ N int }
+
+type samplePtrJob struct{ N int }
+
+var sampleJobSum atomic.Int32
+
+func (j sampleJob) Handle() error     { sampleJobSum.Add(int32(j.N)); return nil }
+func (j *samplePtrJob) Handle() error { sampleJobSum.Add(int32(j.N)); return nil }
+
+func TestRegisterJobs(t *testing.T) {
+	queue.RegisterJobs(sampleJob{}, &samplePtrJob{})
+	if err := queue.DispatchOn("samples", sampleJob{N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.DispatchOn("samples", &samplePtrJob{N: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	queue.Work(ctx, queue.WorkerOptions{Queues: []string{"samples"}, Concurrency: 1, MaxJobs: 2})
+	if got := sampleJobSum.Load(); got != 11 {
+		t.Fatalf("payload sum = %d, want 11 (both jobs decoded)", got)
+	}
+}
+
+type orderJob struct{ Queue string }
+
+var orderJobRuns = make(chan string, 4)
+
+func (j orderJob) Handle() error {
+	orderJobRuns <- j.Queue
+	return nil
+}
+
+func TestStartWorkers_NamedQueuesInPriorityOrder(t *testing.T) {
+	queue.Register("queue_test.orderJob", func() queue.Job { return &orderJob{} })
+	// Queued before any worker consumes "mail" or "bulk".
+	if err := queue.Dispatch(orderJob{Queue: "bulk"}, queue.OnQueue("bulk")); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Dispatch(orderJob{Queue: "mail"}, queue.OnQueue("mail")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue.StartWorkers(ctx, 1, "mail", "bulk")
+	for _, want := range []string{"mail", "bulk"} {
+		select {
+		case got := <-orderJobRuns:
+			if got != want {
+				t.Fatalf("ran %s job, want %s first", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s job did not run", want)
+		}
+	}
+}
+
+func TestWork_MaxTime(t *testing.T) {
+	start := time.Now()
+	reason := queue.Work(context.Background(), queue.WorkerOptions{
+		Queues:  []string{"idle"},
+		MaxTime: 100 * time.Millisecond,
+	})
+	if reason != queue.StopMaxTime {
+		t.Fatalf("reason = %q, want max-time", reason)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("worker took %v to stop", d)
+	}
+}
+
+type burstJob struct{}
+
+var burstActive, burstPeak atomic.Int32
+
+func (burstJob) Handle() error {
+	n := burstActive.Add(1)
+	for {
+		peak := burstPeak.Load()
+		if n <= peak || burstPeak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	burstActive.Add(-1)
+	return nil
+}
+
+func TestWork_AutoscalesWithBacklog(t *testing.T) {
+	queue.Register("queue_test.burstJob", func() queue.Job { return &burstJob{} })
+	for i := 0; i < 40; i++ {
+		if err := queue.Dispatch(burstJob{}, queue.OnQueue("burst")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reason := queue.Work(ctx, queue.WorkerOptions{
+		Queues:         []string{"burst"},
+		Concurrency:    1,
+		MaxConcurrency: 8,
+		ScaleInterval:  20 * time.Millisecond,
+		TargetDrain:    100 * time.Millisecond,
+		MaxJobs:        40,
+	})
+	if reason != queue.StopMaxJobs {
+		t.Fatalf("reason = %q, want max-jobs", reason)
+	}
+	if peak := burstPeak.Load(); peak < 4 || peak > 8 {
+		t.Fatalf("peak concurrency = %d, want the pool to grow towards 8", peak)
+	}
+}
+
+type hangJob struct{}
+
+var (
+	hangStarted = make(chan struct{}, 1)
+	hangRelease = make(chan struct{})
+)
+
+func (hangJob) Handle() error {
+	hangStarted <- struct{}{}
+	<-hangRelease
+	return nil
+}
+
+func TestWork_DrainTimeout(t *testing.T) {
+	queue.Register("queue_test.hangJob", func() queue.Job { return &hangJob{} })
+	defer close(hangRelease)
+	if err := queue.DispatchOn("hang", hangJob{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-hangStarted
+		cancel() // SIGTERM mid-job
+	}()
+	start := time.Now()
+	reason := queue.Work(ctx, queue.WorkerOptions{Queues: []string{"hang"}, Concurrency: 1, DrainTimeout: 100 * time.Millisecond})
+	if reason != queue.StopContext {
+		t.Fatalf("reason = %q, want context", reason)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("Work waited %v for a stuck job despite DrainTimeout", d)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]uint64{
+		"1024":  1024,
+		"256MB": 256 << 20,
+		"512Mi": 512 << 20,
+		"1g":    1 << 30,
+		"64k":   64 << 10,
+	}
+	for in, want := range cases {
+		got, err := queue.ParseByteSize(in)
+		if err != nil || got != want {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := queue.ParseByteSize("lots"); err == nil {
+		t.Error("expected error for invalid size")
+	}
+}
+
+type traceJob struct{}
+
+var traceJobSeen = make(chan string, 1)
+
+func (traceJob) Handle() error { return errors.New("HandleCtx should be called") }
+
+func (traceJob) HandleCtx(ctx context.Context) error {
+	traceJobSeen <- reqid.FromCtx(ctx)
+	return nil
+}
+
+func TestDispatchCtx_PropagatesRequestID(t *testing.T) {
+	queue.Register("queue_test.traceJob", func() queue.Job { return &traceJob{} })
+	ctx := reqid.WithValue(context.Background(), "req-abc")
+	if err := queue.DispatchCtx(ctx, traceJob{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-traceJobSeen:
+		if got != "req-abc" {
+			t.Fatalf("job saw request_id %q, want req-abc", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not run")
+	}
+}
+
+type guardedJob struct{ Skip bool }
+
+var guardedJobDone = make(chan struct{}, 2)
+
+func (guardedJob) Handle() error {
+	guardedJobDone <- struct{}{}
+	return nil
+}
+
+func TestUse_WrapsJobs(t *testing.T) {
+	queue.Register("queue_test.guardedJob", func() queue.Job { return &guardedJob{} })
+	var seen atomic.Int32
+	queue.Use(func(next queue.JobHandler) queue.JobHandler {
+		return func(ctx context.Context, job queue.Job) error {
+			info, _ := queue.Info(ctx)
+			g, ok := job.(*guardedJob)
+			if !ok || info.Type != "queue_test.guardedJob" || info.Attempt != 1 {
+				return next(ctx, job)
+			}
+			seen.Add(1)
+			if g.Skip {
+				return nil
+			}
+			return next(ctx, job)
+		}
+	})
+
+	queue.Dispatch(guardedJob{Skip: true}) //nolint:errcheck
+	queue.Dispatch(guardedJob{})           //nolint:errcheck
+	select {
+	case <-guardedJobDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not run")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := seen.Load(); n != 2 || len(guardedJobDone) != 0 {
+		t.Fatalf("middleware saw %d jobs, skipped job ran: %v", n, len(guardedJobDone) != 0)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	queue.SetRedactFields("email", "password")
+	defer queue.SetRedactFields()
+
+	got := queue.Redact([]byte(`{"UserID":7,"Email":"a@b.c","nested":{"password":"hunter2"}}`))
+	if strings.Contains(got, "a@b.c") || strings.Contains(got, "hunter2") || !strings.Contains(got, `"UserID":7`) {
+		t.Fatalf("Redact = %s", got)
+	}
+}
+
+type welcomeJob struct{ UserID int }
+
+var welcomeRuns = make(chan int, 8)
+
+func (j welcomeJob) Handle() error {
+	welcomeRuns <- j.UserID
+	return nil
+}
+
+func (j welcomeJob) UniqueID() string { return strconv.Itoa(j.UserID) }
+
+type dailyDigestJob struct{ welcomeJob }
+
+func (dailyDigestJob) UniqueFor() time.Duration { return time.Minute }
+
+func TestShouldBeUnique(t *testing.T) {
+	queue.Register("queue_test.welcomeJob", func() queue.Job { return &welcomeJob{} })
+	queue.Register("queue_test.dailyDigestJob", func() queue.Job { return &dailyDigestJob{} })
+	wait := func(want int) {
+		t.Helper()
+		for i := 0; i < want; i++ {
+			select {
+			case <-welcomeRuns:
+			case <-time.After(2 * time.Second):
+				t.Fatalf("ran %d of %d jobs", i, want)
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+		if n := len(welcomeRuns); n != 0 {
+			t.Fatalf("%d duplicate jobs ran", n)
+		}
+	}
+
+	// A fixed window holds even after the job has run.
+	for i := 0; i < 3; i++ {
+		queue.Dispatch(dailyDigestJob{welcomeJob{UserID: 1}}) //nolint:errcheck
+	}
+	queue.Dispatch(dailyDigestJob{welcomeJob{UserID: 2}}) //nolint:errcheck
+	wait(2)
+	queue.Dispatch(dailyDigestJob{welcomeJob{UserID: 1}}) //nolint:errcheck
+	wait(0)
+
+	// Without a window the lock is released once the job is processed.
+	queue.Dispatch(welcomeJob{UserID: 1}) //nolint:errcheck
+	wait(1)
+	queue.Dispatch(welcomeJob{UserID: 1}) //nolint:errcheck
+	wait(1)
+
+	// A delayed job holds its lock until it runs, even past DefaultUniqueFor.
+	defer func(d time.Duration) { queue.DefaultUniqueFor = d }(queue.DefaultUniqueFor)
+	queue.DefaultUniqueFor = 50 * time.Millisecond
+	queue.DispatchAfter(welcomeJob{UserID: 3}, 200*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	queue.Dispatch(welcomeJob{UserID: 3}) //nolint:errcheck
+	time.Sleep(200 * time.Millisecond)    // past the delayed job's due time
+	wait(1)
+}
+
+type slowJob struct{}
+
+var slowJobDone atomic.Bool
+
+func (slowJob) Handle() error {
+	time.Sleep(200 * time.Millisecond)
+	slowJobDone.Store(true)
+	return nil
+}
+
+func TestDrain_WaitsForInFlightJobs(t *testing.T) {
+	queue.Register("queue_test.slowJob", func() queue.Job { return &slowJob{} })
+	queue.StartWorkers(context.Background(), 1)
+	if err := queue.Dispatch(slowJob{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond) // let a worker pick it up
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := queue.Drain(ctx); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if !slowJobDone.Load() {
+		t.Fatal("Drain returned before the in-flight job finished")
+	}
+}
+
+// ─── Driver swaps ─────────────────────────────────────────────────────────────
+//
+// The tests below replace the global driver. Workers started in init stay
+// parked on the original memory driver, so these must remain last in the file.
+
+// delayedDriver records delayed pushes instead of storing them.
+type delayedDriver struct {
+	mu    sync.Mutex
+	queue string
+	delay time.Duration
+	body  []byte
+}
+
+func (d *delayedDriver) Push([]byte) error { return nil }
+
+func (d *delayedDriver) Pop(context.Context) ([]byte, error) {
+	time.Sleep(10 * time.Millisecond)
+	return nil, nil
+}
+
+func (d *delayedDriver) PushDelayedOn(queue string, payload []byte, delay time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queue, d.delay, d.body = queue, delay, payload
+	return nil
+}
+
+func TestDispatchAfter_UsesDelayedDriver(t *testing.T) {
+	d := &delayedDriver{}
+	queue.SetDriver(d)
+	defer queue.SetDriver(queue.NewMemoryDriver())
+
+	queue.DispatchAfter(countJob{}, time.Minute)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.queue != queue.DefaultQueue || d.delay != time.Minute {
+		t.Fatalf("PushDelayedOn(%q, %v), want default queue and 1m", d.queue, d.delay)
+	}
+	if !strings.Contains(string(d.body), `"type":"queue_test.countJob"`) {
+		t.Fatalf("payload = %s, want a countJob envelope", d.body)
+	}
+}
+
+func TestRetryFailed(t *testing.T) {
+	d := newDatabaseDriver(t, "queue_db_retry")
+	db, _ := database.OpenMemory("queue_db_retry")
+	queue.UseDB(db)
+	queue.SetDriver(d)
+	defer queue.SetDriver(queue.NewMemoryDriver())
+
+	rec := queue.FailedJobRecord{Queue: "retry-test", JobType: "queue_test.countJob", Payload: `{}`, Error: "boom", Attempts: 3}
+	if err := db.Create(&rec).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := queue.RetryFailed(rec.ID)
+	if err != nil || n != 1 {
+		t.Fatalf("RetryFailed = %d, %v; want 1, nil", n, err)
+	}
+	if left, _ := queue.ListFailed(); len(left) != 0 {
+		t.Fatalf("%d failed jobs left after retry", len(left))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	raw, err := d.PopFrom(ctx, []string{"retry-test"})
+	if err != nil || raw == nil {
+		t.Fatalf("retried job not on its queue: %q, %v", raw, err)
+	}
+	var env struct{ Type, Queue string }
+	json.Unmarshal(raw, &env)
+	if env.Type != "queue_test.countJob" || env.Queue != "retry-test" {
+		t.Fatalf("envelope = %s", raw)
+	}
+}
+
+type secretJob struct{ Email string }
+
+var secretJobSeen = make(chan string, 1)
+
+func (j secretJob) Handle() error {
+	secretJobSeen <- j.Email
+	return nil
+}
+
+func TestEncrypted_StoresCiphertext(t *testing.T) {
+	queue.Register("queue_test.secretJob", func() queue.Job { return &secretJob{} })
+	d := queue.NewMemoryDriver()
+	queue.SetDriver(d)
+	defer queue.SetDriver(queue.NewMemoryDriver())
+
+	if err := queue.Dispatch(queue.Encrypted(secretJob{Email: "jane@example.com"}), queue.OnQueue("secret")); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := d.PopFrom(context.Background(), []string{"secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "jane") || !strings.Contains(string(raw), `"encrypted":true`) {
+		t.Fatalf("stored envelope = %s, want an encrypted payload", raw)
+	}
+
+	d.PushOn("secret", raw) //nolint:errcheck
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	queue.Work(ctx, queue.WorkerOptions{Queues: []string{"secret"}, Concurrency: 1, MaxJobs: 1})
+	select {
+	case got := <-secretJobSeen:
+		if got != "jane@example.com" {
+			t.Fatalf("worker saw %q", got)
+		}
+	default:
+		t.Fatal("encrypted job was not handled")
+	}
+}
+
+// protoJob is a protobuf message dispatched with the proto codec.
+type protoJob struct {
+	*errdetails.BadRequest_FieldViolation
+}
+
+func (protoJob) Codec() string { return codec.Proto }
+
+var protoJobSeen = make(chan string, 1)
+
+func (j *protoJob) Handle() error {
+	protoJobSeen <- j.GetField()
+	return nil
+}
+
+func TestCodecJob_UsesProtobufPayload(t *testing.T) {
+	queue.Register("*queue_test.protoJob", func() queue.Job {
+		return &protoJob{&errdetails.BadRequest_FieldViolation{}}
+	})
+	d := queue.NewMemoryDriver()
+	queue.SetDriver(d)
+	defer queue.SetDriver(queue.NewMemoryDriver())
+
+	job := protoJob{&errdetails.BadRequest_FieldViolation{Field: "email"}}
+	if err := queue.Dispatch(&job, queue.OnQueue("proto")); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := d.PopFrom(context.Background(), []string{"proto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env struct {
+		Version int    `json:"v"`
+		Codec   string `json:"codec"`
+	}
+	if err := json.Unmarshal(raw, &env); err != nil || env.Codec != codec.Proto || env.Version != 2 {
+		t.Fatalf("stored envelope = %s", raw)
+	}
+
+	d.PushOn("proto", raw) //nolint:errcheck
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	queue.Work(ctx, queue.WorkerOptions{Queues: []string{"proto"}, Concurrency: 1, MaxJobs: 1})
+	select {
+	case got := <-protoJobSeen:
+		if got != "email" {
+			t.Fatalf("worker saw %q", got)
+		}
+	default:
+		t.Fatal("protobuf job was not handled")
+	}
+}
@@ -2,45 +2,112 @@ package queue
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/shashiranjanraj/kashvi/config"
 )
 
 const (
 	redisQueueKey   = "kashvi:queue:jobs"
 	redisDelayedKey = "kashvi:queue:delayed"
+
+	// promoteBatch caps how many due jobs one promotion script moves, so a
+	// large backlog never blocks Redis for long.
+	promoteBatch = 100
 )
 
 // RedisDriver is a production-grade queue driver backed by Redis.
 // Immediate jobs use LPUSH/BRPOP on a list.
-// Delayed jobs use a sorted set scored by Unix timestamp.
+// Delayed jobs use a sorted set scored by the Unix time, in seconds, they
+// become due.
 type RedisDriver struct {
-	rdb *redis.Client
-	ctx context.Context
+	rdb    *redis.Client
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // NewRedisDriver creates a new Redis-backed queue driver.
 // Pass the same *redis.Client used by pkg/cache.
 func NewRedisDriver(rdb *redis.Client) *RedisDriver {
-	d := &RedisDriver{rdb: rdb, ctx: context.Background()}
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &RedisDriver{rdb: rdb, ctx: ctx, cancel: cancel}
 	go d.promoteDelayedJobs() // background ticker
 	return d
 }
 
+// NewRedisDriverFromConfig connects to REDIS_ADDR / REDIS_PASSWORD and
+// returns a driver once the server answers a ping.
+func NewRedisDriverFromConfig() (*RedisDriver, error) {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     config.RedisAddr(),
+		Password: config.RedisPassword(),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		rdb.Close()
+		return nil, fmt.Errorf("queue/redis: ping: %w", err)
+	}
+	return NewRedisDriver(rdb), nil
+}
+
+// Close stops the delayed-job promoter. The Redis client is left open since
+// it is usually shared with pkg/cache.
+func (d *RedisDriver) Close() error {
+	d.cancel()
+	return nil
+}
+
+// redisKey maps a queue name to its list key. The default queue keeps the
+// original key so existing deployments drain cleanly.
+func redisKey(queue string) string {
+	if queue == "" || queue == DefaultQueue {
+		return redisQueueKey
+	}
+	return "kashvi:queue:" + queue
+}
+
 // Push adds a job payload to the immediate queue (LPUSH).
 func (d *RedisDriver) Push(payload []byte) error {
-	if err := d.rdb.LPush(d.ctx, redisQueueKey, payload).Err(); err != nil {
+	return d.PushOn(DefaultQueue, payload)
+}
+
+// Pop blocks until a job is available (BRPOP with 5s timeout).
+func (d *RedisDriver) Pop(ctx context.Context) ([]byte, error) {
+	return d.PopFrom(ctx, []string{DefaultQueue})
+}
+
+// PushOn adds a job payload to the named queue.
+func (d *RedisDriver) PushOn(queue string, payload []byte) error {
+	if err := d.rdb.LPush(d.ctx, redisKey(queue), payload).Err(); err != nil {
 		return fmt.Errorf("queue/redis: push: %w", err)
 	}
 	return nil
 }
 
-// Pop blocks until a job is available (BRPOP with 5s timeout).
-func (d *RedisDriver) Pop(ctx context.Context) ([]byte, error) {
-	result, err := d.rdb.BRPop(ctx, 5*time.Second, redisQueueKey).Result()
+// Size returns the number of ready jobs on queue (delayed jobs excluded).
+func (d *RedisDriver) Size(queue string) int {
+	n, err := d.rdb.LLen(d.ctx, redisKey(queue)).Result()
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// PopFrom blocks until a job is available on any of queues. BRPOP checks
+// keys in order, so earlier queues take priority.
+func (d *RedisDriver) PopFrom(ctx context.Context, queues []string) ([]byte, error) {
+	keys := make([]string, len(queues))
+	for i, q := range queues {
+		keys[i] = redisKey(q)
+	}
+	result, err := d.rdb.BRPop(ctx, 5*time.Second, keys...).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, nil // timeout — no jobs ready, normal
@@ -53,38 +120,114 @@ func (d *RedisDriver) Pop(ctx context.Context) ([]byte, error) {
 	return []byte(result[1]), nil
 }
 
-// PushDelayed schedules a job to run after delay using a Redis sorted set.
-// The score is the Unix timestamp when the job should be promoted.
+// PushDelayed schedules a job on the default queue to run after delay.
 func (d *RedisDriver) PushDelayed(payload []byte, delay time.Duration) error {
-	runAt := float64(time.Now().Add(delay).Unix())
+	return d.PushDelayedOn(DefaultQueue, payload, delay)
+}
+
+// PushDelayedOn schedules a job on the named queue to run after delay. The
+// job waits in a sorted set, so it survives process restarts.
+func (d *RedisDriver) PushDelayedOn(queue string, payload []byte, delay time.Duration) error {
+	member, err := encodeDelayed(queue, payload)
+	if err != nil {
+		return fmt.Errorf("queue/redis: push delayed: %w", err)
+	}
+	runAt := unixSeconds(time.Now().Add(delay))
 	if err := d.rdb.ZAdd(d.ctx, redisDelayedKey, redis.Z{
 		Score:  runAt,
-		Member: string(payload),
+		Member: member,
 	}).Err(); err != nil {
 		return fmt.Errorf("queue/redis: push delayed: %w", err)
 	}
 	return nil
 }
 
-// promoteDelayedJobs moves jobs whose scheduled time has passed into the main queue.
-// Runs every second in the background.
+// delayedMember is what sits in the sorted set. The random ID keeps two
+// identical jobs from collapsing into one member.
+type delayedMember struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+	Job string `json:"job"`
+}
+
+// unixSeconds is the delayed-set score for t. Scores stay in seconds, as
+// they were before queue-aware scheduling, so members already waiting in
+// the set are promoted on time; the fraction keeps millisecond precision.
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
+
+func encodeDelayed(queue string, payload []byte) (string, error) {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(delayedMember{ID: hex.EncodeToString(id[:]), Key: redisKey(queue), Job: string(payload)})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// promoteScript atomically moves one due member into its list. ZREM gates
+// the push, so several processes can run the promoter without duplicating
+// jobs. Both keys are passed in KEYS, as Redis requires of scripts.
+var promoteScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 1 then
+	redis.call('LPUSH', KEYS[2], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// promoteDelayedJobs moves jobs whose scheduled time has passed into their
+// queue. Runs every second until Close is called.
 func (d *RedisDriver) promoteDelayedJobs() {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
-	for range ticker.C {
-		now := strconv.FormatInt(time.Now().Unix(), 10)
-		jobs, err := d.rdb.ZRangeByScore(d.ctx, redisDelayedKey, &redis.ZRangeBy{
-			Min: "-inf",
-			Max: now,
-		}).Result()
-		if err != nil || len(jobs) == 0 {
-			continue
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
 		}
-		pipe := d.rdb.Pipeline()
-		for _, job := range jobs {
-			pipe.ZRem(d.ctx, redisDelayedKey, job)
-			pipe.LPush(d.ctx, redisQueueKey, []byte(job))
+		// A full batch means more may be due; keep going.
+		for n := promoteBatch; n == promoteBatch; {
+			n = d.promoteDue()
 		}
-		pipe.Exec(d.ctx) //nolint:errcheck
 	}
 }
+
+// promoteDue moves up to promoteBatch due members and reports how many it
+// found.
+func (d *RedisDriver) promoteDue() int {
+	now := strconv.FormatFloat(unixSeconds(time.Now()), 'f', 3, 64)
+	due, err := d.rdb.ZRangeByScore(d.ctx, redisDelayedKey, &redis.ZRangeBy{
+		Min: "-inf", Max: now, Count: promoteBatch,
+	}).Result()
+	if err != nil {
+		return 0
+	}
+	promote(d.ctx, d.rdb, due)
+	return len(due)
+}
+
+// promote runs promoteScript for each due member. A member that fails stays
+// in the set and is retried next tick.
+func promote(ctx context.Context, s redis.Scripter, due []string) {
+	for _, m := range due {
+		key, job := decodeDelayed(m)
+		promoteScript.Run(ctx, s, []string{redisDelayedKey, key}, m, job) //nolint:errcheck // retried next tick
+	}
+}
+
+// decodeDelayed returns the list key and job of a delayed-set member.
+// Members written before queue-aware scheduling are plain envelopes and go
+// to the default list.
+func decodeDelayed(member string) (key, job string) {
+	var m delayedMember
+	if json.Unmarshal([]byte(member), &m) == nil && m.Key != "" && m.Job != "" {
+		return m.Key, m.Job
+	}
+	return redisQueueKey, member
+}
@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeScripter runs promoteScript against an in-memory delayed set and lists.
+type fakeScripter struct {
+	delayed map[string]bool
+	lists   map[string][]string
+}
+
+func (f *fakeScripter) EvalSha(ctx context.Context, sha string, keys []string, args ...interface{}) *redis.Cmd {
+	cmd := redis.NewCmd(ctx)
+	if sha != promoteScript.Hash() {
+		cmd.SetErr(fmt.Errorf("NOSCRIPT %s", sha))
+		return cmd
+	}
+	member := args[0].(string)
+	if !f.delayed[member] { // ZREM found nothing
+		cmd.SetVal(int64(0))
+		return cmd
+	}
+	delete(f.delayed, member)
+	f.lists[keys[1]] = append([]string{args[1].(string)}, f.lists[keys[1]]...)
+	cmd.SetVal(int64(1))
+	return cmd
+}
+
+func (f *fakeScripter) Eval(ctx context.Context, _ string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.EvalSha(ctx, promoteScript.Hash(), keys, args...)
+}
+
+func (f *fakeScripter) EvalRO(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.Eval(ctx, script, keys, args...)
+}
+
+func (f *fakeScripter) EvalShaRO(ctx context.Context, sha string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.EvalSha(ctx, sha, keys, args...)
+}
+
+func (f *fakeScripter) ScriptExists(ctx context.Context, _ ...string) *redis.BoolSliceCmd {
+	return redis.NewBoolSliceCmd(ctx)
+}
+
+func (f *fakeScripter) ScriptLoad(ctx context.Context, _ string) *redis.StringCmd {
+	return redis.NewStringCmd(ctx)
+}
+
+func TestPromoteLegacyAndQueueAwareMembers(t *testing.T) {
+	legacy := `{"type":"send_welcome","payload":{"user_id":1}}`
+	job := `{"type":"send_invoice","payload":{"id":7},"queue":"emails"}`
+	member, err := encodeDelayed("emails", []byte(job))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeScripter{
+		delayed: map[string]bool{legacy: true, member: true},
+		lists:   map[string][]string{},
+	}
+
+	// Two promoters picking up the same members push each job once.
+	promote(context.Background(), f, []string{legacy, member})
+	promote(context.Background(), f, []string{legacy, member})
+
+	if got := f.lists[redisQueueKey]; len(got) != 1 || got[0] != legacy {
+		t.Errorf("default list = %q, want the legacy envelope as is", got)
+	}
+	if got := f.lists[redisKey("emails")]; len(got) != 1 || got[0] != job {
+		t.Errorf("emails list = %q, want the job without its delayed wrapper", got)
+	}
+	if len(f.delayed) != 0 {
+		t.Errorf("delayed set still holds %v", f.delayed)
+	}
+}
+
+func TestUnixSeconds(t *testing.T) {
+	at := time.Unix(1700000000, 123456789)
+	if got := unixSeconds(at); got != 1700000000.123 {
+		t.Errorf("unixSeconds = %v, want 1700000000.123", got)
+	}
+	// Members scheduled before queue-aware scheduling were scored with
+	// whole seconds; they must be due by the same instant.
+	if legacy := float64(at.Unix()); unixSeconds(at) < legacy {
+		t.Errorf("score %v is before the legacy score %v", unixSeconds(at), legacy)
+	}
+	if got := unixSeconds(at.Add(-time.Millisecond)); got >= unixSeconds(at) {
+		t.Errorf("scores lose millisecond order: %v >= %v", got, unixSeconds(at))
+	}
+}
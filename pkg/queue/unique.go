@@ -0,0 +1,137 @@
+package queue
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/cache"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// ------------------- Unique jobs -------------------
+
+// ShouldBeUnique is implemented by jobs that must not be queued twice.
+// While a job with the same type and UniqueID is pending, dispatching
+// another is a no-op:
+//
+//	func (j WelcomeEmailJob) UniqueID() string { return strconv.Itoa(int(j.UserID)) }
+//
+// The lock is released once the job has been processed (or has exhausted its
+// retries), or DefaultUniqueFor after it is due at the latest. Implement
+// UniqueFor to keep it for a fixed window instead, even after the job has
+// run.
+type ShouldBeUnique interface {
+	Job
+	UniqueID() string
+}
+
+// UniqueFor is implemented by unique jobs that stay unique for a fixed
+// window after dispatch, e.g. "one welcome email per user per day".
+type UniqueFor interface {
+	UniqueFor() time.Duration
+}
+
+// DefaultUniqueFor bounds how long a unique job's lock is held when the job
+// never finishes (for example, the worker was killed).
+var DefaultUniqueFor = time.Hour
+
+// uniqueKey returns the lock key for job registered as typeName, or "" if
+// it is not unique. Workers pass the envelope type, since they decode into a
+// pointer from the factory.
+func uniqueKey(typeName string, job Job) string {
+	u, ok := job.(ShouldBeUnique)
+	if !ok {
+		return ""
+	}
+	return "kashvi:unique:" + typeName + ":" + u.UniqueID()
+}
+
+// acquireUnique takes the lock for a unique job due after delay. ok is
+// false when an equal job is already pending; key is "" for jobs that are
+// not unique.
+func acquireUnique(job Job, delay time.Duration) (key string, ok bool) {
+	job, _ = unwrap(job)
+	key = uniqueKey(fmt.Sprintf("%T", job), job)
+	if key == "" {
+		return "", true
+	}
+	// A delayed job is pending until it has run, not just until it is due.
+	ttl := max(delay, 0) + DefaultUniqueFor
+	if uf, ok := job.(UniqueFor); ok && uf.UniqueFor() > 0 {
+		ttl = uf.UniqueFor()
+	}
+
+	if cache.RDB != nil {
+		added, err := cache.Add(key, time.Now().Unix(), ttl)
+		if err != nil {
+			// Better a rare duplicate than dropping the job.
+			logger.Warn("queue: unique lock unavailable", "key", key, "error", err)
+			return "", true
+		}
+		if !added {
+			logger.Debug("queue: skipped duplicate job", "key", key)
+		}
+		return key, added
+	}
+	if !localLocks.add(key, ttl) {
+		logger.Debug("queue: skipped duplicate job", "key", key)
+		return key, false
+	}
+	return key, true
+}
+
+// releaseProcessed releases the lock of a job that ran successfully, unless
+// it asked to stay unique for a fixed window.
+func releaseProcessed(typeName string, job Job) {
+	if _, ok := job.(UniqueFor); ok {
+		return
+	}
+	releaseUnique(uniqueKey(typeName, job))
+}
+
+func releaseUnique(key string) {
+	if key == "" {
+		return
+	}
+	if cache.RDB != nil {
+		if err := cache.Del(key); err != nil {
+			logger.Warn("queue: release unique lock", "key", key, "error", err)
+		}
+		return
+	}
+	localLocks.del(key)
+}
+
+// localLocks hold unique-job locks when Redis is not connected, which only
+// de-duplicates within this process.
+var localLocks = &lockSet{locks: map[string]time.Time{}}
+
+type lockSet struct {
+	mu    sync.Mutex
+	locks map[string]time.Time // key → expiry
+}
+
+func (s *lockSet) add(key string, ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if exp, ok := s.locks[key]; ok && now.Before(exp) {
+		return false
+	}
+	if len(s.locks) >= 1024 {
+		for k, exp := range s.locks {
+			if !now.Before(exp) {
+				delete(s.locks, k)
+			}
+		}
+	}
+	s.locks[key] = now.Add(ttl)
+	return true
+}
+
+func (s *lockSet) del(key string) {
+	s.mu.Lock()
+	delete(s.locks, key)
+	s.mu.Unlock()
+}
@@ -0,0 +1,285 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/health"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/metrics"
+)
+
+// WorkerOptions configures Work.
+//
+// The limits make long-running workers restart cleanly under a process
+// supervisor (supervisord, systemd, Kubernetes): when one is hit the worker
+// stops fetching, lets in-flight jobs finish and returns.
+type WorkerOptions struct {
+	// Queues to consume, highest priority first. Default: ["default"].
+	Queues []string
+	// Concurrency is the number of jobs processed in parallel. Default: 5.
+	Concurrency int
+	// MaxJobs stops the worker after this many jobs (0 = unlimited).
+	MaxJobs int
+	// MaxTime stops the worker after it has run this long (0 = unlimited).
+	MaxTime time.Duration
+	// MemoryLimit stops the worker when the Go runtime holds more than this
+	// many bytes from the OS (0 = unlimited).
+	MemoryLimit uint64
+
+	// DrainTimeout bounds how long Work waits for in-flight jobs after it
+	// stops fetching (0 = wait for them all). Set it below the supervisor's
+	// kill timeout, e.g. Kubernetes' terminationGracePeriodSeconds, so the
+	// abandoned jobs are logged before the process is killed.
+	DrainTimeout time.Duration
+
+	// MaxConcurrency enables autoscaling when above Concurrency: the pool
+	// grows towards it while a backlog builds up and shrinks back to
+	// Concurrency once the queues are quiet.
+	MaxConcurrency int
+	// ScaleInterval is how often an autoscaling pool is resized. Default: 1s.
+	ScaleInterval time.Duration
+	// TargetDrain is how quickly an autoscaling pool aims to clear the
+	// current backlog, given the measured job latency. Default: 10s.
+	TargetDrain time.Duration
+}
+
+// StopReason explains why Work returned.
+type StopReason string
+
+const (
+	StopContext StopReason = "context" // ctx cancelled (e.g. SIGTERM)
+	StopMaxJobs StopReason = "max-jobs"
+	StopMaxTime StopReason = "max-time"
+	StopMemory  StopReason = "memory"
+)
+
+// popFailureGrace is how long the driver may keep failing pops before the
+// pool's readiness check fails.
+var popFailureGrace = 30 * time.Second
+
+// memoryCheckInterval is how often Work samples memory when MemoryLimit is set.
+var memoryCheckInterval = time.Second
+
+// Work processes jobs until ctx is cancelled or a limit in opts is reached,
+// then waits for in-flight jobs and reports why it stopped.
+func Work(ctx context.Context, opts WorkerOptions) StopReason {
+	return defaultManager.run(ctx, opts)
+}
+
+func (m *Manager) run(ctx context.Context, opts WorkerOptions) StopReason {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 5
+	}
+	if len(opts.Queues) == 0 {
+		opts.Queues = []string{DefaultQueue}
+	}
+
+	fetchCtx, stopFetching := context.WithCancel(ctx)
+	defer stopFetching()
+
+	var (
+		reason    atomic.Value // StopReason
+		processed atomic.Int64
+		wg        sync.WaitGroup
+		alive     atomic.Int64
+		pops      popHealth
+	)
+	stop := func(r StopReason) {
+		if reason.CompareAndSwap(nil, r) {
+			stopFetching()
+		}
+	}
+
+	if opts.MaxTime > 0 {
+		t := time.AfterFunc(opts.MaxTime, func() { stop(StopMaxTime) })
+		defer t.Stop()
+	}
+	if opts.MemoryLimit > 0 {
+		go watchMemory(fetchCtx, opts.MemoryLimit, func() { stop(StopMemory) })
+	}
+
+	sc := &scaler{m: m, opts: opts}
+	sc.spawn = func() context.CancelFunc {
+		workerCtx, cancel := context.WithCancel(fetchCtx)
+		wg.Add(1)
+		metrics.QueueWorkers.Inc()
+		alive.Add(1)
+		go func() {
+			defer wg.Done()
+			defer metrics.QueueWorkers.Dec()
+			defer alive.Add(-1)
+			for workerCtx.Err() == nil {
+				raw, err := m.pop(workerCtx, opts.Queues)
+				if err != nil {
+					if workerCtx.Err() != nil {
+						return
+					}
+					pops.fail(err)
+					time.Sleep(500 * time.Millisecond)
+					continue
+				}
+				pops.ok()
+				if raw == nil {
+					continue
+				}
+
+				sc.busy.Add(1)
+				start := time.Now()
+				m.process(raw)
+				m.ack(raw)
+				sc.observe(time.Since(start))
+				sc.busy.Add(-1)
+				if n := processed.Add(1); opts.MaxJobs > 0 && n >= int64(opts.MaxJobs) {
+					stop(StopMaxJobs)
+				}
+			}
+		}()
+		return cancel
+	}
+
+	checkName := "queue:" + strings.Join(opts.Queues, ",")
+	health.Register(checkName, func(context.Context) error {
+		if alive.Load() == 0 {
+			return errors.New("no workers running")
+		}
+		return pops.check(popFailureGrace)
+	})
+	defer health.Unregister(checkName)
+
+	logger.Info("queue: workers started", "count", opts.Concurrency, "queues", opts.Queues)
+	sc.resize(opts.Concurrency)
+	scaling := make(chan struct{})
+	if opts.MaxConcurrency > opts.Concurrency {
+		go func() {
+			defer close(scaling)
+			sc.loop(fetchCtx)
+		}()
+	} else {
+		close(scaling)
+	}
+
+	<-fetchCtx.Done()
+	stop(StopContext)
+	<-scaling // no workers are spawned after this
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	var timeout <-chan time.Time
+	if opts.DrainTimeout > 0 {
+		t := time.NewTimer(opts.DrainTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case <-drained:
+	case <-timeout:
+		logger.Warn("queue: drain timed out, abandoning in-flight jobs", "in_flight", sc.busy.Load())
+	}
+
+	r := reason.Load().(StopReason)
+	logger.Info("queue: workers stopped", "reason", string(r), "processed", processed.Load())
+	return r
+}
+
+// popHealth tracks how long the driver has been failing to pop, so one
+// dropped Redis connection does not flip readiness but an outage does.
+type popHealth struct {
+	since atomic.Int64 // unix nanos of the first failure in a row; 0 = healthy
+	last  atomic.Value // error
+}
+
+func (p *popHealth) fail(err error) {
+	p.last.Store(err)
+	p.since.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+func (p *popHealth) ok() { p.since.Store(0) }
+
+func (p *popHealth) check(grace time.Duration) error {
+	since := p.since.Load()
+	if since == 0 {
+		return nil
+	}
+	if d := time.Since(time.Unix(0, since)); d > grace {
+		return fmt.Errorf("driver failing for %s: %v", d.Truncate(time.Second), p.last.Load())
+	}
+	return nil
+}
+
+// pop reads the next job from queues, falling back to the driver's single
+// queue when it does not support named queues.
+func (m *Manager) pop(ctx context.Context, queues []string) ([]byte, error) {
+	m.mu.RLock()
+	d := m.driver
+	m.mu.RUnlock()
+
+	if nd, ok := d.(NamedDriver); ok {
+		return nd.PopFrom(ctx, queues)
+	}
+	return d.Pop(ctx)
+}
+
+// ack tells an AckDriver that raw has finished running.
+func (m *Manager) ack(raw []byte) {
+	m.mu.RLock()
+	d := m.driver
+	m.mu.RUnlock()
+
+	if ad, ok := d.(AckDriver); ok {
+		if err := ad.Ack(raw); err != nil {
+			logger.Error("queue: ack failed", "error", err)
+		}
+	}
+}
+
+func watchMemory(ctx context.Context, limit uint64, exceeded func()) {
+	t := time.NewTicker(memoryCheckInterval)
+	defer t.Stop()
+	var ms runtime.MemStats
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			runtime.ReadMemStats(&ms)
+			if used := ms.Sys - ms.HeapReleased; used > limit {
+				logger.Warn("queue: memory limit reached", "used_bytes", used, "limit_bytes", limit)
+				exceeded()
+				return
+			}
+		}
+	}
+}
+
+// ParseByteSize parses sizes like "256MB", "1G", "512mi" or plain bytes.
+// Units are powers of 1024.
+func ParseByteSize(s string) (uint64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+	mult := uint64(1)
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'K':
+			mult, s = 1<<10, s[:n-1]
+		case 'M':
+			mult, s = 1<<20, s[:n-1]
+		case 'G':
+			mult, s = 1<<30, s[:n-1]
+		}
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return v * mult, nil
+}
@@ -0,0 +1,267 @@
+// Package quota enforces per-tenant request quotas (per day and per month)
+// and records usage for billing.
+//
+// Counters live in Redis when pkg/cache is connected, otherwise in process
+// memory. A background rollup copies them into the kashvi_quota_usage table
+// so reports survive Redis evictions and restarts.
+//
+//	api := r.Group("/api", quota.Middleware(quota.DefaultOptions()))
+//	api.Get("/usage", "usage", quota.UsageHandler(quota.DefaultOptions()))
+//
+// Limits come from QUOTA_DAILY / QUOTA_MONTHLY (0 = unlimited) and can be
+// overridden per tenant:
+//
+//	quota.SetLimits("key:3f9a…", quota.Limits{Daily: 50_000, Monthly: 1_000_000})
+package quota
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+	"github.com/shashiranjanraj/kashvi/pkg/response"
+)
+
+// Period is a quota window.
+type Period string
+
+const (
+	Day   Period = "day"
+	Month Period = "month"
+)
+
+// Limits caps requests per period. Zero means unlimited.
+type Limits struct {
+	Daily   int64
+	Monthly int64
+}
+
+func (l Limits) of(p Period) int64 {
+	if p == Day {
+		return l.Daily
+	}
+	return l.Monthly
+}
+
+// Options configures Middleware and UsageHandler.
+type Options struct {
+	// Tenant names the caller; an empty result skips quota tracking.
+	Tenant func(r *http.Request) string
+	// Limits returns the quota for a tenant.
+	Limits func(tenant string) Limits
+}
+
+// DefaultOptions identifies tenants with DefaultTenant and reads limits
+// from SetLimits overrides, falling back to QUOTA_DAILY / QUOTA_MONTHLY.
+func DefaultOptions() Options {
+	return Options{Tenant: DefaultTenant, Limits: LimitsFor}
+}
+
+// DefaultTenant keys a request by its X-Api-Key header (hashed, so raw keys
+// never reach Redis or the database) or by the authenticated user ID.
+func DefaultTenant(r *http.Request) string {
+	if key := r.Header.Get("X-Api-Key"); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+	if id, ok := middleware.UserIDFromCtx(r); ok {
+		return "user:" + strconv.FormatUint(uint64(id), 10)
+	}
+	return ""
+}
+
+var (
+	limitsMu  sync.RWMutex
+	overrides = map[string]Limits{}
+)
+
+// SetLimits overrides the quota of one tenant.
+func SetLimits(tenant string, l Limits) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	overrides[tenant] = l
+}
+
+// LimitsFor returns the tenant's override or the QUOTA_DAILY /
+// QUOTA_MONTHLY defaults.
+func LimitsFor(tenant string) Limits {
+	limitsMu.RLock()
+	l, ok := overrides[tenant]
+	limitsMu.RUnlock()
+	if ok {
+		return l
+	}
+	daily, _ := strconv.ParseInt(config.Get("QUOTA_DAILY", "0"), 10, 64)
+	monthly, _ := strconv.ParseInt(config.Get("QUOTA_MONTHLY", "0"), 10, 64)
+	return Limits{Daily: daily, Monthly: monthly}
+}
+
+// ─── Usage ────────────────────────────────────────────────────────────────────
+
+// PeriodUsage is a tenant's consumption in the current window.
+type PeriodUsage struct {
+	Period    Period    `json:"period"`
+	Bucket    string    `json:"bucket"` // "2026-10-18" or "2026-10" (UTC)
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"`     // 0 = unlimited
+	Remaining int64     `json:"remaining"` // -1 when unlimited
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+func (u PeriodUsage) exceeded() bool { return u.Limit > 0 && u.Used > u.Limit }
+
+// Report is a tenant's usage for the current day and month.
+type Report struct {
+	Tenant string      `json:"tenant"`
+	Day    PeriodUsage `json:"day"`
+	Month  PeriodUsage `json:"month"`
+}
+
+// bucket returns the window label and its end for t.
+func bucket(p Period, t time.Time) (string, time.Time) {
+	t = t.UTC()
+	if p == Day {
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01-02"), start.AddDate(0, 0, 1)
+	}
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start.Format("2006-01"), start.AddDate(0, 1, 0)
+}
+
+// ttl keeps a counter around past its window so the last rollup still sees
+// the final count.
+func ttl(p Period) time.Duration {
+	if p == Day {
+		return 48 * time.Hour
+	}
+	return 35 * 24 * time.Hour
+}
+
+func usage(p Period, tenant string, used int64, lim Limits, now time.Time) PeriodUsage {
+	b, reset := bucket(p, now)
+	u := PeriodUsage{Period: p, Bucket: b, Used: used, Limit: lim.of(p), Remaining: -1, ResetAt: reset}
+	if u.Limit > 0 {
+		u.Remaining = max(u.Limit-used, 0)
+	}
+	return u
+}
+
+// Usage returns tenant's current consumption without counting a request.
+func Usage(ctx context.Context, tenant string, lim Limits) (Report, error) {
+	s, now := currentStore(), time.Now()
+	rep := Report{Tenant: tenant}
+	for _, p := range []Period{Day, Month} {
+		b, _ := bucket(p, now)
+		n, err := s.Get(ctx, key(p, b, tenant))
+		if err != nil {
+			return rep, err
+		}
+		if p == Day {
+			rep.Day = usage(p, tenant, n, lim, now)
+		} else {
+			rep.Month = usage(p, tenant, n, lim, now)
+		}
+	}
+	return rep, nil
+}
+
+// hit counts one request against both windows and returns the new totals.
+func hit(ctx context.Context, tenant string, lim Limits, by int64) (Report, error) {
+	s, now := currentStore(), time.Now()
+	rep := Report{Tenant: tenant}
+	for _, p := range []Period{Day, Month} {
+		b, _ := bucket(p, now)
+		n, err := s.Incr(ctx, key(p, b, tenant), by, ttl(p))
+		if err != nil {
+			return rep, err
+		}
+		if p == Day {
+			rep.Day = usage(p, tenant, n, lim, now)
+		} else {
+			rep.Month = usage(p, tenant, n, lim, now)
+		}
+	}
+	return rep, nil
+}
+
+// ─── HTTP ─────────────────────────────────────────────────────────────────────
+
+// Middleware counts each request against its tenant's daily and monthly
+// quota. Over quota, it answers 429 with Retry-After; rejected requests are
+// not billed. Responses carry X-Quota-Limit-<Period>,
+// X-Quota-Remaining-<Period> and X-Quota-Reset-<Period> for limited periods.
+// If the counter store fails, requests are let through.
+func Middleware(opts Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant := opts.Tenant(r)
+			if tenant == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			lim := opts.Limits(tenant)
+			rep, err := hit(r.Context(), tenant, lim, 1)
+			if err != nil {
+				logger.Warn("quota: counter unavailable, allowing request", "tenant", tenant, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			setHeaders(w, rep)
+
+			for _, u := range []PeriodUsage{rep.Day, rep.Month} {
+				if !u.exceeded() {
+					continue
+				}
+				if _, err := hit(r.Context(), tenant, lim, -1); err != nil {
+					logger.Warn("quota: could not un-count rejected request", "tenant", tenant, "error", err)
+				}
+				retry := int(time.Until(u.ResetAt).Seconds()) + 1
+				w.Header().Set("Retry-After", strconv.Itoa(retry))
+				response.Error(w, http.StatusTooManyRequests, "Quota exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func setHeaders(w http.ResponseWriter, rep Report) {
+	for _, u := range []PeriodUsage{rep.Day, rep.Month} {
+		if u.Limit <= 0 {
+			continue
+		}
+		name := "Day"
+		if u.Period == Month {
+			name = "Month"
+		}
+		h := w.Header()
+		h.Set("X-Quota-Limit-"+name, strconv.FormatInt(u.Limit, 10))
+		h.Set("X-Quota-Remaining-"+name, strconv.FormatInt(u.Remaining, 10))
+		h.Set("X-Quota-Reset-"+name, strconv.FormatInt(u.ResetAt.Unix(), 10))
+	}
+}
+
+// UsageHandler reports the calling tenant's current usage as JSON.
+// Unidentified callers get 401.
+func UsageHandler(opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenant := opts.Tenant(r)
+		if tenant == "" {
+			response.Unauthorized(w)
+			return
+		}
+		rep, err := Usage(r.Context(), tenant, opts.Limits(tenant))
+		if err != nil {
+			response.Error(w, http.StatusServiceUnavailable, "Usage is unavailable")
+			return
+		}
+		response.Success(w, rep)
+	}
+}
@@ -0,0 +1,99 @@
+package quota_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/quota"
+)
+
+func TestMiddleware_EnforcesDailyQuota(t *testing.T) {
+	quota.SetStore(quota.NewMemoryStore())
+	defer quota.SetStore(nil)
+
+	opts := quota.Options{
+		Tenant: func(r *http.Request) string { return r.Header.Get("X-Tenant") },
+		Limits: func(string) quota.Limits { return quota.Limits{Daily: 2} },
+	}
+	h := quota.Middleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	call := func(tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Tenant", tenant)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		rec := call("acme")
+		if rec.Code != http.StatusOK || rec.Header().Get("X-Quota-Remaining-Day") != wantRemaining {
+			t.Fatalf("request %d: %d remaining=%q", i+1, rec.Code, rec.Header().Get("X-Quota-Remaining-Day"))
+		}
+	}
+	rec := call("acme")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("over quota: %d Retry-After=%q, want 429 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec.Header().Get("X-Quota-Limit-Month") != "" {
+		t.Fatal("unlimited periods should not get quota headers")
+	}
+	if rec := call("other"); rec.Code != http.StatusOK {
+		t.Fatalf("other tenant: %d, want its own quota", rec.Code)
+	}
+
+	rep, err := quota.Usage(context.Background(), "acme", quota.Limits{Daily: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Day.Used != 2 || rep.Month.Used != 2 || rep.Month.Remaining != -1 {
+		t.Fatalf("usage = %+v, want the rejected request left unbilled", rep)
+	}
+}
+
+func TestRollupAndHistory(t *testing.T) {
+	quota.SetStore(quota.NewMemoryStore())
+	defer quota.SetStore(nil)
+	db, err := database.OpenMemory("quota_rollup")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := quota.Middleware(quota.Options{
+		Tenant: func(*http.Request) string { return "user:7" },
+		Limits: func(string) quota.Limits { return quota.Limits{} },
+	})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	for i := 0; i < 3; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		// Rolling up twice must not double-count.
+		if _, err := quota.Rollup(context.Background(), db); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	month := time.Now().UTC().Format("2006-01")
+	rows, err := quota.History(db, quota.Month, month, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Tenant != "user:7" || rows[0].Requests != 3 {
+		t.Fatalf("history = %+v, want user:7 with 3 requests", rows)
+	}
+}
+
+func TestDefaultTenant_HashesAPIKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Key", "sk_live_secret")
+	got := quota.DefaultTenant(req)
+	if !strings.HasPrefix(got, "key:") || strings.Contains(got, "secret") {
+		t.Fatalf("DefaultTenant = %q, want a hashed key id", got)
+	}
+	if quota.DefaultTenant(httptest.NewRequest(http.MethodGet, "/", nil)) != "" {
+		t.Fatal("anonymous request should have no tenant")
+	}
+}
@@ -0,0 +1,125 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// UsageRecord is one tenant's request count for a day or month.
+type UsageRecord struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	Tenant    string    `gorm:"size:255;not null;uniqueIndex:idx_kashvi_quota_usage,priority:1" json:"tenant"`
+	Period    Period    `gorm:"size:10;not null;uniqueIndex:idx_kashvi_quota_usage,priority:2" json:"period"`
+	Bucket    string    `gorm:"size:10;not null;uniqueIndex:idx_kashvi_quota_usage,priority:3" json:"bucket"`
+	Requests  int64     `gorm:"not null;default:0" json:"requests"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (UsageRecord) TableName() string { return "kashvi_quota_usage" }
+
+// RollupInterval reads QUOTA_ROLLUP_INTERVAL (default 5m, 0 disables).
+func RollupInterval() time.Duration {
+	d, err := time.ParseDuration(config.Get("QUOTA_ROLLUP_INTERVAL", "5m"))
+	if err != nil {
+		return 5 * time.Minute
+	}
+	return d
+}
+
+// Rollup copies every live counter into kashvi_quota_usage. Counters are
+// absolute, so running it repeatedly (or from several instances sharing
+// Redis) is safe. It returns the number of rows written.
+func Rollup(ctx context.Context, db *gorm.DB) (int, error) {
+	if err := db.AutoMigrate(&UsageRecord{}); err != nil {
+		return 0, fmt.Errorf("quota: migrate: %w", err)
+	}
+	var rows []UsageRecord
+	now := time.Now()
+	err := currentStore().Each(ctx, func(k string, n int64) {
+		if p, b, tenant, ok := parseKey(k); ok {
+			rows = append(rows, UsageRecord{Tenant: tenant, Period: p, Bucket: b, Requests: n, UpdatedAt: now})
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	err = db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant"}, {Name: "period"}, {Name: "bucket"}},
+		DoUpdates: clause.AssignmentColumns([]string{"requests", "updated_at"}),
+	}).CreateInBatches(rows, 200).Error
+	if err != nil {
+		return 0, fmt.Errorf("quota: rollup: %w", err)
+	}
+	return len(rows), nil
+}
+
+var (
+	rollupMu sync.Mutex
+	rollupDB *gorm.DB
+)
+
+// StartRollups runs Rollup every interval until ctx is cancelled. Flush
+// runs a final one at shutdown.
+func StartRollups(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	if db == nil || interval <= 0 {
+		return
+	}
+	rollupMu.Lock()
+	rollupDB = db
+	rollupMu.Unlock()
+
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if _, err := Rollup(ctx, db); err != nil {
+					logger.Warn("quota: rollup failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Flush runs a last rollup if StartRollups was called.
+func Flush(ctx context.Context) error {
+	rollupMu.Lock()
+	db := rollupDB
+	rollupMu.Unlock()
+	if db == nil {
+		return nil
+	}
+	_, err := Rollup(ctx, db)
+	return err
+}
+
+// History returns rolled-up usage for one period and bucket (e.g. Month,
+// "2026-10"), optionally for a single tenant, busiest first.
+func History(db *gorm.DB, p Period, bucket, tenant string) ([]UsageRecord, error) {
+	if err := db.AutoMigrate(&UsageRecord{}); err != nil {
+		return nil, fmt.Errorf("quota: migrate: %w", err)
+	}
+	q := db.Where("period = ? AND bucket = ?", p, bucket)
+	if tenant != "" {
+		q = q.Where("tenant = ?", tenant)
+	}
+	var out []UsageRecord
+	if err := q.Order("requests desc, tenant").Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("quota: history: %w", err)
+	}
+	return out, nil
+}
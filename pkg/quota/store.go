@@ -0,0 +1,171 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/shashiranjanraj/kashvi/pkg/cache"
+)
+
+const keyPrefix = "kashvi:quota:"
+
+// key is kashvi:quota:<period>:<bucket>:<tenant>. The tenant goes last so
+// it may contain colons.
+func key(p Period, bucket, tenant string) string {
+	return keyPrefix + string(p) + ":" + bucket + ":" + tenant
+}
+
+func parseKey(k string) (p Period, bucket, tenant string, ok bool) {
+	parts := strings.SplitN(strings.TrimPrefix(k, keyPrefix), ":", 3)
+	if len(parts) != 3 || !strings.HasPrefix(k, keyPrefix) {
+		return "", "", "", false
+	}
+	return Period(parts[0]), parts[1], parts[2], true
+}
+
+// Store holds the live counters.
+type Store interface {
+	// Incr adds by to key and returns the new value, keeping it for ttl.
+	Incr(ctx context.Context, key string, by int64, ttl time.Duration) (int64, error)
+	Get(ctx context.Context, key string) (int64, error)
+	// Each calls fn for every counter.
+	Each(ctx context.Context, fn func(key string, n int64)) error
+}
+
+var (
+	storeMu  sync.Mutex
+	override Store
+	memory   = NewMemoryStore()
+	redisFor *redis.Client
+	redisS   Store
+)
+
+// SetStore replaces the counter store (nil restores the default).
+func SetStore(s Store) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	override = s
+}
+
+// currentStore is resolved per call: Redis connects after routes (and
+// their middleware) are built.
+func currentStore() Store {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	if override != nil {
+		return override
+	}
+	if rdb := cache.RDB; rdb != nil {
+		if rdb != redisFor {
+			redisFor, redisS = rdb, NewRedisStore(rdb)
+		}
+		return redisS
+	}
+	return memory
+}
+
+// ─── Redis ────────────────────────────────────────────────────────────────────
+
+// RedisStore keeps counters in Redis, shared by every app instance.
+type RedisStore struct{ rdb *redis.Client }
+
+// NewRedisStore wraps rdb.
+func NewRedisStore(rdb *redis.Client) *RedisStore { return &RedisStore{rdb: rdb} }
+
+func (s *RedisStore) Incr(ctx context.Context, key string, by int64, ttl time.Duration) (int64, error) {
+	pipe := s.rdb.TxPipeline()
+	incr := pipe.IncrBy(ctx, key, by)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("quota/redis: incr: %w", err)
+	}
+	return incr.Val(), nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (int64, error) {
+	n, err := s.rdb.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("quota/redis: get: %w", err)
+	}
+	return n, nil
+}
+
+func (s *RedisStore) Each(ctx context.Context, fn func(key string, n int64)) error {
+	iter := s.rdb.Scan(ctx, 0, keyPrefix+"*", 500).Iterator()
+	for iter.Next(ctx) {
+		n, err := s.Get(ctx, iter.Val())
+		if err != nil {
+			return err
+		}
+		fn(iter.Val(), n)
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("quota/redis: scan: %w", err)
+	}
+	return nil
+}
+
+// ─── Memory ───────────────────────────────────────────────────────────────────
+
+// MemoryStore keeps counters in process; each instance counts separately.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]memCounter
+}
+
+type memCounter struct {
+	n       int64
+	expires time.Time
+}
+
+// NewMemoryStore returns an empty in-process store.
+func NewMemoryStore() *MemoryStore { return &MemoryStore{counters: map[string]memCounter{}} }
+
+func (s *MemoryStore) Incr(_ context.Context, key string, by int64, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.counters[key]
+	if time.Now().After(c.expires) {
+		c.n = 0
+	}
+	c.n += by
+	c.expires = time.Now().Add(ttl)
+	s.counters[key] = c
+	return c.n, nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.counters[key]
+	if !ok || time.Now().After(c.expires) {
+		return 0, nil
+	}
+	return c.n, nil
+}
+
+func (s *MemoryStore) Each(_ context.Context, fn func(key string, n int64)) error {
+	s.mu.Lock()
+	now := time.Now()
+	snapshot := make(map[string]int64, len(s.counters))
+	for k, c := range s.counters {
+		if now.After(c.expires) {
+			delete(s.counters, k)
+			continue
+		}
+		snapshot[k] = c.n
+	}
+	s.mu.Unlock()
+	for k, n := range snapshot {
+		fn(k, n)
+	}
+	return nil
+}
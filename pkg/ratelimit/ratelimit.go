@@ -0,0 +1,186 @@
+// Package ratelimit throttles requests with per-client token buckets.
+//
+// Buckets live in Redis when pkg/cache is connected, so every app instance
+// shares them; otherwise they are kept in process memory.
+//
+//	api := r.Group("/api", ratelimit.PerMinute(60).ByIP())
+//	api.Post("/search", "search", search, ratelimit.PerSecond(2).Burst(5).ByHeader("X-API-Key"))
+//
+//	// ByUser needs the user, so it goes after AuthMiddleware.
+//	account := api.Group("/account", middleware.AuthMiddleware, ratelimit.PerHour(1000).ByUser())
+//
+// A client may spend its whole burst at once and then gets tokens back at
+// the configured rate. Rejected requests get 429 with Retry-After; every
+// response carries X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset (Unix time at which the bucket is full again).
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/clientip"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/middleware"
+	"github.com/shashiranjanraj/kashvi/pkg/response"
+)
+
+// Rule is a rate limit waiting for a key; finish it with ByIP, ByHeader,
+// ByUser or By to get the middleware.
+type Rule struct {
+	rate  float64 // tokens per second
+	burst int
+	name  string
+}
+
+// Per allows n requests per window.
+func Per(n int, window time.Duration) *Rule {
+	if n < 1 {
+		n = 1
+	}
+	return &Rule{
+		rate:  float64(n) / window.Seconds(),
+		burst: n,
+		name:  fmt.Sprintf("%d-per-%s", n, window),
+	}
+}
+
+// PerSecond allows n requests per second.
+func PerSecond(n int) *Rule { return Per(n, time.Second) }
+
+// PerMinute allows n requests per minute.
+func PerMinute(n int) *Rule { return Per(n, time.Minute) }
+
+// PerHour allows n requests per hour.
+func PerHour(n int) *Rule { return Per(n, time.Hour) }
+
+// Parse reads a rule written as "<n>/<window>" with an optional
+// ":<burst>", where window is s, m, h or a duration: "100/m", "10/s:20",
+// "500/30s". It is how config files spell limits.
+func Parse(s string) (*Rule, error) {
+	spec, burst, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+	count, window, ok := strings.Cut(spec, "/")
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if !ok || err != nil || n < 1 {
+		return nil, fmt.Errorf("ratelimit: invalid rule %q", s)
+	}
+	var d time.Duration
+	switch w := strings.TrimSpace(window); w {
+	case "s", "sec", "second":
+		d = time.Second
+	case "m", "min", "minute":
+		d = time.Minute
+	case "h", "hour":
+		d = time.Hour
+	default:
+		if d, err = time.ParseDuration(w); err != nil || d <= 0 {
+			return nil, fmt.Errorf("ratelimit: invalid window in %q", s)
+		}
+	}
+	r := Per(n, d)
+	if hasBurst {
+		b, err := strconv.Atoi(strings.TrimSpace(burst))
+		if err != nil || b < 1 {
+			return nil, fmt.Errorf("ratelimit: invalid burst in %q", s)
+		}
+		r.Burst(b)
+	}
+	return r, nil
+}
+
+// Burst caps how many requests a client may make at once; it defaults to
+// the per-window count.
+func (r *Rule) Burst(n int) *Rule {
+	if n >= 1 {
+		r.burst = n
+	}
+	return r
+}
+
+// Named scopes the rule's buckets. Rules with the same limit share buckets
+// by default; name them to give a route its own allowance.
+func (r *Rule) Named(name string) *Rule {
+	r.name = name
+	return r
+}
+
+// Limit returns the rule's burst, the most requests a client can make at
+// once (reported as X-RateLimit-Limit).
+func (r *Rule) Limit() int { return r.burst }
+
+// Take spends one token from client's bucket. Use it to apply a rule
+// outside HTTP middleware, e.g. to gRPC calls or queue consumers.
+func (r *Rule) Take(ctx context.Context, client string) (Result, error) {
+	return currentStore().Take(ctx, bucketKey(r.name, client), r.rate, r.burst)
+}
+
+// ByIP keys buckets by the resolved client IP (see pkg/clientip).
+func (r *Rule) ByIP() func(http.Handler) http.Handler {
+	return r.By(ipKey)
+}
+
+// ByHeader keys buckets by the value of header, e.g. an API key; the value
+// is hashed before it is stored. Requests without the header fall back to
+// their IP.
+func (r *Rule) ByHeader(header string) func(http.Handler) http.Handler {
+	return r.By(func(req *http.Request) string {
+		v := req.Header.Get(header)
+		if v == "" {
+			return ipKey(req)
+		}
+		sum := sha256.Sum256([]byte(v))
+		return "h:" + hex.EncodeToString(sum[:8])
+	})
+}
+
+// ByUser keys buckets by the authenticated user ID, so it must run after
+// middleware.AuthMiddleware. Anonymous requests fall back to their IP.
+func (r *Rule) ByUser() func(http.Handler) http.Handler {
+	return r.By(func(req *http.Request) string {
+		if id, ok := middleware.UserIDFromCtx(req); ok {
+			return "user:" + strconv.FormatUint(uint64(id), 10)
+		}
+		return ipKey(req)
+	})
+}
+
+func ipKey(r *http.Request) string { return "ip:" + clientip.String(r) }
+
+// By keys buckets with a custom function. An empty key skips limiting.
+// If the bucket store fails, requests are let through.
+func (r *Rule) By(key func(*http.Request) string) func(http.Handler) http.Handler {
+	rule := *r
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			k := key(req)
+			if k == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+			res, err := rule.Take(req.Context(), k)
+			if err != nil {
+				logger.Warn("ratelimit: store unavailable, allowing request", "key", k, "error", err)
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(rule.burst))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(res.ResetAfter).Unix(), 10))
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+				response.Error(w, http.StatusTooManyRequests, "Too Many Requests")
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
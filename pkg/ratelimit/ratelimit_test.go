@@ -0,0 +1,64 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/ratelimit"
+)
+
+func TestRule_TokenBucket(t *testing.T) {
+	ratelimit.SetStore(ratelimit.NewMemoryStore())
+	t.Cleanup(func() { ratelimit.SetStore(nil) })
+
+	h := ratelimit.PerMinute(60).Burst(2).Named("test").ByHeader("X-API-Key")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, want := range []string{"1", "0"} {
+		rec := do("alpha")
+		if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Remaining") != want {
+			t.Fatalf("request %d: %d remaining %q", i, rec.Code, rec.Header().Get("X-RateLimit-Remaining"))
+		}
+		if rec.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Fatalf("limit = %q", rec.Header().Get("X-RateLimit-Limit"))
+		}
+	}
+
+	rec := do("alpha")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("burst exhausted: got %d", rec.Code)
+	}
+	if s, _ := strconv.Atoi(rec.Header().Get("Retry-After")); s != 1 {
+		t.Fatalf("Retry-After = %q, want 1 (one token per second)", rec.Header().Get("Retry-After"))
+	}
+
+	if rec := do("beta"); rec.Code != http.StatusOK {
+		t.Fatalf("other key throttled: %d", rec.Code)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for in, limit := range map[string]int{"100/m": 100, "10/s:20": 20, "500/30s": 500, " 3 / hour ": 3} {
+		r, err := ratelimit.Parse(in)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", in, err)
+		}
+		if r.Limit() != limit {
+			t.Errorf("Parse(%q).Limit() = %d, want %d", in, r.Limit(), limit)
+		}
+	}
+	for _, in := range []string{"", "100", "x/m", "10/fortnight", "10/s:0"} {
+		if _, err := ratelimit.Parse(in); err == nil {
+			t.Errorf("Parse(%q) succeeded", in)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/shashiranjanraj/kashvi/pkg/cache"
+)
+
+const keyPrefix = "kashvi:ratelimit:"
+
+// bucketKey is kashvi:ratelimit:<rule>:<client>.
+func bucketKey(rule, client string) string { return keyPrefix + rule + ":" + client }
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed    bool
+	Remaining  int           // whole tokens left
+	RetryAfter time.Duration // until the next token, when not allowed
+	ResetAfter time.Duration // until the bucket is full again
+}
+
+// Store holds token buckets.
+type Store interface {
+	// Take removes one token from the bucket at key, which refills at rate
+	// tokens per second up to burst.
+	Take(ctx context.Context, key string, rate float64, burst int) (Result, error)
+}
+
+var (
+	storeMu  sync.Mutex
+	override Store
+	memory   = NewMemoryStore()
+	redisFor *redis.Client
+	redisS   Store
+)
+
+// SetStore replaces the bucket store (nil restores the default).
+func SetStore(s Store) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	override = s
+}
+
+// currentStore is resolved per request: Redis connects after routes (and
+// their middleware) are built.
+func currentStore() Store {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	if override != nil {
+		return override
+	}
+	if rdb := cache.RDB; rdb != nil {
+		if rdb != redisFor {
+			redisFor, redisS = rdb, NewRedisStore(rdb)
+		}
+		return redisS
+	}
+	return memory
+}
+
+// result derives a Result from the tokens left after a take.
+func result(allowed bool, tokens, rate float64, burst int) Result {
+	r := Result{
+		Allowed:    allowed,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: seconds((float64(burst) - tokens) / rate),
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return r
+}
+
+func seconds(s float64) time.Duration { return time.Duration(s * float64(time.Second)) }
+
+// ─── Redis ────────────────────────────────────────────────────────────────────
+
+// takeScript refills and takes from a bucket atomically. The bucket is a
+// hash of tokens and the last refill time (ms), expiring once it would be
+// full again anyway. Time comes from the Redis server, so instances whose
+// clocks drift apart still refill a shared bucket at the configured rate.
+var takeScript = redis.NewScript(`
+if redis.replicate_commands then redis.replicate_commands() end -- TIME before a write on Redis < 5
+local rate  = tonumber(ARGV[1]) / 1000
+local burst = tonumber(ARGV[2])
+local t     = redis.call('TIME')
+local now   = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(b[1]) or burst
+local ts = tonumber(b[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore keeps buckets in Redis, shared by every app instance.
+type RedisStore struct{ rdb *redis.Client }
+
+// NewRedisStore wraps rdb.
+func NewRedisStore(rdb *redis.Client) *RedisStore { return &RedisStore{rdb: rdb} }
+
+func (s *RedisStore) Take(ctx context.Context, key string, rate float64, burst int) (Result, error) {
+	vals, err := takeScript.Run(ctx, s.rdb, []string{key}, rate, burst).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit/redis: take: %w", err)
+	}
+	if len(vals) != 2 {
+		return Result{}, fmt.Errorf("ratelimit/redis: take: unexpected reply %v", vals)
+	}
+	allowed, _ := vals[0].(int64)
+	tokens, err := strconv.ParseFloat(fmt.Sprint(vals[1]), 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit/redis: take: %w", err)
+	}
+	return result(allowed == 1, tokens, rate, burst), nil
+}
+
+// ─── Memory ───────────────────────────────────────────────────────────────────
+
+// MemoryStore keeps buckets in process; each instance limits separately.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memBucket
+	lastSweep time.Time
+}
+
+type memBucket struct {
+	tokens float64
+	ts     time.Time
+	fullAt time.Time
+}
+
+// NewMemoryStore returns an empty in-process store.
+func NewMemoryStore() *MemoryStore { return &MemoryStore{buckets: map[string]*memBucket{}} }
+
+func (s *MemoryStore) Take(_ context.Context, key string, rate float64, burst int) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memBucket{tokens: float64(burst), ts: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.ts).Seconds()*rate)
+	b.ts = now
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	r := result(allowed, b.tokens, rate, burst)
+	b.fullAt = now.Add(r.ResetAfter)
+	return r, nil
+}
+
+// sweep drops full buckets once a minute; a missing bucket is a full one.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for k, b := range s.buckets {
+		if now.After(b.fullAt) {
+			delete(s.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// Package recorder captures sanitized request/response pairs in production so
+// incidents can be replayed locally or turned into testkit scenarios.
+//
+// Recording is opt-in (RECORDER_ENABLED=true) and filtered: by default only
+// requests that end in a 5xx are kept. Secrets are redacted before anything
+// is written — sensitive headers and JSON/form fields listed in the options.
+//
+//	r.Use(recorder.Middleware(recorder.DefaultOptions()))   // pkg/app kernel
+//
+// Recordings land on a storage disk (RECORDER_DISK, default "local") under
+// RECORDER_PREFIX. Outgoing calls made through pkg/http with the request
+// context (c.HTTP(), http.WithCtx) are captured alongside, so a replay and
+// an exported scenario can mock them:
+//
+//	kashvi replay 20240102-150405-a1b2c3d4 --target http://localhost:8080
+//	kashvi replay 20240102-150405-a1b2c3d4 --scenario testdata/scenarios
+package recorder
+
+import (
+	"bytes"
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	kashvihttp "github.com/shashiranjanraj/kashvi/pkg/http"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/reqid"
+	"github.com/shashiranjanraj/kashvi/pkg/respwriter"
+)
+
+// ─── Options ──────────────────────────────────────────────────────────────────
+
+// Options controls which requests are recorded and where they are stored.
+type Options struct {
+	Enabled    bool
+	Disk       string   // storage disk name
+	Prefix     string   // directory on the disk
+	Paths      []string // path.Match patterns; empty = every path
+	Methods    []string // empty = every method
+	MinStatus  int      // record only responses with status >= MinStatus (0 = all)
+	SampleRate float64  // fraction of matching requests to keep, 0..1
+	MaxBody    int      // request/response bytes kept per body
+
+	RedactHeaders []string // header names replaced with "[REDACTED]"
+	RedactFields  []string // JSON / form field names replaced with "[REDACTED]"
+}
+
+// DefaultOptions reads RECORDER_* from config.
+func DefaultOptions() Options {
+	minStatus, _ := strconv.Atoi(config.Get("RECORDER_MIN_STATUS", "500"))
+	sample, err := strconv.ParseFloat(config.Get("RECORDER_SAMPLE_RATE", "1"), 64)
+	if err != nil {
+		sample = 1
+	}
+	maxBody, err := strconv.Atoi(config.Get("RECORDER_MAX_BODY", "65536"))
+	if err != nil || maxBody <= 0 {
+		maxBody = 65536
+	}
+	return Options{
+		Enabled:    config.Get("RECORDER_ENABLED", "false") == "true",
+		Disk:       config.Get("RECORDER_DISK", "local"),
+		Prefix:     config.Get("RECORDER_PREFIX", "recordings"),
+		Paths:      splitList(config.Get("RECORDER_PATHS", "")),
+		Methods:    splitList(strings.ToUpper(config.Get("RECORDER_METHODS", ""))),
+		MinStatus:  minStatus,
+		SampleRate: sample,
+		MaxBody:    maxBody,
+		RedactHeaders: []string{
+			"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization",
+			"X-Api-Key", "X-Csrf-Token",
+		},
+		RedactFields: []string{
+			"password", "password_confirmation", "current_password", "token",
+			"access_token", "refresh_token", "secret", "api_key",
+			"card_number", "cvv", "otp",
+		},
+	}
+}
+
+func (o Options) matches(r *http.Request) bool {
+	if len(o.Methods) > 0 && !contains(o.Methods, r.Method) {
+		return false
+	}
+	if len(o.Paths) > 0 {
+		ok := false
+		for _, p := range o.Paths {
+			if m, _ := path.Match(p, r.URL.Path); m || strings.HasSuffix(p, "/*") && strings.HasPrefix(r.URL.Path, strings.TrimSuffix(p, "*")) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return o.SampleRate >= 1 || rand.Float64() < o.SampleRate
+}
+
+// ─── Recording ────────────────────────────────────────────────────────────────
+
+// Message is one side of an HTTP exchange.
+type Message struct {
+	Status    int               `json:"status,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      string            `json:"body,omitempty"`
+	Base64    bool              `json:"base64,omitempty"`    // Body is base64 (binary payload)
+	Truncated bool              `json:"truncated,omitempty"` // Body was cut at MaxBody
+}
+
+// Outbound is an outgoing call made while serving the recorded request.
+type Outbound struct {
+	Method   string  `json:"method"`
+	URL      string  `json:"url"`
+	Response Message `json:"response"`
+}
+
+// Recording is a persisted request/response pair.
+type Recording struct {
+	ID        string        `json:"id"`
+	RequestID string        `json:"request_id,omitempty"`
+	Time      time.Time     `json:"time"`
+	Duration  time.Duration `json:"duration"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Query     string        `json:"query,omitempty"`
+	Request   Message       `json:"request"`
+	Response  Message       `json:"response"`
+	Outbound  []Outbound    `json:"outbound,omitempty"`
+
+	mu sync.Mutex
+}
+
+// ─── Middleware ───────────────────────────────────────────────────────────────
+
+type ctxKey struct{}
+
+func init() {
+	// Attribute outgoing pkg/http calls to the recording in their context.
+	kashvihttp.Observe(func(ctx context.Context, method, url string, resp *kashvihttp.Response) {
+		rec, _ := ctx.Value(ctxKey{}).(*active)
+		if rec == nil {
+			return
+		}
+		msg := rec.opts.message(resp.Headers, resp.Raw, false)
+		msg.Status = resp.StatusCode
+		rec.Recording.mu.Lock()
+		rec.Outbound = append(rec.Outbound, Outbound{Method: method, URL: url, Response: msg})
+		rec.Recording.mu.Unlock()
+	})
+}
+
+// active pairs an in-progress recording with the options that produced it.
+type active struct {
+	*Recording
+	opts Options
+}
+
+// Middleware records matching requests according to opts, and outside
+// production mocks the outgoing calls of replayed requests (ReplayHeader)
+// even when opts.Enabled is false.
+func Middleware(opts Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		next = replayMocks(opts, next)
+		if !opts.Enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !opts.matches(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			reqBody, truncated := readBody(r, opts.MaxBody)
+
+			rec := &active{opts: opts, Recording: &Recording{
+				ID:        newID(start),
+				RequestID: reqid.FromCtx(r.Context()),
+				Time:      start.UTC(),
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Query:     opts.redactQuery(r.URL.RawQuery),
+			}}
+			rec.Request = opts.message(r.Header, reqBody, truncated)
+
+			rw := respwriter.Wrap(w)
+			respBody := &limitedBuffer{max: opts.MaxBody}
+			rw.Tee(respBody)
+
+			next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), ctxKey{}, rec)))
+
+			if rw.Status() < opts.MinStatus {
+				return
+			}
+			rec.Duration = time.Since(start)
+			rec.Response = opts.message(rw.Header(), respBody.Bytes(), respBody.truncated)
+			rec.Response.Status = rw.Status()
+
+			go func() {
+				if err := Save(opts.Disk, opts.Prefix, rec.Recording); err != nil {
+					logger.Warn("recorder: save failed", "id", rec.ID, "error", err)
+				}
+			}()
+		})
+	}
+}
+
+// readBody reads up to max bytes of the request body for the recording and
+// restores r.Body so the handler still sees the full stream.
+func readBody(r *http.Request, max int) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false
+	}
+	buf, _ := io.ReadAll(io.LimitReader(r.Body, int64(max)+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+	if len(buf) > max {
+		return buf[:max], true
+	}
+	return buf, false
+}
+
+// message builds a sanitized Message from headers and body.
+func (o Options) message(h http.Header, body []byte, truncated bool) Message {
+	m := Message{Headers: o.redactHeaders(h), Truncated: truncated}
+	body = o.redactBody(h.Get("Content-Type"), body)
+	if utf8.Valid(body) {
+		m.Body = string(body)
+	} else {
+		m.Body = encodeBase64(body)
+		m.Base64 = true
+	}
+	return m
+}
+
+// limitedBuffer keeps the first max bytes written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+func newID(t time.Time) string {
+	b := make([]byte, 4)
+	_, _ = cryptorand.Read(b)
+	return t.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+package recorder_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	kashvihttp "github.com/shashiranjanraj/kashvi/pkg/http"
+	"github.com/shashiranjanraj/kashvi/pkg/recorder"
+	"github.com/shashiranjanraj/kashvi/pkg/storage"
+)
+
+func TestRecordExportAndReplay(t *testing.T) {
+	t.Chdir(t.TempDir())
+	storage.Connect()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":false}`)) //nolint:errcheck
+	}))
+	defer upstream.Close()
+
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]any
+		json.NewDecoder(r.Body).Decode(&in) //nolint:errcheck
+		if in["email"] != "a@b.c" || in["password"] != "hunter2" {
+			t.Errorf("handler must see the original body, got %v", in)
+		}
+		kashvihttp.WithCtx(r.Context()).Get(upstream.URL + "/charge").Send() //nolint:errcheck
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"status":502,"message":"payment failed"}`)) //nolint:errcheck
+	})
+
+	opts := recorder.DefaultOptions()
+	opts.Enabled = true
+	h := recorder.Middleware(opts)(app)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/orders?token=abc", strings.NewReader(`{"email":"a@b.c","password":"hunter2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Recordings are written in the background.
+	var ids []string
+	for deadline := time.Now().Add(2 * time.Second); len(ids) == 0 && time.Now().Before(deadline); {
+		ids, _ = recorder.List(opts.Disk, opts.Prefix)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(ids) != 1 {
+		t.Fatalf("want 1 recording, got %v", ids)
+	}
+
+	rec, err := recorder.Load(opts.Disk, opts.Prefix, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(rec.Request.Body, "hunter2") || rec.Request.Headers["Authorization"] != "[REDACTED]" || strings.Contains(rec.Query, "abc") {
+		t.Errorf("secrets leaked into recording: %+v", rec)
+	}
+	if rec.Response.Status != http.StatusBadGateway || len(rec.Outbound) != 1 {
+		t.Fatalf("unexpected recording: status %d, outbound %d", rec.Response.Status, len(rec.Outbound))
+	}
+
+	// Export → testkit scenario with the outgoing call mocked.
+	file, err := recorder.ExportScenario(rec, "scenarios")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(file)
+	var sc map[string]any
+	if err := json.Unmarshal(raw, &sc); err != nil {
+		t.Fatal(err)
+	}
+	if sc["expectedCode"].(float64) != 502 || len(sc["netUtilMockStep"].([]any)) != 1 {
+		t.Errorf("unexpected scenario %s", raw)
+	}
+	if _, err := os.Stat(filepath.Join("scenarios", rec.ID+"_response.json")); err != nil {
+		t.Errorf("response file missing: %v", err)
+	}
+
+	// Replay against a server that now behaves.
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Kashvi-Replay") != rec.ID {
+			t.Errorf("replay header missing")
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer local.Close()
+	res, err := recorder.Replay(context.Background(), rec, local.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != http.StatusCreated || res.StatusMatch {
+		t.Errorf("unexpected replay result %+v", res)
+	}
+}
+
+func TestMiddlewareSkipsSuccessfulRequestsByDefault(t *testing.T) {
+	t.Chdir(t.TempDir())
+	storage.Connect()
+
+	opts := recorder.DefaultOptions()
+	opts.Enabled = true
+	h := recorder.Middleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok")) //nolint:errcheck
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	time.Sleep(50 * time.Millisecond)
+	if ids, _ := recorder.List(opts.Disk, opts.Prefix); len(ids) != 0 {
+		t.Fatalf("2xx responses must not be recorded with RECORDER_MIN_STATUS=500, got %v", ids)
+	}
+}
+
+func TestTruncatedJSONBodyIsNotStored(t *testing.T) {
+	t.Chdir(t.TempDir())
+	storage.Connect()
+
+	opts := recorder.DefaultOptions()
+	opts.Enabled = true
+	opts.MaxBody = 32
+	h := recorder.Middleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	body := `{"email":"a@b.c","password":"hunter2","notes":"` + strings.Repeat("x", 100) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var ids []string
+	for deadline := time.Now().Add(2 * time.Second); len(ids) == 0 && time.Now().Before(deadline); {
+		ids, _ = recorder.List(opts.Disk, opts.Prefix)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(ids) != 1 {
+		t.Fatalf("want 1 recording, got %v", ids)
+	}
+	rec, err := recorder.Load(opts.Disk, opts.Prefix, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Request.Truncated || rec.Request.Body != "[REDACTED: unparseable]" {
+		t.Fatalf("truncated body kept: truncated=%v body=%q", rec.Request.Truncated, rec.Request.Body)
+	}
+}
+
+// onlyRecording waits for the single recording the background save writes.
+func onlyRecording(t *testing.T, opts recorder.Options) *recorder.Recording {
+	t.Helper()
+	var ids []string
+	for deadline := time.Now().Add(2 * time.Second); len(ids) == 0 && time.Now().Before(deadline); {
+		ids, _ = recorder.List(opts.Disk, opts.Prefix)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(ids) != 1 {
+		t.Fatalf("want 1 recording, got %v", ids)
+	}
+	rec, err := recorder.Load(opts.Disk, opts.Prefix, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestUnparseableQueryAndMultipartFieldsAreRedacted(t *testing.T) {
+	t.Chdir(t.TempDir())
+	storage.Connect()
+
+	opts := recorder.DefaultOptions()
+	opts.Enabled = true
+	h := recorder.Middleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if b, _ := io.ReadAll(r.Body); !bytes.Contains(b, []byte("hunter2")) {
+			t.Errorf("handler must see the original body, got %q", b)
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("email", "a@b.c")      //nolint:errcheck
+	mw.WriteField("password", "hunter2") //nolint:errcheck
+	fw, _ := mw.CreateFormFile("avatar", "me.png")
+	fw.Write([]byte("png")) //nolint:errcheck
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/signup?token=abc&x=%zz", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec := onlyRecording(t, opts)
+	if rec.Query != "[REDACTED: unparseable]" {
+		t.Errorf("query = %q, want it replaced", rec.Query)
+	}
+	mr := multipart.NewReader(strings.NewReader(rec.Request.Body), mw.Boundary())
+	fields := map[string]string{}
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		b, _ := io.ReadAll(p)
+		fields[p.FormName()] = string(b)
+	}
+	if fields["password"] != "[REDACTED]" || fields["email"] != "a@b.c" || fields["avatar"] != "png" {
+		t.Errorf("recorded multipart fields = %v", fields)
+	}
+}
+
+func TestReplayMocksOutboundCalls(t *testing.T) {
+	t.Chdir(t.TempDir())
+	storage.Connect()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"charged":true}`)) //nolint:errcheck
+	}))
+	charge := upstream.URL + "/charge"
+	app := func(calls int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var resp *kashvihttp.Response
+			for range calls {
+				var err error
+				if resp, err = kashvihttp.WithCtx(r.Context()).Get(charge).Send(); err != nil {
+					http.Error(w, err.Error(), http.StatusBadGateway)
+					return
+				}
+			}
+			w.Write(resp.Raw) //nolint:errcheck
+		})
+	}
+
+	opts := recorder.DefaultOptions()
+	opts.Enabled = true
+	opts.MinStatus = 0
+	recorder.Middleware(opts)(app(1)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/pay", nil))
+	rec := onlyRecording(t, opts)
+	upstream.Close() // replays must not need the real service
+
+	opts.Enabled = false
+	local := httptest.NewServer(recorder.Middleware(opts)(app(1)))
+	defer local.Close()
+	res, err := recorder.Replay(context.Background(), rec, local.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != http.StatusOK || string(res.Body) != `{"charged":true}` {
+		t.Fatalf("replay = %d %s, want the recorded upstream response", res.Status, res.Body)
+	}
+
+	// A call the recording does not contain fails instead of going out.
+	twice := httptest.NewServer(recorder.Middleware(opts)(app(2)))
+	defer twice.Close()
+	res, err = recorder.Replay(context.Background(), rec, twice.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != http.StatusBadGateway || !strings.Contains(string(res.Body), "no recorded response") {
+		t.Fatalf("unrecorded call: %d %s", res.Status, res.Body)
+	}
+}
@@ -0,0 +1,258 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	kashvihttp "github.com/shashiranjanraj/kashvi/pkg/http"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// ReplayHeader carries the recording ID on a replayed request. Outside
+// production, Middleware answers that request's outgoing pkg/http calls
+// from the recording instead of the network.
+const ReplayHeader = "X-Kashvi-Replay"
+
+// skipReplayHeaders are not re-sent: transport-level or recomputed by net/http.
+var skipReplayHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Accept-Encoding":   true,
+	"Transfer-Encoding": true,
+	"X-Request-Id":      true,
+}
+
+// Result is the outcome of replaying a recording.
+type Result struct {
+	Status      int
+	Body        []byte
+	StatusMatch bool // same status as recorded
+	BodyMatch   bool // byte-identical body (after redaction of the recording)
+}
+
+// Replay re-sends rec's request to target (e.g. http://localhost:8080) and
+// compares the response with the recorded one. Redacted headers are dropped,
+// so authenticate the replay via extra if needed. The target mocks its
+// outgoing calls with the recorded ones when it runs Middleware outside
+// production and can load rec from the same disk.
+func Replay(ctx context.Context, rec *Recording, target string, extra http.Header) (*Result, error) {
+	u := strings.TrimRight(target, "/") + rec.Path
+	if rec.Query != "" {
+		u += "?" + rec.Query
+	}
+
+	var body io.Reader
+	if b := decodeBody(rec.Request); len(b) > 0 {
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, rec.Method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: replay %s: %w", rec.ID, err)
+	}
+	for k, v := range rec.Request.Headers {
+		if v == redacted || skipReplayHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	for k, v := range extra {
+		req.Header[k] = v
+	}
+	req.Header.Set(ReplayHeader, rec.ID)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: replay %s: %w", rec.ID, err)
+	}
+	defer resp.Body.Close()
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: replay %s: read body: %w", rec.ID, err)
+	}
+
+	return &Result{
+		Status:      resp.StatusCode,
+		Body:        got,
+		StatusMatch: resp.StatusCode == rec.Response.Status,
+		BodyMatch:   !rec.Response.Truncated && bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(decodeBody(rec.Response))),
+	}, nil
+}
+
+// ─── Outbound mocks ───────────────────────────────────────────────────────────
+
+type replayKey struct{}
+
+// mocks hands out a recording's outgoing responses, each one once, in the
+// order they were recorded.
+type mocks struct {
+	id       string
+	mu       sync.Mutex
+	outbound []Outbound
+	used     []bool
+}
+
+func (m *mocks) take(method, rawURL string) (Outbound, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, out := range m.outbound {
+		if m.used[i] || !strings.EqualFold(out.Method, method) || normalizeURL(out.URL) != rawURL {
+			continue
+		}
+		m.used[i] = true
+		return out, true
+	}
+	return Outbound{}, false
+}
+
+func normalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.String()
+}
+
+// replayStub answers outgoing calls made while serving a replayed request.
+// A call the recording does not contain fails rather than reaching the
+// real service.
+func replayStub(req *http.Request) (*kashvihttp.Response, bool, error) {
+	m, _ := req.Context().Value(replayKey{}).(*mocks)
+	if m == nil {
+		return nil, false, nil
+	}
+	out, ok := m.take(req.Method, req.URL.String())
+	if !ok {
+		return nil, true, fmt.Errorf("recorder: replay %s: no recorded response for %s %s", m.id, req.Method, req.URL)
+	}
+	h := http.Header{}
+	for k, v := range out.Response.Headers {
+		h.Set(k, v)
+	}
+	return &kashvihttp.Response{StatusCode: out.Response.Status, Headers: h, Raw: decodeBody(out.Response)}, true, nil
+}
+
+// replayMocks serves requests carrying ReplayHeader with their recording's
+// outgoing calls mocked. In production it returns next unchanged.
+func replayMocks(opts Options, next http.Handler) http.Handler {
+	if env := config.AppEnv(); env == "production" || env == "prod" {
+		return next
+	}
+	kashvihttp.SetStub(replayStub)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(ReplayHeader)
+		if id == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		m := &mocks{id: id}
+		if rec, err := Load(opts.Disk, opts.Prefix, id); err != nil {
+			// Still mocked: with nothing recorded every outgoing call fails.
+			logger.Warn("recorder: replay: recording not found, outgoing calls will fail", "id", id, "error", err)
+		} else {
+			m.outbound = rec.Outbound
+			m.used = make([]bool, len(rec.Outbound))
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), replayKey{}, m)))
+	})
+}
+
+// ─── testkit export ───────────────────────────────────────────────────────────
+
+// scenario mirrors testkit.Scenario's JSON schema (testkit is test-only code,
+// so it is not imported here).
+type scenario struct {
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	RequestMethod    string            `json:"requestMethod"`
+	RequestURL       string            `json:"requestUrl"`
+	RequestFileName  string            `json:"requestFileName,omitempty"`
+	Headers          map[string]string `json:"headers,omitempty"`
+	ResponseFileName string            `json:"responseFileName,omitempty"`
+	ExpectedCode     int               `json:"expectedCode"`
+	IsMockRequired   bool              `json:"isMockRequired"`
+	NetUtilMockStep  []mockStep        `json:"netUtilMockStep,omitempty"`
+}
+
+type mockStep struct {
+	Method     string `json:"method"`
+	IsMock     bool   `json:"isMock"`
+	MatchURL   string `json:"matchUrl"`
+	ReturnData struct {
+		StatusCode int    `json:"statusCode"`
+		Body       string `json:"body"`
+	} `json:"returnData"`
+}
+
+// ExportScenario writes rec as a testkit scenario into dir: <id>.json plus
+// request/response body files. Outgoing calls become "httprequest" mocks, so
+// the scenario runs offline. It returns the scenario file path.
+func ExportScenario(rec *Recording, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("recorder: export: %w", err)
+	}
+
+	sc := scenario{
+		Name:           "replay " + rec.ID,
+		Description:    fmt.Sprintf("Recorded %s %s at %s (request %s)", rec.Method, rec.Path, rec.Time.Format("2006-01-02 15:04:05Z07:00"), rec.RequestID),
+		RequestMethod:  rec.Method,
+		RequestURL:     rec.Path,
+		ExpectedCode:   rec.Response.Status,
+		IsMockRequired: len(rec.Outbound) > 0,
+	}
+	if rec.Query != "" {
+		sc.RequestURL += "?" + rec.Query
+	}
+	for k, v := range rec.Request.Headers {
+		if v == redacted || skipReplayHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		if sc.Headers == nil {
+			sc.Headers = map[string]string{}
+		}
+		sc.Headers[k] = v
+	}
+
+	if b := decodeBody(rec.Request); len(b) > 0 {
+		sc.RequestFileName = rec.ID + "_request.json"
+		if err := os.WriteFile(filepath.Join(dir, sc.RequestFileName), b, 0o644); err != nil {
+			return "", fmt.Errorf("recorder: export: %w", err)
+		}
+	}
+	if b := decodeBody(rec.Response); len(b) > 0 && json.Valid(b) && !rec.Response.Truncated {
+		sc.ResponseFileName = rec.ID + "_response.json"
+		if err := os.WriteFile(filepath.Join(dir, sc.ResponseFileName), b, 0o644); err != nil {
+			return "", fmt.Errorf("recorder: export: %w", err)
+		}
+	}
+
+	for _, out := range rec.Outbound {
+		var step mockStep
+		step.Method = "httprequest"
+		step.IsMock = true
+		step.MatchURL = out.URL
+		step.ReturnData.StatusCode = out.Response.Status
+		step.ReturnData.Body = base64.StdEncoding.EncodeToString(decodeBody(out.Response))
+		sc.NetUtilMockStep = append(sc.NetUtilMockStep, step)
+	}
+
+	b, err := json.MarshalIndent(sc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("recorder: export: %w", err)
+	}
+	file := filepath.Join(dir, rec.ID+".json")
+	if err := os.WriteFile(file, b, 0o644); err != nil {
+		return "", fmt.Errorf("recorder: export: %w", err)
+	}
+	return file, nil
+}
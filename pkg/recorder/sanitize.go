@@ -0,0 +1,162 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// unparseable replaces a body or query that should be redacted but cannot
+// be parsed, e.g. a body cut off at MaxBody: secrets in it cannot be
+// located, so none of it is kept.
+const unparseable = "[REDACTED: unparseable]"
+
+func (o Options) redactHeaders(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if contains(o.RedactHeaders, k) {
+			out[k] = redacted
+			continue
+		}
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+func (o Options) redactQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	q, err := url.ParseQuery(raw)
+	if err != nil {
+		return unparseable
+	}
+	changed := false
+	for k := range q {
+		if contains(o.RedactFields, k) {
+			q[k] = []string{redacted}
+			changed = true
+		}
+	}
+	if !changed {
+		return raw
+	}
+	return q.Encode()
+}
+
+// redactBody masks sensitive fields in JSON, form and multipart bodies. One
+// of those that does not parse (truncated or invalid) is replaced whole by
+// unparseable. Other content types are returned unchanged.
+func (o Options) redactBody(contentType string, body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	mt, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			return []byte(unparseable)
+		}
+		out, err := json.Marshal(o.redactValue(v))
+		if err != nil {
+			return []byte(unparseable)
+		}
+		return out
+	case mt == "application/x-www-form-urlencoded":
+		if _, err := url.ParseQuery(string(body)); err != nil {
+			return []byte(unparseable)
+		}
+		return []byte(o.redactQuery(string(body)))
+	case mt == "multipart/form-data":
+		out, err := o.redactMultipart(params["boundary"], body)
+		if err != nil {
+			return []byte(unparseable)
+		}
+		return out
+	}
+	return body
+}
+
+// redactMultipart re-encodes a multipart body with the same boundary, so
+// the recorded Content-Type still matches, and the content of sensitive
+// fields replaced.
+func (o Options) redactMultipart(boundary string, body []byte) ([]byte, error) {
+	if boundary == "" {
+		return nil, errors.New("no boundary")
+	}
+	var out bytes.Buffer
+	mw := multipart.NewWriter(&out)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return nil, err
+	}
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		w, err := mw.CreatePart(part.Header)
+		if err != nil {
+			return nil, err
+		}
+		if contains(o.RedactFields, part.FormName()) {
+			_, err = io.WriteString(w, redacted)
+		} else {
+			_, err = io.Copy(w, part)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (o Options) redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if contains(o.RedactFields, k) {
+				t[k] = redacted
+			} else {
+				t[k] = o.redactValue(val)
+			}
+		}
+	case []any:
+		for i := range t {
+			t[i] = o.redactValue(t[i])
+		}
+	}
+	return v
+}
+
+func encodeBase64(b []byte) string { return base64.StdEncoding.EncodeToString(b) }
+
+func decodeBody(m Message) []byte {
+	if !m.Base64 {
+		return []byte(m.Body)
+	}
+	b, err := base64.StdEncoding.DecodeString(m.Body)
+	if err != nil {
+		return nil
+	}
+	return b
+}
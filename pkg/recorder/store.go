@@ -0,0 +1,66 @@
+package recorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/shashiranjanraj/kashvi/pkg/storage"
+)
+
+// Save writes rec to <prefix>/<id>.json on the named storage disk.
+func Save(disk, prefix string, rec *Recording) error {
+	d, ok := storage.Lookup(disk)
+	if !ok {
+		return fmt.Errorf("recorder: storage disk %q is not configured", disk)
+	}
+	rec.mu.Lock()
+	b, err := json.MarshalIndent(rec, "", "  ")
+	rec.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("recorder: encode %s: %w", rec.ID, err)
+	}
+	if err := d.Put(path.Join(prefix, rec.ID+".json"), b); err != nil {
+		return fmt.Errorf("recorder: save %s: %w", rec.ID, err)
+	}
+	return nil
+}
+
+// Load reads the recording with the given id.
+func Load(disk, prefix, id string) (*Recording, error) {
+	d, ok := storage.Lookup(disk)
+	if !ok {
+		return nil, fmt.Errorf("recorder: storage disk %q is not configured", disk)
+	}
+	b, err := d.Get(path.Join(prefix, strings.TrimSuffix(id, ".json")+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("recorder: load %s: %w", id, err)
+	}
+	var rec Recording
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, fmt.Errorf("recorder: decode %s: %w", id, err)
+	}
+	return &rec, nil
+}
+
+// List returns the ids of all stored recordings, newest first.
+func List(disk, prefix string) ([]string, error) {
+	d, ok := storage.Lookup(disk)
+	if !ok {
+		return nil, fmt.Errorf("recorder: storage disk %q is not configured", disk)
+	}
+	files, err := d.Files(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: list: %w", err)
+	}
+	var ids []string
+	for _, f := range files {
+		if strings.HasSuffix(f, ".json") {
+			ids = append(ids, strings.TrimSuffix(path.Base(f), ".json"))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
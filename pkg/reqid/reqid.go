@@ -4,9 +4,10 @@
 // context, forwarded via the X-Request-ID header, and included in every
 // structured log line via logger.WithCtx(ctx).
 //
-// Middleware wiring in kernel/http.go:
+// The kernel installs middleware.RequestID, which also tags the request's
+// logger and forwards the ID on outgoing pkg/http calls:
 //
-//	r.Use(reqid.Middleware())
+//	r.Use(middleware.RequestID())
 //
 // Reading inside a handler or service:
 //
@@ -60,7 +61,9 @@ func FromCtx(ctx context.Context) string {
 //     tracing across microservices).
 //   - Otherwise a new cryptographically random ID is generated.
 //
-// The ID is available downstream via reqid.FromCtx(r.Context()).
+// The ID is available downstream via reqid.FromCtx(r.Context()). Apps built
+// on the kernel use middleware.RequestID instead, which also validates the
+// incoming ID and injects a tagged logger.
 func Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/shashiranjanraj/kashvi/pkg/orm"
+	"github.com/shashiranjanraj/kashvi/pkg/validate"
 )
 
 type envelope struct {
@@ -35,11 +36,12 @@ func Error(w http.ResponseWriter, status int, message string) {
 	write(w, status, envelope{Status: status, Message: message})
 }
 
-// ValidationError sends a 422 with field-level error map.
+// ValidationError sends a 422 with field-level error map; it accepts
+// validate.FieldViolations as well.
 func ValidationError(w http.ResponseWriter, errs map[string]string) {
 	write(w, http.StatusUnprocessableEntity, envelope{
 		Status:  http.StatusUnprocessableEntity,
-		Message: "Validation failed",
+		Message: validate.Message,
 		Errors:  errs,
 	})
 }
@@ -0,0 +1,181 @@
+// Package respwriter provides the single http.ResponseWriter wrapper shared
+// by ctx, metrics and the logging middleware.
+//
+// Every layer that needs to know what was written (status code, body size)
+// calls Wrap instead of defining its own wrapper. Wrap is idempotent — an
+// already-wrapped writer is returned as-is — so stacking middleware never
+// nests wrappers, and the optional interfaces (http.Flusher, http.Hijacker,
+// http.Pusher, io.ReaderFrom) are forwarded to the underlying writer so SSE
+// streams and WebSocket upgrades keep working under any middleware order.
+//
+//	rw := respwriter.Wrap(w)
+//	next.ServeHTTP(rw, r)
+//	log.Info("request", "status", rw.Status(), "bytes", rw.Size())
+package respwriter
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// Writer wraps an http.ResponseWriter and records the status code and the
+// number of body bytes written.
+type Writer struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	rawSize     int64 // body size before compression; -1 if not compressed
+	wroteHeader bool
+	tees        []io.Writer
+	onHeader    []func(status int)
+}
+
+// Wrap returns w as a *Writer, wrapping it only if it is not one already.
+func Wrap(w http.ResponseWriter) *Writer {
+	if rw, ok := w.(*Writer); ok {
+		return rw
+	}
+	return &Writer{ResponseWriter: w, rawSize: -1}
+}
+
+// Reset points the Writer at w and clears its recorded state, so callers
+// that pool Writers can reuse them across requests.
+func (w *Writer) Reset(rw http.ResponseWriter) {
+	w.ResponseWriter = rw
+	w.status = 0
+	w.size = 0
+	w.rawSize = -1
+	w.wroteHeader = false
+	w.tees = nil
+	w.onHeader = nil
+}
+
+// Tee copies every body byte written from now on to dst as well. Errors
+// from dst are ignored — it must never break the real response.
+func (w *Writer) Tee(dst io.Writer) {
+	w.tees = append(w.tees, dst)
+}
+
+// OnHeader runs fn with the final status code just before the response
+// headers are sent, so it can still change them. Hooks run in the order
+// they were added.
+func (w *Writer) OnHeader(fn func(status int)) {
+	w.onHeader = append(w.onHeader, fn)
+}
+
+// Status returns the status code sent to the client. Before anything has
+// been written it returns 200, which is what net/http sends by default.
+func (w *Writer) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Size returns the number of body bytes written so far.
+func (w *Writer) Size() int64 { return w.size }
+
+// UncompressedSize returns the body size before a compression layer below
+// this Writer encoded it, or Size if the response was not compressed.
+func (w *Writer) UncompressedSize() int64 {
+	if w.rawSize < 0 {
+		return w.size
+	}
+	return w.rawSize
+}
+
+// SetUncompressedSize is called by compression middleware with the number
+// of bytes the handler wrote, before encoding.
+func (w *Writer) SetUncompressedSize(n int64) { w.rawSize = n }
+
+// Written reports whether the response headers have been sent.
+func (w *Writer) Written() bool { return w.wroteHeader }
+
+// WriteHeader records code and forwards it. Informational 1xx responses
+// (other than 101) are forwarded without marking the response as written.
+func (w *Writer) WriteHeader(code int) {
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.wroteHeader {
+		return
+	}
+	w.status = code
+	w.wroteHeader = true
+	for _, fn := range w.onHeader {
+		fn(code)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write sends b, implicitly writing a 200 header first if needed.
+func (w *Writer) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	for _, t := range w.tees {
+		t.Write(b[:n]) //nolint:errcheck
+	}
+	return n, err
+}
+
+// ReadFrom lets io.Copy (and http.ServeFile) use the underlying writer's
+// fast path, e.g. sendfile, while still counting bytes.
+func (w *Writer) ReadFrom(r io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if len(w.tees) > 0 {
+		// Go through Write so tees see the bytes. writerFunc has no
+		// ReadFrom, so io.Copy cannot recurse back here.
+		return io.Copy(writerFunc(w.Write), r)
+	}
+	n, err := io.Copy(w.ResponseWriter, r)
+	w.size += n
+	return n, err
+}
+
+// Flush forwards to the underlying http.Flusher, if any.
+func (w *Writer) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Hijack forwards to the underlying http.Hijacker. A successful hijack is
+// recorded as a 101 Switching Protocols response.
+func (w *Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, buf, err := h.Hijack()
+	if err == nil && !w.wroteHeader {
+		w.status = http.StatusSwitchingProtocols
+		w.wroteHeader = true
+	}
+	return conn, buf, err
+}
+
+// Push forwards to the underlying http.Pusher (HTTP/2 server push).
+func (w *Writer) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *Writer) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(b []byte) (int, error) { return f(b) }
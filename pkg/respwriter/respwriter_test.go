@@ -0,0 +1,98 @@
+package respwriter_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/respwriter"
+)
+
+func TestStatusAndSize(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := respwriter.Wrap(rec)
+
+	if rw.Written() {
+		t.Fatal("expected fresh writer to be unwritten")
+	}
+	if rw.Status() != http.StatusOK {
+		t.Errorf("expected default status 200, got %d", rw.Status())
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	rw.WriteHeader(http.StatusInternalServerError) // superfluous, ignored
+	rw.Write([]byte("hello"))                      //nolint:errcheck
+
+	if rw.Status() != http.StatusCreated {
+		t.Errorf("expected 201, got %d", rw.Status())
+	}
+	if rw.Size() != 5 {
+		t.Errorf("expected 5 bytes, got %d", rw.Size())
+	}
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected recorder 201, got %d", rec.Code)
+	}
+}
+
+func TestWrapIsIdempotent(t *testing.T) {
+	rw := respwriter.Wrap(httptest.NewRecorder())
+	if respwriter.Wrap(rw) != rw {
+		t.Error("expected Wrap to return an existing *Writer unchanged")
+	}
+}
+
+func TestFlushPassthrough(t *testing.T) {
+	rec := httptest.NewRecorder()
+	var w http.ResponseWriter = respwriter.Wrap(rec)
+
+	f, ok := w.(http.Flusher)
+	if !ok {
+		t.Fatal("expected wrapper to implement http.Flusher")
+	}
+	f.Flush()
+	if !rec.Flushed {
+		t.Error("expected Flush to reach the underlying writer")
+	}
+}
+
+func TestHijackUnsupported(t *testing.T) {
+	rw := respwriter.Wrap(httptest.NewRecorder())
+	if _, _, err := rw.Hijack(); err != http.ErrNotSupported {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestTeeCopiesBody(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := respwriter.Wrap(rec)
+
+	var tee bytes.Buffer
+	w.Tee(&tee)
+	w.Write([]byte("hello "))              //nolint:errcheck
+	w.ReadFrom(strings.NewReader("world")) //nolint:errcheck
+
+	if tee.String() != "hello world" || rec.Body.String() != "hello world" {
+		t.Fatalf("tee = %q, body = %q", tee.String(), rec.Body.String())
+	}
+	if w.Size() != 11 {
+		t.Fatalf("Size = %d, want 11", w.Size())
+	}
+}
+
+func TestOnHeaderRunsBeforeHeadersAreSent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := respwriter.Wrap(rec)
+	var seen int
+	w.OnHeader(func(status int) {
+		seen = status
+		w.Header().Set("X-Status-Seen", "yes")
+	})
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("ok")) //nolint:errcheck
+
+	if seen != http.StatusAccepted || rec.Header().Get("X-Status-Seen") != "yes" {
+		t.Errorf("seen = %d, headers = %v", seen, rec.Header())
+	}
+}
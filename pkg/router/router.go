@@ -19,10 +19,22 @@ type RouteInfo struct {
 }
 
 type Router struct {
-	mux    chi.Router
-	routes map[string]string // name → path (legacy, for URL())
-	infos  []RouteInfo       // ordered list for route:list
-	mu     sync.RWMutex
+	mux       chi.Router
+	routes    map[string]string // name → path (legacy, for URL())
+	infos     []RouteInfo       // ordered list for route:list
+	preflight map[string]bool   // paths with a route-level PreflightHandler
+	mu        sync.RWMutex
+}
+
+// PreflightHandler is implemented by middleware handlers that answer CORS
+// preflight requests themselves (middleware.CORS). When a route's
+// middleware includes one, the router also registers OPTIONS on the route's
+// path, running only those handlers, so browsers can preflight the route
+// without passing its other middleware (auth, CSRF, …). The first route
+// registered on a path decides its preflight.
+type PreflightHandler interface {
+	http.Handler
+	AnswersPreflight()
 }
 
 type Group struct {
@@ -33,8 +45,9 @@ type Group struct {
 
 func New() *Router {
 	return &Router{
-		mux:    chi.NewRouter(),
-		routes: make(map[string]string),
+		mux:       chi.NewRouter(),
+		routes:    make(map[string]string),
+		preflight: make(map[string]bool),
 	}
 }
 
@@ -126,6 +139,7 @@ func (r *Router) mount(method, path, name string, handler http.HandlerFunc, midd
 	fullPath := normalizePath(path)
 	h := chain(handler, middlewares...)
 	r.mux.Method(method, fullPath, h)
+	r.mountPreflight(fullPath, middlewares)
 
 	if name == "" {
 		return
@@ -174,6 +188,7 @@ func (g *Group) mount(method, path, name string, handler http.HandlerFunc, middl
 	h := chain(handler, combined...)
 
 	g.router.mux.Method(method, fullPath, h)
+	g.router.mountPreflight(fullPath, combined)
 
 	if name == "" {
 		return
@@ -185,6 +200,50 @@ func (g *Group) mount(method, path, name string, handler http.HandlerFunc, middl
 	g.router.infos = append(g.router.infos, RouteInfo{Method: method, Path: fullPath, Name: name})
 }
 
+// mountPreflight registers OPTIONS on path when middlewares include a
+// PreflightHandler, chaining just those handlers.
+func (r *Router) mountPreflight(path string, middlewares []Middleware) {
+	var cors []Middleware
+	for _, mw := range middlewares {
+		if _, ok := mw(http.NotFoundHandler()).(PreflightHandler); ok {
+			cors = append(cors, mw)
+		}
+	}
+	if len(cors) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.preflight[path] {
+		return
+	}
+	r.preflight[path] = true
+	r.mux.Method(http.MethodOptions, path, chain(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), cors...))
+}
+
+// HasRoutePreflight reports whether req is an OPTIONS request for a path
+// whose route answers preflights itself (see PreflightHandler). A global
+// CORS middleware passes such requests through:
+//
+//	opts := middleware.DefaultCORSOptions()
+//	opts.Skip = r.HasRoutePreflight
+//	r.Use(middleware.CORS(opts))
+func (r *Router) HasRoutePreflight(req *http.Request) bool {
+	if req.Method != http.MethodOptions {
+		return false
+	}
+	rctx := chi.NewRouteContext()
+	if !r.mux.Match(rctx, http.MethodOptions, req.URL.Path) {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.preflight[rctx.RoutePattern()]
+}
+
 func chain(handler http.Handler, middlewares ...Middleware) http.Handler {
 	if len(middlewares) == 0 {
 		return handler
@@ -0,0 +1,127 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// ------------------- Wall-clock constraints -------------------
+
+// At anchors a daily or longer interval to a wall-clock time ("15:04") in
+// the task's timezone, instead of counting from the last run:
+//
+//	schedule.Daily().At("03:00").Run(backupDB)
+//	schedule.Weekly().At("08:30").Mondays().Run(sendReport)
+//
+// Weekly() runs on Sundays unless a day constraint is given; Every(n).Days()
+// runs every n days counted from the Unix epoch. An invalid time or a
+// sub-daily interval is logged and ignored.
+func (s *Schedule) At(hhmm string) *Schedule {
+	m, err := parseClock(hhmm)
+	if err != nil {
+		logger.Error("schedule: invalid At time", "at", hhmm, "error", err)
+		return s
+	}
+	if s.e.cronExpr != "" || s.e.interval < 24*time.Hour {
+		logger.Error("schedule: At needs Daily, Weekly or Every(n).Days", "at", hhmm)
+		return s
+	}
+	s.e.at, s.e.anchored = m, true
+	return s
+}
+
+// Days limits the task to the given weekdays.
+func (s *Schedule) Days(days ...time.Weekday) *Schedule {
+	for _, d := range days {
+		s.e.days |= 1 << uint(d)
+	}
+	return s
+}
+
+// Weekdays limits the task to Monday through Friday.
+func (s *Schedule) Weekdays() *Schedule {
+	return s.Days(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)
+}
+
+// Weekends limits the task to Saturday and Sunday.
+func (s *Schedule) Weekends() *Schedule { return s.Days(time.Saturday, time.Sunday) }
+
+// Mondays … Sundays limit the task to one weekday; chain several to allow
+// more.
+func (s *Schedule) Mondays() *Schedule    { return s.Days(time.Monday) }
+func (s *Schedule) Tuesdays() *Schedule   { return s.Days(time.Tuesday) }
+func (s *Schedule) Wednesdays() *Schedule { return s.Days(time.Wednesday) }
+func (s *Schedule) Thursdays() *Schedule  { return s.Days(time.Thursday) }
+func (s *Schedule) Fridays() *Schedule    { return s.Days(time.Friday) }
+func (s *Schedule) Saturdays() *Schedule  { return s.Days(time.Saturday) }
+func (s *Schedule) Sundays() *Schedule    { return s.Days(time.Sunday) }
+
+// Between limits the task to a daily time window, inclusive of both ends.
+// A window that wraps midnight ("22:00", "06:00") is allowed.
+//
+//	schedule.Every(5).Minutes().Between("09:00", "17:00").Weekdays().Run(syncCRM)
+func (s *Schedule) Between(start, end string) *Schedule {
+	from, err1 := parseClock(start)
+	to, err2 := parseClock(end)
+	if err1 != nil || err2 != nil {
+		logger.Error("schedule: invalid Between window", "start", start, "end", end)
+		return s
+	}
+	s.e.window = &[2]int{from, to}
+	return s
+}
+
+// parseClock turns "15:04" into minutes since midnight.
+func parseClock(hhmm string) (int, error) {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return 0, fmt.Errorf("want HH:MM: %w", err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// calendar reports whether e fires on matching wall-clock minutes (cron or
+// At) rather than after an elapsed interval.
+func (e *entry) calendar() bool { return e.cronExpr != "" || e.anchored }
+
+// matchMinute reports whether a calendar entry fires during t's minute.
+func (e *entry) matchMinute(t time.Time) bool {
+	t = e.local(t)
+	if !e.allowed(t) {
+		return false
+	}
+	if e.cronExpr != "" {
+		return e.cron != nil && e.cron.matchMinute(t)
+	}
+	if t.Hour()*60+t.Minute() != e.at {
+		return false
+	}
+	days := int64(e.interval / (24 * time.Hour))
+	switch {
+	case e.days != 0 || days <= 1:
+		return true // Days already checked by allowed
+	case days == 7:
+		return t.Weekday() == time.Sunday
+	default:
+		_, offset := t.Zone()
+		return ((t.Unix()+int64(offset))/86400)%days == 0
+	}
+}
+
+// allowed applies the day and window constraints to t, in e's timezone.
+func (e *entry) allowed(t time.Time) bool {
+	t = e.local(t)
+	if e.days != 0 && e.days&(1<<uint(t.Weekday())) == 0 {
+		return false
+	}
+	if w := e.window; w != nil {
+		m := t.Hour()*60 + t.Minute()
+		if w[0] <= w[1] {
+			return m >= w[0] && m <= w[1]
+		}
+		return m >= w[0] || m <= w[1]
+	}
+	return true
+}
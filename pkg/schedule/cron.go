@@ -0,0 +1,167 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ------------------- Cron parser -------------------
+// Supports standard 5-field cron (minute hour dom month dow) and 6-field
+// cron with a leading seconds field. Each field is a comma list of:
+// * | ? | N | A-B | */S | A-B/S | N/S, where months and weekdays also
+// accept names (JAN-DEC, SUN-SAT) and weekday 7 is Sunday. When both
+// day-of-month and day-of-week are restricted, either may match, as in
+// crontab. The macros @yearly, @monthly, @weekly, @daily and @hourly are
+// accepted too.
+
+type cronSpec struct {
+	second, minute, hour, dom, month, dow uint64 // bit n set = value n matches
+	seconds                               bool   // 6-field expression
+	domAny, dowAny                        bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}
+	dayNames = map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}
+)
+
+// parseCron parses a cron expression; see the section comment for syntax.
+func parseCron(expr string) (*cronSpec, error) {
+	if m, ok := cronMacros[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = m
+	}
+	fields := strings.Fields(expr)
+	spec := &cronSpec{}
+	switch len(fields) {
+	case 5:
+		spec.second = 1 // second 0
+	case 6:
+		spec.seconds = true
+		var err error
+		if spec.second, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+			return nil, fmt.Errorf("schedule: cron %q: second: %w", expr, err)
+		}
+		fields = fields[1:]
+	default:
+		return nil, fmt.Errorf("schedule: cron %q: want 5 or 6 fields, got %d", expr, len(fields))
+	}
+
+	parts := []struct {
+		name     string
+		dst      *uint64
+		min, max int
+		names    map[string]int
+	}{
+		{"minute", &spec.minute, 0, 59, nil},
+		{"hour", &spec.hour, 0, 23, nil},
+		{"day of month", &spec.dom, 1, 31, nil},
+		{"month", &spec.month, 1, 12, monthNames},
+		{"day of week", &spec.dow, 0, 7, dayNames},
+	}
+	for i, p := range parts {
+		bits, err := parseCronField(fields[i], p.min, p.max, p.names)
+		if err != nil {
+			return nil, fmt.Errorf("schedule: cron %q: %s: %w", expr, p.name, err)
+		}
+		*p.dst = bits
+	}
+	if spec.dow&(1<<7) != 0 {
+		spec.dow |= 1 // 7 is Sunday too
+	}
+	spec.domAny = fields[2] == "*" || fields[2] == "?"
+	spec.dowAny = fields[4] == "*" || fields[4] == "?"
+	return spec, nil
+}
+
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step %q", stepStr)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = cronValue(a, names); err != nil {
+				return 0, err
+			}
+			if hi, err = cronValue(b, names); err != nil {
+				return 0, err
+			}
+		default:
+			n, err := cronValue(rng, names)
+			if err != nil {
+				return 0, err
+			}
+			lo = n
+			if !hasStep {
+				hi = n // "N/S" runs from N to max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(s string, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToUpper(s)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", s)
+	}
+	return n, nil
+}
+
+// matchMinute reports whether the spec fires during t's minute.
+func (c *cronSpec) matchMinute(t time.Time) bool {
+	if c.minute&(1<<uint(t.Minute())) == 0 ||
+		c.hour&(1<<uint(t.Hour())) == 0 ||
+		c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// match reports whether the spec fires at t, to the second.
+func (c *cronSpec) match(t time.Time) bool {
+	return c.second&(1<<uint(t.Second())) != 0 && c.matchMinute(t)
+}
@@ -7,18 +7,31 @@
 //	schedule.Daily().At("03:00").Run(backupDB)
 //	schedule.Cron("0 * * * *").Run(myTask)
 //
+//	// Dispatch a queue job instead of running inline, so heavy work runs on
+//	// worker capacity with retries:
+//	schedule.Job(jobs.ReportJob{}, "0 6 * * *")
+//	schedule.Hourly().Job(jobs.SyncJob{}, queue.OnQueue("low"))
+//
 //	// Start the scheduler in the background (call once at boot):
 //	schedule.Start(ctx)
+//
+//	// Or run it in the foreground of a dedicated process (kashvi schedule:work):
+//	schedule.Work(ctx)
+//
+//	// Or evaluate due tasks once and exit, from an external cron or a
+//	// Kubernetes CronJob firing every minute (kashvi schedule:run):
+//	schedule.RunDue(time.Now())
 package schedule
 
 import (
 	"context"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/shashiranjanraj/kashvi/pkg/health"
 	"github.com/shashiranjanraj/kashvi/pkg/logger"
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
 )
 
 // Task is the function signature for a scheduled task.
@@ -28,13 +41,21 @@ type Task func()
 type entry struct {
 	id         string
 	interval   time.Duration
-	cronExpr   string // "" unless using Cron()
+	cronExpr   string    // "" unless using Cron()
+	cron       *cronSpec // parsed cronExpr; nil if invalid
+	loc        *time.Location
+	at         int     // minutes since midnight; see At
+	anchored   bool    // At was set
+	days       uint64  // bit n = time.Weekday(n) allowed; 0 = any day
+	window     *[2]int // Between, in minutes since midnight
 	task       Task
 	lastRun    time.Time
 	running    bool // overlap guard
 	noOverlap  bool
 	beforeHook Task
 	afterHook  Task
+	catchUp    bool // RunOnMissed
+	loaded     bool // persisted lastRun restored
 	mu         sync.Mutex
 }
 
@@ -65,10 +86,21 @@ func Daily() *Schedule { return Every(24).Hours() }
 // Weekly schedules the task to run every 7 days.
 func Weekly() *Schedule { return Every(7).Days() }
 
-// Cron schedules using a 5-field cron expression (min hour dom mon dow).
-// Full cron parsing is done inline to keep dependencies at zero.
+// Cron schedules using a cron expression: 5 fields (min hour dom mon dow)
+// or 6 with a leading seconds field, with lists, ranges, steps and names:
+//
+//	schedule.Cron("0 9 * * MON-FRI")
+//	schedule.Cron("*/10 * * * * *") // every 10 seconds
+//
+// Parsing is done inline (see cron.go) to keep dependencies at zero. An
+// invalid expression is logged and never fires.
 func Cron(expr string) *Schedule {
 	e := &entry{cronExpr: expr, noOverlap: false}
+	spec, err := parseCron(expr)
+	if err != nil {
+		logger.Error("schedule: invalid cron expression", "expr", expr, "error", err)
+	}
+	e.cron = spec
 	return &Schedule{e: e}
 }
 
@@ -97,6 +129,28 @@ func (s *Schedule) WithoutOverlapping() *Schedule {
 	return s
 }
 
+// RunOnMissed runs the task once on startup if it was due while the
+// process was down. It needs a Store (see UseDB); without it, missed runs
+// are skipped and the task keeps its cadence.
+func (s *Schedule) RunOnMissed() *Schedule {
+	s.e.catchUp = true
+	return s
+}
+
+// Timezone evaluates the schedule in the named IANA zone instead of the
+// process's local time, so Cron("0 9 * * *").Timezone("Asia/Kolkata") fires
+// at 09:00 IST on any server. Interval tasks run by RunDue are aligned to
+// the zone's midnight. An unknown zone is logged and ignored.
+func (s *Schedule) Timezone(name string) *Schedule {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logger.Error("schedule: unknown timezone", "timezone", name, "error", err)
+		return s
+	}
+	s.e.loc = loc
+	return s
+}
+
 // Before registers a hook that fires before the task.
 func (s *Schedule) Before(fn Task) *Schedule {
 	s.e.beforeHook = fn
@@ -109,7 +163,8 @@ func (s *Schedule) After(fn Task) *Schedule {
 	return s
 }
 
-// Name gives the entry a human-readable identifier for logging.
+// Name gives the entry a stable identifier for logging and for its
+// persisted last run (see Store).
 func (s *Schedule) Name(id string) *Schedule {
 	s.e.id = id
 	return s
@@ -127,33 +182,174 @@ func (s *Schedule) Run(fn Task) {
 	regMu.Unlock()
 }
 
+// Job registers a queue job dispatched on each run instead of a function
+// run inline. The scheduler only pushes the job; workers handle it with the
+// usual retries, so register the job type with queue.Register. The entry is
+// named after the job type unless Name is set.
+func (s *Schedule) Job(job queue.Job, opts ...queue.DispatchOption) {
+	if s.e.id == "" {
+		s.e.id = fmt.Sprintf("job:%T", job)
+	}
+	s.Run(func() {
+		if err := queue.Dispatch(job, opts...); err != nil {
+			logger.Error("schedule: dispatch failed", "id", s.e.id, "error", err)
+		}
+	})
+}
+
+// Job dispatches job on the 5-field cron expr. It returns the registered
+// entry so it can still be named or guarded:
+//
+//	schedule.Job(jobs.ReportJob{}, "0 6 * * *").Name("daily-report")
+func Job(job queue.Job, expr string, opts ...queue.DispatchOption) *Schedule {
+	s := Cron(expr)
+	s.Job(job, opts...)
+	return s
+}
+
 // ------------------- Scheduler loop -------------------
 
+// inflight tracks running tasks so Work and RunDue can wait for them.
+var inflight sync.WaitGroup
+
 // Start begins the scheduler loop in the background.
 // It ticks every second and dispatches due tasks.
 // Call before any tasks are registered to ensure none are missed.
 func Start(ctx context.Context) {
-	go run(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	loopsMu.Lock()
+	loops = append(loops, loop{cancel: cancel, done: done})
+	loopsMu.Unlock()
+
+	go func() {
+		defer close(done)
+		run(ctx)
+	}()
+	logger.Info("schedule: scheduler started")
+}
+
+// loop is a scheduler started with Start.
+type loop struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	loopsMu sync.Mutex
+	loops   []loop
+)
+
+// Stop halts every scheduler started with Start and waits for running tasks
+// to finish, or until ctx expires.
+func Stop(ctx context.Context) error {
+	loopsMu.Lock()
+	current := loops
+	loops = nil
+	loopsMu.Unlock()
+
+	for _, l := range current {
+		l.cancel()
+	}
+	done := make(chan struct{})
+	go func() {
+		for _, l := range current {
+			<-l.done
+		}
+		inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Work runs the scheduler loop in the foreground until ctx is cancelled, then
+// waits for running tasks to finish.
+func Work(ctx context.Context) {
 	logger.Info("schedule: scheduler started")
+	run(ctx)
+	inflight.Wait()
+}
+
+// RunDue runs every task due at now once, waits for them to finish and
+// returns how many ran. It is meant to be invoked once a minute by an
+// external scheduler, so interval tasks are due when now (to the minute) is a
+// multiple of their interval since the Unix epoch — Hourly at :00, Daily at
+// 00:00 UTC — and sub-minute intervals run on every call.
+func RunDue(now time.Time) int {
+	regMu.Lock()
+	current := make([]*entry, len(entries))
+	copy(current, entries)
+	regMu.Unlock()
+
+	ran := 0
+	for _, e := range current {
+		if missed := e.restore(now); missed || dueAt(e, now) {
+			dispatch(e)
+			ran++
+		}
+	}
+	inflight.Wait()
+	return ran
+}
+
+func dueAt(e *entry, now time.Time) bool {
+	if e.calendar() {
+		return e.matchMinute(now)
+	}
+	if !e.allowed(now) {
+		return false
+	}
+	step := int64(e.interval / time.Minute)
+	if step <= 1 {
+		return true
+	}
+	offset := 0
+	if e.loc != nil {
+		_, offset = now.In(e.loc).Zone()
+	}
+	return ((now.Unix()+int64(offset))/60)%step == 0
+}
+
+// local converts t to the entry's timezone, if it has one.
+func (e *entry) local(t time.Time) time.Time {
+	if e.loc != nil {
+		return t.In(e.loc)
+	}
+	return t
 }
 
+// StaleAfter is how long the loop may go without ticking before the
+// "scheduler" readiness check fails — e.g. when restoring state from a slow
+// store blocks it.
+var StaleAfter = 10 * time.Second
+
 func run(ctx context.Context) {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
+	beat := health.NewHeartbeat(StaleAfter)
+	health.Register("scheduler", beat.Check)
+	defer health.Unregister("scheduler")
+
 	for {
 		select {
 		case <-ctx.Done():
 			logger.Info("schedule: scheduler stopped")
 			return
 		case now := <-ticker.C:
+			beat.Beat()
 			regMu.Lock()
 			current := make([]*entry, len(entries))
 			copy(current, entries)
 			regMu.Unlock()
 
 			for _, e := range current {
-				if isDue(e, now) {
+				if e.restore(now) || isDue(e, now) {
 					dispatch(e)
 				}
 			}
@@ -162,13 +358,35 @@ func run(ctx context.Context) {
 }
 
 func isDue(e *entry, now time.Time) bool {
-	if e.cronExpr != "" {
-		return matchCron(e.cronExpr, now)
+	if e.cron != nil && e.cron.seconds {
+		return e.allowed(now) && e.cron.match(e.local(now)) && !sameSecond(e.lastRunAt(), now)
+	}
+	if e.calendar() {
+		// The loop ticks every second: fire once per matching minute.
+		return e.matchMinute(now) && !sameMinute(e.lastRunAt(), now)
 	}
-	if e.lastRun.IsZero() {
+	if !e.allowed(now) {
+		return false
+	}
+	last := e.lastRunAt()
+	if last.IsZero() {
 		return true // first run
 	}
-	return now.Sub(e.lastRun) >= e.interval
+	return now.Sub(last) >= e.interval
+}
+
+func sameMinute(a, b time.Time) bool {
+	return !a.IsZero() && a.Truncate(time.Minute).Equal(b.Truncate(time.Minute))
+}
+
+func sameSecond(a, b time.Time) bool {
+	return !a.IsZero() && a.Truncate(time.Second).Equal(b.Truncate(time.Second))
+}
+
+func (e *entry) lastRunAt() time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastRun
 }
 
 func dispatch(e *entry) {
@@ -180,9 +398,13 @@ func dispatch(e *entry) {
 	}
 	e.running = true
 	e.lastRun = time.Now()
+	last := e.lastRun
 	e.mu.Unlock()
+	persist(e, last)
 
+	inflight.Add(1)
 	go func() {
+		defer inflight.Done()
 		defer func() {
 			e.mu.Lock()
 			e.running = false
@@ -203,55 +425,6 @@ func dispatch(e *entry) {
 	}()
 }
 
-// ------------------- Minimal cron parser -------------------
-// Supports 5-field cron: minute hour dom month dow
-// Each field: * | number | */step | number-number
-
-func matchCron(expr string, t time.Time) bool {
-	fields := strings.Fields(expr)
-	if len(fields) != 5 {
-		return false
-	}
-	checks := []struct {
-		field string
-		val   int
-	}{
-		{fields[0], t.Minute()},
-		{fields[1], t.Hour()},
-		{fields[2], t.Day()},
-		{fields[3], int(t.Month())},
-		{fields[4], int(t.Weekday())},
-	}
-	for _, c := range checks {
-		if !matchField(c.field, c.val) {
-			return false
-		}
-	}
-	return true
-}
-
-func matchField(field string, val int) bool {
-	if field == "*" {
-		return true
-	}
-	// */step
-	if strings.HasPrefix(field, "*/") {
-		var step int
-		fmt.Sscanf(field[2:], "%d", &step)
-		return step > 0 && val%step == 0
-	}
-	// range a-b
-	if strings.Contains(field, "-") {
-		var lo, hi int
-		fmt.Sscanf(field, "%d-%d", &lo, &hi)
-		return val >= lo && val <= hi
-	}
-	// exact
-	var n int
-	fmt.Sscanf(field, "%d", &n)
-	return n == val
-}
-
 // List returns all currently registered scheduled entries (for CLI display).
 func List() []string {
 	regMu.Lock()
@@ -262,6 +435,12 @@ func List() []string {
 		if freq == "" {
 			freq = e.interval.String()
 		}
+		if e.anchored {
+			freq += fmt.Sprintf(" at %02d:%02d", e.at/60, e.at%60)
+		}
+		if e.loc != nil {
+			freq += " " + e.loc.String()
+		}
 		out = append(out, fmt.Sprintf("%s  [%s]", e.id, freq))
 	}
 	return out
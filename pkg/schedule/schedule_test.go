@@ -0,0 +1,199 @@
+package schedule_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/health"
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
+	"github.com/shashiranjanraj/kashvi/pkg/schedule"
+)
+
+func TestRunDue(t *testing.T) {
+	var every5, hourly, cron atomic.Int32
+	schedule.Every(5).Minutes().Name("every5").Run(func() { every5.Add(1) })
+	schedule.Hourly().Name("hourly").Run(func() { hourly.Add(1) })
+	schedule.Cron("30 2 * * *").Name("cron").Run(func() { cron.Add(1) })
+
+	at := func(hhmm string) time.Time {
+		ts, err := time.Parse("2006-01-02 15:04", "2024-06-03 "+hhmm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+
+	if n := schedule.RunDue(at("02:30")); n != 2 || cron.Load() != 1 || every5.Load() != 1 {
+		t.Fatalf("02:30: ran %d, cron=%d every5=%d", n, cron.Load(), every5.Load())
+	}
+	if n := schedule.RunDue(at("02:32")); n != 0 {
+		t.Fatalf("02:32: ran %d, want 0", n)
+	}
+	if n := schedule.RunDue(at("03:00")); n != 2 || hourly.Load() != 1 || every5.Load() != 2 {
+		t.Fatalf("03:00: ran %d, hourly=%d every5=%d", n, hourly.Load(), every5.Load())
+	}
+}
+
+func TestStop_WaitsForRunningTasks(t *testing.T) {
+	var finished atomic.Bool
+	schedule.Every(1).Seconds().Name("slow").Run(func() {
+		time.Sleep(300 * time.Millisecond)
+		finished.Store(true)
+	})
+
+	schedule.Start(context.Background())
+	time.Sleep(1100 * time.Millisecond) // first tick dispatches the task
+	if c, ok := health.Ready(context.Background()).Checks["scheduler"]; !ok || c.Status != health.StatusOK {
+		t.Fatalf("scheduler readiness = %+v (registered %v)", c, ok)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := schedule.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if !finished.Load() {
+		t.Fatal("Stop returned before the running task finished")
+	}
+}
+
+type reportJob struct{ Kind string }
+
+var reportRuns atomic.Int32
+
+func (j reportJob) Handle() error {
+	if j.Kind == "daily" {
+		reportRuns.Add(1)
+	}
+	return nil
+}
+
+func TestJob_DispatchesToQueue(t *testing.T) {
+	queue.Register("schedule_test.reportJob", func() queue.Job { return &reportJob{} })
+	schedule.Job(reportJob{Kind: "daily"}, "0 6 * * *", queue.OnQueue("reports"))
+
+	schedule.RunDue(time.Date(2024, 6, 3, 6, 0, 0, 0, time.Local))
+	if reportRuns.Load() != 0 {
+		t.Fatal("job ran inline in the scheduler")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	queue.Work(ctx, queue.WorkerOptions{Queues: []string{"reports"}, Concurrency: 1, MaxJobs: 1})
+	if reportRuns.Load() != 1 {
+		t.Fatal("scheduled job was not dispatched to the reports queue")
+	}
+}
+
+func TestRunOnMissed_CatchesUpFromStore(t *testing.T) {
+	db, err := database.OpenMemory("schedule_store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schedule.UseDB(db); err != nil {
+		t.Fatal(err)
+	}
+	defer schedule.SetStore(nil)
+
+	store, _ := schedule.NewDatabaseStore(db)
+	now := time.Date(2024, 6, 3, 2, 17, 0, 0, time.Local)
+	for _, id := range []string{"catch-up", "skip-missed", "cron-catch-up"} {
+		if err := store.Save(id, now.Add(-3*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var caught, skipped, cron atomic.Int32
+	schedule.Hourly().Name("catch-up").RunOnMissed().Run(func() { caught.Add(1) })
+	schedule.Hourly().Name("skip-missed").Run(func() { skipped.Add(1) })
+	schedule.Cron("0 1 * * *").Name("cron-catch-up").RunOnMissed().Run(func() { cron.Add(1) })
+
+	schedule.RunDue(now)
+	if caught.Load() != 1 || cron.Load() != 1 {
+		t.Fatalf("missed runs not caught up: interval=%d cron=%d", caught.Load(), cron.Load())
+	}
+	if skipped.Load() != 0 {
+		t.Fatal("missed run without RunOnMissed should be skipped")
+	}
+	if last, ok, _ := store.Load("catch-up"); !ok || !last.After(now) {
+		t.Fatalf("last run not persisted: %v %v", last, ok)
+	}
+
+	schedule.RunDue(now.Add(time.Minute))
+	if caught.Load() != 1 || cron.Load() != 1 {
+		t.Fatal("caught-up tasks ran again")
+	}
+}
+
+func TestCron_FullSyntax(t *testing.T) {
+	var list, names, tz, invalid atomic.Int32
+	schedule.Cron("0,15,30 * * * *").Name("list").Run(func() { list.Add(1) })
+	schedule.Cron("0 9 * JAN-MAR MON-FRI").Name("names").Run(func() { names.Add(1) })
+	schedule.Cron("30 9 * * *").Timezone("Asia/Kolkata").Name("kolkata").Run(func() { tz.Add(1) })
+	schedule.Cron("61 * * * *").Name("invalid").Run(func() { invalid.Add(1) })
+
+	utc := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	schedule.RunDue(utc("2025-01-06T04:00:00Z")) // Monday, 09:30 IST
+	schedule.RunDue(utc("2025-01-06T04:15:00Z"))
+	schedule.RunDue(utc("2025-01-06T04:20:00Z"))
+	schedule.RunDue(utc("2025-01-11T09:00:00Z")) // Saturday
+	schedule.RunDue(utc("2025-02-03T09:00:00Z")) // Monday
+
+	if list.Load() != 4 {
+		t.Errorf("list ran %d times, want 4", list.Load())
+	}
+	if names.Load() != 1 {
+		t.Errorf("MON-FRI in JAN-MAR ran %d times, want 1", names.Load())
+	}
+	if tz.Load() != 1 {
+		t.Errorf("09:30 Asia/Kolkata ran %d times, want 1", tz.Load())
+	}
+	if invalid.Load() != 0 {
+		t.Error("invalid expression fired")
+	}
+}
+
+func TestAt_AnchorsToWallClock(t *testing.T) {
+	var daily, monday, window atomic.Int32
+	schedule.Daily().At("03:00").Timezone("Asia/Kolkata").Name("daily-at").Run(func() { daily.Add(1) })
+	schedule.Weekly().At("08:30").Mondays().Name("monday-at").Run(func() { monday.Add(1) })
+	schedule.Every(30).Minutes().Between("09:00", "17:00").Weekdays().Name("window").Run(func() { window.Add(1) })
+
+	utc := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts.In(time.UTC)
+	}
+	for _, ts := range []string{
+		"2025-01-05T21:30:00Z", // Sun 21:30 UTC = Mon 03:00 IST
+		"2025-01-06T03:00:00Z", // Mon 03:00 UTC
+		"2025-01-06T08:30:00Z", // Mon 08:30
+		"2025-01-06T09:00:00Z", // Mon 09:00
+		"2025-01-06T18:00:00Z", // Mon 18:00, outside the window
+		"2025-01-07T08:30:00Z", // Tue 08:30
+		"2025-01-11T10:00:00Z", // Sat 10:00
+	} {
+		schedule.RunDue(utc(ts))
+	}
+
+	if daily.Load() != 1 {
+		t.Errorf("Daily().At(03:00 IST) ran %d times, want 1", daily.Load())
+	}
+	if monday.Load() != 1 {
+		t.Errorf("Weekly().At(08:30).Mondays() ran %d times, want 1", monday.Load())
+	}
+	if window.Load() != 1 {
+		t.Errorf("Between(09:00, 17:00).Weekdays() ran %d times, want 1", window.Load())
+	}
+}
@@ -0,0 +1,192 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// ------------------- Persistent state -------------------
+
+// Store persists when each task last ran, keyed by its Name, so a restart
+// neither fires every task at once nor forgets runs missed while the
+// process was down. Name tasks explicitly: generated names ("task-3")
+// change when tasks are added.
+type Store interface {
+	Load(id string) (last time.Time, ok bool, err error)
+	Save(id string, at time.Time) error
+}
+
+var (
+	storeMu sync.RWMutex
+	store   Store
+)
+
+// SetStore persists last-run times in s. nil keeps them in memory only.
+func SetStore(s Store) {
+	storeMu.Lock()
+	store = s
+	storeMu.Unlock()
+}
+
+func currentStore() Store {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+	return store
+}
+
+// UseDB persists last-run times in the kashvi_schedule_runs table of db.
+func UseDB(db *gorm.DB) error {
+	s, err := NewDatabaseStore(db)
+	if err != nil {
+		return err
+	}
+	SetStore(s)
+	return nil
+}
+
+// RunRecord is a task's last run in kashvi_schedule_runs.
+type RunRecord struct {
+	ID        string    `gorm:"primaryKey;size:191"`
+	LastRunAt time.Time `gorm:"not null"`
+}
+
+func (RunRecord) TableName() string { return "kashvi_schedule_runs" }
+
+// DatabaseStore keeps last-run times in kashvi_schedule_runs.
+type DatabaseStore struct{ db *gorm.DB }
+
+// NewDatabaseStore migrates kashvi_schedule_runs and returns a store on db.
+func NewDatabaseStore(db *gorm.DB) (*DatabaseStore, error) {
+	if err := db.AutoMigrate(&RunRecord{}); err != nil {
+		return nil, fmt.Errorf("schedule: migrate kashvi_schedule_runs: %w", err)
+	}
+	return &DatabaseStore{db: db}, nil
+}
+
+func (s *DatabaseStore) Load(id string) (time.Time, bool, error) {
+	var rec RunRecord
+	err := s.db.Where("id = ?", id).First(&rec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("schedule: load %s: %w", id, err)
+	}
+	return rec.LastRunAt, true, nil
+}
+
+func (s *DatabaseStore) Save(id string, at time.Time) error {
+	err := s.db.Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&RunRecord{ID: id, LastRunAt: at}).Error
+	if err != nil {
+		return fmt.Errorf("schedule: save %s: %w", id, err)
+	}
+	return nil
+}
+
+// RedisStore keeps last-run times under kashvi:schedule:last:<id>.
+type RedisStore struct{ rdb *redis.Client }
+
+// NewRedisStore returns a store on rdb, typically cache.RDB.
+func NewRedisStore(rdb *redis.Client) *RedisStore { return &RedisStore{rdb: rdb} }
+
+func redisKey(id string) string { return "kashvi:schedule:last:" + id }
+
+func (s *RedisStore) Load(id string) (time.Time, bool, error) {
+	v, err := s.rdb.Get(context.Background(), redisKey(id)).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("schedule: load %s: %w", id, err)
+	}
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("schedule: load %s: %w", id, err)
+	}
+	return time.UnixMilli(ms), true, nil
+}
+
+func (s *RedisStore) Save(id string, at time.Time) error {
+	if err := s.rdb.Set(context.Background(), redisKey(id), at.UnixMilli(), 0).Err(); err != nil {
+		return fmt.Errorf("schedule: save %s: %w", id, err)
+	}
+	return nil
+}
+
+// restore loads e's persisted last run the first time e is evaluated and
+// reports whether a run was missed that should be caught up now. Missed
+// interval runs of tasks without RunOnMissed are skipped: the next run
+// keeps the original cadence.
+func (e *entry) restore(now time.Time) (missed bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.loaded {
+		return false
+	}
+	e.loaded = true
+	st := currentStore()
+	if st == nil {
+		return false
+	}
+	last, ok, err := st.Load(e.id)
+	if err != nil {
+		logger.Warn("schedule: could not load last run", "id", e.id, "error", err)
+		return false
+	}
+	if !ok || !last.After(e.lastRun) {
+		return false
+	}
+	e.lastRun = last
+
+	if e.calendar() {
+		missed = cronMissed(e, last, now)
+	} else {
+		missed = now.Sub(last) >= e.interval
+		if missed && !e.catchUp {
+			// Resume on the old cadence instead of firing right away.
+			e.lastRun = last.Add(now.Sub(last).Truncate(e.interval))
+		}
+	}
+	if missed && !e.catchUp {
+		logger.Info("schedule: skipped runs missed while stopped", "id", e.id, "last_run", last)
+		return false
+	}
+	return missed
+}
+
+// maxCatchUpScan bounds the minutes cronMissed inspects.
+const maxCatchUpScan = 366 * 24 * 60
+
+// cronMissed reports whether calendar entry e matched any minute after
+// last and before now's minute.
+func cronMissed(e *entry, last, now time.Time) bool {
+	end := now.Truncate(time.Minute)
+	t := last.Truncate(time.Minute).Add(time.Minute)
+	for i := 0; t.Before(end) && i < maxCatchUpScan; i++ {
+		if e.matchMinute(t) {
+			return true
+		}
+		t = t.Add(time.Minute)
+	}
+	return false
+}
+
+// persist records a run of e.
+func persist(e *entry, at time.Time) {
+	if st := currentStore(); st != nil {
+		if err := st.Save(e.id, at); err != nil {
+			logger.Warn("schedule: could not save last run", "id", e.id, "error", err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+)
+
+// ------------------- CSRF tokens -------------------
+
+const (
+	// CSRFKey is the session key holding the CSRF token.
+	CSRFKey = "_csrf_token"
+	// CSRFField is the form field middleware.CSRF reads the token from.
+	CSRFField = "_token"
+	// CSRFHeader is the header middleware.CSRF reads the token from, for
+	// JavaScript clients.
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// CSRFToken returns the session's CSRF token, creating it on first use.
+// A new token marks the session changed, so it is stored on the next Save.
+func (s *Session) CSRFToken() string {
+	if tok, ok := s.GetString(CSRFKey); ok && tok != "" {
+		return tok
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	tok := hex.EncodeToString(b)
+	s.Set(CSRFKey, tok)
+	return tok
+}
+
+// RegenerateCSRFToken replaces the token, e.g. after login, so a token
+// seen before authentication stops working.
+func (s *Session) RegenerateCSRFToken() string {
+	s.Delete(CSRFKey)
+	return s.CSRFToken()
+}
+
+// CSRFToken returns the CSRF token of the request's session, for templates
+// and <meta name="csrf-token"> tags:
+//
+//	tmpl.Execute(w, map[string]any{"CSRF": session.CSRFToken(r)})
+func CSRFToken(r *http.Request) string { return FromCtx(r).CSRFToken() }
+
+// CSRFInput returns a hidden form input carrying the request's CSRF token:
+//
+//	<form method="POST">{{ .CSRFInput }} … </form>
+func CSRFInput(r *http.Request) template.HTML {
+	return template.HTML(`<input type="hidden" name="` + CSRFField + `" value="` +
+		template.HTMLEscapeString(CSRFToken(r)) + `">`)
+}
@@ -202,9 +202,16 @@ func Middleware(opts Options) func(http.Handler) http.Handler {
 // FromCtx retrieves the session from the request context.
 // Returns an empty (unsaved) session if none is present.
 func FromCtx(r *http.Request) *Session {
-	if s, ok := r.Context().Value(ctxKey{}).(*Session); ok {
+	if s, ok := Lookup(r); ok {
 		return s
 	}
 	id, _ := newID()
 	return &Session{id: id, data: map[string]interface{}{}, opts: DefaultOptions()}
 }
+
+// Lookup returns the session Middleware put in the request context, and
+// false when there is none.
+func Lookup(r *http.Request) (*Session, bool) {
+	s, ok := r.Context().Value(ctxKey{}).(*Session)
+	return s, ok
+}
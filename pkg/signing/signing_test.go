@@ -0,0 +1,128 @@
+package signing_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/signing"
+)
+
+func useKeys(t *testing.T, keys map[string][]string) {
+	t.Helper()
+	signing.SetKeyFunc(func(service string) [][]byte {
+		var out [][]byte
+		for _, k := range keys[service] {
+			out = append(out, []byte(k))
+		}
+		return out
+	})
+	t.Cleanup(func() { signing.SetKeyFunc(nil) })
+}
+
+func TestSignVerify(t *testing.T) {
+	useKeys(t, map[string][]string{"billing": {"new-key", "old-key"}})
+	body := []byte(`{"amount":42}`)
+
+	req := httptest.NewRequest("POST", "/internal/charge?x=1", nil)
+	if err := signing.Sign(req, "billing", body); err != nil {
+		t.Fatal(err)
+	}
+	v := signing.NewVerifier()
+	if svc, err := v.Verify(req, body); err != nil || svc != "billing" {
+		t.Fatalf("Verify = %q, %v", svc, err)
+	}
+	if _, err := v.Verify(req, body); !errors.Is(err, signing.ErrReplay) {
+		t.Errorf("replay: err = %v", err)
+	}
+
+	// Rotation: a request signed with the previous key still verifies.
+	old := httptest.NewRequest("POST", "/internal/charge", nil)
+	signing.SignWithKey(old, "billing", []byte("old-key"), body, time.Now())
+	if _, err := v.Verify(old, body); err != nil {
+		t.Errorf("old key: %v", err)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	useKeys(t, map[string][]string{"billing": {"k"}})
+	body := []byte("payload")
+	v := signing.NewVerifier()
+
+	fresh := func(mutate func(r *signingReq)) error {
+		req := httptest.NewRequest("POST", "/x", nil)
+		at := time.Now()
+		sr := &signingReq{key: []byte("k"), body: body, at: at, path: "/x"}
+		if mutate != nil {
+			mutate(sr)
+		}
+		signing.SignWithKey(req, "billing", sr.key, body, sr.at)
+		req.URL.Path = sr.path
+		_, err := v.Verify(req, sr.body)
+		return err
+	}
+
+	cases := map[string]struct {
+		mutate func(*signingReq)
+		want   error
+	}{
+		"wrong key":     {func(s *signingReq) { s.key = []byte("guess") }, signing.ErrSignature},
+		"tampered body": {func(s *signingReq) { s.body = []byte("payload!") }, signing.ErrBodyHash},
+		"other path":    {func(s *signingReq) { s.path = "/y" }, signing.ErrSignature},
+		"stale":         {func(s *signingReq) { s.at = time.Now().Add(-time.Hour) }, signing.ErrExpired},
+	}
+	for name, tc := range cases {
+		if err := fresh(tc.mutate); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", name, err, tc.want)
+		}
+	}
+
+	if _, err := v.Verify(httptest.NewRequest("GET", "/", nil), nil); !errors.Is(err, signing.ErrMissing) {
+		t.Errorf("unsigned: err = %v", err)
+	}
+}
+
+type signingReq struct {
+	key, body []byte
+	at        time.Time
+	path      string
+}
+
+func TestVerifyRemembersNoncesWithinWindow(t *testing.T) {
+	useKeys(t, map[string][]string{"billing": {"k"}})
+	now := time.Now()
+	v := signing.NewVerifier()
+	v.Now = func() time.Time { return now }
+
+	sign := func(at time.Time) *http.Request {
+		req := httptest.NewRequest("POST", "/x", nil)
+		signing.SignWithKey(req, "billing", []byte("k"), nil, at)
+		return req
+	}
+	first := sign(now)
+	if _, err := v.Verify(first, nil); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2000; i++ {
+		if _, err := v.Verify(sign(now), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now = now.Add(signing.DefaultSkew / 2)
+	if _, err := v.Verify(first, nil); !errors.Is(err, signing.ErrReplay) {
+		t.Fatalf("replay within the window: err = %v", err)
+	}
+
+	// Well past the window old nonces are pruned; new ones still verify.
+	now = now.Add(3 * signing.DefaultSkew)
+	req := sign(now)
+	if _, err := v.Verify(req, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(req, nil); !errors.Is(err, signing.ErrReplay) {
+		t.Fatalf("replay after pruning: err = %v", err)
+	}
+}
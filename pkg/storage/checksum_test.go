@@ -0,0 +1,45 @@
+package storage_test
+
+import (
+	"crypto/sha256"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/storage"
+)
+
+func TestPutVerifiedOnLocalDisk(t *testing.T) {
+	d := tempDisk(t)
+	sum, err := storage.PutVerifiedOn(d, "docs/a.txt", []byte("hello"))
+	if err != nil || sum != storage.Checksum([]byte("hello")) {
+		t.Fatalf("PutVerifiedOn = %q, %v", sum, err)
+	}
+	if got := read(t, d, "docs/a.txt"); got != "hello" {
+		t.Fatalf("content = %q", got)
+	}
+	// Not the 0600 of the temporary file it was written to.
+	if fi, err := os.Stat(d.Path("docs/a.txt")); err != nil || fi.Mode().Perm() != 0o644 {
+		t.Fatalf("stat = %v, %v; want mode 0644", fi, err)
+	}
+}
+
+func TestPutWithChecksumMismatchLeavesNothing(t *testing.T) {
+	d := tempDisk(t)
+	put(t, d, "docs/a.txt", "old")
+
+	err := d.PutWithChecksum("docs/a.txt", []byte("new"), sha256.Sum256([]byte("other")))
+	if !errors.Is(err, storage.ErrChecksumMismatch) {
+		t.Fatalf("err = %v, want ErrChecksumMismatch", err)
+	}
+	if got := read(t, d, "docs/a.txt"); got != "old" {
+		t.Fatalf("existing file replaced: %q", got)
+	}
+	entries, err := os.ReadDir(d.Path("docs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("temporary file left behind: %v", entries)
+	}
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// ─── Sync ─────────────────────────────────────────────────────────────────────
+
+// SyncOptions tunes Sync.
+type SyncOptions struct {
+	// Concurrency is the number of files copied in parallel. Default: 4.
+	Concurrency int
+	// Overwrite copies files that already exist on dst with the same size.
+	// By default they are skipped, which makes re-running a sync cheap.
+	Overwrite bool
+	// DeleteSource removes each file from src once it is on dst, turning the
+	// sync into a move (e.g. shipping local uploads to S3). A source file is
+	// only deleted after dst's copy is read back and matches its SHA-256;
+	// a skipped file whose content differs on dst is kept and reported as
+	// failed.
+	DeleteSource bool
+	// Progress, when set, is called after every file. Calls are serialized.
+	Progress func(SyncProgress)
+}
+
+// SyncProgress reports one finished file.
+type SyncProgress struct {
+	Path    string
+	Skipped bool
+	Err     error
+	Done    int // files finished so far, including this one
+	Total   int
+}
+
+// SyncResult summarizes a Sync run.
+type SyncResult struct {
+	Copied  int
+	Skipped int
+	Failed  int
+}
+
+// Sync copies every file under prefix from src to dst, keeping paths. Files
+// that fail are reported through Progress and counted; Sync keeps going and
+// returns their errors joined.
+//
+//	res, err := storage.Sync(storage.Use("local"), storage.Use("s3"), "uploads",
+//	    storage.SyncOptions{DeleteSource: true})
+func Sync(src, dst Disk, prefix string, opts SyncOptions) (SyncResult, error) {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 4
+	}
+	paths, err := listFiles(src, prefix)
+	if err != nil {
+		return SyncResult{}, fmt.Errorf("storage: sync %s: %w", prefix, err)
+	}
+
+	var (
+		res  SyncResult
+		errs []error
+		mu   sync.Mutex // guards res, errs and Progress calls
+		done atomic.Int64
+		wg   sync.WaitGroup
+		jobs = make(chan string)
+	)
+	for range opts.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				skipped, err := syncFile(src, dst, p, opts)
+				mu.Lock()
+				switch {
+				case err != nil:
+					res.Failed++
+					errs = append(errs, err)
+				case skipped:
+					res.Skipped++
+				default:
+					res.Copied++
+				}
+				if opts.Progress != nil {
+					opts.Progress(SyncProgress{
+						Path: p, Skipped: skipped, Err: err,
+						Done: int(done.Add(1)), Total: len(paths),
+					})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, p := range paths {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+	return res, errors.Join(errs...)
+}
+
+func syncFile(src, dst Disk, path string, opts SyncOptions) (skipped bool, err error) {
+	if !opts.Overwrite && dst.Exists(path) {
+		srcSize, err1 := src.Size(path)
+		dstSize, err2 := dst.Size(path)
+		if err1 == nil && err2 == nil && srcSize == dstSize {
+			skipped = true
+		}
+	}
+
+	// The source's checksum is taken while copying (or, for a skipped file,
+	// by reading it) so DeleteSource only removes files dst holds intact.
+	h := sha256.New()
+	if skipped {
+		if opts.DeleteSource {
+			if err := hashFile(src, path, h); err != nil {
+				return skipped, fmt.Errorf("storage: sync %s: %w", path, err)
+			}
+		}
+	} else {
+		rc, err := src.GetStream(path)
+		if err != nil {
+			return false, fmt.Errorf("storage: sync %s: %w", path, err)
+		}
+		err = dst.PutStream(path, io.TeeReader(rc, h))
+		rc.Close()
+		if err != nil {
+			return false, fmt.Errorf("storage: sync %s: %w", path, err)
+		}
+	}
+
+	if opts.DeleteSource {
+		if err := VerifyOn(dst, path, hex.EncodeToString(h.Sum(nil))); err != nil {
+			if skipped {
+				return skipped, fmt.Errorf("storage: sync %s: kept source, dst has different content (set Overwrite to replace it): %w", path, err)
+			}
+			return skipped, fmt.Errorf("storage: sync %s: kept source: %w", path, err)
+		}
+		if err := src.Delete(path); err != nil {
+			return skipped, fmt.Errorf("storage: sync %s: delete source: %w", path, err)
+		}
+	}
+	return skipped, nil
+}
+
+func hashFile(d Disk, path string, h hash.Hash) error {
+	rc, err := d.GetStream(path)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(h, rc)
+	return err
+}
+
+// ─── Prune ────────────────────────────────────────────────────────────────────
+
+// Prune deletes files under prefix on d last modified more than olderThan
+// ago and returns how many it removed. A missing prefix prunes nothing.
+func Prune(d Disk, prefix string, olderThan time.Duration) (int, error) {
+	paths, err := listFiles(d, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("storage: prune %s: %w", prefix, err)
+	}
+	cutoff := time.Now().Add(-olderThan)
+	var (
+		n    int
+		errs []error
+	)
+	for _, p := range paths {
+		mod, err := d.LastModified(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !mod.Before(cutoff) {
+			continue
+		}
+		if err := d.Delete(p); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
+
+// PruneTask returns a function that prunes prefix on the named disk, for the
+// scheduler; it logs what it removed instead of returning errors.
+//
+//	schedule.Daily().Name("prune-exports").Run(storage.PruneTask("local", "exports", 7))
+func PruneTask(disk, prefix string, days int) func() {
+	return func() {
+		d, ok := Lookup(disk)
+		if !ok {
+			logger.Error("storage: prune: disk not configured", "disk", disk)
+			return
+		}
+		n, err := Prune(d, prefix, time.Duration(days)*24*time.Hour)
+		if err != nil {
+			logger.Warn("storage: prune finished with errors", "disk", disk, "prefix", prefix, "deleted", n, "error", err)
+			return
+		}
+		logger.Info("storage: pruned", "disk", disk, "prefix", prefix, "deleted", n)
+	}
+}
+
+// listFiles is AllFiles with a missing directory treated as empty.
+func listFiles(d Disk, prefix string) ([]string, error) {
+	paths, err := d.AllFiles(prefix)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return paths, err
+}
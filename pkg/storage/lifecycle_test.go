@@ -0,0 +1,83 @@
+package storage_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/storage"
+)
+
+func tempDisk(t *testing.T) *storage.TempDisk {
+	t.Helper()
+	d, err := storage.Temp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Cleanup() })
+	return d
+}
+
+func put(t *testing.T, d storage.Disk, path, content string) {
+	t.Helper()
+	if err := d.Put(path, []byte(content)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func read(t *testing.T, d storage.Disk, path string) string {
+	t.Helper()
+	b, err := d.Get(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestSyncSkipsAndOverwrites(t *testing.T) {
+	src, dst := tempDisk(t), tempDisk(t)
+	put(t, src, "up/a.txt", "aaaa")
+	put(t, src, "up/b.txt", "bbbb")
+	put(t, dst, "up/a.txt", "AAAA") // same size, different content
+
+	res, err := storage.Sync(src, dst, "up", storage.SyncOptions{})
+	if err != nil || res.Copied != 1 || res.Skipped != 1 {
+		t.Fatalf("sync = %+v, %v", res, err)
+	}
+	if got := read(t, dst, "up/a.txt"); got != "AAAA" {
+		t.Fatalf("skipped file was replaced: %q", got)
+	}
+
+	res, err = storage.Sync(src, dst, "up", storage.SyncOptions{Overwrite: true})
+	if err != nil || res.Copied != 2 {
+		t.Fatalf("overwrite = %+v, %v", res, err)
+	}
+	if got := read(t, dst, "up/a.txt"); got != "aaaa" {
+		t.Fatalf("Overwrite did not replace: %q", got)
+	}
+}
+
+func TestSyncDeleteSourceVerifiesDestination(t *testing.T) {
+	src, dst := tempDisk(t), tempDisk(t)
+	put(t, src, "up/moved.txt", "moved")
+	put(t, src, "up/same.txt", "same")
+	put(t, src, "up/conflict.txt", "mine")
+	put(t, dst, "up/same.txt", "same")
+	put(t, dst, "up/conflict.txt", "THEI") // same size, different content
+
+	res, err := storage.Sync(src, dst, "up", storage.SyncOptions{DeleteSource: true})
+	if !errors.Is(err, storage.ErrChecksumMismatch) {
+		t.Fatalf("err = %v, want a checksum mismatch for the conflict", err)
+	}
+	if res.Copied != 1 || res.Skipped != 1 || res.Failed != 1 {
+		t.Fatalf("sync = %+v", res)
+	}
+	if src.Exists("up/moved.txt") || src.Exists("up/same.txt") {
+		t.Fatal("verified files were not deleted from src")
+	}
+	if !src.Exists("up/conflict.txt") {
+		t.Fatal("source deleted although dst holds different content")
+	}
+	if got := read(t, dst, "up/conflict.txt"); got != "THEI" {
+		t.Fatalf("dst conflict file changed: %q", got)
+	}
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/fs"
@@ -58,6 +59,52 @@ func (d *localDisk) PutStream(path string, r io.Reader) error {
 	return nil
 }
 
+// PutWithChecksum writes content to a temporary file next to path, hashing
+// what reaches the disk, and renames it into place only when the hash
+// matches sum. A reader never sees a partial or corrupted file.
+func (d *localDisk) PutWithChecksum(path string, content []byte, sum [sha256.Size]byte) error {
+	full := d.abs(path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return fmt.Errorf("storage/local: mkdir: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(full), ".put-*")
+	if err != nil {
+		return fmt.Errorf("storage/local: create %s: %w", path, err)
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp) // no-op after a successful rename
+
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return fmt.Errorf("storage/local: write %s: %w", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("storage/local: sync %s: %w", path, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return fmt.Errorf("storage/local: verify %s: %w", path, err)
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("storage/local: verify %s: %w", path, err)
+	}
+	if !bytes.Equal(h.Sum(nil), sum[:]) {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, path)
+	}
+	// CreateTemp makes the file 0600; give it the mode Put files get.
+	if err := os.Chmod(tmp, 0o644); err != nil {
+		return fmt.Errorf("storage/local: chmod %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, full); err != nil {
+		return fmt.Errorf("storage/local: rename %s: %w", path, err)
+	}
+	return nil
+}
+
 // ── Read ──────────────────────────────────────────────────────────────────────
 
 func (d *localDisk) Get(path string) ([]byte, error) {
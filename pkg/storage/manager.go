@@ -50,6 +50,15 @@ func Use(name string) Disk {
 	return d
 }
 
+// Lookup returns the named disk and whether it is configured. Unlike Use it
+// never panics, which suits background writers that must not crash the app.
+func Lookup(name string) (Disk, bool) {
+	managerMu.RLock()
+	defer managerMu.RUnlock()
+	d, ok := disks[name]
+	return d, ok
+}
+
 // RegisterDisk lets you plug in a custom Disk implementation at boot time.
 func RegisterDisk(name string, d Disk) {
 	managerMu.Lock()
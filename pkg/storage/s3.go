@@ -3,6 +3,8 @@ package storage
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"strings"
@@ -95,6 +97,22 @@ func (d *s3Disk) PutStream(path string, r io.Reader) error {
 	return nil
 }
 
+// PutWithChecksum sends sum as the object's SHA-256 checksum; S3 rejects
+// the upload when the bytes it received do not match.
+func (d *s3Disk) PutWithChecksum(path string, content []byte, sum [sha256.Size]byte) error {
+	_, err := d.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket:            aws.String(d.bucket),
+		Key:               aws.String(path),
+		Body:              bytes.NewReader(content),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+		ChecksumSHA256:    aws.String(base64.StdEncoding.EncodeToString(sum[:])),
+	})
+	if err != nil {
+		return fmt.Errorf("storage/s3: put %s: %w", path, err)
+	}
+	return nil
+}
+
 // ── Read ──────────────────────────────────────────────────────────────────────
 
 func (d *s3Disk) Get(path string) ([]byte, error) {
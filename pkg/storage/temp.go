@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
+)
+
+// ─── Scratch space ────────────────────────────────────────────────────────────
+
+// TempDisk is a Disk over a private scratch directory. Everything written
+// to it is removed by Cleanup; the request- and job-scoped disks returned by
+// TempFrom are cleaned up automatically.
+type TempDisk struct {
+	*localDisk
+}
+
+// Temp creates a scratch disk under STORAGE_TEMP_ROOT (default: the OS temp
+// directory). The caller must call Cleanup; inside a request or job prefer
+// TempFrom, which does it for you.
+func Temp() (*TempDisk, error) {
+	root := config.Get("STORAGE_TEMP_ROOT", os.TempDir())
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, fmt.Errorf("storage/temp: mkdir: %w", err)
+	}
+	dir, err := os.MkdirTemp(root, "kashvi-*")
+	if err != nil {
+		return nil, fmt.Errorf("storage/temp: create: %w", err)
+	}
+	return &TempDisk{localDisk: &localDisk{root: dir, baseURL: "file://" + filepath.ToSlash(dir)}}, nil
+}
+
+// Dir returns the absolute scratch directory.
+func (t *TempDisk) Dir() string { return t.root }
+
+// Path returns the absolute filesystem path of path, for tools that need a
+// real file (image converters, zip writers, exec'd binaries).
+func (t *TempDisk) Path(path string) string { return t.abs(path) }
+
+// CreateFile creates a new uniquely named file in the scratch directory;
+// pattern follows os.CreateTemp ("export-*.csv").
+func (t *TempDisk) CreateFile(pattern string) (*os.File, error) {
+	f, err := os.CreateTemp(t.root, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("storage/temp: create: %w", err)
+	}
+	return f, nil
+}
+
+// Spool copies r into a new scratch file and returns it rewound to the
+// start, with its size. Use it to hold large bodies on disk, not in memory.
+func (t *TempDisk) Spool(r io.Reader, pattern string) (*os.File, int64, error) {
+	f, err := t.CreateFile(pattern)
+	if err != nil {
+		return nil, 0, err
+	}
+	n, err := io.Copy(f, r)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, 0, fmt.Errorf("storage/temp: spool: %w", err)
+	}
+	return f, n, nil
+}
+
+// Cleanup removes the scratch directory and everything in it.
+func (t *TempDisk) Cleanup() error {
+	if err := os.RemoveAll(t.root); err != nil {
+		return fmt.Errorf("storage/temp: cleanup: %w", err)
+	}
+	return nil
+}
+
+// ─── Request / job scope ──────────────────────────────────────────────────────
+
+// ErrNoTempScope is returned by TempFrom outside a request or job wrapped
+// by TempMiddleware, TempJobMiddleware or WithTemp.
+var ErrNoTempScope = errors.New("storage: no temp scope in context")
+
+type tempScope struct {
+	mu     sync.Mutex
+	disk   *TempDisk
+	closed bool
+}
+
+type tempKey struct{}
+
+// WithTemp returns a context carrying a scratch disk that is created on
+// first use by TempFrom, and the cleanup func that removes it.
+func WithTemp(ctx context.Context) (context.Context, func()) {
+	s := &tempScope{}
+	return context.WithValue(ctx, tempKey{}, s), func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.closed = true
+		if s.disk != nil {
+			s.disk.Cleanup() //nolint:errcheck
+		}
+	}
+}
+
+// TempFrom returns the scratch disk of the request or job running in ctx,
+// creating it on first use. It is removed when the request or job ends.
+//
+//	tmp, err := storage.TempFrom(ctx)
+//	f, _ := tmp.CreateFile("export-*.csv")
+func TempFrom(ctx context.Context) (*TempDisk, error) {
+	s, ok := ctx.Value(tempKey{}).(*tempScope)
+	if !ok {
+		return nil, ErrNoTempScope
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrNoTempScope
+	}
+	if s.disk == nil {
+		d, err := Temp()
+		if err != nil {
+			return nil, err
+		}
+		s.disk = d
+	}
+	return s.disk, nil
+}
+
+// TempMiddleware gives every request a scratch scope (see TempFrom). The
+// directory is only created when a handler asks for it.
+func TempMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cleanup := WithTemp(r.Context())
+			defer cleanup()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// TempJobMiddleware gives every job attempt a scratch scope (see TempFrom);
+// jobs read it in HandleCtx.
+func TempJobMiddleware() queue.Middleware {
+	return func(next queue.JobHandler) queue.JobHandler {
+		return func(ctx context.Context, job queue.Job) error {
+			ctx, cleanup := WithTemp(ctx)
+			defer cleanup()
+			return next(ctx, job)
+		}
+	}
+}
+
+// ─── Upload spooling ──────────────────────────────────────────────────────────
+
+// SpooledUpload is a multipart file streamed to scratch space.
+type SpooledUpload struct {
+	*os.File // rewound to the start
+	Filename string
+	Size     int64
+	Header   multipart.FileHeader
+}
+
+// SpoolUpload streams the multipart field from r straight into the
+// request's scratch disk, without buffering it in memory the way
+// ParseMultipartForm does. Call it before anything else reads the body;
+// the file is removed when the request ends.
+//
+//	up, err := storage.SpoolUpload(c.R, "file")
+//	storage.PutStream("imports/"+up.Filename, up)
+func SpoolUpload(r *http.Request, field string) (*SpooledUpload, error) {
+	tmp, err := TempFrom(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("storage/temp: spool %s: %w", field, err)
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("storage/temp: spool %s: %w", field, http.ErrMissingFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("storage/temp: spool %s: %w", field, err)
+		}
+		if part.FormName() != field || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+		f, n, err := tmp.Spool(part, "upload-*")
+		part.Close()
+		if err != nil {
+			return nil, err
+		}
+		return &SpooledUpload{
+			File:     f,
+			Filename: filepath.Base(part.FileName()),
+			Size:     n,
+			Header:   multipart.FileHeader{Filename: part.FileName(), Header: part.Header, Size: n},
+		}, nil
+	}
+}
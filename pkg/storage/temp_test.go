@@ -0,0 +1,75 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/storage"
+)
+
+func TestTempFrom_CleanedUpWithScope(t *testing.T) {
+	if _, err := storage.TempFrom(context.Background()); !errors.Is(err, storage.ErrNoTempScope) {
+		t.Fatalf("outside scope: %v", err)
+	}
+
+	ctx, cleanup := storage.WithTemp(context.Background())
+	tmp, err := storage.TempFrom(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := storage.TempFrom(ctx); again != tmp {
+		t.Fatal("TempFrom returned a second disk in the same scope")
+	}
+	if err := tmp.Put("a/b.txt", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(tmp.Path("a/b.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	cleanup()
+	if _, err := os.Stat(tmp.Dir()); !os.IsNotExist(err) {
+		t.Fatalf("scratch dir survived cleanup: %v", err)
+	}
+	if _, err := storage.TempFrom(ctx); err == nil {
+		t.Fatal("TempFrom after cleanup created a new disk")
+	}
+}
+
+func TestSpoolUpload_StreamsToScratch(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("title", "report")
+	fw, _ := mw.CreateFormFile("file", "../data.csv")
+	fw.Write([]byte("id,name\n1,a\n"))
+	mw.Close()
+
+	var dir string
+	h := storage.TempMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		up, err := storage.SpoolUpload(r, "file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer up.Close()
+		data, _ := io.ReadAll(up)
+		if up.Filename != "data.csv" || up.Size != 12 || string(data) != "id,name\n1,a\n" {
+			t.Fatalf("upload = %q %d %q", up.Filename, up.Size, data)
+		}
+		tmp, _ := storage.TempFrom(r.Context())
+		dir = tmp.Dir()
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("request scratch dir survived: %v", err)
+	}
+}
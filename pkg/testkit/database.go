@@ -0,0 +1,62 @@
+package testkit
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+)
+
+// ─── In-memory database ───────────────────────────────────────────────────────
+
+var memoryDBSeq atomic.Uint64
+
+// MemoryDB opens a fresh, isolated in-memory SQLite database, migrates models
+// into it and installs it as database.DB for the duration of the test. The
+// previous database.DB is restored and the in-memory database discarded when
+// the test finishes.
+//
+// Because database.DB is a package global, tests using MemoryDB must not call
+// t.Parallel(); use the returned *gorm.DB directly if you need parallelism.
+//
+//	func TestCreateUser(t *testing.T) {
+//	    db := testkit.MemoryDB(t, &models.User{})
+//	    ...
+//	}
+func MemoryDB(t testing.TB, models ...any) *gorm.DB {
+	t.Helper()
+
+	name := fmt.Sprintf("testkit_%d_%s", memoryDBSeq.Add(1), sanitizeName(t.Name()))
+	db, err := database.OpenMemory(name)
+	if err != nil {
+		t.Fatalf("testkit: open in-memory database: %v", err)
+	}
+	if len(models) > 0 {
+		if err := db.AutoMigrate(models...); err != nil {
+			_ = database.Close(db)
+			t.Fatalf("testkit: migrate in-memory database: %v", err)
+		}
+	}
+
+	prev := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = prev
+		_ = database.Close(db)
+	})
+	return db
+}
+
+// sanitizeName keeps DSN-safe characters from a test name.
+func sanitizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,38 @@
+package testkit_test
+
+import (
+	"testing"
+
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	"github.com/shashiranjanraj/kashvi/pkg/testkit"
+)
+
+type memWidget struct {
+	ID   uint
+	Name string
+}
+
+func TestMemoryDBIsIsolated(t *testing.T) {
+	t.Run("first", func(t *testing.T) {
+		db := testkit.MemoryDB(t, &memWidget{})
+		if database.DB != db {
+			t.Fatal("MemoryDB should install itself as database.DB")
+		}
+		if err := db.Create(&memWidget{Name: "a"}).Error; err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	})
+	t.Run("second", func(t *testing.T) {
+		db := testkit.MemoryDB(t, &memWidget{})
+		var n int64
+		if err := db.Model(&memWidget{}).Count(&n).Error; err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		if n != 0 {
+			t.Fatalf("expected empty table in fresh database, got %d rows", n)
+		}
+	})
+	if database.DB != nil {
+		t.Fatal("database.DB should be restored after the test")
+	}
+}
@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+// GormPlugin records a client span for every statement, as a child of the
+// span in the statement's context — queries built with orm.WithCtx(ctx) or
+// c.DB() nest under their request. Statements without a span in their
+// context are not traced. database.Connect installs it when tracing is
+// enabled.
+type GormPlugin struct{}
+
+// Name implements gorm.Plugin.
+func (GormPlugin) Name() string { return "kashvi:tracing" }
+
+// Initialize registers before/after callbacks on every statement kind.
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	errs := []error{
+		cb.Create().Before("gorm:create").Register("tracing:before_create", startQuery("INSERT")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", endQuery),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", startQuery("SELECT")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", endQuery),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", startQuery("UPDATE")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", endQuery),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", startQuery("DELETE")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", endQuery),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", startQuery("SELECT")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", endQuery),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", startQuery("RAW")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", endQuery),
+	}
+	return errors.Join(errs...)
+}
+
+func startQuery(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+		name := op
+		if t := db.Statement.Table; t != "" {
+			name += " " + t
+		}
+		attrs := []trace.SpanStartOption{
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNameKey.String(db.Dialector.Name()),
+				semconv.DBOperationName(op),
+				semconv.DBCollectionName(db.Statement.Table),
+			),
+		}
+		_, span := Tracer().Start(ctx, name, attrs...)
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+func endQuery(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	// Parameterised SQL only: bound values may hold personal data.
+	span.SetAttributes(semconv.DBQueryText(db.Statement.SQL.String()))
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
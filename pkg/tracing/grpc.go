@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ─── gRPC ─────────────────────────────────────────────────────────────────────
+
+// UnaryServerInterceptor starts a server span for every unary call,
+// continuing the caller's trace from its traceparent metadata. pkg/grpc
+// installs it; until Init enables tracing, calls pass straight through.
+func UnaryServerInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if !Enabled() {
+		return handler(ctx, req)
+	}
+	ctx, span := startRPC(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	endRPC(span, err)
+	return resp, err
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming calls;
+// the span covers the whole stream.
+func StreamServerInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if !Enabled() {
+		return handler(srv, ss)
+	}
+	ctx, span := startRPC(ss.Context(), info.FullMethod)
+	err := handler(srv, &tracedStream{ServerStream: ss, ctx: ctx})
+	endRPC(span, err)
+	return err
+}
+
+// UnaryClientInterceptor starts a client span for outgoing calls and sends
+// its traceparent, so the callee's spans join the caller's trace:
+//
+//	conn, err := grpc.NewClient(addr, grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor))
+func UnaryClientInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply interface{},
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	if !Enabled() {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	ctx, span := Tracer().Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(rpcAttrs(method)...),
+	)
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	err := invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+	endRPC(span, err)
+	return err
+}
+
+func startRPC(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	return Tracer().Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(rpcAttrs(method)...),
+	)
+}
+
+func endRPC(span trace.Span, err error) {
+	st := status.Convert(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+	if err != nil {
+		span.SetStatus(codes.Error, st.Message())
+	}
+	span.End()
+}
+
+// rpcAttrs splits "/pkg.Service/Method" into the rpc.* attributes.
+func rpcAttrs(method string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.RPCSystemGRPC}
+	if svc, m, ok := strings.Cut(strings.TrimPrefix(method, "/"), "/"); ok {
+		attrs = append(attrs, semconv.RPCService(svc), semconv.RPCMethod(m))
+	}
+	return attrs
+}
+
+// tracedStream overrides the stream's context with the span's.
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedStream) Context() context.Context { return s.ctx }
+
+// metadataCarrier adapts gRPC metadata to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) { metadata.MD(c).Set(key, value) }
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
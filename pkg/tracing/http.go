@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/shashiranjanraj/kashvi/pkg/respwriter"
+)
+
+// ─── HTTP server ──────────────────────────────────────────────────────────────
+
+// Middleware starts a server span for every request, continuing the
+// caller's trace when it sends a traceparent header. The span is named after
+// the matched route ("GET /users/{id}") and records the status code; 5xx
+// responses mark it as an error. Handlers reach it through r.Context().
+// Until Init enables tracing, requests pass straight through.
+func Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := Tracer().Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+					semconv.ServerAddress(r.Host),
+				),
+			)
+			defer span.End()
+
+			rw := respwriter.Wrap(w)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			if rc := chi.RouteContext(r.Context()); rc != nil {
+				if p := rc.RoutePattern(); p != "" {
+					span.SetName(r.Method + " " + p)
+					span.SetAttributes(semconv.HTTPRoute(p))
+				}
+			}
+			status := rw.Status()
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= 500 {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
+
+// ─── HTTP client ──────────────────────────────────────────────────────────────
+
+// StartClient starts a client span for req, a child of the span in ctx, and
+// writes its traceparent into req's headers. Call the returned func with the
+// response status (0 if none) and error when the call completes. pkg/http
+// does this for every attempt.
+func StartClient(ctx context.Context, req *http.Request) func(status int, err error) {
+	ctx, span := Tracer().Start(ctx, req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Hostname()),
+			semconv.URLFull(req.URL.Redacted()),
+		),
+	)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return func(status int, err error) {
+		if status > 0 {
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		}
+		switch {
+		case err != nil:
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		case status >= 400:
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		span.End()
+	}
+}
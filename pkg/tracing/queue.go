@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
+)
+
+// ─── Queue ────────────────────────────────────────────────────────────────────
+
+// metaPrefix namespaces trace headers in a job's metadata.
+const metaPrefix = "otel:"
+
+// Propagator carries the dispatching span's trace context in the metadata
+// of jobs queued with queue.DispatchCtx, so the job's span joins the
+// request's trace. Install it with queue.AddPropagator.
+type Propagator struct{}
+
+// Inject implements queue.Propagator.
+func (Propagator) Inject(ctx context.Context, meta map[string]string) {
+	otel.GetTextMapPropagator().Inject(ctx, metaCarrier(meta))
+}
+
+// Extract implements queue.Propagator.
+func (Propagator) Extract(ctx context.Context, meta map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, metaCarrier(meta))
+}
+
+// metaCarrier adapts job metadata to propagation.TextMapCarrier.
+type metaCarrier map[string]string
+
+func (c metaCarrier) Get(key string) string { return c[metaPrefix+key] }
+
+func (c metaCarrier) Set(key, value string) { c[metaPrefix+key] = value }
+
+func (c metaCarrier) Keys() []string {
+	var keys []string
+	for k := range c {
+		if name, ok := strings.CutPrefix(k, metaPrefix); ok {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// InstrumentQueue adds JobMiddleware and Propagator to the queue. Servers
+// and workers call it at boot when tracing is enabled.
+func InstrumentQueue() {
+	queue.Use(JobMiddleware())
+	queue.AddPropagator(Propagator{})
+}
+
+// JobMiddleware records a consumer span for every job attempt, named after
+// the job type and parented to the dispatching request when the job was
+// queued with queue.DispatchCtx. A job that returns an error marks its span
+// as failed; retries are separate spans with their attempt number.
+func JobMiddleware() queue.Middleware {
+	return func(next queue.JobHandler) queue.JobHandler {
+		return func(ctx context.Context, job queue.Job) error {
+			info, _ := queue.Info(ctx)
+			ctx, span := Tracer().Start(ctx, "job "+info.Type,
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					semconv.MessagingSystemKey.String("kashvi"),
+					semconv.MessagingDestinationName(info.Queue),
+					semconv.MessagingOperationName("process"),
+					attribute.String("kashvi.job.type", info.Type),
+					attribute.Int("kashvi.job.attempt", info.Attempt),
+				),
+			)
+			defer span.End()
+			err := next(ctx, job)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Package tracing adds OpenTelemetry distributed tracing to Kashvi.
+//
+// Tracing is off until an OTLP endpoint is configured; then the server
+// exports spans for every HTTP request, gRPC call, outgoing pkg/http call,
+// SQL statement and queue job, linked into one trace per request:
+//
+//	OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector:4318
+//	OTEL_SERVICE_NAME=orders-api
+//
+// The framework wires everything when tracing is enabled:
+//
+//	tracing.Init(tracing.DefaultOptions())   // server / worker boot
+//	r.Use(tracing.Middleware())              // kernel, HTTP server spans
+//	db.Use(tracing.GormPlugin{})             // database.Connect, SQL spans
+//	queue.Use(tracing.JobMiddleware())       // job spans, parented to the dispatcher
+//
+// Application code creates its own spans with Tracer, and they nest under
+// the request's span through ctx:
+//
+//	ctx, span := tracing.Tracer().Start(ctx, "pricing.quote")
+//	defer span.End()
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/shashiranjanraj/kashvi/config"
+)
+
+// instrumentation is the tracer name used for Kashvi's own spans.
+const instrumentation = "github.com/shashiranjanraj/kashvi"
+
+// ─── Configuration ────────────────────────────────────────────────────────────
+
+// Options configures Init.
+type Options struct {
+	// Endpoint is the OTLP/HTTP collector URL, e.g.
+	// "http://otel-collector:4318". Empty disables tracing.
+	Endpoint string
+	// Headers are sent with every export (e.g. an API key for a SaaS backend).
+	Headers map[string]string
+	// ServiceName identifies this service in the tracing backend.
+	ServiceName string
+	// Environment is recorded as deployment.environment.name.
+	Environment string
+	// SampleRatio is the fraction of new traces recorded, 0–1. Calls that
+	// arrive with a sampled parent are always recorded.
+	SampleRatio float64
+}
+
+// DefaultOptions reads the standard OpenTelemetry variables:
+//
+//	OTEL_EXPORTER_OTLP_ENDPOINT    collector base URL (…/v1/traces is appended)
+//	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT  full traces URL, overrides the above
+//	OTEL_EXPORTER_OTLP_HEADERS     key=value,key2=value2
+//	OTEL_SERVICE_NAME              default: APP_NAME
+//	OTEL_TRACES_SAMPLER_ARG        sample ratio, default 1
+//	OTEL_SDK_DISABLED=true         turns tracing off
+func DefaultOptions() Options {
+	opts := Options{
+		ServiceName: config.Get("OTEL_SERVICE_NAME", config.AppName()),
+		Environment: config.AppEnv(),
+		SampleRatio: 1,
+	}
+	if strings.EqualFold(config.Get("OTEL_SDK_DISABLED", ""), "true") {
+		return opts
+	}
+	opts.Endpoint = config.Get("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	if opts.Endpoint == "" {
+		if base := config.Get("OTEL_EXPORTER_OTLP_ENDPOINT", ""); base != "" {
+			opts.Endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+	for _, kv := range strings.Split(config.Get("OTEL_EXPORTER_OTLP_HEADERS", ""), ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.TrimSpace(k) != "" {
+			if opts.Headers == nil {
+				opts.Headers = map[string]string{}
+			}
+			opts.Headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	if r, err := strconv.ParseFloat(config.Get("OTEL_TRACES_SAMPLER_ARG", ""), 64); err == nil && r >= 0 && r <= 1 {
+		opts.SampleRatio = r
+	}
+	return opts
+}
+
+// ─── Provider ─────────────────────────────────────────────────────────────────
+
+var (
+	enabled  atomic.Bool
+	provider atomic.Pointer[sdktrace.TracerProvider]
+)
+
+// Init installs an OTLP-exporting tracer provider and the W3C trace-context
+// and baggage propagators. It does nothing when opts.Endpoint is empty.
+// Call Shutdown before exiting so buffered spans are exported.
+func Init(opts Options) error {
+	if opts.Endpoint == "" {
+		return nil
+	}
+	exp, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(opts.Endpoint),
+		otlptracehttp.WithHeaders(opts.Headers),
+	)
+	if err != nil {
+		return fmt.Errorf("tracing: exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+		semconv.DeploymentEnvironmentName(opts.Environment),
+	))
+	if err != nil {
+		return fmt.Errorf("tracing: resource: %w", err)
+	}
+	Use(sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	))
+	return nil
+}
+
+// Use installs tp as the global tracer provider, with the W3C propagators,
+// and marks tracing enabled. Init calls it; tests pass a provider backed by
+// tracetest.SpanRecorder.
+func Use(tp *sdktrace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	provider.Store(tp)
+	enabled.Store(true)
+}
+
+// Enabled reports whether Init or Use installed a provider. The framework
+// only adds its instrumentation when it is.
+func Enabled() bool { return enabled.Load() }
+
+// Shutdown exports buffered spans and stops the provider. The server calls
+// it in the flush phase of graceful shutdown.
+func Shutdown(ctx context.Context) error {
+	tp := provider.Swap(nil)
+	if tp == nil {
+		return nil
+	}
+	enabled.Store(false)
+	if err := tp.Shutdown(ctx); err != nil {
+		return fmt.Errorf("tracing: shutdown: %w", err)
+	}
+	return nil
+}
+
+// Tracer returns the tracer for application spans.
+func Tracer() trace.Tracer { return otel.Tracer(instrumentation) }
+
+// TraceID returns the ID of the trace active in ctx, or "" when there is
+// none, e.g. to include in error responses or log lines.
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/shashiranjanraj/kashvi/pkg/database"
+	kashvihttp "github.com/shashiranjanraj/kashvi/pkg/http"
+	"github.com/shashiranjanraj/kashvi/pkg/queue"
+	"github.com/shashiranjanraj/kashvi/pkg/tracing"
+)
+
+var recorder = tracetest.NewSpanRecorder()
+
+func TestMain(m *testing.M) {
+	tracing.Use(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	tracing.InstrumentQueue()
+	os.Exit(m.Run())
+}
+
+// spansOf returns the ended spans of trace id.
+func spansOf(id trace.TraceID) map[string]sdktrace.ReadOnlySpan {
+	out := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		if s.SpanContext().TraceID() == id {
+			out[s.Name()] = s
+		}
+	}
+	return out
+}
+
+func TestHTTP_ServerAndClientSpans(t *testing.T) {
+	var gotParent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotParent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware())
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		kashvihttp.WithCtx(r.Context()).Get(upstream.URL).Send() //nolint:errcheck
+	})
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("traceparent", parent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	id, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spans := spansOf(id)
+	server, ok := spans["GET /users/{id}"]
+	if !ok {
+		t.Fatalf("no server span named after the route; got %v", spans)
+	}
+	if server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("server span parent = %s, want the caller's span", server.Parent().SpanID())
+	}
+	client, ok := spans["GET"]
+	if !ok || client.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Fatalf("client span missing or not a child of the server span: %v", spans)
+	}
+	if want := "00-" + id.String() + "-" + client.SpanContext().SpanID().String() + "-01"; gotParent != want {
+		t.Errorf("upstream traceparent = %q, want %q", gotParent, want)
+	}
+}
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+func TestGormPlugin(t *testing.T) {
+	db, err := database.OpenMemory("tracing_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close(db)
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&widget{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, span := tracing.Tracer().Start(context.Background(), "request")
+	var got []widget
+	db.WithContext(ctx).Where("name = ?", "secret").Find(&got)
+	span.End()
+
+	q, ok := spansOf(span.SpanContext().TraceID())["SELECT widgets"]
+	if !ok {
+		t.Fatal("no span for the query")
+	}
+	if q.Parent().SpanID() != span.SpanContext().SpanID() {
+		t.Error("query span is not a child of the request span")
+	}
+	var sql string
+	for _, a := range q.Attributes() {
+		if a.Key == "db.query.text" {
+			sql = a.Value.AsString()
+		}
+	}
+	if !strings.Contains(sql, "name = ?") || strings.Contains(sql, "secret") {
+		t.Errorf("db.query.text = %q, want parameterised SQL without bound values", sql)
+	}
+}
+
+type tracedJob struct{ N int }
+
+var jobCtx = make(chan context.Context, 1)
+
+func (tracedJob) Handle() error { return nil }
+
+func (tracedJob) HandleCtx(ctx context.Context) error {
+	jobCtx <- ctx
+	return nil
+}
+
+func TestJobMiddleware_ContinuesDispatchTrace(t *testing.T) {
+	queue.RegisterJobs(tracedJob{})
+	ctx, span := tracing.Tracer().Start(context.Background(), "request")
+	if err := queue.DispatchOnCtx(ctx, "traced", tracedJob{N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	span.End()
+
+	wctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	queue.Work(wctx, queue.WorkerOptions{Queues: []string{"traced"}, Concurrency: 1, MaxJobs: 1})
+
+	got := trace.SpanContextFromContext(<-jobCtx)
+	if got.TraceID() != span.SpanContext().TraceID() {
+		t.Fatalf("job trace = %s, want the dispatching request's %s", got.TraceID(), span.SpanContext().TraceID())
+	}
+	job, ok := spansOf(got.TraceID())["job tracing_test.tracedJob"]
+	if !ok || job.Parent().SpanID() != span.SpanContext().SpanID() {
+		t.Fatal("job span missing or not a child of the dispatching span")
+	}
+}
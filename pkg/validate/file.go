@@ -0,0 +1,238 @@
+package validate
+
+import (
+	"fmt"
+	"image"
+	// Decoders used by dimensions=.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// File rules apply to *multipart.FileHeader and []*multipart.FileHeader
+// fields (see bind.Form / ctx.BindForm):
+//
+//	file                   value must be an uploaded file
+//	image                  content must sniff as an image (png, jpeg, gif, webp, bmp)
+//	mimes=png,jpg,pdf      sniffed content type must match one of the extensions
+//	                       or MIME types (image/* wildcards allowed)
+//	max_size=5MB           upload no larger than the size (B, KB, MB, GB)
+//	min_size=1KB           upload at least the size
+//	dimensions=min_width=200,max_height=1000,ratio=3/2
+//	                       image constraints: width, height, min_/max_ variants, ratio
+//
+// The content type is always sniffed from the bytes, never taken from the
+// client's Content-Type header or file name.
+
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// isFileRule reports whether key is one of the file rules.
+func isFileRule(key string) bool {
+	switch key {
+	case "file", "image", "mimes", "max_size", "min_size", "dimensions":
+		return true
+	}
+	return false
+}
+
+// applyFileRule checks every uploaded file in v against one rule.
+func applyFileRule(key, param, field string, v reflect.Value) string {
+	var files []*multipart.FileHeader
+	switch {
+	case v.Type() == fileHeaderType:
+		if !v.IsNil() {
+			files = append(files, v.Interface().(*multipart.FileHeader))
+		}
+	case v.Kind() == reflect.Slice && v.Type().Elem() == fileHeaderType:
+		files = v.Interface().([]*multipart.FileHeader)
+	default:
+		return fmt.Sprintf("The %s must be a file.", field)
+	}
+	for _, fh := range files {
+		if msg := checkFile(key, param, field, fh); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+func checkFile(key, param, field string, fh *multipart.FileHeader) string {
+	switch key {
+	case "file":
+		return ""
+	case "max_size":
+		if limit, err := parseSize(param); err == nil && fh.Size > limit {
+			return fmt.Sprintf("The %s must not be greater than %s.", field, param)
+		}
+		return ""
+	case "min_size":
+		if limit, err := parseSize(param); err == nil && fh.Size < limit {
+			return fmt.Sprintf("The %s must be at least %s.", field, param)
+		}
+		return ""
+	}
+
+	ct, err := sniff(fh)
+	if err != nil {
+		return fmt.Sprintf("The %s failed to upload.", field)
+	}
+	switch key {
+	case "image":
+		if !strings.HasPrefix(ct, "image/") {
+			return fmt.Sprintf("The %s must be an image.", field)
+		}
+	case "mimes":
+		if !mimeAllowed(ct, fh.Filename, strings.Split(param, ",")) {
+			return fmt.Sprintf("The %s must be a file of type: %s.", field, param)
+		}
+	case "dimensions":
+		cfg, err := imageConfig(fh)
+		if err != nil {
+			return fmt.Sprintf("The %s must be an image.", field)
+		}
+		if !dimensionsOK(cfg, param) {
+			return fmt.Sprintf("The %s has invalid image dimensions.", field)
+		}
+	}
+	return ""
+}
+
+// sniff returns the media type detected from the first 512 bytes.
+func sniff(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	mt, _, _ := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	return mt, nil
+}
+
+// mimeAllowed matches the sniffed type against extensions and MIME types.
+// Text formats (csv, json, …) sniff as text/plain or octet-stream, so for
+// those the file extension decides — but never for binary families, where
+// a renamed file must not pass as an image or PDF.
+func mimeAllowed(sniffed, filename string, allowed []string) bool {
+	generic := sniffed == "text/plain" || sniffed == "application/octet-stream"
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		want := a
+		if !strings.Contains(a, "/") {
+			want, _, _ = mime.ParseMediaType(mime.TypeByExtension("." + a))
+		}
+		switch {
+		case want == sniffed:
+			return true
+		case strings.HasSuffix(want, "/*") && strings.HasPrefix(sniffed, strings.TrimSuffix(want, "*")):
+			return true
+		case generic && a == ext && !binaryFamily(want):
+			return true
+		}
+	}
+	return false
+}
+
+func binaryFamily(mt string) bool {
+	for _, p := range []string{"image/", "audio/", "video/", "font/", "application/pdf", "application/zip"} {
+		if strings.HasPrefix(mt, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func imageConfig(fh *multipart.FileHeader) (image.Config, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	return cfg, err
+}
+
+// dimensionsOK evaluates "min_width=200,max_height=1000,ratio=3/2".
+func dimensionsOK(cfg image.Config, param string) bool {
+	for _, c := range strings.Split(param, ",") {
+		k, val, _ := strings.Cut(strings.TrimSpace(c), "=")
+		if k == "ratio" {
+			num, den, ok := strings.Cut(val, "/")
+			want := mustParseFloat(num)
+			if ok {
+				want /= mustParseFloat(den)
+			}
+			got := float64(cfg.Width) / float64(cfg.Height)
+			if cfg.Height == 0 || want <= 0 || got-want > 0.01 || want-got > 0.01 {
+				return false
+			}
+			continue
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			continue
+		}
+		w, h := cfg.Width, cfg.Height
+		switch k {
+		case "width":
+			if w != n {
+				return false
+			}
+		case "height":
+			if h != n {
+				return false
+			}
+		case "min_width":
+			if w < n {
+				return false
+			}
+		case "max_width":
+			if w > n {
+				return false
+			}
+		case "min_height":
+			if h < n {
+				return false
+			}
+		case "max_height":
+			if h > n {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parseSize parses "512", "500KB", "5MB", "1GB" (binary multiples).
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		n      int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, mult = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.n
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(f * float64(mult)), nil
+}
@@ -0,0 +1,58 @@
+package validate
+
+import (
+	"context"
+	"reflect"
+)
+
+// Validator is implemented by binding structs that need rules the tag
+// system cannot express — invariants across several fields, or business
+// rules that depend on the request context:
+//
+//	func (in *BookingInput) Validate(ctx context.Context) map[string]string {
+//	    errs := map[string]string{}
+//	    if !in.End.After(in.Start) {
+//	        errs["end_date"] = "The end_date must be after start_date."
+//	    }
+//	    return errs
+//	}
+//
+// Validate runs after the tag rules, even when some of them failed, so the
+// client sees every problem at once. A tag error for a field wins over a
+// hook error for the same field.
+type Validator interface {
+	Validate(ctx context.Context) map[string]string
+}
+
+// StructCtx is Struct followed by the Validator hook, if v implements it
+// (on either the value or the pointer receiver).
+func StructCtx(ctx context.Context, v interface{}) map[string]string {
+	errs := tagErrors(ctx, v)
+
+	hook, ok := asValidator(v)
+	if !ok {
+		return errs
+	}
+	for field, msg := range hook.Validate(ctx) {
+		if _, exists := errs[field]; !exists && msg != "" {
+			errs[field] = msg
+		}
+	}
+	return errs
+}
+
+func asValidator(v interface{}) (Validator, bool) {
+	if hv, ok := v.(Validator); ok {
+		return hv, true
+	}
+	// A struct passed by value whose hook has a pointer receiver: validate
+	// an addressable copy.
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr {
+		return nil, false
+	}
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	hv, ok := ptr.Interface().(Validator)
+	return hv, ok
+}
@@ -0,0 +1,234 @@
+package validate
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1" //nolint:gosec // required by the Pwned Passwords range API
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/shashiranjanraj/kashvi/config"
+	kashvihttp "github.com/shashiranjanraj/kashvi/pkg/http"
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// ─── Strength ────────────────────────────────────────────────────────────────
+
+// PasswordPolicy describes what the `password` rule accepts.
+type PasswordPolicy struct {
+	MinLength    int
+	Upper        bool // at least one upper-case letter
+	Lower        bool // at least one lower-case letter
+	Digit        bool // at least one digit
+	Symbol       bool // at least one non-alphanumeric character
+	RejectCommon bool // refuse entries of the common-password dictionary
+}
+
+var (
+	policiesMu sync.RWMutex
+	policies   = map[string]PasswordPolicy{
+		"default": {MinLength: 8, RejectCommon: true},
+		"strong":  {MinLength: 10, Upper: true, Lower: true, Digit: true, Symbol: true, RejectCommon: true},
+	}
+)
+
+// SetPasswordPolicy defines or overrides a named policy for password=<name>.
+// "default" is used by a bare `password` rule.
+func SetPasswordPolicy(name string, p PasswordPolicy) {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	policies[name] = p
+}
+
+// CheckPassword returns the first way plain violates the named policy, or
+// "" when it passes. Use it outside struct validation, e.g. in a
+// change-password flow that reads the value from elsewhere.
+func CheckPassword(plain, policy string) string {
+	return passwordMessage("password", plain, policy)
+}
+
+func passwordMessage(field, plain, policy string) string {
+	if policy == "" {
+		policy = "default"
+	}
+	policiesMu.RLock()
+	p, ok := policies[policy]
+	policiesMu.RUnlock()
+	if !ok {
+		return fmt.Sprintf("The %s has an unknown password policy %q.", field, policy)
+	}
+
+	if len([]rune(plain)) < p.MinLength {
+		return fmt.Sprintf("The %s must be at least %d characters.", field, p.MinLength)
+	}
+	var upper, lower, digit, symbol bool
+	for _, c := range plain {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	switch {
+	case p.Upper && !upper, p.Lower && !lower:
+		return fmt.Sprintf("The %s must contain both upper-case and lower-case letters.", field)
+	case p.Digit && !digit:
+		return fmt.Sprintf("The %s must contain at least one number.", field)
+	case p.Symbol && !symbol:
+		return fmt.Sprintf("The %s must contain at least one symbol.", field)
+	case p.RejectCommon && isCommonPassword(plain):
+		return fmt.Sprintf("The %s is too common. Please choose a different password.", field)
+	}
+	return ""
+}
+
+// isCommonPassword checks the dictionary, also after stripping the digits
+// and symbols people bolt on to satisfy class rules ("Password1!").
+func isCommonPassword(plain string) bool {
+	lower := strings.ToLower(plain)
+	if _, ok := commonPasswords[lower]; ok {
+		return true
+	}
+	core := strings.TrimRightFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) })
+	core = strings.TrimLeftFunc(core, func(r rune) bool { return !unicode.IsLetter(r) })
+	_, ok := commonPasswords[core]
+	return ok && core != ""
+}
+
+// commonPasswords holds the most used passwords from public breach corpora.
+var commonPasswords = func() map[string]struct{} {
+	list := []string{
+		"123456", "password", "12345678", "qwerty", "123456789", "12345", "1234",
+		"111111", "1234567", "dragon", "123123", "baseball", "abc123", "football",
+		"monkey", "letmein", "696969", "shadow", "master", "666666", "qwertyuiop",
+		"123321", "mustang", "1234567890", "michael", "654321", "superman",
+		"1qaz2wsx", "7777777", "121212", "000000", "qazwsx", "123qwe", "killer",
+		"trustno1", "jordan", "jennifer", "zxcvbnm", "asdfgh", "hunter", "buster",
+		"soccer", "harley", "batman", "andrew", "tigger", "sunshine", "iloveyou",
+		"2000", "charlie", "robert", "thomas", "hockey", "ranger", "daniel",
+		"starwars", "klaster", "112233", "george", "computer", "michelle",
+		"jessica", "pepper", "1111", "zxcvbn", "555555", "11111111", "131313",
+		"freedom", "777777", "pass", "maggie", "159753", "aaaaaa", "ginger",
+		"princess", "joshua", "cheese", "amanda", "summer", "love", "ashley",
+		"nicole", "chelsea", "biteme", "matthew", "access", "yankees", "987654321",
+		"dallas", "austin", "thunder", "taylor", "matrix", "welcome", "admin",
+		"administrator", "passw0rd", "p@ssw0rd", "p@ssword", "qwerty123",
+		"changeme", "secret", "login", "whatever", "default", "guest", "root",
+		"test", "letmein1", "football1", "baseball1", "abcdef", "abcd1234",
+		"qwe123", "1q2w3e4r", "1q2w3e", "zaq12wsx", "asdf1234", "asdfghjkl",
+		"password1", "password123", "welcome1", "iloveyou1", "monkey1", "dragon1",
+	}
+	m := make(map[string]struct{}, len(list))
+	for _, p := range list {
+		m[p] = struct{}{}
+	}
+	return m
+}()
+
+// ─── Breach check (Pwned Passwords) ──────────────────────────────────────────
+
+// PwnedRangeURL is the k-anonymity range endpoint. Only the first five hex
+// characters of the SHA-1 hash leave the process.
+var PwnedRangeURL = "https://api.pwnedpasswords.com/range/"
+
+const (
+	pwnedTimeout      = 3 * time.Second
+	pwnedCacheTTL     = 24 * time.Hour
+	pwnedCacheEntries = 10000
+)
+
+type pwnedRange struct {
+	counts  map[string]int // SHA-1 suffix → breach count
+	expires time.Time
+}
+
+var (
+	pwnedMu    sync.Mutex
+	pwnedCache = map[string]pwnedRange{}
+)
+
+// Pwned reports how many times plain appears in the Pwned Passwords corpus.
+// The lookup goes through pkg/http and is bound to ctx. Range responses are
+// cached per prefix for 24h.
+func Pwned(ctx context.Context, plain string) (int, error) {
+	sum := sha1.Sum([]byte(plain)) //nolint:gosec
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	pwnedMu.Lock()
+	entry, ok := pwnedCache[prefix]
+	pwnedMu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.counts[suffix], nil
+	}
+
+	resp, err := kashvihttp.WithCtx(ctx).Get(PwnedRangeURL+prefix).
+		Header("Accept", "text/plain").
+		Header("Add-Padding", "true").
+		Timeout(pwnedTimeout).
+		Send()
+	if err != nil {
+		return 0, fmt.Errorf("validate: pwned passwords: %w", err)
+	}
+	if !resp.OK() {
+		return 0, fmt.Errorf("validate: pwned passwords: status %d", resp.StatusCode)
+	}
+
+	counts := make(map[string]int)
+	sc := bufio.NewScanner(strings.NewReader(resp.Text()))
+	for sc.Scan() {
+		s, c, ok := strings.Cut(strings.TrimSpace(sc.Text()), ":")
+		if !ok {
+			continue
+		}
+		if n, _ := strconv.Atoi(c); n > 0 { // padding lines have count 0
+			counts[s] = n
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return 0, fmt.Errorf("validate: pwned passwords: %w", err)
+	}
+
+	pwnedMu.Lock()
+	if len(pwnedCache) >= pwnedCacheEntries {
+		pwnedCache = map[string]pwnedRange{}
+	}
+	pwnedCache[prefix] = pwnedRange{counts: counts, expires: time.Now().Add(pwnedCacheTTL)}
+	pwnedMu.Unlock()
+
+	return counts[suffix], nil
+}
+
+// uncompromisedMessage implements `uncompromised[=threshold]`. It fails
+// open: when the API is unreachable the password is accepted and a warning
+// is logged, so an outage never blocks sign-ups. PASSWORD_BREACH_CHECK=false
+// turns the check off (offline development, tests). The lookup is bound to
+// the validation's ctx, so it ends with the request that triggered it.
+func uncompromisedMessage(ctx context.Context, field, plain, param string) string {
+	if plain == "" || config.Get("PASSWORD_BREACH_CHECK", "true") == "false" {
+		return ""
+	}
+	threshold := 1
+	if n, err := strconv.Atoi(param); err == nil && n > 0 {
+		threshold = n
+	}
+	count, err := Pwned(ctx, plain)
+	if err != nil {
+		logger.Warn("validate: breach check skipped", "error", err)
+		return ""
+	}
+	if count >= threshold {
+		return fmt.Sprintf("The %s has appeared in a data breach. Please choose a different %s.", field, field)
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package validate
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Sanitizer normalises a single string value.
+type Sanitizer func(string) string
+
+var (
+	sanitizersMu sync.RWMutex
+	sanitizers   = map[string]Sanitizer{
+		"trim":            strings.TrimSpace,
+		"lower":           strings.ToLower,
+		"upper":           strings.ToUpper,
+		"title":           titleCase,
+		"squish":          squish,
+		"strip_tags":      stripTags,
+		"normalize_phone": normalizePhone,
+	}
+)
+
+// RegisterSanitizer adds (or replaces) a named step usable in `sanitize`
+// tags.
+//
+//	validate.RegisterSanitizer("slug", func(s string) string { ... })
+func RegisterSanitizer(name string, fn Sanitizer) {
+	sanitizersMu.Lock()
+	defer sanitizersMu.Unlock()
+	sanitizers[name] = fn
+}
+
+// Sanitize applies the `sanitize` tag of every string field in v (which
+// must be a pointer), left to right:
+//
+//	type Input struct {
+//	    Email string `json:"email" sanitize:"trim,lower" validate:"required,email"`
+//	    Phone string `json:"phone" sanitize:"normalize_phone"`
+//	}
+//
+// string, *string and []string fields are rewritten in place; nested
+// structs (and slices of them) are walked. Unknown step names are ignored.
+// bind.JSON and ctx.BindJSON call this before validation.
+func Sanitize(v interface{}) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return
+	}
+	sanitizeValue(rv.Elem())
+}
+
+func sanitizeValue(rv reflect.Value) {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if !rv.IsNil() {
+			sanitizeValue(rv.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < rv.Len(); i++ {
+			sanitizeValue(rv.Index(i))
+		}
+	case reflect.Struct:
+		if rv.Type() == timeType {
+			return
+		}
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			f := rt.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			fv := rv.Field(i)
+			if tag := f.Tag.Get("sanitize"); tag != "" {
+				applySanitizers(fv, strings.Split(tag, ","))
+				continue
+			}
+			sanitizeValue(fv)
+		}
+	}
+}
+
+func applySanitizers(fv reflect.Value, steps []string) {
+	switch {
+	case fv.Kind() == reflect.String:
+		fv.SetString(runSanitizers(fv.String(), steps))
+	case fv.Kind() == reflect.Ptr && !fv.IsNil() && fv.Elem().Kind() == reflect.String:
+		fv.Elem().SetString(runSanitizers(fv.Elem().String(), steps))
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String:
+		for i := 0; i < fv.Len(); i++ {
+			fv.Index(i).SetString(runSanitizers(fv.Index(i).String(), steps))
+		}
+	}
+}
+
+func runSanitizers(s string, steps []string) string {
+	sanitizersMu.RLock()
+	defer sanitizersMu.RUnlock()
+	for _, step := range steps {
+		if fn, ok := sanitizers[strings.TrimSpace(step)]; ok {
+			s = fn(s)
+		}
+	}
+	return s
+}
+
+// ─── Built-in steps ──────────────────────────────────────────────────────────
+
+var tagRE = regexp.MustCompile(`<[^>]*>`)
+
+func stripTags(s string) string { return tagRE.ReplaceAllString(s, "") }
+
+// squish trims and collapses internal runs of whitespace to one space.
+func squish(s string) string { return strings.Join(strings.Fields(s), " ") }
+
+// titleCase upper-cases the first letter of each word and lower-cases the rest.
+func titleCase(s string) string {
+	r := []rune(strings.ToLower(s))
+	start := true
+	for i, c := range r {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) || c == '\'' {
+			if start {
+				r[i] = unicode.ToUpper(c)
+			}
+			start = false
+			continue
+		}
+		start = true
+	}
+	return string(r)
+}
+
+// normalizePhone keeps digits and a leading '+', turning the international
+// "00" prefix into '+': " (020) 7946-0958 " → "02079460958",
+// "0049 30 1234" → "+49301234".
+func normalizePhone(s string) string {
+	s = strings.TrimSpace(s)
+	var b strings.Builder
+	for i, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case c == '+' && i == 0:
+			b.WriteRune(c)
+		}
+	}
+	out := b.String()
+	if strings.HasPrefix(out, "00") {
+		out = "+" + out[2:]
+	}
+	return out
+}
@@ -29,8 +29,21 @@
 //	not_in=a,b,c        value must NOT be one of the listed items
 //	regex=pattern       value must match the regex (avoid commas in pattern)
 //	confirmed           value must equal a sibling field named <field>_confirmation
-//	before=date         value (as date) must be before given date
-//	after=date          value (as date) must be after given date
+//	same=field          value must equal the sibling field
+//	different=field     value must differ from the sibling field
+//	required_with=field required when the sibling field is not empty
+//	required_if=field,v required when the sibling field equals v
+//	before=date|field   value (as date) must be before given date or sibling field
+//	after=date|field    value (as date) must be after given date or sibling field
+//	password[=policy]   length, character classes and common-password dictionary
+//	                    (policies: default, strong; see SetPasswordPolicy)
+//	uncompromised[=n]   not seen n+ times in Pwned Passwords (k-anonymity API)
+//
+// File uploads (*multipart.FileHeader fields) support file, image,
+// mimes=, max_size=, min_size= and dimensions=; see file.go.
+//
+// Invariants the tags cannot express go in a Validate(ctx) method on the
+// struct; see Validator.
 //
 // Example:
 //
@@ -44,6 +57,7 @@
 package validate
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -58,9 +72,16 @@ import (
 
 // ─── Public API ───────────────────────────────────────────────────────────────
 
-// Struct validates all exported fields of v that carry a `validate` tag.
+// Struct validates all exported fields of v that carry a `validate` tag,
+// then runs v's Validator hook (with context.Background()) if it has one.
 // Returns a map of fieldName → error message; empty map means no errors.
 func Struct(v interface{}) map[string]string {
+	return StructCtx(context.Background(), v)
+}
+
+// tagErrors applies the `validate` tag rules only. ctx bounds rules that do
+// I/O (uncompromised).
+func tagErrors(ctx context.Context, v interface{}) map[string]string {
 	errs := make(map[string]string)
 	rv := reflect.ValueOf(v)
 	if rv.Kind() == reflect.Ptr {
@@ -92,7 +113,7 @@ func Struct(v interface{}) map[string]string {
 			if rule == "nullable" {
 				continue
 			}
-			if msg := applyRule(rule, name, value, rv); msg != "" {
+			if msg := applyRule(ctx, rule, name, value, rv); msg != "" {
 				errs[name] = msg
 				break // first failing rule per field
 			}
@@ -107,9 +128,12 @@ func HasErrors(errs map[string]string) bool { return len(errs) > 0 }
 
 // ─── Core dispatcher ──────────────────────────────────────────────────────────
 
-func applyRule(rule, field string, v reflect.Value, parent reflect.Value) string {
-	raw := fmt.Sprintf("%v", v.Interface())
+func applyRule(ctx context.Context, rule, field string, v reflect.Value, parent reflect.Value) string {
 	key, param, _ := strings.Cut(rule, "=")
+	if isFileRule(key) {
+		return applyFileRule(key, param, field, v)
+	}
+	raw := fmt.Sprintf("%v", v.Interface())
 
 	switch key {
 	// ── Presence ──────────────────────────────────────────────────────
@@ -265,6 +289,12 @@ func applyRule(rule, field string, v reflect.Value, parent reflect.Value) string
 			}
 		}
 
+	// ── Passwords ─────────────────────────────────────────────────────
+	case "password":
+		return passwordMessage(field, raw, param)
+	case "uncompromised":
+		return uncompromisedMessage(ctx, field, raw, param)
+
 	// ── Pattern ───────────────────────────────────────────────────────
 	case "regex":
 		re, err := regexp.Compile(param)
@@ -283,16 +313,40 @@ func applyRule(rule, field string, v reflect.Value, parent reflect.Value) string
 			return fmt.Sprintf("The %s confirmation does not match.", field)
 		}
 
+	case "same":
+		other, ok := sibling(parent, param)
+		if !ok || fmt.Sprintf("%v", other.Interface()) != raw {
+			return fmt.Sprintf("The %s and %s must match.", field, param)
+		}
+	case "different":
+		other, ok := sibling(parent, param)
+		if ok && fmt.Sprintf("%v", other.Interface()) == raw {
+			return fmt.Sprintf("The %s and %s must be different.", field, param)
+		}
+	case "required_with":
+		other, ok := sibling(parent, param)
+		if ok && !isEmpty(other) && isEmpty(v) {
+			return fmt.Sprintf("The %s field is required when %s is present.", field, param)
+		}
+	case "required_if":
+		name, want, _ := strings.Cut(param, ",")
+		other, ok := sibling(parent, name)
+		if ok && fmt.Sprintf("%v", other.Interface()) == want && isEmpty(v) {
+			return fmt.Sprintf("The %s field is required when %s is %s.", field, name, want)
+		}
+
 	// ── Date comparison ───────────────────────────────────────────────
+	// The parameter is a date literal or the name of a sibling field
+	// (after=start_date).
 	case "before":
-		t1, err1 := parseDate(raw)
-		t2, err2 := parseDate(param)
+		t1, err1 := dateOf(v)
+		t2, err2 := dateParam(parent, param)
 		if err1 != nil || err2 != nil || !t1.Before(t2) {
 			return fmt.Sprintf("The %s must be a date before %s.", field, param)
 		}
 	case "after":
-		t1, err1 := parseDate(raw)
-		t2, err2 := parseDate(param)
+		t1, err1 := dateOf(v)
+		t2, err2 := dateParam(parent, param)
 		if err1 != nil || err2 != nil || !t1.After(t2) {
 			return fmt.Sprintf("The %s must be a date after %s.", field, param)
 		}
@@ -323,6 +377,29 @@ func parseDate(s string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("cannot parse %q as date", s)
 }
 
+var timeType = reflect.TypeOf(time.Time{})
+
+// dateOf reads v as a date: a time.Time (or pointer to one) or a string in
+// one of dateLayouts.
+func dateOf(v reflect.Value) (time.Time, error) {
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time), nil
+	}
+	return parseDate(fmt.Sprintf("%v", v.Interface()))
+}
+
+// dateParam resolves a before=/after= parameter: a sibling field if one has
+// that name, otherwise a date literal.
+func dateParam(parent reflect.Value, param string) (time.Time, error) {
+	if other, ok := sibling(parent, param); ok {
+		return dateOf(other)
+	}
+	return parseDate(param)
+}
+
 func isEmpty(v reflect.Value) bool {
 	switch v.Kind() {
 	case reflect.String:
@@ -390,7 +467,7 @@ func splitRules(tag string) []string {
 	var current strings.Builder
 	inParam := false // true when we are inside a multi-value param (in=, not_in=, between=)
 
-	multiValuePrefixes := []string{"in=", "not_in=", "between="}
+	multiValuePrefixes := []string{"in=", "not_in=", "between=", "required_if=", "mimes=", "dimensions="}
 
 	for i := 0; i < len(tag); i++ {
 		ch := tag[i]
@@ -439,10 +516,17 @@ func looksLikeNewRule(s string) bool {
 		"boolean", "date", "alpha", "alpha_num", "alpha_dash", "numeric",
 		"integer", "confirmed", "regex=", "min=", "max=", "size=",
 		"gt=", "gte=", "lt=", "lte=", "digits=", "before=", "after=",
-		"in=", "not_in=", "between=",
+		"in=", "not_in=", "between=", "same=", "different=",
+		"required_with=", "required_if=", "file", "image", "mimes=",
+		"max_size=", "min_size=", "dimensions=", "password", "uncompromised",
 	}
 	for _, k := range known {
-		if strings.HasPrefix(s, k) {
+		if !strings.HasPrefix(s, k) {
+			continue
+		}
+		// Bare keywords must be whole tokens, so "image/png" inside
+		// mimes= is not mistaken for the image rule.
+		if strings.HasSuffix(k, "=") || len(s) == len(k) || s[len(k)] == ',' {
 			return true
 		}
 	}
@@ -458,6 +542,20 @@ func hasRule(rules []string, target string) bool {
 	return false
 }
 
+// sibling finds the field of parent whose json name (or Go name) is name.
+func sibling(parent reflect.Value, name string) (reflect.Value, bool) {
+	if parent.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	rt := parent.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		if f := rt.Field(i); jsonFieldName(f) == name || f.Name == name {
+			return parent.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
 // findSiblingByJSONSuffix looks for a field in parent whose json name
 // ends with the given suffix (e.g. "_confirmation").
 // Used by 'confirmed': the field being validated IS the _confirmation field;
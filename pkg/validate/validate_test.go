@@ -1,8 +1,14 @@
 package validate_test
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/shashiranjanraj/kashvi/pkg/reqid"
 	"github.com/shashiranjanraj/kashvi/pkg/validate"
 )
 
@@ -160,3 +166,209 @@ func TestAlphaDashRule(t *testing.T) {
 		t.Error("expected alpha_dash to fail for spaces/punctuation")
 	}
 }
+
+type bookingInput struct {
+	Start   time.Time `json:"start_date" validate:"required"`
+	End     time.Time `json:"end_date"   validate:"required,after=start_date"`
+	Guests  int       `json:"guests"     validate:"required,gte=1"`
+	Rooms   int       `json:"rooms"`
+	Payment string    `json:"payment"    validate:"required,in=card,invoice"`
+	VATID   string    `json:"vat_id"     validate:"required_if=payment,invoice"`
+}
+
+func (b *bookingInput) Validate(ctx context.Context) map[string]string {
+	errs := map[string]string{}
+	if b.Guests > b.Rooms*4 {
+		errs["guests"] = "At most 4 guests per room."
+	}
+	if ctx.Value(ctxKey{}) == "closed" {
+		errs["start_date"] = "Bookings are closed."
+	}
+	return errs
+}
+
+type ctxKey struct{}
+
+func TestCrossFieldRules(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	in := bookingInput{Start: start, End: start.AddDate(0, 0, -1), Guests: 2, Rooms: 1, Payment: "invoice"}
+
+	errs := validate.Struct(&in)
+	if _, ok := errs["end_date"]; !ok {
+		t.Errorf("expected end_date error, got %v", errs)
+	}
+	if _, ok := errs["vat_id"]; !ok {
+		t.Errorf("expected vat_id error, got %v", errs)
+	}
+
+	in.End, in.VATID = start.AddDate(0, 0, 3), "DE123"
+	if errs := validate.Struct(&in); validate.HasErrors(errs) {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+}
+
+func TestValidatorHook(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	in := bookingInput{Start: start, End: start.AddDate(0, 0, 1), Guests: 9, Rooms: 2, Payment: "card"}
+
+	// Passed by value: the pointer-receiver hook still runs.
+	errs := validate.StructCtx(context.WithValue(context.Background(), ctxKey{}, "closed"), in)
+	if errs["guests"] != "At most 4 guests per room." {
+		t.Errorf("guests = %q", errs["guests"])
+	}
+	if errs["start_date"] != "Bookings are closed." {
+		t.Errorf("start_date = %q", errs["start_date"])
+	}
+
+	// Tag errors win over hook errors for the same field.
+	in.Guests = 0
+	if errs := validate.Struct(&in); errs["guests"] != "The guests field is required." {
+		t.Errorf("guests = %q", errs["guests"])
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	type address struct {
+		City string `sanitize:"squish,title"`
+	}
+	nick := "  <b>Neo</b> "
+	in := struct {
+		Email   string   `sanitize:"trim,lower"`
+		Name    string   `sanitize:"title"`
+		Phone   string   `sanitize:"normalize_phone"`
+		Nick    *string  `sanitize:"strip_tags,trim"`
+		Tags    []string `sanitize:"trim,upper"`
+		Address address
+		Raw     string
+	}{
+		Email:   "  John@Example.COM ",
+		Name:    "mary-jane o'neil",
+		Phone:   "0044 (20) 7946-0958",
+		Nick:    &nick,
+		Tags:    []string{" go ", "web"},
+		Address: address{City: "  new   york "},
+		Raw:     "  untouched ",
+	}
+	validate.Sanitize(&in)
+
+	checks := map[string][2]string{
+		"email": {in.Email, "john@example.com"},
+		"name":  {in.Name, "Mary-Jane O'neil"},
+		"phone": {in.Phone, "+442079460958"},
+		"nick":  {*in.Nick, "Neo"},
+		"tags":  {in.Tags[0] + in.Tags[1], "GOWEB"},
+		"city":  {in.Address.City, "New York"},
+		"raw":   {in.Raw, "  untouched "},
+	}
+	for name, c := range checks {
+		if c[0] != c[1] {
+			t.Errorf("%s = %q, want %q", name, c[0], c[1])
+		}
+	}
+}
+
+func TestPasswordRule(t *testing.T) {
+	cases := map[string]string{
+		"short":          "The password must be at least 10 characters.",
+		"alllowercase1!": "The password must contain both upper-case and lower-case letters.",
+		"NoDigitsHere!":  "The password must contain at least one number.",
+		"NoSymbols123A":  "The password must contain at least one symbol.",
+		"Password123!":   "The password is too common. Please choose a different password.",
+		"c0rrect-Horse":  "",
+	}
+	for pw, want := range cases {
+		errs := validate.Struct(struct {
+			Password string `json:"password" validate:"required,password=strong"`
+		}{pw})
+		if errs["password"] != want {
+			t.Errorf("%q: got %q, want %q", pw, errs["password"], want)
+		}
+	}
+}
+
+func TestUncompromised(t *testing.T) {
+	// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/range/5BAA6" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		fmt.Fprint(w, "1E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\r\n0000000000000000000000000000000000A:0\r\n")
+	}))
+	defer srv.Close()
+	old := validate.PwnedRangeURL
+	validate.PwnedRangeURL = srv.URL + "/range/"
+	defer func() { validate.PwnedRangeURL = old }()
+
+	type input struct {
+		Password string `json:"password" validate:"uncompromised"`
+	}
+	if errs := validate.Struct(input{"password"}); errs["password"] == "" {
+		t.Error("breached password accepted")
+	}
+	if errs := validate.Struct(input{"password"}); errs["password"] == "" || calls != 1 {
+		t.Errorf("expected cached range (calls = %d)", calls)
+	}
+
+	// Network failure fails open.
+	validate.PwnedRangeURL = "http://127.0.0.1:1/range/"
+	if errs := validate.Struct(input{"another-secret"}); validate.HasErrors(errs) {
+		t.Errorf("expected fail-open, got %v", errs)
+	}
+}
+
+func TestUncompromisedUsesValidationContext(t *testing.T) {
+	var gotID string
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		gotID = r.Header.Get("X-Request-ID")
+	}))
+	defer srv.Close()
+	old := validate.PwnedRangeURL
+	validate.PwnedRangeURL = srv.URL + "/range/"
+	defer func() { validate.PwnedRangeURL = old }()
+
+	type input struct {
+		Password string `json:"password" validate:"uncompromised"`
+	}
+	ctx := reqid.WithValue(context.Background(), "req-249")
+	if errs := validate.StructCtx(ctx, input{"correct horse battery"}); validate.HasErrors(errs) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if gotID != "req-249" {
+		t.Errorf("X-Request-ID = %q, want the lookup bound to the validation context", gotID)
+	}
+
+	// A request that has already ended does not wait on the lookup.
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if errs := validate.StructCtx(cancelled, input{"staple battery horse"}); validate.HasErrors(errs) {
+		t.Errorf("expected fail-open, got %v", errs)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want no lookup for the cancelled context", calls)
+	}
+}
+
+func TestFieldViolations(t *testing.T) {
+	type input struct {
+		Email string `json:"email" validate:"required,email"`
+		Name  string `json:"name" validate:"required"`
+	}
+	if err := validate.Check(context.Background(), &input{Email: "a@example.com", Name: "A"}); err != nil {
+		t.Fatalf("valid input: %v", err)
+	}
+	err := validate.Check(context.Background(), &input{Email: "nope"})
+	v, ok := validate.AsViolations(fmt.Errorf("create user: %w", err))
+	if !ok || len(v) != 2 || v["email"] == "" || v["name"] == "" {
+		t.Fatalf("AsViolations = %v, %v; want email and name", v, ok)
+	}
+	if got := v.Fields(); got[0] != "email" || got[1] != "name" {
+		t.Errorf("Fields() = %v, want sorted", got)
+	}
+	if _, ok := validate.AsViolations(fmt.Errorf("other")); ok {
+		t.Error("AsViolations matched an unrelated error")
+	}
+}
@@ -0,0 +1,68 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ─── Field violations ─────────────────────────────────────────────────────────
+
+// FieldViolations maps each failed field (its JSON name) to a message. It is
+// the one validation error shape Kashvi uses on every transport:
+//
+//	HTTP       422 {"status": 422, "message": "Validation failed", "errors": {field: message}}
+//	gRPC       INVALID_ARGUMENT with a google.rpc.BadRequest detail, one FieldViolation per field
+//	WebSocket  {"type": "error", "payload": {"error": "Validation failed", "errors": {field: message}}}
+//
+// FieldViolations is an error, so services can return it from any layer and
+// leave rendering to the transport (ctx.ValidationError, grpc.InvalidArgument,
+// ws.Client.SendViolations; gRPC and WebSocket RPC handlers convert it when
+// they return it):
+//
+//	if err := validate.Check(ctx, order); err != nil {
+//	    return nil, err
+//	}
+type FieldViolations map[string]string
+
+// Message is the summary sent with violations on every transport.
+const Message = "Validation failed"
+
+// Error lists the violations in field order.
+func (v FieldViolations) Error() string {
+	parts := make([]string, 0, len(v))
+	for _, f := range v.Fields() {
+		parts = append(parts, f+": "+v[f])
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Fields returns the failed field names, sorted.
+func (v FieldViolations) Fields() []string {
+	fields := make([]string, 0, len(v))
+	for f := range v {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Check is StructCtx returning an error: nil when v is valid, otherwise
+// FieldViolations.
+func Check(ctx context.Context, v interface{}) error {
+	if errs := StructCtx(ctx, v); len(errs) > 0 {
+		return FieldViolations(errs)
+	}
+	return nil
+}
+
+// AsViolations reports whether err is or wraps FieldViolations and returns
+// them.
+func AsViolations(err error) (FieldViolations, bool) {
+	var v FieldViolations
+	if errors.As(err, &v) && len(v) > 0 {
+		return v, true
+	}
+	return nil, false
+}
@@ -0,0 +1,54 @@
+package ws
+
+import (
+	appctx "github.com/shashiranjanraj/kashvi/pkg/ctx"
+)
+
+// ─── Authenticated upgrades ───────────────────────────────────────────────────
+
+// AuthFunc identifies the user behind a handshake. Returning ok=false
+// rejects the upgrade with 401.
+type AuthFunc func(c *appctx.Context) (userID string, ok bool)
+
+// UpgradeWithAuth authenticates the handshake with auth before upgrading.
+// The client remembers the user ID and a copy of c (request and store, as
+// left by auth and earlier middleware), so OnMessage knows who sent what:
+//
+//	r.Get("/ws/chat", "ws.chat", appctx.Wrap(func(c *appctx.Context) {
+//	    ws.UpgradeWithAuth(c, hubs.Chat, func(c *appctx.Context) (string, bool) {
+//	        claims, err := auth.ValidateToken(c.Query("token"))
+//	        if err != nil {
+//	            return "", false
+//	        }
+//	        c.Set("role", claims.Role)
+//	        return strconv.FormatUint(uint64(claims.UserID), 10), true
+//	    })
+//	}))
+//
+//	hubs.Chat.OnMessage = func(h *ws.Hub, m ws.Message) {
+//	    log.Info("chat", "from", m.Client.UserID(), "role", m.Client.Context().GetString("role"))
+//	}
+//
+// Browsers cannot set headers on WebSocket handshakes, so tokens usually
+// arrive as a query parameter or cookie.
+func UpgradeWithAuth(c *appctx.Context, hub *Hub, auth AuthFunc) {
+	userID, ok := auth(c)
+	if !ok {
+		c.Unauthorized()
+		return
+	}
+	snapshot := c.Copy()
+	upgrade(c.W, c.R, hub, func(cl *Client) {
+		cl.userID = userID
+		cl.ctx = snapshot
+	})
+}
+
+// UserID returns the ID UpgradeWithAuth authenticated, or "" for clients
+// connected with Upgrade.
+func (c *Client) UserID() string { return c.userID }
+
+// Context returns the handshake's context snapshot: its request (headers,
+// query, detached from cancellation) and store. Nil for clients connected
+// with Upgrade.
+func (c *Client) Context() *appctx.Context { return c.ctx }
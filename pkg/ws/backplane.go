@@ -0,0 +1,138 @@
+package ws
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/shashiranjanraj/kashvi/pkg/logger"
+)
+
+// ─── Backplane ────────────────────────────────────────────────────────────────
+
+// Backplane fans broadcasts out between server instances. Each instance's
+// hub publishes its Broadcast and BroadcastTo messages and delivers what the
+// others publish to its own clients, so a message reaches every client no
+// matter which pod it is connected to.
+type Backplane interface {
+	// Publish sends payload to every subscriber of channel.
+	Publish(ctx context.Context, channel string, payload []byte) error
+	// Subscribe calls handle for every payload published on channel until
+	// ctx is done or the subscription fails.
+	Subscribe(ctx context.Context, channel string, handle func(payload []byte)) error
+}
+
+// backplaneMessage is what hubs exchange over the backplane.
+type backplaneMessage struct {
+	Origin string `json:"origin"`
+	Room   string `json:"room,omitempty"` // "" = every client
+	Data   []byte `json:"data"`
+}
+
+// backplaneOutbox bounds messages waiting to be published; when the
+// backplane is slower than this, remote delivery is dropped and logged.
+const backplaneOutbox = 1024
+
+// UseBackplane connects the hub to other instances through b. Every
+// instance must use the same channel for the same logical hub. Call it
+// before Run:
+//
+//	var Chat = ws.NewHub()
+//
+//	func init() {
+//	    Chat.UseBackplane(ws.NewRedisBackplane(cache.RDB), "chat")
+//	    go Chat.Run()
+//	}
+func (h *Hub) UseBackplane(b Backplane, channel string) {
+	id := make([]byte, 8)
+	rand.Read(id) //nolint:errcheck
+	h.backplane = b
+	h.channel = channel
+	h.origin = hex.EncodeToString(id)
+	h.outbox = make(chan backplaneMessage, backplaneOutbox)
+	h.remote = make(chan roomMessage, 256)
+}
+
+// startBackplane runs the publisher and subscriber goroutines; called by Run.
+func (h *Hub) startBackplane() {
+	ctx := context.Background()
+	go func() {
+		for msg := range h.outbox {
+			payload, _ := json.Marshal(msg)
+			if err := h.backplane.Publish(ctx, h.channel, payload); err != nil {
+				logger.Warn("ws: backplane publish failed", "channel", h.channel, "error", err)
+			}
+		}
+	}()
+	go func() {
+		for {
+			err := h.backplane.Subscribe(ctx, h.channel, func(payload []byte) {
+				var msg backplaneMessage
+				if json.Unmarshal(payload, &msg) != nil || msg.Origin == h.origin {
+					return // malformed, or our own message already delivered locally
+				}
+				h.remote <- roomMessage{room: msg.Room, data: msg.Data}
+			})
+			logger.Warn("ws: backplane subscription ended, resubscribing", "channel", h.channel, "error", err)
+			time.Sleep(time.Second)
+		}
+	}()
+}
+
+// publish queues a locally originated broadcast for other instances.
+// Called only from Run.
+func (h *Hub) publish(room string, data []byte) {
+	if h.backplane == nil {
+		return
+	}
+	select {
+	case h.outbox <- backplaneMessage{Origin: h.origin, Room: room, Data: data}:
+	default:
+		logger.Warn("ws: backplane outbox full, message not sent to other instances", "channel", h.channel)
+	}
+}
+
+// ─── Redis ────────────────────────────────────────────────────────────────────
+
+// RedisBackplane is a Backplane over Redis pub/sub.
+type RedisBackplane struct {
+	rdb    *redis.Client
+	prefix string
+}
+
+// NewRedisBackplane returns a backplane on rdb, typically cache.RDB.
+// Channels are namespaced "kashvi:ws:<channel>".
+func NewRedisBackplane(rdb *redis.Client) *RedisBackplane {
+	return &RedisBackplane{rdb: rdb, prefix: "kashvi:ws:"}
+}
+
+// Publish implements Backplane.
+func (b *RedisBackplane) Publish(ctx context.Context, channel string, payload []byte) error {
+	return b.rdb.Publish(ctx, b.prefix+channel, payload).Err()
+}
+
+// Subscribe implements Backplane. go-redis reconnects dropped pub/sub
+// connections itself; messages published while disconnected are lost.
+func (b *RedisBackplane) Subscribe(ctx context.Context, channel string, handle func(payload []byte)) error {
+	ps := b.rdb.Subscribe(ctx, b.prefix+channel)
+	defer ps.Close()
+	if _, err := ps.Receive(ctx); err != nil { // wait for the subscription
+		return err
+	}
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return redis.ErrClosed
+			}
+			handle([]byte(msg.Payload))
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ─── Contracts ────────────────────────────────────────────────────────────────
+
+// Schema is a JSON Schema (draft 2020-12) object.
+type Schema map[string]any
+
+// EventContract describes one envelope type.
+type EventContract struct {
+	Type    string `json:"type"`
+	RPC     bool   `json:"rpc,omitempty"`
+	Payload Schema `json:"payload"`
+	Reply   Schema `json:"reply,omitempty"` // payload of the TypeReply, for RPC
+}
+
+// Contract is a hub's message protocol as seen by a client SDK.
+type Contract struct {
+	Hub      string          `json:"hub"`
+	Receives []EventContract `json:"receives"` // client → server
+	Sends    []EventContract `json:"sends"`    // server → client
+}
+
+// Document bundles the contracts of several hubs with the named types they
+// share under $defs, like the components section of an OpenAPI spec.
+type Document struct {
+	Schema string            `json:"$schema"`
+	Hubs   []Contract        `json:"hubs"`
+	Defs   map[string]Schema `json:"$defs,omitempty"`
+}
+
+// eventSpec records the Go types behind an envelope type; nil means any.
+type eventSpec struct {
+	rpc            bool
+	payload, reply reflect.Type
+}
+
+// describe records typ for contracts; sends is false for handlers.
+func (h *Hub) describe(typ string, sends bool, spec eventSpec) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+	m := &h.receives
+	if sends {
+		m = &h.sends
+	}
+	if *m == nil {
+		*m = make(map[string]eventSpec)
+	}
+	(*m)[typ] = spec
+}
+
+// Emits declares that the hub sends typ envelopes with a T payload, so it
+// appears in the hub's contract. Handlers are recorded automatically; what
+// the server sends is not.
+//
+//	ws.Emits[ChatMessage](hub, "chat.message")
+func Emits[T any](h *Hub, typ string) {
+	h.describe(typ, true, eventSpec{payload: reflect.TypeFor[T]()})
+}
+
+// Named sets the hub's name, used in contracts and as its readiness check
+// ("ws:<name>"). Call it before Run.
+//
+//	var Chat = ws.NewHub().Named("chat")
+func (h *Hub) Named(name string) *Hub {
+	h.name = "ws:" + name
+	return h
+}
+
+// Name returns the hub's name without the "ws:" prefix.
+func (h *Hub) Name() string { return strings.TrimPrefix(h.name, "ws:") }
+
+// Contracts describes the given hubs, or every hub when none are given.
+func Contracts(hs ...*Hub) Document {
+	if len(hs) == 0 {
+		hubsMu.Lock()
+		hs = append(hs, hubs...)
+		hubsMu.Unlock()
+	}
+	g := &schemaGen{defs: map[string]Schema{}, names: map[reflect.Type]string{}}
+	doc := Document{Schema: "https://json-schema.org/draft/2020-12/schema"}
+	for _, h := range hs {
+		doc.Hubs = append(doc.Hubs, h.contract(g))
+	}
+	if len(g.defs) > 0 {
+		doc.Defs = g.defs
+	}
+	return doc
+}
+
+func (h *Hub) contract(g *schemaGen) Contract {
+	h.handlersMu.RLock()
+	defer h.handlersMu.RUnlock()
+	c := Contract{Hub: h.Name(), Receives: []EventContract{}, Sends: []EventContract{}}
+	for _, typ := range sortedKeys(h.receives) {
+		s := h.receives[typ]
+		ec := EventContract{Type: typ, RPC: s.rpc, Payload: g.schema(s.payload)}
+		if s.rpc {
+			ec.Reply = g.schema(s.reply)
+		}
+		c.Receives = append(c.Receives, ec)
+	}
+	for _, typ := range sortedKeys(h.sends) {
+		c.Sends = append(c.Sends, EventContract{Type: typ, Payload: g.schema(h.sends[typ].payload)})
+	}
+	return c
+}
+
+func sortedKeys(m map[string]eventSpec) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ContractHandler serves Contracts for hs (all hubs when empty) as JSON, or
+// as TypeScript with ?format=ts. Mount it where frontend builds can fetch
+// it, like an OpenAPI document:
+//
+//	r.Get("/ws/contract", "ws.contract", ws.ContractHandler())
+func ContractHandler(hs ...*Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc := Contracts(hs...)
+		if r.URL.Query().Get("format") == "ts" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprint(w, doc.TypeScript())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(doc) //nolint:errcheck
+	}
+}
+
+// ─── JSON Schema ──────────────────────────────────────────────────────────────
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	rawType       = reflect.TypeFor[json.RawMessage]()
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+// schemaGen turns Go types into JSON Schemas the way encoding/json would
+// serialize them. Named structs go to defs and are referenced by $ref.
+type schemaGen struct {
+	defs  map[string]Schema
+	names map[reflect.Type]string
+}
+
+func (g *schemaGen) schema(t reflect.Type) Schema {
+	if t == nil {
+		return Schema{}
+	}
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case t == rawType:
+		return Schema{}
+	case t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface &&
+		(t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType)):
+		return Schema{} // custom encoding: shape unknown
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = g.defName(t)
+			g.names[t] = name
+			g.defs[name] = Schema{} // placeholder for recursive types
+			g.defs[name] = g.object(t)
+		}
+		return Schema{"$ref": "#/$defs/" + name}
+	default:
+		return Schema{}
+	}
+}
+
+// defName is the struct's name, qualified by its package when two
+// packages use the same one.
+func (g *schemaGen) defName(t reflect.Type) string {
+	name := t.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i] // generic instantiation
+	}
+	if _, taken := g.defs[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	pkg = pkg[strings.LastIndexByte(pkg, '/')+1:]
+	return exportName(pkg) + name
+}
+
+func (g *schemaGen) object(t reflect.Type) Schema {
+	props := Schema{}
+	var required []string
+	g.fields(t, props, &required)
+	s := Schema{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+	return s
+}
+
+// fields adds t's JSON fields to props, flattening untagged embedded
+// structs like encoding/json does.
+func (g *schemaGen) fields(t reflect.Type, props Schema, required *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s := g.schema(ft)
+		if strings.Contains(opts, "string") {
+			s = Schema{"type": "string"}
+		}
+		props[name] = s
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// ─── TypeScript ───────────────────────────────────────────────────────────────
+
+// TypeScript renders the document as TypeScript declarations: an interface
+// per named type and, per hub, unions of the messages a client sends and
+// receives plus a map of its RPC calls.
+func (d Document) TypeScript() string {
+	var b strings.Builder
+	b.WriteString("// Code generated by kashvi ws:contract. DO NOT EDIT.\n")
+
+	names := make([]string, 0, len(d.Defs))
+	for n := range d.Defs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		s := d.Defs[n]
+		if _, ok := s["properties"]; ok {
+			fmt.Fprintf(&b, "\nexport interface %s %s\n", n, tsType(s))
+		} else {
+			fmt.Fprintf(&b, "\nexport type %s = %s;\n", n, tsType(s))
+		}
+	}
+
+	for _, c := range d.Hubs {
+		prefix := exportName(c.Hub)
+		fmt.Fprintf(&b, "\n// Hub %q\n", c.Hub)
+		fmt.Fprintf(&b, "export type %sClientMessage =%s;\n", prefix, tsUnion(c.Receives, nil))
+		fmt.Fprintf(&b, "export type %sServerMessage =%s;\n", prefix, tsUnion(c.Sends, []string{
+			`{ type: "ack"; id: string; payload?: unknown }`,
+			`{ type: "reply"; id: string; payload: unknown }`,
+			`{ type: "error"; id?: string; payload: { error: string; type?: string; errors?: Record<string, string> } }`,
+		}))
+		fmt.Fprintf(&b, "export interface %sRPC {\n", prefix)
+		for _, e := range c.Receives {
+			if e.RPC {
+				fmt.Fprintf(&b, "  %q: { request: %s; response: %s };\n", e.Type, tsType(e.Payload), tsType(e.Reply))
+			}
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+func tsUnion(events []EventContract, extra []string) string {
+	var members []string
+	for _, e := range events {
+		members = append(members, fmt.Sprintf("{ type: %q; id?: string; payload: %s }", e.Type, tsType(e.Payload)))
+	}
+	members = append(members, extra...)
+	if len(members) == 0 {
+		return " never"
+	}
+	return "\n  | " + strings.Join(members, "\n  | ")
+}
+
+func tsType(s Schema) string {
+	if ref, ok := s["$ref"].(string); ok {
+		return strings.TrimPrefix(ref, "#/$defs/")
+	}
+	switch s["type"] {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		item := tsType(s["items"].(Schema))
+		if strings.ContainsAny(item, " |") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if props, ok := s["properties"].(Schema); ok {
+			req := map[string]bool{}
+			if r, ok := s["required"].([]string); ok {
+				for _, n := range r {
+					req[n] = true
+				}
+			}
+			keys := make([]string, 0, len(props))
+			for k := range props {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			var b strings.Builder
+			b.WriteString("{\n")
+			for _, k := range keys {
+				opt := "?"
+				if req[k] {
+					opt = ""
+				}
+				fmt.Fprintf(&b, "  %s%s: %s;\n", tsKey(k), opt, tsType(props[k].(Schema)))
+			}
+			b.WriteString("}")
+			return b.String()
+		}
+		if ap, ok := s["additionalProperties"].(Schema); ok {
+			return "Record<string, " + tsType(ap) + ">"
+		}
+	}
+	return "unknown"
+}
+
+// tsKey quotes property names that are not valid identifiers.
+func tsKey(k string) string {
+	for i, r := range k {
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return fmt.Sprintf("%q", k)
+		}
+	}
+	return k
+}
+
+// exportName turns "chat-room" or "ws:hub1" into "ChatRoom" / "WsHub1".
+func exportName(s string) string {
+	var b strings.Builder
+	up := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			up = true
+			continue
+		}
+		if up {
+			r = unicode.ToUpper(r)
+			up = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,223 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/shashiranjanraj/kashvi/pkg/validate"
+)
+
+// ─── JSON envelope ────────────────────────────────────────────────────────────
+
+// Envelope is the structured message format used by On, Handle, HandleRPC
+// and the *JSON senders:
+//
+//	{"type": "chat.message", "payload": {"text": "hi"}, "id": "42"}
+//
+// ID is optional; it correlates a message with its ack or reply (see
+// rpc.go).
+type Envelope struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	ID      string          `json:"id,omitempty"`
+}
+
+// Envelope types the hub itself sends or understands.
+const (
+	// TypeError is the type of the reply sent for malformed envelopes,
+	// unknown types and payloads that do not decode.
+	TypeError = "error"
+	// TypeAck acknowledges a message carrying an ID, in both directions.
+	TypeAck = "ack"
+	// TypeReply carries the result of a HandleRPC handler.
+	TypeReply = "reply"
+)
+
+// ErrorPayload is the payload of a TypeError reply. Payloads that fail
+// validation carry Errors, the field → message map an HTTP 422 carries:
+//
+//	{"type": "error", "id": "7", "payload": {"error": "Validation failed",
+//	 "type": "orders.create", "errors": {"quantity": "The quantity must be at least 1."}}}
+type ErrorPayload struct {
+	Error  string                   `json:"error"`
+	Type   string                   `json:"type,omitempty"` // type of the offending message
+	Errors validate.FieldViolations `json:"errors,omitempty"`
+}
+
+// HandlerFunc handles one envelope type.
+type HandlerFunc func(c *Client, payload json.RawMessage)
+
+// On registers fn for messages whose envelope type is typ. Once a hub has
+// any handler, inbound messages are decoded as envelopes and dispatched by
+// type instead of being passed to OnMessage; anything else gets a TypeError
+// reply. Handlers run on the hub loop, like OnMessage. Messages carrying an
+// ID are acknowledged with {"type": "ack", "id": …} once fn returns.
+//
+//	hub.On("room.join", func(c *ws.Client, p json.RawMessage) {
+//	    var room string
+//	    json.Unmarshal(p, &room)
+//	    hub.Join(c, room)
+//	})
+func (h *Hub) On(typ string, fn HandlerFunc) {
+	h.describe(typ, false, eventSpec{})
+	h.handle(typ, func(c *Client, env Envelope) {
+		fn(c, env.Payload)
+		c.ack(env.ID)
+	})
+}
+
+// handle registers the envelope-level handler behind On, Handle and
+// HandleRPC.
+func (h *Hub) handle(typ string, fn func(c *Client, env Envelope)) {
+	h.handlersMu.Lock()
+	defer h.handlersMu.Unlock()
+	if h.handlers == nil {
+		h.handlers = make(map[string]func(*Client, Envelope))
+	}
+	h.handlers[typ] = fn
+}
+
+// Handle registers fn for typ with the payload decoded into T and checked
+// with pkg/validate (`validate` tags and Validate(ctx) hooks). A payload that
+// does not decode or validate gets a TypeError reply and fn is not called.
+//
+//	type ChatMessage struct{ Room, Text string }
+//
+//	ws.Handle(hub, "chat.message", func(c *ws.Client, m ChatMessage) {
+//	    hub.BroadcastToJSON(m.Room, "chat.message", m)
+//	})
+func Handle[T any](h *Hub, typ string, fn func(c *Client, v T)) {
+	h.describe(typ, false, eventSpec{payload: reflect.TypeFor[T]()})
+	h.handle(typ, func(c *Client, env Envelope) {
+		v, ok := decode[T](c, env)
+		if !ok {
+			return
+		}
+		fn(c, v)
+		c.ack(env.ID)
+	})
+}
+
+// decode unmarshals env's payload into T and validates it, replying with a
+// TypeError when it does not fit.
+func decode[T any](c *Client, env Envelope) (T, bool) {
+	var v T
+	if len(env.Payload) > 0 {
+		if err := json.Unmarshal(env.Payload, &v); err != nil {
+			c.sendError(env.ID, env.Type, fmt.Sprintf("invalid payload: %v", err))
+			return v, false
+		}
+	}
+	if errs := validate.StructCtx(c.validationContext(), &v); len(errs) > 0 {
+		c.sendViolations(env.ID, env.Type, errs)
+		return v, false
+	}
+	return v, true
+}
+
+// validationContext is the handshake's context for Validate(ctx) hooks, or
+// context.Background() for clients connected with Upgrade.
+func (c *Client) validationContext() context.Context {
+	if c.ctx != nil {
+		return c.ctx.Context()
+	}
+	return context.Background()
+}
+
+// dispatch routes an inbound message by envelope type. It reports false
+// when the hub has no handlers, leaving the message to OnMessage.
+func (h *Hub) dispatch(msg Message) bool {
+	h.handlersMu.RLock()
+	handlers := h.handlers
+	if len(handlers) == 0 {
+		h.handlersMu.RUnlock()
+		return false
+	}
+	var env Envelope
+	err := json.Unmarshal(msg.Data, &env)
+	fn := handlers[env.Type]
+	h.handlersMu.RUnlock()
+
+	switch {
+	case err != nil || env.Type == "":
+		msg.Client.sendError(env.ID, "", `message must be a JSON object {"type": ..., "payload": ...}`)
+	case fn == nil:
+		msg.Client.sendError(env.ID, env.Type, fmt.Sprintf("unknown message type %q", env.Type))
+	default:
+		fn(msg.Client, env)
+	}
+	return true
+}
+
+// ─── Senders ──────────────────────────────────────────────────────────────────
+
+// marshalEnvelope encodes payload under typ and id.
+func marshalEnvelope(typ, id string, payload any) ([]byte, error) {
+	env := Envelope{Type: typ, ID: id}
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("ws: marshal %s payload: %w", typ, err)
+		}
+		env.Payload = raw
+	}
+	return json.Marshal(env)
+}
+
+// SendJSON queues an envelope for this client.
+func (c *Client) SendJSON(typ string, payload any) error {
+	return c.sendEnvelope(typ, "", payload)
+}
+
+func (c *Client) sendEnvelope(typ, id string, payload any) error {
+	data, err := marshalEnvelope(typ, id, payload)
+	if err != nil {
+		return err
+	}
+	c.Send(data)
+	return nil
+}
+
+// SendError replies with a TypeError envelope; typ is the type of the
+// message being rejected ("" when it could not be parsed).
+func (c *Client) SendError(typ, message string) {
+	c.sendError("", typ, message)
+}
+
+// sendError is SendError correlated with the rejected message's ID.
+func (c *Client) sendError(id, typ, message string) {
+	c.sendEnvelope(TypeError, id, ErrorPayload{Error: message, Type: typ}) //nolint:errcheck
+}
+
+// SendViolations replies with a TypeError envelope listing field
+// violations, the WebSocket form of an HTTP 422; typ is the type of the
+// message being rejected.
+func (c *Client) SendViolations(typ string, v validate.FieldViolations) {
+	c.sendViolations("", typ, v)
+}
+
+func (c *Client) sendViolations(id, typ string, v validate.FieldViolations) {
+	c.sendEnvelope(TypeError, id, ErrorPayload{Error: validate.Message, Type: typ, Errors: v}) //nolint:errcheck
+}
+
+// BroadcastJSON sends an envelope to every client.
+func (h *Hub) BroadcastJSON(typ string, payload any) error {
+	data, err := marshalEnvelope(typ, "", payload)
+	if err != nil {
+		return err
+	}
+	h.Broadcast <- data
+	return nil
+}
+
+// BroadcastToJSON sends an envelope to every client in room.
+func (h *Hub) BroadcastToJSON(room, typ string, payload any) error {
+	data, err := marshalEnvelope(typ, "", payload)
+	if err != nil {
+		return err
+	}
+	h.BroadcastTo(room, data)
+	return nil
+}
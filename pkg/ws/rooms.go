@@ -0,0 +1,107 @@
+package ws
+
+import "sort"
+
+// ─── Rooms ────────────────────────────────────────────────────────────────────
+
+// roomMessage is a BroadcastTo request handled by Run.
+type roomMessage struct {
+	room string
+	data []byte
+}
+
+// Join adds client to room. Rooms are created on first join and removed
+// when their last client leaves or disconnects. Join is safe to call from
+// anywhere, including OnMessage:
+//
+//	hub.OnMessage = func(h *ws.Hub, m ws.Message) {
+//	    h.Join(m.Client, "order:"+string(m.Data))
+//	}
+func (h *Hub) Join(client *Client, room string) {
+	h.roomsMu.Lock()
+	defer h.roomsMu.Unlock()
+	if client.gone {
+		return
+	}
+	members := h.rooms[room]
+	if members == nil {
+		members = make(map[*Client]bool)
+		h.rooms[room] = members
+	}
+	members[client] = true
+	client.rooms[room] = true
+}
+
+// Leave removes client from room.
+func (h *Hub) Leave(client *Client, room string) {
+	h.roomsMu.Lock()
+	defer h.roomsMu.Unlock()
+	h.leave(client, room)
+}
+
+func (h *Hub) leave(client *Client, room string) {
+	delete(client.rooms, room)
+	if members := h.rooms[room]; members != nil {
+		delete(members, client)
+		if len(members) == 0 {
+			delete(h.rooms, room)
+		}
+	}
+}
+
+// forget removes client from every room once the hub drops it.
+func (h *Hub) forget(client *Client) {
+	h.roomsMu.Lock()
+	defer h.roomsMu.Unlock()
+	client.gone = true
+	for room := range client.rooms {
+		h.leave(client, room)
+	}
+}
+
+// BroadcastTo sends data to every client in room. Like Broadcast, clients
+// whose send buffer is full are disconnected.
+func (h *Hub) BroadcastTo(room string, data []byte) {
+	h.roomcast <- roomMessage{room: room, data: data}
+}
+
+// members snapshots the clients in room.
+func (h *Hub) members(room string) []*Client {
+	h.roomsMu.RLock()
+	defer h.roomsMu.RUnlock()
+	out := make([]*Client, 0, len(h.rooms[room]))
+	for c := range h.rooms[room] {
+		out = append(out, c)
+	}
+	return out
+}
+
+// RoomCount returns the number of clients in room.
+func (h *Hub) RoomCount(room string) int {
+	h.roomsMu.RLock()
+	defer h.roomsMu.RUnlock()
+	return len(h.rooms[room])
+}
+
+// RoomCounts returns the client count of every non-empty room.
+func (h *Hub) RoomCounts() map[string]int {
+	h.roomsMu.RLock()
+	defer h.roomsMu.RUnlock()
+	out := make(map[string]int, len(h.rooms))
+	for room, members := range h.rooms {
+		out[room] = len(members)
+	}
+	return out
+}
+
+// Rooms returns the rooms client has joined, sorted.
+func (c *Client) Rooms() []string {
+	c.hub.roomsMu.RLock()
+	defer c.hub.roomsMu.RUnlock()
+	out := make([]string, 0, len(c.rooms))
+	for room := range c.rooms {
+		out = append(out, room)
+	}
+	sort.Strings(out)
+	return out
+}
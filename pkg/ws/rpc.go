@@ -0,0 +1,157 @@
+package ws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/shashiranjanraj/kashvi/pkg/validate"
+)
+
+// ─── RPC ──────────────────────────────────────────────────────────────────────
+
+// HandleRPC registers a request/response handler for typ. The client sends
+//
+//	{"type": "orders.get", "id": "7", "payload": {"id": 42}}
+//
+// and receives {"type": "reply", "id": "7", "payload": <result>}, or a
+// TypeError envelope with the same ID when fn fails or the payload does not
+// decode or validate (see Handle). When fn returns validate.FieldViolations
+// the error carries them, as for an invalid payload. Like On handlers, fn runs on the hub loop; for slow work, start a
+// goroutine from an On handler and answer later with Client.Reply.
+//
+//	ws.HandleRPC(hub, "orders.get", func(c *ws.Client, req GetOrder) (*Order, error) {
+//	    return orders.Find(req.ID)
+//	})
+func HandleRPC[Req, Resp any](h *Hub, typ string, fn func(c *Client, req Req) (Resp, error)) {
+	h.describe(typ, false, eventSpec{rpc: true, payload: reflect.TypeFor[Req](), reply: reflect.TypeFor[Resp]()})
+	h.handle(typ, func(c *Client, env Envelope) {
+		req, ok := decode[Req](c, env)
+		if !ok {
+			return
+		}
+		resp, err := fn(c, req)
+		if v, ok := validate.AsViolations(err); ok {
+			c.sendViolations(env.ID, typ, v)
+			return
+		}
+		if err != nil {
+			c.sendError(env.ID, typ, err.Error())
+			return
+		}
+		if err := c.Reply(env.ID, resp); err != nil {
+			c.sendError(env.ID, typ, err.Error())
+		}
+	})
+}
+
+// Reply sends a TypeReply envelope answering the message with id.
+func (c *Client) Reply(id string, payload any) error {
+	return c.sendEnvelope(TypeReply, id, payload)
+}
+
+// ack acknowledges a message that carried an ID.
+func (c *Client) ack(id string) {
+	if id != "" {
+		c.sendEnvelope(TypeAck, id, nil) //nolint:errcheck
+	}
+}
+
+// ─── Acknowledgements ─────────────────────────────────────────────────────────
+
+// AckTimeout bounds SendWithAck when ctx has no deadline.
+var AckTimeout = 10 * time.Second
+
+var (
+	// ErrAckTimeout is returned by SendWithAck when the client does not
+	// acknowledge in time.
+	ErrAckTimeout = errors.New("ws: acknowledgement timed out")
+	// ErrClientGone is returned by SendWithAck when the client disconnects
+	// before acknowledging.
+	ErrClientGone = errors.New("ws: client disconnected")
+)
+
+// SendWithAck sends an envelope with a fresh ID and waits for the client to
+// answer {"type": "ack", "id": …, "payload": …}, returning the ack's
+// payload. Use it for messages that must be confirmed (e.g. a server-side
+// prompt) or as RPC from server to client. Acks are picked up by the
+// client's read loop, so it is safe to call from a handler.
+//
+//	res, err := c.SendWithAck(ctx, "confirm.transfer", transfer)
+func (c *Client) SendWithAck(ctx context.Context, typ string, payload any) (json.RawMessage, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, AckTimeout)
+		defer cancel()
+	}
+
+	id := "s" + strconv.FormatUint(c.nextID.Add(1), 10)
+	ch := make(chan json.RawMessage, 1)
+	c.acksMu.Lock()
+	if c.acksDone {
+		c.acksMu.Unlock()
+		return nil, ErrClientGone
+	}
+	if c.acks == nil {
+		c.acks = make(map[string]chan json.RawMessage)
+	}
+	c.acks[id] = ch
+	c.acksMu.Unlock()
+	defer func() {
+		c.acksMu.Lock()
+		delete(c.acks, id)
+		c.acksMu.Unlock()
+	}()
+
+	if err := c.sendEnvelope(typ, id, payload); err != nil {
+		return nil, err
+	}
+	select {
+	case res, ok := <-ch:
+		if !ok {
+			return nil, ErrClientGone
+		}
+		return res, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ErrAckTimeout
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// resolveAck hands an inbound ack to its waiting SendWithAck and reports
+// whether msg was consumed. It only parses msg while acks are pending.
+func (c *Client) resolveAck(msg []byte) bool {
+	c.acksMu.Lock()
+	defer c.acksMu.Unlock()
+	if len(c.acks) == 0 || !bytes.Contains(msg, []byte(`"`+TypeAck+`"`)) {
+		return false
+	}
+	var env Envelope
+	if json.Unmarshal(msg, &env) != nil || env.Type != TypeAck {
+		return false
+	}
+	ch, ok := c.acks[env.ID]
+	if !ok {
+		return false
+	}
+	delete(c.acks, env.ID)
+	ch <- env.Payload
+	return true
+}
+
+// failAcks wakes every pending SendWithAck when the connection ends.
+func (c *Client) failAcks() {
+	c.acksMu.Lock()
+	defer c.acksMu.Unlock()
+	c.acksDone = true
+	for id, ch := range c.acks {
+		close(ch)
+		delete(c.acks, id)
+	}
+}
@@ -16,10 +16,18 @@
 package ws
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+	appctx "github.com/shashiranjanraj/kashvi/pkg/ctx"
+	"github.com/shashiranjanraj/kashvi/pkg/health"
 	"github.com/shashiranjanraj/kashvi/pkg/logger"
 )
 
@@ -49,11 +57,31 @@ type Client struct {
 	hub  *Hub
 	conn *websocket.Conn
 	send chan []byte
+
+	// closeCode is sent in the close frame when send is closed; set by the
+	// hub before closing the channel (0 = plain close).
+	closeCode int
+
+	// rooms the client has joined and whether the hub dropped it; both
+	// guarded by hub.roomsMu.
+	rooms map[string]bool
+	gone  bool
+
+	// Set by UpgradeWithAuth; see auth.go.
+	userID string
+	ctx    *appctx.Context
+
+	// Acknowledgements awaited by SendWithAck, by envelope ID; see rpc.go.
+	acksMu   sync.Mutex
+	acks     map[string]chan json.RawMessage
+	acksDone bool // the read loop ended
+	nextID   atomic.Uint64
 }
 
 // readPump pumps messages from the WebSocket connection to the hub.
 func (c *Client) readPump() {
 	defer func() {
+		c.failAcks()
 		c.hub.unregister <- c
 		c.conn.Close()
 	}()
@@ -72,6 +100,9 @@ func (c *Client) readPump() {
 			}
 			break
 		}
+		if c.resolveAck(msg) {
+			continue
+		}
 		c.hub.Inbound <- Message{Client: c, Data: msg}
 	}
 }
@@ -82,13 +113,18 @@ func (c *Client) writePump() {
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
+		c.hub.pumps.Done()
 	}()
 	for {
 		select {
 		case msg, ok := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				frame := []byte{}
+				if c.closeCode != 0 {
+					frame = websocket.FormatCloseMessage(c.closeCode, "server shutting down")
+				}
+				c.conn.WriteMessage(websocket.CloseMessage, frame)
 				return
 			}
 			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
@@ -127,47 +163,125 @@ type Hub struct {
 	Inbound    chan Message // messages received from clients
 	register   chan *Client
 	unregister chan *Client
-	// OnMessage is called for every inbound message (optional).
+	// OnMessage is called for every inbound message (optional). Hubs with
+	// envelope handlers (see On) dispatch by type instead.
 	OnMessage func(hub *Hub, msg Message)
+
+	handlersMu sync.RWMutex // guards handlers, receives and sends
+	handlers   map[string]func(*Client, Envelope)
+	receives   map[string]eventSpec // for contracts; see contract.go
+	sends      map[string]eventSpec
+
+	roomcast chan roomMessage // BroadcastTo → Run
+	roomsMu  sync.RWMutex     // guards rooms and every Client.rooms
+	rooms    map[string]map[*Client]bool
+
+	// Set by UseBackplane; see backplane.go.
+	backplane Backplane
+	channel   string
+	origin    string
+	outbox    chan backplaneMessage
+	remote    chan roomMessage // from other instances → Run
+
+	shutdown chan struct{}
+	ping     chan chan struct{} // readiness probe answered by Run
+	name     string             // readiness check name, e.g. "ws:hub1"
+	closed   bool               // owned by Run
+	running  atomic.Bool        // Run has been started
+	pumps    sync.WaitGroup     // one per live writePump
 }
 
+// hubs records every hub so Shutdown can drain them all.
+var (
+	hubsMu sync.Mutex
+	hubs   []*Hub
+)
+
 // NewHub creates a new Hub. Call hub.Run() in a goroutine at startup.
 func NewHub() *Hub {
-	return &Hub{
+	h := &Hub{
 		clients:    make(map[*Client]bool),
 		Broadcast:  make(chan []byte, 256),
 		Inbound:    make(chan Message, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		roomcast:   make(chan roomMessage, 256),
+		rooms:      make(map[string]map[*Client]bool),
+		shutdown:   make(chan struct{}),
+		ping:       make(chan chan struct{}),
 	}
+	hubsMu.Lock()
+	hubs = append(hubs, h)
+	h.name = fmt.Sprintf("ws:hub%d", len(hubs))
+	hubsMu.Unlock()
+	return h
 }
 
 // Run starts the hub event loop. Must be run in its own goroutine.
+//
+// While Run is looping the hub is registered as a readiness check; a loop
+// stuck in OnMessage fails it.
 func (h *Hub) Run() {
+	h.running.Store(true)
+	health.Register(h.name, h.alive)
+	if h.backplane != nil {
+		h.startBackplane()
+	}
 	for {
 		select {
+		case reply := <-h.ping:
+			close(reply)
+
 		case client := <-h.register:
+			if h.closed {
+				client.closeCode = websocket.CloseGoingAway
+				h.forget(client)
+				close(client.send)
+				continue
+			}
 			h.clients[client] = true
 			logger.Info("ws: client connected", "total", len(h.clients))
 
+		case <-h.shutdown:
+			h.closed = true
+			for client := range h.clients {
+				client.closeCode = websocket.CloseGoingAway
+				h.drop(client)
+			}
+
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
+				h.drop(client)
 				logger.Info("ws: client disconnected", "total", len(h.clients))
 			}
 
 		case msg := <-h.Broadcast:
+			h.publish("", msg)
 			for client := range h.clients {
-				select {
-				case client.send <- msg:
-				default:
-					close(client.send)
-					delete(h.clients, client)
+				h.deliver(client, msg)
+			}
+
+		case msg := <-h.roomcast:
+			h.publish(msg.room, msg.data)
+			for _, client := range h.members(msg.room) {
+				h.deliver(client, msg.data)
+			}
+
+		case msg := <-h.remote: // nil channel without a backplane
+			if msg.room == "" {
+				for client := range h.clients {
+					h.deliver(client, msg.data)
 				}
+				continue
+			}
+			for _, client := range h.members(msg.room) {
+				h.deliver(client, msg.data)
 			}
 
 		case msg := <-h.Inbound:
+			if h.dispatch(msg) {
+				continue
+			}
 			if h.OnMessage != nil {
 				h.OnMessage(h, msg)
 			}
@@ -175,20 +289,103 @@ func (h *Hub) Run() {
 	}
 }
 
+// deliver queues msg for client, dropping a client whose buffer is full.
+// Called only from Run.
+func (h *Hub) deliver(client *Client, msg []byte) {
+	if !h.clients[client] {
+		return
+	}
+	select {
+	case client.send <- msg:
+	default:
+		h.drop(client)
+	}
+}
+
+// drop removes client from the hub and its rooms and closes its send
+// channel. Called only from Run.
+func (h *Hub) drop(client *Client) {
+	delete(h.clients, client)
+	h.forget(client)
+	close(client.send)
+}
+
+// alive reports whether the Run loop answers within ctx.
+func (h *Hub) alive(ctx context.Context) error {
+	reply := make(chan struct{})
+	select {
+	case h.ping <- reply:
+	case <-ctx.Done():
+		return errors.New("hub loop not responding")
+	}
+	<-reply
+	return nil
+}
+
 // ClientCount returns the number of currently connected clients.
 func (h *Hub) ClientCount() int { return len(h.clients) }
 
+// Shutdown sends every client a "going away" close frame, refuses new
+// connections and waits until all close frames are written or ctx expires.
+// A hub whose Run loop was never started has no clients and returns at once.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	if !h.running.Load() {
+		return nil
+	}
+	select {
+	case h.shutdown <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	done := make(chan struct{})
+	go func() {
+		h.pumps.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown drains every hub created with NewHub (see Hub.Shutdown).
+func Shutdown(ctx context.Context) error {
+	hubsMu.Lock()
+	all := append([]*Hub(nil), hubs...)
+	hubsMu.Unlock()
+
+	var firstErr error
+	for _, h := range all {
+		if err := h.Shutdown(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
 // ─── Upgrade ─────────────────────────────────────────────────────────────────
 
 // Upgrade upgrades an HTTP connection to a WebSocket and registers the
 // resulting client with the given hub.
 func Upgrade(w http.ResponseWriter, r *http.Request, hub *Hub) {
+	upgrade(w, r, hub, nil)
+}
+
+// upgrade completes the handshake; init, when set, fills in the client
+// before it is registered.
+func upgrade(w http.ResponseWriter, r *http.Request, hub *Hub, init func(*Client)) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		logger.Error("ws: upgrade failed", "error", err)
 		return
 	}
-	client := &Client{hub: hub, conn: conn, send: make(chan []byte, 256)}
+	client := &Client{hub: hub, conn: conn, send: make(chan []byte, 256), rooms: map[string]bool{}}
+	if init != nil {
+		init(client)
+	}
+	hub.pumps.Add(1)
 	hub.register <- client
 	go client.writePump()
 	go client.readPump()
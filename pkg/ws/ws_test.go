@@ -0,0 +1,427 @@
+package ws_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	appctx "github.com/shashiranjanraj/kashvi/pkg/ctx"
+	"github.com/shashiranjanraj/kashvi/pkg/validate"
+	"github.com/shashiranjanraj/kashvi/pkg/ws"
+)
+
+func dial(t *testing.T, srv *httptest.Server) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func read(conn *websocket.Conn, wait time.Duration) (string, bool) {
+	conn.SetReadDeadline(time.Now().Add(wait))
+	_, msg, err := conn.ReadMessage()
+	return string(msg), err == nil
+}
+
+func TestHub_Rooms(t *testing.T) {
+	hub := ws.NewHub()
+	joined := make(chan struct{}, 2)
+	hub.OnMessage = func(h *ws.Hub, m ws.Message) {
+		h.Join(m.Client, string(m.Data))
+		joined <- struct{}{}
+	}
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws.Upgrade(w, r, hub)
+	}))
+	defer srv.Close()
+
+	alice, bob := dial(t, srv), dial(t, srv)
+	alice.WriteMessage(websocket.TextMessage, []byte("order:1"))
+	bob.WriteMessage(websocket.TextMessage, []byte("order:2"))
+	<-joined
+	<-joined
+
+	if n := hub.RoomCount("order:1"); n != 1 {
+		t.Fatalf("RoomCount = %d", n)
+	}
+	hub.BroadcastTo("order:1", []byte("shipped"))
+	if msg, ok := read(alice, time.Second); !ok || msg != "shipped" {
+		t.Fatalf("alice got %q %v", msg, ok)
+	}
+	if msg, ok := read(bob, 200*time.Millisecond); ok {
+		t.Fatalf("bob got %q from a room it did not join", msg)
+	}
+
+	bob.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.RoomCount("order:2") != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("disconnected client still counted in its room")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if counts := hub.RoomCounts(); len(counts) != 1 || counts["order:1"] != 1 {
+		t.Fatalf("RoomCounts = %v", counts)
+	}
+}
+
+// memBackplane is an in-process Backplane shared by hubs standing in for
+// separate instances.
+type memBackplane struct {
+	mu   sync.Mutex
+	subs map[string][]func([]byte)
+}
+
+func (b *memBackplane) Publish(_ context.Context, channel string, payload []byte) error {
+	b.mu.Lock()
+	subs := append([]func([]byte){}, b.subs[channel]...)
+	b.mu.Unlock()
+	for _, handle := range subs {
+		handle(payload)
+	}
+	return nil
+}
+
+func (b *memBackplane) Subscribe(ctx context.Context, channel string, handle func([]byte)) error {
+	b.mu.Lock()
+	b.subs[channel] = append(b.subs[channel], handle)
+	b.mu.Unlock()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestHub_BackplaneFansOutAcrossInstances(t *testing.T) {
+	bp := &memBackplane{subs: map[string][]func([]byte){}}
+	podA, podB := ws.NewHub(), ws.NewHub()
+	podA.UseBackplane(bp, "chat")
+	podB.UseBackplane(bp, "chat")
+	joined := make(chan struct{}, 1)
+	podA.OnMessage = func(h *ws.Hub, m ws.Message) {
+		h.Join(m.Client, string(m.Data))
+		joined <- struct{}{}
+	}
+	go podA.Run()
+	go podB.Run()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws.Upgrade(w, r, podA)
+	}))
+	defer srv.Close()
+	conn := dial(t, srv)
+	conn.WriteMessage(websocket.TextMessage, []byte("room:1"))
+	<-joined
+	for {
+		bp.mu.Lock()
+		n := len(bp.subs["chat"])
+		bp.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	podB.Broadcast <- []byte("to-all")
+	if msg, ok := read(conn, time.Second); !ok || msg != "to-all" {
+		t.Fatalf("broadcast from other instance: %q %v", msg, ok)
+	}
+	podB.BroadcastTo("room:1", []byte("to-room"))
+	if msg, ok := read(conn, time.Second); !ok || msg != "to-room" {
+		t.Fatalf("room broadcast from other instance: %q %v", msg, ok)
+	}
+	podA.Broadcast <- []byte("local")
+	if msg, ok := read(conn, time.Second); !ok || msg != "local" {
+		t.Fatalf("local broadcast: %q %v", msg, ok)
+	}
+	if msg, ok := read(conn, 200*time.Millisecond); ok {
+		t.Fatalf("local broadcast echoed back through the backplane: %q", msg)
+	}
+}
+
+func TestUpgradeWithAuth(t *testing.T) {
+	hub := ws.NewHub()
+	from := make(chan string, 1)
+	hub.OnMessage = func(h *ws.Hub, m ws.Message) {
+		from <- m.Client.UserID() + "/" + m.Client.Context().GetString("role")
+	}
+	go hub.Run()
+
+	srv := httptest.NewServer(appctx.Wrap(func(c *appctx.Context) {
+		ws.UpgradeWithAuth(c, hub, func(c *appctx.Context) (string, bool) {
+			if c.Query("token") != "secret" {
+				return "", false
+			}
+			c.Set("role", "admin")
+			return "42", true
+		})
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?token=wrong", nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("bad token: err=%v resp=%v", err, resp)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?token=secret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.WriteMessage(websocket.TextMessage, []byte("hi"))
+	select {
+	case got := <-from:
+		if got != "42/admin" {
+			t.Fatalf("sender = %q", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no message")
+	}
+}
+
+func TestHub_EnvelopeHandlers(t *testing.T) {
+	type chat struct {
+		Text string `json:"text"`
+	}
+	hub := ws.NewHub()
+	ws.Handle(hub, "chat.message", func(c *ws.Client, m chat) {
+		c.SendJSON("chat.echo", m)
+	})
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws.Upgrade(w, r, hub)
+	}))
+	defer srv.Close()
+	conn := dial(t, srv)
+
+	reply := func(msg string) ws.Envelope {
+		t.Helper()
+		conn.WriteMessage(websocket.TextMessage, []byte(msg))
+		raw, ok := read(conn, time.Second)
+		if !ok {
+			t.Fatalf("no reply to %s", msg)
+		}
+		var env ws.Envelope
+		if err := json.Unmarshal([]byte(raw), &env); err != nil {
+			t.Fatalf("reply %q: %v", raw, err)
+		}
+		return env
+	}
+
+	if env := reply(`{"type":"chat.message","payload":{"text":"hi"}}`); env.Type != "chat.echo" || string(env.Payload) != `{"text":"hi"}` {
+		t.Fatalf("echo = %s %s", env.Type, env.Payload)
+	}
+	for _, bad := range []string{
+		`{"type":"chat.typing"}`,
+		`{"type":"chat.message","payload":"hi"}`,
+		`not json`,
+	} {
+		env := reply(bad)
+		var p ws.ErrorPayload
+		json.Unmarshal(env.Payload, &p)
+		if env.Type != ws.TypeError || p.Error == "" {
+			t.Fatalf("%s: reply = %s %s", bad, env.Type, env.Payload)
+		}
+	}
+}
+
+func TestHub_RPCAndAcks(t *testing.T) {
+	type sum struct{ A, B int }
+	hub := ws.NewHub()
+	ws.HandleRPC(hub, "math.add", func(c *ws.Client, s sum) (int, error) {
+		if s.A < 0 {
+			return 0, errors.New("negative")
+		}
+		return s.A + s.B, nil
+	})
+	acked := make(chan string, 1)
+	hub.On("confirm.me", func(c *ws.Client, _ json.RawMessage) {
+		go func() {
+			res, err := c.SendWithAck(context.Background(), "confirm", "ok?")
+			if err != nil {
+				acked <- err.Error()
+				return
+			}
+			acked <- string(res)
+		}()
+	})
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws.Upgrade(w, r, hub)
+	}))
+	defer srv.Close()
+	conn := dial(t, srv)
+
+	next := func() ws.Envelope {
+		t.Helper()
+		raw, ok := read(conn, time.Second)
+		if !ok {
+			t.Fatal("no message")
+		}
+		var env ws.Envelope
+		json.Unmarshal([]byte(raw), &env)
+		return env
+	}
+
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"math.add","id":"1","payload":{"A":2,"B":3}}`))
+	if env := next(); env.Type != ws.TypeReply || env.ID != "1" || string(env.Payload) != "5" {
+		t.Fatalf("reply = %+v", env)
+	}
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"math.add","id":"2","payload":{"A":-1}}`))
+	if env := next(); env.Type != ws.TypeError || env.ID != "2" {
+		t.Fatalf("error reply = %+v", env)
+	}
+
+	// Server → client with acknowledgement; the On message itself is acked first.
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"confirm.me","id":"3"}`))
+	got := map[string]ws.Envelope{}
+	for range 2 {
+		env := next()
+		got[env.Type] = env
+	}
+	if got[ws.TypeAck].ID != "3" {
+		t.Fatalf("handler ack = %+v", got[ws.TypeAck])
+	}
+	prompt := got["confirm"]
+	if prompt.ID == "" || string(prompt.Payload) != `"ok?"` {
+		t.Fatalf("prompt = %+v", prompt)
+	}
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"ack","id":"`+prompt.ID+`","payload":"yes"}`))
+	select {
+	case res := <-acked:
+		if res != `"yes"` {
+			t.Fatalf("SendWithAck = %s", res)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("SendWithAck did not return")
+	}
+}
+
+type contractUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type contractMessage struct {
+	Text   string          `json:"text"`
+	From   *contractUser   `json:"from"`
+	Tags   []string        `json:"tags,omitempty"`
+	Meta   map[string]int  `json:"meta,omitempty"`
+	SentAt time.Time       `json:"sent_at"`
+	Extra  json.RawMessage `json:"extra,omitempty"`
+	Skip   string          `json:"-"`
+}
+
+func TestHub_ValidationErrors(t *testing.T) {
+	type order struct {
+		SKU      string `json:"sku" validate:"required"`
+		Quantity int    `json:"quantity" validate:"gte=1"`
+	}
+	hub := ws.NewHub()
+	ws.HandleRPC(hub, "orders.create", func(c *ws.Client, o order) (string, error) {
+		if o.SKU == "SOLD-OUT" {
+			return "", validate.FieldViolations{"sku": "The sku is out of stock."}
+		}
+		return "ok", nil
+	})
+	go hub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws.Upgrade(w, r, hub)
+	}))
+	defer srv.Close()
+	conn := dial(t, srv)
+
+	errorsFor := func(msg string) map[string]string {
+		t.Helper()
+		conn.WriteMessage(websocket.TextMessage, []byte(msg))
+		raw, ok := read(conn, time.Second)
+		if !ok {
+			t.Fatalf("no reply to %s", msg)
+		}
+		var env ws.Envelope
+		var p ws.ErrorPayload
+		json.Unmarshal([]byte(raw), &env)
+		json.Unmarshal(env.Payload, &p)
+		if env.Type != ws.TypeError || env.ID != "1" || p.Error != validate.Message || p.Type != "orders.create" {
+			t.Fatalf("reply = %s", raw)
+		}
+		return p.Errors
+	}
+
+	// The payload is validated before the handler runs…
+	if errs := errorsFor(`{"type":"orders.create","id":"1","payload":{"quantity":0}}`); errs["sku"] == "" || errs["quantity"] == "" {
+		t.Errorf("errors = %v, want sku and quantity", errs)
+	}
+	// …and violations the handler returns have the same shape.
+	if errs := errorsFor(`{"type":"orders.create","id":"1","payload":{"sku":"SOLD-OUT","quantity":1}}`); errs["sku"] != "The sku is out of stock." {
+		t.Errorf("errors = %v, want the handler's sku violation", errs)
+	}
+}
+
+func TestHub_Contract(t *testing.T) {
+	hub := ws.NewHub().Named("chat-room")
+	ws.Handle(hub, "chat.send", func(*ws.Client, contractMessage) {})
+	ws.HandleRPC(hub, "users.get", func(*ws.Client, struct {
+		ID int `json:"id"`
+	}) (contractUser, error) {
+		return contractUser{}, nil
+	})
+	hub.On("ping", func(*ws.Client, json.RawMessage) {})
+	ws.Emits[contractMessage](hub, "chat.message")
+
+	doc := ws.Contracts(hub)
+	if len(doc.Hubs) != 1 || doc.Hubs[0].Hub != "chat-room" {
+		t.Fatalf("hubs = %+v", doc.Hubs)
+	}
+	c := doc.Hubs[0]
+	var types []string
+	for _, e := range c.Receives {
+		types = append(types, e.Type)
+	}
+	if strings.Join(types, ",") != "chat.send,ping,users.get" {
+		t.Fatalf("receives = %v", types)
+	}
+	if len(c.Sends) != 1 || c.Sends[0].Payload["$ref"] != "#/$defs/contractMessage" {
+		t.Fatalf("sends = %+v", c.Sends)
+	}
+	if rpc := c.Receives[2]; !rpc.RPC || rpc.Reply["$ref"] != "#/$defs/contractUser" {
+		t.Fatalf("rpc = %+v", rpc)
+	}
+
+	raw, err := json.Marshal(doc.Defs["contractMessage"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"properties":{"extra":{},"from":{"$ref":"#/$defs/contractUser"},"meta":{"additionalProperties":{"type":"integer"},"type":"object"},"sent_at":{"format":"date-time","type":"string"},"tags":{"items":{"type":"string"},"type":"array"},"text":{"type":"string"}},"required":["from","sent_at","text"],"type":"object"}`
+	if string(raw) != want {
+		t.Fatalf("schema =\n%s\nwant\n%s", raw, want)
+	}
+
+	ts := doc.TypeScript()
+	for _, s := range []string{
+		"export interface contractUser {\n  id: number;\n  name?: string;\n}",
+		"  tags?: string[];",
+		"  meta?: Record<string, number>;",
+		`| { type: "chat.send"; id?: string; payload: contractMessage }`,
+		`| { type: "ping"; id?: string; payload: unknown }`,
+		"export type ChatRoomServerMessage =",
+		`"users.get": { request: {
+  id: number;
+}; response: contractUser };`,
+	} {
+		if !strings.Contains(ts, s) {
+			t.Errorf("TypeScript missing %q:\n%s", s, ts)
+		}
+	}
+}